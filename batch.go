@@ -0,0 +1,246 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// BatchRequest is a sub-request of a batch request, see the Batch DSL.
+	BatchRequest struct {
+		// ID is an optional identifier echoed in the corresponding sub-response.
+		ID string `json:"id,omitempty"`
+		// Method is the sub-request HTTP method, e.g. "GET".
+		Method string `json:"method"`
+		// Path is the sub-request path including the query string, e.g. "/bottles/1?view=tiny".
+		Path string `json:"path"`
+		// Headers are added to the headers of the batch request for the sub-request.
+		Headers map[string]string `json:"headers,omitempty"`
+		// Body is the JSON encoded sub-request body if any.
+		Body json.RawMessage `json:"body,omitempty"`
+	}
+
+	// BatchResponse is the response to a sub-request of a batch request.
+	BatchResponse struct {
+		// ID is the identifier of the sub-request if any.
+		ID string `json:"id,omitempty"`
+		// Status is the sub-response HTTP status code.
+		Status int `json:"status"`
+		// Headers are the sub-response headers.
+		Headers map[string]string `json:"headers,omitempty"`
+		// Body is the JSON encoded sub-response body of successful sub-requests.
+		Body json.RawMessage `json:"body,omitempty"`
+		// Error describes the failure of the sub-request if its status is 400 or greater.
+		Error *ErrorResponse `json:"error,omitempty"`
+	}
+
+	// BatchDispatcher dispatches the sub-requests of batch requests to the handlers mounted on
+	// a service. The sub-requests go through the service mux so that they are decoded,
+	// validated and authorized exactly like standalone requests.
+	BatchDispatcher struct {
+		// Concurrency is the maximum number of sub-requests handled concurrently, 1 handles
+		// the sub-requests in order. Defaults to 8.
+		Concurrency int
+
+		service *Service
+		actions map[string]bool
+	}
+
+	// batchWriter records the response of a sub-request.
+	batchWriter struct {
+		header http.Header
+		status int
+		body   bytes.Buffer
+	}
+)
+
+// NewBatchDispatcher returns a dispatcher that only accepts sub-requests that target the given
+// actions. The actions are given as "resource#action", e.g. "bottle#show".
+func NewBatchDispatcher(service *Service, actions ...string) *BatchDispatcher {
+	allowed := make(map[string]bool, len(actions))
+	for _, a := range actions {
+		allowed[a] = true
+	}
+	return &BatchDispatcher{Concurrency: 8, service: service, actions: allowed}
+}
+
+// Dispatch handles the given sub-requests and returns the sub-responses in the same order. The
+// headers, remote address and TLS connection state of the batch request held by ctx, if any, are
+// inherited by the sub-requests so that they share its credentials. The sub-requests use ctx as
+// their context so that they are canceled together with the batch request. Sub-requests that do not target one of the dispatcher actions fail
+// with a 404 sub-response without reaching any handler.
+func (d *BatchDispatcher) Dispatch(ctx context.Context, reqs []*BatchRequest) []*BatchResponse {
+	var parent *http.Request
+	if req := ContextRequest(ctx); req != nil {
+		parent = req.Request
+	}
+	concurrency := d.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	resps := make([]*BatchResponse, len(reqs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, r := range reqs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, r *BatchRequest) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			resps[i] = d.dispatch(ctx, parent, r)
+		}(i, r)
+	}
+	wg.Wait()
+	return resps
+}
+
+// dispatch handles a single sub-request.
+func (d *BatchDispatcher) dispatch(ctx context.Context, parent *http.Request, r *BatchRequest) *BatchResponse {
+	method := strings.ToUpper(r.Method)
+	path := r.Path
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	if !d.allowed(method, path) {
+		msg := fmt.Sprintf("%s %s does not target an action that may be batched", method, r.Path)
+		return batchError(r.ID, ErrNotFound(msg, "method", method, "path", r.Path))
+	}
+	req, err := newSubRequest(ctx, parent, method, r)
+	if err != nil {
+		return batchError(r.ID, ErrBadRequest(err))
+	}
+	w := &batchWriter{header: make(http.Header)}
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				w.status = http.StatusInternalServerError
+				w.body.Reset()
+			}
+		}()
+		d.service.Mux.ServeHTTP(w, req)
+	}()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	resp := &BatchResponse{ID: r.ID, Status: w.status}
+	for k := range w.header {
+		if resp.Headers == nil {
+			resp.Headers = make(map[string]string, len(w.header))
+		}
+		resp.Headers[k] = w.header.Get(k)
+	}
+	body := w.body.Bytes()
+	if w.status >= 400 {
+		var e ErrorResponse
+		if err := json.Unmarshal(body, &e); err == nil && e.Code != "" {
+			resp.Error = &e
+			return resp
+		}
+	}
+	if len(body) > 0 && json.Valid(body) {
+		resp.Body = json.RawMessage(body)
+	} else if len(body) > 0 {
+		// Encode non JSON bodies as JSON strings.
+		resp.Body, _ = json.Marshal(string(body))
+	}
+	return resp
+}
+
+// newSubRequest builds the HTTP request of a sub-request, it inherits the headers, remote address
+// and TLS connection state of the parent batch request if any.
+func newSubRequest(ctx context.Context, parent *http.Request, method string, r *BatchRequest) (*http.Request, error) {
+	req, err := http.NewRequest(method, r.Path, bytes.NewReader(r.Body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if parent != nil {
+		req.RemoteAddr = parent.RemoteAddr
+		req.TLS = parent.TLS
+		for k, vals := range parent.Header {
+			if k == "Content-Length" || k == "Content-Type" {
+				continue
+			}
+			req.Header[k] = append([]string(nil), vals...)
+		}
+	}
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(r.Body) > 0 && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// allowed returns true if the route matching the given method and path is handled by one of the
+// dispatcher actions. Static path segments take precedence over wildcards.
+func (d *BatchDispatcher) allowed(method, path string) bool {
+	var match *RouteInfo
+	routes := d.service.Routes()
+	for i, r := range routes {
+		if r.Method != method || !matchRoute(r.Pattern, path) {
+			continue
+		}
+		if match == nil || wildcards(r.Pattern) < wildcards(match.Pattern) {
+			match = &routes[i]
+		}
+	}
+	return match != nil && d.actions[match.Resource+"#"+match.Action]
+}
+
+// matchRoute returns true if path matches the route pattern, the ":name" segments of the pattern
+// match any segment and a trailing "*name" segment matches the rest of the path.
+func matchRoute(pattern, path string) bool {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	vs := strings.Split(strings.Trim(path, "/"), "/")
+	for i, p := range ps {
+		if strings.HasPrefix(p, "*") {
+			return true
+		}
+		if i >= len(vs) {
+			return false
+		}
+		if !strings.HasPrefix(p, ":") && p != vs[i] {
+			return false
+		}
+	}
+	return len(ps) == len(vs)
+}
+
+// wildcards returns the number of wildcard segments of the given route pattern.
+func wildcards(pattern string) int {
+	return strings.Count(pattern, "/:") + strings.Count(pattern, "/*")
+}
+
+// batchError builds the sub-response of a sub-request that failed with err.
+func batchError(id string, err error) *BatchResponse {
+	e := err.(*ErrorResponse)
+	return &BatchResponse{ID: id, Status: e.Status, Error: e}
+}
+
+// Header returns the sub-response headers.
+func (w *batchWriter) Header() http.Header { return w.header }
+
+// WriteHeader records the sub-response status code.
+func (w *batchWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write records the sub-response body.
+func (w *batchWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
@@ -0,0 +1,97 @@
+package goa_test
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("BatchDispatcher", func() {
+	var service *goa.Service
+	var dispatcher *goa.BatchDispatcher
+	var last *http.Request
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		last = nil
+		service.Mux.Handle("GET", "/bottles/:id", func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+			if params.Get("id") == "inherit" {
+				last = req
+			}
+			if params.Get("id") == "0" {
+				rw.WriteHeader(404)
+				json.NewEncoder(rw).Encode(goa.ErrNotFound("no bottle"))
+				return
+			}
+			rw.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(rw).Encode(map[string]string{"id": params.Get("id"), "auth": req.Header.Get("Authorization")})
+		})
+		service.Mux.Handle("DELETE", "/bottles/:id", func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+			rw.WriteHeader(204)
+		})
+		service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: "/bottles/:id", Resource: "bottle", Action: "show"})
+		service.AddRoute(goa.RouteInfo{Method: "DELETE", Pattern: "/bottles/:id", Resource: "bottle", Action: "delete"})
+		dispatcher = goa.NewBatchDispatcher(service, "bottle#show")
+	})
+
+	It("dispatches the sub-requests and returns the sub-responses in order", func() {
+		resps := dispatcher.Dispatch(service.Context, []*goa.BatchRequest{
+			{ID: "a", Method: "GET", Path: "/bottles/1", Headers: map[string]string{"Authorization": "Bearer x"}},
+			{ID: "b", Method: "get", Path: "/bottles/2?view=tiny"},
+		})
+		Ω(resps).Should(HaveLen(2))
+		Ω(resps[0].ID).Should(Equal("a"))
+		Ω(resps[0].Status).Should(Equal(200))
+		Ω(resps[0].Headers["Content-Type"]).Should(Equal("application/json"))
+		Ω(string(resps[0].Body)).Should(MatchJSON(`{"id":"1","auth":"Bearer x"}`))
+		Ω(resps[1].ID).Should(Equal("b"))
+		Ω(string(resps[1].Body)).Should(MatchJSON(`{"id":"2","auth":""}`))
+	})
+
+	It("returns the error responses of failed sub-requests", func() {
+		resps := dispatcher.Dispatch(service.Context, []*goa.BatchRequest{{Method: "GET", Path: "/bottles/0"}})
+		Ω(resps[0].Status).Should(Equal(404))
+		Ω(resps[0].Error).ShouldNot(BeNil())
+		Ω(resps[0].Error.Code).Should(Equal("not_found"))
+		Ω(resps[0].Body).Should(BeEmpty())
+	})
+
+	It("rejects sub-requests to actions that may not be batched", func() {
+		resps := dispatcher.Dispatch(service.Context, []*goa.BatchRequest{
+			{Method: "DELETE", Path: "/bottles/1"},
+			{Method: "GET", Path: "/wines/1"},
+		})
+		Ω(resps[0].Status).Should(Equal(404))
+		Ω(resps[0].Error.Detail).Should(ContainSubstring("may be batched"))
+		Ω(resps[1].Status).Should(Equal(404))
+	})
+
+	It("inherits the batch request headers, remote address, TLS state and context", func() {
+		type key int
+		parent, err := http.NewRequest("POST", "/batch", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		parent.Header.Set("Authorization", "Bearer x")
+		parent.Header.Set("Content-Type", "application/json")
+		parent.RemoteAddr = "10.0.0.1:1234"
+		parent.TLS = &tls.ConnectionState{ServerName: "example.com"}
+		ctx := context.WithValue(service.Context, key(0), "batch")
+		ctx = goa.NewContext(ctx, httptest.NewRecorder(), parent, nil)
+
+		resps := dispatcher.Dispatch(ctx, []*goa.BatchRequest{{Method: "GET", Path: "/bottles/inherit"}})
+		Ω(resps[0].Status).Should(Equal(200))
+		sub := last
+		Ω(sub).ShouldNot(BeNil())
+		Ω(sub.Header.Get("Authorization")).Should(Equal("Bearer x"))
+		Ω(sub.Header.Get("Content-Type")).Should(BeEmpty())
+		Ω(sub.RemoteAddr).Should(Equal("10.0.0.1:1234"))
+		Ω(sub.TLS).Should(Equal(parent.TLS))
+		Ω(sub.Context().Value(key(0))).Should(Equal("batch"))
+	})
+})
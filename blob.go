@@ -0,0 +1,158 @@
+package goa
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// BlobUploadRequest describes a blob that a client intends to upload.
+	BlobUploadRequest struct {
+		// Filename is the name of the file being uploaded if any.
+		Filename string `json:"filename,omitempty" xml:"filename,omitempty" form:"filename,omitempty"`
+		// ContentType is the content type of the blob.
+		ContentType string `json:"content_type,omitempty" xml:"content_type,omitempty" form:"content_type,omitempty"`
+		// Size is the size of the blob in bytes.
+		Size int64 `json:"size" xml:"size" form:"size"`
+	}
+
+	// PresignedUpload contains the information a client needs to upload a blob directly to the
+	// store.
+	PresignedUpload struct {
+		// URL is the presigned URL the blob must be uploaded to.
+		URL string `json:"url" xml:"url" form:"url"`
+		// Method is the HTTP method of the upload request, e.g. "PUT".
+		Method string `json:"method" xml:"method" form:"method"`
+		// Headers lists the headers the upload request must include.
+		Headers map[string]string `json:"headers,omitempty" xml:"headers,omitempty" form:"headers,omitempty"`
+		// ExpiresAt is the time after which the URL cannot be used anymore.
+		ExpiresAt time.Time `json:"expires_at" xml:"expires_at" form:"expires_at"`
+	}
+
+	// BlobInfo describes an uploaded blob.
+	BlobInfo struct {
+		// Key is the unique identifier of the blob in the store.
+		Key string `json:"id" xml:"id" form:"id"`
+		// ContentType is the content type of the blob.
+		ContentType string `json:"content_type,omitempty" xml:"content_type,omitempty" form:"content_type,omitempty"`
+		// Size is the size of the blob in bytes.
+		Size int64 `json:"size" xml:"size" form:"size"`
+	}
+
+	// BlobStore is the interface implemented by the blob storage backends (S3, GCS, local disk
+	// etc.) used by presigned uploads.
+	BlobStore interface {
+		// CreateUpload registers a new upload and returns the key of the blob.
+		CreateUpload(ctx context.Context, req *BlobUploadRequest) (string, error)
+		// PresignUpload returns the presigned URL used to upload the blob with the given key.
+		PresignUpload(ctx context.Context, key string, expiry time.Duration) (*PresignedUpload, error)
+		// ConfirmUpload is called once the client has uploaded the blob, it returns the
+		// blob information or nil if the blob has not been uploaded.
+		ConfirmUpload(ctx context.Context, key string) (*BlobInfo, error)
+	}
+
+	// DirectUploadOptions configures the presigned uploads handled by a DirectUploader.
+	DirectUploadOptions struct {
+		// MaxSize is the maximum size of a blob in bytes. Zero means no limit.
+		MaxSize int64
+		// Expiry is the duration during which presigned URLs are valid, defaults to 15
+		// minutes.
+		Expiry time.Duration
+	}
+
+	// DirectUploader implements the "create upload, presign URL, confirm upload" flow that lets
+	// clients upload blobs directly to a store such as S3 instead of going through the service.
+	// It is used by the code generated for the PresignedUpload DSL.
+	DirectUploader struct {
+		service *Service
+		store   BlobStore
+		options DirectUploadOptions
+	}
+)
+
+// NewDirectUploader creates an uploader that uses the given service to decode and encode the
+// requests and responses and the given store to presign the blob uploads.
+func NewDirectUploader(service *Service, store BlobStore, options DirectUploadOptions) *DirectUploader {
+	if options.Expiry == 0 {
+		options.Expiry = 15 * time.Minute
+	}
+	return &DirectUploader{service: service, store: store, options: options}
+}
+
+// Handler returns the handler that implements the presigned upload flow. The handler creates an
+// upload when the request path does not define the UploadIDParam path parameter, confirms the
+// upload when the request path ends with "/confirm" and presigns the upload URL otherwise.
+func (u *DirectUploader) Handler() Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		key := ContextRequest(ctx).Params.Get(UploadIDParam)
+		switch {
+		case key == "":
+			return u.create(ctx, req)
+		case strings.HasSuffix(req.URL.Path, "/confirm"):
+			return u.confirm(ctx, key)
+		default:
+			return u.presign(ctx, key)
+		}
+	}
+}
+
+// create registers a new upload and writes the blob key.
+func (u *DirectUploader) create(ctx context.Context, req *http.Request) error {
+	var payload BlobUploadRequest
+	if err := u.service.DecodeRequest(req, &payload); err != nil {
+		if _, ok := err.(ServiceError); !ok {
+			err = ErrBadRequest(err)
+		}
+		return err
+	}
+	if payload.Size < 0 {
+		return ErrInvalidRequest("invalid blob size", "size", payload.Size)
+	}
+	if err := u.checkSize(payload.Size); err != nil {
+		return err
+	}
+	key, err := u.store.CreateUpload(ctx, &payload)
+	if err != nil {
+		return err
+	}
+	return u.service.Send(ctx, 201, &BlobInfo{Key: key, ContentType: payload.ContentType, Size: payload.Size})
+}
+
+// presign writes the presigned URL of the upload.
+func (u *DirectUploader) presign(ctx context.Context, key string) error {
+	p, err := u.store.PresignUpload(ctx, key, u.options.Expiry)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return ErrNotFound(fmt.Sprintf("upload %#v not found", key))
+	}
+	return u.service.Send(ctx, 200, p)
+}
+
+// confirm checks that the blob was uploaded and writes its information.
+func (u *DirectUploader) confirm(ctx context.Context, key string) error {
+	info, err := u.store.ConfirmUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		return ErrUploadConflict(fmt.Sprintf("blob %#v has not been uploaded", key))
+	}
+	if err := u.checkSize(info.Size); err != nil {
+		return err
+	}
+	return u.service.Send(ctx, 200, info)
+}
+
+// checkSize returns an error of class ErrRequestBodyTooLarge if size exceeds the maximum size.
+func (u *DirectUploader) checkSize(size int64) error {
+	if u.options.MaxSize > 0 && size > u.options.MaxSize {
+		return ErrRequestBodyTooLarge(fmt.Sprintf("blob size exceeds %d bytes", u.options.MaxSize))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+// testBlobStore is a blob store that records the created uploads.
+type testBlobStore struct {
+	created  *goa.BlobUploadRequest
+	uploaded map[string]*goa.BlobInfo
+	expiry   time.Duration
+}
+
+func (s *testBlobStore) CreateUpload(ctx context.Context, req *goa.BlobUploadRequest) (string, error) {
+	s.created = req
+	return "blob1", nil
+}
+
+func (s *testBlobStore) PresignUpload(ctx context.Context, key string, expiry time.Duration) (*goa.PresignedUpload, error) {
+	s.expiry = expiry
+	return &goa.PresignedUpload{URL: "https://bucket.example.com/" + key, Method: "PUT"}, nil
+}
+
+func (s *testBlobStore) ConfirmUpload(ctx context.Context, key string) (*goa.BlobInfo, error) {
+	return s.uploaded[key], nil
+}
+
+var _ = Describe("DirectUploader", func() {
+	var store *testBlobStore
+	var method, path, body string
+	var params url.Values
+
+	var rw *httptest.ResponseRecorder
+	var err error
+
+	BeforeEach(func() {
+		store = &testBlobStore{uploaded: map[string]*goa.BlobInfo{
+			"blob0": {Key: "blob0", Size: 200},
+		}}
+		method = "POST"
+		body = ""
+		params = url.Values{}
+	})
+
+	JustBeforeEach(func() {
+		service := goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		service.Decoder.Register(goa.NewJSONDecoder, "*/*")
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rw = httptest.NewRecorder()
+		ctx := goa.NewContext(context.Background(), rw, req, params)
+		uploader := goa.NewDirectUploader(service, store, goa.DirectUploadOptions{MaxSize: 100})
+		err = uploader.Handler()(ctx, rw, req)
+	})
+
+	Context("creating an upload", func() {
+		BeforeEach(func() {
+			path = "/blobs"
+			body = `{"filename":"logo.png","content_type":"image/png","size":42}`
+		})
+
+		It("registers the upload with the store", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(201))
+			Ω(rw.Body.String()).Should(MatchJSON(`{"id":"blob1","content_type":"image/png","size":42}`))
+			Ω(store.created).Should(Equal(&goa.BlobUploadRequest{Filename: "logo.png", ContentType: "image/png", Size: 42}))
+		})
+
+		Context("exceeding the maximum size", func() {
+			BeforeEach(func() {
+				body = `{"size":101}`
+			})
+
+			It("returns a request too large error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(413))
+			})
+		})
+	})
+
+	Context("presigning the upload URL", func() {
+		BeforeEach(func() {
+			path = "/blobs/blob1/url"
+			params.Set(goa.UploadIDParam, "blob1")
+		})
+
+		It("returns the presigned URL", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Body.String()).Should(ContainSubstring(`"url":"https://bucket.example.com/blob1"`))
+			Ω(store.expiry).Should(Equal(15 * time.Minute))
+		})
+	})
+
+	Context("confirming the upload", func() {
+		BeforeEach(func() {
+			path = "/blobs/blob0/confirm"
+			params.Set(goa.UploadIDParam, "blob0")
+		})
+
+		It("checks the blob size", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(413))
+		})
+
+		Context("of a blob that was not uploaded", func() {
+			BeforeEach(func() {
+				path = "/blobs/blob1/confirm"
+				params.Set(goa.UploadIDParam, "blob1")
+			})
+
+			It("returns a conflict error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(409))
+			})
+		})
+	})
+})
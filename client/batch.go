@@ -0,0 +1,122 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// BatchCall is an action call run by RunBatch, typically a closure around a generated
+	// client method that decodes the response:
+	//
+	//	func(ctx context.Context) (interface{}, error) {
+	//		resp, err := c.ShowBottle(ctx, ShowBottlePath(id))
+	//		if err != nil {
+	//			return nil, err
+	//		}
+	//		return c.DecodeBottle(resp)
+	//	}
+	BatchCall func(ctx context.Context) (interface{}, error)
+
+	// BatchResult is the outcome of a batch call.
+	BatchResult struct {
+		// Value is the value returned by the call.
+		Value interface{}
+		// Err is the error returned by the call if any. Calls that were not started
+		// because the batch was canceled have their error set to the context error.
+		Err error
+	}
+
+	// BatchOptions configures the execution of a batch.
+	BatchOptions struct {
+		// Concurrency is the maximum number of calls run concurrently, defaults to the
+		// number of calls.
+		Concurrency int
+		// FailFast causes the batch to stop at the first error: the context given to the
+		// calls in flight is canceled and the remaining calls are not started.
+		FailFast bool
+	}
+
+	// BatchError is the error returned by RunBatch when calls fail and FailFast is false.
+	BatchError struct {
+		// Errors maps the indices of the failed calls to their errors.
+		Errors map[int]error
+	}
+)
+
+// RunBatch runs the given calls concurrently and returns their results in the same order as the
+// calls. The error returned is nil if all the calls succeeded, the first error encountered if
+// options.FailFast is true and a *BatchError listing all the errors otherwise.
+func RunBatch(ctx context.Context, calls []BatchCall, options BatchOptions) ([]*BatchResult, error) {
+	results := make([]*BatchResult, len(calls))
+	workers := options.Concurrency
+	if workers <= 0 || workers > len(calls) {
+		workers = len(calls)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		indices  = make(chan int)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if err := ctx.Err(); err != nil {
+					results[i] = &BatchResult{Err: err}
+					continue
+				}
+				v, err := calls[i](ctx)
+				results[i] = &BatchResult{Value: v, Err: err}
+				if err != nil && options.FailFast {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	for i := range calls {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	if options.FailFast {
+		return results, firstErr
+	}
+	errs := make(map[int]error)
+	for i, r := range results {
+		if r.Err != nil {
+			errs[i] = r.Err
+		}
+	}
+	if len(errs) > 0 {
+		return results, &BatchError{Errors: errs}
+	}
+	return results, nil
+}
+
+// Error returns a message listing the failed calls.
+func (e *BatchError) Error() string {
+	indices := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	msgs := make([]string, len(indices))
+	for j, i := range indices {
+		msgs[j] = fmt.Sprintf("call %d: %s", i, e.Errors[i])
+	}
+	return fmt.Sprintf("%d batch call(s) failed: %s", len(msgs), strings.Join(msgs, "; "))
+}
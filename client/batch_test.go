@@ -0,0 +1,108 @@
+package client_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goadesign/goa/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("RunBatch", func() {
+	value := func(v interface{}, delay time.Duration) client.BatchCall {
+		return func(ctx context.Context) (interface{}, error) {
+			time.Sleep(delay)
+			return v, nil
+		}
+	}
+
+	It("returns the results in the order of the calls", func() {
+		calls := []client.BatchCall{
+			value("a", 20*time.Millisecond),
+			value("b", 0),
+			value("c", 10*time.Millisecond),
+		}
+		results, err := client.RunBatch(context.Background(), calls, client.BatchOptions{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(results).Should(HaveLen(3))
+		for i, v := range []string{"a", "b", "c"} {
+			Ω(results[i].Err).ShouldNot(HaveOccurred())
+			Ω(results[i].Value).Should(Equal(v))
+		}
+	})
+
+	It("runs at most Concurrency calls at once", func() {
+		var running, max int32
+		calls := make([]client.BatchCall, 8)
+		for i := range calls {
+			calls[i] = func(ctx context.Context) (interface{}, error) {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&max)
+					if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil, nil
+			}
+		}
+		_, err := client.RunBatch(context.Background(), calls, client.BatchOptions{Concurrency: 2})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(atomic.LoadInt32(&max)).Should(BeNumerically("<=", 2))
+		Ω(atomic.LoadInt32(&max)).Should(BeNumerically(">", 0))
+	})
+
+	It("cancels the calls in flight and does not start the others with FailFast", func() {
+		var (
+			mu      sync.Mutex
+			started []int
+		)
+		failure := errors.New("boom")
+		calls := make([]client.BatchCall, 4)
+		for i := range calls {
+			i := i
+			calls[i] = func(ctx context.Context) (interface{}, error) {
+				mu.Lock()
+				started = append(started, i)
+				mu.Unlock()
+				if i == 1 {
+					return nil, failure
+				}
+				if i == 0 {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				return i, nil
+			}
+		}
+		results, err := client.RunBatch(context.Background(), calls, client.BatchOptions{Concurrency: 2, FailFast: true})
+		Ω(err).Should(Equal(failure))
+		Ω(results[0].Err).Should(Equal(context.Canceled))
+		Ω(results[1].Err).Should(Equal(failure))
+		Ω(results[2].Err).Should(Equal(context.Canceled))
+		Ω(results[3].Err).Should(Equal(context.Canceled))
+		Ω(started).Should(ConsistOf(0, 1))
+	})
+
+	It("runs all the calls and reports all the errors without FailFast", func() {
+		first, second := errors.New("first"), errors.New("second")
+		calls := []client.BatchCall{
+			value("a", 0),
+			func(context.Context) (interface{}, error) { return nil, first },
+			value("c", 0),
+			func(context.Context) (interface{}, error) { return nil, second },
+		}
+		results, err := client.RunBatch(context.Background(), calls, client.BatchOptions{Concurrency: 1})
+		Ω(err).Should(BeAssignableToTypeOf(&client.BatchError{}))
+		Ω(err.(*client.BatchError).Errors).Should(Equal(map[int]error{1: first, 3: second}))
+		Ω(err.Error()).Should(Equal("2 batch call(s) failed: call 1: first; call 3: second"))
+		Ω(results[0].Value).Should(Equal("a"))
+		Ω(results[2].Value).Should(Equal("c"))
+	})
+})
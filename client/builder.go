@@ -0,0 +1,74 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/context"
+)
+
+// IdempotencyKeyHeader is the name of the header that carries the idempotency key of a request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// AffinityKeyHeader is the name of the header that carries the affinity key of a request, load
+// balancers may use it to route the requests with the same key to the same instance.
+const AffinityKeyHeader = "X-Affinity-Key"
+
+// RequestOptions holds the per-call customizations recorded by the generated request builders.
+// The zero value is ready to use.
+type RequestOptions struct {
+	// Context is the context used to build and send the request, defaults to
+	// context.Background().
+	Context context.Context
+	// Header lists the headers set on the request, they override the headers set by the
+	// generated code.
+	Header http.Header
+	// Query lists the query string values added to the request.
+	Query url.Values
+	// RetryPolicy overrides the client retry policy for the request if not nil.
+	RetryPolicy RetryPolicy
+}
+
+// SetHeader records a header to be set on the request.
+func (o *RequestOptions) SetHeader(name, value string) {
+	if o.Header == nil {
+		o.Header = make(http.Header)
+	}
+	o.Header.Set(name, value)
+}
+
+// AddQuery records a query string value to be added to the request.
+func (o *RequestOptions) AddQuery(name, value string) {
+	if o.Query == nil {
+		o.Query = make(url.Values)
+	}
+	o.Query.Add(name, value)
+}
+
+// Ctx returns the context used to build and send the request.
+func (o *RequestOptions) Ctx() context.Context {
+	ctx := o.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if o.RetryPolicy != nil {
+		ctx = WithRetryPolicy(ctx, o.RetryPolicy)
+	}
+	return ctx
+}
+
+// Apply sets the recorded headers and query string values on req.
+func (o *RequestOptions) Apply(req *http.Request) {
+	for name, values := range o.Header {
+		req.Header[name] = values
+	}
+	if len(o.Query) > 0 {
+		query := req.URL.Query()
+		for name, values := range o.Query {
+			for _, v := range values {
+				query.Add(name, v)
+			}
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+}
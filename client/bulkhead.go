@@ -0,0 +1,186 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+)
+
+// ErrBulkheadFull is the error returned by clients when no request slot frees up in their
+// bulkhead in time.
+var ErrBulkheadFull = errors.New("bulkhead full")
+
+type (
+	// Bulkhead limits the number of requests a client sends concurrently so that a slow
+	// downstream service cannot exhaust the goroutines and connections of the caller. Each
+	// client should use its own bulkhead so that the downstream services are isolated from one
+	// another. A slot is taken when a request is sent and freed when the response body is
+	// closed or the request fails. Bulkhead is safe for concurrent use and its zero value
+	// lets one request be sent at once, see NewBulkhead.
+	//
+	// Bulkheads publish the "goa.client.bulkhead.<name>.inflight" and
+	// "goa.client.bulkhead.<name>.waiting" gauges and the "goa.client.bulkhead.<name>.rejected"
+	// counter, see goa.NewMetrics.
+	Bulkhead struct {
+		// Name identifies the bulkhead in the metrics and logs.
+		Name string
+		// MaxConcurrent is the maximum number of requests sent at once, defaults to 1.
+		// It must not be modified once the bulkhead is in use.
+		MaxConcurrent int
+		// MaxWait is the maximum duration a request waits for a slot to free up, requests
+		// fail with ErrBulkheadFull right away if zero.
+		MaxWait time.Duration
+
+		once  sync.Once
+		slots chan struct{}
+		mu    sync.Mutex
+		stats BulkheadStats
+	}
+
+	// BulkheadStats is a snapshot of the activity of a bulkhead.
+	BulkheadStats struct {
+		// MaxConcurrent is the maximum number of concurrent requests.
+		MaxConcurrent int
+		// InFlight is the number of requests being sent.
+		InFlight int
+		// Waiting is the number of requests waiting for a slot.
+		Waiting int
+		// Accepted is the total number of requests that got a slot.
+		Accepted uint64
+		// Rejected is the total number of requests that failed with ErrBulkheadFull.
+		Rejected uint64
+	}
+
+	// bulkheadBody frees the bulkhead slot of a request when its response body is closed.
+	bulkheadBody struct {
+		io.ReadCloser
+		release func()
+	}
+)
+
+// NewBulkhead creates a bulkhead that lets up to maxConcurrent requests be sent at once and makes
+// the other requests wait at most maxWait for a slot. Set it in the Bulkhead field of a client:
+//
+//	c := ratings.New(nil)
+//	c.Bulkhead = client.NewBulkhead("ratings", 20, 100*time.Millisecond)
+//
+func NewBulkhead(name string, maxConcurrent int, maxWait time.Duration) *Bulkhead {
+	return &Bulkhead{Name: name, MaxConcurrent: maxConcurrent, MaxWait: maxWait}
+}
+
+// init creates the slots on first use.
+func (b *Bulkhead) init() {
+	b.once.Do(func() {
+		n := b.MaxConcurrent
+		if n < 1 {
+			n = 1
+		}
+		b.slots = make(chan struct{}, n)
+		b.mu.Lock()
+		b.stats.MaxConcurrent = n
+		b.mu.Unlock()
+	})
+}
+
+// Acquire takes a slot, waiting up to MaxWait for one to free up. It returns ErrBulkheadFull if
+// no slot frees up in time and the context error if the context is done first. Callers that
+// acquire a slot must free it with Release.
+func (b *Bulkhead) Acquire(ctx context.Context) error {
+	b.init()
+	select {
+	case b.slots <- struct{}{}:
+		b.update(func(s *BulkheadStats) { s.InFlight++; s.Accepted++ })
+		return nil
+	default:
+	}
+	if b.MaxWait <= 0 {
+		b.reject()
+		return ErrBulkheadFull
+	}
+	b.update(func(s *BulkheadStats) { s.Waiting++ })
+	expired := make(chan struct{})
+	t := goa.ContextClock(ctx).AfterFunc(b.MaxWait, func() { close(expired) })
+	defer t.Stop()
+	select {
+	case b.slots <- struct{}{}:
+		b.update(func(s *BulkheadStats) { s.Waiting--; s.InFlight++; s.Accepted++ })
+		return nil
+	case <-expired:
+		b.update(func(s *BulkheadStats) { s.Waiting-- })
+		b.reject()
+		return ErrBulkheadFull
+	case <-ctx.Done():
+		b.update(func(s *BulkheadStats) { s.Waiting-- })
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken with Acquire.
+func (b *Bulkhead) Release() {
+	b.init()
+	<-b.slots
+	b.update(func(s *BulkheadStats) { s.InFlight-- })
+}
+
+// Stats returns a snapshot of the bulkhead activity.
+func (b *Bulkhead) Stats() BulkheadStats {
+	b.init()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// Doer returns a Doer that sends the requests with d once a slot is acquired.
+func (b *Bulkhead) Doer(d Doer) Doer {
+	return doFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		return b.do(ctx, d, req)
+	})
+}
+
+// do sends req with d once a slot is acquired and frees the slot when the response body is
+// closed.
+func (b *Bulkhead) do(ctx context.Context, d Doer, req *http.Request) (*http.Response, error) {
+	if err := b.Acquire(ctx); err != nil {
+		goa.LogError(ctx, "bulkhead", "name", b.Name, "err", err)
+		return nil, err
+	}
+	resp, err := d.Do(ctx, req)
+	if err != nil || resp.Body == nil {
+		b.Release()
+		return resp, err
+	}
+	var once sync.Once
+	resp.Body = &bulkheadBody{ReadCloser: resp.Body, release: func() { once.Do(b.Release) }}
+	return resp, nil
+}
+
+// update applies f to the bulkhead stats and publishes the gauges.
+func (b *Bulkhead) update(f func(*BulkheadStats)) {
+	b.mu.Lock()
+	f(&b.stats)
+	inFlight, waiting := b.stats.InFlight, b.stats.Waiting
+	b.mu.Unlock()
+	goa.SetGauge([]string{"goa", "client", "bulkhead", b.Name, "inflight"}, float32(inFlight))
+	goa.SetGauge([]string{"goa", "client", "bulkhead", b.Name, "waiting"}, float32(waiting))
+}
+
+// reject records a rejected request.
+func (b *Bulkhead) reject() {
+	b.mu.Lock()
+	b.stats.Rejected++
+	b.mu.Unlock()
+	go goa.IncrCounter([]string{"goa", "client", "bulkhead", b.Name, "rejected"}, 1.0)
+}
+
+// Close closes the response body and frees the bulkhead slot.
+func (r *bulkheadBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
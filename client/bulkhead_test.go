@@ -0,0 +1,110 @@
+package client_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
+	"github.com/goadesign/goa/goatest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Bulkhead", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("accounts for the slots", func() {
+		b := client.NewBulkhead("test", 2, 0)
+		Ω(b.Acquire(ctx)).ShouldNot(HaveOccurred())
+		Ω(b.Acquire(ctx)).ShouldNot(HaveOccurred())
+		Ω(b.Acquire(ctx)).Should(Equal(client.ErrBulkheadFull))
+		Ω(b.Stats()).Should(Equal(client.BulkheadStats{MaxConcurrent: 2, InFlight: 2, Accepted: 2, Rejected: 1}))
+
+		b.Release()
+		Ω(b.Acquire(ctx)).ShouldNot(HaveOccurred())
+		b.Release()
+		b.Release()
+		Ω(b.Stats()).Should(Equal(client.BulkheadStats{MaxConcurrent: 2, Accepted: 3, Rejected: 1}))
+	})
+
+	It("rejects requests that wait longer than MaxWait", func() {
+		clock := goatest.NewClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+		ctx = goa.WithClock(ctx, clock)
+		b := client.NewBulkhead("test", 1, time.Second)
+		Ω(b.Acquire(ctx)).ShouldNot(HaveOccurred())
+
+		done := make(chan error, 1)
+		go func() { done <- b.Acquire(ctx) }()
+		Eventually(func() int { return b.Stats().Waiting }).Should(Equal(1))
+		Consistently(done).ShouldNot(Receive())
+
+		var err error
+		Eventually(func() bool {
+			clock.Advance(time.Second)
+			select {
+			case err = <-done:
+				return true
+			default:
+				return false
+			}
+		}).Should(BeTrue())
+		Ω(err).Should(Equal(client.ErrBulkheadFull))
+		Ω(b.Stats()).Should(Equal(client.BulkheadStats{MaxConcurrent: 1, InFlight: 1, Accepted: 1, Rejected: 1}))
+	})
+
+	It("hands the slot to a waiting request once released", func() {
+		b := client.NewBulkhead("test", 1, time.Minute)
+		Ω(b.Acquire(ctx)).ShouldNot(HaveOccurred())
+		done := make(chan error, 1)
+		go func() { done <- b.Acquire(ctx) }()
+		Eventually(func() int { return b.Stats().Waiting }).Should(Equal(1))
+		b.Release()
+		Eventually(done).Should(Receive(BeNil()))
+		Ω(b.Stats().InFlight).Should(Equal(1))
+	})
+
+	It("frees the slot when the response body is closed", func() {
+		b := client.NewBulkhead("test", 1, 0)
+		c := client.New(doer(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("ok"))}, nil
+		}))
+		c.Bulkhead = b
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		resp, err := c.Do(ctx, req)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(b.Stats().InFlight).Should(Equal(1))
+		_, err = c.Do(ctx, req)
+		Ω(err).Should(Equal(client.ErrBulkheadFull))
+
+		Ω(resp.Body.Close()).ShouldNot(HaveOccurred())
+		Ω(resp.Body.Close()).ShouldNot(HaveOccurred())
+		Ω(b.Stats().InFlight).Should(Equal(0))
+		resp, err = c.Do(ctx, req)
+		Ω(err).ShouldNot(HaveOccurred())
+		resp.Body.Close()
+		Ω(b.Stats()).Should(Equal(client.BulkheadStats{MaxConcurrent: 1, Accepted: 2, Rejected: 1}))
+	})
+
+	It("lets one request be sent at once given its zero value", func() {
+		var b client.Bulkhead
+		Ω(b.Acquire(ctx)).ShouldNot(HaveOccurred())
+		Ω(b.Acquire(ctx)).Should(Equal(client.ErrBulkheadFull))
+		b.Release()
+		Ω(b.Stats()).Should(Equal(client.BulkheadStats{MaxConcurrent: 1, Accepted: 1, Rejected: 1}))
+	})
+})
+
+// doer adapts a function to the client.Doer interface.
+type doer func(context.Context, *http.Request) (*http.Response, error)
+
+func (d doer) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return d(ctx, req)
+}
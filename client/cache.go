@@ -0,0 +1,244 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+)
+
+type (
+	// CachedResponse is a GET response stored in a CacheStore.
+	CachedResponse struct {
+		// Status is the response status code.
+		Status int
+		// Header contains the response headers.
+		Header http.Header
+		// Body is the response body.
+		Body []byte
+		// ETag is the value of the response ETag header if any.
+		ETag string
+		// Expires is the time until which the response may be used without revalidation.
+		Expires time.Time
+		// Vary contains the values of the request headers listed in the response Vary
+		// header, the response is only used for requests with the same header values.
+		Vary http.Header
+	}
+
+	// CacheStore is the interface implemented by the stores used by Client to cache GET
+	// responses. Implementations must be safe for concurrent use.
+	CacheStore interface {
+		// Get returns the response cached under the given key if any.
+		Get(key string) (*CachedResponse, bool)
+		// Set caches the response under the given key.
+		Set(key string, resp *CachedResponse)
+		// Delete removes the response cached under the given key if any.
+		Delete(key string)
+	}
+
+	// MemoryCache is a CacheStore that keeps the responses in memory.
+	MemoryCache struct {
+		sync.RWMutex
+		entries map[string]*CachedResponse
+	}
+)
+
+// NewMemoryCache creates an empty in-memory cache store.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]*CachedResponse)}
+}
+
+// Get returns the response cached under the given key if any.
+func (m *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	resp, ok := m.entries[key]
+	return resp, ok
+}
+
+// Set caches the response under the given key.
+func (m *MemoryCache) Set(key string, resp *CachedResponse) {
+	m.Lock()
+	defer m.Unlock()
+	m.entries[key] = resp
+}
+
+// Delete removes the response cached under the given key if any.
+func (m *MemoryCache) Delete(key string) {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.entries, key)
+}
+
+// doCached makes a GET request using the cache: fresh cached responses are returned without
+// making a request, stale responses with an ETag are revalidated using If-None-Match and
+// cacheable responses are stored according to their Cache-Control, ETag and Vary headers.
+// Responses are cached per credentials so that they are never returned to requests made with
+// different credentials.
+func (c *Client) doCached(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if cacheDirectives(req.Header)["no-store"] != "" {
+		return c.send(ctx, req)
+	}
+	key := cacheKey(req)
+	now := goa.ContextClock(ctx).Now()
+	cached, ok := c.Cache.Get(key)
+	ok = ok && cached.matches(req)
+	if ok {
+		if now.Before(cached.Expires) {
+			return cached.response(req), nil
+		}
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+	}
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && ok {
+		resp.Body.Close()
+		refreshed := *cached
+		refreshed.Header = cloneHeader(cached.Header)
+		for k, v := range resp.Header {
+			refreshed.Header[k] = v
+		}
+		refreshed.Expires = expiresAt(now, resp.Header)
+		c.Cache.Set(key, &refreshed)
+		return refreshed.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	etag := resp.Header.Get("ETag")
+	expires := expiresAt(now, resp.Header)
+	vary, varies := varyHeader(req, resp.Header)
+	if !varies || cacheDirectives(resp.Header)["no-store"] != "" || (etag == "" && !expires.After(now)) {
+		c.Cache.Delete(key)
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	c.Cache.Set(key, &CachedResponse{
+		Status:  resp.StatusCode,
+		Header:  cloneHeader(resp.Header),
+		Body:    body,
+		ETag:    etag,
+		Expires: expires,
+		Vary:    vary,
+	})
+	return resp, nil
+}
+
+// cacheKey computes the key of the cache entry for req from its URL, Accept header and
+// credentials. The credentials are hashed so that they are not kept in the cache store.
+func cacheKey(req *http.Request) string {
+	key := req.URL.String() + " " + req.Header.Get("Accept")
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		sum := sha256.Sum256([]byte(auth))
+		key += " " + hex.EncodeToString(sum[:])
+	}
+	return key
+}
+
+// varyHeader returns the values of the request headers listed in the Vary response header. It
+// returns false if the response varies on "*" and thus cannot be cached.
+func varyHeader(req *http.Request, header http.Header) (http.Header, bool) {
+	var vary http.Header
+	for _, v := range header["Vary"] {
+		for _, n := range strings.Split(v, ",") {
+			n = http.CanonicalHeaderKey(strings.TrimSpace(n))
+			if n == "" {
+				continue
+			}
+			if n == "*" {
+				return nil, false
+			}
+			if vary == nil {
+				vary = make(http.Header)
+			}
+			vary[n] = req.Header[n]
+		}
+	}
+	return vary, true
+}
+
+// matches returns true if the request headers listed in the response Vary header have the same
+// values in req as in the request that produced the cached response.
+func (r *CachedResponse) matches(req *http.Request) bool {
+	for n, vals := range r.Vary {
+		if strings.Join(req.Header[n], ", ") != strings.Join(vals, ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+// response builds a HTTP response from the cached data. The response header is a copy so that
+// callers cannot modify the cached response.
+func (r *CachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(r.Status) + " " + http.StatusText(r.Status),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        cloneHeader(r.Header),
+		Body:          ioutil.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// expiresAt computes the time until which a response with the given headers is fresh from the
+// Cache-Control max-age and no-cache directives.
+func expiresAt(now time.Time, header http.Header) time.Time {
+	directives := cacheDirectives(header)
+	if directives["no-cache"] != "" {
+		return now
+	}
+	maxAge, err := strconv.Atoi(directives["max-age"])
+	if err != nil || maxAge <= 0 {
+		return now
+	}
+	return now.Add(time.Duration(maxAge) * time.Second)
+}
+
+// cacheDirectives parses the Cache-Control header. Directives without a value are mapped to
+// their name.
+func cacheDirectives(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, d := range strings.Split(header.Get("Cache-Control"), ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" {
+			continue
+		}
+		if i := strings.Index(d, "="); i > 0 {
+			directives[d[:i]] = strings.Trim(d[i+1:], `"`)
+		} else {
+			directives[d] = d
+		}
+	}
+	return directives
+}
+
+// cloneHeader returns a deep copy of h.
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
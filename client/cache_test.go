@@ -0,0 +1,169 @@
+package client_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
+	"github.com/goadesign/goa/goatest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+// cacheDoer records the requests it receives and replies with the next response.
+type cacheDoer struct {
+	requests  []*http.Request
+	responses []*http.Response
+}
+
+func (d *cacheDoer) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	d.requests = append(d.requests, req)
+	resp := d.responses[0]
+	d.responses = d.responses[1:]
+	return resp, nil
+}
+
+func cacheResponse(status int, body string, keyvals ...string) *http.Response {
+	h := make(http.Header)
+	for i := 0; i < len(keyvals); i += 2 {
+		h.Add(keyvals[i], keyvals[i+1])
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     h,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+var _ = Describe("Cache", func() {
+	var (
+		doer  *cacheDoer
+		c     *client.Client
+		clock *goatest.Clock
+		ctx   context.Context
+	)
+
+	BeforeEach(func() {
+		doer = &cacheDoer{}
+		c = client.New(doer)
+		c.Cache = client.NewMemoryCache()
+		clock = goatest.NewClock(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+		ctx = goa.WithClock(context.Background(), clock)
+	})
+
+	get := func(keyvals ...string) (*http.Response, string) {
+		req, err := http.NewRequest("GET", "http://example.com/bottles/1", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		for i := 0; i < len(keyvals); i += 2 {
+			req.Header.Set(keyvals[i], keyvals[i+1])
+		}
+		resp, err := c.Do(ctx, req)
+		Ω(err).ShouldNot(HaveOccurred())
+		body, err := ioutil.ReadAll(resp.Body)
+		Ω(err).ShouldNot(HaveOccurred())
+		return resp, string(body)
+	}
+
+	It("returns fresh responses without making a request", func() {
+		doer.responses = []*http.Response{
+			cacheResponse(200, "v1", "Cache-Control", "max-age=60"),
+			cacheResponse(200, "v2", "Cache-Control", "max-age=60"),
+		}
+		_, body := get()
+		Ω(body).Should(Equal("v1"))
+		clock.Advance(30 * time.Second)
+		_, body = get()
+		Ω(body).Should(Equal("v1"))
+		Ω(doer.requests).Should(HaveLen(1))
+
+		clock.Advance(31 * time.Second)
+		_, body = get()
+		Ω(body).Should(Equal("v2"))
+		Ω(doer.requests).Should(HaveLen(2))
+	})
+
+	It("revalidates stale responses with their ETag", func() {
+		doer.responses = []*http.Response{
+			cacheResponse(200, "v1", "ETag", `"1"`, "X-Version", "1"),
+			cacheResponse(304, "", "ETag", `"1"`, "Cache-Control", "max-age=60"),
+		}
+		get()
+		resp, body := get()
+		Ω(doer.requests).Should(HaveLen(2))
+		Ω(doer.requests[1].Header.Get("If-None-Match")).Should(Equal(`"1"`))
+		Ω(resp.StatusCode).Should(Equal(200))
+		Ω(body).Should(Equal("v1"))
+		Ω(resp.Header.Get("X-Version")).Should(Equal("1"))
+
+		_, body = get()
+		Ω(body).Should(Equal("v1"))
+		Ω(doer.requests).Should(HaveLen(2))
+	})
+
+	It("does not store no-store responses", func() {
+		doer.responses = []*http.Response{
+			cacheResponse(200, "v1", "Cache-Control", "no-store, max-age=60", "ETag", `"1"`),
+			cacheResponse(200, "v2", "Cache-Control", "max-age=60"),
+		}
+		get()
+		_, body := get()
+		Ω(body).Should(Equal("v2"))
+		Ω(doer.requests).Should(HaveLen(2))
+		Ω(doer.requests[1].Header.Get("If-None-Match")).Should(BeEmpty())
+	})
+
+	It("bypasses the cache for no-store requests", func() {
+		doer.responses = []*http.Response{
+			cacheResponse(200, "v1", "Cache-Control", "max-age=60"),
+			cacheResponse(200, "v2", "Cache-Control", "max-age=60"),
+		}
+		get()
+		_, body := get("Cache-Control", "no-store")
+		Ω(body).Should(Equal("v2"))
+		Ω(doer.requests).Should(HaveLen(2))
+	})
+
+	It("does not share responses between credentials", func() {
+		doer.responses = []*http.Response{
+			cacheResponse(200, "alice", "Cache-Control", "max-age=60"),
+			cacheResponse(200, "bob", "Cache-Control", "max-age=60"),
+		}
+		_, body := get("Authorization", "Bearer alice")
+		Ω(body).Should(Equal("alice"))
+		_, body = get("Authorization", "Bearer bob")
+		Ω(body).Should(Equal("bob"))
+		_, body = get("Authorization", "Bearer alice")
+		Ω(body).Should(Equal("alice"))
+		Ω(doer.requests).Should(HaveLen(2))
+	})
+
+	It("honors the Vary header", func() {
+		doer.responses = []*http.Response{
+			cacheResponse(200, "en", "Cache-Control", "max-age=60", "Vary", "Accept-Language"),
+			cacheResponse(200, "fr", "Cache-Control", "max-age=60", "Vary", "Accept-Language"),
+		}
+		_, body := get("Accept-Language", "en")
+		Ω(body).Should(Equal("en"))
+		_, body = get("Accept-Language", "fr")
+		Ω(body).Should(Equal("fr"))
+		_, body = get("Accept-Language", "fr")
+		Ω(body).Should(Equal("fr"))
+		Ω(doer.requests).Should(HaveLen(2))
+	})
+
+	It("returns copies of the cached headers", func() {
+		doer.responses = []*http.Response{
+			cacheResponse(200, "v1", "Cache-Control", "max-age=60", "X-Version", "1"),
+		}
+		resp, _ := get()
+		resp.Header.Set("X-Version", "2")
+		resp, _ = get()
+		resp.Header.Set("X-Version", "3")
+		resp, _ = get()
+		Ω(resp.Header.Get("X-Version")).Should(Equal("1"))
+	})
+})
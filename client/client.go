@@ -8,7 +8,6 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
-	"time"
 
 	"golang.org/x/net/context"
 
@@ -33,6 +32,19 @@ type (
 		UserAgent string
 		// Dump indicates whether to dump request response.
 		Dump bool
+		// Cache is the store used to cache GET responses according to their Cache-Control
+		// and ETag headers, caching is disabled if nil.
+		Cache CacheStore
+		// ValidateRequests causes the generated clients to run the validations defined in
+		// the design on the request payloads and parameters before sending the requests.
+		ValidateRequests bool
+		// RetryPolicy decides whether failed requests are sent again, requests are not
+		// retried if nil. See BackoffPolicy for an implementation with exponential backoff
+		// and WithRetryPolicy to override the policy for a single request.
+		RetryPolicy RetryPolicy
+		// Bulkhead limits the number of requests sent concurrently by the client, requests
+		// are not limited if nil. See NewBulkhead.
+		Bulkhead *Bulkhead
 	}
 )
 
@@ -60,9 +72,21 @@ func (f doFunc) Do(ctx context.Context, req *http.Request) (*http.Response, erro
 	return f(ctx, req)
 }
 
-// Do wraps the underlying http client Do method and adds logging.
+// Do wraps the underlying http client Do method and adds logging and retries.
 // The logger should be in the context.
 func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	policy := ContextRetryPolicy(ctx)
+	if policy == nil {
+		policy = c.RetryPolicy
+	}
+	if policy != nil {
+		return c.doWithRetries(ctx, req, policy)
+	}
+	return c.do(ctx, req)
+}
+
+// do sends the request once.
+func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
 	// TODO: setting the request ID should be done via client middleware. For now only set it if the
 	// caller provided one in the ctx.
 	if ctxreqid := ContextRequestID(ctx); ctxreqid != "" {
@@ -71,24 +95,42 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 	if c.UserAgent != "" {
 		req.Header.Set("User-Agent", c.UserAgent)
 	}
-	startedAt := time.Now()
+	if k := ContextAffinityKey(ctx); k != "" && req.Header.Get(AffinityKeyHeader) == "" {
+		req.Header.Set(AffinityKeyHeader, k)
+	}
+	clock := goa.ContextClock(ctx)
+	startedAt := clock.Now()
 	ctx, id := ContextWithRequestID(ctx)
 	goa.LogInfo(ctx, "started", "id", id, req.Method, req.URL.String())
 	if c.Dump {
 		c.dumpRequest(ctx, req)
 	}
-	resp, err := c.Doer.Do(ctx, req)
+	var resp *http.Response
+	var err error
+	if c.Cache != nil && req.Method == "GET" {
+		resp, err = c.doCached(ctx, req)
+	} else {
+		resp, err = c.send(ctx, req)
+	}
 	if err != nil {
 		goa.LogError(ctx, "failed", "err", err)
 		return nil, err
 	}
-	goa.LogInfo(ctx, "completed", "id", id, "status", resp.StatusCode, "time", time.Since(startedAt).String())
+	goa.LogInfo(ctx, "completed", "id", id, "status", resp.StatusCode, "time", clock.Now().Sub(startedAt).String())
 	if c.Dump {
 		c.dumpResponse(ctx, resp)
 	}
 	return resp, err
 }
 
+// send sends the request with the underlying Doer, going through the client bulkhead if any.
+func (c *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if c.Bulkhead != nil {
+		return c.Bulkhead.do(ctx, c.Doer, req)
+	}
+	return c.Doer.Do(ctx, req)
+}
+
 // Dump request if needed.
 func (c *Client) dumpRequest(ctx context.Context, req *http.Request) {
 	reqBody, err := dumpReqBody(req)
@@ -212,6 +254,9 @@ func filterHeaders(headers http.Header, iterator headerIterator) {
 // shortID produces a "unique" 6 bytes long string.
 // Do not use as a reliable way to get unique IDs, instead use for things like logging.
 func shortID() string {
+	if id, ok := goa.NextSequentialID(); ok {
+		return id
+	}
 	b := make([]byte, 6)
 	io.ReadFull(rand.Reader, b)
 	return base64.StdEncoding.EncodeToString(b)
@@ -234,6 +279,52 @@ func ContextRequestID(ctx context.Context) string {
 	return reqID
 }
 
+// operationKey is the context key used to store the resource and action of a request.
+const operationKey clientKey = 3
+
+// operation identifies the design action called by a request.
+type operation struct {
+	resource, action string
+}
+
+// WithOperation returns a context that records that the requests sent with it call the given
+// action of the given resource. The generated clients set the operation of the requests they send
+// so that middlewares such as the OpenTelemetry client instrumentation can name their spans after
+// the design.
+func WithOperation(ctx context.Context, resource, action string) context.Context {
+	return context.WithValue(ctx, operationKey, operation{resource, action})
+}
+
+// ContextOperation extracts the resource and action set with WithOperation from the context.
+// It returns empty strings if the context does not contain an operation.
+func ContextOperation(ctx context.Context) (resource, action string) {
+	if o, ok := ctx.Value(operationKey).(operation); ok {
+		return o.resource, o.action
+	}
+	return "", ""
+}
+
+// affinityKey is the context key used to store the affinity key of the requests.
+const affinityKey clientKey = 4
+
+// WithAffinityKey returns a context that causes the requests sent with it to carry the given
+// affinity key in the X-Affinity-Key header. Services may use it to propagate the affinity key of
+// the request they handle to the downstream services they call:
+//
+//	ctx = client.WithAffinityKey(ctx, middleware.ContextAffinityKey(ctx))
+//
+func WithAffinityKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, affinityKey, key)
+}
+
+// ContextAffinityKey extracts the affinity key set with WithAffinityKey from the context.
+func ContextAffinityKey(ctx context.Context) string {
+	if k, ok := ctx.Value(affinityKey).(string); ok {
+		return k
+	}
+	return ""
+}
+
 // ContextWithRequestID returns ctx and the request ID if it already has one or creates and returns a new context with
 // a new request ID.
 func ContextWithRequestID(ctx context.Context) (context.Context, string) {
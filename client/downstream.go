@@ -0,0 +1,34 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+)
+
+// TrackDownstream wraps d so that the outcome of the requests it sends is recorded in registry
+// under the given downstream name: the downstream is marked down when a request fails to get a
+// response or gets a 5xx response and up otherwise. Use it to create the clients of the optional
+// downstream services called by a service so that the actions that declare fallbacks degrade
+// gracefully:
+//
+//	c := ratings.New(client.TrackDownstream(client.HTTPClientDoer(http.DefaultClient), "ratings", service.Downstreams))
+//
+func TrackDownstream(d Doer, name string, registry *goa.DownstreamRegistry) Doer {
+	registry.Register(name)
+	return doFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		resp, err := d.Do(ctx, req)
+		switch {
+		case err != nil:
+			registry.MarkDown(ctx, name, err)
+		case resp.StatusCode >= 500:
+			registry.MarkDown(ctx, name, fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status))
+		default:
+			registry.MarkUp(name)
+		}
+		return resp, err
+	})
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// PageFetcher retrieves the page identified by cursor, the empty cursor identifies the first
+	// page. It returns the page items and the cursor of the next page or the empty string if
+	// the page is the last one.
+	PageFetcher func(ctx context.Context, cursor string) (items []interface{}, next string, err error)
+
+	// PageIterator iterates over the items of a paginated list by fetching the pages as needed.
+	// Typical usage:
+	//
+	//	it := client.NewPageIterator(ctx, fetch)
+	//	for it.Next() {
+	//		item := it.Item().(*app.Bottle)
+	//		...
+	//	}
+	//	if err := it.Err(); err != nil {
+	//		...
+	//	}
+	PageIterator struct {
+		ctx     context.Context
+		fetch   PageFetcher
+		cursor  string
+		fetched bool
+		items   []interface{}
+		item    interface{}
+		err     error
+	}
+)
+
+// NewPageIterator creates an iterator that uses fetch to retrieve the pages.
+func NewPageIterator(ctx context.Context, fetch PageFetcher) *PageIterator {
+	return &PageIterator{ctx: ctx, fetch: fetch}
+}
+
+// Next advances the iterator to the next item, fetching the next page if needed. It returns false
+// once all the items have been iterated over or if fetching a page failed in which case Err returns
+// the error.
+func (it *PageIterator) Next() bool {
+	for len(it.items) == 0 {
+		if it.err != nil || (it.fetched && it.cursor == "") {
+			return false
+		}
+		items, next, err := it.fetch(it.ctx, it.cursor)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if it.fetched && next == it.cursor {
+			it.err = fmt.Errorf("pagination cursor %#v did not advance", next)
+			return false
+		}
+		it.fetched = true
+		it.items = items
+		it.cursor = next
+	}
+	it.item, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Item returns the current item.
+func (it *PageIterator) Item() interface{} {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration if any.
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+// ListAll follows the pages retrieved by fetch and returns all their items. It stops after max
+// items if max is positive so that unexpectedly large lists do not exhaust memory.
+func ListAll(ctx context.Context, fetch PageFetcher, max int) ([]interface{}, error) {
+	var all []interface{}
+	it := NewPageIterator(ctx, fetch)
+	for (max <= 0 || len(all) < max) && it.Next() {
+		all = append(all, it.Item())
+	}
+	return all, it.Err()
+}
+
+// NextPageLink returns the URL of the "next" relation of the Link header written by paginated
+// actions, the empty string if resp is the last page. The generated page iterators use the URL as
+// the cursor of the next page.
+func NextPageLink(resp *http.Response) string {
+	for _, h := range resp.Header["Link"] {
+		for _, link := range strings.Split(h, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				if strings.TrimSpace(param) == `rel="next"` {
+					return target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// FollowPageLink points req to the page link returned by NextPageLink. Relative links are
+// resolved against the request URL. FollowPageLink does nothing if link is empty.
+func FollowPageLink(req *http.Request, link string) error {
+	if link == "" {
+		return nil
+	}
+	u, err := req.URL.Parse(link)
+	if err != nil {
+		return err
+	}
+	req.URL = u
+	return nil
+}
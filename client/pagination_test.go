@@ -0,0 +1,130 @@
+package client_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("PageIterator", func() {
+	var (
+		pages   map[string][]interface{}
+		links   map[string]string
+		fetched []string
+		fetch   client.PageFetcher
+	)
+
+	BeforeEach(func() {
+		pages = map[string][]interface{}{"": {1, 2}, "p2": {3}, "p3": {4, 5}}
+		links = map[string]string{"": "p2", "p2": "p3", "p3": ""}
+		fetched = nil
+		fetch = func(ctx context.Context, cursor string) ([]interface{}, string, error) {
+			fetched = append(fetched, cursor)
+			return pages[cursor], links[cursor], nil
+		}
+	})
+
+	It("iterates over the items of all the pages", func() {
+		var items []interface{}
+		it := client.NewPageIterator(context.Background(), fetch)
+		for it.Next() {
+			items = append(items, it.Item())
+		}
+		Ω(it.Err()).ShouldNot(HaveOccurred())
+		Ω(items).Should(Equal([]interface{}{1, 2, 3, 4, 5}))
+		Ω(fetched).Should(Equal([]string{"", "p2", "p3"}))
+	})
+
+	It("skips empty pages", func() {
+		pages["p2"] = nil
+		all, err := client.ListAll(context.Background(), fetch, 0)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(all).Should(Equal([]interface{}{1, 2, 4, 5}))
+	})
+
+	It("stops with an error when the cursor does not advance", func() {
+		links["p2"] = "p2"
+		var items []interface{}
+		it := client.NewPageIterator(context.Background(), fetch)
+		for it.Next() {
+			items = append(items, it.Item())
+		}
+		Ω(it.Err()).Should(MatchError(`pagination cursor "p2" did not advance`))
+		Ω(items).Should(Equal([]interface{}{1, 2}))
+		Ω(fetched).Should(Equal([]string{"", "p2"}))
+	})
+
+	It("stops with the error returned by the fetcher", func() {
+		fetchErr := errors.New("boom")
+		fetch = func(ctx context.Context, cursor string) ([]interface{}, string, error) {
+			fetched = append(fetched, cursor)
+			if cursor == "p2" {
+				return nil, "", fetchErr
+			}
+			return pages[cursor], links[cursor], nil
+		}
+		it := client.NewPageIterator(context.Background(), fetch)
+		Ω(it.Next()).Should(BeTrue())
+		Ω(it.Next()).Should(BeTrue())
+		Ω(it.Next()).Should(BeFalse())
+		Ω(it.Err()).Should(Equal(fetchErr))
+		Ω(it.Next()).Should(BeFalse())
+		Ω(fetched).Should(Equal([]string{"", "p2"}))
+	})
+
+	Context("ListAll", func() {
+		It("stops after max items", func() {
+			all, err := client.ListAll(context.Background(), fetch, 3)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(all).Should(Equal([]interface{}{1, 2, 3}))
+			Ω(fetched).Should(Equal([]string{"", "p2"}))
+		})
+
+		It("returns the items listed before a fetch error", func() {
+			fetch = func(ctx context.Context, cursor string) ([]interface{}, string, error) {
+				if cursor == "p3" {
+					return nil, "", errors.New("boom")
+				}
+				return pages[cursor], links[cursor], nil
+			}
+			all, err := client.ListAll(context.Background(), fetch, 0)
+			Ω(err).Should(MatchError("boom"))
+			Ω(all).Should(Equal([]interface{}{1, 2, 3}))
+		})
+	})
+})
+
+var _ = Describe("NextPageLink", func() {
+	It("returns the next link", func() {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Add("Link", `</bottles?page=1>; rel="prev", </bottles?page=3>; rel="next"`)
+		Ω(client.NextPageLink(resp)).Should(Equal("/bottles?page=3"))
+	})
+
+	It("returns the empty string on the last page", func() {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Add("Link", `</bottles?page=1>; rel="prev"`)
+		Ω(client.NextPageLink(resp)).Should(BeEmpty())
+	})
+})
+
+var _ = Describe("FollowPageLink", func() {
+	It("resolves the link against the request URL", func() {
+		u, _ := url.Parse("https://example.com/bottles?color=red")
+		req := &http.Request{URL: u}
+		Ω(client.FollowPageLink(req, "/bottles?color=red&page=2")).ShouldNot(HaveOccurred())
+		Ω(req.URL.String()).Should(Equal("https://example.com/bottles?color=red&page=2"))
+	})
+
+	It("leaves the request untouched given no link", func() {
+		u, _ := url.Parse("https://example.com/bottles")
+		req := &http.Request{URL: u}
+		Ω(client.FollowPageLink(req, "")).ShouldNot(HaveOccurred())
+		Ω(req.URL).Should(Equal(u))
+	})
+})
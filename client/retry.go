@@ -0,0 +1,173 @@
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+)
+
+const (
+	// DefaultRetryMaxAttempts is the default maximum number of attempts made to send a request,
+	// including the first one.
+	DefaultRetryMaxAttempts = 3
+
+	// DefaultRetryBackoff is the default delay before the first retry of a request.
+	DefaultRetryBackoff = 100 * time.Millisecond
+
+	// DefaultRetryMaxBackoff is the default maximum delay between two attempts.
+	DefaultRetryMaxBackoff = 10 * time.Second
+
+	// DefaultRetryJitter is the default fraction of the delay between two attempts that is
+	// randomized.
+	DefaultRetryJitter = 0.5
+)
+
+// retryPolicyKey is the context key used to store the retry policy of a request.
+const retryPolicyKey clientKey = 2
+
+type (
+	// RetryPolicy decides whether a request is sent again after an attempt failed. Retry is
+	// called after each attempt with the request, the response and the error returned by the
+	// attempt and the number of attempts made so far. It returns whether the request should be
+	// retried and the delay to wait before doing so. The body of resp, if any, is closed by the
+	// client when the request is retried.
+	RetryPolicy interface {
+		Retry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool)
+	}
+
+	// BackoffPolicy is a RetryPolicy that retries requests with an exponential backoff and
+	// jitter. A request is retried if the attempt failed to get a response or got a 429, 502,
+	// 503 or 504 response and if it is idempotent: only requests using the GET, HEAD, OPTIONS,
+	// TRACE, PUT or DELETE methods or carrying an idempotency key (see IdempotencyKeyHeader)
+	// are retried unless RetryUnsafe is true. The zero value is ready to use.
+	BackoffPolicy struct {
+		// MaxAttempts is the maximum number of attempts including the first one, defaults
+		// to DefaultRetryMaxAttempts.
+		MaxAttempts int
+		// Backoff is the delay before the first retry, it doubles after each attempt.
+		// Defaults to DefaultRetryBackoff.
+		Backoff time.Duration
+		// MaxBackoff is the maximum delay between two attempts, defaults to
+		// DefaultRetryMaxBackoff.
+		MaxBackoff time.Duration
+		// Jitter is the fraction of the delay that is randomized, defaults to
+		// DefaultRetryJitter. A negative value disables jitter.
+		Jitter float64
+		// RetryUnsafe causes requests that are not idempotent to be retried as well.
+		RetryUnsafe bool
+	}
+)
+
+// Retry implements RetryPolicy.
+func (p *BackoffPolicy) Retry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultRetryMaxAttempts
+	}
+	if attempt >= maxAttempts {
+		return 0, false
+	}
+	if !p.RetryUnsafe && !IsIdempotent(req) {
+		return 0, false
+	}
+	if err == nil {
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		default:
+			return 0, false
+		}
+	}
+	return p.delay(attempt), true
+}
+
+// delay computes the delay before the retry following the given attempt.
+func (p *BackoffPolicy) delay(attempt int) time.Duration {
+	backoff, maxBackoff, jitter := p.Backoff, p.MaxBackoff, p.Jitter
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+	if jitter == 0 {
+		jitter = DefaultRetryJitter
+	}
+	d := backoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	if jitter > 0 {
+		if jitter > 1 {
+			jitter = 1
+		}
+		r := time.Duration(jitter * float64(d))
+		if r > 0 {
+			d = d - r + time.Duration(rand.Int63n(int64(r)+1))
+		}
+	}
+	return d
+}
+
+// IsIdempotent returns true if req may safely be sent more than once: either its method is
+// idempotent or it carries an idempotency key.
+func IsIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// WithRetryPolicy returns a context that causes the requests sent with it to use the given retry
+// policy instead of the client RetryPolicy. Use it to configure the retries of a single call.
+func WithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey, policy)
+}
+
+// ContextRetryPolicy extracts the retry policy set with WithRetryPolicy from the context.
+func ContextRetryPolicy(ctx context.Context) RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey).(RetryPolicy); ok {
+		return p
+	}
+	return nil
+}
+
+// doWithRetries sends req using policy to decide whether to send it again. The request body is
+// read in memory so that it can be sent with each attempt.
+func (c *Client) doWithRetries(ctx context.Context, req *http.Request, policy RetryPolicy) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	for attempt := 1; ; attempt++ {
+		if body != nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := c.do(ctx, req)
+		d, retry := policy.Retry(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		goa.LogInfo(ctx, "retrying", "attempt", attempt, "in", d.String())
+		if !sleep(ctx, d) {
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+
+	"github.com/goadesign/goa"
+)
+
+const (
+	// LastEventIDHeader is the name of the request header used to resume a server sent events
+	// stream after the last event received.
+	LastEventIDHeader = "Last-Event-ID"
+
+	// DefaultStreamBackoff is the default delay before the first reconnection attempt of a
+	// stream.
+	DefaultStreamBackoff = time.Second
+
+	// DefaultStreamMaxBackoff is the default maximum delay between reconnection attempts of a
+	// stream.
+	DefaultStreamMaxBackoff = 30 * time.Second
+)
+
+type (
+	// StreamOptions configures how server sent events and websocket streams reconnect after
+	// the connection is lost. The delay between attempts doubles after each consecutive
+	// failure up to MaxBackoff.
+	StreamOptions struct {
+		// MaxRetries is the maximum number of consecutive failed connection attempts
+		// before the stream gives up, zero means the stream retries until its context is
+		// canceled.
+		MaxRetries int
+		// Backoff is the delay before the first reconnection attempt, defaults to
+		// DefaultStreamBackoff. Server sent events streams use the retry delay sent by
+		// the server instead if any.
+		Backoff time.Duration
+		// MaxBackoff is the maximum delay between reconnection attempts, defaults to
+		// DefaultStreamMaxBackoff.
+		MaxBackoff time.Duration
+		// PingInterval is the interval at which websocket streams send ping frames to keep
+		// the connection alive and detect broken connections, zero disables pings.
+		PingInterval time.Duration
+	}
+
+	// Event is a server sent event.
+	Event struct {
+		// ID is the event ID, the stream resumes after the last event ID when it
+		// reconnects.
+		ID string
+		// Type is the event type, "message" if the server did not specify one.
+		Type string
+		// Data is the event data.
+		Data []byte
+	}
+
+	// WSStream is a websocket connection that is re-established with backoff when it is lost.
+	// The messages received from the server are sent to the Messages channel which is closed
+	// once the stream stops, the error that stopped the stream if any is then sent to the Err
+	// channel.
+	WSStream struct {
+		dial    func(context.Context) (*websocket.Conn, error)
+		options StreamOptions
+		ctx     context.Context
+		cancel  context.CancelFunc
+
+		messages chan []byte
+		errc     chan error
+
+		mu   sync.Mutex
+		conn *websocket.Conn
+	}
+
+	// backoff computes the delays between consecutive reconnection attempts.
+	backoff struct {
+		options  StreamOptions
+		delay    time.Duration
+		failures int
+	}
+)
+
+// StreamEvents sends the request created by newRequest and streams the server sent events
+// contained in the response body to the returned events channel. The request is sent again
+// with the Last-Event-ID header set to the ID of the last event received when the connection is
+// lost. The events channel is closed when ctx is canceled, when the server responds with 204 No
+// Content or when the stream gives up, in which case the error that stopped it is sent to the
+// returned error channel first.
+func (c *Client) StreamEvents(ctx context.Context, newRequest func(context.Context) (*http.Request, error), options StreamOptions) (<-chan *Event, <-chan error) {
+	events := make(chan *Event)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(events)
+		var (
+			lastID string
+			b      = newBackoff(options)
+		)
+		for {
+			received, err := c.streamEvents(ctx, newRequest, &lastID, b, events)
+			if err == nil || ctx.Err() != nil {
+				return
+			}
+			if received {
+				b.reset()
+			}
+			if _, permanent := err.(*streamStatusError); permanent {
+				errc <- err
+				return
+			}
+			d, ok := b.next()
+			if !ok {
+				errc <- err
+				return
+			}
+			goa.LogInfo(ctx, "event stream reconnecting", "err", err, "in", d.String())
+			if !sleep(ctx, d) {
+				return
+			}
+		}
+	}()
+	return events, errc
+}
+
+// streamEvents makes one request and reads the events until the response body is exhausted. It
+// returns whether any event was received and the error that interrupted the stream, a nil error
+// means the stream is over.
+func (c *Client) streamEvents(ctx context.Context, newRequest func(context.Context) (*http.Request, error), lastID *string, b *backoff, events chan<- *Event) (bool, error) {
+	req, err := newRequest(ctx)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-store") // Bypass the response cache
+	if *lastID != "" {
+		req.Header.Set(LastEventIDHeader, *lastID)
+	}
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, &streamStatusError{resp.Status}
+	}
+
+	var (
+		received bool
+		r        = bufio.NewReader(resp.Body)
+		ev       = &Event{ID: *lastID}
+		data     bytes.Buffer
+	)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return received, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			// Blank line: dispatch the event if it has data.
+			if data.Len() > 0 {
+				ev.Data = bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+				if ev.Type == "" {
+					ev.Type = "message"
+				}
+				*lastID = ev.ID
+				select {
+				case events <- ev:
+					received = true
+				case <-ctx.Done():
+					return received, ctx.Err()
+				}
+			}
+			ev = &Event{ID: *lastID}
+			data = bytes.Buffer{}
+			continue
+		}
+		parseEventField(line, ev, &data, b)
+	}
+}
+
+// parseEventField parses a line of a server sent event and records the field value in ev, data
+// or b for the retry field.
+func parseEventField(line string, ev *Event, data *bytes.Buffer, b *backoff) {
+	if strings.HasPrefix(line, ":") {
+		return // Comment
+	}
+	field, value := line, ""
+	if i := strings.Index(line, ":"); i >= 0 {
+		field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+	}
+	switch field {
+	case "event":
+		ev.Type = value
+	case "data":
+		data.WriteString(value)
+		data.WriteByte('\n')
+	case "id":
+		if !strings.Contains(value, "\x00") {
+			ev.ID = value
+		}
+	case "retry":
+		if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+			b.options.Backoff = time.Duration(ms) * time.Millisecond
+			b.delay = b.options.Backoff
+		}
+	}
+}
+
+// NewWSStream creates a websocket stream that uses dial to establish the connection, typically a
+// closure around a generated client websocket method. The stream runs until ctx is canceled,
+// Close is called or the maximum number of retries is reached.
+func NewWSStream(ctx context.Context, dial func(context.Context) (*websocket.Conn, error), options StreamOptions) *WSStream {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &WSStream{
+		dial:     dial,
+		options:  options,
+		ctx:      ctx,
+		cancel:   cancel,
+		messages: make(chan []byte),
+		errc:     make(chan error, 1),
+	}
+	go s.run()
+	return s
+}
+
+// Messages returns the channel that receives the messages sent by the server.
+func (s *WSStream) Messages() <-chan []byte {
+	return s.messages
+}
+
+// Err returns the channel that receives the error that stopped the stream if any. The channel
+// is closed once the stream stops.
+func (s *WSStream) Err() <-chan error {
+	return s.errc
+}
+
+// Send sends a message to the server. It fails if the stream is not currently connected.
+func (s *WSStream) Send(msg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return fmt.Errorf("websocket stream not connected")
+	}
+	return websocket.Message.Send(s.conn, msg)
+}
+
+// Close stops the stream and closes the underlying connection.
+func (s *WSStream) Close() error {
+	s.cancel()
+	return nil
+}
+
+// run establishes the connection and reads messages until the stream stops.
+func (s *WSStream) run() {
+	defer close(s.errc)
+	defer close(s.messages)
+	b := newBackoff(s.options)
+	for {
+		conn, err := s.dial(s.ctx)
+		if err == nil {
+			b.reset()
+			err = s.read(conn)
+		}
+		if s.ctx.Err() != nil {
+			return
+		}
+		d, ok := b.next()
+		if !ok {
+			s.errc <- err
+			return
+		}
+		goa.LogInfo(s.ctx, "websocket stream reconnecting", "err", err, "in", d.String())
+		if !sleep(s.ctx, d) {
+			return
+		}
+	}
+}
+
+// read forwards the messages received on conn to the messages channel and sends pings at the
+// configured interval until the connection fails or the stream is closed.
+func (s *WSStream) read(conn *websocket.Conn) error {
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	go func() {
+		var tick <-chan time.Time
+		if s.options.PingInterval > 0 {
+			t := time.NewTicker(s.options.PingInterval)
+			defer t.Stop()
+			tick = t.C
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-s.ctx.Done():
+				conn.Close()
+				return
+			case <-tick:
+				if err := s.ping(conn); err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(conn, &msg); err != nil {
+			return err
+		}
+		select {
+		case s.messages <- msg:
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+}
+
+// ping sends a ping frame on conn, the server pong is handled by the websocket package.
+func (s *WSStream) ping(conn *websocket.Conn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	payloadType := conn.PayloadType
+	conn.PayloadType = websocket.PingFrame
+	_, err := conn.Write(nil)
+	conn.PayloadType = payloadType
+	return err
+}
+
+// newBackoff creates a backoff using the given options and their defaults.
+func newBackoff(options StreamOptions) *backoff {
+	if options.Backoff <= 0 {
+		options.Backoff = DefaultStreamBackoff
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = DefaultStreamMaxBackoff
+	}
+	return &backoff{options: options, delay: options.Backoff}
+}
+
+// next returns the delay before the next attempt and false if the maximum number of retries is
+// reached.
+func (b *backoff) next() (time.Duration, bool) {
+	b.failures++
+	if b.options.MaxRetries > 0 && b.failures > b.options.MaxRetries {
+		return 0, false
+	}
+	d := b.delay
+	b.delay *= 2
+	if b.delay > b.options.MaxBackoff {
+		b.delay = b.options.MaxBackoff
+	}
+	return d, true
+}
+
+// reset resets the delay and failure count after a successful connection.
+func (b *backoff) reset() {
+	b.failures = 0
+	b.delay = b.options.Backoff
+}
+
+// sleep waits for d to elapse using the context clock, it returns false if ctx is canceled
+// first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	elapsed := make(chan struct{})
+	t := goa.ContextClock(ctx).AfterFunc(d, func() { close(elapsed) })
+	select {
+	case <-elapsed:
+		return true
+	case <-ctx.Done():
+		t.Stop()
+		return false
+	}
+}
+
+// streamStatusError is returned when the server responds to an event stream request with an
+// unexpected status, such errors are not retried.
+type streamStatusError struct {
+	status string
+}
+
+// Error returns the error message.
+func (e *streamStatusError) Error() string {
+	return fmt.Sprintf("event stream request failed: %s", e.status)
+}
@@ -0,0 +1,54 @@
+package goa
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// Clock is the interface used by goa and its middlewares to retrieve the current time and
+	// to schedule timers. The default clock SystemClock relies on the time package, tests may
+	// store a clock they control in the service context with WithClock to control time
+	// deterministically, see goatest.Clock.
+	Clock interface {
+		// Now returns the current time.
+		Now() time.Time
+		// AfterFunc waits for the duration to elapse and then calls f in its own
+		// goroutine. The returned Timer can be used to cancel the call.
+		AfterFunc(d time.Duration, f func()) Timer
+	}
+
+	// Timer represents a single event scheduled with Clock.AfterFunc.
+	Timer interface {
+		// Stop prevents the timer from firing. It returns true if the call stops the
+		// timer, false if the timer has already expired or been stopped.
+		Stop() bool
+	}
+
+	// systemClock implements Clock using the time package.
+	systemClock struct{}
+)
+
+// SystemClock is the default clock, it relies on the time package.
+var SystemClock Clock = systemClock{}
+
+// WithClock sets the clock in the context.
+func WithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockKey, clock)
+}
+
+// ContextClock extracts the clock from the given context. It returns SystemClock if the context
+// does not contain a clock.
+func ContextClock(ctx context.Context) Clock {
+	if c := ctx.Value(clockKey); c != nil {
+		return c.(Clock)
+	}
+	return SystemClock
+}
+
+// Now returns the current local time.
+func (systemClock) Now() time.Time { return time.Now() }
+
+// AfterFunc calls time.AfterFunc.
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
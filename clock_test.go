@@ -0,0 +1,31 @@
+package goa_test
+
+import (
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("ContextClock", func() {
+	It("defaults to the system clock", func() {
+		Ω(goa.ContextClock(context.Background())).Should(Equal(goa.SystemClock))
+	})
+
+	It("returns the clock set with WithClock", func() {
+		clock := &fixedClock{now: time.Unix(42, 0)}
+		ctx := goa.WithClock(context.Background(), clock)
+		Ω(goa.ContextClock(ctx).Now()).Should(Equal(time.Unix(42, 0)))
+	})
+})
+
+// fixedClock is a goa.Clock that always returns the same time.
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time { return c.now }
+
+func (c *fixedClock) AfterFunc(d time.Duration, f func()) goa.Timer { return time.AfterFunc(d, f) }
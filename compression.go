@@ -0,0 +1,40 @@
+package goa
+
+import (
+	"mime"
+	"strings"
+)
+
+// SetIncompressible records media types whose representations do not compress well, typically
+// because they are already compressed (images, archives etc.). The compression middleware leaves
+// the responses with these media types alone, see Compressible. The generated controller mount
+// functions register the media types marked with the Incompressible DSL.
+func (service *Service) SetIncompressible(mediaTypes ...string) {
+	if service.incompressible == nil {
+		service.incompressible = make(map[string]bool, len(mediaTypes))
+	}
+	for _, mt := range mediaTypes {
+		service.incompressible[canonicalMediaType(mt)] = true
+	}
+}
+
+// Compressible returns false if the given Content-Type header value identifies a media type
+// registered with SetIncompressible, true otherwise. The media type parameters are ignored.
+func (service *Service) Compressible(contentType string) bool {
+	if len(service.incompressible) == 0 || contentType == "" {
+		return true
+	}
+	return !service.incompressible[canonicalMediaType(contentType)]
+}
+
+// canonicalMediaType returns the lowercase media type of the given Content-Type header value
+// without its parameters.
+func canonicalMediaType(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
@@ -0,0 +1,51 @@
+package goa
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// Container holds the request-scoped dependencies such as database transactions or the
+// authenticated user. The code generated for the dependencies declared in the design with the
+// Dependency DSL provides typed accessors on top of the container stored in the request context,
+// see the middleware.Container middleware.
+type Container struct {
+	lock   sync.RWMutex
+	values map[string]interface{}
+}
+
+// NewContainer creates an empty dependency container.
+func NewContainer() *Container {
+	return &Container{values: make(map[string]interface{})}
+}
+
+// WithContainer sets the dependency container in the context.
+func WithContainer(ctx context.Context, c *Container) context.Context {
+	return context.WithValue(ctx, containerKey, c)
+}
+
+// ContextContainer extracts the dependency container from the given context. It returns nil if
+// the context does not contain a container.
+func ContextContainer(ctx context.Context) *Container {
+	if c := ctx.Value(containerKey); c != nil {
+		return c.(*Container)
+	}
+	return nil
+}
+
+// Set stores the value of the dependency with the given name, overriding any existing value.
+func (c *Container) Set(name string, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.values[name] = value
+}
+
+// Get returns the value of the dependency with the given name and true if the dependency was set,
+// nil and false otherwise.
+func (c *Container) Get(name string) (interface{}, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	v, ok := c.values[name]
+	return v, ok
+}
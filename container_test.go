@@ -0,0 +1,30 @@
+package goa_test
+
+import (
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Container", func() {
+	It("is not set by default", func() {
+		Ω(goa.ContextContainer(context.Background())).Should(BeNil())
+	})
+
+	It("returns the container set with WithContainer", func() {
+		c := goa.NewContainer()
+		ctx := goa.WithContainer(context.Background(), c)
+		Ω(goa.ContextContainer(ctx)).Should(BeIdenticalTo(c))
+	})
+
+	It("stores dependency values", func() {
+		c := goa.NewContainer()
+		c.Set("tenant", "acme")
+		v, ok := c.Get("tenant")
+		Ω(ok).Should(BeTrue())
+		Ω(v).Should(Equal("acme"))
+		_, ok = c.Get("user")
+		Ω(ok).Should(BeFalse())
+	})
+})
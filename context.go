@@ -19,6 +19,13 @@ const (
 	logContextKey
 	errKey
 	securityScopesKey
+	clockKey
+	disconnectedKey
+	containerKey
+	deferredPayloadKey
+	classificationsKey
+	subjectKey
+	timingsKey
 )
 
 type (
@@ -45,6 +52,9 @@ type (
 		Status int
 		// Length is the response body length.
 		Length int
+
+		// timings records the start of the response if not nil.
+		timings *Timings
 	}
 
 	// key is the type used to store internal values in the context.
@@ -157,6 +167,9 @@ func (r *ResponseData) Written() bool {
 func (r *ResponseData) WriteHeader(status int) {
 	go IncrCounter([]string{"goa", "response", strconv.Itoa(status)}, 1.0)
 	r.Status = status
+	if r.timings != nil {
+		r.timings.StartResponse()
+	}
 	r.ResponseWriter.WriteHeader(status)
 }
 
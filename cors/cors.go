@@ -20,6 +20,11 @@ type key string
 // OriginKey is the context key used to store the request origin match
 const OriginKey key = "origin"
 
+// OriginFunc validates the origin of a CORS request at runtime, it returns true if the request
+// origin is allowed. The generated code defines a variable of this type for each function
+// declared with the OriginFunc DSL.
+type OriginFunc func(origin string, req *http.Request) bool
+
 // MatchOrigin returns true if the given Origin header value matches the
 // origin specification.
 // Spec can be one of:
@@ -65,3 +70,25 @@ func HandlePreflight() goa.Handler {
 		return nil
 	}
 }
+
+// CheckOrigin returns true if the given origin validation function allows the request origin.
+// It returns false if f is nil so that requests are rejected until the function is set.
+func CheckOrigin(f OriginFunc, origin string, req *http.Request) bool {
+	if f == nil {
+		return false
+	}
+	return f(origin, req)
+}
+
+// HandlePreflightByMethod returns a handler that dispatches preflight requests to the handler
+// registered for the method given by their Access-Control-Request-Method header, or to def if
+// there is none. It is used by the generated code when the actions that share a path define
+// different CORS policies.
+func HandlePreflightByMethod(def goa.Handler, handlers map[string]goa.Handler) goa.Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		if h, ok := handlers[req.Header.Get("Access-Control-Request-Method")]; ok {
+			return h(ctx, rw, req)
+		}
+		return def(ctx, rw, req)
+	}
+}
@@ -1,9 +1,14 @@
 package cors_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"testing"
 
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/cors"
 )
 
@@ -51,3 +56,47 @@ func TestMatchOriginRegexp(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckOrigin(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	if cors.CheckOrigin(nil, "http://example.com", req) {
+		t.Errorf("cors.CheckOrigin should reject origins when the function is not set")
+	}
+	allow := func(origin string, req *http.Request) bool { return origin == "http://example.com" }
+	if !cors.CheckOrigin(allow, "http://example.com", req) {
+		t.Errorf("cors.CheckOrigin should allow origins accepted by the function")
+	}
+	if cors.CheckOrigin(allow, "http://other.com", req) {
+		t.Errorf("cors.CheckOrigin should reject origins rejected by the function")
+	}
+}
+
+func TestHandlePreflightByMethod(t *testing.T) {
+	handler := func(name string) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.Header().Set("X-Handler", name)
+			return nil
+		}
+	}
+	h := cors.HandlePreflightByMethod(handler("default"), map[string]goa.Handler{"DELETE": handler("delete")})
+	data := []struct {
+		Method  string
+		Handler string
+	}{
+		{"DELETE", "delete"},
+		{"GET", "default"},
+		{"", "default"},
+	}
+
+	for _, test := range data {
+		req, _ := http.NewRequest("OPTIONS", "/", nil)
+		if test.Method != "" {
+			req.Header.Set("Access-Control-Request-Method", test.Method)
+		}
+		rw := httptest.NewRecorder()
+		h(context.Background(), rw, req)
+		if got := rw.Header().Get("X-Handler"); got != test.Handler {
+			t.Errorf("preflight request for %q should be handled by %q, got %q", test.Method, test.Handler, got)
+		}
+	}
+}
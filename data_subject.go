@@ -0,0 +1,222 @@
+package goa
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// DataRequest describes a request made by a data subject to export or delete their data.
+	DataRequest struct {
+		// ID is the unique identifier of the request.
+		ID string
+		// Subject identifies the data subject that made the request, see ContextSubject.
+		Subject string
+		// Kind is DataExport or DataDelete.
+		Kind string
+		// Status is one of DataRequestPending, DataRequestRunning, DataRequestCompleted or
+		// DataRequestFailed.
+		Status string
+		// CreatedAt is the time the request was made.
+		CreatedAt time.Time
+		// CompletedAt is the time the request completed or failed, nil if it is still in
+		// progress.
+		CompletedAt *time.Time
+		// DownloadURL is the URL the exported data can be downloaded from once an export
+		// request completes.
+		DownloadURL string
+		// Error is the error message of failed requests.
+		Error string
+	}
+
+	// DataSubjectHandler is the interface implemented by the services to export and delete the
+	// data of a data subject. The methods are called asynchronously by DataSubjectService.
+	DataSubjectHandler interface {
+		// Export exports the data of the given subject and returns the URL the data can be
+		// downloaded from.
+		Export(ctx context.Context, subject string) (downloadURL string, err error)
+		// Delete deletes the data of the given subject.
+		Delete(ctx context.Context, subject string) error
+	}
+
+	// DataRequestStore is the interface implemented by the storage backends of data requests.
+	DataRequestStore interface {
+		// SaveDataRequest creates or updates the given request.
+		SaveDataRequest(ctx context.Context, req *DataRequest) error
+		// GetDataRequest returns the request with the given identifier or nil if there is
+		// none.
+		GetDataRequest(ctx context.Context, id string) (*DataRequest, error)
+	}
+
+	// MemoryDataRequestStore is a DataRequestStore that keeps the requests in memory. It is
+	// meant for development and tests: the requests are lost when the process exits.
+	MemoryDataRequestStore struct {
+		sync.Mutex
+		requests map[string]DataRequest
+	}
+
+	// DataSubjectService implements the asynchronous job pattern used by the code generated for
+	// the DataSubjectRequests DSL: requests are recorded as pending, run in the background by
+	// the DataSubjectHandler and polled by the data subject until they complete.
+	DataSubjectService struct {
+		store   DataRequestStore
+		handler DataSubjectHandler
+	}
+)
+
+const (
+	// DataExport is the kind of the requests to export the data of a data subject.
+	DataExport = "export"
+	// DataDelete is the kind of the requests to delete the data of a data subject.
+	DataDelete = "delete"
+)
+
+const (
+	// DataRequestPending is the status of the requests that have not started yet.
+	DataRequestPending = "pending"
+	// DataRequestRunning is the status of the requests being processed.
+	DataRequestRunning = "running"
+	// DataRequestCompleted is the status of the requests that completed successfully.
+	DataRequestCompleted = "completed"
+	// DataRequestFailed is the status of the requests that failed.
+	DataRequestFailed = "failed"
+)
+
+// WithSubject sets the identifier of the authenticated principal making the request in the
+// context. The JWT middleware sets it to the value of the "sub" claim, other security middlewares
+// should call WithSubject once the request is authenticated.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey, subject)
+}
+
+// ContextSubject returns the identifier of the authenticated principal making the request, see
+// WithSubject. It returns an empty string if the request is not authenticated.
+func ContextSubject(ctx context.Context) string {
+	if s := ctx.Value(subjectKey); s != nil {
+		return s.(string)
+	}
+	return ""
+}
+
+// NewMemoryDataRequestStore creates an empty in-memory data request store.
+func NewMemoryDataRequestStore() *MemoryDataRequestStore {
+	return &MemoryDataRequestStore{requests: make(map[string]DataRequest)}
+}
+
+// SaveDataRequest creates or updates the given request.
+func (s *MemoryDataRequestStore) SaveDataRequest(ctx context.Context, req *DataRequest) error {
+	s.Lock()
+	defer s.Unlock()
+	s.requests[req.ID] = *req
+	return nil
+}
+
+// GetDataRequest returns a copy of the request with the given identifier or nil if there is none.
+func (s *MemoryDataRequestStore) GetDataRequest(ctx context.Context, id string) (*DataRequest, error) {
+	s.Lock()
+	defer s.Unlock()
+	req, ok := s.requests[id]
+	if !ok {
+		return nil, nil
+	}
+	return &req, nil
+}
+
+// NewDataSubjectService creates a service that records the data requests in store and runs them
+// with handler.
+func NewDataSubjectService(store DataRequestStore, handler DataSubjectHandler) *DataSubjectService {
+	return &DataSubjectService{store: store, handler: handler}
+}
+
+// Submit records a request of the given kind made by the subject of the context and runs it in
+// the background. It returns the pending request. Submit returns ErrUnauthorized if the context
+// does not define a subject.
+func (s *DataSubjectService) Submit(ctx context.Context, kind string) (*DataRequest, error) {
+	if kind != DataExport && kind != DataDelete {
+		return nil, fmt.Errorf("invalid data request kind %#v", kind)
+	}
+	subject := ContextSubject(ctx)
+	if subject == "" {
+		return nil, ErrUnauthorized("data requests must be made by an authenticated subject")
+	}
+	req := &DataRequest{
+		ID:        newDataRequestID(),
+		Subject:   subject,
+		Kind:      kind,
+		Status:    DataRequestPending,
+		CreatedAt: ContextClock(ctx).Now(),
+	}
+	if err := s.store.SaveDataRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	// The job outlives the HTTP request, only retain the logger and clock.
+	jctx := WithClock(WithSubject(context.Background(), subject), ContextClock(ctx))
+	if logger := ContextLogger(ctx); logger != nil {
+		jctx = WithLogger(jctx, logger)
+	}
+	job := *req
+	go s.run(jctx, &job)
+	return req, nil
+}
+
+// Request returns the request with the given identifier. It returns ErrNotFound if there is no
+// such request or if it was made by a subject other than the subject of the context so that
+// subjects cannot probe the requests of others.
+func (s *DataSubjectService) Request(ctx context.Context, id string) (*DataRequest, error) {
+	subject := ContextSubject(ctx)
+	if subject == "" {
+		return nil, ErrUnauthorized("data requests must be made by an authenticated subject")
+	}
+	req, err := s.store.GetDataRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil || req.Subject != subject {
+		return nil, ErrNotFound(fmt.Sprintf("no data request with id %#v", id))
+	}
+	return req, nil
+}
+
+// run runs the given request and records its outcome.
+func (s *DataSubjectService) run(ctx context.Context, req *DataRequest) {
+	req.Status = DataRequestRunning
+	if err := s.store.SaveDataRequest(ctx, req); err != nil {
+		LogError(ctx, "data request", "id", req.ID, "err", err)
+		return
+	}
+	var err error
+	switch req.Kind {
+	case DataExport:
+		req.DownloadURL, err = s.handler.Export(ctx, req.Subject)
+	case DataDelete:
+		err = s.handler.Delete(ctx, req.Subject)
+	}
+	now := ContextClock(ctx).Now()
+	req.CompletedAt = &now
+	req.Status = DataRequestCompleted
+	if err != nil {
+		req.Status = DataRequestFailed
+		req.Error = err.Error()
+		LogError(ctx, "data request", "id", req.ID, "kind", req.Kind, "err", err)
+	}
+	if err := s.store.SaveDataRequest(ctx, req); err != nil {
+		LogError(ctx, "data request", "id", req.ID, "err", err)
+	}
+}
+
+// newDataRequestID returns a random data request identifier suitable for use in URL paths.
+// Identifiers are sequential when SequentialIDs is in effect.
+func newDataRequestID() string {
+	if id, ok := NextSequentialID(); ok {
+		return id
+	}
+	b := make([]byte, 12)
+	io.ReadFull(rand.Reader, b)
+	return hex.EncodeToString(b)
+}
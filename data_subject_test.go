@@ -0,0 +1,91 @@
+package goa_test
+
+import (
+	"errors"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+// testDataSubjectHandler records the subjects whose data is exported or deleted.
+type testDataSubjectHandler struct {
+	exported, deleted chan string
+	err               error
+}
+
+func (h *testDataSubjectHandler) Export(ctx context.Context, subject string) (string, error) {
+	h.exported <- subject
+	return "https://example.com/exports/" + subject, h.err
+}
+
+func (h *testDataSubjectHandler) Delete(ctx context.Context, subject string) error {
+	h.deleted <- subject
+	return h.err
+}
+
+var _ = Describe("DataSubjectService", func() {
+	var handler *testDataSubjectHandler
+	var store *goa.MemoryDataRequestStore
+	var service *goa.DataSubjectService
+	var ctx context.Context
+
+	BeforeEach(func() {
+		handler = &testDataSubjectHandler{exported: make(chan string, 1), deleted: make(chan string, 1)}
+		store = goa.NewMemoryDataRequestStore()
+		service = goa.NewDataSubjectService(store, handler)
+		ctx = goa.WithSubject(context.Background(), "user-1")
+	})
+
+	status := func(id string) func() string {
+		return func() string {
+			req, err := store.GetDataRequest(ctx, id)
+			Ω(err).ShouldNot(HaveOccurred())
+			return req.Status
+		}
+	}
+
+	It("exports the data of the subject in the background", func() {
+		req, err := service.Submit(ctx, goa.DataExport)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(req.Subject).Should(Equal("user-1"))
+		Ω(req.Kind).Should(Equal(goa.DataExport))
+		Ω(req.Status).Should(Equal(goa.DataRequestPending))
+		Eventually(handler.exported).Should(Receive(Equal("user-1")))
+		Eventually(status(req.ID)).Should(Equal(goa.DataRequestCompleted))
+
+		req, err = service.Request(ctx, req.ID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(req.DownloadURL).Should(Equal("https://example.com/exports/user-1"))
+		Ω(req.CompletedAt).ShouldNot(BeNil())
+	})
+
+	It("records failures", func() {
+		handler.err = errors.New("boom")
+		req, err := service.Submit(ctx, goa.DataDelete)
+		Ω(err).ShouldNot(HaveOccurred())
+		Eventually(handler.deleted).Should(Receive(Equal("user-1")))
+		Eventually(status(req.ID)).Should(Equal(goa.DataRequestFailed))
+
+		req, err = service.Request(ctx, req.ID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(req.Error).Should(Equal("boom"))
+	})
+
+	It("hides the requests of other subjects", func() {
+		req, err := service.Submit(ctx, goa.DataDelete)
+		Ω(err).ShouldNot(HaveOccurred())
+		Eventually(handler.deleted).Should(Receive())
+
+		_, err = service.Request(goa.WithSubject(ctx, "user-2"), req.ID)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(*goa.ErrorResponse).Status).Should(Equal(404))
+	})
+
+	It("requires a subject", func() {
+		_, err := service.Submit(context.Background(), goa.DataExport)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(*goa.ErrorResponse).Status).Should(Equal(401))
+	})
+})
@@ -142,6 +142,7 @@ var (
 					"code":   "invalid_value",
 					"detail": "Value of ID must be an integer",
 					"meta":   map[string]interface{}{"timestamp": 1458609066},
+					"errors": []interface{}{errorFieldExample},
 				},
 			},
 			TypeName: "error",
@@ -179,6 +180,44 @@ var (
 			Description: "a meta object containing non-standard meta-information about the error.",
 			Example:     map[string]interface{}{"timestamp": 1458609066},
 		},
+		"errors": &AttributeDefinition{
+			Type: &Array{
+				ElemType: &AttributeDefinition{
+					Type: Object{
+						"field": &AttributeDefinition{
+							Type:        String,
+							Description: "the name of the parameter or header or the path to the payload attribute.",
+							Example:     "id",
+						},
+						"code": &AttributeDefinition{
+							Type:        String,
+							Description: "the validation that failed, expressed as a string value.",
+							Example:     "invalid_type",
+						},
+						"message": &AttributeDefinition{
+							Type:        String,
+							Description: "a human-readable description of the validation error.",
+							Example:     "Value of ID must be an integer",
+						},
+						"value": &AttributeDefinition{
+							Type:        Any,
+							Description: "the invalid value if any.",
+							Example:     "foo",
+						},
+					},
+					Validation: &dslengine.ValidationDefinition{Required: []string{"field", "code", "message"}},
+				},
+			},
+			Description: "the individual validation errors, one per failing parameter, header or payload attribute.",
+			Example:     []interface{}{errorFieldExample},
+		},
+	}
+
+	errorFieldExample = map[string]interface{}{
+		"field":   "id",
+		"code":    "invalid_type",
+		"message": "Value of ID must be an integer",
+		"value":   "foo",
 	}
 
 	errorMediaView = &ViewDefinition{
@@ -219,7 +258,23 @@ func CanonicalIdentifier(identifier string) string {
 // HasKnownEncoder returns true if the encoder for the given MIME type is known by goa.
 // MIME types with unknown encoders must be associated with a package path explicitly in the DSL.
 func HasKnownEncoder(mimeType string) bool {
-	return KnownEncoders[mimeType] != ""
+	return KnownEncoderMIMEType(mimeType) != ""
+}
+
+// KnownEncoderMIMEType returns the key of KnownEncoders and KnownEncoderFunctions that holds the
+// encoder of the given MIME type. MIME types with a structured syntax suffix such as
+// "application/vnd.goa.error+cbor" use the encoder of the suffix ("application/cbor") unless
+// their encoder is known. KnownEncoderMIMEType returns "" if the encoder is not known.
+func KnownEncoderMIMEType(mimeType string) string {
+	if KnownEncoders[mimeType] != "" {
+		return mimeType
+	}
+	if i := strings.LastIndex(mimeType, "+"); i != -1 {
+		if mt := "application/" + mimeType[i+1:]; KnownEncoders[mt] != "" {
+			return mt
+		}
+	}
+	return ""
 }
 
 // ExtractWildcards returns the names of the wildcards that appear in path.
@@ -36,6 +36,24 @@ var _ = Describe("CanonicalIdentifier", func() {
 	})
 })
 
+var _ = Describe("KnownEncoderMIMEType", func() {
+	It("returns the MIME types of known encoders", func() {
+		Ω(design.KnownEncoderMIMEType("application/cbor")).Should(Equal("application/cbor"))
+		Ω(design.KnownEncoderMIMEType("application/x-msgpack")).Should(Equal("application/x-msgpack"))
+	})
+
+	It("uses the structured syntax suffix", func() {
+		Ω(design.KnownEncoderMIMEType("application/vnd.goa.bottle+cbor")).Should(Equal("application/cbor"))
+		Ω(design.KnownEncoderMIMEType("application/vnd.goa.bottle+msgpack")).Should(Equal("application/msgpack"))
+		Ω(design.HasKnownEncoder("application/vnd.goa.bottle+json")).Should(BeTrue())
+	})
+
+	It("returns the empty string for unknown encoders", func() {
+		Ω(design.KnownEncoderMIMEType("application/vnd.goa.bottle+yaml")).Should(BeEmpty())
+		Ω(design.HasKnownEncoder("text/plain")).Should(BeFalse())
+	})
+})
+
 var _ = Describe("ExtractWildcards", func() {
 	var path string
 	var wcs []string
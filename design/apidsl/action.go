@@ -6,6 +6,7 @@ import (
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/middleware"
 )
 
 // Files defines an API endpoint that serves static assets. The logic for what to do when the
@@ -37,10 +38,13 @@ import (
 //    })
 func Files(path, filename string, dsls ...func()) {
 	if r, ok := resourceDefinition(); ok {
+		file, line := dslengine.CallerLocation()
 		server := &design.FileServerDefinition{
 			Parent:      r,
 			RequestPath: path,
 			FilePath:    filename,
+			File:        file,
+			Line:        line,
 		}
 		if len(dsls) > 0 {
 			if !dslengine.Execute(dsls[0], server) {
@@ -117,9 +121,16 @@ func Action(name string, dsl func()) {
 // segment and `*name` is a catch-all that matches the path until the end.
 func Routing(routes ...*design.RouteDefinition) {
 	if a, ok := actionDefinition(); ok {
+		file, line := dslengine.CallerLocation()
 		for _, r := range routes {
 			r.Parent = a
+			r.File, r.Line = file, line
 			a.Routes = append(a.Routes, r)
+			if !r.IsAbsolute() {
+				for _, m := range a.Parent.Mounts {
+					a.Routes = append(a.Routes, m.Route(r))
+				}
+			}
 		}
 	}
 }
@@ -303,6 +314,93 @@ func Params(dsl func()) {
 	}
 }
 
+// NoParentParams prevents the action from inheriting the parameters defined by the parent
+// resources and the API. When given names only the corresponding parameters are excluded,
+// otherwise none of the parent parameters are inherited. Parameters matching wildcards of the
+// action routes are always inherited. This is typically used by child resource actions that
+// define absolute routes. Example:
+//
+//	var _ = Resource("review", func() {
+//		Parent("bottle")
+//		Action("list", func() {
+//			Routing(
+//				GET("/reviews"),
+//				GET("//reviews"),
+//			)
+//			NoParentParams("vintage") // Do not inherit the "vintage" query string parameter
+//		})
+//	})
+//
+func NoParentParams(names ...string) {
+	if a, ok := actionDefinition(); ok {
+		if len(names) == 0 {
+			a.NoParentParams = true
+			return
+		}
+		a.ExcludedParentParams = append(a.ExcludedParentParams, names...)
+	}
+}
+
+// Fallback declares that the action depends on the optional downstream service with the given
+// name and that the response with the given name is sent in place of running the action while the
+// downstream is reported unavailable in the service downstream registry (see
+// goa.DownstreamRegistry). The response must be defined by the action, resource or API, its
+// example if any is used as body so that the design can describe a cached or partial result:
+//
+//	Action("show", func() {
+//		Routing(GET("/:id"))
+//		Fallback("ratings", "Degraded")
+//		Response(OK)
+//		Response("Degraded", func() {
+//			Status(203)
+//			Media(BottleMedia)
+//			Example(map[string]interface{}{"id": 1, "name": "Unrated"})
+//		})
+//	})
+//
+func Fallback(downstream, response string) {
+	if a, ok := actionDefinition(); ok {
+		a.Fallbacks = append(a.Fallbacks, &design.FallbackDefinition{
+			Parent:     a,
+			Downstream: downstream,
+			Response:   response,
+		})
+	}
+}
+
+// Idempotent declares that the action accepts the optional Idempotency-Key request header. Clients
+// set the header to a unique value when making a request and reuse it when retrying the request,
+// the Idempotency middleware then replays the response to the first request instead of running the
+// action again. Idempotent adds the header to the action headers so that it is documented in the
+// generated Swagger specification. It may only be used in actions with POST or PATCH routes.
+//
+//	Action("create", func() {
+//		Routing(POST(""))
+//		Idempotent()
+//		Payload(BottlePayload)
+//		Response(Created)
+//	})
+//
+func Idempotent() {
+	if a, ok := actionDefinition(); ok {
+		a.Idempotent = true
+		if a.Headers == nil {
+			a.Headers = newAttribute(a.Parent.MediaType)
+			a.Headers.Type = make(design.Object)
+		}
+		headers := a.Headers.Type.ToObject()
+		if _, ok := headers[middleware.IdempotencyKeyHeader]; ok {
+			return
+		}
+		maxLength := 255
+		headers[middleware.IdempotencyKeyHeader] = &design.AttributeDefinition{
+			Type:        design.String,
+			Description: "Unique key identifying the request, retries of the request with the same key get the response to the first request replayed",
+			Validation:  &dslengine.ValidationDefinition{MaxLength: &maxLength},
+		}
+	}
+}
+
 // Payload implements the action payload DSL. An action payload describes the HTTP request body
 // data structure. The function accepts either a type or a DSL that describes the payload members
 // using the Member DSL which accepts the same syntax as the Attribute DSL. This function can be
@@ -332,6 +430,28 @@ func OptionalPayload(p interface{}, dsls ...func()) {
 	payload(true, p, dsls...)
 }
 
+// MultipartPayload implements the action multipart payload DSL. The function works identically to
+// the Payload DSL except the request body is a multipart/form-data form: each payload attribute
+// corresponds to a form part. Attributes of type File describe file parts, the generated payload
+// fields are *multipart.FileHeader values. MaxLength sets the maximum size in bytes of a file part.
+// The other attributes describe regular form fields and must be primitives or arrays of
+// primitives. Example:
+//
+//	MultipartPayload(func() {
+//		Member("name", String)
+//		Member("label", File, func() {
+//			MaxLength(1024 * 1024)	// Label images cannot exceed 1MB
+//		})
+//		Required("name", "label")
+//	})
+//
+func MultipartPayload(p interface{}, dsls ...func()) {
+	payload(false, p, dsls...)
+	if a, ok := actionDefinition(); ok {
+		a.PayloadMultipart = true
+	}
+}
+
 func payload(isOptional bool, p interface{}, dsls ...func()) {
 	if len(dsls) > 1 {
 		dslengine.ReportError("too many arguments given to Payload")
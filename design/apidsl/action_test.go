@@ -213,6 +213,37 @@ var _ = Describe("Action", func() {
 			})
 		})
 	})
+
+	Context("with Idempotent", func() {
+		var route *RouteDefinition
+
+		BeforeEach(func() {
+			name = "foo"
+			route = POST("")
+			dsl = func() {
+				Routing(route)
+				Idempotent()
+			}
+		})
+
+		It("sets the Idempotent flag and adds the Idempotency-Key header", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action.Idempotent).Should(BeTrue())
+			Ω(action.Headers).ShouldNot(BeNil())
+			Ω(action.Headers.Type.ToObject()).Should(HaveKey("Idempotency-Key"))
+			Ω(action.Headers.IsRequired("Idempotency-Key")).Should(BeFalse())
+		})
+
+		Context("with a GET route", func() {
+			BeforeEach(func() {
+				route = GET("")
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+			})
+		})
+	})
 })
 
 var _ = Describe("Payload", func() {
@@ -304,4 +335,195 @@ var _ = Describe("Payload", func() {
 		})
 	})
 
+	Context("with parent parameters", func() {
+		var dsl func()
+		var action *ActionDefinition
+
+		BeforeEach(func() {
+			dsl = nil
+		})
+
+		JustBeforeEach(func() {
+			dslengine.Reset()
+			Resource("parent", func() {
+				BasePath("/parents")
+				Params(func() {
+					Param("vintage", Integer)
+					Param("sort", String)
+				})
+				Action("show", func() {
+					Routing(GET("/:parentID"))
+				})
+			})
+			Resource("child", func() {
+				Parent("parent")
+				Action("list", func() {
+					Routing(GET("/children"), GET("//children"))
+					if dsl != nil {
+						dsl()
+					}
+				})
+			})
+			dslengine.Run()
+			action = Design.Resources["child"].Actions["list"]
+		})
+
+		It("inherits them by default", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action.AllParams().Type.ToObject()).Should(HaveKey("vintage"))
+			Ω(action.AllParams().Type.ToObject()).Should(HaveKey("sort"))
+		})
+
+		It("only includes the wildcards of the route in the route parameters", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			relative := action.RouteParams(action.Routes[0]).Type.ToObject()
+			Ω(relative).Should(HaveKey("parentID"))
+			Ω(relative).Should(HaveKey("vintage"))
+			absolute := action.RouteParams(action.Routes[1]).Type.ToObject()
+			Ω(absolute).ShouldNot(HaveKey("parentID"))
+			Ω(absolute).Should(HaveKey("vintage"))
+			Ω(action.AllParams().Type.ToObject()).Should(HaveKey("parentID"))
+		})
+
+		Context("excluding specific parameters", func() {
+			BeforeEach(func() {
+				dsl = func() { NoParentParams("vintage") }
+			})
+
+			It("does not inherit the excluded parameters", func() {
+				Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+				Ω(action.AllParams().Type.ToObject()).ShouldNot(HaveKey("vintage"))
+				Ω(action.AllParams().Type.ToObject()).Should(HaveKey("sort"))
+			})
+		})
+
+		Context("excluding all parameters", func() {
+			BeforeEach(func() {
+				dsl = func() { NoParentParams() }
+			})
+
+			It("only keeps the route wildcards", func() {
+				Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+				Ω(action.AllParams().Type.ToObject()).Should(HaveLen(1))
+				Ω(action.AllParams().Type.ToObject()).Should(HaveKey("parentID"))
+			})
+		})
+
+		Context("excluding a route wildcard", func() {
+			BeforeEach(func() {
+				dsl = func() { NoParentParams("parentID") }
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+				Ω(dslengine.Errors.Error()).Should(ContainSubstring(`cannot exclude parent parameter "parentID"`))
+			})
+		})
+	})
+})
+
+var _ = Describe("MultipartPayload", func() {
+	var payloadDSL func()
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		payloadDSL = func() {
+			Attribute("name")
+			Attribute("label", File)
+			Required("label")
+		}
+	})
+
+	JustBeforeEach(func() {
+		Resource("foo", func() {
+			Action("bar", func() {
+				Routing(POST(""))
+				MultipartPayload(payloadDSL)
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("sets the payload and the multipart flag", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		a := Design.Resources["foo"].Actions["bar"]
+		Ω(a.Payload).ShouldNot(BeNil())
+		Ω(a.PayloadMultipart).Should(BeTrue())
+		Ω(a.Payload.Type.ToObject()["label"].Type).Should(Equal(File))
+	})
+
+	Context("with a nested object attribute", func() {
+		BeforeEach(func() {
+			payloadDSL = func() {
+				Attribute("obj", func() {
+					Attribute("name")
+				})
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("used in a regular payload", func() {
+		JustBeforeEach(func() {
+			dslengine.Reset()
+			Resource("foo", func() {
+				Action("bar", func() {
+					Routing(POST(""))
+					Payload(payloadDSL)
+				})
+			})
+			dslengine.Run()
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("MultipartPayload"))
+		})
+	})
+})
+
+var _ = Describe("Fallback", func() {
+	var response string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		response = "Degraded"
+	})
+
+	JustBeforeEach(func() {
+		Resource("foo", func() {
+			Action("bar", func() {
+				Routing(GET(""))
+				Fallback("ratings", response)
+				Response(OK)
+				Response("Degraded", func() {
+					Status(203)
+				})
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("records the fallback", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		a := Design.Resources["foo"].Actions["bar"]
+		Ω(a.Fallbacks).Should(HaveLen(1))
+		Ω(a.Fallbacks[0].Downstream).Should(Equal("ratings"))
+		Ω(a.Fallbacks[0].Response).Should(Equal("Degraded"))
+		Ω(a.Fallbacks[0].Parent).Should(Equal(a))
+	})
+
+	Context("with an undefined response", func() {
+		BeforeEach(func() {
+			response = "Unknown"
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`response "Unknown" is not defined`))
+		})
+	})
 })
@@ -0,0 +1,38 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Affinity defines the affinity key of the requests made to the actions of the resource or to the
+// action in which it appears. The key is read from the header or the parameter with the given name,
+// source must be "header" or "param". The generated code applies the middleware.Affinity
+// middleware which exposes the key to the action (see middleware.ContextAffinityKey), for example
+// to pick a shard with middleware.HashRing. Action affinities override the resource one.
+//
+//	Resource("account", func() {
+//		Affinity("param", "accountID")
+//	})
+//
+//	Action("search", func() {
+//		Affinity("header", "X-Tenant-Id")
+//	})
+//
+func Affinity(source, name string) {
+	var parent dslengine.Definition
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.ResourceDefinition, *design.ActionDefinition:
+		parent = def
+	default:
+		dslengine.IncompatibleDSL()
+		return
+	}
+	a := &design.AffinityDefinition{Parent: parent, Source: source, Name: name}
+	switch def := parent.(type) {
+	case *design.ResourceDefinition:
+		def.Affinity = a
+	case *design.ActionDefinition:
+		def.Affinity = a
+	}
+}
@@ -0,0 +1,74 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Affinity", func() {
+	var actionDSL func()
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		actionDSL = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("account", func() {
+			BasePath("/accounts")
+			Affinity("param", "accountID")
+			Action("show", func() {
+				Routing(GET("/:accountID"))
+				Response(OK)
+			})
+			Action("search", func() {
+				Routing(GET(""))
+				if actionDSL != nil {
+					actionDSL()
+				} else {
+					Affinity("header", "X-Tenant-Id")
+				}
+				Response(OK)
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("records the affinities", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		res := Design.Resources["account"]
+		Ω(res.Actions["show"].EffectiveAffinity()).Should(Equal(res.Affinity))
+		Ω(res.Affinity.Source).Should(Equal("param"))
+		Ω(res.Affinity.Name).Should(Equal("accountID"))
+		af := res.Actions["search"].EffectiveAffinity()
+		Ω(af.Source).Should(Equal("header"))
+		Ω(af.Name).Should(Equal("X-Tenant-Id"))
+	})
+
+	Context("with an unknown parameter", func() {
+		BeforeEach(func() {
+			actionDSL = func() {}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`affinity parameter "accountID" is not a parameter of the action`))
+		})
+	})
+
+	Context("with an invalid source", func() {
+		BeforeEach(func() {
+			actionDSL = func() {
+				Affinity("cookie", "session")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`invalid source "cookie"`))
+		})
+	})
+})
@@ -17,6 +17,8 @@ import (
 //		Title("title")				// API title used in documentation
 //		Description("description")		// API description used in documentation
 //		Version("2.0")				// API version being described
+//		VersionHeader("X-API-Version")		// Header used to request the version
+//		VersionMediaType("application/vnd.name")	// Vendor media type used to request the version
 //		TermsOfService("terms")
 //		Contact(func() {			// API Contact information
 //			Name("contact name")
@@ -95,6 +97,51 @@ func Version(ver string) {
 	}
 }
 
+// VersionHeader specifies the name of the request header used by clients to request the version
+// of the API described by the design, e.g.:
+//
+//	API("myapi", func() {
+//		Version("2")
+//		VersionHeader("X-API-Version")
+//	})
+//
+// This makes it possible to serve multiple versions of the API under the same paths: the code
+// generated for each version mounts the controllers on a mux that dispatches the requests to the
+// controllers of the version they specify, see goa.Service.VersionMux. Requests that do not
+// specify a version are handled by the most recent version. VersionHeader may be used together
+// with VersionMediaType.
+func VersionHeader(name string) {
+	if api, ok := apiDefinition(); ok {
+		if name == "" {
+			dslengine.ReportError("version header name cannot be empty")
+			return
+		}
+		api.VersionHeader = name
+	}
+}
+
+// VersionMediaType specifies the vendor media type used by clients to request the version of the
+// API described by the design with the Accept header. The requested version follows the vendor
+// media type with a ".v" separator, e.g. given:
+//
+//	API("myapi", func() {
+//		Version("2")
+//		VersionMediaType("application/vnd.myapi")
+//	})
+//
+// requests with an Accept header value of "application/vnd.myapi.v2+json" are handled by the
+// controllers generated for the design. See VersionHeader for details on how requests are
+// dispatched.
+func VersionMediaType(vendor string) {
+	if api, ok := apiDefinition(); ok {
+		if !strings.Contains(vendor, "/") || strings.ContainsAny(vendor, "+; ") {
+			dslengine.ReportError("invalid version media type %#v, must be of the form \"application/vnd.name\"", vendor)
+			return
+		}
+		api.VersionMediaType = vendor
+	}
+}
+
 // Description sets the definition description.
 // Description can be called inside API, Resource, Action or MediaType.
 func Description(d string) {
@@ -105,6 +152,8 @@ func Description(d string) {
 		def.Description = d
 	case *design.FileServerDefinition:
 		def.Description = d
+	case *design.UploadDefinition:
+		def.Description = d
 	case *design.ActionDefinition:
 		def.Description = d
 	case *design.MediaTypeDefinition:
@@ -117,6 +166,8 @@ func Description(d string) {
 		def.Description = d
 	case *design.SecuritySchemeDefinition:
 		def.Description = d
+	case *design.WebhookDefinition:
+		def.Description = d
 	default:
 		dslengine.IncompatibleDSL()
 	}
@@ -151,6 +202,9 @@ func BasePath(val string) {
 // such as "https://*.mydomain.com". The special value "*" defines the policy for all origins
 // (in which case there should be only one Origin DSL in the parent resource).
 // The origin can also be a regular expression wrapped into "/".
+//
+// Origin may appear in API, Resource or Action. The policies of an action override the resource
+// and API policies defined for the same origin, the policies of a resource override the API ones.
 // Example:
 //
 //        Origin("http://swagger.goa.design", func() { // Define CORS policy, may be prefixed with "*" wildcard
@@ -162,6 +216,14 @@ func BasePath(val string) {
 //        })
 //
 //        Origin("/[api|swagger].goa.design/", func() {}) // Define CORS policy with a regular expression
+//
+//        Origin("https://*.goa.design", func() {
+//                OriginFunc("TenantOrigin")           // Validate matching origins at runtime
+//                Credentials()
+//        })
+//
+// Credentials cannot be allowed for all origins or together with wildcard headers, methods or
+// exposed headers: browsers reject such responses.
 func Origin(origin string, dsl func()) {
 	cors := &design.CORSDefinition{Origin: origin}
 
@@ -187,6 +249,12 @@ func Origin(origin string, dsl func()) {
 			def.Origins = make(map[string]*design.CORSDefinition)
 		}
 		def.Origins[origin] = cors
+	case *design.ActionDefinition:
+		parent = def
+		if def.Origins == nil {
+			def.Origins = make(map[string]*design.CORSDefinition)
+		}
+		def.Origins[origin] = cors
 	default:
 		dslengine.IncompatibleDSL()
 		return
@@ -194,6 +262,28 @@ func Origin(origin string, dsl func()) {
 	cors.Parent = parent
 }
 
+// OriginFunc sets the name of the function that validates the origins matching the policy at
+// runtime, for example to allow the origins of the tenants of a multi-tenant service. The
+// generated app package defines an exported variable of type cors.OriginFunc with the given
+// name, the policy only applies to the requests for which the function returns true. Requests
+// are rejected until the variable is set. Used in Origin DSL.
+//
+//        Origin("*", func() {
+//                OriginFunc("TenantOrigin")
+//                Credentials()
+//        })
+//
+// and in the service main:
+//
+//        app.TenantOrigin = func(origin string, req *http.Request) bool {
+//                return tenants.Allowed(origin)
+//        }
+func OriginFunc(name string) {
+	if cors, ok := corsDefinition(); ok {
+		cors.Func = name
+	}
+}
+
 // Methods sets the origin allowed methods. Used in Origin DSL.
 func Methods(vals ...string) {
 	if cors, ok := corsDefinition(); ok {
@@ -208,10 +298,16 @@ func Expose(vals ...string) {
 	}
 }
 
-// MaxAge sets the cache expiry for preflight request responses. Used in Origin DSL.
+// MaxAge sets the cache expiry for preflight request responses when used in the Origin DSL. It
+// sets the number of seconds the response is fresh when used in the CacheControl DSL.
 func MaxAge(val uint) {
-	if cors, ok := corsDefinition(); ok {
-		cors.MaxAge = val
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.CORSDefinition:
+		def.MaxAge = val
+	case *design.CacheControlDefinition:
+		def.MaxAge = &val
+	default:
+		dslengine.IncompatibleDSL()
 	}
 }
 
@@ -403,11 +499,16 @@ func buildEncodingDefinition(encoding bool, args ...interface{}) *design.Encodin
 	return d
 }
 
-// Package sets the Go package path to the encoder or decoder. It must be used inside a
-// Consumes or Produces DSL.
+// Package sets the Go package path to the encoder or decoder or to the package defining a
+// dependency type. It must be used inside a Consumes, Produces or Dependency DSL.
 func Package(path string) {
-	if e, ok := encodingDefinition(); ok {
-		e.PackagePath = path
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.EncodingDefinition:
+		def.PackagePath = path
+	case *design.DependencyDefinition:
+		def.PackagePath = path
+	default:
+		dslengine.IncompatibleDSL()
 	}
 }
 
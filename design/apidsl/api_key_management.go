@@ -0,0 +1,113 @@
+package apidsl
+
+import "github.com/goadesign/goa/design"
+
+const (
+	// APIKeyMediaIdentifier is the identifier of the media type describing the keys returned
+	// by the API key management resource.
+	APIKeyMediaIdentifier = "application/vnd.goa.api-key"
+
+	// CreatedAPIKeyMediaIdentifier is the identifier of the media type returned when a key is
+	// created, it is the only one that includes the key value.
+	CreatedAPIKeyMediaIdentifier = "application/vnd.goa.created-api-key"
+)
+
+// APIKeyManagement defines an "api_keys" resource that lets the authenticated subject of a request
+// manage their own API keys. The "create" action creates a key and responds with its value which
+// is only displayed once, the "list" action lists the keys of the subject without their values and
+// the "revoke" action revokes a key.
+//
+// APIKeyManagement must appear at the top level (like Resource) and returns the resource
+// definition. The optional DSL is run in the context of the resource and is typically used to
+// define its security:
+//
+//	var _ = APIKeyManagement("/me/keys", func() {
+//		Security(JWT)
+//	})
+//
+// The goagen "main" command generates the implementation of the controller on top of the KeyStore
+// interface of the middleware/security/apikey package. Mount the apikey middleware with the same
+// store so that the keys authenticate the requests made to the actions secured with an
+// APIKeySecurity scheme. Keys are owned by the subject returned by goa.ContextSubject.
+func APIKeyManagement(path string, dsl ...func()) *design.ResourceDefinition {
+	keyAttributes := func() {
+		Attribute("id", design.String, "Unique key identifier")
+		Attribute("name", design.String, "Key name")
+		Attribute("scopes", ArrayOf(design.String), "Scopes granted to the key")
+		Attribute("created_at", design.DateTime, "Key creation timestamp")
+		Attribute("expires_at", design.DateTime, "Key expiration timestamp")
+		Attribute("revoked_at", design.DateTime, "Key revocation timestamp")
+	}
+	media := MediaType(APIKeyMediaIdentifier, func() {
+		Description("API key, the key value is only returned when the key is created")
+		TypeName("GoaAPIKey")
+		Attributes(func() {
+			keyAttributes()
+			Required("id", "name", "scopes", "created_at")
+		})
+		View("default", func() {
+			Attribute("id")
+			Attribute("name")
+			Attribute("scopes")
+			Attribute("created_at")
+			Attribute("expires_at")
+			Attribute("revoked_at")
+		})
+	})
+	created := MediaType(CreatedAPIKeyMediaIdentifier, func() {
+		Description("API key with its value, the value cannot be retrieved later")
+		TypeName("GoaCreatedAPIKey")
+		Attributes(func() {
+			keyAttributes()
+			Attribute("key", design.String, "Key value, send it in the requests to authenticate them")
+			Required("id", "name", "scopes", "created_at", "key")
+		})
+		View("default", func() {
+			Attribute("id")
+			Attribute("name")
+			Attribute("scopes")
+			Attribute("created_at")
+			Attribute("expires_at")
+			Attribute("key")
+		})
+	})
+	return Resource("api_keys", func() {
+		Description("Manage the API keys of the authenticated subject")
+		BasePath(path)
+		Metadata("goa:api_keys")
+		for _, d := range dsl {
+			d()
+		}
+		Action("create", func() {
+			Description("Create an API key, the response contains the key value which cannot be retrieved later")
+			Routing(POST(""))
+			Payload(func() {
+				Attribute("name", design.String, "Key name")
+				Attribute("scopes", ArrayOf(design.String), "Scopes granted to the key")
+				Attribute("expires_in", design.Integer, "Key lifetime in seconds, the key never expires if omitted", func() {
+					Minimum(1)
+				})
+				Required("name")
+			})
+			Response(design.Created, created)
+			Response(design.BadRequest, design.ErrorMedia)
+			Response(design.Unauthorized)
+		})
+		Action("list", func() {
+			Description("List the API keys of the authenticated subject")
+			Routing(GET(""))
+			Response(design.OK, CollectionOf(media))
+			Response(design.Unauthorized)
+		})
+		Action("revoke", func() {
+			Description("Revoke an API key of the authenticated subject")
+			Routing(DELETE("/:keyID"))
+			Params(func() {
+				Param("keyID", design.String, "Key identifier")
+			})
+			Response(design.NoContent)
+			Response(design.Unauthorized)
+			Response(design.NotFound)
+		})
+	})
+}
@@ -0,0 +1,51 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("APIKeyManagement", func() {
+	var res *ResourceDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+	})
+
+	JustBeforeEach(func() {
+		res = APIKeyManagement("/me/keys", func() {
+			Description("My keys")
+		})
+		dslengine.Run()
+	})
+
+	It("defines the API key resource and media types", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(res).ShouldNot(BeNil())
+		Ω(res.Metadata).Should(HaveKey("goa:api_keys"))
+		Ω(res.Description).Should(Equal("My keys"))
+		create := res.Actions["create"]
+		Ω(create).ShouldNot(BeNil())
+		Ω(create.Routes[0].Verb).Should(Equal("POST"))
+		Ω(create.Routes[0].FullPath()).Should(Equal("/me/keys"))
+		Ω(create.Payload.Type.ToObject()).Should(HaveKey("expires_in"))
+		Ω(create.Responses["Created"].MediaType).Should(Equal(CreatedAPIKeyMediaIdentifier))
+		list := res.Actions["list"]
+		Ω(list).ShouldNot(BeNil())
+		Ω(list.Routes[0].Verb).Should(Equal("GET"))
+		revoke := res.Actions["revoke"]
+		Ω(revoke).ShouldNot(BeNil())
+		Ω(revoke.Routes[0].Verb).Should(Equal("DELETE"))
+		Ω(revoke.Routes[0].FullPath()).Should(Equal("/me/keys/:keyID"))
+		Ω(revoke.Responses).Should(HaveKey("NotFound"))
+		mt := Design.MediaTypeWithIdentifier(APIKeyMediaIdentifier)
+		Ω(mt).ShouldNot(BeNil())
+		Ω(mt.Type.ToObject()).ShouldNot(HaveKey("key"))
+		created := Design.MediaTypeWithIdentifier(CreatedAPIKeyMediaIdentifier)
+		Ω(created).ShouldNot(BeNil())
+		Ω(created.Type.ToObject()).Should(HaveKey("key"))
+	})
+})
@@ -56,6 +56,35 @@ var _ = Describe("API", func() {
 		})
 	})
 
+	Context("with a version header but no version", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				VersionHeader("X-API-Version")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("Version must be set"))
+		})
+	})
+
+	Context("with an invalid version media type", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Version("2")
+				VersionMediaType("application/vnd.foo+json")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("invalid version media type"))
+		})
+	})
+
 	Context("with valid DSL", func() {
 		JustBeforeEach(func() {
 			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
@@ -104,6 +133,22 @@ var _ = Describe("API", func() {
 			})
 		})
 
+		Context("with a version negotiated with headers", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Version("2")
+					VersionHeader("X-API-Version")
+					VersionMediaType("application/vnd.foo")
+				}
+			})
+
+			It("sets the API version scheme", func() {
+				Ω(Design.VersionHeader).Should(Equal("X-API-Version"))
+				Ω(Design.VersionMediaType).Should(Equal("application/vnd.foo"))
+				Ω(Design.VersionNegotiated()).Should(BeTrue())
+			})
+		})
+
 		Context("with a terms of service", func() {
 			const terms = "terms"
 
@@ -200,6 +245,26 @@ var _ = Describe("API", func() {
 			})
 		})
 
+		Context("with Dependencies", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Dependency("Tx", "*sql.Tx", func() {
+						Package("database/sql")
+					})
+					Dependency("Tenant", "string")
+				}
+			})
+
+			It("sets the API dependencies", func() {
+				Ω(Design.Dependencies).Should(HaveLen(2))
+				Ω(Design.Dependencies[0].Name).Should(Equal("Tx"))
+				Ω(Design.Dependencies[0].GoType).Should(Equal("*sql.Tx"))
+				Ω(Design.Dependencies[0].PackagePath).Should(Equal("database/sql"))
+				Ω(Design.Dependencies[1].Name).Should(Equal("Tenant"))
+				Ω(Design.Dependencies[1].PackagePath).Should(BeEmpty())
+			})
+		})
+
 		Context("with a BasePath", func() {
 			const basePath = "basePath"
 
@@ -268,7 +268,25 @@ func Default(def interface{}) {
 //	})
 //
 // If you do not want an auto-generated example for an attribute, add NoExample() to it.
+//
+// Example may also be used in a Response DSL to set the example response body used in the
+// generated documentation, for example to show the body of error responses:
+//
+//	Response(NotFound, ErrorMedia, func() {
+//		Example(map[string]interface{}{
+//			"id":     "3F1FKVRR",
+//			"code":   "not_found",
+//			"status": 404,
+//			"detail": "bottle 42 not found",
+//		})
+//	})
+//
+// The value must be compatible with the response body type.
 func Example(exp interface{}) {
+	if r, ok := dslengine.CurrentDefinition().(*design.ResponseDefinition); ok {
+		r.Example = exp
+		return
+	}
 	if a, ok := attributeDefinition(); ok {
 		if pass := a.SetExample(exp); !pass {
 			dslengine.ReportError("example value %#v is incompatible with attribute of type %s",
@@ -326,6 +344,25 @@ func Enum(val ...interface{}) {
 	}
 }
 
+// OpenEnum marks the enum validation of the attribute as open: values that are not listed in the
+// Enum DSL are accepted and preserved instead of being rejected. The enum values are still used to
+// document the attribute and the generated types expose "IsKnown" methods that make it possible to
+// detect unknown values. Open enums make it possible to add enum values to the design without
+// breaking existing clients or servers. Enums are closed by default.
+//
+//	Attribute("status", String, func() {
+//		Enum("pending", "shipped")
+//		OpenEnum()
+//	})
+func OpenEnum() {
+	if a, ok := attributeDefinition(); ok {
+		if a.Validation == nil {
+			a.Validation = &dslengine.ValidationDefinition{}
+		}
+		a.Validation.OpenEnum = true
+	}
+}
+
 // SupportedValidationFormats lists the supported formats for use with the
 // Format DSL.
 var SupportedValidationFormats = []string{
@@ -407,64 +444,77 @@ func Pattern(p string) {
 
 // Minimum adds a "minimum" validation to the attribute.
 // See http://json-schema.org/latest/json-schema-validation.html#anchor21.
+// The value may be any Go integer or float or a string containing a number. Integer values are
+// preserved exactly, including int64 and uint64 values that cannot be represented by a float64.
 func Minimum(val interface{}) {
 	if a, ok := attributeDefinition(); ok {
 		if a.Type != nil && a.Type.Kind() != design.IntegerKind && a.Type.Kind() != design.NumberKind {
 			incompatibleAttributeType("minimum", a.Type.Name(), "an integer or a number")
 		} else {
-			var f float64
-			switch v := val.(type) {
-			case float32, float64, int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-				f = reflect.ValueOf(v).Convert(reflect.TypeOf(float64(0.0))).Float()
-			case string:
-				var err error
-				f, err = strconv.ParseFloat(v, 64)
-				if err != nil {
-					dslengine.ReportError("invalid number value %#v", v)
-					return
-				}
-			default:
-				dslengine.ReportError("invalid number value %#v", v)
+			f, lit, ok := rangeBound(val)
+			if !ok {
 				return
 			}
 			if a.Validation == nil {
 				a.Validation = &dslengine.ValidationDefinition{}
 			}
 			a.Validation.Minimum = &f
+			a.Validation.MinimumLiteral = lit
 		}
 	}
 }
 
 // Maximum adds a "maximum" validation to the attribute.
 // See http://json-schema.org/latest/json-schema-validation.html#anchor17.
+// The value may be any Go integer or float or a string containing a number, see Minimum.
 func Maximum(val interface{}) {
 	if a, ok := attributeDefinition(); ok {
 		if a.Type != nil && a.Type.Kind() != design.IntegerKind && a.Type.Kind() != design.NumberKind {
 			incompatibleAttributeType("maximum", a.Type.Name(), "an integer or a number")
 		} else {
-			var f float64
-			switch v := val.(type) {
-			case float32, float64, int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
-				f = reflect.ValueOf(v).Convert(reflect.TypeOf(float64(0.0))).Float()
-			case string:
-				var err error
-				f, err = strconv.ParseFloat(v, 64)
-				if err != nil {
-					dslengine.ReportError("invalid number value %#v", v)
-					return
-				}
-			default:
-				dslengine.ReportError("invalid number value %#v", v)
+			f, lit, ok := rangeBound(val)
+			if !ok {
 				return
 			}
 			if a.Validation == nil {
 				a.Validation = &dslengine.ValidationDefinition{}
 			}
 			a.Validation.Maximum = &f
+			a.Validation.MaximumLiteral = lit
 		}
 	}
 }
 
+// rangeBound returns the float64 value and the exact decimal representation of the given minimum
+// or maximum value. It reports an error and returns false if the value is not a number.
+func rangeBound(val interface{}) (float64, string, bool) {
+	switch v := val.(type) {
+	case int, int8, int16, int32, int64:
+		i := reflect.ValueOf(v).Int()
+		return float64(i), strconv.FormatInt(i, 10), true
+	case uint, uint8, uint16, uint32, uint64:
+		u := reflect.ValueOf(v).Uint()
+		return float64(u), strconv.FormatUint(u, 10), true
+	case float32, float64:
+		f := reflect.ValueOf(v).Float()
+		return f, strconv.FormatFloat(f, 'g', -1, 64), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			break
+		}
+		if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return f, v, true
+		}
+		if _, err := strconv.ParseUint(v, 10, 64); err == nil {
+			return f, v, true
+		}
+		return f, strconv.FormatFloat(f, 'g', -1, 64), true
+	}
+	dslengine.ReportError("invalid number value %#v", val)
+	return 0, "", false
+}
+
 // MinLength adss a "minItems" validation to the attribute.
 // See http://json-schema.org/latest/json-schema-validation.html#anchor45.
 func MinLength(val int) {
@@ -482,10 +532,11 @@ func MinLength(val int) {
 
 // MaxLength adss a "maxItems" validation to the attribute.
 // See http://json-schema.org/latest/json-schema-validation.html#anchor42.
+// When used on a File attribute MaxLength sets the maximum size of the file in bytes.
 func MaxLength(val int) {
 	if a, ok := attributeDefinition(); ok {
-		if a.Type != nil && a.Type.Kind() != design.StringKind && a.Type.Kind() != design.ArrayKind {
-			incompatibleAttributeType("maximum length", a.Type.Name(), "a string or an array")
+		if a.Type != nil && a.Type.Kind() != design.StringKind && a.Type.Kind() != design.ArrayKind && a.Type.Kind() != design.FileKind {
+			incompatibleAttributeType("maximum length", a.Type.Name(), "a string, an array or a file")
 		} else {
 			if a.Validation == nil {
 				a.Validation = &dslengine.ValidationDefinition{}
@@ -495,6 +546,69 @@ func MaxLength(val int) {
 	}
 }
 
+// Normalization adds a validation that checks that the value of a string attribute is in the given
+// Unicode normalization form: "NFC", "NFD", "NFKC" or "NFKD". Use it together with the "nfc"
+// ParamTransform to normalize parameters instead of rejecting them.
+func Normalization(form string) {
+	if a, ok := attributeDefinition(); ok {
+		if a.Type != nil && a.Type.Kind() != design.StringKind {
+			incompatibleAttributeType("normalization", a.Type.Name(), "a string")
+			return
+		}
+		switch form {
+		case "NFC", "NFD", "NFKC", "NFKD":
+		default:
+			dslengine.ReportError("invalid Unicode normalization form %#v, must be one of NFC, NFD, NFKC or NFKD", form)
+			return
+		}
+		if a.Validation == nil {
+			a.Validation = &dslengine.ValidationDefinition{}
+		}
+		a.Validation.Normalization = form
+	}
+}
+
+// MaxRunes adds a validation that limits the number of Unicode code points of a string attribute.
+// Contrary to MaxLength which limits the number of bytes of the UTF-8 encoded value in the
+// generated code, MaxRunes counts multi-byte characters once.
+func MaxRunes(val int) {
+	if a, ok := attributeDefinition(); ok {
+		if a.Type != nil && a.Type.Kind() != design.StringKind {
+			incompatibleAttributeType("maximum runes", a.Type.Name(), "a string")
+			return
+		}
+		if a.Validation == nil {
+			a.Validation = &dslengine.ValidationDefinition{}
+		}
+		a.Validation.MaxRunes = &val
+	}
+}
+
+// MaxGraphemes adds a validation that limits the number of user-perceived characters of a string
+// attribute: accented letters made of combining marks, flags or emoji sequences count as one
+// character. Use it for user facing fields such as names whose display width matters.
+//
+// Example:
+//
+//    Attribute("display_name", String, func() {
+//        Normalization("NFC")
+//        MaxGraphemes(32)
+//        MaxLength(256) // Bound the number of bytes as well
+//    })
+//
+func MaxGraphemes(val int) {
+	if a, ok := attributeDefinition(); ok {
+		if a.Type != nil && a.Type.Kind() != design.StringKind {
+			incompatibleAttributeType("maximum graphemes", a.Type.Name(), "a string")
+			return
+		}
+		if a.Validation == nil {
+			a.Validation = &dslengine.ValidationDefinition{}
+		}
+		a.Validation.MaxGraphemes = &val
+	}
+}
+
 // Required adds a "required" validation to the attribute.
 // See http://json-schema.org/latest/json-schema-validation.html#anchor61.
 func Required(names ...string) {
@@ -213,6 +213,89 @@ var _ = Describe("Attribute", func() {
 		})
 	})
 
+	Context("with a name and a DSL defining an open enum", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				OpenEnum()
+				Enum("one", "two")
+			}
+		})
+
+		It("produces an attribute with an open enum validation", func() {
+			o := parent.Type.(Object)
+			Ω(o).Should(HaveKey(name))
+			Ω(o[name].Validation).ShouldNot(BeNil())
+			Ω(o[name].Validation.Values).Should(Equal([]interface{}{"one", "two"}))
+			Ω(o[name].Validation.OpenEnum).Should(BeTrue())
+		})
+	})
+
+	Context("with a name and a DSL defining character validations", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Normalization("NFC")
+				MaxRunes(64)
+				MaxGraphemes(32)
+			}
+		})
+
+		It("produces an attribute with the validations", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			o := parent.Type.(Object)
+			Ω(o[name].Validation).ShouldNot(BeNil())
+			Ω(o[name].Validation.Normalization).Should(Equal("NFC"))
+			Ω(*o[name].Validation.MaxRunes).Should(Equal(64))
+			Ω(*o[name].Validation.MaxGraphemes).Should(Equal(32))
+		})
+	})
+
+	Context("with a name and a DSL defining an invalid normalization form", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() { Normalization("NFX") }
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a name, type integer and a DSL defining int64 and uint64 bounds", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dataType = Integer
+			dsl = func() {
+				Minimum(int64(-9007199254740993))
+				Maximum(uint64(18446744073709551615))
+			}
+		})
+
+		It("preserves the exact bounds", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			v := parent.Type.(Object)[name].Validation
+			Ω(v.MinimumLiteral).Should(Equal("-9007199254740993"))
+			Ω(v.MaximumLiteral).Should(Equal("18446744073709551615"))
+			Ω(*v.Minimum).Should(BeNumerically("<", 0))
+		})
+	})
+
+	Context("with a name, type number and a DSL defining a string bound", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dataType = Number
+			dsl = func() { Minimum("0.5") }
+		})
+
+		It("parses the bound", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			v := parent.Type.(Object)[name].Validation
+			Ω(*v.Minimum).Should(Equal(0.5))
+			Ω(v.MinimumLiteral).Should(Equal("0.5"))
+		})
+	})
+
 	Context("with a name, type integer and a DSL defining an enum validation", func() {
 		BeforeEach(func() {
 			name = "foo"
@@ -0,0 +1,120 @@
+package apidsl
+
+import (
+	"strconv"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// BatchResponseMediaIdentifier is the identifier of the media type of the sub-responses returned
+// by the batch resource.
+const BatchResponseMediaIdentifier = "application/vnd.goa.batch-response"
+
+// Batch defines a "batch" resource whose "run" action responds to POST requests made to the given
+// path. The request body is an array of sub-requests, each made of a method, a path, optional
+// headers and an optional JSON body. The response lists the sub-responses in the same order, each
+// with its own status, headers and body or error. The sub-requests must target the actions listed
+// with BatchAction.
+//
+// Batch must appear at the top level (like Resource) and returns the resource definition. The DSL
+// is run in the context of the resource and lists the actions that may be batched, it may also
+// limit the number of sub-requests with MaxBatchSize and define the resource security:
+//
+//	var _ = Batch("/batch", func() {
+//		BatchAction("bottle", "show")
+//		BatchAction("bottle", "list")
+//		MaxBatchSize(20)
+//	})
+//
+// The goagen "main" command generates the implementation of the controller on top of
+// goa.BatchDispatcher: the sub-requests go through the service mux and are handled by the existing
+// controllers, including their middleware, validations and security. The sub-requests inherit the
+// headers of the batch request so that they share its credentials.
+func Batch(path string, dsl ...func()) *design.ResourceDefinition {
+	request := Type("GoaBatchRequest", func() {
+		Description("Sub-request of a batch request")
+		Attribute("id", design.String, "Optional identifier echoed in the sub-response")
+		Attribute("method", design.String, "HTTP method", func() {
+			Enum("GET", "POST", "PUT", "PATCH", "DELETE")
+		})
+		Attribute("path", design.String, "Request path including the query string", func() {
+			Pattern("^/")
+		})
+		Attribute("headers", HashOf(design.String, design.String), "Request headers added to the headers of the batch request")
+		Attribute("body", design.Any, "Request body")
+		Required("method", "path")
+	})
+	media := MediaType(BatchResponseMediaIdentifier, func() {
+		Description("Response to a sub-request of a batch request")
+		Attributes(func() {
+			Attribute("id", design.String, "Identifier of the sub-request")
+			Attribute("status", design.Integer, "HTTP status code")
+			Attribute("headers", HashOf(design.String, design.String), "Response headers")
+			Attribute("body", design.Any, "Response body of successful sub-requests")
+			Attribute("error", design.Any, "Error response of failed sub-requests")
+			Required("status")
+		})
+		View("default", func() {
+			Attribute("id")
+			Attribute("status")
+			Attribute("headers")
+			Attribute("body")
+			Attribute("error")
+		})
+	})
+	return Resource("batch", func() {
+		Description("Run several requests at once")
+		BasePath(path)
+		Metadata("goa:batch")
+		for _, d := range dsl {
+			d()
+		}
+		max := 0
+		if r, ok := resourceDefinition(); ok {
+			if v := r.Metadata["goa:batch:max"]; len(v) > 0 {
+				max, _ = strconv.Atoi(v[len(v)-1])
+			}
+		}
+		Action("run", func() {
+			Description("Run the sub-requests and return their responses in the same order")
+			Routing(POST(""))
+			Payload(ArrayOf(request), func() {
+				if max > 0 {
+					MaxLength(max)
+				}
+			})
+			Response(design.OK, CollectionOf(media))
+			Response(design.BadRequest, design.ErrorMedia)
+		})
+	})
+}
+
+// BatchAction lists an action that may be targeted by the sub-requests of the batch requests.
+// BatchAction may only be used in the Batch DSL.
+func BatchAction(resource, action string) {
+	if r, ok := resourceDefinition(); ok {
+		if _, batch := r.Metadata["goa:batch"]; !batch {
+			dslengine.IncompatibleDSL()
+			return
+		}
+		Metadata("goa:batch", resource+"#"+action)
+	}
+}
+
+// MaxBatchSize sets the maximum number of sub-requests of a batch request, batch requests with
+// more sub-requests are rejected with a 400 response. MaxBatchSize may only be used in the Batch
+// DSL.
+func MaxBatchSize(n int) {
+	if r, ok := resourceDefinition(); ok {
+		if _, batch := r.Metadata["goa:batch"]; !batch {
+			dslengine.IncompatibleDSL()
+			return
+		}
+		if n <= 0 {
+			dslengine.ReportError("maximum batch size must be positive, got %d", n)
+			return
+		}
+		Metadata("goa:batch:max", strconv.Itoa(n))
+	}
+}
@@ -0,0 +1,59 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Batch", func() {
+	var res *ResourceDefinition
+	var action string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		action = "show"
+	})
+
+	JustBeforeEach(func() {
+		Resource("bottle", func() {
+			Action("show", func() {
+				Routing(GET("/bottles/:id"))
+			})
+		})
+		res = Batch("/batch", func() {
+			BatchAction("bottle", action)
+			MaxBatchSize(20)
+		})
+		dslengine.Run()
+	})
+
+	It("defines the batch resource", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(res).ShouldNot(BeNil())
+		Ω(res.Metadata["goa:batch"]).Should(Equal([]string{"bottle#show"}))
+		run := res.Actions["run"]
+		Ω(run).ShouldNot(BeNil())
+		Ω(run.Routes[0].Verb).Should(Equal("POST"))
+		Ω(run.Routes[0].FullPath()).Should(Equal("/batch"))
+		Ω(run.Payload.Type.IsArray()).Should(BeTrue())
+		Ω(*run.Payload.Validation.MaxLength).Should(Equal(20))
+		Ω(run.Responses).Should(HaveKey("OK"))
+		mt := Design.MediaTypeWithIdentifier(BatchResponseMediaIdentifier)
+		Ω(mt).ShouldNot(BeNil())
+		Ω(mt.Type.ToObject()).Should(HaveKey("error"))
+	})
+
+	Context("with an unknown action", func() {
+		BeforeEach(func() {
+			action = "unknown"
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("unknown action"))
+		})
+	})
+})
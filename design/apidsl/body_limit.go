@@ -0,0 +1,40 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// BodyLimit defines the maximum length in bytes of the request bodies of the actions of the API,
+// resource or action in which it is used. Resource body limits override the API one and action
+// body limits override the resource one, actions that have no body limit use the controller
+// MaxRequestBodyLength (1GB by default). Requests whose body exceeds the limit fail with a 413
+// response. The generated controller mounting code calls the controller SetBodyLimit method.
+// Examples:
+//
+//    var _ = API("cellar", func() {
+//        BodyLimit(1 << 20)                       // 1MB for all actions
+//    })
+//
+//    var _ = Resource("bottle", func() {
+//        Action("upload", func() {
+//            BodyLimit(100 << 20)                 // 100MB for uploads
+//        })
+//    })
+//
+func BodyLimit(bytes int64) {
+	if bytes <= 0 {
+		dslengine.ReportError("body limit must be greater than 0, got %d", bytes)
+		return
+	}
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.APIDefinition:
+		def.BodyLimit = bytes
+	case *design.ResourceDefinition:
+		def.BodyLimit = bytes
+	case *design.ActionDefinition:
+		def.BodyLimit = bytes
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
@@ -0,0 +1,74 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BodyLimit", func() {
+	var actionDSL func()
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		actionDSL = nil
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			BodyLimit(1 << 20)
+		})
+		Resource("bottle", func() {
+			BasePath("/bottles")
+			BodyLimit(10 << 20)
+			Action("create", func() {
+				Routing(POST(""))
+				if actionDSL != nil {
+					actionDSL()
+				}
+				Response(Created)
+			})
+		})
+		Resource("account", func() {
+			BasePath("/accounts")
+			Action("create", func() {
+				Routing(POST(""))
+				Response(Created)
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("computes the effective body limits", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(Design.Resources["bottle"].Actions["create"].EffectiveBodyLimit()).Should(Equal(int64(10 << 20)))
+		Ω(Design.Resources["account"].Actions["create"].EffectiveBodyLimit()).Should(Equal(int64(1 << 20)))
+	})
+
+	Context("at the action level", func() {
+		BeforeEach(func() {
+			actionDSL = func() {
+				BodyLimit(100 << 20)
+			}
+		})
+
+		It("overrides the resource body limit", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.Resources["bottle"].Actions["create"].EffectiveBodyLimit()).Should(Equal(int64(100 << 20)))
+		})
+	})
+
+	Context("with an invalid limit", func() {
+		BeforeEach(func() {
+			actionDSL = func() {
+				BodyLimit(0)
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,107 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// CacheControl defines the Cache-Control header set by the generated response method. The DSL
+// lists the header directives: Public, Private, NoCache, NoStore, MustRevalidate, MaxAge,
+// SharedMaxAge and StaleWhileRevalidate. CacheControl must appear in a Response DSL, responses
+// defined on a resource or API apply their cache control to the actions that use them:
+//
+//    Response(OK, func() {
+//        Media(BottleMedia)
+//        CacheControl(func() {
+//            Public()
+//            MaxAge(60)                 // max-age=60
+//            SharedMaxAge(300)          // s-maxage=300
+//            StaleWhileRevalidate(30)   // stale-while-revalidate=30
+//        })
+//        Vary("Accept-Language")
+//        SurrogateKey("bottles")
+//    })
+//
+// The directives are documented as the default value of the Cache-Control response header in the
+// generated Swagger specification.
+func CacheControl(dsl func()) {
+	if r, ok := responseDefinition(); ok {
+		c := &design.CacheControlDefinition{Parent: r}
+		if !dslengine.Execute(dsl, c) {
+			return
+		}
+		r.CacheControl = c
+	}
+}
+
+// Public allows shared caches to store the response. Public must appear in a CacheControl DSL.
+func Public() {
+	if c, ok := cacheControlDefinition(); ok {
+		c.Public = true
+	}
+}
+
+// Private restricts the storage of the response to the client cache. Private must appear in a
+// CacheControl DSL.
+func Private() {
+	if c, ok := cacheControlDefinition(); ok {
+		c.Private = true
+	}
+}
+
+// NoCache requires caches to revalidate the response before using it. NoCache must appear in a
+// CacheControl DSL.
+func NoCache() {
+	if c, ok := cacheControlDefinition(); ok {
+		c.NoCache = true
+	}
+}
+
+// NoStore prevents caches from storing the response. NoStore must appear in a CacheControl DSL.
+func NoStore() {
+	if c, ok := cacheControlDefinition(); ok {
+		c.NoStore = true
+	}
+}
+
+// MustRevalidate prevents caches from using the response once stale. MustRevalidate must appear
+// in a CacheControl DSL.
+func MustRevalidate() {
+	if c, ok := cacheControlDefinition(); ok {
+		c.MustRevalidate = true
+	}
+}
+
+// SharedMaxAge sets the number of seconds the response is fresh in shared caches (s-maxage),
+// overriding MaxAge for these caches. SharedMaxAge must appear in a CacheControl DSL.
+func SharedMaxAge(val uint) {
+	if c, ok := cacheControlDefinition(); ok {
+		c.SharedMaxAge = &val
+	}
+}
+
+// StaleWhileRevalidate sets the number of seconds caches may use the response once stale while
+// they revalidate it in the background. StaleWhileRevalidate must appear in a CacheControl DSL.
+func StaleWhileRevalidate(val uint) {
+	if c, ok := cacheControlDefinition(); ok {
+		c.StaleWhileRevalidate = val
+	}
+}
+
+// Vary lists the request headers the response depends on, the generated response method adds them
+// to the Vary response header. Vary must appear in a Response DSL.
+func Vary(headers ...string) {
+	if r, ok := responseDefinition(); ok {
+		r.Vary = append(r.Vary, headers...)
+	}
+}
+
+// SurrogateKey lists the keys added to the Surrogate-Key response header. CDNs use the keys to
+// purge all the responses tagged with a given key at once. Handlers may add keys specific to the
+// response instance with goa.AddSurrogateKeys before calling the response method. SurrogateKey
+// must appear in a Response DSL.
+func SurrogateKey(keys ...string) {
+	if r, ok := responseDefinition(); ok {
+		r.SurrogateKeys = append(r.SurrogateKeys, keys...)
+	}
+}
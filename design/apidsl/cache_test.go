@@ -0,0 +1,89 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CacheControl", func() {
+	var dsl func()
+	var res *ResponseDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		dsl = nil
+		res = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("res", func() {
+			Action("action", func() {
+				Routing(GET("/"))
+				Response(OK, "text/plain", dsl)
+			})
+		})
+		dslengine.Run()
+		if r, ok := Design.Resources["res"]; ok {
+			if a, ok := r.Actions["action"]; ok {
+				res = a.Responses[OK]
+			}
+		}
+	})
+
+	Context("with directives", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				CacheControl(func() {
+					Public()
+					MustRevalidate()
+					MaxAge(0)
+					SharedMaxAge(300)
+					StaleWhileRevalidate(30)
+				})
+				Vary("Accept-Language", "Accept")
+				SurrogateKey("bottles")
+			}
+		})
+
+		It("sets the response caching headers", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.CacheControl).ShouldNot(BeNil())
+			Ω(res.CacheControl.HeaderValue()).Should(Equal("public, must-revalidate, max-age=0, s-maxage=300, stale-while-revalidate=30"))
+			Ω(res.Vary).Should(Equal([]string{"Accept-Language", "Accept"}))
+			Ω(res.SurrogateKeys).Should(Equal([]string{"bottles"}))
+		})
+	})
+
+	Context("with conflicting directives", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				CacheControl(func() {
+					Public()
+					Private()
+				})
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("used outside of a response", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				CacheControl(func() {
+					Burst(2)
+				})
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,37 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Challenge requires the requests made to the action in which it appears to carry an
+// anti-automation challenge token such as a captcha or Turnstile response. It is typically used
+// on the actions that are attractive to bots: signup, login or password reset. The optional
+// argument is the name of the request header holding the token, it defaults to
+// "X-Challenge-Token".
+//
+// The generated code applies the middleware.RequireChallenge middleware which verifies the token
+// with the ChallengeVerifier variable of the generated app package before the action handler runs
+// and rejects the requests whose token is missing or invalid with a 403 response. Set the variable
+// before mounting the controllers, e.g. to middleware.NewTurnstileVerifier(secret).
+//
+//	Action("signup", func() {
+//		Routing(POST("/signup"))
+//		Challenge()
+//	})
+func Challenge(header ...string) {
+	a, ok := actionDefinition()
+	if !ok {
+		return
+	}
+	if len(header) > 1 {
+		dslengine.ReportError("too many arguments given to Challenge")
+		return
+	}
+	h := "X-Challenge-Token"
+	if len(header) == 1 {
+		h = header[0]
+	}
+	a.Challenge = &design.ChallengeDefinition{Parent: a, Header: h}
+}
@@ -0,0 +1,42 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Challenge", func() {
+	BeforeEach(func() {
+		dslengine.Reset()
+		Resource("account", func() {
+			BasePath("/accounts")
+			Action("signup", func() {
+				Routing(POST(""))
+				Challenge()
+				Response(Created)
+			})
+			Action("reset", func() {
+				Routing(POST("/reset"))
+				Challenge("X-Captcha")
+				Response(NoContent)
+			})
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Response(OK)
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("records the challenge requirements", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		res := Design.Resources["account"]
+		Ω(res.Actions["signup"].Challenge.Header).Should(Equal("X-Challenge-Token"))
+		Ω(res.Actions["reset"].Challenge.Header).Should(Equal("X-Captcha"))
+		Ω(res.Actions["show"].Challenge).Should(BeNil())
+		Ω(Design.HasChallenges()).Should(BeTrue())
+	})
+})
@@ -0,0 +1,41 @@
+package apidsl
+
+import (
+	"regexp"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// classificationRegex matches valid data classifications such as "pii" or "pii:email".
+var classificationRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*(:[a-z0-9_]+)*$`)
+
+// Classification declares the kind of data held by the attribute in which it is used. A
+// classification consists of a category optionally followed by colon separated subcategories,
+// for example "pii:email" or "secret". Classified attributes are:
+//
+// * documented with the "x-classification" extension in the JSON schema and Swagger
+//   specification,
+//
+// * redacted from the validation errors returned by the generated code and from the parameters
+//   and payloads logged by the LogRequest middleware,
+//
+// * listed in the data inventory written by the goagen "governance" command.
+//
+// Example:
+//
+//    var UserPayload = Type("UserPayload", func() {
+//        Attribute("email", String, func() {
+//            Format("email")
+//            Classification("pii:email")
+//        })
+//    })
+//
+func Classification(class string) {
+	if a, ok := attributeDefinition(); ok {
+		if !classificationRegex.MatchString(class) {
+			dslengine.ReportError("invalid classification %#v, must be a lowercase category optionally followed by colon separated subcategories (e.g. \"pii:email\")", class)
+			return
+		}
+		a.Classification = class
+	}
+}
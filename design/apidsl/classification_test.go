@@ -0,0 +1,74 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Classification", func() {
+	var class string
+	var ut *UserTypeDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		class = "pii:email"
+	})
+
+	JustBeforeEach(func() {
+		ut = Type("user", func() {
+			Attribute("email", String, func() {
+				Classification(class)
+			})
+			Attribute("name", String)
+		})
+		dslengine.Run()
+	})
+
+	It("classifies the attribute", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(ut.ToObject()["email"].Classification).Should(Equal("pii:email"))
+		Ω(ut.ToObject()["name"].Classification).Should(BeEmpty())
+	})
+
+	Context("with an invalid classification", func() {
+		BeforeEach(func() {
+			class = "PII email"
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("invalid classification"))
+		})
+	})
+
+	Context("on a media type attribute referencing the type", func() {
+		var mt *MediaTypeDefinition
+
+		JustBeforeEach(func() {
+			dslengine.Reset()
+			Type("user", func() {
+				Attribute("email", String, func() {
+					Classification(class)
+				})
+			})
+			mt = MediaType("application/vnd.user", func() {
+				Reference(Design.Types["user"])
+				Attributes(func() {
+					Attribute("email")
+				})
+				View("default", func() {
+					Attribute("email")
+				})
+			})
+			dslengine.Run()
+		})
+
+		It("inherits the classification", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(mt.ToObject()["email"].Classification).Should(Equal("pii:email"))
+		})
+	})
+})
@@ -0,0 +1,38 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// RequireClientCert requires the requests made to the actions of the resource or to the action in
+// which it appears to be authenticated with a verified TLS client certificate. The optional
+// subjects list the accepted certificate common names or DNS names. The generated code applies the
+// middleware.RequireClientCert middleware which rejects the other requests with a 403 response.
+// Action requirements override the resource one.
+//
+// The certificates are checked once the TLS handshake is done so that the other actions of the
+// service remain available to clients without certificates: start the service with
+// goa.Service.ListenAndServeMutualTLS or on a listener that verifies the client certificates.
+//
+//	Resource("admin", func() {
+//		RequireClientCert("ops.example.com")
+//	})
+//
+func RequireClientCert(subjects ...string) {
+	var parent dslengine.Definition
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.ResourceDefinition, *design.ActionDefinition:
+		parent = def
+	default:
+		dslengine.IncompatibleDSL()
+		return
+	}
+	c := &design.ClientCertDefinition{Parent: parent, Subjects: subjects}
+	switch def := parent.(type) {
+	case *design.ResourceDefinition:
+		def.ClientCert = c
+	case *design.ActionDefinition:
+		def.ClientCert = c
+	}
+}
@@ -0,0 +1,38 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequireClientCert", func() {
+	BeforeEach(func() {
+		dslengine.Reset()
+		Resource("admin", func() {
+			BasePath("/admin")
+			RequireClientCert()
+			Action("show", func() {
+				Routing(GET(""))
+				Response(OK)
+			})
+			Action("purge", func() {
+				Routing(DELETE(""))
+				RequireClientCert("ops.example.com")
+				Response(NoContent)
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("records the client certificate requirements", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		res := Design.Resources["admin"]
+		Ω(res.ClientCert).ShouldNot(BeNil())
+		Ω(res.Actions["show"].EffectiveClientCert()).Should(Equal(res.ClientCert))
+		Ω(res.Actions["show"].EffectiveClientCert().Subjects).Should(BeEmpty())
+		Ω(res.Actions["purge"].EffectiveClientCert().Subjects).Should(Equal([]string{"ops.example.com"}))
+	})
+})
@@ -112,6 +112,56 @@ func corsDefinition() (*design.CORSDefinition, bool) {
 	return cors, ok
 }
 
+// proxyDefinition returns true and current context if it is a ProxyDefinition,
+// nil and false otherwise.
+func proxyDefinition() (*design.ProxyDefinition, bool) {
+	p, ok := dslengine.CurrentDefinition().(*design.ProxyDefinition)
+	if !ok {
+		dslengine.IncompatibleDSL()
+	}
+	return p, ok
+}
+
+// uploadDefinition returns true and current context if it is an UploadDefinition,
+// nil and false otherwise.
+func uploadDefinition() (*design.UploadDefinition, bool) {
+	u, ok := dslengine.CurrentDefinition().(*design.UploadDefinition)
+	if !ok {
+		dslengine.IncompatibleDSL()
+	}
+	return u, ok
+}
+
+// paginationDefinition returns true and current context if it is a PaginationDefinition,
+// nil and false otherwise.
+func paginationDefinition() (*design.PaginationDefinition, bool) {
+	p, ok := dslengine.CurrentDefinition().(*design.PaginationDefinition)
+	if !ok {
+		dslengine.IncompatibleDSL()
+	}
+	return p, ok
+}
+
+// rateLimitDefinition returns true and current context if it is a RateLimitDefinition,
+// nil and false otherwise.
+func rateLimitDefinition() (*design.RateLimitDefinition, bool) {
+	r, ok := dslengine.CurrentDefinition().(*design.RateLimitDefinition)
+	if !ok {
+		dslengine.IncompatibleDSL()
+	}
+	return r, ok
+}
+
+// cacheControlDefinition returns true and current context if it is a CacheControlDefinition,
+// nil and false otherwise.
+func cacheControlDefinition() (*design.CacheControlDefinition, bool) {
+	c, ok := dslengine.CurrentDefinition().(*design.CacheControlDefinition)
+	if !ok {
+		dslengine.IncompatibleDSL()
+	}
+	return c, ok
+}
+
 // actionDefinition returns true and current context if it is an ActionDefinition,
 // nil and false otherwise.
 func actionDefinition() (*design.ActionDefinition, bool) {
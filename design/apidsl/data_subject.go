@@ -0,0 +1,87 @@
+package apidsl
+
+import "github.com/goadesign/goa/design"
+
+// DataRequestMediaIdentifier is the identifier of the media type returned by the data subject
+// resource.
+const DataRequestMediaIdentifier = "application/vnd.goa.data-request"
+
+// DataSubjectRequests defines a "data_subject" resource that lets the authenticated subject of a
+// request export or delete their data, e.g. to comply with the GDPR rights of access and erasure.
+// The resource follows the asynchronous job pattern: the "export" and "delete" actions respond
+// with 202 Accepted and a data request that the subject polls with the "show" action until its
+// status becomes "completed" or "failed". Completed export requests include the URL the data can
+// be downloaded from.
+//
+// DataSubjectRequests must appear at the top level (like Resource) and returns the resource
+// definition. The optional DSL is run in the context of the resource and is typically used to
+// define its security:
+//
+//	var _ = DataSubjectRequests("/me/data", func() {
+//		Security(JWT, func() {
+//			Scope("api:read")
+//		})
+//	})
+//
+// The goagen "main" command generates the implementation of the controller on top of
+// goa.DataSubjectService, only the actual export and deletion are left to implement. Requests are
+// attributed to the subject returned by goa.ContextSubject which the JWT middleware initializes
+// from the "sub" claim of the token.
+func DataSubjectRequests(path string, dsl ...func()) *design.ResourceDefinition {
+	media := MediaType(DataRequestMediaIdentifier, func() {
+		Description("Request made by a data subject to export or delete their data")
+		Attributes(func() {
+			Attribute("id", design.String, "Unique request identifier")
+			Attribute("kind", design.String, "Request kind", func() {
+				Enum("export", "delete")
+			})
+			Attribute("status", design.String, "Request status", func() {
+				Enum("pending", "running", "completed", "failed")
+			})
+			Attribute("created_at", design.DateTime, "Request creation timestamp")
+			Attribute("completed_at", design.DateTime, "Request completion timestamp")
+			Attribute("download_url", design.String, "URL of the exported data, set once an export request completes")
+			Attribute("error", design.String, "Error message of failed requests")
+			Required("id", "kind", "status", "created_at")
+		})
+		View("default", func() {
+			Attribute("id")
+			Attribute("kind")
+			Attribute("status")
+			Attribute("created_at")
+			Attribute("completed_at")
+			Attribute("download_url")
+			Attribute("error")
+		})
+	})
+	return Resource("data_subject", func() {
+		Description("Export or delete the data of the authenticated subject")
+		BasePath(path)
+		Metadata("goa:data_subject")
+		for _, d := range dsl {
+			d()
+		}
+		Action("export", func() {
+			Description("Request an export of the data of the authenticated subject")
+			Routing(POST("/export"))
+			Response(design.Accepted, media)
+			Response(design.Unauthorized)
+		})
+		Action("delete", func() {
+			Description("Request the deletion of the data of the authenticated subject")
+			Routing(POST("/delete"))
+			Response(design.Accepted, media)
+			Response(design.Unauthorized)
+		})
+		Action("show", func() {
+			Description("Retrieve the status of a data request made by the authenticated subject")
+			Routing(GET("/requests/:requestID"))
+			Params(func() {
+				Param("requestID", design.String, "Data request identifier")
+			})
+			Response(design.OK, media)
+			Response(design.Unauthorized)
+			Response(design.NotFound)
+		})
+	})
+}
@@ -0,0 +1,44 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DataSubjectRequests", func() {
+	var res *ResourceDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+	})
+
+	JustBeforeEach(func() {
+		basic := BasicAuthSecurity("basic")
+		res = DataSubjectRequests("/me/data", func() {
+			Security(basic)
+		})
+		dslengine.Run()
+	})
+
+	It("defines the data subject resource and media type", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(res).ShouldNot(BeNil())
+		Ω(res.BasePath).Should(Equal("/me/data"))
+		Ω(res.Metadata).Should(HaveKey("goa:data_subject"))
+		Ω(res.Security).ShouldNot(BeNil())
+		Ω(res.Security.Scheme.SchemeName).Should(Equal("basic"))
+		Ω(res.Actions).Should(HaveLen(3))
+		Ω(res.Actions["export"].Routes[0].FullPath()).Should(Equal("/me/data/export"))
+		Ω(res.Actions["export"].Responses).Should(HaveKey("Accepted"))
+		Ω(res.Actions["delete"].Routes[0].Verb).Should(Equal("POST"))
+		Ω(res.Actions["show"].Routes[0].FullPath()).Should(Equal("/me/data/requests/:requestID"))
+		Ω(res.Actions["show"].Responses).Should(HaveKey("NotFound"))
+		mt := Design.MediaTypeWithIdentifier(DataRequestMediaIdentifier)
+		Ω(mt).ShouldNot(BeNil())
+		Ω(mt.Type.ToObject()).Should(HaveKey("download_url"))
+		Ω(mt.Type.ToObject()["status"].Validation.Values).Should(ContainElement("completed"))
+	})
+})
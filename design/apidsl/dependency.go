@@ -0,0 +1,37 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Dependency declares a request-scoped dependency such as a database transaction, the
+// authenticated user or the tenant. The generated application package exposes typed accessors
+// Set<Name> and Get<Name> that store and retrieve the dependency value in the container created
+// for each request by the middleware.Container middleware. This makes it possible for middleware
+// to pass per-request resources to the controllers without defining ad-hoc context keys. The
+// optional DSL may use Package to specify the import path of the package defining the Go type.
+// Example:
+//
+//    var _ = API("cellar", func() {
+//        Dependency("Tx", "*sql.Tx", func() {
+//            Package("database/sql")
+//        })
+//        Dependency("Tenant", "string")
+//    })
+//
+func Dependency(name, goType string, dsl ...func()) {
+	if a, ok := apiDefinition(); ok {
+		if len(dsl) > 1 {
+			dslengine.ReportError("too many arguments given to Dependency")
+			return
+		}
+		d := &design.DependencyDefinition{Name: name, GoType: goType}
+		if len(dsl) == 1 {
+			if !dslengine.Execute(dsl[0], d) {
+				return
+			}
+		}
+		a.Dependencies = append(a.Dependencies, d)
+	}
+}
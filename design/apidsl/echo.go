@@ -0,0 +1,56 @@
+package apidsl
+
+import "github.com/goadesign/goa/design"
+
+// EchoMediaIdentifier is the identifier of the media type returned by the echo resource.
+const EchoMediaIdentifier = "application/vnd.goa.echo"
+
+// Echo defines a development only "echo" resource whose single action accepts any request made to
+// a path under the given base path and responds with the request method, path, parameters, headers
+// and decoded payload as seen by goa. This is useful to troubleshoot client encoding issues or the
+// behavior of proxies sitting in front of the service. The goagen "main" command generates the
+// implementation of the echo controller so that the resource works out of the box.
+//
+// Echo must appear at the top level (like Resource) and returns the resource definition:
+//
+//	var _ = Echo("/debug/echo")
+//
+// The echo resource exposes the requests it receives verbatim including their headers, make sure
+// to remove it from the design before deploying the service.
+func Echo(path string) *design.ResourceDefinition {
+	media := MediaType(EchoMediaIdentifier, func() {
+		Description("Request as seen by the echo resource")
+		Attributes(func() {
+			Attribute("method", design.String, "Request HTTP method")
+			Attribute("path", design.String, "Request path")
+			Attribute("params", HashOf(design.String, ArrayOf(design.String)), "Request path and query string parameters")
+			Attribute("headers", HashOf(design.String, ArrayOf(design.String)), "Request headers")
+			Attribute("payload", design.Any, "Decoded request body")
+			Required("method", "path")
+		})
+		View("default", func() {
+			Attribute("method")
+			Attribute("path")
+			Attribute("params")
+			Attribute("headers")
+			Attribute("payload")
+		})
+	})
+	return Resource("echo", func() {
+		Description("Development only resource that echoes the requests it receives")
+		BasePath(path)
+		Metadata("goa:echo")
+		Action("echo", func() {
+			Description("Respond with the request as seen by goa")
+			Routing(
+				GET("/*path"),
+				POST("/*path"),
+				PUT("/*path"),
+				PATCH("/*path"),
+				DELETE("/*path"),
+			)
+			OptionalPayload(design.Any)
+			Response(design.OK, media)
+		})
+	})
+}
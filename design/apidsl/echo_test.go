@@ -0,0 +1,41 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Echo", func() {
+	var path string
+	var res *ResourceDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		path = "/debug/echo"
+	})
+
+	JustBeforeEach(func() {
+		res = Echo(path)
+		dslengine.Run()
+	})
+
+	It("defines the echo resource and media type", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(res).ShouldNot(BeNil())
+		Ω(res.BasePath).Should(Equal(path))
+		Ω(res.Metadata).Should(HaveKey("goa:echo"))
+		Ω(res.Actions).Should(HaveKey("echo"))
+		echo := res.Actions["echo"]
+		Ω(echo.Routes).Should(HaveLen(5))
+		Ω(echo.Routes[0].FullPath()).Should(Equal("/debug/echo/*path"))
+		Ω(echo.PayloadOptional).Should(BeTrue())
+		Ω(echo.Responses).Should(HaveKey("OK"))
+		mt := Design.MediaTypeWithIdentifier(EchoMediaIdentifier)
+		Ω(mt).ShouldNot(BeNil())
+		Ω(mt.Type.ToObject()).Should(HaveKey("headers"))
+		Ω(mt.Type.ToObject()).Should(HaveKey("payload"))
+	})
+})
@@ -0,0 +1,59 @@
+package apidsl
+
+import "github.com/goadesign/goa/design"
+
+// HealthMediaIdentifier is the identifier of the media type returned by the health resource.
+const HealthMediaIdentifier = "application/health+json"
+
+// HealthCheck defines a "health" resource whose single unauthenticated "check" action responds to
+// GET requests made to the given path with the health of the service in the format described in
+// https://tools.ietf.org/html/draft-inadarei-api-health-check. The response status is 200 when the
+// service is healthy and 503 otherwise, making the endpoint suitable for liveness and readiness
+// probes. The goagen "main" command generates the implementation of the health controller which
+// runs the checks registered with the healthcheck package default registry.
+//
+// HealthCheck must appear at the top level (like Resource) and returns the resource definition:
+//
+//	var _ = HealthCheck("/healthz")
+func HealthCheck(path string) *design.ResourceDefinition {
+	result := Type("HealthCheckResult", func() {
+		Description("Result of a health check")
+		Attribute("status", design.String, "Check status", func() {
+			Enum("pass", "warn", "fail")
+		})
+		Attribute("time", design.DateTime, "Time the check ran")
+		Attribute("output", design.String, "Error message of failed checks")
+		Required("status", "time")
+	})
+	media := MediaType(HealthMediaIdentifier, func() {
+		Description("Health of the service")
+		TypeName("Health")
+		Attributes(func() {
+			Attribute("status", design.String, "Service health status", func() {
+				Enum("pass", "warn", "fail")
+			})
+			Attribute("version", design.String, "Service version")
+			Attribute("serviceId", design.String, "Unique service identifier")
+			Attribute("checks", HashOf(design.String, ArrayOf(result)), "Results of the checks indexed by check name")
+			Required("status")
+		})
+		View("default", func() {
+			Attribute("status")
+			Attribute("version")
+			Attribute("serviceId")
+			Attribute("checks")
+		})
+	})
+	return Resource("health", func() {
+		Description("Service health")
+		BasePath(path)
+		Metadata("goa:healthcheck")
+		Action("check", func() {
+			Description("Run the health checks of the service")
+			Routing(GET(""))
+			NoSecurity()
+			Response(design.OK, media)
+			Response(design.ServiceUnavailable, media)
+		})
+	})
+}
@@ -0,0 +1,39 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthCheck", func() {
+	var res *ResourceDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+	})
+
+	JustBeforeEach(func() {
+		res = HealthCheck("/healthz")
+		dslengine.Run()
+	})
+
+	It("defines the health resource and media type", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(res).ShouldNot(BeNil())
+		Ω(res.Metadata).Should(HaveKey("goa:healthcheck"))
+		check := res.Actions["check"]
+		Ω(check).ShouldNot(BeNil())
+		Ω(check.Routes[0].Verb).Should(Equal("GET"))
+		Ω(check.Routes[0].FullPath()).Should(Equal("/healthz"))
+		Ω(check.Security).Should(BeNil())
+		Ω(check.Responses).Should(HaveKey("OK"))
+		Ω(check.Responses).Should(HaveKey("ServiceUnavailable"))
+		mt := Design.MediaTypeWithIdentifier(HealthMediaIdentifier)
+		Ω(mt).ShouldNot(BeNil())
+		Ω(mt.TypeName).Should(Equal("Health"))
+		Ω(mt.Type.ToObject()).Should(HaveKey("checks"))
+	})
+})
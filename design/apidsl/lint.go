@@ -0,0 +1,27 @@
+package apidsl
+
+// Lint enables design lint rules: the rules check the design once it has been validated and
+// generation fails with the list of violations if there are any. Lint accepts the names of the
+// rules to enable, calling it without arguments enables all the registered rules. Lint must
+// appear in the API DSL:
+//
+//        var _ = API("cellar", func() {
+//                Lint("error-media", "action-description", "kebab-case-paths")
+//        })
+//
+// The built-in rules are:
+//
+//    - "error-media": 4xx responses must use ErrorMedia
+//    - "action-description": actions must have a description
+//    - "kebab-case-paths": path segments must be kebab-case
+//
+// Custom rules are registered with dslengine.RegisterLintRule.
+func Lint(rules ...string) {
+	if a, ok := apiDefinition(); ok {
+		if len(rules) == 0 {
+			a.LintAll = true
+			return
+		}
+		a.Lint = append(a.Lint, rules...)
+	}
+}
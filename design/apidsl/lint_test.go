@@ -0,0 +1,139 @@
+package apidsl_test
+
+import (
+	"fmt"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lint", func() {
+	var rules []string
+	var path string
+	var dsl func()
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		rules = []string{"error-media", "action-description", "kebab-case-paths"}
+		path = "/wine-bottles"
+		dsl = func() {
+			Description("Show a bottle")
+			Response(NotFound, ErrorMedia)
+		}
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			Lint(rules...)
+		})
+		Resource("bottle", func() {
+			BasePath(path)
+			Action("show", func() {
+				Routing(GET("/:id"))
+				dsl()
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("accepts a design that follows the rules", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(Design.LintRules()).Should(Equal(rules))
+	})
+
+	Context("without rule names", func() {
+		BeforeEach(func() {
+			rules = nil
+		})
+
+		It("enables all the registered rules", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.LintAll).Should(BeTrue())
+			Ω(Design.LintRules()).Should(ContainElement("kebab-case-paths"))
+		})
+	})
+
+	Context("with a violation of a rule that is not enabled", func() {
+		BeforeEach(func() {
+			rules = []string{"error-media"}
+			path = "/wineBottles"
+		})
+
+		It("does not produce an error", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("with a 4xx response not using ErrorMedia", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Description("Show a bottle")
+				Response(NotFound)
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`response "NotFound" of resource "bottle" action "show": 4xx responses must use ErrorMedia, use Response(NotFound, ErrorMedia) (lint rule error-media)`))
+		})
+	})
+
+	Context("with an action missing a description", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Response(NotFound, ErrorMedia)
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`resource "bottle" action "show": missing description, add a Description to the action DSL (lint rule action-description)`))
+		})
+	})
+
+	Context("with a path that is not kebab-case", func() {
+		BeforeEach(func() {
+			path = "/wineBottles"
+		})
+
+		It("produces an error suggesting a kebab-case segment", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`path segment "wineBottles" is not kebab-case, use "wine-bottles" (lint rule kebab-case-paths)`))
+		})
+	})
+
+	Context("with an unknown rule", func() {
+		BeforeEach(func() {
+			rules = []string{"no-such-rule"}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`unknown lint rule "no-such-rule"`))
+		})
+	})
+
+	Context("with a custom rule", func() {
+		BeforeEach(func() {
+			dslengine.RegisterLintRule(&dslengine.LintRule{
+				Name:        "no-show",
+				Description: "actions must not be named show",
+				Check: func(def dslengine.Definition) error {
+					if a, ok := def.(*ActionDefinition); ok && a.Name == "show" {
+						return fmt.Errorf("rename the action")
+					}
+					return nil
+				},
+			})
+			rules = []string{"no-show"}
+		})
+
+		It("runs the rule", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`resource "bottle" action "show": rename the action (lint rule no-show)`))
+		})
+	})
+})
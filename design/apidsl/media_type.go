@@ -5,6 +5,7 @@ import (
 	"mime"
 	"strings"
 
+	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
 )
@@ -213,6 +214,150 @@ func ContentType(typ string) {
 	}
 }
 
+// Storage marks the media type as persisted in the database table with the given name. The
+// optional primaryKey arguments list the names of the media type attributes that make up the
+// table primary key, the attribute named "id" is used if none is given. The information is stored
+// in the media type "storage:table" and "storage:pk" metadata and is used by the goagen "storage"
+// command to generate the corresponding model structs and repository interfaces. The column
+// name of an attribute may be overridden with the "storage:column" attribute metadata.
+//
+//    var BottleMedia = MediaType("application/vnd.goa.example.bottle", func() {
+//        Storage("bottles", "id")
+//        Attributes(func() {
+//            Attribute("id", Integer)
+//            Attribute("name", String, func() {
+//                Metadata("storage:column", "bottle_name")
+//            })
+//        })
+//        View("default", func() {
+//            Attribute("id")
+//            Attribute("name")
+//        })
+//    })
+//
+func Storage(table string, primaryKey ...string) {
+	if mt, ok := mediaTypeDefinition(); ok {
+		if mt.Metadata == nil {
+			mt.Metadata = make(map[string][]string)
+		}
+		mt.Metadata["storage:table"] = []string{table}
+		if len(primaryKey) > 0 {
+			mt.Metadata["storage:pk"] = primaryKey
+		}
+	}
+}
+
+// ETag lists the media type attributes that identify a version of the entity, typically an
+// "updated_at" timestamp or a "version" counter. The generated media type struct exposes an ETag
+// method that computes the entity tag from the values of these attributes and the response
+// helpers of actions returning the media type with status code 200 set the ETag header and
+// respond with 304 Not Modified when the request If-None-Match header matches. Views that do not
+// render all the attributes do not produce entity tags. The attributes must be of primitive
+// types.
+//
+//    var BottleMedia = MediaType("application/vnd.goa.example.bottle", func() {
+//        ETag("id", "updated_at")
+//        Attributes(func() {
+//            Attribute("id", Integer)
+//            Attribute("updated_at", DateTime)
+//        })
+//        View("default", func() {
+//            Attribute("id")
+//            Attribute("updated_at")
+//        })
+//    })
+//
+func ETag(attributes ...string) {
+	if mt, ok := mediaTypeDefinition(); ok {
+		if len(attributes) == 0 {
+			dslengine.ReportError("ETag requires at least one attribute name")
+			return
+		}
+		mt.ETagAttributes = attributes
+	}
+}
+
+// WeakETag is like ETag but produces weak entity tags. Use weak entity tags when the attributes
+// identify a version of the entity whose representations may differ, for example because they
+// are encoded differently. Weak entity tags never match the If-Match headers of mutating
+// requests.
+func WeakETag(attributes ...string) {
+	if mt, ok := mediaTypeDefinition(); ok {
+		if len(attributes) == 0 {
+			dslengine.ReportError("WeakETag requires at least one attribute name")
+			return
+		}
+		mt.ETagAttributes = attributes
+		mt.WeakETag = true
+	}
+}
+
+// LastModified sets the name of the DateTime attribute that holds the last modification time of
+// the media type instances. The generated media type struct exposes a LastModified method that
+// returns the attribute value and the response helpers of actions returning the media type with
+// status code 200 set the Last-Modified header and respond with 304 Not Modified when the request
+// If-Modified-Since header is not older.
+//
+// The contexts of the actions with PUT, PATCH or DELETE routes of resources whose default media
+// type defines an entity tag or a last modification time expose a CheckPreconditions method that
+// evaluates the request If-Match and If-Unmodified-Since headers against the current state of
+// the resource and returns an error with status code 412 Precondition Failed if they don't match:
+//
+//    var BottleMedia = MediaType("application/vnd.goa.example.bottle", func() {
+//        ETag("id", "updated_at")
+//        LastModified("updated_at")
+//        Attributes(func() {
+//            Attribute("id", Integer)
+//            Attribute("updated_at", DateTime)
+//        })
+//        View("default", func() {
+//            Attribute("id")
+//            Attribute("updated_at")
+//        })
+//    })
+//
+func LastModified(attribute string) {
+	if mt, ok := mediaTypeDefinition(); ok {
+		mt.LastModifiedAttribute = attribute
+	}
+}
+
+// Incompressible declares that the representations of the media type do not compress well,
+// typically because they are already compressed (images, archives etc.). The generated controller
+// mount functions register the media types of the action responses declared incompressible with
+// the service so that the compression middleware writes these responses as is. Incompressible may
+// appear in a MediaType or a Response DSL, the latter is useful for responses whose media type
+// identifier does not correspond to a media type definition:
+//
+//    var ExportMedia = MediaType("application/zip", func() {
+//        Incompressible()
+//        Attributes(func() {
+//            Attribute("data", String)
+//        })
+//        View("default", func() {
+//            Attribute("data")
+//        })
+//    })
+//
+//    Action("thumbnail", func() {
+//        Routing(GET("/:id/thumbnail"))
+//        Response(OK, "image/png", func() {
+//            Incompressible()
+//            Stream()
+//        })
+//    })
+//
+func Incompressible() {
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.MediaTypeDefinition:
+		def.Incompressible = true
+	case *design.ResponseDefinition:
+		def.Incompressible = true
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
+
 // View adds a new view to a media type. A view has a name and lists attributes that are
 // rendered when the view is used to produce a response. The attribute names must appear in the
 // media type definition. If an attribute is itself a media type then the view may specify which
@@ -421,3 +566,62 @@ func CollectionOf(v interface{}, apidsl ...func()) *design.MediaTypeDefinition {
 	design.GeneratedMediaTypes[canonical] = mt
 	return mt
 }
+
+// StreamOf creates a stream media type from its element media type. A stream media type
+// represents the content of responses that stream a sequence of resources as newline delimited
+// JSON (application/x-ndjson) such as log tailing or export actions. Like CollectionOf it can be
+// called from any place where a media type can be used. The responses that use a stream media
+// type are streamed as if defined with Stream("ndjson"): the generated response methods accept a
+// channel or an iterator of the element media type and write each item as a line of JSON.
+//
+//	Action("export", func() {
+//		Routing(GET("/export"))
+//		Response(OK, StreamOf(BottleMedia))
+//	})
+//
+// The resulting media type identifier is built from the element media type by setting the media
+// type parameter "type" to "stream".
+func StreamOf(v interface{}, apidsl ...func()) *design.MediaTypeDefinition {
+	m, ok := v.(*design.MediaTypeDefinition)
+	if !ok {
+		if id, ok := v.(string); ok {
+			m = design.Design.MediaTypes[design.CanonicalIdentifier(id)]
+		}
+	}
+	if m == nil {
+		dslengine.ReportError("invalid StreamOf argument: not a media type and not a known media type identifier")
+		// don't return nil to avoid panics, the error will get reported at the end
+		return design.NewMediaTypeDefinition("InvalidStream", "text/plain", nil)
+	}
+	mediatype, params, err := mime.ParseMediaType(m.Identifier)
+	if err != nil {
+		dslengine.ReportError("invalid media type identifier %#v: %s", m.Identifier, err)
+		// don't return nil to avoid panics, the error will get reported at the end
+		return design.NewMediaTypeDefinition("InvalidStream", "text/plain", nil)
+	}
+	params["type"] = "stream"
+	id := mime.FormatMediaType(mediatype, params)
+	canonical := design.CanonicalIdentifier(id)
+	if mt, ok := design.GeneratedMediaTypes[canonical]; ok {
+		return mt
+	}
+	mt := design.NewMediaTypeDefinition("", id, func() {
+		if mt, ok := mediaTypeDefinition(); ok {
+			mt.TypeName = m.TypeName + "Stream"
+			mt.ContentType = goa.NDJSONContentType
+			mt.AttributeDefinition = &design.AttributeDefinition{Type: ArrayOf(m)}
+			if len(apidsl) > 0 {
+				dslengine.Execute(apidsl[0], mt)
+			}
+			if mt.Views == nil {
+				mt.Views = make(map[string]*design.ViewDefinition)
+				for n, v := range m.Views {
+					mt.Views[n] = v
+				}
+			}
+		}
+	})
+	mt.Streamed = true
+	design.GeneratedMediaTypes[canonical] = mt
+	return mt
+}
@@ -180,6 +180,139 @@ var _ = Describe("MediaType", func() {
 		})
 	})
 
+	Context("with an entity tag", func() {
+		var etag []string
+
+		BeforeEach(func() {
+			name = "application/foo"
+			etag = []string{"id", "updated_at"}
+			dslFunc = func() {
+				ETag(etag...)
+				Attributes(func() {
+					Attribute("id", Integer)
+					Attribute("updated_at", DateTime)
+					Attribute("tags", ArrayOf(String))
+				})
+				View("default", func() {
+					Attribute("id")
+					Attribute("updated_at")
+				})
+				View("tiny", func() {
+					Attribute("id")
+				})
+			}
+		})
+
+		It("sets the entity tag attributes", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(mt.ETagAttributes).Should(Equal([]string{"id", "updated_at"}))
+		})
+
+		It("keeps the entity tag of the views that render the attributes", func() {
+			p, _, err := mt.Project("default")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(p.ETagAttributes).Should(Equal([]string{"id", "updated_at"}))
+			p, _, err = mt.Project("tiny")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(p.ETagAttributes).Should(BeEmpty())
+		})
+
+		Context("using an unknown attribute", func() {
+			BeforeEach(func() {
+				etag = []string{"version"}
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+			})
+		})
+
+		Context("using a non primitive attribute", func() {
+			BeforeEach(func() {
+				etag = []string{"tags"}
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("with a weak entity tag and a last modification time", func() {
+		var lastModified string
+
+		BeforeEach(func() {
+			ProjectedMediaTypes = make(MediaTypeRoot)
+			name = "application/foo"
+			lastModified = "updated_at"
+			dslFunc = func() {
+				WeakETag("id")
+				LastModified(lastModified)
+				Attributes(func() {
+					Attribute("id", Integer)
+					Attribute("updated_at", DateTime)
+				})
+				View("default", func() {
+					Attribute("id")
+					Attribute("updated_at")
+				})
+				View("tiny", func() {
+					Attribute("id")
+				})
+			}
+		})
+
+		It("sets the entity tag and last modification time attributes", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(mt.ETagAttributes).Should(Equal([]string{"id"}))
+			Ω(mt.WeakETag).Should(BeTrue())
+			Ω(mt.LastModifiedAttribute).Should(Equal("updated_at"))
+		})
+
+		It("keeps the last modification time of the views that render the attribute", func() {
+			p, _, err := mt.Project("default")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(p.WeakETag).Should(BeTrue())
+			Ω(p.LastModifiedAttribute).Should(Equal("updated_at"))
+			p, _, err = mt.Project("tiny")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(p.LastModifiedAttribute).Should(BeEmpty())
+		})
+
+		Context("using a non DateTime attribute", func() {
+			BeforeEach(func() {
+				lastModified = "id"
+			})
+
+			It("produces an error", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("declared incompressible", func() {
+		BeforeEach(func() {
+			name = "application/zip"
+			dslFunc = func() {
+				Incompressible()
+				Attributes(func() {
+					Attribute("data", String)
+				})
+				View("default", func() {
+					Attribute("data")
+				})
+			}
+		})
+
+		It("sets the media type and its projections as incompressible", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(mt.Incompressible).Should(BeTrue())
+			p, _, err := mt.Project("default")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(p.Incompressible).Should(BeTrue())
+		})
+	})
+
 	Context("with views", func() {
 		const viewName = "view"
 		const viewAtt = "att"
@@ -796,3 +929,40 @@ var _ = Describe("Example", func() {
 		})
 	})
 })
+
+var _ = Describe("StreamOf", func() {
+	var stream *MediaTypeDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		mt := MediaType("application/vnd.example", func() {
+			Attribute("id")
+			View("default", func() {
+				Attribute("id")
+			})
+		})
+		stream = StreamOf(mt)
+		Resource("example", func() {
+			Action("export", func() {
+				Routing(GET("/export"))
+				Response(OK, stream)
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("produces a stream media type", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(stream.Identifier).Should(Equal("application/vnd.example; type=stream"))
+		Ω(stream.TypeName).Should(Equal("ExampleStream"))
+		Ω(stream.ContentType).Should(Equal("application/x-ndjson"))
+		Ω(stream.Streamed).Should(BeTrue())
+		Ω(stream.Type.IsArray()).Should(BeTrue())
+		Ω(stream.Views).Should(HaveKey("default"))
+	})
+
+	It("streams the responses that use it", func() {
+		resp := Design.Resources["example"].Actions["export"].Responses["OK"]
+		Ω(resp.Stream).Should(Equal("ndjson"))
+	})
+})
@@ -39,6 +39,73 @@ import (
 //
 //        Metadata("swagger:summary", "Short summary of what action does")
 //
+// `storage:column`: overrides the name of the database column generated by the goagen "storage"
+// command for the attribute, see Storage.
+// Applicable to media type attributes only.
+//
+//        Metadata("storage:column", "bottle_name")
+//
+// `validation:failfast`: makes the generated code stop validating requests at the first error
+// instead of reporting all the violations found in the parameters, headers and payload in a single
+// response. Applicable to API, resources and actions.
+//
+//        Metadata("validation:failfast")
+//
+// `validation:strict_content_type`: makes the generated code reject requests whose Content-Type
+// header is not one of the MIME types listed in the API Consumes definitions with a 415
+// Unsupported Media Type response instead of attempting to decode their bodies. Applicable to API,
+// resources and actions.
+//
+//        Metadata("validation:strict_content_type")
+//
+// `validation:warn`: makes the validations defined on the attribute produce warnings instead of
+// errors. Requests that violate the validations are not rejected, instead the generated code logs
+// the violations and reports them to the client via "Warning" response headers. This is useful to
+// tighten the validations of an existing API without breaking clients immediately. Applicable to
+// attributes, parameters and headers. Type errors (e.g. a non integer value given to an integer
+// parameter) are always reported as errors.
+//
+//        Metadata("validation:warn")
+//
+// `localizable`: declares that the action responses depend on the request Accept-Language header.
+// The generated code sets the "Vary: Accept-Language" response header, handlers and the service
+// error translator may retrieve the locales requested by the client using the Locale middleware.
+// Applicable to API, resources and actions.
+//
+//        Metadata("localizable")
+//
+// `stream:write_timeout` and `stream:buffer`: make the generated action context expose a Stream
+// method that returns a goa.StreamWriter configured with the given write timeout (a Go duration)
+// and maximum number of buffered bytes. The stream writer applies backpressure to the handler and
+// aborts the response when the client does not keep up so that a slow consumer cannot pin server
+// memory. Applicable to API, resources and actions.
+//
+//        Metadata("stream:write_timeout", "10s")
+//        Metadata("stream:buffer", "65536")
+//
+// `stream:sse`: marks the action response as a stream of server sent events (text/event-stream).
+// The generated client exposes a method that returns a channel of events decoded into the OK
+// response media type and reconnects with backoff when the connection is lost, resuming after
+// the last event received using the Last-Event-ID header. Applicable to actions only.
+//
+//        Metadata("stream:sse")
+//
+// `loadtest:weight`: sets the relative weight of the action in the load test scenarios generated by
+// the goagen "loadtest" command. Actions default to a weight of 1, a weight of 0 excludes the action
+// from the scenarios.
+// Applicable to actions only.
+//
+//        Metadata("loadtest:weight", "5")
+//
+// `deprecated` and `deprecated:sunset`: mark the action or attribute as deprecated. The value of
+// "deprecated" explains why and what replaces it, "deprecated:sunset" is the date (YYYY-MM-DD)
+// after which the action or attribute is removed. Deprecated actions are flagged in the Swagger
+// specification and checked by the goagen "governance" report.
+// Applicable to actions and attributes.
+//
+//        Metadata("deprecated", "use the show action instead")
+//        Metadata("deprecated:sunset", "2017-06-30")
+//
 // The special key names listed above may be used as follows:
 //
 //        var Account = Type("Account", func() {
@@ -80,6 +147,12 @@ func Metadata(name string, value ...string) {
 		}
 		def.Metadata[name] = append(def.Metadata[name], value...)
 
+	case *design.UploadDefinition:
+		if def.Metadata == nil {
+			def.Metadata = make(map[string][]string)
+		}
+		def.Metadata[name] = append(def.Metadata[name], value...)
+
 	case *design.ResourceDefinition:
 		if def.Metadata == nil {
 			def.Metadata = make(map[string][]string)
@@ -0,0 +1,30 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Middleware declares the middleware that wraps the handlers of a resource or action. The names
+// refer to middleware registered with the service RegisterMiddleware method at setup time. The
+// generated controller mounting code applies the middleware in the order given, resource
+// middleware first followed by action middleware. The names are also listed in the generated
+// Swagger specification via the "x-middleware" extension. Examples:
+//
+//    Resource("bottle", func() {
+//        Middleware("ratelimit")      // Applies to all the bottle actions
+//        Action("delete", func() {
+//            Middleware("audit")      // Applies to the delete action after "ratelimit"
+//        })
+//    })
+//
+func Middleware(names ...string) {
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.ActionDefinition:
+		def.Middleware = append(def.Middleware, names...)
+	case *design.ResourceDefinition:
+		def.Middleware = append(def.Middleware, names...)
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
@@ -0,0 +1,132 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Origin", func() {
+	var dsl func()
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("bottle", func() {
+			BasePath("/bottles")
+			Origin("http://swagger.goa.design", func() {
+				Methods("GET")
+				MaxAge(600)
+			})
+			Action("list", func() {
+				Routing(GET(""))
+				Response(OK)
+			})
+			Action("delete", func() {
+				Routing(DELETE("/:id"))
+				if dsl != nil {
+					dsl()
+				}
+				Response(NoContent)
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("in an action", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Origin("http://swagger.goa.design", func() {
+					Methods("DELETE")
+					MaxAge(60)
+				})
+				Origin("https://*.goa.design", func() {
+					OriginFunc("TenantOrigin")
+					Credentials()
+				})
+			}
+		})
+
+		It("overrides the resource policies", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			res := Design.Resources["bottle"]
+			Ω(res.Actions["list"].AllOrigins()).Should(Equal(res.AllOrigins()))
+			origins := res.Actions["delete"].AllOrigins()
+			Ω(origins).Should(HaveLen(2))
+			Ω(origins[0].Methods).Should(Equal([]string{"DELETE"}))
+			Ω(origins[0].MaxAge).Should(Equal(uint(60)))
+			Ω(origins[1].Func).Should(Equal("TenantOrigin"))
+			Ω(Design.OriginFuncs()).Should(Equal([]string{"TenantOrigin"}))
+		})
+
+		It("excludes the action paths from the resource preflight paths", func() {
+			Ω(Design.Resources["bottle"].PreflightPaths()).Should(Equal([]string{"/bottles"}))
+		})
+	})
+
+	Context("allowing credentials for all origins", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Origin("*", func() {
+					Credentials()
+				})
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("cannot allow credentials for all origins"))
+		})
+	})
+
+	Context("allowing credentials for all origins validated by a function", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Origin("*", func() {
+					OriginFunc("TenantOrigin")
+					Credentials()
+				})
+			}
+		})
+
+		It("does not produce an error", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("allowing credentials with wildcard headers", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Origin("http://swagger.goa.design", func() {
+					Headers("*")
+					Credentials()
+				})
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("cannot use wildcard headers with credentials"))
+		})
+	})
+
+	Context("with an invalid origin function name", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Origin("http://swagger.goa.design", func() {
+					OriginFunc("tenant origin")
+				})
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("invalid origin function name"))
+		})
+	})
+})
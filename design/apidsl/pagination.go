@@ -0,0 +1,105 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// DefaultPerPage is the default number of items per page of paginated actions.
+const DefaultPerPage = 20
+
+// Paginate declares that the action lists items one page at a time. style is either "page" for
+// pagination based on page numbers or "cursor" for pagination based on opaque cursors. Paginate
+// adds the "page" (or "cursor") and "per_page" query string parameters to the action unless they
+// are already defined. The generated context exposes the parsed parameters via its Pagination
+// method and the OK response helper writes the Link header pointing to the first, previous, next
+// and last pages as well as the X-Total-Count header. The optional DSL may use PerPage to set the
+// default and maximum number of items per page. Example:
+//
+//	Action("list", func() {
+//		Routing(GET(""))
+//		Paginate("page", func() {
+//			PerPage(25, 100)
+//		})
+//		Response(OK, CollectionOf(BottleMedia))
+//	})
+//
+// The action implementation sets the total number of items (or the next cursor) before sending
+// the response:
+//
+//	p := ctx.Pagination()
+//	bottles, total := db.ListBottles(p.Offset(), p.PerPage)
+//	p.Total = total
+//	return ctx.OK(bottles)
+//
+func Paginate(style string, dsl ...func()) {
+	a, ok := actionDefinition()
+	if !ok {
+		return
+	}
+	p := &design.PaginationDefinition{Parent: a, DefaultPerPage: DefaultPerPage}
+	switch style {
+	case "page":
+	case "cursor":
+		p.Cursor = true
+	default:
+		dslengine.ReportError(`invalid pagination style %#v, must be "page" or "cursor"`, style)
+		return
+	}
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to Paginate")
+		return
+	}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], p) {
+			return
+		}
+	}
+	a.Pagination = p
+
+	one := 1.0
+	perPage := &design.AttributeDefinition{
+		Type:         design.Integer,
+		Description:  "Number of items per page",
+		DefaultValue: p.DefaultPerPage,
+		Validation:   &dslengine.ValidationDefinition{Minimum: &one},
+	}
+	if p.MaxPerPage > 0 {
+		max := float64(p.MaxPerPage)
+		perPage.Validation.Maximum = &max
+	}
+	params := design.Object{goa.PerPageParam: perPage}
+	if p.Cursor {
+		params[goa.CursorParam] = &design.AttributeDefinition{
+			Type:        design.String,
+			Description: "Cursor of the requested page, omit to request the first page",
+		}
+	} else {
+		params[goa.PageParam] = &design.AttributeDefinition{
+			Type:         design.Integer,
+			Description:  "Requested page number",
+			DefaultValue: 1,
+			Validation:   &dslengine.ValidationDefinition{Minimum: &one},
+		}
+	}
+	if a.Params == nil {
+		a.Params = newAttribute(a.Parent.MediaType)
+		a.Params.Type = make(design.Object)
+	}
+	existing := a.Params.Type.ToObject()
+	for n, att := range params {
+		if _, ok := existing[n]; !ok {
+			existing[n] = att
+		}
+	}
+}
+
+// PerPage sets the default and maximum number of items per page of a paginated action, a maximum
+// of zero means no maximum. PerPage must appear in a Paginate DSL.
+func PerPage(def, max int) {
+	if p, ok := paginationDefinition(); ok {
+		p.DefaultPerPage = def
+		p.MaxPerPage = max
+	}
+}
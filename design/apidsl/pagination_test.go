@@ -0,0 +1,102 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Paginate", func() {
+	var style string
+	var dsl func()
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		style = "page"
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("res", func() {
+			Action("list", func() {
+				Routing(GET(""))
+				if dsl == nil {
+					Paginate(style)
+				} else {
+					Paginate(style, dsl)
+				}
+			})
+		})
+		dslengine.Run()
+		action = Design.Resources["res"].Actions["list"]
+	})
+
+	It("adds the page and per_page params", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(action.Pagination).ShouldNot(BeNil())
+		Ω(action.Pagination.Cursor).Should(BeFalse())
+		Ω(action.Pagination.DefaultPerPage).Should(Equal(DefaultPerPage))
+		params := action.Params.Type.ToObject()
+		Ω(params).Should(HaveKey("page"))
+		Ω(params).Should(HaveKey("per_page"))
+		Ω(params["page"].Type).Should(Equal(Integer))
+		Ω(params["per_page"].DefaultValue).Should(Equal(DefaultPerPage))
+	})
+
+	Context("with the cursor style", func() {
+		BeforeEach(func() {
+			style = "cursor"
+		})
+
+		It("adds the cursor and per_page params", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action.Pagination.Cursor).Should(BeTrue())
+			params := action.Params.Type.ToObject()
+			Ω(params).Should(HaveKey("cursor"))
+			Ω(params).ShouldNot(HaveKey("page"))
+			Ω(params["cursor"].Type).Should(Equal(String))
+		})
+	})
+
+	Context("with PerPage", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				PerPage(25, 100)
+			}
+		})
+
+		It("sets the default and maximum", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action.Pagination.DefaultPerPage).Should(Equal(25))
+			Ω(action.Pagination.MaxPerPage).Should(Equal(100))
+			perPage := action.Params.Type.ToObject()["per_page"]
+			Ω(perPage.DefaultValue).Should(Equal(25))
+			Ω(*perPage.Validation.Maximum).Should(Equal(100.0))
+		})
+	})
+
+	Context("with a default greater than the maximum", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				PerPage(50, 10)
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an invalid style", func() {
+		BeforeEach(func() {
+			style = "offset"
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,76 @@
+package apidsl
+
+import (
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// SupportedParamTransforms lists the transforms that may be used with the ParamTransform DSL.
+// Append the names of custom transforms registered with goa.RegisterParamTransform to make them
+// available to the DSL.
+var SupportedParamTransforms = []string{
+	"lowercase",
+	"uppercase",
+	"trim",
+	"nfc",
+	"ulid",
+}
+
+// ParamTransform canonicalizes the value of the string parameter or header in which it is used
+// before the value is validated and given to the action handler. The transforms are applied in
+// order, the built-in transforms are:
+//
+// * "lowercase" and "uppercase" change the case of the value,
+//
+// * "trim" removes the leading and trailing white space,
+//
+// * "nfc" applies the Unicode Normalization Form C,
+//
+// * "ulid" converts a ULID to its canonical uppercase Crockford base32 form.
+//
+// The transformed value also replaces the raw value in the request params or headers so that
+// middlewares that key caches or access control decisions on the params see the canonical value.
+// ParamTransform may only be used in the Params and Headers DSLs, the attribute must be a string
+// or an array of strings.
+//
+// Example:
+//
+//    Action("show", func() {
+//        Routing(GET("/:slug"))
+//        Params(func() {
+//            Param("slug", String, func() {
+//                ParamTransform("trim", "nfc", "lowercase")
+//                Pattern("^[a-z0-9-]+$")
+//            })
+//        })
+//    })
+//
+func ParamTransform(names ...string) {
+	a, ok := attributeDefinition()
+	if !ok {
+		return
+	}
+	if a.Type != nil && a.Type.Kind() != design.StringKind {
+		if !a.Type.IsArray() || a.Type.ToArray().ElemType.Type.Kind() != design.StringKind {
+			incompatibleAttributeType("param transform", a.Type.Name(), "a string or an array of strings")
+			return
+		}
+	}
+	for _, n := range names {
+		supported := false
+		for _, s := range SupportedParamTransforms {
+			if s == n {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			dslengine.ReportError("unsupported param transform %#v, supported transforms are: %s",
+				n, strings.Join(SupportedParamTransforms, ", "))
+			return
+		}
+	}
+	a.Transforms = append(a.Transforms, names...)
+}
@@ -0,0 +1,71 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParamTransform", func() {
+	var paramType DataType
+	var transforms []string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		paramType = String
+		transforms = []string{"trim", "lowercase"}
+	})
+
+	JustBeforeEach(func() {
+		Resource("bottle", func() {
+			Action("show", func() {
+				Routing(GET("/:slug"))
+				Params(func() {
+					Param("slug", paramType, func() {
+						ParamTransform(transforms...)
+					})
+				})
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("sets the attribute transforms", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		slug := Design.Resources["bottle"].Actions["show"].Params.Type.ToObject()["slug"]
+		Ω(slug.Transforms).Should(Equal([]string{"trim", "lowercase"}))
+	})
+
+	Context("on an array of strings", func() {
+		BeforeEach(func() {
+			paramType = ArrayOf(String)
+		})
+
+		It("sets the attribute transforms", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("on a non string attribute", func() {
+		BeforeEach(func() {
+			paramType = Integer
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an unsupported transform", func() {
+		BeforeEach(func() {
+			transforms = []string{"rot13"}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("unsupported param transform"))
+		})
+	})
+})
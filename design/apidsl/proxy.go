@@ -0,0 +1,103 @@
+package apidsl
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Proxy declares that the resource actions are handled by an upstream service. The generated
+// controller mounting code forwards the requests made to the actions to the upstream service
+// appending the request path to the given base URL instead of calling the controller. This makes
+// it possible to put a designed API in front of an existing service and migrate the actions one at
+// a time. The optional DSL may set the upstream request timeout, rewrite headers and require the
+// upstream responses to be validated against the action response definitions. Example:
+//
+//	var _ = Resource("legacy", func() {
+//		BasePath("/legacy")
+//		Proxy("http://legacy.internal:8080", func() {
+//			Timeout("5s")                           // Upstream request timeout
+//			SetHeader("X-Forwarded-Proto", "https") // Header set on upstream requests
+//			RemoveHeader("Cookie")                  // Header not forwarded upstream
+//			ValidateResponse()                      // Validate upstream responses
+//		})
+//		Action("show", func() {
+//			Routing(GET("/:id"))
+//			Response(OK, LegacyMedia)
+//		})
+//	})
+//
+func Proxy(url string, dsl ...func()) {
+	if r, ok := resourceDefinition(); ok {
+		p := &design.ProxyDefinition{Parent: r, URL: url}
+		if len(dsl) > 1 {
+			dslengine.ReportError("too many arguments given to Proxy")
+			return
+		}
+		if len(dsl) == 1 {
+			if !dslengine.Execute(dsl[0], p) {
+				return
+			}
+		}
+		r.Proxy = p
+	}
+}
+
+// Timeout sets the maximum duration of upstream requests, e.g. "10s". Used in Proxy DSL.
+func Timeout(d string) {
+	if p, ok := proxyDefinition(); ok {
+		timeout, err := time.ParseDuration(d)
+		if err != nil {
+			dslengine.ReportError("invalid timeout %#v: %s", d, err)
+			return
+		}
+		p.Timeout = timeout
+	}
+}
+
+// SetHeader sets a header on the upstream requests when used in Proxy DSL, overriding the value
+// sent by the client if any. SetHeader sets a response header overriding the profile value when
+// used in SecurityHeaders DSL.
+func SetHeader(name, value string) {
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.ProxyDefinition:
+		if def.SetHeaders == nil {
+			def.SetHeaders = make(map[string]string)
+		}
+		def.SetHeaders[name] = value
+	case *design.SecurityHeadersDefinition:
+		if def.Headers == nil {
+			def.Headers = make(map[string]string)
+		}
+		def.Headers[name] = value
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
+
+// RemoveHeader prevents the client header with the given name from being forwarded upstream when
+// used in Proxy DSL. RemoveHeader prevents the profile header with the given name from being set
+// when used in SecurityHeaders DSL.
+func RemoveHeader(name string) {
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.ProxyDefinition:
+		def.RemoveHeaders = append(def.RemoveHeaders, name)
+	case *design.SecurityHeadersDefinition:
+		if def.Headers == nil {
+			def.Headers = make(map[string]string)
+		}
+		def.Headers[name] = ""
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
+
+// ValidateResponse requires the upstream responses to be validated against the action OK response
+// media type before being written back to the client. Invalid responses are replaced with a 502
+// Bad Gateway error response. Used in Proxy DSL.
+func ValidateResponse() {
+	if p, ok := proxyDefinition(); ok {
+		p.ValidateResponse = true
+	}
+}
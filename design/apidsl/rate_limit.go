@@ -0,0 +1,84 @@
+package apidsl
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// RateLimit defines the number of requests clients may make to the actions of the API, resource or
+// action in which it is used. Resource rate limits override the API one and action rate limits
+// override the resource one. Clients are identified by their IP address unless the optional DSL
+// uses KeyBy, the optional DSL may also use Burst to allow short bursts of requests. The generated
+// controller mounting code applies the middleware.RateLimiter middleware using the store set in
+// the generated RateLimitStore variable. Examples:
+//
+//    var _ = API("cellar", func() {
+//        RateLimit(100, time.Minute)              // 100 requests per minute per IP address
+//    })
+//
+//    var _ = Resource("bottle", func() {
+//        RateLimit(10, time.Second, func() {
+//            Burst(20)
+//            KeyBy("apikey", "X-Api-Key")
+//        })
+//    })
+//
+func RateLimit(requests int, interval time.Duration, dsl ...func()) {
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to RateLimit")
+		return
+	}
+	var parent dslengine.Definition
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.APIDefinition, *design.ResourceDefinition, *design.ActionDefinition:
+		parent = def
+	default:
+		dslengine.IncompatibleDSL()
+		return
+	}
+	r := &design.RateLimitDefinition{Parent: parent, Requests: requests, Interval: interval, KeyBy: "ip"}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], r) {
+			return
+		}
+	}
+	switch def := parent.(type) {
+	case *design.APIDefinition:
+		def.RateLimit = r
+	case *design.ResourceDefinition:
+		def.RateLimit = r
+	case *design.ActionDefinition:
+		def.RateLimit = r
+	}
+}
+
+// Burst sets the maximum number of requests a client may make at once, it defaults to the number
+// of requests allowed per interval. Burst must appear in a RateLimit DSL.
+func Burst(n int) {
+	if r, ok := rateLimitDefinition(); ok {
+		r.Burst = n
+	}
+}
+
+// KeyBy sets how the clients are rate limited: "ip" limits the requests per client IP address,
+// "user" per value of the Authorization header and "apikey" per value of the header whose name is
+// given as second argument. KeyBy must appear in a RateLimit DSL:
+//
+//    RateLimit(1000, time.Hour, func() {
+//        KeyBy("apikey", "X-Api-Key")
+//    })
+//
+func KeyBy(key string, header ...string) {
+	if r, ok := rateLimitDefinition(); ok {
+		if len(header) > 1 {
+			dslengine.ReportError("too many arguments given to KeyBy")
+			return
+		}
+		r.KeyBy = key
+		if len(header) == 1 {
+			r.KeyHeader = header[0]
+		}
+	}
+}
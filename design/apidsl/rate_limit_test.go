@@ -0,0 +1,111 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimit", func() {
+	var actionDSL func()
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		actionDSL = nil
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			RateLimit(100, time.Minute)
+		})
+		Resource("bottle", func() {
+			BasePath("/bottles")
+			RateLimit(10, time.Second, func() {
+				Burst(20)
+				KeyBy("apikey", "X-Api-Key")
+			})
+			Action("show", func() {
+				Routing(GET("/:id"))
+				if actionDSL != nil {
+					actionDSL()
+				}
+				Response(OK)
+			})
+		})
+		Resource("account", func() {
+			BasePath("/accounts")
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Response(OK)
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("records the rate limits", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(Design.HasRateLimits()).Should(BeTrue())
+		r := Design.Resources["bottle"].RateLimit
+		Ω(r).ShouldNot(BeNil())
+		Ω(r.Requests).Should(Equal(10))
+		Ω(r.Interval).Should(Equal(time.Second))
+		Ω(r.Burst).Should(Equal(20))
+		Ω(r.KeyBy).Should(Equal("apikey"))
+		Ω(r.KeyHeader).Should(Equal("X-Api-Key"))
+	})
+
+	It("computes the effective rate limits", func() {
+		show := Design.Resources["bottle"].Actions["show"]
+		Ω(show.EffectiveRateLimit()).Should(Equal(Design.Resources["bottle"].RateLimit))
+		show = Design.Resources["account"].Actions["show"]
+		Ω(show.EffectiveRateLimit()).Should(Equal(Design.RateLimit))
+		Ω(show.EffectiveRateLimit().KeyBy).Should(Equal("ip"))
+	})
+
+	Context("at the action level", func() {
+		BeforeEach(func() {
+			actionDSL = func() {
+				RateLimit(1, time.Second, func() {
+					KeyBy("user")
+				})
+			}
+		})
+
+		It("overrides the resource rate limit", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			r := Design.Resources["bottle"].Actions["show"].EffectiveRateLimit()
+			Ω(r.Requests).Should(Equal(1))
+			Ω(r.KeyBy).Should(Equal("user"))
+		})
+	})
+
+	Context("with an API key but no header", func() {
+		BeforeEach(func() {
+			actionDSL = func() {
+				RateLimit(1, time.Second, func() {
+					KeyBy("apikey")
+				})
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an invalid interval", func() {
+		BeforeEach(func() {
+			actionDSL = func() {
+				RateLimit(1, 0)
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
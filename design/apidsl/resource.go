@@ -1,6 +1,8 @@
 package apidsl
 
 import (
+	"strings"
+
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
 )
@@ -113,6 +115,42 @@ func Parent(p string) {
 	}
 }
 
+// MountAt exposes the resource actions under an additional base path so that the same resource
+// may be shared by multiple parents without duplicating its definition. The location is either the
+// name of a parent resource, in which case the resource base path is appended to the parent
+// canonical path, or a base path that replaces the resource base path. Base paths are relative to
+// the API base path unless they start with "//". Each relative action route gets an additional
+// route for each mount and a distinct href function named after the mount is generated. Example:
+//
+//	var _ = Resource("attachment", func() {
+//		Parent("post")
+//		BasePath("/attachments")
+//		MountAt("Comment", "comment")            // Also exposed under the comment resource
+//		MountAt("Draft", "/drafts/:draftID/files") // and under the drafts prefix
+//		Action("show", func() {
+//			Routing(GET("/:attachmentID"))
+//		})
+//	})
+//
+func MountAt(name, location string) {
+	if r, ok := resourceDefinition(); ok {
+		m := &design.MountDefinition{Name: name, Resource: r}
+		if strings.HasPrefix(location, "/") {
+			m.BasePath = location
+		} else {
+			m.ParentName = location
+		}
+		r.Mounts = append(r.Mounts, m)
+		for _, a := range r.Actions {
+			for _, ro := range a.Routes {
+				if ro.Mount == nil && !ro.IsAbsolute() {
+					a.Routes = append(a.Routes, m.Route(ro))
+				}
+			}
+		}
+	}
+}
+
 // CanonicalActionName sets the name of the action used to compute the resource collection and
 // resource collection items hrefs. See Resource.
 func CanonicalActionName(a string) {
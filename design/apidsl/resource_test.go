@@ -302,4 +302,49 @@ var _ = Describe("Resource", func() {
 			Ω(res.Description).Should(Equal(description))
 		})
 	})
+
+	Context("with mounts", func() {
+		BeforeEach(func() {
+			name = "attachment"
+			Resource("post", func() {
+				BasePath("/posts")
+				Action("show", func() { Routing(GET("/:postID")) })
+			})
+			dsl = func() {
+				BasePath("/attachments")
+				MountAt("Post", "post")
+				Action("show", func() { Routing(GET("/:id")) })
+				MountAt("Draft", "/drafts/:draftID/files")
+			}
+		})
+
+		It("adds a route per mount", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(res.Mounts).Should(HaveLen(2))
+			routes := res.Actions["show"].Routes
+			Ω(routes).Should(HaveLen(3))
+			Ω(routes[0].FullPath()).Should(Equal("/attachments/:id"))
+			Ω(routes[1].FullPath()).Should(Equal("/posts/:postID/attachments/:id"))
+			Ω(routes[2].FullPath()).Should(Equal("/drafts/:draftID/files/:id"))
+		})
+
+		It("computes the mount canonical routes", func() {
+			Ω(res.Mount("Post").CanonicalRoute()).Should(Equal(res.Actions["show"].Routes[1]))
+			Ω(res.Mount("Draft").CanonicalRoute()).Should(Equal(res.Actions["show"].Routes[2]))
+		})
+	})
+
+	Context("with a mount under an unknown parent", func() {
+		BeforeEach(func() {
+			name = "attachment"
+			dsl = func() {
+				MountAt("Post", "post")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`Parent resource named "post" not found`))
+		})
+	})
 })
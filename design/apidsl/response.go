@@ -37,6 +37,13 @@ import (
 //                Status(201)                     // Set response status (overrides template's)
 //        })
 //
+//        Response(BadRequest, ErrorMedia, func() {
+//                Example(map[string]interface{}{ // Set the example body used in the docs
+//                        "code":   "bad_request",
+//                        "status": 400,
+//                })
+//        })
+//
 //        Response("MyResponse", func() {         // Define custom response (using no template)
 //                Description("This is my response")
 //                Media(BottleMedia)
@@ -118,6 +125,55 @@ func Status(status int) {
 	}
 }
 
+// Stream makes the generated response method stream the response body to the client instead of
+// encoding a fully materialized value so that actions returning large payloads do not have to
+// hold them in memory. The optional format argument is one of:
+//
+// "bytes" (default): the response method accepts an io.Reader and copies its content to the
+// client. The Content-Type header is set to the response media type, application/octet-stream if
+// there is none.
+//
+// "ndjson": the response method accepts a channel of the response type items (the collection
+// element type if the response type is an array) and writes each item received on the channel as
+// a line of JSON (application/x-ndjson). The method returns once the channel is closed or the
+// request context is done.
+//
+//	Action("export", func() {
+//		Routing(GET("/export"))
+//		Response(OK, BottleMedia, func() {
+//			Stream("ndjson")
+//		})
+//	})
+//
+func Stream(format ...string) {
+	if r, ok := responseDefinition(); ok {
+		if len(format) > 1 {
+			dslengine.ReportError("too many arguments given to Stream")
+			return
+		}
+		r.Stream = "bytes"
+		if len(format) > 0 {
+			r.Stream = format[0]
+		}
+	}
+}
+
+// RequireResponses enforces response coverage on all the API actions: each action must define at
+// least one success response (status code lower than 400) and one error response. Actions that
+// require authentication (see Security) must also define the Unauthorized and Forbidden
+// responses. The check runs once the action responses have been merged with the responses
+// defined on the parent resource and API. RequireResponses must appear in the API DSL:
+//
+//        var _ = API("cellar", func() {
+//                RequireResponses()
+//        })
+//
+func RequireResponses() {
+	if a, ok := apiDefinition(); ok {
+		a.RequireResponses = true
+	}
+}
+
 func executeResponseDSL(name string, paramsAndDSL ...interface{}) *design.ResponseDefinition {
 	var params []string
 	var dsl func()
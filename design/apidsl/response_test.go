@@ -177,6 +177,94 @@ var _ = Describe("Response", func() {
 		})
 	})
 
+	Context("with an example", func() {
+		var example interface{}
+
+		BeforeEach(func() {
+			name = "NotFound"
+			dt = ErrorMedia
+			example = map[string]interface{}{"code": "not_found", "status": 404}
+			dsl = func() {
+				Example(example)
+			}
+		})
+
+		It("sets the example", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).ShouldNot(HaveOccurred())
+			Ω(res.Status).Should(Equal(404))
+			Ω(res.Example).Should(Equal(example))
+		})
+
+		Context("that is incompatible with the body type", func() {
+			BeforeEach(func() {
+				example = "not found"
+			})
+
+			It("produces an invalid response definition", func() {
+				Ω(res).ShouldNot(BeNil())
+				Ω(res.Validate()).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("with a stream", func() {
+		BeforeEach(func() {
+			name = "OK"
+			dsl = func() {
+				Stream()
+			}
+		})
+
+		It("streams bytes", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).ShouldNot(HaveOccurred())
+			Ω(res.Stream).Should(Equal("bytes"))
+		})
+
+		Context("of NDJSON items", func() {
+			BeforeEach(func() {
+				dt = ErrorMedia
+				dsl = func() {
+					Stream("ndjson")
+				}
+			})
+
+			It("streams NDJSON", func() {
+				Ω(res).ShouldNot(BeNil())
+				Ω(res.Validate()).ShouldNot(HaveOccurred())
+				Ω(res.Stream).Should(Equal("ndjson"))
+			})
+		})
+
+		Context("of NDJSON items with no body type", func() {
+			BeforeEach(func() {
+				name = "NoContent"
+				dsl = func() {
+					Stream("ndjson")
+				}
+			})
+
+			It("produces an invalid response definition", func() {
+				Ω(res).ShouldNot(BeNil())
+				Ω(res.Validate()).Should(HaveOccurred())
+			})
+		})
+
+		Context("with an invalid format", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Stream("xml")
+				}
+			})
+
+			It("produces an invalid response definition", func() {
+				Ω(res).ShouldNot(BeNil())
+				Ω(res.Validate()).Should(HaveOccurred())
+			})
+		})
+	})
+
 	Context("not from the goa default definitions", func() {
 		BeforeEach(func() {
 			name = "foo"
@@ -198,3 +286,108 @@ var _ = Describe("Response", func() {
 	})
 
 })
+
+var _ = Describe("RequireResponses", func() {
+	var dsl, resDSL func()
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		dsl = nil
+		resDSL = nil
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			RequireResponses()
+			BasicAuthSecurity("basic")
+		})
+		Resource("res", func() {
+			if resDSL != nil {
+				resDSL()
+			}
+			Action("action", func() {
+				Routing(GET("/"))
+				if dsl != nil {
+					dsl()
+				}
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("sets the API flag", func() {
+		Ω(Design.RequireResponses).Should(BeTrue())
+	})
+
+	Context("with an action missing an error response", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Response(OK)
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("at least one error response"))
+		})
+	})
+
+	Context("with an action missing a success response", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Response(NotFound)
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("at least one success response"))
+		})
+	})
+
+	Context("with a secured action missing the Forbidden response", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Security("basic")
+				Response(OK)
+				Response(Unauthorized)
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("Forbidden (403)"))
+			Ω(dslengine.Errors.Error()).ShouldNot(ContainSubstring("Unauthorized (401)"))
+		})
+	})
+
+	Context("with a secured action defining all the required responses", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Security("basic")
+				Response(OK)
+				Response(Unauthorized)
+				Response(Forbidden)
+			}
+		})
+
+		It("does not report errors", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("with responses inherited from the resource", func() {
+		BeforeEach(func() {
+			resDSL = func() {
+				Response(BadRequest)
+			}
+			dsl = func() {
+				Response(NoContent)
+			}
+		})
+
+		It("does not report errors", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		})
+	})
+})
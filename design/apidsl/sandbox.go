@@ -0,0 +1,25 @@
+package apidsl
+
+// Sandbox generates handlers that can send the design example responses instead of calling the
+// controllers. This makes it possible for API consumers to integrate against realistic responses
+// before the backend is implemented. The sandbox mode is enabled at runtime by setting the
+// service Sandbox field:
+//
+//        var _ = API("cellar", func() {
+//                Sandbox()
+//        })
+//
+//        service := goa.New("cellar")
+//        service.Sandbox = os.Getenv("SANDBOX") != ""
+//
+// In sandbox mode requests are still authenticated and validated, the handlers then send one of
+// the action responses with an example body generated from the response media type or set with
+// the response Example DSL. Clients select the response with the X-Sandbox-Response header by
+// name or status code (e.g. "NotFound" or "404") and the media type view with the
+// X-Sandbox-View header. The response defaults to the success response with the lowest status
+// and the view to "default".
+func Sandbox() {
+	if a, ok := apiDefinition(); ok {
+		a.Sandbox = true
+	}
+}
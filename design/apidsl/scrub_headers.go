@@ -0,0 +1,33 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// ScrubHeaders removes the response headers that are not declared in the design from the
+// responses of the API, resource or action in which it is used. This prevents internal headers
+// set by handlers or downstream services from leaking to clients. The headers declared in the
+// action responses, the security headers (see SecurityHeaders) and the standard headers listed in
+// middleware.DefaultAllowedHeaders are always kept, the optional names list additional headers
+// that must be kept. Example:
+//
+//    var _ = API("cellar", func() {
+//        ScrubHeaders("X-Request-Id")         // Keep X-Request-Id in addition to declared headers
+//    })
+//
+func ScrubHeaders(allowed ...string) {
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.APIDefinition:
+		def.ScrubHeaders = true
+		def.AllowedHeaders = append(def.AllowedHeaders, allowed...)
+	case *design.ResourceDefinition:
+		def.ScrubHeaders = true
+		def.AllowedHeaders = append(def.AllowedHeaders, allowed...)
+	case *design.ActionDefinition:
+		def.ScrubHeaders = true
+		def.AllowedHeaders = append(def.AllowedHeaders, allowed...)
+	default:
+		dslengine.IncompatibleDSL()
+	}
+}
@@ -0,0 +1,81 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ScrubHeaders", func() {
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		API("test", func() {
+			ScrubHeaders("x-request-id")
+		})
+		Resource("bottle", func() {
+			SecurityHeaders("basic")
+			Action("show", func() {
+				Routing(GET("/bottles"))
+				Response(OK, func() {
+					Headers(func() {
+						Header("X-Rate-Limit")
+					})
+				})
+			})
+		})
+		dslengine.Run()
+		action = Design.Resources["bottle"].Actions["show"]
+	})
+
+	It("allows the declared and security headers", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(action.ScrubsHeaders()).Should(BeTrue())
+		Ω(action.AllowedResponseHeaders()).Should(Equal([]string{
+			"Referrer-Policy",
+			"X-Content-Type-Options",
+			"X-Frame-Options",
+			"X-Rate-Limit",
+			"X-Request-Id",
+		}))
+	})
+})
+
+var _ = Describe("ScrubHeaders with rate limited and paginated actions", func() {
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		API("test", func() {
+			ScrubHeaders()
+		})
+		Resource("bottle", func() {
+			BasePath("/bottles")
+			RateLimit(10, time.Second)
+			Action("list", func() {
+				Routing(GET(""))
+				Paginate("page")
+				Response(OK)
+			})
+		})
+		dslengine.Run()
+		action = Design.Resources["bottle"].Actions["list"]
+	})
+
+	It("allows the rate limit and pagination headers", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(action.AllowedResponseHeaders()).Should(Equal([]string{
+			"Link",
+			"Retry-After",
+			"X-Ratelimit-Limit",
+			"X-Ratelimit-Remaining",
+			"X-Ratelimit-Reset",
+			"X-Total-Count",
+		}))
+	})
+})
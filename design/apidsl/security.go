@@ -52,6 +52,8 @@ func Security(scheme interface{}, dsl ...func()) {
 		parent.Security = def
 	case *design.FileServerDefinition:
 		parent.Security = def
+	case *design.UploadDefinition:
+		parent.Security = def
 	case *design.ResourceDefinition:
 		parent.Security = def
 	case *design.APIDefinition:
@@ -75,6 +77,8 @@ func NoSecurity() {
 		parent.Security = def
 	case *design.FileServerDefinition:
 		parent.Security = def
+	case *design.UploadDefinition:
+		parent.Security = def
 	case *design.ResourceDefinition:
 		parent.Security = def
 	default:
@@ -225,6 +229,7 @@ func OAuth2Security(name string, dsl ...func()) *design.SecuritySchemeDefinition
 //    JWTSecurity("jwt", func() {
 //        Header("Authorization")
 //        TokenURL("https://example.com/token")
+//        ScopesClaim("scp")
 //        Scope("my_system:write", "Write to the system")
 //        Scope("my_system:read", "Read anything in there")
 //    })
@@ -379,3 +384,18 @@ func TokenURL(tokenURL string) {
 	}
 	dslengine.IncompatibleDSL()
 }
+
+// ScopesClaim sets the name of the JWT claim that lists the scopes granted to the token, the
+// default is "scopes". The claim value may be a list of strings or a single string of space
+// separated scopes. The JWT middleware checks that the claim contains the scopes required by the
+// action (see Security) and fails the request with a 403 error listing the missing scopes
+// otherwise. Use within a JWTSecurity definition.
+func ScopesClaim(name string) {
+	if parent, ok := dslengine.CurrentDefinition().(*design.SecuritySchemeDefinition); ok {
+		if parent.Kind == design.JWTSecurityKind {
+			parent.ScopesClaim = name
+			return
+		}
+	}
+	dslengine.IncompatibleDSL()
+}
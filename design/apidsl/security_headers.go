@@ -0,0 +1,54 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// SecurityHeaders defines the security headers set on the responses of the API, resource or action
+// in which it is used. The profile is the name of one of the predefined sets of headers listed in
+// middleware.SecurityHeaderProfiles ("basic" or "strict"), it may be empty in which case only the
+// headers set in the optional DSL apply. Resource security headers override the API ones and
+// action security headers override the resource ones. The optional DSL may use SetHeader to set
+// or override a header and RemoveHeader to prevent a profile header from being set. The generated
+// controller mounting code applies the middleware.SecurityHeaders middleware. Examples:
+//
+//    var _ = API("cellar", func() {
+//        SecurityHeaders("strict")                // All responses use the strict profile
+//    })
+//
+//    var _ = Resource("docs", func() {
+//        SecurityHeaders("strict", func() {
+//            SetHeader("Content-Security-Policy", "default-src 'self'")
+//            RemoveHeader("X-Frame-Options")
+//        })
+//    })
+//
+func SecurityHeaders(profile string, dsl ...func()) {
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to SecurityHeaders")
+		return
+	}
+	var parent dslengine.Definition
+	switch def := dslengine.CurrentDefinition().(type) {
+	case *design.APIDefinition, *design.ResourceDefinition, *design.ActionDefinition:
+		parent = def
+	default:
+		dslengine.IncompatibleDSL()
+		return
+	}
+	s := &design.SecurityHeadersDefinition{Parent: parent, Profile: profile}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], s) {
+			return
+		}
+	}
+	switch def := parent.(type) {
+	case *design.APIDefinition:
+		def.SecurityHeaders = s
+	case *design.ResourceDefinition:
+		def.SecurityHeaders = s
+	case *design.ActionDefinition:
+		def.SecurityHeaders = s
+	}
+}
@@ -0,0 +1,60 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SecurityHeaders", func() {
+	var profile string
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		profile = "basic"
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			SecurityHeaders(profile)
+		})
+		Resource("docs", func() {
+			SecurityHeaders("", func() {
+				SetHeader("Content-Security-Policy", "default-src 'self'")
+			})
+			Action("show", func() {
+				Routing(GET("/docs"))
+				SecurityHeaders("strict", func() {
+					RemoveHeader("X-Frame-Options")
+				})
+			})
+		})
+		dslengine.Run()
+		action = Design.Resources["docs"].Actions["show"]
+	})
+
+	It("overrides the API and resource security headers", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		s := action.EffectiveSecurityHeaders()
+		Ω(s).ShouldNot(BeNil())
+		Ω(s.Profile).Should(Equal("strict"))
+		Ω(s.Headers).Should(Equal(map[string]string{
+			"Content-Security-Policy": "default-src 'self'",
+			"X-Frame-Options":         "",
+		}))
+	})
+
+	Context("with an unknown profile", func() {
+		BeforeEach(func() {
+			profile = "unknown"
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`unknown profile "unknown"`))
+		})
+	})
+})
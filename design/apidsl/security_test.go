@@ -44,6 +44,7 @@ var _ = Describe("Security", func() {
 				Description("desc")
 				Header("Authorization")
 				TokenURL("/token")
+				ScopesClaim("scp")
 				Scope("user:read", "Read users")
 				Scope("user:write", "Write users")
 			})
@@ -69,6 +70,7 @@ var _ = Describe("Security", func() {
 		Ω(Design.SecuritySchemes[3].Kind).Should(Equal(JWTSecurityKind))
 		Ω(Design.SecuritySchemes[3].TokenURL).Should(Equal("http://example.com/token"))
 		Ω(Design.SecuritySchemes[3].Scopes).Should(HaveLen(2))
+		Ω(Design.SecuritySchemes[3].ScopesClaim).Should(Equal("scp"))
 	})
 
 	Context("with basic security", func() {
@@ -117,6 +119,17 @@ var _ = Describe("Security", func() {
 			Ω(dslengine.Errors).Should(HaveOccurred())
 		})
 
+		It("should fail because of invalid declaration of ScopesClaim", func() {
+			API("", func() {
+				BasicAuthSecurity("broken_basic_authz", func() {
+					Description("desc")
+					ScopesClaim("scp")
+				})
+			})
+			dslengine.Run()
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+
 		It("should fail because of invalid declaration of Header", func() {
 			API("", func() {
 				BasicAuthSecurity("broken_basic_authz", func() {
@@ -0,0 +1,101 @@
+package apidsl
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// ResumableUpload defines endpoints that implement the tus resumable upload protocol (see
+// http://tus.io/protocols/resumable-upload.html) under the given request path. Clients create
+// uploads with POST requests sent to the path, retrieve the upload offset with HEAD requests and
+// append to the upload with PATCH requests sent to the upload location. The generated controller
+// interface includes a method named after the upload that returns the goa.UploadStore backing
+// the upload. The optional DSL may set a description, the maximum upload size, a security scheme
+// and metadata. Example:
+//
+//	var _ = Resource("videos", func() {
+//		ResumableUpload("video", "/videos/uploads", func() {
+//			Description("Upload large video files")
+//			MaxUploadSize(10 << 30) // 10 GB
+//			Security("jwt")
+//		})
+//	})
+//
+// generates a controller interface with the method:
+//
+//	VideoUploadStore() goa.UploadStore
+//
+func ResumableUpload(name, path string, dsl ...func()) {
+	if r, ok := resourceDefinition(); ok {
+		u := &design.UploadDefinition{Parent: r, Name: name, RequestPath: path}
+		if len(dsl) > 1 {
+			dslengine.ReportError("too many arguments given to ResumableUpload")
+			return
+		}
+		if len(dsl) == 1 {
+			if !dslengine.Execute(dsl[0], u) {
+				return
+			}
+		}
+		r.Uploads = append(r.Uploads, u)
+	}
+}
+
+// PresignedUpload defines endpoints that implement the presigned upload flow under the given
+// request path. Clients create an upload with a POST request sent to the path, retrieve a
+// presigned URL with a POST request sent to the upload location followed by "/url", upload the
+// blob directly to the store (e.g. S3 or GCS) using the URL and confirm the upload with a POST
+// request sent to the upload location followed by "/confirm". The generated controller interface
+// includes a method named after the upload that returns the goa.BlobStore backing the upload. The
+// optional DSL may set a description, the maximum upload size, the validity of the presigned URLs,
+// a security scheme and metadata. Example:
+//
+//	var _ = Resource("images", func() {
+//		PresignedUpload("image", "/images/uploads", func() {
+//			MaxUploadSize(10 << 20) // 10 MB
+//			URLExpiry("5m")
+//		})
+//	})
+//
+// generates a controller interface with the method:
+//
+//	ImageBlobStore() goa.BlobStore
+//
+func PresignedUpload(name, path string, dsl ...func()) {
+	if r, ok := resourceDefinition(); ok {
+		u := &design.UploadDefinition{Parent: r, Name: name, RequestPath: path, Presigned: true}
+		if len(dsl) > 1 {
+			dslengine.ReportError("too many arguments given to PresignedUpload")
+			return
+		}
+		if len(dsl) == 1 {
+			if !dslengine.Execute(dsl[0], u) {
+				return
+			}
+		}
+		r.Uploads = append(r.Uploads, u)
+	}
+}
+
+// URLExpiry sets the duration during which the presigned upload URLs are valid, e.g. "5m". Used in
+// PresignedUpload DSL.
+func URLExpiry(d string) {
+	if u, ok := uploadDefinition(); ok {
+		expiry, err := time.ParseDuration(d)
+		if err != nil {
+			dslengine.ReportError("invalid URL expiry %#v: %s", d, err)
+			return
+		}
+		u.Expiry = expiry
+	}
+}
+
+// MaxUploadSize sets the maximum size in bytes of the uploads. Used in ResumableUpload and
+// PresignedUpload DSL.
+func MaxUploadSize(size int64) {
+	if u, ok := uploadDefinition(); ok {
+		u.MaxSize = size
+	}
+}
@@ -0,0 +1,110 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResumableUpload", func() {
+	var name, path string
+	var dsl func()
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		name = "video"
+		path = "videos/uploads/"
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			BasicAuthSecurity("basic")
+			Security("basic")
+		})
+		Resource("videos", func() {
+			if dsl == nil {
+				ResumableUpload(name, path)
+			} else {
+				ResumableUpload(name, path, dsl)
+			}
+		})
+		dslengine.Run()
+	})
+
+	It("defines the upload", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		r := Design.Resources["videos"]
+		Ω(r.Uploads).Should(HaveLen(1))
+		u := r.Uploads[0]
+		Ω(u.Name).Should(Equal(name))
+		Ω(u.RequestPath).Should(Equal("/videos/uploads"))
+		Ω(u.Security).ShouldNot(BeNil())
+		Ω(u.Security.Scheme.SchemeName).Should(Equal("basic"))
+	})
+
+	Context("with a DSL", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Description("video uploads")
+				MaxUploadSize(1024)
+				NoSecurity()
+			}
+		})
+
+		It("sets the upload properties", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			u := Design.Resources["videos"].Uploads[0]
+			Ω(u.Description).Should(Equal("video uploads"))
+			Ω(u.MaxSize).Should(Equal(int64(1024)))
+			Ω(u.Security).Should(BeNil())
+		})
+	})
+
+	Context("with a wildcard in the path", func() {
+		BeforeEach(func() {
+			path = "/videos/*name"
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a negative maximum size", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				MaxUploadSize(-1)
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("PresignedUpload", func() {
+	BeforeEach(func() {
+		dslengine.Reset()
+		Resource("images", func() {
+			PresignedUpload("image", "/images/uploads", func() {
+				MaxUploadSize(1024)
+				URLExpiry("5m")
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("defines the presigned upload", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		u := Design.Resources["images"].Uploads[0]
+		Ω(u.Presigned).Should(BeTrue())
+		Ω(u.MaxSize).Should(Equal(int64(1024)))
+		Ω(u.Expiry).Should(Equal(5 * time.Minute))
+	})
+})
@@ -0,0 +1,58 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Webhook declares an outbound callback of the action: once a client registers a URL by calling
+// the action, the API notifies it of the event with the given name by making POST requests to
+// the URL. media is the media type of the callback request body given as a *MediaTypeDefinition
+// or an identifier, urlParam is the name of the action parameter or payload attribute holding the
+// callback URL. The optional DSL may use Description to describe the event.
+//
+// The generated application package exposes a Dispatch<Event> function per webhook that delivers
+// the event using a goa.WebhookDispatcher which signs the request body with HMAC-SHA256 and
+// retries failed deliveries with exponential backoff. The callbacks are documented in the OpenAPI
+// 3 specification generated by goagen swagger.
+//
+//	Action("subscribe", func() {
+//		Routing(POST("/subscriptions"))
+//		Payload(func() {
+//			Member("callback_url", String, func() {
+//				Format("uri")
+//			})
+//			Required("callback_url")
+//		})
+//		Webhook("bottle.created", BottleMedia, "callback_url", func() {
+//			Description("Sent when a bottle is added to the cellar")
+//		})
+//		Response(Created)
+//	})
+//
+func Webhook(event string, media interface{}, urlParam string, dsl ...func()) {
+	if a, ok := actionDefinition(); ok {
+		if len(dsl) > 1 {
+			dslengine.ReportError("too many arguments given to Webhook")
+			return
+		}
+		w := &design.WebhookDefinition{Parent: a, Event: event, URLParam: urlParam}
+		switch m := media.(type) {
+		case *design.MediaTypeDefinition:
+			if m != nil {
+				w.MediaType = m.Identifier
+			}
+		case string:
+			w.MediaType = m
+		default:
+			dslengine.ReportError("media type must be a string or a pointer to MediaTypeDefinition, got %#v", media)
+			return
+		}
+		if len(dsl) == 1 {
+			if !dslengine.Execute(dsl[0], w) {
+				return
+			}
+		}
+		a.Webhooks = append(a.Webhooks, w)
+	}
+}
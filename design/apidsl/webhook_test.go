@@ -0,0 +1,77 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhook", func() {
+	var urlParam string
+
+	BeforeEach(func() {
+		dslengine.Reset()
+		urlParam = "callback_url"
+	})
+
+	JustBeforeEach(func() {
+		bottle := MediaType("application/vnd.bottle", func() {
+			Attributes(func() {
+				Attribute("id", Integer)
+			})
+			View("default", func() {
+				Attribute("id")
+			})
+		})
+		Resource("subscription", func() {
+			Action("create", func() {
+				Routing(POST(""))
+				Payload(func() {
+					Member("callback_url", String)
+					Member("count", Integer)
+				})
+				Webhook("bottle.created", bottle, urlParam, func() {
+					Description("A bottle was created")
+				})
+				Response(Created)
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("records the webhook", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		a := Design.Resources["subscription"].Actions["create"]
+		Ω(a.Webhooks).Should(HaveLen(1))
+		w := a.Webhooks[0]
+		Ω(w.Event).Should(Equal("bottle.created"))
+		Ω(w.MediaType).Should(Equal("application/vnd.bottle"))
+		Ω(w.URLParam).Should(Equal("callback_url"))
+		Ω(w.Description).Should(Equal("A bottle was created"))
+		Ω(w.Parent).Should(Equal(a))
+	})
+
+	Context("with an unknown callback URL attribute", func() {
+		BeforeEach(func() {
+			urlParam = "url"
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`callback URL "url" is not a parameter or payload attribute`))
+		})
+	})
+
+	Context("with a callback URL attribute that is not a string", func() {
+		BeforeEach(func() {
+			urlParam = "count"
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`callback URL "count" must be a string`))
+		})
+	})
+})
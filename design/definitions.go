@@ -5,10 +5,14 @@ import (
 	"net/http"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dimfeld/httppath"
+	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/middleware"
 )
 
 type (
@@ -22,6 +26,13 @@ type (
 		Description string
 		// Version is the version of the API described by this design.
 		Version string
+		// VersionHeader is the name of the request header clients use to request this
+		// version of the API, e.g. "X-API-Version".
+		VersionHeader string
+		// VersionMediaType is the vendor media type clients use in the Accept header to
+		// request this version of the API, e.g. "application/vnd.myapi" for
+		// "application/vnd.myapi.v2+json".
+		VersionMediaType string
 		// Host is the default API hostname
 		Host string
 		// Schemes is the supported API URL schemes
@@ -73,9 +84,137 @@ type (
 		Security *SecurityDefinition
 		// NoExamples indicates whether to bypass automatic example generation.
 		NoExamples bool
+		// Dependencies lists the request-scoped dependencies shared by the API handlers and
+		// middleware.
+		Dependencies []*DependencyDefinition
+		// SecurityHeaders defines the security headers set on all responses if any.
+		SecurityHeaders *SecurityHeadersDefinition
+		// RateLimit defines the rate limit applied to all the API actions if any.
+		RateLimit *RateLimitDefinition
+		// BodyLimit is the maximum length in bytes of the request bodies of all the API
+		// actions, 0 if the controller default applies.
+		BodyLimit int64
+		// ScrubHeaders is true if the response headers not declared in the design must be
+		// removed from all responses.
+		ScrubHeaders bool
+		// AllowedHeaders lists the response headers that are not removed by ScrubHeaders in
+		// addition to the declared ones.
+		AllowedHeaders []string
+		// RequireResponses is true if all actions must declare at least one success and one
+		// error response and secured actions must declare the 401 and 403 responses.
+		RequireResponses bool
+		// Sandbox is true if the generated handlers can send the design examples in place of
+		// calling the controllers, see the Sandbox DSL.
+		Sandbox bool
+		// Lint lists the names of the lint rules run on the design, see the Lint DSL.
+		Lint []string
+		// LintAll is true if all the registered lint rules run on the design.
+		LintAll bool
 
 		// rand is the random generator used to generate examples.
 		rand *RandomGenerator
+		// examplesValidated keeps track of the type attributes whose examples have been
+		// validated so that types finalized multiple times (e.g. payloads) only report
+		// errors once per DSL run.
+		examplesValidated map[*AttributeDefinition]bool
+	}
+
+	// DependencyDefinition describes a request-scoped dependency such as a database transaction
+	// or the authenticated user. The generated code provides typed accessors that store and
+	// retrieve the dependency value in the request context container.
+	DependencyDefinition struct {
+		// Name of dependency, used to name the generated accessors.
+		Name string
+		// GoType is the Go type of the dependency value, e.g. "*sql.Tx".
+		GoType string
+		// PackagePath is the import path of the package defining GoType if any.
+		PackagePath string
+	}
+
+	// SecurityHeadersDefinition defines the security headers set on responses, see the
+	// middleware.SecurityHeaders middleware.
+	SecurityHeadersDefinition struct {
+		// Parent API, resource or action
+		Parent dslengine.Definition
+		// Profile is the name of the predefined set of headers, see
+		// middleware.SecurityHeaderProfiles.
+		Profile string
+		// Headers lists the headers that override the profile indexed by name. An empty
+		// value prevents the profile header from being set.
+		Headers map[string]string
+	}
+
+	// RateLimitDefinition defines the number of requests a client may make, see the
+	// middleware.RateLimiter middleware.
+	RateLimitDefinition struct {
+		// Parent API, resource or action
+		Parent dslengine.Definition
+		// Requests is the number of requests allowed per interval.
+		Requests int
+		// Interval is the duration over which Requests are allowed.
+		Interval time.Duration
+		// Burst is the maximum number of requests that may be made at once, zero means
+		// Requests.
+		Burst int
+		// KeyBy is the way clients are identified: "ip", "user" or "apikey".
+		KeyBy string
+		// KeyHeader is the name of the header holding the API key when KeyBy is "apikey".
+		KeyHeader string
+	}
+
+	// CacheControlDefinition defines the Cache-Control header of a response, see the
+	// CacheControl DSL.
+	CacheControlDefinition struct {
+		// Parent response
+		Parent dslengine.Definition
+		// Public is true if shared caches may store the response.
+		Public bool
+		// Private is true if only the client cache may store the response.
+		Private bool
+		// NoCache is true if caches must revalidate the response before using it.
+		NoCache bool
+		// NoStore is true if caches must not store the response.
+		NoStore bool
+		// MustRevalidate is true if caches must not use the response once stale.
+		MustRevalidate bool
+		// MaxAge is the number of seconds the response is fresh if any.
+		MaxAge *uint
+		// SharedMaxAge is the number of seconds the response is fresh in shared caches if
+		// any.
+		SharedMaxAge *uint
+		// StaleWhileRevalidate is the number of seconds caches may use the response once
+		// stale while they revalidate it in the background, zero means none.
+		StaleWhileRevalidate uint
+	}
+
+	// AffinityDefinition defines how the affinity key of a request is computed, see the
+	// middleware.Affinity middleware.
+	AffinityDefinition struct {
+		// Parent resource or action
+		Parent dslengine.Definition
+		// Source is where the key is read from: "header" or "param".
+		Source string
+		// Name is the name of the header or parameter holding the key.
+		Name string
+	}
+
+	// ClientCertDefinition requires requests to be authenticated with a TLS client certificate,
+	// see the middleware.RequireClientCert middleware.
+	ClientCertDefinition struct {
+		// Parent resource or action
+		Parent dslengine.Definition
+		// Subjects lists the accepted certificate common names or DNS names, any verified
+		// certificate is accepted if empty.
+		Subjects []string
+	}
+
+	// ChallengeDefinition requires requests to carry a verified anti-automation challenge token
+	// such as a captcha response, see the middleware.RequireChallenge middleware.
+	ChallengeDefinition struct {
+		// Parent action
+		Parent *ActionDefinition
+		// Header is the name of the request header holding the token.
+		Header string
 	}
 
 	// ContactDefinition contains the API contact information.
@@ -128,6 +267,8 @@ type (
 		Actions map[string]*ActionDefinition
 		// FileServers is the list of static asset serving endpoints
 		FileServers []*FileServerDefinition
+		// Uploads is the list of resumable upload endpoints
+		Uploads []*UploadDefinition
 		// Action with canonical resource path
 		CanonicalActionName string
 		// Map of response definitions that apply to all actions indexed by name.
@@ -143,11 +284,66 @@ type (
 		// Security defines security requirements for the Resource,
 		// for actions that don't define one themselves.
 		Security *SecurityDefinition
+		// Middleware lists the names of the middleware applied to all the resource actions.
+		Middleware []string
+		// SecurityHeaders overrides the API security headers for the resource actions.
+		SecurityHeaders *SecurityHeadersDefinition
+		// RateLimit overrides the API rate limit for the resource actions.
+		RateLimit *RateLimitDefinition
+		// BodyLimit overrides the API request body length limit for the resource actions.
+		BodyLimit int64
+		// Affinity defines the affinity key of the requests made to the resource actions.
+		Affinity *AffinityDefinition
+		// ClientCert requires the requests made to the resource actions to be authenticated
+		// with a client certificate.
+		ClientCert *ClientCertDefinition
+		// ScrubHeaders is true if the response headers not declared in the design must be
+		// removed from the resource action responses.
+		ScrubHeaders bool
+		// AllowedHeaders lists the response headers that are not removed by ScrubHeaders in
+		// addition to the declared ones.
+		AllowedHeaders []string
+		// Mounts lists the additional base paths under which the resource actions are exposed.
+		Mounts []*MountDefinition
+		// Proxy defines the upstream service the resource requests are forwarded to if any.
+		Proxy *ProxyDefinition
+	}
+
+	// ProxyDefinition defines the upstream service that handles the requests made to a
+	// resource actions.
+	ProxyDefinition struct {
+		// Parent resource
+		Parent *ResourceDefinition
+		// URL is the upstream service base URL.
+		URL string
+		// Timeout is the maximum duration of upstream requests, zero means no timeout.
+		Timeout time.Duration
+		// SetHeaders lists the headers set on upstream requests indexed by name.
+		SetHeaders map[string]string
+		// RemoveHeaders lists the names of the request headers not forwarded upstream.
+		RemoveHeaders []string
+		// ValidateResponse is true if the upstream responses must be validated against the
+		// action response definitions before being written back to the client.
+		ValidateResponse bool
+	}
+
+	// MountDefinition defines an additional base path under which the actions of a resource are
+	// exposed, either under another parent resource or at a given path prefix.
+	MountDefinition struct {
+		// Name of mount, used to name the corresponding generated href function.
+		Name string
+		// BasePath is the resource base path used for the mount. It is relative to the API
+		// base path unless it starts with "//".
+		BasePath string
+		// ParentName is the name of the parent resource the resource is mounted under if any.
+		ParentName string
+		// Resource is the mounted resource.
+		Resource *ResourceDefinition
 	}
 
 	// CORSDefinition contains the definition for a specific origin CORS policy.
 	CORSDefinition struct {
-		// Parent API or resource
+		// Parent API, resource or action
 		Parent dslengine.Definition
 		// Origin
 		Origin string
@@ -163,6 +359,9 @@ type (
 		Credentials bool
 		// Sets Whether the Origin string is a regular expression
 		Regexp bool
+		// Func is the name of the generated variable holding the cors.OriginFunc that
+		// validates the matching origins at runtime, see OriginFunc.
+		Func string
 	}
 
 	// EncodingDefinition defines an encoder supported by the API.
@@ -203,6 +402,20 @@ type (
 		Metadata dslengine.MetadataDefinition
 		// Standard is true if the response definition comes from the goa default responses
 		Standard bool
+		// Example is the example response body set with the Example DSL if any
+		Example interface{}
+		// Stream is the format of the response body if it is streamed, "bytes" or "ndjson",
+		// see the Stream DSL.
+		Stream string
+		// Incompressible is true if the response body does not compress well, see the
+		// Incompressible DSL.
+		Incompressible bool
+		// CacheControl defines the response Cache-Control header if any.
+		CacheControl *CacheControlDefinition
+		// Vary lists the request headers added to the response Vary header.
+		Vary []string
+		// SurrogateKeys lists the keys written to the response Surrogate-Key header.
+		SurrogateKeys []string
 	}
 
 	// ResponseTemplateDefinition defines a response template.
@@ -244,12 +457,98 @@ type (
 		Payload *UserTypeDefinition
 		// PayloadOptional is true if the request payload is optional, false otherwise.
 		PayloadOptional bool
+		// PayloadMultipart is true if the request payload is sent as a multipart/form-data
+		// body, see MultipartPayload.
+		PayloadMultipart bool
+		// NoParentParams is true if the action does not inherit the parent parameters.
+		NoParentParams bool
+		// ExcludedParentParams lists the names of the parent parameters not inherited by the
+		// action.
+		ExcludedParentParams []string
 		// Request headers that need to be made available to action
 		Headers *AttributeDefinition
 		// Metadata is a list of key/value pairs
 		Metadata dslengine.MetadataDefinition
 		// Security defines security requirements for the action
 		Security *SecurityDefinition
+		// Middleware lists the names of the middleware applied to the action.
+		Middleware []string
+		// SecurityHeaders overrides the API and resource security headers for the action.
+		SecurityHeaders *SecurityHeadersDefinition
+		// RateLimit overrides the API and resource rate limits for the action.
+		RateLimit *RateLimitDefinition
+		// BodyLimit overrides the API and resource request body length limits for the
+		// action.
+		BodyLimit int64
+		// Affinity overrides the resource affinity key for the action.
+		Affinity *AffinityDefinition
+		// ClientCert overrides the resource client certificate requirement for the action.
+		ClientCert *ClientCertDefinition
+		// Challenge requires the action requests to carry a verified challenge token.
+		Challenge *ChallengeDefinition
+		// ScrubHeaders is true if the response headers not declared in the design must be
+		// removed from the action responses.
+		ScrubHeaders bool
+		// AllowedHeaders lists the response headers that are not removed by ScrubHeaders in
+		// addition to the declared ones.
+		AllowedHeaders []string
+		// Pagination describes how the action paginates the listed items if it does.
+		Pagination *PaginationDefinition
+		// Idempotent is true if the action accepts the Idempotency-Key header used by the
+		// Idempotency middleware to replay the response to retried requests.
+		Idempotent bool
+		// Fallbacks lists the responses sent in place of running the action when the
+		// optional downstream services it depends on are unavailable.
+		Fallbacks []*FallbackDefinition
+		// Webhooks lists the outbound callbacks the API makes to the URL given by the
+		// clients of the action.
+		Webhooks []*WebhookDefinition
+		// Origins defines the CORS policies that override the API and resource policies
+		// for this action.
+		Origins map[string]*CORSDefinition
+	}
+
+	// WebhookDefinition describes an outbound callback: a POST request the API makes to a URL
+	// registered by the client of an action to notify it of an event.
+	WebhookDefinition struct {
+		// Parent action
+		Parent *ActionDefinition
+		// Event is the name of the event, e.g. "bottle.created".
+		Event string
+		// Description of the event
+		Description string
+		// MediaType is the identifier of the media type of the callback request body.
+		MediaType string
+		// URLParam is the name of the action parameter or payload attribute that holds the
+		// callback URL.
+		URLParam string
+	}
+
+	// FallbackDefinition describes the response sent by an action when an optional downstream
+	// service is reported unavailable, see goa.DownstreamRegistry.
+	FallbackDefinition struct {
+		// Parent action
+		Parent *ActionDefinition
+		// Downstream is the name of the downstream service.
+		Downstream string
+		// Response is the name of the action response sent when the downstream is
+		// unavailable, its example if any is used as response body.
+		Response string
+	}
+
+	// PaginationDefinition describes the pagination of the items listed by an action, see
+	// PageParam, PerPageParam and CursorParam for the names of the query string parameters.
+	PaginationDefinition struct {
+		// Parent action
+		Parent *ActionDefinition
+		// Cursor is true if the pages are identified by an opaque cursor rather than by
+		// their number.
+		Cursor bool
+		// DefaultPerPage is the number of items per page used when the request does not
+		// specify it.
+		DefaultPerPage int
+		// MaxPerPage is the maximum number of items per page, zero means no maximum.
+		MaxPerPage int
 	}
 
 	// FileServerDefinition defines an endpoint that servers static assets.
@@ -268,6 +567,10 @@ type (
 		Metadata dslengine.MetadataDefinition
 		// Security defines security requirements for the file server.
 		Security *SecurityDefinition
+		// File is the name of the design file that defines the file server if known.
+		File string
+		// Line is the line number of the file server definition in File if known.
+		Line int
 	}
 
 	// LinkDefinition defines a media type link, it specifies a URL to a related resource.
@@ -304,6 +607,12 @@ type (
 		Path string
 		// Parent is the action this route applies to.
 		Parent *ActionDefinition
+		// File is the name of the design file that defines the route if known.
+		File string
+		// Line is the line number of the route definition in File if known.
+		Line int
+		// Mount is the resource mount that produced the route if any.
+		Mount *MountDefinition
 	}
 
 	// AttributeDefinition defines a JSON object member with optional description, default
@@ -325,6 +634,11 @@ type (
 		Example interface{}
 		// Optional view used to render Attribute (only applies to media type attributes).
 		View string
+		// Optional data classification, e.g. "pii:email", see the Classification DSL.
+		Classification string
+		// Optional names of the transforms applied to the parameter or header value before it
+		// is validated, see the ParamTransform DSL.
+		Transforms []string
 		// NonZeroAttributes lists the names of the child attributes that cannot have a
 		// zero value (and thus whose presence does not need to be validated).
 		NonZeroAttributes map[string]bool
@@ -351,6 +665,31 @@ type (
 	// ActionIterator is the type of functions given to IterateActions.
 	ActionIterator func(a *ActionDefinition) error
 
+	// UploadDefinition defines endpoints that implement the tus resumable upload protocol or
+	// the presigned upload flow.
+	UploadDefinition struct {
+		// Parent resource
+		Parent *ResourceDefinition
+		// Name of upload, used to name the controller method that returns the upload store
+		Name string
+		// Presigned is true if the endpoints implement the presigned upload flow where clients
+		// upload blobs directly to the store instead of the tus protocol.
+		Presigned bool
+		// Expiry is the duration during which the presigned upload URLs are valid.
+		Expiry time.Duration
+		// Description for docs
+		Description string
+		// RequestPath is the HTTP path used to create uploads, the other requests are sent to
+		// RequestPath followed by the upload identifier.
+		RequestPath string
+		// MaxSize is the maximum size of an upload in bytes, zero means no limit.
+		MaxSize int64
+		// Metadata is a list of key/value pairs
+		Metadata dslengine.MetadataDefinition
+		// Security defines security requirements for the upload endpoints.
+		Security *SecurityDefinition
+	}
+
 	// FileServerIterator is the type of functions given to IterateFileServers.
 	FileServerIterator func(f *FileServerDefinition) error
 
@@ -590,6 +929,110 @@ func (a *APIDefinition) MediaTypeWithIdentifier(id string) *MediaTypeDefinition
 	return nil
 }
 
+// HasRateLimits returns true if any of the API actions is rate limited, see
+// ActionDefinition.EffectiveRateLimit.
+func (a *APIDefinition) HasRateLimits() bool {
+	if a.RateLimit != nil {
+		return true
+	}
+	for _, r := range a.Resources {
+		if r.RateLimit != nil {
+			return true
+		}
+		for _, act := range r.Actions {
+			if act.RateLimit != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// OriginFuncs returns the sorted names of the origin validation functions used by the API,
+// resources and actions CORS policies, see OriginFunc.
+func (a *APIDefinition) OriginFuncs() []string {
+	seen := make(map[string]bool)
+	add := func(origins map[string]*CORSDefinition) {
+		for _, o := range origins {
+			if o.Func != "" {
+				seen[o.Func] = true
+			}
+		}
+	}
+	add(a.Origins)
+	for _, r := range a.Resources {
+		add(r.Origins)
+		for _, act := range r.Actions {
+			add(act.Origins)
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasChallenges returns true if any of the API actions requires a challenge token, see
+// ActionDefinition.Challenge.
+func (a *APIDefinition) HasChallenges() bool {
+	for _, r := range a.Resources {
+		for _, act := range r.Actions {
+			if act.Challenge != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// VersionNegotiated returns true if clients request the API version with a header or the Accept
+// header rather than with the request path, see VersionHeader and VersionMediaType.
+func (a *APIDefinition) VersionNegotiated() bool {
+	return a.VersionHeader != "" || a.VersionMediaType != ""
+}
+
+// LintRules returns the names of the lint rules enabled with the Lint DSL.
+func (a *APIDefinition) LintRules() []string {
+	if !a.LintAll {
+		return a.Lint
+	}
+	rules := dslengine.LintRules()
+	names := make([]string, len(rules))
+	for i, r := range rules {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// IterateLintDefinitions calls it with the API definition, the user types, the media types and
+// the resources followed by their actions. Each action is followed by its routes and responses.
+func (a *APIDefinition) IterateLintDefinitions(it func(dslengine.Definition)) {
+	it(a)
+	a.IterateUserTypes(func(u *UserTypeDefinition) error {
+		it(u)
+		return nil
+	})
+	a.IterateMediaTypes(func(mt *MediaTypeDefinition) error {
+		it(mt)
+		return nil
+	})
+	a.IterateResources(func(r *ResourceDefinition) error {
+		it(r)
+		return r.IterateActions(func(act *ActionDefinition) error {
+			it(act)
+			for _, route := range act.Routes {
+				it(route)
+			}
+			return act.IterateResponses(func(resp *ResponseDefinition) error {
+				it(resp)
+				return nil
+			})
+		})
+	})
+}
+
 // IterateResources calls the given iterator passing in each resource sorted in alphabetical order.
 // Iteration stops if an iterator returns an error and in this case IterateResources returns that
 // error.
@@ -615,7 +1058,8 @@ func (a *APIDefinition) DSL() func() {
 }
 
 // Finalize sets the Consumes and Produces fields to the defaults if empty.
-// Also it records built-in media types that are used by the user design.
+// Also it records built-in media types that are used by the user design and validates the user
+// type examples.
 func (a *APIDefinition) Finalize() {
 	if len(a.Consumes) == 0 {
 		a.Consumes = DefaultDecoders
@@ -645,6 +1089,10 @@ func (a *APIDefinition) Finalize() {
 			return nil
 		})
 	})
+	a.IterateUserTypes(func(u *UserTypeDefinition) error {
+		u.validateExamples(u)
+		return nil
+	})
 }
 
 // NewResourceDefinition creates a resource definition but does not
@@ -748,6 +1196,16 @@ func (r *ResourceDefinition) FullPath() string {
 	return httppath.Clean(path.Join(basePath, r.BasePath))
 }
 
+// Mount returns the resource mount with the given name if any, nil otherwise.
+func (r *ResourceDefinition) Mount(name string) *MountDefinition {
+	for _, m := range r.Mounts {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
 // Parent returns the parent resource if any, nil otherwise.
 func (r *ResourceDefinition) Parent() *ResourceDefinition {
 	if r.ParentName != "" {
@@ -768,6 +1226,11 @@ func (r *ResourceDefinition) AllOrigins() []*CORSDefinition {
 	for n, o := range r.Origins {
 		all[n] = o
 	}
+	return sortOrigins(all)
+}
+
+// sortOrigins returns the given CORS policies sorted by key.
+func sortOrigins(all map[string]*CORSDefinition) []*CORSDefinition {
 	names := make([]string, len(all))
 	i := 0
 	for n := range all {
@@ -782,10 +1245,14 @@ func (r *ResourceDefinition) AllOrigins() []*CORSDefinition {
 	return cors
 }
 
-// PreflightPaths returns the paths that should handle OPTIONS requests.
+// PreflightPaths returns the paths that should handle OPTIONS requests with the resource CORS
+// policies, that is the paths of the actions that do not override them.
 func (r *ResourceDefinition) PreflightPaths() []string {
 	var paths []string
 	r.IterateActions(func(a *ActionDefinition) error {
+		if len(a.Origins) > 0 {
+			return nil
+		}
 		for _, r := range a.Routes {
 			if r.Verb == "OPTIONS" {
 				continue
@@ -820,6 +1287,9 @@ func (r *ResourceDefinition) Finalize() {
 		f.Finalize()
 		return nil
 	})
+	for _, u := range r.Uploads {
+		u.Finalize()
+	}
 	r.IterateActions(func(a *ActionDefinition) error {
 		a.Finalize()
 		return nil
@@ -840,9 +1310,64 @@ func (r *ResourceDefinition) UserTypes() map[string]*UserTypeDefinition {
 	return types
 }
 
+// Context returns the generic definition name used in error messages.
+func (p *ProxyDefinition) Context() string {
+	return fmt.Sprintf("proxy %s of %s", p.URL, p.Parent.Context())
+}
+
+// Context returns the generic definition name used in error messages.
+func (m *MountDefinition) Context() string {
+	return fmt.Sprintf("mount %#v of %s", m.Name, m.Resource.Context())
+}
+
+// FullPath computes the base path to the mounted resource actions concatenating the API or parent
+// resource base path with the mount base path as needed.
+func (m *MountDefinition) FullPath() string {
+	if m.ParentName != "" {
+		var basePath string
+		if p, ok := Design.Resources[m.ParentName]; ok {
+			if ca := p.CanonicalAction(); ca != nil && len(ca.Routes) > 0 {
+				basePath = ca.Routes[0].FullPath()
+			}
+		}
+		return httppath.Clean(path.Join(basePath, m.Resource.BasePath))
+	}
+	if strings.HasPrefix(m.BasePath, "//") {
+		return httppath.Clean(m.BasePath)
+	}
+	return httppath.Clean(path.Join(Design.BasePath, m.BasePath))
+}
+
+// CanonicalRoute returns the route of the resource canonical action produced by the mount, nil if
+// there isn't one.
+func (m *MountDefinition) CanonicalRoute() *RouteDefinition {
+	ca := m.Resource.CanonicalAction()
+	if ca == nil {
+		return nil
+	}
+	for _, r := range ca.Routes {
+		if r.Mount == m {
+			return r
+		}
+	}
+	return nil
+}
+
+// Route creates the route corresponding to the given action route for the mount.
+func (m *MountDefinition) Route(r *RouteDefinition) *RouteDefinition {
+	return &RouteDefinition{
+		Verb:   r.Verb,
+		Path:   r.Path,
+		Parent: r.Parent,
+		File:   r.File,
+		Line:   r.Line,
+		Mount:  m,
+	}
+}
+
 // Context returns the generic definition name used in error messages.
 func (cors *CORSDefinition) Context() string {
-	return fmt.Sprintf("CORS policy for resource %s origin %s", cors.Parent.Context(), cors.Origin)
+	return fmt.Sprintf("CORS policy for %s origin %s", cors.Parent.Context(), cors.Origin)
 }
 
 // Context returns the generic definition name used in error messages.
@@ -881,6 +1406,13 @@ func (a *AttributeDefinition) IsRequired(attName string) bool {
 	return false
 }
 
+// ValidationWarns returns true if the attribute validations produce warnings instead of errors,
+// see the "validation:warn" metadata.
+func (a *AttributeDefinition) ValidationWarns() bool {
+	_, ok := a.Metadata["validation:warn"]
+	return ok
+}
+
 // HasDefaultValue returns true if the given attribute has a default value.
 func (a *AttributeDefinition) HasDefaultValue(attName string) bool {
 	if a.Type.IsObject() {
@@ -949,7 +1481,7 @@ func (a *AttributeDefinition) IsPrimitivePointer(attName string) bool {
 	if att == nil {
 		return false
 	}
-	if att.Type.IsPrimitive() {
+	if att.Type.IsPrimitive() && att.Type.Kind() != FileKind { // file fields are always pointers
 		return !a.IsRequired(attName) && !a.HasDefaultValue(attName) && !a.IsNonZero(attName)
 	}
 	return false
@@ -1111,6 +1643,55 @@ func (a *AttributeDefinition) Merge(other *AttributeDefinition) *AttributeDefini
 	return a
 }
 
+// Classifications returns the classification of the classified child attributes of a indexed by
+// path. The path of an attribute is its name prefixed with the dot separated names of its parents
+// up to a, e.g. "address.phone". The elements of arrays share the path of the array. The child
+// attributes of classified attributes are not listed.
+func (a *AttributeDefinition) Classifications() map[string]string {
+	res := make(map[string]string)
+	a.classifications("", res, make(map[*AttributeDefinition]bool))
+	return res
+}
+
+// classifications implements Classifications. seen contains the user types being traversed and
+// prevents infinite recursions on recursive types.
+func (a *AttributeDefinition) classifications(path string, res map[string]string, seen map[*AttributeDefinition]bool) {
+	switch actual := a.Type.(type) {
+	case *UserTypeDefinition:
+		if seen[actual.AttributeDefinition] {
+			return
+		}
+		seen[actual.AttributeDefinition] = true
+		actual.AttributeDefinition.classifications(path, res, seen)
+		delete(seen, actual.AttributeDefinition)
+	case *MediaTypeDefinition:
+		if seen[actual.AttributeDefinition] {
+			return
+		}
+		seen[actual.AttributeDefinition] = true
+		actual.AttributeDefinition.classifications(path, res, seen)
+		delete(seen, actual.AttributeDefinition)
+	case *Array:
+		if c := actual.ElemType.Classification; c != "" && path != "" {
+			res[path] = c
+			return
+		}
+		actual.ElemType.classifications(path, res, seen)
+	case Object:
+		for n, att := range actual {
+			p := n
+			if path != "" {
+				p = path + "." + n
+			}
+			if att.Classification != "" {
+				res[p] = att.Classification
+				continue
+			}
+			att.classifications(p, res, seen)
+		}
+	}
+}
+
 // Inherit merges the properties of existing target type attributes with the argument's.
 // The algorithm is recursive so that child attributes are also merged.
 func (a *AttributeDefinition) Inherit(parent *AttributeDefinition) {
@@ -1144,6 +1725,12 @@ func (a *AttributeDefinition) inheritRecursive(parent *AttributeDefinition) {
 			if att.View == "" {
 				att.View = patt.View
 			}
+			if att.Classification == "" {
+				att.Classification = patt.Classification
+			}
+			if att.Transforms == nil {
+				att.Transforms = patt.Transforms
+			}
 			if att.Type == nil {
 				att.Type = patt.Type
 			} else if att.shouldInherit(patt) {
@@ -1189,6 +1776,84 @@ func (l *LicenseDefinition) Context() string {
 	return "unnamed license"
 }
 
+// Context returns the generic definition name used in error messages.
+func (s *SecurityHeadersDefinition) Context() string {
+	return fmt.Sprintf("security headers of %s", s.Parent.Context())
+}
+
+// Context returns the generic definition name used in error messages.
+func (r *RateLimitDefinition) Context() string {
+	return fmt.Sprintf("rate limit of %s", r.Parent.Context())
+}
+
+// Context returns the generic definition name used in error messages.
+func (c *CacheControlDefinition) Context() string {
+	if c.Parent != nil {
+		return "cache control of " + c.Parent.Context()
+	}
+	return "cache control"
+}
+
+// HeaderValue returns the value of the Cache-Control header, e.g. "public, max-age=60".
+func (c *CacheControlDefinition) HeaderValue() string {
+	var directives []string
+	if c.Public {
+		directives = append(directives, "public")
+	}
+	if c.Private {
+		directives = append(directives, "private")
+	}
+	if c.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	if c.NoStore {
+		directives = append(directives, "no-store")
+	}
+	if c.MustRevalidate {
+		directives = append(directives, "must-revalidate")
+	}
+	if c.MaxAge != nil {
+		directives = append(directives, fmt.Sprintf("max-age=%d", *c.MaxAge))
+	}
+	if c.SharedMaxAge != nil {
+		directives = append(directives, fmt.Sprintf("s-maxage=%d", *c.SharedMaxAge))
+	}
+	if c.StaleWhileRevalidate > 0 {
+		directives = append(directives, fmt.Sprintf("stale-while-revalidate=%d", c.StaleWhileRevalidate))
+	}
+	return strings.Join(directives, ", ")
+}
+
+// Context returns the generic definition name used in error messages.
+func (c *ClientCertDefinition) Context() string {
+	return fmt.Sprintf("client certificate requirement of %s", c.Parent.Context())
+}
+
+// Context returns the generic definition name used in error messages.
+func (c *ChallengeDefinition) Context() string {
+	return fmt.Sprintf("challenge of %s", c.Parent.Context())
+}
+
+// Context returns the generic definition name used in error messages.
+func (a *AffinityDefinition) Context() string {
+	return fmt.Sprintf("affinity of %s", a.Parent.Context())
+}
+
+// Context returns the generic definition name used in error messages.
+func (d *DependencyDefinition) Context() string {
+	return fmt.Sprintf("dependency %#v", d.Name)
+}
+
+// Context returns the generic definition name used in error messages.
+func (f *FallbackDefinition) Context() string {
+	return fmt.Sprintf("fallback for downstream %#v of %s", f.Downstream, f.Parent.Context())
+}
+
+// Context returns the generic definition name used in error messages.
+func (w *WebhookDefinition) Context() string {
+	return fmt.Sprintf("webhook %#v of %s", w.Event, w.Parent.Context())
+}
+
 // Context returns the generic definition name used in error messages.
 func (d *DocsDefinition) Context() string {
 	return fmt.Sprintf("documentation for %s", Design.Name)
@@ -1222,29 +1887,35 @@ func (r *ResponseDefinition) Context() string {
 }
 
 // Finalize sets the response media type from its type if the type is a media type and no media
-// type is already specified.
+// type is already specified. Responses that use a media type created with StreamOf are streamed
+// as newline delimited JSON.
 func (r *ResponseDefinition) Finalize() {
-	if r.Type == nil {
-		return
-	}
-	if r.MediaType != "" && r.MediaType != "text/plain" {
-		return
+	if r.Type != nil && (r.MediaType == "" || r.MediaType == "text/plain") {
+		if mt, ok := r.Type.(*MediaTypeDefinition); ok {
+			r.MediaType = mt.Identifier
+		}
 	}
-	mt, ok := r.Type.(*MediaTypeDefinition)
-	if !ok {
-		return
+	if r.Stream == "" && Design != nil {
+		if mt := Design.MediaTypeWithIdentifier(r.MediaType); mt != nil && mt.Streamed {
+			r.Stream = "ndjson"
+		}
 	}
-	r.MediaType = mt.Identifier
 }
 
 // Dup returns a copy of the response definition.
 func (r *ResponseDefinition) Dup() *ResponseDefinition {
 	res := ResponseDefinition{
-		Name:        r.Name,
-		Status:      r.Status,
-		Description: r.Description,
-		MediaType:   r.MediaType,
-		ViewName:    r.ViewName,
+		Name:           r.Name,
+		Status:         r.Status,
+		Description:    r.Description,
+		MediaType:      r.MediaType,
+		ViewName:       r.ViewName,
+		Example:        r.Example,
+		Stream:         r.Stream,
+		Incompressible: r.Incompressible,
+		CacheControl:   r.CacheControl,
+		Vary:           r.Vary,
+		SurrogateKeys:  r.SurrogateKeys,
 	}
 	if r.Headers != nil {
 		res.Headers = DupAtt(r.Headers)
@@ -1270,6 +1941,24 @@ func (r *ResponseDefinition) Merge(other *ResponseDefinition) {
 		r.MediaType = other.MediaType
 		r.ViewName = other.ViewName
 	}
+	if r.Example == nil {
+		r.Example = other.Example
+	}
+	if r.Stream == "" {
+		r.Stream = other.Stream
+	}
+	if other.Incompressible {
+		r.Incompressible = true
+	}
+	if r.CacheControl == nil {
+		r.CacheControl = other.CacheControl
+	}
+	if r.Vary == nil {
+		r.Vary = other.Vary
+	}
+	if r.SurrogateKeys == nil {
+		r.SurrogateKeys = other.SurrogateKeys
+	}
 	if other.Headers != nil {
 		otherHeaders := other.Headers.Type.ToObject()
 		if len(otherHeaders) > 0 {
@@ -1333,15 +2022,82 @@ func (a *ActionDefinition) AllParams() *AttributeDefinition {
 	if a.HasAbsoluteRoutes() {
 		return res
 	}
+	inherited := &AttributeDefinition{Type: Object{}}
 	if p := a.Parent.Parent(); p != nil {
-		res = res.Merge(p.CanonicalAction().AllParams())
+		inherited = inherited.Merge(p.CanonicalAction().AllParams())
 	} else {
-		res = res.Merge(a.Parent.Params)
-		res = res.Merge(Design.Params)
+		inherited = inherited.Merge(a.Parent.Params)
+		inherited = inherited.Merge(Design.Params)
+	}
+	if a.NoParentParams || len(a.ExcludedParentParams) > 0 {
+		wildcards := make(map[string]bool)
+		for _, r := range a.Routes {
+			for _, wc := range r.Params() {
+				wildcards[wc] = true
+			}
+		}
+		obj := inherited.Type.ToObject()
+		for n := range obj {
+			if !wildcards[n] && a.excludesParentParam(n) {
+				delete(obj, n)
+			}
+		}
+	}
+	return res.Merge(inherited)
+}
+
+// RouteParams returns the path and query string parameters of the action for the given route.
+// Unlike AllParams it only includes the wildcards that appear in the route path, the wildcards
+// of the other action routes are omitted. For example the parent resource wildcards are not
+// parameters of the absolute routes of a child resource action.
+func (a *ActionDefinition) RouteParams(r *RouteDefinition) *AttributeDefinition {
+	res := a.AllParams()
+	inRoute := make(map[string]bool)
+	for _, wc := range r.Params() {
+		inRoute[wc] = true
+	}
+	omitted := make(map[string]bool)
+	for _, ro := range a.Routes {
+		for _, wc := range ro.Params() {
+			if !inRoute[wc] {
+				omitted[wc] = true
+			}
+		}
+	}
+	// Copy the object and the required list, both are shared with the action params.
+	obj := make(Object)
+	for n, att := range res.Type.ToObject() {
+		if !omitted[n] {
+			obj[n] = att
+		}
+	}
+	res.Type = obj
+	if res.Validation != nil && len(res.Validation.Required) > 0 {
+		var required []string
+		for _, n := range res.Validation.Required {
+			if _, ok := obj[n]; ok {
+				required = append(required, n)
+			}
+		}
+		res.Validation.Required = required
 	}
 	return res
 }
 
+// excludesParentParam returns true if the action does not inherit the parent parameter with the
+// given name.
+func (a *ActionDefinition) excludesParentParam(name string) bool {
+	if a.NoParentParams {
+		return true
+	}
+	for _, n := range a.ExcludedParentParams {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
 // HasAbsoluteRoutes returns true if all the action routes are absolute.
 func (a *ActionDefinition) HasAbsoluteRoutes() bool {
 	for _, r := range a.Routes {
@@ -1405,6 +2161,339 @@ func (a *ActionDefinition) WebSocket() bool {
 	return true
 }
 
+// FailFast returns true if the code generated for the action should stop validating the request
+// at the first error instead of reporting all the errors in the response. Fail-fast is enabled by
+// setting the "validation:failfast" metadata on the action, its resource or the API.
+func (a *ActionDefinition) FailFast() bool {
+	if _, ok := a.Metadata["validation:failfast"]; ok {
+		return true
+	}
+	if a.Parent != nil {
+		if _, ok := a.Parent.Metadata["validation:failfast"]; ok {
+			return true
+		}
+	}
+	_, ok := Design.Metadata["validation:failfast"]
+	return ok
+}
+
+// StrictContentType returns true if the code generated for the action should reject requests whose
+// Content-Type is not one of the MIME types listed in the API Consumes definitions instead of
+// attempting to decode their bodies. It is enabled by setting the
+// "validation:strict_content_type" metadata on the action, its resource or the API.
+func (a *ActionDefinition) StrictContentType() bool {
+	if _, ok := a.Metadata["validation:strict_content_type"]; ok {
+		return true
+	}
+	if a.Parent != nil {
+		if _, ok := a.Parent.Metadata["validation:strict_content_type"]; ok {
+			return true
+		}
+	}
+	_, ok := Design.Metadata["validation:strict_content_type"]
+	return ok
+}
+
+// IncompressibleMediaTypes returns the sorted media types of the action responses that do not
+// compress well: the responses marked with the Incompressible DSL and the responses whose media
+// type is. The Content-Type of media types that define one is returned instead of their
+// identifier.
+func (a *ActionDefinition) IncompressibleMediaTypes() []string {
+	set := make(map[string]bool)
+	for _, r := range a.Responses {
+		if r.MediaType == "" {
+			continue
+		}
+		mediaType := r.MediaType
+		incompressible := r.Incompressible
+		if mt := Design.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+			incompressible = incompressible || mt.Incompressible
+			if mt.ContentType != "" {
+				mediaType = mt.ContentType
+			}
+		}
+		if incompressible {
+			set[mediaType] = true
+		}
+	}
+	var mediaTypes []string
+	for mt := range set {
+		mediaTypes = append(mediaTypes, mt)
+	}
+	sort.Strings(mediaTypes)
+	return mediaTypes
+}
+
+// EffectiveMiddleware returns the names of the middleware that apply to the action: the
+// resource middleware followed by the action middleware.
+func (a *ActionDefinition) EffectiveMiddleware() []string {
+	var names []string
+	if a.Parent != nil {
+		names = append(names, a.Parent.Middleware...)
+	}
+	return append(names, a.Middleware...)
+}
+
+// EffectiveSecurityHeaders returns the security headers that apply to the action: the action
+// security headers override the resource ones which override the API ones. It returns nil if none
+// of these define security headers.
+func (a *ActionDefinition) EffectiveSecurityHeaders() *SecurityHeadersDefinition {
+	defs := []*SecurityHeadersDefinition{Design.SecurityHeaders}
+	if a.Parent != nil {
+		defs = append(defs, a.Parent.SecurityHeaders)
+	}
+	defs = append(defs, a.SecurityHeaders)
+	var res *SecurityHeadersDefinition
+	for _, def := range defs {
+		if def == nil {
+			continue
+		}
+		if res == nil {
+			res = &SecurityHeadersDefinition{Parent: a}
+		}
+		if def.Profile != "" {
+			res.Profile = def.Profile
+		}
+		for n, v := range def.Headers {
+			if res.Headers == nil {
+				res.Headers = make(map[string]string)
+			}
+			res.Headers[n] = v
+		}
+	}
+	return res
+}
+
+// EffectiveRateLimit returns the rate limit that applies to the action: the action rate limit
+// overrides the resource one which overrides the API one. It returns nil if none of these define
+// a rate limit.
+func (a *ActionDefinition) EffectiveRateLimit() *RateLimitDefinition {
+	if a.RateLimit != nil {
+		return a.RateLimit
+	}
+	if a.Parent != nil && a.Parent.RateLimit != nil {
+		return a.Parent.RateLimit
+	}
+	return Design.RateLimit
+}
+
+// EffectiveBodyLimit returns the maximum length of the action request bodies: the action body
+// limit overrides the resource one which overrides the API one. It returns 0 if none of these
+// define a body limit.
+func (a *ActionDefinition) EffectiveBodyLimit() int64 {
+	if a.BodyLimit > 0 {
+		return a.BodyLimit
+	}
+	if a.Parent != nil && a.Parent.BodyLimit > 0 {
+		return a.Parent.BodyLimit
+	}
+	return Design.BodyLimit
+}
+
+// AllOrigins computes the CORS policies of the action taking into account the resource and API
+// policies, the action policies override the policies defined for the same origin. The result is
+// sorted alphabetically by policy origin.
+func (a *ActionDefinition) AllOrigins() []*CORSDefinition {
+	all := make(map[string]*CORSDefinition)
+	if a.Parent != nil {
+		for _, o := range a.Parent.AllOrigins() {
+			key := o.Origin
+			if o.Regexp {
+				key = "/" + key + "/"
+			}
+			all[key] = o
+		}
+	}
+	for n, o := range a.Origins {
+		all[n] = o
+	}
+	return sortOrigins(all)
+}
+
+// EffectiveAffinity returns the affinity definition that applies to the action: the action one
+// if any, the resource one otherwise.
+func (a *ActionDefinition) EffectiveAffinity() *AffinityDefinition {
+	if a.Affinity != nil {
+		return a.Affinity
+	}
+	if a.Parent != nil {
+		return a.Parent.Affinity
+	}
+	return nil
+}
+
+// EffectiveClientCert returns the client certificate requirement that applies to the action: the
+// action one if any, the resource one otherwise.
+func (a *ActionDefinition) EffectiveClientCert() *ClientCertDefinition {
+	if a.ClientCert != nil {
+		return a.ClientCert
+	}
+	if a.Parent != nil {
+		return a.Parent.ClientCert
+	}
+	return nil
+}
+
+// Classifications returns the classification of the classified params and payload fields of the
+// action indexed by field name, see AttributeDefinition.Classifications.
+func (a *ActionDefinition) Classifications() map[string]string {
+	res := make(map[string]string)
+	if a.Params != nil {
+		for n, c := range a.Params.Classifications() {
+			res[n] = c
+		}
+	}
+	if a.Payload != nil {
+		for n, c := range a.Payload.AttributeDefinition.Classifications() {
+			res[n] = c
+		}
+	}
+	return res
+}
+
+// ScrubsHeaders returns true if the response headers that are not declared in the design must be
+// removed from the action responses, that is if ScrubHeaders is set on the action, its resource or
+// the API.
+func (a *ActionDefinition) ScrubsHeaders() bool {
+	if a.ScrubHeaders || Design.ScrubHeaders {
+		return true
+	}
+	return a.Parent != nil && a.Parent.ScrubHeaders
+}
+
+// AllowedResponseHeaders returns the sorted canonical names of the response headers allowed when
+// scrubbing headers: the headers declared in the action responses, the security headers, the rate
+// limit and pagination headers and the headers explicitly allowed by the API, resource and action.
+func (a *ActionDefinition) AllowedResponseHeaders() []string {
+	names := make(map[string]bool)
+	add := func(ns ...string) {
+		for _, n := range ns {
+			names[http.CanonicalHeaderKey(n)] = true
+		}
+	}
+	add(Design.AllowedHeaders...)
+	if a.Parent != nil {
+		add(a.Parent.AllowedHeaders...)
+	}
+	add(a.AllowedHeaders...)
+	for _, r := range a.Responses {
+		if r.Headers == nil {
+			continue
+		}
+		for n := range r.Headers.Type.ToObject() {
+			add(n)
+		}
+	}
+	if a.EffectiveRateLimit() != nil {
+		add(middleware.RateLimitLimitHeader, middleware.RateLimitRemainingHeader,
+			middleware.RateLimitResetHeader, "Retry-After")
+	}
+	if a.Pagination != nil {
+		add("Link", goa.TotalCountHeader)
+	}
+	if sh := a.EffectiveSecurityHeaders(); sh != nil {
+		for n := range middleware.SecurityHeaderProfiles[sh.Profile] {
+			add(n)
+		}
+		for n, v := range sh.Headers {
+			if v != "" {
+				add(n)
+			}
+		}
+	}
+	res := make([]string, len(names))
+	i := 0
+	for n := range names {
+		res[i] = n
+		i++
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Localizable returns true if the action responses depend on the request Accept-Language header.
+// An action is localizable if the "localizable" metadata is set on the action, its resource or the
+// API.
+func (a *ActionDefinition) Localizable() bool {
+	if _, ok := a.Metadata["localizable"]; ok {
+		return true
+	}
+	if a.Parent != nil {
+		if _, ok := a.Parent.Metadata["localizable"]; ok {
+			return true
+		}
+	}
+	_, ok := Design.Metadata["localizable"]
+	return ok
+}
+
+// Streams returns true if the action response is streamed to the client, that is if the
+// "stream:write_timeout", "stream:buffer" or "stream:sse" metadata is set on the action, its
+// resource or the API.
+func (a *ActionDefinition) Streams() bool {
+	_, ok := a.inheritedMetadata("stream:write_timeout")
+	if !ok {
+		_, ok = a.inheritedMetadata("stream:buffer")
+	}
+	return ok || a.ServerSentEvents()
+}
+
+// ServerSentEvents returns true if the action response is a stream of server sent events, that
+// is if the "stream:sse" metadata is set on the action.
+func (a *ActionDefinition) ServerSentEvents() bool {
+	_, ok := a.Metadata["stream:sse"]
+	return ok
+}
+
+// StreamWriteTimeout returns the value of the "stream:write_timeout" metadata set on the action,
+// its resource or the API. It returns 0 if the metadata is not set or is not a valid duration.
+func (a *ActionDefinition) StreamWriteTimeout() time.Duration {
+	v, ok := a.inheritedMetadata("stream:write_timeout")
+	if !ok {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// StreamBuffer returns the value of the "stream:buffer" metadata set on the action, its resource
+// or the API. It returns 0 if the metadata is not set or is not a valid integer.
+func (a *ActionDefinition) StreamBuffer() int {
+	v, ok := a.inheritedMetadata("stream:buffer")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// inheritedMetadata returns the first value of the metadata with the given key looking up the
+// action, its resource and the API in order.
+func (a *ActionDefinition) inheritedMetadata(key string) (string, bool) {
+	mds := []dslengine.MetadataDefinition{a.Metadata}
+	if a.Parent != nil {
+		mds = append(mds, a.Parent.Metadata)
+	}
+	if Design != nil {
+		mds = append(mds, Design.Metadata)
+	}
+	for _, md := range mds {
+		if vals, ok := md[key]; ok {
+			if len(vals) == 0 {
+				return "", true
+			}
+			return vals[0], true
+		}
+	}
+	return "", false
+}
+
 // Finalize inherits security scheme and action responses from parent and top level design.
 func (a *ActionDefinition) Finalize() {
 	// Inherit security scheme
@@ -1422,10 +2511,18 @@ func (a *ActionDefinition) Finalize() {
 	if a.Payload != nil {
 		a.Payload.Finalize()
 	}
+	reportErrors(a.Params.ValidateExample("", a))
+	reportErrors(a.Headers.ValidateExample("", a))
 
 	a.mergeResponses()
 	a.initImplicitParams()
 	a.initQueryParams()
+
+	if Design.RequireResponses {
+		reportErrors(a.validateResponseCoverage())
+	}
+	reportErrors(a.validateFallbacks())
+	reportErrors(a.validateWebhooks())
 }
 
 // UserTypes returns all the user types used by the action payload and parameters.
@@ -1608,11 +2705,56 @@ func (f *FileServerDefinition) Finalize() {
 	}
 }
 
+// Location returns the location of the file server definition in the design source formatted
+// as "file:line" or an empty string if unknown.
+func (f *FileServerDefinition) Location() string {
+	if f.File == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", f.File, f.Line)
+}
+
 // IsDir returns true if the file server serves a directory, false otherwise.
 func (f *FileServerDefinition) IsDir() bool {
 	return WildcardRegex.MatchString(f.RequestPath)
 }
 
+// Context returns the generic definition name used in error messages.
+func (p *PaginationDefinition) Context() string {
+	if p.Parent != nil {
+		return "pagination of " + p.Parent.Context()
+	}
+	return "pagination"
+}
+
+// Context returns the generic definition name used in error messages.
+func (u *UploadDefinition) Context() string {
+	suffix := fmt.Sprintf("upload %#v", u.Name)
+	var prefix string
+	if u.Parent != nil {
+		prefix = u.Parent.Context() + " "
+	}
+	return prefix + suffix
+}
+
+// Finalize normalizes the request path and inherits security scheme from parent and top level
+// design.
+func (u *UploadDefinition) Finalize() {
+	// Make sure request path starts with a "/" and does not end with one so codegen can rely
+	// on it.
+	u.RequestPath = "/" + strings.Trim(u.RequestPath, "/")
+	// Inherit security
+	if u.Security == nil {
+		u.Security = u.Parent.Security // ResourceDefinition
+		if u.Security == nil {
+			u.Security = Design.Security
+		}
+	}
+	if u.Security != nil && u.Security.Scheme.Kind == NoSecurityKind {
+		u.Security = nil
+	}
+}
+
 // ByFilePath makes FileServerDefinition sortable for code generators.
 type ByFilePath []*FileServerDefinition
 
@@ -1671,6 +2813,15 @@ func (r *RouteDefinition) Context() string {
 	return fmt.Sprintf(`route %s "%s" of %s`, r.Verb, r.Path, r.Parent.Context())
 }
 
+// Location returns the location of the route definition in the design source formatted as
+// "file:line" or an empty string if unknown.
+func (r *RouteDefinition) Location() string {
+	if r.File == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", r.File, r.Line)
+}
+
 // Params returns the route parameters.
 // For example for the route "GET /foo/:fooID" Params returns []string{"fooID"}.
 func (r *RouteDefinition) Params() []string {
@@ -1684,7 +2835,9 @@ func (r *RouteDefinition) FullPath() string {
 		return httppath.Clean(r.Path[1:])
 	}
 	var base string
-	if r.Parent != nil && r.Parent.Parent != nil {
+	if r.Mount != nil {
+		base = r.Mount.FullPath()
+	} else if r.Parent != nil && r.Parent.Parent != nil {
 		base = r.Parent.Parent.FullPath()
 	}
 	return httppath.Clean(path.Join(base, r.Path))
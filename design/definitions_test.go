@@ -162,3 +162,38 @@ var _ = Describe("FullPath", func() {
 		})
 	})
 })
+
+var _ = Describe("Classifications", func() {
+	var att *design.AttributeDefinition
+
+	BeforeEach(func() {
+		address := &design.UserTypeDefinition{
+			TypeName: "Address",
+			AttributeDefinition: &design.AttributeDefinition{Type: design.Object{
+				"phone": {Type: design.String, Classification: "pii:phone"},
+				"city":  {Type: design.String},
+			}},
+		}
+		person := &design.UserTypeDefinition{TypeName: "Person"}
+		person.AttributeDefinition = &design.AttributeDefinition{Type: design.Object{
+			"email":     {Type: design.String, Classification: "pii:email"},
+			"addresses": {Type: &design.Array{ElemType: &design.AttributeDefinition{Type: address}}},
+			"friend":    {Type: person},
+			"ssn": {
+				Classification: "pii:ssn",
+				Type: design.Object{
+					"value": {Type: design.String, Classification: "secret"},
+				},
+			},
+		}}
+		att = &design.AttributeDefinition{Type: person}
+	})
+
+	It("returns the paths of the classified attributes", func() {
+		Ω(att.Classifications()).Should(Equal(map[string]string{
+			"email":           "pii:email",
+			"addresses.phone": "pii:phone",
+			"ssn":             "pii:ssn",
+		}))
+	})
+})
@@ -48,6 +48,8 @@ func (d *dupper) DupAttribute(att *AttributeDefinition) *AttributeDefinition {
 		DefaultValue:      att.DefaultValue,
 		NonZeroAttributes: att.NonZeroAttributes,
 		View:              att.View,
+		Classification:    att.Classification,
+		Transforms:        att.Transforms,
 		DSLFunc:           att.DSLFunc,
 		Example:           att.Example,
 	}
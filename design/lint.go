@@ -0,0 +1,102 @@
+package design
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// The built-in lint rules, enable them with the Lint DSL.
+var (
+	// ErrorMediaLintRule requires the 4xx responses of the actions to use ErrorMedia so that
+	// all client errors share the same format.
+	ErrorMediaLintRule = dslengine.RegisterLintRule(&dslengine.LintRule{
+		Name:        "error-media",
+		Description: "4xx responses must use ErrorMedia",
+		Check: func(def dslengine.Definition) error {
+			r, ok := def.(*ResponseDefinition)
+			if !ok || r.Status < 400 || r.Status >= 500 {
+				return nil
+			}
+			if CanonicalIdentifier(r.MediaType) == CanonicalIdentifier(ErrorMediaIdentifier) {
+				return nil
+			}
+			return fmt.Errorf("4xx responses must use ErrorMedia, use Response(%s, ErrorMedia)", r.Name)
+		},
+	})
+
+	// ActionDescriptionLintRule requires all actions to have a description.
+	ActionDescriptionLintRule = dslengine.RegisterLintRule(&dslengine.LintRule{
+		Name:        "action-description",
+		Description: "actions must have a description",
+		Check: func(def dslengine.Definition) error {
+			if a, ok := def.(*ActionDefinition); ok && a.Description == "" {
+				return fmt.Errorf("missing description, add a Description to the action DSL")
+			}
+			return nil
+		},
+	})
+
+	// KebabCasePathsLintRule requires the literal segments of the API and resource base paths
+	// and of the action paths to be kebab-case, e.g. "/wine-cellars/:cellarID".
+	KebabCasePathsLintRule = dslengine.RegisterLintRule(&dslengine.LintRule{
+		Name:        "kebab-case-paths",
+		Description: "path segments must be kebab-case",
+		Check: func(def dslengine.Definition) error {
+			var path string
+			switch actual := def.(type) {
+			case *APIDefinition:
+				path = actual.BasePath
+			case *ResourceDefinition:
+				path = actual.BasePath
+			case *RouteDefinition:
+				path = actual.Path
+			default:
+				return nil
+			}
+			verr := new(dslengine.ValidationErrors)
+			for _, s := range strings.Split(path, "/") {
+				if s == "" || s[0] == ':' || s[0] == '*' || kebabCaseRegex.MatchString(s) {
+					continue
+				}
+				verr.Add(def, "path segment %#v is not kebab-case, use %#v", s, kebabCase(s))
+			}
+			err := verr.AsError()
+			if err == nil {
+				// *ValidationErrors(nil) != error(nil)
+				return nil
+			}
+			return err
+		},
+	})
+)
+
+// kebabCaseRegex matches kebab-case path segments optionally followed by extensions.
+var kebabCaseRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*(\.[a-z0-9]+)*$`)
+
+// kebabCase converts a camel case, snake case or space separated name to kebab-case.
+func kebabCase(s string) string {
+	var b []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == ' ' || r == '-':
+			if len(b) > 0 && b[len(b)-1] != '-' {
+				b = append(b, '-')
+			}
+		case unicode.IsUpper(r):
+			if i > 0 && len(b) > 0 && b[len(b)-1] != '-' &&
+				(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+					(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b = append(b, '-')
+			}
+			b = append(b, unicode.ToLower(r))
+		default:
+			b = append(b, r)
+		}
+	}
+	return strings.Trim(string(b), "-")
+}
@@ -0,0 +1,40 @@
+package design_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KebabCasePathsLintRule", func() {
+	var path string
+	var err error
+
+	JustBeforeEach(func() {
+		action := &ActionDefinition{Name: "show", Parent: &ResourceDefinition{Name: "bottle"}}
+		err = KebabCasePathsLintRule.Check(&RouteDefinition{Verb: "GET", Path: path, Parent: action})
+	})
+
+	Context("with a kebab-case path", func() {
+		BeforeEach(func() {
+			path = "/wine-cellars/:cellarID/bottles.json"
+		})
+
+		It("returns a nil error", func() {
+			// Compare the interface value: a nil *ValidationErrors must not be returned as a
+			// non-nil error.
+			Ω(err == nil).Should(BeTrue())
+		})
+	})
+
+	Context("with a path that is not kebab-case", func() {
+		BeforeEach(func() {
+			path = "/wineCellars/:cellarID"
+		})
+
+		It("returns an error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring(`path segment "wineCellars" is not kebab-case, use "wine-cellars"`))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"encoding/binary"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/manveru/faker"
@@ -58,9 +59,15 @@ func (r *RandomGenerator) DateTime() time.Time {
 	return time.Unix(unix, 0)
 }
 
-// UUID produces a random UUID.
+// UUID produces a random (version 4) UUID.
 func (r *RandomGenerator) UUID() uuid.UUID {
-	return uuid.NewV4()
+	var u uuid.UUID
+	for i := range u {
+		u[i] = byte(r.rand.Intn(256))
+	}
+	u.SetVersion(uuid.V4)
+	u.SetVariant()
+	return u
 }
 
 // Bool produces a random boolean.
@@ -72,3 +79,146 @@ func (r *RandomGenerator) Bool() bool {
 func (r *RandomGenerator) Float64() float64 {
 	return r.rand.Float64()
 }
+
+// Sample produces a random value of the given type that satisfies the type validations. Contrary
+// to GenerateExample, Sample ignores the examples defined in the design and does not cache the
+// generated values so that each call produces a new value. The sequence of values produced by a
+// generator only depends on its seed: this makes it possible to reproduce failing property-based
+// tests or load test payloads by reusing the same seed.
+func (r *RandomGenerator) Sample(t DataType) interface{} {
+	return r.SampleAttribute(&AttributeDefinition{Type: t})
+}
+
+// SampleAttribute produces a random value that satisfies the attribute type and validations. See
+// Sample.
+func (r *RandomGenerator) SampleAttribute(att *AttributeDefinition) interface{} {
+	return r.sample(att, nil)
+}
+
+// sample implements SampleAttribute, seen is used to stop recursing into user types that refer
+// to themselves.
+func (r *RandomGenerator) sample(att *AttributeDefinition, seen []string) interface{} {
+	if att == nil || att.Type == nil {
+		return nil
+	}
+	if ds, ok := att.Type.(DataStructure); ok {
+		key := userTypeKey(att.Type)
+		count := 0
+		for _, k := range seen {
+			if k == key {
+				count++
+			}
+		}
+		if count > 1 {
+			// Only go a couple of levels deep
+			return nil
+		}
+		seen = append(seen, key)
+		att = sampledDefinition(att, ds)
+	}
+
+	switch {
+	case att.Type.IsArray():
+		ary := att.Type.ToArray()
+		ln := newExampleGenerator(att, r).ExampleLength()
+		res := make([]interface{}, 0, ln)
+		for i := 0; i < ln; i++ {
+			if v := r.sample(ary.ElemType, seen); v != nil {
+				res = append(res, v)
+			}
+		}
+		return ary.MakeSlice(res)
+
+	case att.Type.IsHash():
+		h := att.Type.ToHash()
+		ln := newExampleGenerator(att, r).ExampleLength()
+		res := make(map[interface{}]interface{})
+		for i := 0; i < ln; i++ {
+			k := r.sample(h.KeyType, seen)
+			v := r.sample(h.ElemType, seen)
+			if k != nil && v != nil {
+				res[k] = v
+			}
+		}
+		return h.MakeMap(res)
+
+	case att.Type.IsObject():
+		return r.sampleObject(att, seen)
+
+	default:
+		var v interface{}
+		for i := 0; i < maxAttempts; i++ {
+			v = newExampleGenerator(att, r).Generate(seen)
+			if att.Validation == nil || validateExampleRules("", v, att.Validation, nil) == nil {
+				break
+			}
+		}
+		return v
+	}
+}
+
+// sampleObject produces a random object, the required attributes are always set, the others are
+// set at random.
+func (r *RandomGenerator) sampleObject(att *AttributeDefinition, seen []string) map[string]interface{} {
+	obj := att.Type.ToObject()
+	required := make(map[string]bool)
+	if att.Validation != nil {
+		for _, n := range att.Validation.Required {
+			required[n] = true
+		}
+	}
+	// ensure fixed ordering so values only depend on the seed
+	keys := make([]string, len(obj))
+	i := 0
+	for n := range obj {
+		keys[i] = n
+		i++
+	}
+	sort.Strings(keys)
+	res := make(map[string]interface{})
+	for _, n := range keys {
+		if !required[n] && !r.Bool() {
+			continue
+		}
+		if v := r.sample(obj[n], seen); v != nil {
+			res[n] = v
+		}
+	}
+	return res
+}
+
+// userTypeKey returns the name used to detect recursive user types.
+func userTypeKey(t DataType) string {
+	switch t := t.(type) {
+	case *MediaTypeDefinition:
+		return t.Identifier
+	case *UserTypeDefinition:
+		return t.TypeName
+	}
+	return ""
+}
+
+// sampledDefinition returns the attribute definition of the user type ds with the validations
+// of att merged in. Media types are projected using the attribute view.
+func sampledDefinition(att *AttributeDefinition, ds DataStructure) *AttributeDefinition {
+	def := ds.Definition()
+	if mt, ok := att.Type.(*MediaTypeDefinition); ok {
+		v := att.View
+		if v == "" {
+			v = DefaultView
+		}
+		projected, _, err := mt.Project(v)
+		if err != nil {
+			panic(err) // bug
+		}
+		def = projected.AttributeDefinition
+	}
+	val := att.Validation
+	if val == nil {
+		val = def.Validation
+	} else if def.Validation != nil {
+		val = val.Dup()
+		val.Merge(def.Validation)
+	}
+	return &AttributeDefinition{Type: def.Type, Validation: val}
+}
@@ -0,0 +1,80 @@
+package design_test
+
+import (
+	"reflect"
+
+	. "github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sample", func() {
+	var ut *UserTypeDefinition
+
+	BeforeEach(func() {
+		min, max := 1.0, 5.0
+		minLen, maxLen := 2, 4
+		ut = &UserTypeDefinition{
+			TypeName: "Review",
+			AttributeDefinition: &AttributeDefinition{
+				Type: Object{
+					"rating": &AttributeDefinition{
+						Type:       Integer,
+						Validation: &dslengine.ValidationDefinition{Minimum: &min, Maximum: &max},
+					},
+					"status": &AttributeDefinition{
+						Type:       String,
+						Validation: &dslengine.ValidationDefinition{Values: []interface{}{"draft", "published"}},
+					},
+					"tags": &AttributeDefinition{
+						Type:       &Array{ElemType: &AttributeDefinition{Type: String}},
+						Validation: &dslengine.ValidationDefinition{MinLength: &minLen, MaxLength: &maxLen},
+					},
+					"comment": &AttributeDefinition{Type: String},
+				},
+				Validation: &dslengine.ValidationDefinition{Required: []string{"rating", "status", "tags"}},
+				Example:    map[string]interface{}{"rating": 3, "status": "draft", "tags": []string{"a", "b"}},
+			},
+		}
+	})
+
+	It("produces values that satisfy the type validations", func() {
+		r := NewRandomGenerator("seed")
+		for i := 0; i < 50; i++ {
+			sample := r.Sample(ut)
+			Ω(sample).Should(BeAssignableToTypeOf(map[string]interface{}{}))
+			m := sample.(map[string]interface{})
+			Ω(m).Should(HaveKey("rating"))
+			Ω(m["rating"]).Should(BeNumerically(">=", 1))
+			Ω(m["rating"]).Should(BeNumerically("<=", 5))
+			Ω([]interface{}{"draft", "published"}).Should(ContainElement(m["status"]))
+			Ω(len(m["tags"].([]string))).Should(BeNumerically(">=", 2))
+			Ω(len(m["tags"].([]string))).Should(BeNumerically("<=", 4))
+		}
+	})
+
+	It("ignores the design example and does not cache values", func() {
+		r := NewRandomGenerator("seed")
+		samples := make([]interface{}, 10)
+		for i := range samples {
+			samples[i] = r.Sample(ut)
+		}
+		Ω(ut.Example).Should(Equal(map[string]interface{}{"rating": 3, "status": "draft", "tags": []string{"a", "b"}}))
+		different := false
+		for _, s := range samples[1:] {
+			if !reflect.DeepEqual(samples[0], s) {
+				different = true
+			}
+		}
+		Ω(different).Should(BeTrue())
+	})
+
+	It("is reproducible given the same seed", func() {
+		r1, r2 := NewRandomGenerator("seed"), NewRandomGenerator("seed")
+		for i := 0; i < 10; i++ {
+			Ω(r1.Sample(ut)).Should(Equal(r2.Sample(ut)))
+		}
+		Ω(r1.UUID()).Should(Equal(r2.UUID()))
+	})
+})
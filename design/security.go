@@ -67,6 +67,8 @@ type SecuritySchemeDefinition struct {
 	TokenURL string `json:"token_url,omitempty"`
 	// AuthorizationURL holds URL for retrieving authorization codes with oauth2
 	AuthorizationURL string `json:"authorization_url,omitempty"`
+	// ScopesClaim is the name of the JWT claim that lists the scopes granted to the token.
+	ScopesClaim string `json:"scopes_claim,omitempty"`
 }
 
 // DSL returns the DSL function
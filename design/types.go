@@ -135,6 +135,20 @@ type (
 		Views map[string]*ViewDefinition
 		// Resource this media type is the canonical representation for if any
 		Resource *ResourceDefinition
+		// ETagAttributes lists the names of the attributes used to compute the entity tag
+		// of the media type instances if any.
+		ETagAttributes []string
+		// WeakETag is true if the entity tag computed from ETagAttributes is weak.
+		WeakETag bool
+		// LastModifiedAttribute is the name of the DateTime attribute that holds the last
+		// modification time of the media type instances if any.
+		LastModifiedAttribute string
+		// Incompressible is true if the media type representations do not compress well,
+		// see the Incompressible DSL.
+		Incompressible bool
+		// Streamed is true if the media type was created with StreamOf: the responses that
+		// use it stream their elements as newline delimited JSON.
+		Streamed bool
 	}
 )
 
@@ -163,6 +177,8 @@ const (
 	UserTypeKind
 	// MediaTypeKind represents a media type.
 	MediaTypeKind
+	// FileKind represents a file sent in a multipart/form-data request body.
+	FileKind
 )
 
 const (
@@ -188,6 +204,10 @@ const (
 
 	// Any is the type for an arbitrary JSON value (interface{} in Go).
 	Any = Primitive(AnyKind)
+
+	// File is the type for a file part of a multipart/form-data request body parsed as a Go
+	// *multipart.FileHeader. File may only be used in payloads defined with MultipartPayload.
+	File = Primitive(FileKind)
 )
 
 // DataType implementation
@@ -208,6 +228,8 @@ func (p Primitive) Name() string {
 		return "string"
 	case Any:
 		return "any"
+	case File:
+		return "file"
 	default:
 		panic("unknown primitive type") // bug
 	}
@@ -248,7 +270,7 @@ func (p Primitive) CanHaveDefault() (ok bool) {
 
 // IsCompatible returns true if val is compatible with p.
 func (p Primitive) IsCompatible(val interface{}) bool {
-	if p != Boolean && p != Integer && p != Number && p != String && p != DateTime && p != UUID && p != Any {
+	if p != Boolean && p != Integer && p != Number && p != String && p != DateTime && p != UUID && p != Any && p != File {
 		panic("unknown primitive type") // bug
 	}
 	if p == Any {
@@ -262,7 +284,7 @@ func (p Primitive) IsCompatible(val interface{}) bool {
 	case float32, float64:
 		return p == Number
 	case string:
-		if p == String {
+		if p == String || p == File {
 			return true
 		}
 		if p == DateTime {
@@ -297,6 +319,9 @@ func (p Primitive) GenerateExample(r *RandomGenerator, seen []string) interface{
 	case Any:
 		// to not make it too complicated, pick one of the primitive types
 		return anyPrimitive[r.Int()%len(anyPrimitive)].GenerateExample(r, seen)
+	case File:
+		// the example of a file is its name
+		return r.String()
 	default:
 		panic("unknown primitive type") // bug
 	}
@@ -670,17 +695,38 @@ func (u *UserTypeDefinition) IsCompatible(val interface{}) bool {
 	return u.Type == nil || u.Type.IsCompatible(val)
 }
 
-// Finalize merges base type attributes.
+// Finalize merges base type attributes, validates the examples defined in the design and
+// generates the missing ones.
 func (u *UserTypeDefinition) Finalize() {
+	u.finalize(u)
+}
+
+// finalize implements Finalize, def is the definition used to report invalid examples.
+func (u *UserTypeDefinition) finalize(def dslengine.Definition) {
 	if u.Reference != nil {
 		if bat := u.AttributeDefinition; bat != nil {
 			u.AttributeDefinition.Inherit(bat)
 		}
 	}
 
+	u.validateExamples(def)
+
 	u.GenerateExample(Design.RandomGenerator(), nil)
 }
 
+// validateExamples validates the examples defined in the design for the type attributes and
+// records any error with the DSL engine. def is the definition used to report invalid examples.
+func (u *UserTypeDefinition) validateExamples(def dslengine.Definition) {
+	if Design.examplesValidated == nil {
+		Design.examplesValidated = make(map[*AttributeDefinition]bool)
+	}
+	if Design.examplesValidated[u.AttributeDefinition] {
+		return
+	}
+	Design.examplesValidated[u.AttributeDefinition] = true
+	reportErrors(u.AttributeDefinition.ValidateExample("", def))
+}
+
 // NewMediaTypeDefinition creates a media type definition but does not
 // execute the DSL.
 func NewMediaTypeDefinition(name, identifier string, dsl func()) *MediaTypeDefinition {
@@ -725,7 +771,7 @@ func (m *MediaTypeDefinition) Finalize() {
 	if m.ContentType == "" {
 		m.ContentType = m.Identifier
 	}
-	m.UserTypeDefinition.Finalize()
+	m.UserTypeDefinition.finalize(m)
 }
 
 // ViewIterator is the type of the function given to IterateViews.
@@ -819,6 +865,8 @@ func (m *MediaTypeDefinition) projectSingle(view, canonical string) (p *MediaTyp
 	}}
 
 	ProjectedMediaTypes[canonical] = p
+	p.Incompressible = m.Incompressible
+	m.projectCaching(p, viewObj)
 	projectedObj := p.Type.ToObject()
 	mtObj := m.Type.ToObject()
 	for n := range viewObj {
@@ -875,6 +923,28 @@ func (m *MediaTypeDefinition) projectSingle(view, canonical string) (p *MediaTyp
 	return
 }
 
+// projectCaching sets the entity tag and last modification time attributes of the projection p
+// of m if the view renders them.
+func (m *MediaTypeDefinition) projectCaching(p *MediaTypeDefinition, viewObj Object) {
+	if len(m.ETagAttributes) > 0 {
+		// Views that do not render all the attributes the entity tag is computed from
+		// cannot produce one.
+		p.ETagAttributes = m.ETagAttributes
+		p.WeakETag = m.WeakETag
+		for _, n := range m.ETagAttributes {
+			if _, ok := viewObj[n]; !ok {
+				p.ETagAttributes = nil
+				break
+			}
+		}
+	}
+	if m.LastModifiedAttribute != "" {
+		if _, ok := viewObj[m.LastModifiedAttribute]; ok {
+			p.LastModifiedAttribute = m.LastModifiedAttribute
+		}
+	}
+}
+
 func (m *MediaTypeDefinition) projectCollection(view string) (*MediaTypeDefinition, *UserTypeDefinition, error) {
 	// Project the collection element media type
 	e := m.ToArray().ElemType.Type.(*MediaTypeDefinition) // validation checked this cast would work
@@ -6,11 +6,17 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/middleware"
 )
 
 type routeInfo struct {
@@ -33,6 +39,8 @@ func newRouteInfo(resource *ResourceDefinition, action *ActionDefinition, route
 		var orig dslengine.Definition
 		if strings.Contains(route.Path, v) {
 			orig = route
+		} else if route.Mount != nil && strings.Contains(route.Mount.FullPath(), v) {
+			orig = route.Mount
 		} else if strings.Contains(resource.BasePath, v) {
 			orig = resource
 		} else {
@@ -50,6 +58,64 @@ func newRouteInfo(resource *ResourceDefinition, action *ActionDefinition, route
 	}
 }
 
+type fileServerInfo struct {
+	Key        string
+	Path       string
+	Wildcard   string
+	FileServer *FileServerDefinition
+}
+
+func newFileServerInfo(f *FileServerDefinition, rpath string) *fileServerInfo {
+	if !strings.HasPrefix(rpath, "/") {
+		rpath = "/" + rpath
+	}
+	var wildcard string
+	if wcs := ExtractWildcards(rpath); len(wcs) > 0 {
+		wildcard = wcs[0]
+	}
+	return &fileServerInfo{
+		Key:        WildcardRegex.ReplaceAllLiteralString(rpath, "*"),
+		Path:       rpath,
+		Wildcard:   wildcard,
+		FileServer: f,
+	}
+}
+
+// Location returns the location of the file server definition formatted for use in error
+// messages, an empty string if unknown.
+func (f *fileServerInfo) Location() string {
+	if loc := f.FileServer.Location(); loc != "" {
+		return fmt.Sprintf(" (defined at %s)", loc)
+	}
+	return ""
+}
+
+// Shadows returns the name of the wildcard of route that sits at the same position as the file
+// server catch-all wildcard if it has a different name, an empty string otherwise.
+func (f *fileServerInfo) Shadows(route *routeInfo) string {
+	if f.Wildcard == "" || route.Route.Verb != "GET" || len(route.Wildcards) == 0 {
+		return ""
+	}
+	if !strings.HasPrefix(route.Key, f.Key) {
+		return ""
+	}
+	// File server request paths contain at most one wildcard so it matches the first wildcard
+	// of the route.
+	if name := route.Wildcards[0].Name; name != f.Wildcard {
+		return name
+	}
+	return ""
+}
+
+// Location returns the location of the route definition formatted for use in error messages,
+// an empty string if unknown.
+func (r *routeInfo) Location() string {
+	if loc := r.Route.Location(); loc != "" {
+		return fmt.Sprintf(" (defined at %s)", loc)
+	}
+	return ""
+}
+
 // DifferentWildcards returns the list of wildcards in other that have a different name from the
 // wildcard in target at the same position.
 func (r *routeInfo) DifferentWildcards(other *routeInfo) (res [][2]*wildCardInfo) {
@@ -84,63 +150,241 @@ func (a *APIDefinition) Validate() error {
 	a.validateLicense(verr)
 	a.validateDocs(verr)
 	a.validateOrigins(verr)
+	a.validateDependencies(verr)
+	a.validatePolicies(verr)
+	allRoutes, allFiles := a.validateResources(verr)
+	validateRouteConflicts(allRoutes, verr)
+	validateFileServerConflicts(allFiles, allRoutes, verr)
+	a.validateTypes(verr)
+
+	err := verr.AsError()
+	if err == nil {
+		// *ValidationErrors(nil) != error(nil)
+		return nil
+	}
+	return err
+}
+
+func (a *APIDefinition) validateContact(verr *dslengine.ValidationErrors) {
+	if a.Contact != nil && a.Contact.URL != "" {
+		if _, err := url.ParseRequestURI(a.Contact.URL); err != nil {
+			verr.Add(a, "invalid contact URL value: %s", err)
+		}
+	}
+}
+
+func (a *APIDefinition) validateLicense(verr *dslengine.ValidationErrors) {
+	if a.License != nil && a.License.URL != "" {
+		if _, err := url.ParseRequestURI(a.License.URL); err != nil {
+			verr.Add(a, "invalid license URL value: %s", err)
+		}
+	}
+}
+
+func (a *APIDefinition) validateDocs(verr *dslengine.ValidationErrors) {
+	if a.Docs != nil && a.Docs.URL != "" {
+		if _, err := url.ParseRequestURI(a.Docs.URL); err != nil {
+			verr.Add(a, "invalid docs URL value: %s", err)
+		}
+	}
+}
+
+func (a *APIDefinition) validateOrigins(verr *dslengine.ValidationErrors) {
+	for _, origin := range a.Origins {
+		verr.Merge(origin.Validate())
+	}
+}
+
+func (a *APIDefinition) validateDependencies(verr *dslengine.ValidationErrors) {
+	names := make(map[string]bool)
+	for _, d := range a.Dependencies {
+		if d.Name == "" {
+			verr.Add(d, "dependency name cannot be empty")
+		} else if names[d.Name] {
+			verr.Add(d, "dependency %#v is defined more than once", d.Name)
+		}
+		names[d.Name] = true
+		if d.GoType == "" {
+			verr.Add(d, "dependency Go type cannot be empty")
+		}
+	}
+}
+
+// validatePolicies validates the API wide request policies.
+func (a *APIDefinition) validatePolicies(verr *dslengine.ValidationErrors) {
+	if a.VersionNegotiated() && a.Version == "" {
+		verr.Add(a, "Version must be set when the API version is requested with VersionHeader or VersionMediaType")
+	}
+	if a.SecurityHeaders != nil {
+		verr.Merge(a.SecurityHeaders.Validate())
+	}
+	if a.RateLimit != nil {
+		verr.Merge(a.RateLimit.Validate())
+	}
+}
 
+// validateResources validates the API resources and returns the routes and file server paths
+// they define.
+func (a *APIDefinition) validateResources(verr *dslengine.ValidationErrors) ([]*routeInfo, []*fileServerInfo) {
 	var allRoutes []*routeInfo
+	var allFiles []*fileServerInfo
+	webhooks := make(map[string]*WebhookDefinition)
 	a.IterateResources(func(r *ResourceDefinition) error {
 		verr.Merge(r.Validate())
+		for _, f := range r.FileServers {
+			allFiles = append(allFiles, newFileServerInfo(f, f.RequestPath))
+			if f.IsDir() {
+				// Directory file servers also serve index.html on the directory path.
+				dir := WildcardRegex.ReplaceAllLiteralString(f.RequestPath, "") + "/"
+				allFiles = append(allFiles, newFileServerInfo(f, dir))
+			}
+		}
 		r.IterateActions(func(ac *ActionDefinition) error {
+			for _, w := range ac.Webhooks {
+				if other, ok := webhooks[w.Event]; ok {
+					verr.Add(w, "event is also sent by %s", other.Parent.Context())
+				}
+				webhooks[w.Event] = w
+			}
 			if ac.Docs != nil && ac.Docs.URL != "" {
 				if _, err := url.ParseRequestURI(ac.Docs.URL); err != nil {
 					verr.Add(ac, "invalid action docs URL value: %s", err)
 				}
 			}
-			for _, ro := range ac.Routes {
-				if ro.IsAbsolute() {
-					continue
-				}
-				info := newRouteInfo(r, ac, ro)
-				allRoutes = append(allRoutes, info)
-				rwcs := ExtractWildcards(ac.Parent.FullPath())
-				wcs := ExtractWildcards(ro.Path)
-				for _, rwc := range rwcs {
-					for _, wc := range wcs {
-						if rwc == wc {
-							verr.Add(ac, `duplicate wildcard "%s" in resource base path "%s" and action route "%s"`,
-								wc, ac.Parent.FullPath(), ro.Path)
-						}
-					}
-				}
-			}
+			allRoutes = append(allRoutes, validateActionRoutes(r, ac, verr)...)
 			return nil
 		})
 		return nil
 	})
-	for _, route := range allRoutes {
-		for _, other := range allRoutes {
+	return allRoutes, allFiles
+}
+
+// validateActionRoutes checks that the action relative routes do not reuse the wildcards of
+// the resource base path and returns them.
+func validateActionRoutes(r *ResourceDefinition, ac *ActionDefinition, verr *dslengine.ValidationErrors) []*routeInfo {
+	var routes []*routeInfo
+	for _, ro := range ac.Routes {
+		if ro.IsAbsolute() {
+			continue
+		}
+		info := newRouteInfo(r, ac, ro)
+		routes = append(routes, info)
+		base := ac.Parent.FullPath()
+		if ro.Mount != nil {
+			base = ro.Mount.FullPath()
+		}
+		rwcs := ExtractWildcards(base)
+		wcs := ExtractWildcards(ro.Path)
+		for _, rwc := range rwcs {
+			for _, wc := range wcs {
+				if rwc == wc {
+					verr.Add(ac, `duplicate wildcard "%s" in resource base path "%s" and action route "%s"%s. Suggested fix: rename the wildcard in the action route.`,
+						wc, base, ro.Path, info.Location())
+				}
+			}
+		}
+	}
+	return routes
+}
+
+// validateRouteConflicts checks that no two routes have the same verb and path and that
+// wildcards at the same positions have the same names.
+func validateRouteConflicts(allRoutes []*routeInfo, verr *dslengine.ValidationErrors) {
+	for i, route := range allRoutes {
+		for j, other := range allRoutes {
 			if route == other {
 				continue
 			}
+			if j > i && route.Key == other.Key && route.Route.Verb == other.Route.Verb {
+				verr.Add(route.Action,
+					`route %s "%s"%s is also defined by %s action %s%s. Remove one of the routes or change its verb or path.`,
+					route.Route.Verb,
+					route.Route.FullPath(),
+					route.Location(),
+					other.Resource.Name,
+					other.Action.Name,
+					other.Location(),
+				)
+			}
 			if strings.HasPrefix(route.Key, other.Key) {
-				diffs := route.DifferentWildcards(other)
-				if len(diffs) > 0 {
-					var msg string
-					conflicts := make([]string, len(diffs))
-					for i, d := range diffs {
-						conflicts[i] = fmt.Sprintf(`"%s" from %s and "%s" from %s`, d[0].Name, d[0].Orig.Context(), d[1].Name, d[1].Orig.Context())
-					}
-					msg = fmt.Sprintf("%s", strings.Join(conflicts, ", "))
-					verr.Add(route.Action,
-						`route "%s" conflicts with route "%s" of %s action %s. Make sure wildcards at the same positions have the same name. Conflicting wildcards are %s.`,
-						route.Route.FullPath(),
-						other.Route.FullPath(),
-						other.Resource.Name,
-						other.Action.Name,
-						msg,
-					)
-				}
+				validateWildcardConflicts(route, other, verr)
+			}
+		}
+	}
+}
+
+// validateWildcardConflicts checks that the wildcards of route and other have the same names
+// at the same positions.
+func validateWildcardConflicts(route, other *routeInfo, verr *dslengine.ValidationErrors) {
+	diffs := route.DifferentWildcards(other)
+	if len(diffs) == 0 {
+		return
+	}
+	conflicts := make([]string, len(diffs))
+	fixes := make([]string, len(diffs))
+	for i, d := range diffs {
+		conflicts[i] = fmt.Sprintf(`"%s" from %s and "%s" from %s`, d[0].Name, d[0].Orig.Context(), d[1].Name, d[1].Orig.Context())
+		fixes[i] = fmt.Sprintf(`rename "%s" to "%s" in %s`, d[0].Name, d[1].Name, d[0].Orig.Context())
+	}
+	verr.Add(route.Action,
+		`route "%s"%s conflicts with route "%s" of %s action %s%s. Make sure wildcards at the same positions have the same name. Conflicting wildcards are %s. Suggested fix: %s.`,
+		route.Route.FullPath(),
+		route.Location(),
+		other.Route.FullPath(),
+		other.Resource.Name,
+		other.Action.Name,
+		other.Location(),
+		strings.Join(conflicts, ", "),
+		strings.Join(fixes, ", "),
+	)
+}
+
+// validateFileServerConflicts checks that the file server request paths do not conflict with
+// one another or with the GET routes.
+func validateFileServerConflicts(allFiles []*fileServerInfo, allRoutes []*routeInfo, verr *dslengine.ValidationErrors) {
+	for i, f := range allFiles {
+		for _, route := range allRoutes {
+			if route.Route.Verb == "GET" && route.Key == f.Key {
+				verr.Add(f.FileServer,
+					`request path "%s"%s conflicts with route GET "%s" of %s action %s%s. Change the file server request path or the action route.`,
+					f.Path,
+					f.Location(),
+					route.Route.FullPath(),
+					route.Resource.Name,
+					route.Action.Name,
+					route.Location(),
+				)
+			} else if name := f.Shadows(route); name != "" {
+				verr.Add(f.FileServer,
+					`request path "%s"%s shadows route GET "%s" of %s action %s%s. Suggested fix: rename "%s" to "%s" in %s.`,
+					f.Path,
+					f.Location(),
+					route.Route.FullPath(),
+					route.Resource.Name,
+					route.Action.Name,
+					route.Location(),
+					name,
+					f.Wildcard,
+					route.Route.Context(),
+				)
+			}
+		}
+		for _, other := range allFiles[i+1:] {
+			if f.Key == other.Key && f.FileServer != other.FileServer {
+				verr.Add(f.FileServer,
+					`request path "%s"%s is also served by %s%s. Remove one of the file servers or change its request path.`,
+					f.Path,
+					f.Location(),
+					other.FileServer.Context(),
+					other.Location(),
+				)
 			}
 		}
 	}
+}
+
+// validateTypes validates the API media types, user types, responses and encodings.
+func (a *APIDefinition) validateTypes(verr *dslengine.ValidationErrors) {
 	a.IterateMediaTypes(func(mt *MediaTypeDefinition) error {
 		verr.Merge(mt.Validate())
 		return nil
@@ -159,64 +403,179 @@ func (a *APIDefinition) Validate() error {
 	for _, enc := range a.Produces {
 		verr.Merge(enc.Validate())
 	}
+}
 
-	err := verr.AsError()
-	if err == nil {
-		// *ValidationErrors(nil) != error(nil)
-		return nil
+// Validate tests whether the resource definition is consistent: action names are valid and each action is
+// valid.
+func (r *ResourceDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if r.Name == "" {
+		verr.Add(r, "Resource name cannot be empty")
 	}
-	return err
+	r.validateActions(verr)
+	if r.ParentName != "" {
+		r.validateParent(verr)
+	}
+	for _, resp := range r.Responses {
+		verr.Merge(resp.Validate())
+	}
+	if r.Params != nil {
+		verr.Merge(r.Params.Validate("resource parameters", r))
+	}
+	for _, origin := range r.Origins {
+		verr.Merge(origin.Validate())
+	}
+	if r.Proxy != nil {
+		verr.Merge(r.Proxy.Validate())
+	}
+	if r.SecurityHeaders != nil {
+		verr.Merge(r.SecurityHeaders.Validate())
+	}
+	if r.RateLimit != nil {
+		verr.Merge(r.RateLimit.Validate())
+	}
+	if r.Affinity != nil {
+		verr.Merge(r.Affinity.Validate())
+	}
+	if actions := r.Metadata["goa:batch"]; len(actions) > 0 {
+		r.validateBatchActions(actions, verr)
+	}
+	for i, m := range r.Mounts {
+		verr.Merge(m.Validate())
+		for _, other := range r.Mounts[i+1:] {
+			if m.Name == other.Name {
+				verr.Add(m, "mount name is used more than once")
+			}
+		}
+	}
+	return verr.AsError()
 }
 
-func (a *APIDefinition) validateContact(verr *dslengine.ValidationErrors) {
-	if a.Contact != nil && a.Contact.URL != "" {
-		if _, err := url.ParseRequestURI(a.Contact.URL); err != nil {
-			verr.Add(a, "invalid contact URL value: %s", err)
+// validateBatchActions checks that the actions listed in the Batch DSL exist and are not the
+// batch resource actions.
+func (r *ResourceDefinition) validateBatchActions(actions []string, verr *dslengine.ValidationErrors) {
+	for _, ra := range actions {
+		parts := strings.SplitN(ra, "#", 2)
+		if parts[0] == r.Name {
+			verr.Add(r, "batch action %#v cannot be an action of the batch resource", ra)
+			continue
+		}
+		res, ok := Design.Resources[parts[0]]
+		if !ok {
+			verr.Add(r, "unknown resource %#v in batch action %#v", parts[0], ra)
+			continue
+		}
+		if _, ok := res.Actions[parts[1]]; !ok {
+			verr.Add(r, "unknown action %#v in batch action %#v", parts[1], ra)
 		}
 	}
 }
 
-func (a *APIDefinition) validateLicense(verr *dslengine.ValidationErrors) {
-	if a.License != nil && a.License.URL != "" {
-		if _, err := url.ParseRequestURI(a.License.URL); err != nil {
-			verr.Add(a, "invalid license URL value: %s", err)
+// Validate checks the proxy upstream URL is an absolute HTTP URL.
+func (p *ProxyDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		verr.Add(p, "invalid upstream URL: %s", err)
+	} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add(p, "invalid upstream URL %#v, must be an absolute http or https URL", p.URL)
+	}
+	if p.Timeout < 0 {
+		verr.Add(p, "timeout cannot be negative")
+	}
+	return verr.AsError()
+}
+
+// Validate checks the security headers profile exists and the header names are not empty.
+func (s *SecurityHeadersDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if s.Profile != "" {
+		if _, ok := middleware.SecurityHeaderProfiles[s.Profile]; !ok {
+			var names []string
+			for n := range middleware.SecurityHeaderProfiles {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			verr.Add(s, "unknown profile %#v, must be one of %s", s.Profile, strings.Join(names, ", "))
 		}
 	}
+	for n := range s.Headers {
+		if n == "" {
+			verr.Add(s, "header name cannot be empty")
+		}
+	}
+	return verr.AsError()
 }
 
-func (a *APIDefinition) validateDocs(verr *dslengine.ValidationErrors) {
-	if a.Docs != nil && a.Docs.URL != "" {
-		if _, err := url.ParseRequestURI(a.Docs.URL); err != nil {
-			verr.Add(a, "invalid docs URL value: %s", err)
+// Validate checks the rate limit allows requests and identifies clients in a supported way.
+func (r *RateLimitDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if r.Requests <= 0 {
+		verr.Add(r, "number of requests must be greater than 0")
+	}
+	if r.Interval <= 0 {
+		verr.Add(r, "interval must be greater than 0")
+	}
+	if r.Burst < 0 {
+		verr.Add(r, "burst cannot be negative")
+	}
+	switch r.KeyBy {
+	case "ip", "user":
+	case "apikey":
+		if r.KeyHeader == "" {
+			verr.Add(r, "name of the API key header is required")
 		}
+	default:
+		verr.Add(r, "invalid key %#v, must be one of ip, user or apikey", r.KeyBy)
 	}
+	return verr.AsError()
 }
 
-func (a *APIDefinition) validateOrigins(verr *dslengine.ValidationErrors) {
-	for _, origin := range a.Origins {
-		verr.Merge(origin.Validate())
+// hasParam returns true if the action has a path or query string parameter with the given name.
+func (a *ActionDefinition) hasParam(name string) bool {
+	if a.Params != nil {
+		if _, ok := a.Params.Type.ToObject()[name]; ok {
+			return true
+		}
 	}
+	for _, r := range a.Routes {
+		for _, p := range r.Params() {
+			if p == name {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-// Validate tests whether the resource definition is consistent: action names are valid and each action is
-// valid.
-func (r *ResourceDefinition) Validate() *dslengine.ValidationErrors {
+// Validate checks the affinity key is read from a named header or parameter.
+func (a *AffinityDefinition) Validate() *dslengine.ValidationErrors {
 	verr := new(dslengine.ValidationErrors)
-	if r.Name == "" {
-		verr.Add(r, "Resource name cannot be empty")
-	}
-	r.validateActions(verr)
-	if r.ParentName != "" {
-		r.validateParent(verr)
+	if a.Source != "header" && a.Source != "param" {
+		verr.Add(a, "invalid source %#v, must be header or param", a.Source)
 	}
-	for _, resp := range r.Responses {
-		verr.Merge(resp.Validate())
+	if a.Name == "" {
+		verr.Add(a, "name of the %s holding the key cannot be empty", a.Source)
 	}
-	if r.Params != nil {
-		verr.Merge(r.Params.Validate("resource parameters", r))
+	return verr.AsError()
+}
+
+// Validate checks the mount has a name and refers to an existing parent resource if any.
+func (m *MountDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if m.Name == "" {
+		verr.Add(m, "mount name cannot be empty")
 	}
-	for _, origin := range r.Origins {
-		verr.Merge(origin.Validate())
+	if m.ParentName != "" {
+		p, ok := Design.Resources[m.ParentName]
+		if !ok {
+			verr.Add(m, "Parent resource named %#v not found", m.ParentName)
+		} else if p.CanonicalAction() == nil {
+			verr.Add(m, "Parent resource %#v has no canonical action", m.ParentName)
+		}
+		if m.ParentName == m.Resource.Name {
+			verr.Add(m, "resource cannot be mounted under itself")
+		}
 	}
 	return verr.AsError()
 }
@@ -232,6 +591,14 @@ func (r *ResourceDefinition) validateActions(verr *dslengine.ValidationErrors) {
 	for _, f := range r.FileServers {
 		verr.Merge(f.Validate())
 	}
+	names := make(map[string]bool)
+	for _, u := range r.Uploads {
+		if names[u.Name] {
+			verr.Add(u, "duplicate upload name")
+		}
+		names[u.Name] = true
+		verr.Merge(u.Validate())
+	}
 	if r.CanonicalActionName != "" && !found {
 		verr.Add(r, `unknown canonical action "%s"`, r.CanonicalActionName)
 	}
@@ -248,7 +615,11 @@ func (r *ResourceDefinition) validateParent(verr *dslengine.ValidationErrors) {
 	}
 }
 
-// Validate makes sure the CORS definition origin is valid.
+// exportedIdentifierRegex matches exported Go identifiers.
+var exportedIdentifierRegex = regexp.MustCompile(`^[A-Z][a-zA-Z0-9_]*$`)
+
+// Validate makes sure the CORS definition origin is valid and that it does not allow credentials
+// together with wildcards.
 func (cors *CORSDefinition) Validate() *dslengine.ValidationErrors {
 	verr := new(dslengine.ValidationErrors)
 	if !cors.Regexp && strings.Count(cors.Origin, "*") > 1 {
@@ -260,6 +631,25 @@ func (cors *CORSDefinition) Validate() *dslengine.ValidationErrors {
 			verr.Add(cors, "invalid origin, should be a valid regular expression")
 		}
 	}
+	if cors.Func != "" && !exportedIdentifierRegex.MatchString(cors.Func) {
+		verr.Add(cors, "invalid origin function name %#v, must be an exported Go identifier", cors.Func)
+	}
+	if cors.Credentials {
+		if cors.Origin == "*" && !cors.Regexp && cors.Func == "" {
+			verr.Add(cors, "cannot allow credentials for all origins, list the allowed origins or validate them with OriginFunc")
+		}
+		wildcards := []struct {
+			name string
+			vals []string
+		}{{"headers", cors.Headers}, {"methods", cors.Methods}, {"exposed headers", cors.Exposed}}
+		for _, w := range wildcards {
+			for _, v := range w.vals {
+				if v == "*" {
+					verr.Add(cors, "cannot use wildcard %s with credentials, browsers do not expand \"*\" in responses to requests with credentials", w.name)
+				}
+			}
+		}
+	}
 	return verr
 }
 
@@ -291,7 +681,7 @@ func (enc *EncodingDefinition) Validate() *dslengine.ValidationErrors {
 		}
 	} else {
 		for _, m := range enc.MIMETypes {
-			if _, ok := KnownEncoders[m]; !ok {
+			if !HasKnownEncoder(m) {
 				knownMIMETypes := make([]string, len(KnownEncoders))
 				i := 0
 				for k := range KnownEncoders {
@@ -320,6 +710,32 @@ func (a *ActionDefinition) Validate() *dslengine.ValidationErrors {
 	if len(a.Routes) == 0 {
 		verr.Add(a, "No route defined for action")
 	}
+	verr.Merge(a.validateResponses())
+	verr.Merge(a.ValidateParams())
+	if a.Payload != nil {
+		verr.Merge(a.Payload.Validate("action payload", a))
+	}
+	verr.Merge(a.validateFiles())
+	verr.Merge(a.validatePolicies())
+	verr.Merge(a.validateExcludedParentParams())
+	verr.Merge(a.validateStreamMetadata())
+	if a.Idempotent && !a.hasVerb("POST", "PATCH") {
+		verr.Add(a, "Idempotent may only be used in actions with POST or PATCH routes")
+	}
+	if a.Pagination != nil {
+		verr.Merge(a.Pagination.Validate())
+	}
+	if a.Parent == nil {
+		verr.Add(a, "missing parent resource")
+	}
+
+	return verr.AsError()
+}
+
+// validateResponses validates the action responses and checks that their status codes are
+// unique.
+func (a *ActionDefinition) validateResponses() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
 	for i, r := range a.Responses {
 		for j, r2 := range a.Responses {
 			if i != j && r.Status == r2.Status {
@@ -328,14 +744,169 @@ func (a *ActionDefinition) Validate() *dslengine.ValidationErrors {
 		}
 		verr.Merge(r.Validate())
 	}
-	verr.Merge(a.ValidateParams())
-	if a.Payload != nil {
-		verr.Merge(a.Payload.Validate("action payload", a))
+	return verr.AsError()
+}
+
+// validatePolicies validates the action request policies and middleware.
+func (a *ActionDefinition) validatePolicies() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if a.SecurityHeaders != nil {
+		verr.Merge(a.SecurityHeaders.Validate())
 	}
-	if a.Parent == nil {
-		verr.Add(a, "missing parent resource")
+	if a.RateLimit != nil {
+		verr.Merge(a.RateLimit.Validate())
+	}
+	if a.Affinity != nil {
+		verr.Merge(a.Affinity.Validate())
+	}
+	for _, origin := range a.Origins {
+		verr.Merge(origin.Validate())
+	}
+	if a.Challenge != nil && a.Challenge.Header == "" {
+		verr.Add(a.Challenge, "challenge token header name cannot be empty")
+	}
+	if af := a.EffectiveAffinity(); af != nil && af.Source == "param" && af.Name != "" && !a.hasParam(af.Name) {
+		verr.Add(a, "affinity parameter %#v is not a parameter of the action", af.Name)
+	}
+	for _, n := range a.Middleware {
+		if n == "" {
+			verr.Add(a, "middleware name cannot be empty")
+		}
 	}
+	return verr.AsError()
+}
+
+// validateExcludedParentParams checks that the excluded parent parameters are not used by the
+// action routes.
+func (a *ActionDefinition) validateExcludedParentParams() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	for _, n := range a.ExcludedParentParams {
+		for _, r := range a.Routes {
+			for _, wc := range r.Params() {
+				if wc == n {
+					verr.Add(a, `cannot exclude parent parameter "%s" used by route "%s"`, n, r.FullPath())
+				}
+			}
+		}
+	}
+	return verr.AsError()
+}
+
+// validateStreamMetadata validates the streaming metadata values of the action.
+func (a *ActionDefinition) validateStreamMetadata() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if v, ok := a.inheritedMetadata("stream:write_timeout"); ok {
+		if d, err := time.ParseDuration(v); err != nil || d < 0 {
+			verr.Add(a, `invalid "stream:write_timeout" metadata value %#v, must be a positive duration such as "10s"`, v)
+		}
+	}
+	if v, ok := a.inheritedMetadata("stream:buffer"); ok {
+		if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+			verr.Add(a, `invalid "stream:buffer" metadata value %#v, must be a positive number of bytes`, v)
+		}
+	}
+	return verr.AsError()
+}
+
+// hasVerb returns true if one of the action routes uses one of the given HTTP verbs.
+func (a *ActionDefinition) hasVerb(verbs ...string) bool {
+	for _, r := range a.Routes {
+		for _, v := range verbs {
+			if r.Verb == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateFiles checks that File attributes are only used in multipart payloads and that the
+// attributes of multipart payloads can be loaded from form parts.
+func (a *ActionDefinition) validateFiles() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if hasFile(a.Params) || hasFile(a.Headers) {
+		verr.Add(a, "File may only be used in payloads defined with MultipartPayload")
+	}
+	if a.Payload == nil {
+		return verr.AsError()
+	}
+	if !a.PayloadMultipart {
+		if hasFile(a.Payload.AttributeDefinition) {
+			verr.Add(a, "File may only be used in payloads defined with MultipartPayload")
+		}
+		return verr.AsError()
+	}
+	o := a.Payload.ToObject()
+	if o == nil {
+		verr.Add(a, "multipart payload must be an object")
+		return verr.AsError()
+	}
+	for n, att := range o {
+		t := att.Type
+		if t.IsArray() {
+			t = t.ToArray().ElemType.Type
+		}
+		if !t.IsPrimitive() {
+			verr.Add(a, "invalid type for multipart payload attribute %#v, must be a File, a primitive or an array of Files or primitives", n)
+		}
+	}
+	return verr.AsError()
+}
+
+// hasFile returns true if att or any of its child attributes is a File.
+func hasFile(att *AttributeDefinition) bool {
+	if att == nil || att.Type == nil {
+		return false
+	}
+	found := false
+	att.Walk(func(a *AttributeDefinition) error {
+		if a.Type != nil && a.Type.Kind() == FileKind {
+			found = true
+		}
+		return nil
+	})
+	return found
+}
+
+// Validate checks the pagination page sizes are consistent.
+func (p *PaginationDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if p.DefaultPerPage <= 0 {
+		verr.Add(p, "default number of items per page must be positive, got %d", p.DefaultPerPage)
+	}
+	if p.MaxPerPage < 0 {
+		verr.Add(p, "maximum number of items per page cannot be negative, got %d", p.MaxPerPage)
+	}
+	if p.MaxPerPage > 0 && p.DefaultPerPage > p.MaxPerPage {
+		verr.Add(p, "default number of items per page (%d) exceeds maximum (%d)", p.DefaultPerPage, p.MaxPerPage)
+	}
+	return verr.AsError()
+}
 
+// Validate checks the upload is properly initialized.
+func (u *UploadDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if u.Name == "" {
+		verr.Add(u, "Upload must have a non empty name")
+	}
+	if u.RequestPath == "" {
+		verr.Add(u, "Upload must have a non empty route path")
+	}
+	if u.Parent == nil {
+		verr.Add(u, "missing parent resource")
+	}
+	if WildcardRegex.MatchString(u.RequestPath) {
+		verr.Add(u, "invalid request path %s, may not contain wildcards", u.RequestPath)
+	}
+	if u.MaxSize < 0 {
+		verr.Add(u, "invalid maximum size %d, must be positive", u.MaxSize)
+	}
+	if u.Expiry != 0 && !u.Presigned {
+		verr.Add(u, "URL expiry is only supported by presigned uploads")
+	}
+	if u.Expiry < 0 {
+		verr.Add(u, "invalid URL expiry %s, must be positive", u.Expiry)
+	}
 	return verr.AsError()
 }
 
@@ -432,21 +1003,7 @@ func (a *AttributeDefinition) Validate(ctx string, parent dslengine.Definition)
 	if ctx != "" {
 		ctx += " - "
 	}
-	// If both Default and Enum are given, make sure the Default value is one of Enum values.
-	// TODO: We only do the default value and enum check just for primitive types.
-	// Issue 388 (https://github.com/goadesign/goa/issues/388) will address this for other types.
-	if a.Type.IsPrimitive() && a.DefaultValue != nil && a.Validation != nil && a.Validation.Values != nil {
-		var found bool
-		for _, e := range a.Validation.Values {
-			if e == a.DefaultValue {
-				found = true
-				break
-			}
-		}
-		if !found {
-			verr.Add(parent, "%sdefault value %#v is not one of the accepted values: %#v", ctx, a.DefaultValue, a.Validation.Values)
-		}
-	}
+	verr.Merge(a.validateEnum(ctx, parent))
 	o := a.Type.ToObject()
 	if o != nil {
 		for _, n := range a.AllRequired() {
@@ -475,8 +1032,33 @@ func (a *AttributeDefinition) Validate(ctx string, parent dslengine.Definition)
 	return verr.AsError()
 }
 
-// Validate checks that the response definition is consistent: its status is set and the media
-// type definition if any is valid.
+// validateEnum checks that the attribute default value is one of the enum values if any.
+func (a *AttributeDefinition) validateEnum(ctx string, parent dslengine.Definition) *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if a.Validation == nil {
+		return nil
+	}
+	if a.Validation.OpenEnum && a.Validation.Values == nil {
+		verr.Add(parent, "%sattribute is marked as an open enum but does not define any enum value", ctx)
+	}
+	// If both Default and Enum are given, make sure the Default value is one of Enum values.
+	// TODO: We only do the default value and enum check just for primitive types.
+	// Issue 388 (https://github.com/goadesign/goa/issues/388) will address this for other types.
+	// Open enums accept values that are not listed so the default value need not be one of them.
+	if !a.Type.IsPrimitive() || a.DefaultValue == nil || a.Validation.Values == nil || a.Validation.OpenEnum {
+		return verr.AsError()
+	}
+	for _, e := range a.Validation.Values {
+		if e == a.DefaultValue {
+			return verr.AsError()
+		}
+	}
+	verr.Add(parent, "%sdefault value %#v is not one of the accepted values: %#v", ctx, a.DefaultValue, a.Validation.Values)
+	return verr.AsError()
+}
+
+// Validate checks that the response definition is consistent: its status is set, the media
+// type definition if any is valid and the example if any is compatible with the body type.
 func (r *ResponseDefinition) Validate() *dslengine.ValidationErrors {
 	verr := new(dslengine.ValidationErrors)
 	if r.Headers != nil {
@@ -485,6 +1067,48 @@ func (r *ResponseDefinition) Validate() *dslengine.ValidationErrors {
 	if r.Status == 0 {
 		verr.Add(r, "response status not defined")
 	}
+	if r.Example != nil {
+		verr.Merge(r.validateExample())
+	}
+	switch r.Stream {
+	case "", "bytes":
+	case "ndjson":
+		if r.Type == nil && (Design == nil || Design.MediaTypeWithIdentifier(r.MediaType) == nil) {
+			verr.Add(r, "ndjson stream requires a response type or media type")
+		}
+	default:
+		verr.Add(r, "invalid stream format %#v, must be \"bytes\" or \"ndjson\"", r.Stream)
+	}
+	if r.CacheControl != nil {
+		verr.Merge(r.CacheControl.Validate())
+	}
+	return verr.AsError()
+}
+
+// validateExample checks that the response example is compatible with the response body type.
+func (r *ResponseDefinition) validateExample() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	dt := r.Type
+	if dt == nil && r.MediaType != "" && Design != nil {
+		if mt := Design.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+			dt = mt.Type
+		}
+	}
+	if dt != nil && !dt.IsCompatible(r.Example) {
+		verr.Add(r, "example value %#v is incompatible with response body type %s", r.Example, dt.Name())
+	}
+	return verr.AsError()
+}
+
+// Validate checks that the Cache-Control directives are consistent.
+func (c *CacheControlDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if c.Public && c.Private {
+		verr.Add(c, "response cannot be both public and private")
+	}
+	if c.NoStore && (c.MaxAge != nil || c.SharedMaxAge != nil || c.StaleWhileRevalidate > 0) {
+		verr.Add(c, "response that may not be stored cannot define an age")
+	}
 	return verr.AsError()
 }
 
@@ -516,24 +1140,7 @@ func (m *MediaTypeDefinition) Validate() *dslengine.ValidationErrors {
 	if m.Type == nil { // TBD move this to somewhere else than validation code
 		m.Type = String
 	}
-	var obj Object
-	if a := m.Type.ToArray(); a != nil {
-		if a.ElemType == nil {
-			verr.Add(m, "array element type is nil")
-		} else {
-			if err := a.ElemType.Validate("array element", m); err != nil {
-				verr.Merge(err)
-			} else {
-				if _, ok := a.ElemType.Type.(*MediaTypeDefinition); !ok {
-					verr.Add(m, "collection media type array element type must be a media type, got %s", a.ElemType.Type.Name())
-				} else {
-					obj = a.ElemType.Type.ToObject()
-				}
-			}
-		}
-	} else {
-		obj = m.Type.ToObject()
-	}
+	obj := m.validateElemType(verr)
 	if obj != nil {
 		for n, att := range obj {
 			verr.Merge(att.Validate("attribute "+n, m))
@@ -562,9 +1169,86 @@ func (m *MediaTypeDefinition) Validate() *dslengine.ValidationErrors {
 	for _, l := range m.Links {
 		verr.Merge(l.Validate())
 	}
+
+	m.validateStorage(obj, verr)
+	m.validateCaching(obj, verr)
 	return verr.AsError()
 }
 
+// validateElemType validates the element type of collection media types and returns the object
+// rendered by the media type or its elements, nil if there is none.
+func (m *MediaTypeDefinition) validateElemType(verr *dslengine.ValidationErrors) Object {
+	a := m.Type.ToArray()
+	if a == nil {
+		return m.Type.ToObject()
+	}
+	if a.ElemType == nil {
+		verr.Add(m, "array element type is nil")
+		return nil
+	}
+	if err := a.ElemType.Validate("array element", m); err != nil {
+		verr.Merge(err)
+		return nil
+	}
+	if _, ok := a.ElemType.Type.(*MediaTypeDefinition); !ok {
+		verr.Add(m, "collection media type array element type must be a media type, got %s", a.ElemType.Type.Name())
+		return nil
+	}
+	return a.ElemType.Type.ToObject()
+}
+
+// validateStorage checks that the media type storage metadata is consistent with its attributes.
+func (m *MediaTypeDefinition) validateStorage(obj Object, verr *dslengine.ValidationErrors) {
+	table, ok := m.Metadata["storage:table"]
+	if !ok {
+		return
+	}
+	if len(table) == 0 || table[0] == "" {
+		verr.Add(m, "storage table name cannot be empty")
+	}
+	if obj == nil {
+		verr.Add(m, "media type with storage must be an object")
+		return
+	}
+	pks, ok := m.Metadata["storage:pk"]
+	if !ok {
+		pks = []string{"id"}
+	}
+	for _, pk := range pks {
+		if _, ok := obj[pk]; !ok {
+			verr.Add(m, "storage primary key %#v is not an attribute of the media type", pk)
+		}
+	}
+}
+
+// validateCaching checks that the media type entity tag and last modification time attributes
+// exist.
+func (m *MediaTypeDefinition) validateCaching(obj Object, verr *dslengine.ValidationErrors) {
+	if len(m.ETagAttributes) > 0 {
+		if obj == nil || m.IsArray() {
+			verr.Add(m, "media type with entity tag must be an object")
+		} else {
+			for _, n := range m.ETagAttributes {
+				att, ok := obj[n]
+				if !ok {
+					verr.Add(m, "entity tag attribute %#v is not an attribute of the media type", n)
+				} else if !att.Type.IsPrimitive() {
+					verr.Add(m, "entity tag attribute %#v must be of a primitive type", n)
+				}
+			}
+		}
+	}
+	if n := m.LastModifiedAttribute; n != "" {
+		if obj == nil || m.IsArray() {
+			verr.Add(m, "media type with last modification time must be an object")
+		} else if att, ok := obj[n]; !ok {
+			verr.Add(m, "last modification time attribute %#v is not an attribute of the media type", n)
+		} else if att.Type.Kind() != DateTimeKind {
+			verr.Add(m, "last modification time attribute %#v must be of type DateTime", n)
+		}
+	}
+}
+
 // Validate checks that the link definition is consistent: it has a media type or the name of an
 // attribute part of the parent media type.
 func (l *LinkDefinition) Validate() *dslengine.ValidationErrors {
@@ -612,3 +1296,299 @@ func (v *ViewDefinition) Validate() *dslengine.ValidationErrors {
 	verr.Merge(v.AttributeDefinition.Validate("", v))
 	return verr.AsError()
 }
+
+// validateResponseCoverage checks that the action declares at least one success and one error
+// response and that secured actions declare the Unauthorized and Forbidden responses. It is run
+// once the action responses have been merged with the resource and API responses.
+func (a *ActionDefinition) validateResponseCoverage() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	var success, failure, unauthorized, forbidden bool
+	for _, r := range a.Responses {
+		switch {
+		case r.Status < 400:
+			success = true
+		case r.Status == 401:
+			unauthorized = true
+			failure = true
+		case r.Status == 403:
+			forbidden = true
+			failure = true
+		default:
+			failure = true
+		}
+	}
+	if !success {
+		verr.Add(a, "action must define at least one success response")
+	}
+	if !failure {
+		verr.Add(a, "action must define at least one error response")
+	}
+	if a.Security != nil {
+		if !unauthorized {
+			verr.Add(a, "secured action must define the Unauthorized (401) response")
+		}
+		if !forbidden {
+			verr.Add(a, "secured action must define the Forbidden (403) response")
+		}
+	}
+	return verr.AsError()
+}
+
+// validateFallbacks checks that the fallback responses are defined by the action. It runs once
+// the action responses have been merged with the resource and API responses.
+func (a *ActionDefinition) validateFallbacks() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	seen := make(map[string]bool)
+	for _, f := range a.Fallbacks {
+		if seen[f.Downstream] {
+			verr.Add(f, "fallback defined twice")
+		}
+		seen[f.Downstream] = true
+		if _, ok := a.Responses[f.Response]; !ok {
+			verr.Add(f, "response %#v is not defined by the action", f.Response)
+		}
+	}
+	return verr.AsError()
+}
+
+// validateWebhooks checks that the webhook media types exist and that the callback URLs are given
+// by action parameters or payload attributes. It runs once the action is finalized.
+func (a *ActionDefinition) validateWebhooks() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	seen := make(map[string]bool)
+	for _, w := range a.Webhooks {
+		if w.Event == "" {
+			verr.Add(w, "event name cannot be empty")
+		}
+		if seen[w.Event] {
+			verr.Add(w, "webhook defined twice")
+		}
+		seen[w.Event] = true
+		if Design.MediaTypeWithIdentifier(w.MediaType) == nil {
+			verr.Add(w, "media type %#v is not defined", w.MediaType)
+		}
+		att := a.AllParams().Type.ToObject()[w.URLParam]
+		if att == nil && a.Payload != nil && a.Payload.Type.IsObject() {
+			att = a.Payload.Type.ToObject()[w.URLParam]
+		}
+		if att == nil {
+			verr.Add(w, "callback URL %#v is not a parameter or payload attribute of the action", w.URLParam)
+		} else if att.Type.Kind() != StringKind {
+			verr.Add(w, "callback URL %#v must be a string", w.URLParam)
+		}
+	}
+	return verr.AsError()
+}
+
+// reportErrors records the given validation errors with the DSL engine, it is used to report the
+// errors detected while finalizing the definitions.
+func reportErrors(verr *dslengine.ValidationErrors) {
+	if verr != nil {
+		dslengine.Errors = append(dslengine.Errors, &dslengine.Error{GoError: verr})
+	}
+}
+
+// ValidateExample checks that the attribute example, if any, satisfies the attribute type and
+// validations. It also checks the examples of the child attributes defined inline (user type and
+// media type attributes are checked when the corresponding type is finalized). ctx is the path of
+// the attribute and is used to build the error messages.
+func (a *AttributeDefinition) ValidateExample(ctx string, parent dslengine.Definition) *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if a == nil || a.Type == nil {
+		return nil
+	}
+	// "-" denotes the NoExample DSL
+	if a.Example != nil && a.Example != "-" {
+		verr.Merge(a.validateValue("example", ctx, a.Example, parent))
+	}
+	switch actual := a.Type.(type) {
+	case Object:
+		names := make([]string, 0, len(actual))
+		for n := range actual {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			verr.Merge(actual[n].ValidateExample(examplePath(ctx, n), parent))
+		}
+	case *Array:
+		verr.Merge(actual.ElemType.ValidateExample(ctx+"[*]", parent))
+	case *Hash:
+		verr.Merge(actual.KeyType.ValidateExample(ctx+"[key]", parent))
+		verr.Merge(actual.ElemType.ValidateExample(ctx+"[*]", parent))
+	}
+	return verr.AsError()
+}
+
+// ValidateValue checks that val satisfies the attribute type and validations recursing through
+// the value elements for arrays, hashes and objects. Integers must be given as Go integers, not as
+// float64 values. ctx is the path of the attribute and is used to build the error messages.
+// Tools use ValidateValue to check values such as recorded requests against the design.
+func (a *AttributeDefinition) ValidateValue(ctx string, val interface{}, parent dslengine.Definition) *dslengine.ValidationErrors {
+	return a.validateValue("value", ctx, val, parent)
+}
+
+// validateValue implements ValidateValue, kind describes the value in the error messages, e.g.
+// "example".
+func (a *AttributeDefinition) validateValue(kind, ctx string, val interface{}, parent dslengine.Definition) *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if val == nil {
+		return nil
+	}
+	what := kind
+	if ctx != "" {
+		what = fmt.Sprintf("%s of %#v", kind, ctx)
+	}
+	if !a.Type.IsCompatible(val) {
+		verr.Add(parent, "%s: value %#v is incompatible with type %s", what, val, a.Type.Name())
+		return verr
+	}
+	validations := []*dslengine.ValidationDefinition{a.Validation}
+	if ds, ok := a.Type.(DataStructure); ok {
+		validations = append(validations, ds.Definition().Validation)
+	}
+	var required []string
+	for _, v := range validations {
+		if v != nil {
+			verr.Merge(validateExampleRules(what, val, v, parent))
+			required = append(required, v.Required...)
+		}
+	}
+	rv := reflect.ValueOf(val)
+	switch {
+	case a.Type.IsObject():
+		if rv.Kind() != reflect.Map {
+			break
+		}
+		kt := rv.Type().Key()
+		if kt.Kind() != reflect.String && kt.Kind() != reflect.Interface {
+			break
+		}
+		for _, n := range required {
+			if !rv.MapIndex(reflect.ValueOf(n).Convert(kt)).IsValid() {
+				verr.Add(parent, "%s: missing required attribute %#v", what, n)
+			}
+		}
+		obj := a.Type.ToObject()
+		names := make([]string, 0, len(obj))
+		for n := range obj {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			if v := rv.MapIndex(reflect.ValueOf(n).Convert(kt)); v.IsValid() {
+				verr.Merge(obj[n].validateValue(kind, examplePath(ctx, n), v.Interface(), parent))
+			}
+		}
+	case a.Type.IsArray():
+		elem := a.Type.ToArray().ElemType
+		for i := 0; i < rv.Len(); i++ {
+			verr.Merge(elem.validateValue(kind, fmt.Sprintf("%s[%d]", ctx, i), rv.Index(i).Interface(), parent))
+		}
+	case a.Type.IsHash():
+		h := a.Type.ToHash()
+		for _, k := range rv.MapKeys() {
+			path := fmt.Sprintf("%s[%v]", ctx, k.Interface())
+			verr.Merge(h.KeyType.validateValue(kind, path, k.Interface(), parent))
+			verr.Merge(h.ElemType.validateValue(kind, path, rv.MapIndex(k).Interface(), parent))
+		}
+	}
+	return verr.AsError()
+}
+
+// validateExampleRules checks that val satisfies the given validation rules except for the
+// required attributes which are checked by validateValue.
+func validateExampleRules(what string, val interface{}, v *dslengine.ValidationDefinition, parent dslengine.Definition) *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	// Open enums accept values that are not listed.
+	if len(v.Values) > 0 && !v.OpenEnum {
+		found := false
+		for _, e := range v.Values {
+			if reflect.DeepEqual(e, val) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			verr.Add(parent, "%s: value %#v is not one of the allowed values %#v", what, val, v.Values)
+		}
+	}
+	if s, ok := val.(string); ok {
+		verr.Merge(validateExampleString(what, s, v, parent))
+	}
+	if f, ok := exampleNumber(val); ok {
+		if v.Minimum != nil && f < *v.Minimum {
+			verr.Add(parent, "%s: value %#v is lower than the minimum %v", what, val, *v.Minimum)
+		}
+		if v.Maximum != nil && f > *v.Maximum {
+			verr.Add(parent, "%s: value %#v is greater than the maximum %v", what, val, *v.Maximum)
+		}
+	}
+	if l, ok := exampleLength(val); ok {
+		if v.MinLength != nil && l < *v.MinLength {
+			verr.Add(parent, "%s: value %#v has length %d lower than the minimum length %d", what, val, l, *v.MinLength)
+		}
+		if v.MaxLength != nil && l > *v.MaxLength {
+			verr.Add(parent, "%s: value %#v has length %d greater than the maximum length %d", what, val, l, *v.MaxLength)
+		}
+	}
+	return verr.AsError()
+}
+
+// validateExampleString checks a string example against the string validations.
+func validateExampleString(what, s string, v *dslengine.ValidationDefinition, parent dslengine.Definition) *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if v.Format != "" {
+		if err := goa.ValidateFormat(goa.Format(v.Format), s); err != nil {
+			verr.Add(parent, "%s: value %#v does not match format %#v: %s", what, s, v.Format, err)
+		}
+	}
+	if v.Pattern != "" && !goa.ValidatePattern(v.Pattern, s) {
+		verr.Add(parent, "%s: value %#v does not match pattern %#v", what, s, v.Pattern)
+	}
+	if v.Normalization != "" && !goa.ValidateNormalization(v.Normalization, s) {
+		verr.Add(parent, "%s: value %#v is not in Unicode normalization form %s", what, s, v.Normalization)
+	}
+	if v.MaxRunes != nil && utf8.RuneCountInString(s) > *v.MaxRunes {
+		verr.Add(parent, "%s: value %#v has more than the maximum %d runes", what, s, *v.MaxRunes)
+	}
+	if v.MaxGraphemes != nil && goa.GraphemeCount(s) > *v.MaxGraphemes {
+		verr.Add(parent, "%s: value %#v has more than the maximum %d graphemes", what, s, *v.MaxGraphemes)
+	}
+	return verr.AsError()
+}
+
+// examplePath appends the attribute name to the given path.
+func examplePath(ctx, name string) string {
+	if ctx == "" {
+		return name
+	}
+	return ctx + "." + name
+}
+
+// exampleNumber returns the float64 value of the given example if it's a number.
+func exampleNumber(val interface{}) (float64, bool) {
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// exampleLength returns the length of the given example if it's a string, an array or a hash.
+func exampleLength(val interface{}) (int, bool) {
+	if s, ok := val.(string); ok {
+		return utf8.RuneCountInString(s), true
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len(), true
+	}
+	return 0, false
+}
@@ -288,3 +288,219 @@ var _ = Describe("Validation", func() {
 		})
 	})
 })
+
+var _ = Describe("Example validation", func() {
+	var dsl func()
+	var item *UserTypeDefinition
+
+	JustBeforeEach(func() {
+		dslengine.Reset()
+		item = Type("item", func() {
+			Attribute("name", String)
+			Required("name")
+		})
+		Type("bar", func() {
+			dsl()
+		})
+		dslengine.Run()
+	})
+
+	Context("with valid examples", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Attribute("name", String, func() {
+					Pattern("^[a-z]+$")
+					Example("foo")
+				})
+				Attribute("count", Integer, func() {
+					Minimum(1)
+					Maximum(10)
+					Example(5)
+				})
+				Attribute("tags", ArrayOf(String), func() {
+					MaxLength(2)
+					Example([]string{"a", "b"})
+				})
+			}
+		})
+
+		It("does not produce an error", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("with an example that is not listed in an open enum", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Attribute("color", String, func() {
+					Enum("red", "green")
+					OpenEnum()
+					Example("blue")
+				})
+			}
+		})
+
+		It("does not produce an error", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("with an example that does not match the pattern", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Attribute("name", String, func() {
+					Pattern("^[a-z]+$")
+					Example("Foo")
+				})
+			}
+		})
+
+		It("produces an error with the attribute path", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`type "bar": example of "name": value "Foo" does not match pattern "^[a-z]+$"`))
+		})
+
+		It("produces the error again when the type is run in a new design", func() {
+			bar := Design.Types["bar"]
+			dslengine.Reset()
+			Design.Types = map[string]*UserTypeDefinition{"bar": bar}
+			Ω(dslengine.Run()).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`example of "name": value "Foo" does not match pattern "^[a-z]+$"`))
+		})
+	})
+
+	Context("with a nested example violating a validation", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Attribute("origin", func() {
+					Attribute("country", String, func() {
+						Enum("FR", "US")
+					})
+					Attribute("rating", Integer, func() {
+						Maximum(5)
+					})
+					Required("country")
+					Example(map[string]interface{}{"rating": 6})
+				})
+			}
+		})
+
+		It("reports each violation", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			msg := dslengine.Errors.Error()
+			Ω(msg).Should(ContainSubstring(`example of "origin": missing required attribute "country"`))
+			Ω(msg).Should(ContainSubstring(`example of "origin.rating": value 6 is greater than the maximum 5`))
+		})
+	})
+
+	Context("with an array element violating a validation", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Attribute("items", ArrayOf(item), func() {
+					Example([]map[string]interface{}{{"name": "a"}, {}})
+				})
+			}
+		})
+
+		It("reports the element index", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`example of "items[1]": missing required attribute "name"`))
+		})
+	})
+})
+
+var _ = Describe("Route conflict validation", func() {
+	var dsl func()
+
+	JustBeforeEach(func() {
+		dslengine.Reset()
+		API("test", nil)
+		Resource("one", func() {
+			Action("show", func() {
+				Routing(GET("/things/:id"))
+			})
+		})
+		Resource("two", dsl)
+		dslengine.Run()
+	})
+
+	Context("with conflicting wildcard names", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Action("show", func() {
+					Routing(GET("/things/:thingID/parts"))
+				})
+			}
+		})
+
+		It("reports both definitions with their locations and a fix", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			msg := dslengine.Errors.Error()
+			Ω(msg).Should(ContainSubstring(`route "/things/:thingID/parts" (defined at `))
+			Ω(msg).Should(ContainSubstring(`conflicts with route "/things/:id" of one action show (defined at `))
+			Ω(msg).Should(ContainSubstring(`Suggested fix: rename "thingID" to "id"`))
+		})
+	})
+
+	Context("with duplicate routes", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Action("get", func() {
+					Routing(GET("/things/:id"))
+				})
+			}
+		})
+
+		It("reports the duplicate", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`route GET "/things/:id" (defined at `))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`is also defined by two action get`))
+		})
+	})
+
+	Context("with a file server serving an action route", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Files("/things/:id", "public/things")
+			}
+		})
+
+		It("reports the conflict", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`request path "/things/:id" (defined at `))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`conflicts with route GET "/things/:id" of one action show`))
+		})
+	})
+
+	Context("with a file server catch-all shadowing an action route", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Files("/docs/*filepath", "public/docs")
+				Action("history", func() {
+					Routing(GET("/docs/:page/history"))
+				})
+			}
+		})
+
+		It("reports the shadowing with a fix", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`shadows route GET "/docs/:page/history" of two action history`))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`Suggested fix: rename "page" to "filepath"`))
+		})
+	})
+
+	Context("with two file servers serving the same path", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Files("/index.html", "public/index.html")
+				Files("/index.html", "public/home.html")
+			}
+		})
+
+		It("reports the duplicate", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`request path "/index.html" (defined at `))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`is also served by resource "two" file server public/home.html`))
+		})
+	})
+})
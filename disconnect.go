@@ -0,0 +1,60 @@
+package goa
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// withDisconnect returns a context that is canceled when the client closes the underlying
+// connection. The disconnect is derived from the request context which net/http cancels when the
+// client goes away, so it is detected regardless of how the response writer is wrapped. The
+// returned cancel function must be called once the request has been handled, this also stops the
+// goroutine watching the request context.
+func withDisconnect(ctx context.Context, req *http.Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	reqCtx := req.Context()
+	if reqCtx.Done() == nil {
+		// The request context can never be canceled, e.g. in tests.
+		return ctx, cancel
+	}
+	disconnected := make(chan struct{})
+	ctx = context.WithValue(ctx, disconnectedKey, disconnected)
+	go func() {
+		select {
+		case <-reqCtx.Done():
+			if ctx.Err() != nil || reqCtx.Err() != context.Canceled {
+				// The request was handled already or timed out upstream.
+				cancel()
+				return
+			}
+			close(disconnected)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// ContextClientDisconnected returns true if the client closed the connection used to send the
+// request before the response was written.
+func ContextClientDisconnected(ctx context.Context) bool {
+	if d := ctx.Value(disconnectedKey); d != nil {
+		select {
+		case <-d.(chan struct{}):
+			return true
+		default:
+		}
+	}
+	return false
+}
+
+// reportDisconnect logs and counts a client disconnect.
+func reportDisconnect(ctx context.Context, err error) {
+	go IncrCounter([]string{"goa", "client_disconnected"}, 1.0)
+	if err != nil {
+		LogInfo(ctx, "client disconnected", "classification", "client_disconnected", "err", err)
+		return
+	}
+	LogInfo(ctx, "client disconnected", "classification", "client_disconnected")
+}
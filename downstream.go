@@ -0,0 +1,143 @@
+package goa
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultDownstreamProbeInterval is the default interval at which requests are let through to
+// the actions that depend on a downstream marked down so that its recovery can be detected.
+const DefaultDownstreamProbeInterval = 10 * time.Second
+
+type (
+	// DownstreamRegistry keeps track of the availability of the downstream services a service
+	// depends on. The clients used to call the downstream services report failures and
+	// successes with MarkDown and MarkUp (see client.TrackDownstream) and the actions that
+	// declare a fallback in the design send the fallback response instead of running while the
+	// downstream is down. DownstreamRegistry is safe for concurrent use.
+	DownstreamRegistry struct {
+		// ProbeInterval is the interval at which a request is let through to an action
+		// whose downstream is down, giving the client a chance to mark the downstream up
+		// again. Defaults to DefaultDownstreamProbeInterval.
+		ProbeInterval time.Duration
+
+		mu     sync.Mutex
+		states map[string]*downstreamState
+	}
+
+	// downstreamState records the availability of a downstream.
+	downstreamState struct {
+		err    error     // cause of the downstream being down, nil if up
+		probed time.Time // time the downstream was marked down or last probed
+	}
+)
+
+// NewDownstreamRegistry creates an empty downstream registry.
+func NewDownstreamRegistry() *DownstreamRegistry {
+	return &DownstreamRegistry{
+		ProbeInterval: DefaultDownstreamProbeInterval,
+		states:        make(map[string]*downstreamState),
+	}
+}
+
+// Register adds the downstream with the given name to the registry, it is initially available.
+// Register does nothing if the downstream is already registered.
+func (r *DownstreamRegistry) Register(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.states[name]; !ok {
+		r.states[name] = &downstreamState{}
+	}
+}
+
+// MarkDown records that the downstream with the given name is unavailable because of err. The
+// time the downstream went down is read from the clock stored in ctx, see ContextClock.
+func (r *DownstreamRegistry) MarkDown(ctx context.Context, name string, err error) {
+	if err == nil {
+		err = ErrDownstreamUnavailable("downstream is unavailable", "downstream", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[name] = &downstreamState{err: err, probed: ContextClock(ctx).Now()}
+}
+
+// MarkUp records that the downstream with the given name is available.
+func (r *DownstreamRegistry) MarkUp(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[name] = &downstreamState{}
+}
+
+// Err returns the error that caused the downstream with the given name to be marked down, nil
+// if the downstream is available or unknown.
+func (r *DownstreamRegistry) Err(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.states[name]; ok {
+		return s.err
+	}
+	return nil
+}
+
+// IsUp returns true if the downstream with the given name is available or unknown.
+func (r *DownstreamRegistry) IsUp(name string) bool {
+	return r.Err(name) == nil
+}
+
+// Health returns the state of all the registered downstreams indexed by name, the error is nil
+// for available downstreams.
+func (r *DownstreamRegistry) Health() map[string]error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	health := make(map[string]error, len(r.states))
+	for n, s := range r.states {
+		health[n] = s.err
+	}
+	return health
+}
+
+// probe returns the error that caused the downstream to be marked down unless it is available
+// or the probe interval elapsed since it was last probed, in which case it returns nil and
+// restarts the interval.
+func (r *DownstreamRegistry) probe(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.states[name]
+	if !ok || s.err == nil {
+		return nil
+	}
+	interval := r.ProbeInterval
+	if interval <= 0 {
+		interval = DefaultDownstreamProbeInterval
+	}
+	if now := ContextClock(ctx).Now(); now.Sub(s.probed) >= interval {
+		s.probed = now
+		return nil
+	}
+	return s.err
+}
+
+// Fallback returns a middleware that sends a response with the given status and body in place of
+// running the handler while the downstream with the given name is marked down in the service
+// downstream registry. No body is sent if body is nil. The generated code mounts this middleware
+// on the actions that define a fallback in the design.
+func (service *Service) Fallback(downstream string, status int, body interface{}) Middleware {
+	service.Downstreams.Register(downstream)
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			err := service.Downstreams.probe(ctx, downstream)
+			if err == nil {
+				return h(ctx, rw, req)
+			}
+			LogInfo(ctx, "fallback", "downstream", downstream, "err", err)
+			if body == nil {
+				rw.WriteHeader(status)
+				return nil
+			}
+			return service.Send(ctx, status, body)
+		}
+	}
+}
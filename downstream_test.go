@@ -0,0 +1,103 @@
+package goa_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DownstreamRegistry", func() {
+	var registry *goa.DownstreamRegistry
+
+	BeforeEach(func() {
+		registry = goa.NewDownstreamRegistry()
+	})
+
+	It("reports unknown downstreams as available", func() {
+		Ω(registry.IsUp("ratings")).Should(BeTrue())
+		Ω(registry.Health()).Should(BeEmpty())
+	})
+
+	It("records the downstream states", func() {
+		registry.Register("ratings")
+		registry.Register("stock")
+		cause := errors.New("connection refused")
+		registry.MarkDown(context.Background(), "stock", cause)
+		Ω(registry.IsUp("ratings")).Should(BeTrue())
+		Ω(registry.IsUp("stock")).Should(BeFalse())
+		Ω(registry.Health()).Should(Equal(map[string]error{"ratings": nil, "stock": cause}))
+		registry.MarkUp("stock")
+		Ω(registry.Err("stock")).ShouldNot(HaveOccurred())
+	})
+
+	It("uses a default cause", func() {
+		registry.MarkDown(context.Background(), "stock", nil)
+		Ω(registry.Err("stock")).Should(HaveOccurred())
+		Ω(registry.Err("stock").(goa.ServiceError).ResponseStatus()).Should(Equal(503))
+	})
+})
+
+var _ = Describe("Fallback", func() {
+	var service *goa.Service
+	var clock *goatest.Clock
+	var called bool
+	var rw *httptest.ResponseRecorder
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		clock = goatest.NewClock(time.Unix(1500000000, 0))
+		service.WithClock(clock)
+		called = false
+	})
+
+	JustBeforeEach(func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called = true
+			rw.WriteHeader(200)
+			return nil
+		}
+		h = service.Fallback("ratings", 203, map[string]interface{}{"name": "Unrated"})(h)
+		req, err := http.NewRequest("GET", "/", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw = httptest.NewRecorder()
+		ctx := goa.NewContext(service.Context, rw, req, nil)
+		Ω(h(ctx, rw, req)).ShouldNot(HaveOccurred())
+	})
+
+	It("runs the handler when the downstream is available", func() {
+		Ω(called).Should(BeTrue())
+		Ω(rw.Code).Should(Equal(200))
+		Ω(service.Downstreams.Health()).Should(HaveKey("ratings"))
+	})
+
+	Context("with the downstream down", func() {
+		BeforeEach(func() {
+			service.Downstreams.MarkDown(service.Context, "ratings", nil)
+		})
+
+		It("sends the fallback response", func() {
+			Ω(called).Should(BeFalse())
+			Ω(rw.Code).Should(Equal(203))
+			Ω(rw.Body.String()).Should(MatchJSON(`{"name":"Unrated"}`))
+		})
+
+		Context("once the probe interval elapsed", func() {
+			BeforeEach(func() {
+				clock.Advance(goa.DefaultDownstreamProbeInterval)
+			})
+
+			It("lets the request through", func() {
+				Ω(called).Should(BeTrue())
+			})
+		})
+	})
+})
@@ -0,0 +1,243 @@
+package goa
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultDrainLogInterval is the default interval at which Shutdown logs the draining progress.
+const DefaultDrainLogInterval = 5 * time.Second
+
+type (
+	// InFlightRequest describes a request being handled by the service.
+	InFlightRequest struct {
+		// Controller is the name of the controller handling the request.
+		Controller string
+		// Action is the name of the action handling the request.
+		Action string
+		// Method is the request HTTP method.
+		Method string
+		// Path is the request URL path.
+		Path string
+		// Started is the time the service started handling the request.
+		Started time.Time
+	}
+
+	// DrainStatus describes the progress of the graceful shutdown of a service, see
+	// Service.Shutdown.
+	DrainStatus struct {
+		// Draining is true once Shutdown has been called.
+		Draining bool
+		// Started is the time Shutdown was called, zero if Draining is false.
+		Started time.Time
+		// Elapsed is the time elapsed since Shutdown was called, zero if Draining is false.
+		Elapsed time.Duration
+		// Requests lists the requests being handled ordered by start time.
+		Requests []*InFlightRequest
+	}
+
+	// drainer tracks the in-flight requests and the servers started by the service.
+	drainer struct {
+		mu       sync.Mutex
+		seq      uint64
+		requests map[uint64]*InFlightRequest
+		servers  []*http.Server
+		started  time.Time
+		idle     chan struct{} // closed when no request is in flight once draining
+		hooksRan bool          // whether the shutdown hooks ran
+	}
+)
+
+// newDrainer creates a drainer.
+func newDrainer() *drainer {
+	return &drainer{requests: make(map[uint64]*InFlightRequest)}
+}
+
+// DrainStatus returns the requests currently in flight and the progress of the graceful shutdown
+// if Shutdown has been called. Operators may use it to tune the shutdown timeout, e.g. by
+// exposing it via an administrative endpoint.
+func (service *Service) DrainStatus() *DrainStatus {
+	status := &DrainStatus{}
+	d := service.drain
+	if d == nil {
+		return status
+	}
+	now := ContextClock(service.Context).Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.started.IsZero() {
+		status.Draining = true
+		status.Started = d.started
+		status.Elapsed = now.Sub(d.started)
+	}
+	for _, r := range d.requests {
+		c := *r
+		status.Requests = append(status.Requests, &c)
+	}
+	sort.Sort(byStart(status.Requests))
+	return status
+}
+
+// Shutdown gracefully shuts down the servers started with ListenAndServe or ListenAndServeTLS:
+// it stops accepting new connections and waits for the in-flight requests to complete, including
+// the requests served by servers that were not started by the service. Shutdown logs the number
+// of in-flight requests and the actions handling them every DrainLogInterval as well as the total
+// drain time once done. The drain time is also recorded as the "goa.drain.duration" metric sample
+// in milliseconds and the number of in-flight requests as the "goa.drain.in_flight" gauge.
+//
+// Shutdown runs the hooks registered with OnShutdown once the drain completes or times out. It
+// returns the context error if ctx is done before all the requests complete, the requests still
+// in flight are logged in this case.
+func (service *Service) Shutdown(ctx context.Context) error {
+	d := service.drain
+	if d == nil {
+		return service.runShutdownHooks(ctx)
+	}
+	clock := ContextClock(service.Context)
+	start := clock.Now()
+	servers, idle := d.start(start)
+	service.LogInfo("drain started", "in_flight", len(service.DrainStatus().Requests))
+
+	errc := make(chan error, len(servers))
+	for _, s := range servers {
+		go func(s *http.Server) { errc <- s.Shutdown(ctx) }(s)
+	}
+	interval := service.DrainLogInterval
+	if interval <= 0 {
+		interval = DefaultDrainLogInterval
+	}
+	tick := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case tick <- struct{}{}:
+		default:
+		}
+	}
+	timer := clock.AfterFunc(interval, notify)
+
+	var err error
+	pending := len(servers)
+	for err == nil && (pending > 0 || idle != nil) {
+		select {
+		case e := <-errc:
+			pending--
+			err = e
+		case <-idle:
+			idle = nil
+		case <-tick:
+			service.logDrainProgress(service.LogInfo, "draining")
+			timer = clock.AfterFunc(interval, notify)
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	timer.Stop()
+
+	elapsed := clock.Now().Sub(start)
+	AddSample([]string{"goa", "drain", "duration"}, float32(elapsed)/float32(time.Millisecond))
+	if err != nil {
+		service.logDrainProgress(service.LogError, "drain incomplete", "err", err)
+	} else {
+		service.LogInfo("drain complete", "elapsed", elapsed.String())
+	}
+	if herr := service.runShutdownHooks(ctx); err == nil {
+		err = herr
+	}
+	return err
+}
+
+// logDrainProgress logs the number of in-flight requests, the actions handling them and the
+// elapsed drain time using log.
+func (service *Service) logDrainProgress(log func(string, ...interface{}), msg string, keyvals ...interface{}) {
+	status := service.DrainStatus()
+	SetGauge([]string{"goa", "drain", "in_flight"}, float32(len(status.Requests)))
+	counts := make(map[string]int)
+	for _, r := range status.Requests {
+		counts[r.Controller+"#"+r.Action]++
+	}
+	actions := make([]string, 0, len(counts))
+	for a, n := range counts {
+		actions = append(actions, fmt.Sprintf("%s:%d", a, n))
+	}
+	sort.Strings(actions)
+	keyvals = append([]interface{}{
+		"in_flight", len(status.Requests),
+		"actions", strings.Join(actions, ","),
+		"elapsed", status.Elapsed.String(),
+	}, keyvals...)
+	log(msg, keyvals...)
+}
+
+// serve runs the server, it returns nil if the server was shut down.
+func (service *Service) serve(srv *http.Server, listen func() error) error {
+	if err := listen(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// addServer registers the server so that Shutdown shuts it down.
+func (d *drainer) addServer(srv *http.Server) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.servers = append(d.servers, srv)
+}
+
+// track records that the request is in flight and returns the function that records its
+// completion.
+func (d *drainer) track(ctrl, action string, req *http.Request, started time.Time) func() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.seq++
+	id := d.seq
+	d.requests[id] = &InFlightRequest{
+		Controller: ctrl,
+		Action:     action,
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Started:    started,
+	}
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.requests, id)
+		if len(d.requests) == 0 && d.idle != nil {
+			close(d.idle)
+			d.idle = nil
+		}
+	}
+}
+
+// start records the start of the drain and returns the servers to shut down and a channel closed
+// once no request is in flight.
+func (d *drainer) start(now time.Time) ([]*http.Server, chan struct{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.started.IsZero() {
+		d.started = now
+	}
+	servers := append([]*http.Server(nil), d.servers...)
+	if len(d.requests) == 0 {
+		return servers, nil
+	}
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+	}
+	return servers, d.idle
+}
+
+// byStart sorts in-flight requests by start time.
+type byStart []*InFlightRequest
+
+func (b byStart) Len() int           { return len(b) }
+func (b byStart) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byStart) Less(i, j int) bool { return b[i].Started.Before(b[j].Started) }
@@ -0,0 +1,69 @@
+package goa_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Shutdown", func() {
+	var service *goa.Service
+	var release chan struct{}
+	var done chan struct{}
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.WithLogger(goa.NewLogger(log.New(ioutil.Discard, "", 0)))
+		rel, dn := make(chan struct{}), make(chan struct{})
+		release, done = rel, dn
+		ctrl := service.NewController("BottleController")
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			<-rel
+			return nil
+		}
+		mux := ctrl.MuxHandler("export", handler, nil)
+		req, _ := http.NewRequest("GET", "/bottles/export", nil)
+		go func() {
+			mux(&TestResponseWriter{ParentHeader: make(http.Header)}, req, nil)
+			close(dn)
+		}()
+		Eventually(func() int { return len(service.DrainStatus().Requests) }).Should(Equal(1))
+	})
+
+	It("reports the in-flight requests", func() {
+		status := service.DrainStatus()
+		Ω(status.Draining).Should(BeFalse())
+		Ω(status.Requests[0].Controller).Should(Equal("BottleController"))
+		Ω(status.Requests[0].Action).Should(Equal("export"))
+		Ω(status.Requests[0].Method).Should(Equal("GET"))
+		Ω(status.Requests[0].Path).Should(Equal("/bottles/export"))
+		close(release)
+		<-done
+		Ω(service.DrainStatus().Requests).Should(BeEmpty())
+	})
+
+	It("waits for the in-flight requests to complete", func() {
+		errc := make(chan error, 1)
+		go func() { errc <- service.Shutdown(context.Background()) }()
+		Eventually(func() bool { return service.DrainStatus().Draining }).Should(BeTrue())
+		Consistently(errc).ShouldNot(Receive())
+		close(release)
+		Eventually(errc).Should(Receive(BeNil()))
+	})
+
+	It("returns the context error when the drain times out", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := service.Shutdown(ctx)
+		Ω(err).Should(Equal(context.DeadlineExceeded))
+		Ω(service.DrainStatus().Requests).Should(HaveLen(1))
+		close(release)
+		<-done
+	})
+})
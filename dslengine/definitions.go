@@ -82,6 +82,9 @@ type (
 		// Values represents an enum validation as described at
 		// http://json-schema.org/latest/json-schema-validation.html#anchor76.
 		Values []interface{}
+		// OpenEnum is true if the enum validation is open: values not listed in Values are
+		// accepted and preserved instead of causing a validation error.
+		OpenEnum bool
 		// Format represents a format validation as described at
 		// http://json-schema.org/latest/json-schema-validation.html#anchor104.
 		Format string
@@ -94,12 +97,25 @@ type (
 		// Maximum represents a maximum value validation as described at
 		// http://json-schema.org/latest/json-schema-validation.html#anchor17.
 		Maximum *float64
+		// MinimumLiteral and MaximumLiteral are the exact decimal representations of Minimum
+		// and Maximum. They preserve the int64 and uint64 bounds that a float64 cannot
+		// represent exactly.
+		MinimumLiteral string
+		MaximumLiteral string
 		// MinLength represents an minimum length validation as described at
 		// http://json-schema.org/latest/json-schema-validation.html#anchor29.
 		MinLength *int
 		// MaxLength represents an maximum length validation as described at
 		// http://json-schema.org/latest/json-schema-validation.html#anchor26.
 		MaxLength *int
+		// Normalization is the Unicode normalization form string values must be in, one of
+		// "NFC", "NFD", "NFKC" or "NFKD".
+		Normalization string
+		// MaxRunes is the maximum number of Unicode code points of string values.
+		MaxRunes *int
+		// MaxGraphemes is the maximum number of user-perceived characters (grapheme clusters)
+		// of string values.
+		MaxGraphemes *int
 		// Required list the required fields of object attributes as described at
 		// http://json-schema.org/latest/json-schema-validation.html#anchor61.
 		Required []string
@@ -129,6 +145,7 @@ func (v *ValidationDefinition) Merge(other *ValidationDefinition) {
 	if v.Values == nil {
 		v.Values = other.Values
 	}
+	v.OpenEnum = v.OpenEnum || other.OpenEnum
 	if v.Format == "" {
 		v.Format = other.Format
 	}
@@ -137,19 +154,38 @@ func (v *ValidationDefinition) Merge(other *ValidationDefinition) {
 	}
 	if v.Minimum == nil || (other.Minimum != nil && *v.Minimum > *other.Minimum) {
 		v.Minimum = other.Minimum
+		v.MinimumLiteral = other.MinimumLiteral
 	}
 	if v.Maximum == nil || (other.Maximum != nil && *v.Maximum < *other.Maximum) {
 		v.Maximum = other.Maximum
+		v.MaximumLiteral = other.MaximumLiteral
 	}
-	if v.MinLength == nil || (other.MinLength != nil && *v.MinLength > *other.MinLength) {
-		v.MinLength = other.MinLength
-	}
-	if v.MaxLength == nil || (other.MaxLength != nil && *v.MaxLength < *other.MaxLength) {
-		v.MaxLength = other.MaxLength
+	v.MinLength = lowerInt(v.MinLength, other.MinLength)
+	v.MaxLength = greaterInt(v.MaxLength, other.MaxLength)
+	if v.Normalization == "" {
+		v.Normalization = other.Normalization
 	}
+	v.MaxRunes = greaterInt(v.MaxRunes, other.MaxRunes)
+	v.MaxGraphemes = greaterInt(v.MaxGraphemes, other.MaxGraphemes)
 	v.AddRequired(other.Required)
 }
 
+// lowerInt returns the lowest of a and b, nil values are overridden.
+func lowerInt(a, b *int) *int {
+	if a == nil || (b != nil && *a > *b) {
+		return b
+	}
+	return a
+}
+
+// greaterInt returns the greatest of a and b, nil values are overridden.
+func greaterInt(a, b *int) *int {
+	if a == nil || (b != nil && *a < *b) {
+		return b
+	}
+	return a
+}
+
 // AddRequired merges the required fields from other into v
 func (v *ValidationDefinition) AddRequired(required []string) {
 	for _, r := range required {
@@ -167,29 +203,39 @@ func (v *ValidationDefinition) AddRequired(required []string) {
 }
 
 // HasRequiredOnly returns true if the validation only has the Required field with a non-zero value.
+// Open enums do not count as they are not validated.
 func (v *ValidationDefinition) HasRequiredOnly() bool {
-	if len(v.Values) > 0 {
+	if len(v.Values) > 0 && !v.OpenEnum {
 		return false
 	}
-	if v.Format != "" || v.Pattern != "" {
+	if v.Format != "" || v.Pattern != "" || v.Normalization != "" {
 		return false
 	}
 	if (v.Minimum != nil) || (v.Maximum != nil) || (v.MaxLength != nil) {
 		return false
 	}
+	if (v.MaxRunes != nil) || (v.MaxGraphemes != nil) {
+		return false
+	}
 	return true
 }
 
 // Dup makes a shallow dup of the validation.
 func (v *ValidationDefinition) Dup() *ValidationDefinition {
 	return &ValidationDefinition{
-		Values:    v.Values,
-		Format:    v.Format,
-		Pattern:   v.Pattern,
-		Minimum:   v.Minimum,
-		Maximum:   v.Maximum,
-		MinLength: v.MinLength,
-		MaxLength: v.MaxLength,
-		Required:  v.Required,
+		Values:         v.Values,
+		OpenEnum:       v.OpenEnum,
+		Format:         v.Format,
+		Pattern:        v.Pattern,
+		Minimum:        v.Minimum,
+		Maximum:        v.Maximum,
+		MinimumLiteral: v.MinimumLiteral,
+		MaximumLiteral: v.MaximumLiteral,
+		MinLength:      v.MinLength,
+		MaxLength:      v.MaxLength,
+		Normalization:  v.Normalization,
+		MaxRunes:       v.MaxRunes,
+		MaxGraphemes:   v.MaxGraphemes,
+		Required:       v.Required,
 	}
 }
@@ -0,0 +1,124 @@
+package dslengine
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+type (
+	// LintRule is a design lint rule. Lint rules enforce conventions that go beyond the
+	// validity of the design, e.g. "all actions must have a description". The rules enabled by
+	// a root (see Linter) run once its definitions have been validated and finalized, the
+	// violations fail the DSL execution.
+	LintRule struct {
+		// Name identifies the rule when enabling it and in error messages, e.g.
+		// "action-description".
+		Name string
+		// Description explains what the rule enforces.
+		Description string
+		// Check is called with each definition visited by the lint pass and returns the
+		// violations of the rule by the definition, nil if there are none or if the rule
+		// does not apply to the definition. Check may use ValidationErrors to report
+		// several violations. The error messages should tell how to fix the design.
+		Check func(def Definition) error
+	}
+
+	// Linter is the interface implemented by the DSL roots that support linting.
+	Linter interface {
+		Root
+		// LintRules returns the names of the lint rules enabled for the root.
+		LintRules() []string
+		// IterateLintDefinitions calls it with each definition checked by the lint rules,
+		// including nested definitions.
+		IterateLintDefinitions(it func(Definition))
+	}
+)
+
+var (
+	// lintRules contains the registered lint rules indexed by name.
+	lintRules = make(map[string]*LintRule)
+
+	// lintMu protects lintRules.
+	lintMu sync.Mutex
+)
+
+// RegisterLintRule registers a lint rule so that it can be enabled by name. Registering a rule
+// with the name of a registered rule replaces it, this makes it possible to customize the
+// built-in rules. RegisterLintRule returns the rule so that it can be used in a package level
+// variable declaration:
+//
+//	var _ = dslengine.RegisterLintRule(&dslengine.LintRule{
+//		Name:        "resource-description",
+//		Description: "resources must have a description",
+//		Check: func(def dslengine.Definition) error {
+//			if r, ok := def.(*design.ResourceDefinition); ok && r.Description == "" {
+//				return fmt.Errorf("missing description, add a Description to the resource DSL")
+//			}
+//			return nil
+//		},
+//	})
+func RegisterLintRule(rule *LintRule) *LintRule {
+	lintMu.Lock()
+	defer lintMu.Unlock()
+	lintRules[rule.Name] = rule
+	return rule
+}
+
+// LookupLintRule returns the registered lint rule with the given name, nil if there is none.
+func LookupLintRule(name string) *LintRule {
+	lintMu.Lock()
+	defer lintMu.Unlock()
+	return lintRules[name]
+}
+
+// LintRules returns the registered lint rules sorted by name.
+func LintRules() []*LintRule {
+	lintMu.Lock()
+	defer lintMu.Unlock()
+	names := make([]string, 0, len(lintRules))
+	for n := range lintRules {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	rules := make([]*LintRule, len(names))
+	for i, n := range names {
+		rules[i] = lintRules[n]
+	}
+	return rules
+}
+
+// lint runs the lint rules enabled by the root on its definitions and records the violations in
+// Errors.
+func lint(root Linter) {
+	names := root.LintRules()
+	if len(names) == 0 {
+		return
+	}
+	var rules []*LintRule
+	for _, n := range names {
+		rule := LookupLintRule(n)
+		if rule == nil {
+			Errors = append(Errors, &Error{GoError: fmt.Errorf("%s: unknown lint rule %#v", root.DSLName(), n)})
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	errors := &ValidationErrors{}
+	root.IterateLintDefinitions(func(def Definition) {
+		for _, rule := range rules {
+			err := rule.Check(def)
+			if err == nil {
+				continue
+			}
+			verr := &ValidationErrors{}
+			verr.AddError(def, err)
+			for i, e := range verr.Errors {
+				errors.Add(verr.Definitions[i], "%s (lint rule %s)", e, rule.Name)
+			}
+		}
+	})
+	if err := errors.AsError(); err != nil {
+		Errors = append(Errors, &Error{GoError: err})
+	}
+}
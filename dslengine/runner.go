@@ -77,7 +77,11 @@ func Reset() {
 // Run runs the given root definitions. It iterates over the definition sets
 // multiple times to first execute the DSL, the validate the resulting
 // definitions and finally finalize them. The executed DSL may register new
-// roots to have them be executed (last) in the same run.
+// roots to have them be executed (last) in the same run. Finalizers may
+// record errors in Errors (e.g. for checks that require the final state of
+// the definitions), Run returns these errors once all definitions have been
+// finalized. Run then runs the lint rules enabled by the roots that implement
+// Linter.
 func Run() error {
 	if len(roots) == 0 {
 		return nil
@@ -113,6 +117,17 @@ func Run() error {
 	for _, root := range roots {
 		root.IterateSets(finalizeSet)
 	}
+	if Errors != nil {
+		return Errors
+	}
+	for _, root := range roots {
+		if l, ok := root.(Linter); ok {
+			lint(l)
+		}
+	}
+	if Errors != nil {
+		return Errors
+	}
 
 	return nil
 }
@@ -216,6 +231,12 @@ func InvalidArgError(expected string, actual interface{}) {
 		actual, reflect.TypeOf(actual), expected)
 }
 
+// CallerLocation returns the location in the user code of the call to the DSL function that
+// invoked CallerLocation. It returns an empty string and 0 if the location cannot be computed.
+func CallerLocation() (file string, line int) {
+	return computeErrorLocation()
+}
+
 // Error returns the error message.
 func (m MultiError) Error() string {
 	msgs := make([]string, len(m))
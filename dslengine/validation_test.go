@@ -275,3 +275,19 @@ var _ = Describe("Validation", func() {
 		})
 	})
 })
+
+var _ = Describe("ValidationDefinition", func() {
+	Context("Merge", func() {
+		It("keeps the enum open if either validation is open", func() {
+			values := []interface{}{"a", "b"}
+			v := &dslengine.ValidationDefinition{Values: values}
+			v.Merge(&dslengine.ValidationDefinition{Values: []interface{}{"c"}, OpenEnum: true})
+			Ω(v.Values).Should(Equal(values))
+			Ω(v.OpenEnum).Should(BeTrue())
+
+			v = &dslengine.ValidationDefinition{Values: values, OpenEnum: true}
+			v.Merge(&dslengine.ValidationDefinition{})
+			Ω(v.OpenEnum).Should(BeTrue())
+		})
+	})
+})
@@ -7,6 +7,10 @@ import (
 	"fmt"
 	"io"
 	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -66,7 +70,8 @@ type (
 	// known Content-Type to encoder mapping.
 	HTTPEncoder struct {
 		pools        map[string]*encoderPool // Registered encoders
-		contentTypes []string                // List of content types for type negotiation
+		contentTypes []string                // Sorted list of registered content types
+		preferred    []string                // Registered content types in order of registration
 	}
 )
 
@@ -102,7 +107,9 @@ func NewHTTPDecoder() *HTTPDecoder {
 	}
 }
 
-// Decode uses registered Decoders to unmarshal a body based on the contentType.
+// Decode uses registered Decoders to unmarshal a body based on the contentType. It returns an
+// error of class ErrUnsupportedMediaType listing the supported content types if no decoder is
+// registered for contentType and there is no default decoder.
 func (decoder *HTTPDecoder) Decode(v interface{}, body io.Reader, contentType string) error {
 	now := time.Now()
 	defer MeasureSince([]string{"goa", "decode", contentType}, now)
@@ -116,11 +123,20 @@ func (decoder *HTTPDecoder) Decode(v interface{}, body io.Reader, contentType st
 		}
 	}
 	p = decoder.pools[contentType]
+	if p == nil {
+		if base := suffixMediaType(contentType); base != "" {
+			p = decoder.pools[base]
+		}
+	}
 	if p == nil {
 		p = decoder.pools["*/*"]
 	}
 	if p == nil {
-		return nil
+		if len(decoder.pools) == 0 {
+			return nil
+		}
+		return ErrUnsupportedMediaType(fmt.Sprintf("no decoder registered for %#v", contentType),
+			"supported", strings.Join(decoder.contentTypes(), ", "))
 	}
 
 	// the decoderPool will handle whether or not a pool is actually in use
@@ -133,6 +149,36 @@ func (decoder *HTTPDecoder) Decode(v interface{}, body io.Reader, contentType st
 	return nil
 }
 
+// RequireContentType returns an error of class ErrUnsupportedMediaType if the media type of the
+// request Content-Type header is not one of the given MIME types. It is called by the code
+// generated for actions that use the "validation:strict_content_type" metadata prior to decoding
+// the request body.
+func RequireContentType(req *http.Request, mimeTypes ...string) error {
+	contentType := req.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		for _, mt := range mimeTypes {
+			if strings.EqualFold(mediaType, mt) {
+				return nil
+			}
+		}
+	}
+	if contentType == "" {
+		return ErrUnsupportedMediaType("missing Content-Type header", "supported", strings.Join(mimeTypes, ", "))
+	}
+	return ErrUnsupportedMediaType(fmt.Sprintf("unsupported Content-Type %#v", contentType), "supported", strings.Join(mimeTypes, ", "))
+}
+
+// contentTypes returns the sorted list of content types with registered decoders.
+func (decoder *HTTPDecoder) contentTypes() []string {
+	contentTypes := make([]string, 0, len(decoder.pools))
+	for contentType := range decoder.pools {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+	return contentTypes
+}
+
 // Register sets a specific decoder to be used for the specified content types. If a decoder is
 // already registered, it is overwritten.
 func (decoder *HTTPDecoder) Register(f DecoderFunc, contentTypes ...string) {
@@ -187,26 +233,22 @@ func (p *decoderPool) Put(d Decoder) {
 }
 
 // Encode uses the registered encoders and given content type to marshal and write the given value
-// using the given writer.
+// using the given writer. accept is the value of the request Accept header, the encoder used is
+// the one registered for the acceptable media type with the highest quality, see negotiate. It
+// returns an error of class ErrNotAcceptable listing the supported content types if none of the
+// registered encoders is acceptable, e.g. because accept excludes them with a quality of 0 and
+// there is no default encoder.
 func (encoder *HTTPEncoder) Encode(v interface{}, resp io.Writer, accept string) error {
 	now := time.Now()
 	if accept == "" {
 		accept = "*/*"
 	}
-	var contentType string
-	for _, t := range encoder.contentTypes {
-		if accept == "*/*" || accept == t {
-			contentType = accept
-			break
-		}
-	}
+	contentType := encoder.negotiate(accept)
 	defer MeasureSince([]string{"goa", "encode", contentType}, now)
 	p := encoder.pools[contentType]
-	if p == nil && contentType != "*/*" {
-		p = encoder.pools["*/*"]
-	}
 	if p == nil {
-		return fmt.Errorf("No encoder registered for %s and no default encoder", contentType)
+		return ErrNotAcceptable(fmt.Sprintf("no acceptable encoder registered for %#v", accept),
+			"supported", strings.Join(encoder.contentTypes, ", "))
 	}
 
 	// the encoderPool will handle whether or not a pool is actually in use
@@ -228,6 +270,9 @@ func (encoder *HTTPEncoder) Register(f EncoderFunc, contentTypes ...string) {
 		if err != nil {
 			mediaType = contentType
 		}
+		if _, ok := encoder.pools[mediaType]; !ok {
+			encoder.preferred = append(encoder.preferred, mediaType)
+		}
 		encoder.pools[mediaType] = p
 	}
 
@@ -236,6 +281,105 @@ func (encoder *HTTPEncoder) Register(f EncoderFunc, contentTypes ...string) {
 	for contentType := range encoder.pools {
 		encoder.contentTypes = append(encoder.contentTypes, contentType)
 	}
+	sort.Strings(encoder.contentTypes)
+}
+
+// negotiate returns the registered content type that best matches the given Accept header value
+// or "" if none of the registered content types is acceptable. The quality of a content type is
+// given by the most specific media range that matches it so that "text/*;q=0.5, text/plain" gives
+// "text/plain" a quality of 1 and a quality of 0 excludes the content type. Media types with a
+// structured syntax suffix such as "application/vnd.goa.error+cbor" match the content type of the
+// suffix ("application/cbor") if they are not registered themselves. Ties are broken using the
+// specificity of the matching ranges then the order in which the content types were registered.
+// The default encoder content type "*/*" is returned only if no other content type is acceptable.
+func (encoder *HTTPEncoder) negotiate(accept string) string {
+	var ranges []acceptable
+	for _, r := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(r))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+				q = f
+			}
+		}
+		ranges = append(ranges, acceptable{mediaType, q})
+	}
+	if len(ranges) == 0 {
+		// Be lenient with malformed Accept headers.
+		ranges = []acceptable{{"*/*", 1}}
+	}
+
+	var (
+		best        string
+		bestQ       float64
+		bestMatched int
+	)
+	for _, t := range encoder.preferred {
+		if t == "*/*" {
+			continue
+		}
+		q, matched := 0.0, 0
+		for _, r := range ranges {
+			if s := r.matches(t, encoder.pools); s > matched {
+				q, matched = r.q, s
+			}
+		}
+		if q > bestQ || q > 0 && q == bestQ && matched > bestMatched {
+			best, bestQ, bestMatched = t, q, matched
+		}
+	}
+	if best != "" {
+		return best
+	}
+	if _, ok := encoder.pools["*/*"]; ok {
+		for _, r := range ranges {
+			if r.q > 0 {
+				return "*/*"
+			}
+		}
+	}
+	return ""
+}
+
+// acceptable is a media range of an Accept header and its quality.
+type acceptable struct {
+	mediaType string
+	q         float64
+}
+
+// matches returns how specifically the media range matches the given registered content type:
+// 0 if it does not match, 1 for "*/*", 2 for a "type/*" range, 3 for a range whose structured
+// syntax suffix matches and 4 for an exact match.
+func (a acceptable) matches(contentType string, pools map[string]*encoderPool) int {
+	switch {
+	case a.mediaType == contentType:
+		return 4
+	case a.mediaType == "*/*":
+		return 1
+	case strings.HasSuffix(a.mediaType, "/*"):
+		if strings.HasPrefix(contentType, strings.TrimSuffix(a.mediaType, "*")) {
+			return 2
+		}
+	default:
+		if _, ok := pools[a.mediaType]; !ok && suffixMediaType(a.mediaType) == contentType {
+			return 3
+		}
+	}
+	return 0
+}
+
+// suffixMediaType returns the media type corresponding to the structured syntax suffix of the
+// given media type, e.g. "application/cbor" for "application/vnd.goa.error+cbor". It returns ""
+// if the media type has no suffix.
+func suffixMediaType(mediaType string) string {
+	i := strings.LastIndex(mediaType, "+")
+	if i == -1 || i == len(mediaType)-1 {
+		return ""
+	}
+	return "application/" + mediaType[i+1:]
 }
 
 // newEncodePool checks to see if the EncoderFactory returns reusable encoders and if so, creates
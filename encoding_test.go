@@ -0,0 +1,214 @@
+package goa_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/encoding/cbor"
+	"github.com/goadesign/goa/encoding/msgpack"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequireContentType", func() {
+	var contentType string
+	var err error
+
+	BeforeEach(func() {
+		contentType = ""
+	})
+
+	JustBeforeEach(func() {
+		req, _ := http.NewRequest("POST", "/bottles", nil)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		err = goa.RequireContentType(req, "application/json", "application/xml")
+	})
+
+	Context("with an accepted content type", func() {
+		BeforeEach(func() {
+			contentType = "application/json; charset=utf-8"
+		})
+
+		It("does not return an error", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("with an unsupported content type", func() {
+		BeforeEach(func() {
+			contentType = "text/plain"
+		})
+
+		It("returns an unsupported media type error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(415))
+			Ω(err.Error()).Should(ContainSubstring("text/plain"))
+		})
+	})
+
+	Context("with no content type", func() {
+		It("returns an unsupported media type error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(415))
+			Ω(err.Error()).Should(ContainSubstring("missing Content-Type"))
+		})
+	})
+})
+
+var _ = Describe("HTTPDecoder", func() {
+	var decoder *goa.HTTPDecoder
+
+	BeforeEach(func() {
+		decoder = goa.NewHTTPDecoder()
+		decoder.Register(goa.NewJSONDecoder, "application/json")
+		decoder.Register(goa.NewXMLDecoder, "application/xml")
+	})
+
+	It("decodes bodies with a registered content type", func() {
+		var v map[string]interface{}
+		err := decoder.Decode(&v, strings.NewReader(`{"foo":"bar"}`), "application/json; charset=utf-8")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v).Should(HaveKeyWithValue("foo", "bar"))
+	})
+
+	It("decodes bodies whose media type has a registered suffix", func() {
+		var v map[string]interface{}
+		err := decoder.Decode(&v, strings.NewReader(`{"foo":"bar"}`), "application/vnd.goa.bottle+json")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v).Should(HaveKeyWithValue("foo", "bar"))
+	})
+
+	It("returns an unsupported media type error listing the supported content types", func() {
+		var v interface{}
+		err := decoder.Decode(&v, strings.NewReader("foo"), "text/plain")
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(415))
+		Ω(err.(*goa.ErrorResponse).Meta).Should(ContainElement(HaveKeyWithValue("supported", "application/json, application/xml")))
+	})
+})
+
+var _ = Describe("HTTPEncoder", func() {
+	var encoder *goa.HTTPEncoder
+
+	BeforeEach(func() {
+		encoder = goa.NewHTTPEncoder()
+		encoder.Register(goa.NewJSONEncoder, "application/json")
+		encoder.Register(goa.NewXMLEncoder, "application/xml")
+	})
+
+	It("encodes values with an acceptable content type", func() {
+		var buf bytes.Buffer
+		err := encoder.Encode("foo", &buf, "application/json")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(buf.String()).Should(Equal("\"foo\"\n"))
+	})
+
+	It("returns a not acceptable error listing the supported content types", func() {
+		var buf bytes.Buffer
+		err := encoder.Encode("foo", &buf, "text/plain")
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(406))
+		Ω(err.(*goa.ErrorResponse).Meta).Should(ContainElement(HaveKeyWithValue("supported", "application/json, application/xml")))
+	})
+	It("negotiates the content type using the Accept header qualities", func() {
+		var buf bytes.Buffer
+		err := encoder.Encode("foo", &buf, "text/html, application/json;q=0.5, application/xml;q=0.9")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(buf.String()).Should(Equal("<string>foo</string>"))
+	})
+
+	It("uses the quality of the most specific matching media range", func() {
+		var buf bytes.Buffer
+		err := encoder.Encode("foo", &buf, "application/*;q=0.5, application/xml;q=0.4, application/json;q=0.3")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(buf.String()).Should(Equal("<string>foo</string>"))
+	})
+
+	It("excludes content types with a quality of 0", func() {
+		var buf bytes.Buffer
+		err := encoder.Encode("foo", &buf, "application/json;q=0, */*")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(buf.String()).Should(Equal("<string>foo</string>"))
+	})
+
+	It("prefers the content type registered first when wildcards match", func() {
+		for _, accept := range []string{"*/*", "application/*", "", "garbage;;"} {
+			var buf bytes.Buffer
+			err := encoder.Encode("foo", &buf, accept)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(buf.String()).Should(Equal("\"foo\"\n"), accept)
+		}
+	})
+
+	It("prefers more specific media ranges with equal qualities", func() {
+		var buf bytes.Buffer
+		err := encoder.Encode("foo", &buf, "*/*, application/xml")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(buf.String()).Should(Equal("<string>foo</string>"))
+	})
+
+	It("returns a not acceptable error if all content types are excluded", func() {
+		var buf bytes.Buffer
+		err := encoder.Encode("foo", &buf, "application/*;q=0")
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(406))
+	})
+
+	Context("with a default encoder", func() {
+		BeforeEach(func() {
+			encoder.Register(goa.NewXMLEncoder, "*/*")
+		})
+
+		It("uses it if no other content type is acceptable", func() {
+			var buf bytes.Buffer
+			err := encoder.Encode("foo", &buf, "text/plain")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(buf.String()).Should(Equal("<string>foo</string>"))
+		})
+
+		It("prefers the acceptable registered content types", func() {
+			var buf bytes.Buffer
+			err := encoder.Encode("foo", &buf, "*/*")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(buf.String()).Should(Equal("\"foo\"\n"))
+		})
+	})
+
+	It("matches media types using their suffix", func() {
+		var buf bytes.Buffer
+		err := encoder.Encode("foo", &buf, "application/vnd.goa.bottle+json")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(buf.String()).Should(Equal("\"foo\"\n"))
+	})
+
+	Context("with CBOR and MessagePack encoders", func() {
+		var decoder *goa.HTTPDecoder
+
+		BeforeEach(func() {
+			encoder.Register(cbor.NewEncoder, "application/cbor")
+			encoder.Register(msgpack.NewEncoder, "application/msgpack")
+			decoder = goa.NewHTTPDecoder()
+			decoder.Register(cbor.NewDecoder, "application/cbor")
+			decoder.Register(msgpack.NewDecoder, "application/msgpack")
+		})
+
+		It("round trips values", func() {
+			for _, mt := range []string{"application/cbor", "application/msgpack"} {
+				var buf bytes.Buffer
+				err := encoder.Encode(map[string]interface{}{"name": "bottle"}, &buf, mt+", application/json;q=0.1")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(buf.String()).ShouldNot(HavePrefix("{"))
+				var v map[string]interface{}
+				err = decoder.Decode(&v, &buf, mt)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(v).Should(HaveLen(1))
+				Ω(fmt.Sprintf("%s", v["name"])).Should(Equal("bottle"))
+			}
+		})
+	})
+})
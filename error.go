@@ -30,10 +30,14 @@ middleware) also generate an internal error response.
 package goa
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -41,6 +45,15 @@ var (
 	// ErrorMediaIdentifier is the media type identifier used for error responses.
 	ErrorMediaIdentifier = "application/vnd.goa.error"
 
+	// ProblemMediaIdentifier is the media type identifier used for error responses when
+	// problem details are enabled, see UseProblemDetails.
+	ProblemMediaIdentifier = "application/problem+json"
+
+	// ProblemTypeBase is the URI prefix used to build the "type" member of problem details: the
+	// type of an error is ProblemTypeBase followed by the error code. The type is "about:blank"
+	// if ProblemTypeBase is empty.
+	ProblemTypeBase = ""
+
 	// ErrBadRequest is a generic bad request error.
 	ErrBadRequest = NewErrorClass("bad_request", 400)
 
@@ -54,6 +67,14 @@ var (
 	// ErrInvalidEncoding is the error produced when a request body fails to be decoded.
 	ErrInvalidEncoding = NewErrorClass("invalid_encoding", 400)
 
+	// ErrUnsupportedMediaType is the error produced when the request Content-Type is not one of
+	// the media types accepted by an action that requires a strict content type.
+	ErrUnsupportedMediaType = NewErrorClass("unsupported_media_type", 415)
+
+	// ErrNotAcceptable is the error produced when none of the media types listed in the request
+	// Accept header can be produced by the registered encoders.
+	ErrNotAcceptable = NewErrorClass("not_acceptable", 406)
+
 	// ErrRequestBodyTooLarge is the error produced when the size of a request body exceeds
 	// MaxRequestBodyLength bytes.
 	ErrRequestBodyTooLarge = NewErrorClass("request_too_large", 413)
@@ -62,15 +83,51 @@ var (
 	// security scheme defined in the design.
 	ErrNoAuthMiddleware = NewErrorClass("no_auth_middleware", 500)
 
+	// ErrMissingScopes is the error produced when the credentials of a request do not grant all
+	// the scopes required by the action, see CheckScopes.
+	ErrMissingScopes = NewErrorClass("missing_scopes", 403)
+
 	// ErrInvalidFile is the error produced by ServeFiles when requested to serve non-existant
 	// or non-readable files.
 	ErrInvalidFile = NewErrorClass("invalid_file", 404)
 
+	// ErrPreconditionFailed is the error produced when the conditional headers of a request to a
+	// mutating action do not match the current state of the resource, see CheckPreconditions.
+	ErrPreconditionFailed = NewErrorClass("precondition_failed", 412)
+
+	// ErrDownstreamUnavailable is the error recorded when a downstream service is marked
+	// unavailable without a specific cause, see DownstreamRegistry.
+	ErrDownstreamUnavailable = NewErrorClass("downstream_unavailable", 503)
+
 	// ErrNotFound is the error returned to requests that don't match a registered handler.
 	ErrNotFound = NewErrorClass("not_found", 404)
 
 	// ErrInternal is the class of error used for uncaught errors.
 	ErrInternal = NewErrorClass("internal", 500)
+
+	// ErrClientDisconnected is the class of errors returned when the client closes the
+	// connection before the response is written. These errors are not internal errors, they
+	// are logged and counted using the "client_disconnected" classification and no response is
+	// sent.
+	ErrClientDisconnected = NewErrorClass("client_disconnected", 499)
+
+	// ErrorResponseFields defines the names of the fields of the JSON representation of error
+	// responses. Services that must follow existing conventions may override the names, for
+	// example:
+	//
+	//	goa.ErrorResponseFields.Detail = "message"
+	//	goa.ErrorResponseFields.Code = "error_code"
+	//
+	// Note that the names are used both to encode and decode error responses so that clients
+	// must use the same configuration as the service.
+	ErrorResponseFields = ErrorFieldNames{
+		ID:     "id",
+		Code:   "code",
+		Status: "status",
+		Detail: "detail",
+		Meta:   "meta",
+		Errors: "errors",
+	}
 )
 
 type (
@@ -106,14 +163,98 @@ type (
 		Status int `json:"status" xml:"status" form:"status"`
 		// Detail describes the specific error occurrence.
 		Detail string `json:"detail" xml:"detail" form:"detail"`
-		// Meta contains additional key/value pairs useful to clients.
+		// Meta contains additional key/value pairs useful to clients. Use MetaMap to retrieve
+		// the pairs as a single map and UseErrorMetaMap to serialize them as a JSON object.
 		Meta []map[string]interface{} `json:"meta,omitempty" xml:"meta,omitempty" form:"meta,omitempty"`
+		// Errors lists the individual validation errors, one per failing parameter, header or
+		// payload attribute. MergeErrors preserves the validation errors of the merged errors.
+		Errors []*FieldError `json:"errors,omitempty" xml:"errors,omitempty" form:"errors,omitempty"`
+	}
+
+	// FieldError describes the validation error of a single parameter, header or payload
+	// attribute.
+	FieldError struct {
+		// Field is the name of the parameter or header or the path to the payload attribute.
+		Field string `json:"field" xml:"field" form:"field"`
+		// Code identifies the validation that failed, e.g. "missing" or "invalid_format".
+		Code string `json:"code" xml:"code" form:"code"`
+		// Message describes the error.
+		Message string `json:"message" xml:"message" form:"message"`
+		// Value is the invalid value if any.
+		Value interface{} `json:"value,omitempty" xml:"value,omitempty" form:"value,omitempty"`
 	}
+
+	// ErrorFieldNames lists the names of the fields used in the JSON representation of
+	// ErrorResponse, see ErrorResponseFields.
+	ErrorFieldNames struct {
+		// ID is the name of the field containing the error ID.
+		ID string
+		// Code is the name of the field containing the error code.
+		Code string
+		// Status is the name of the field containing the HTTP status.
+		Status string
+		// Detail is the name of the field containing the error detail.
+		Detail string
+		// Meta is the name of the field containing the error metadata.
+		Meta string
+		// Errors is the name of the field containing the individual validation errors.
+		Errors string
+	}
+)
+
+var (
+	// problemDetails is true if error responses are serialized as RFC 7807 problem details.
+	problemDetails bool
+	// errorMediaIdentifier is the value of ErrorMediaIdentifier before problem details were
+	// enabled.
+	errorMediaIdentifier string
 )
 
+// UseProblemDetails controls whether ErrorResponse is serialized as a RFC 7807 problem details
+// object (https://tools.ietf.org/html/rfc7807) with the "type", "title", "status", "detail" and
+// "instance" members instead of using ErrorResponseFields. The error code and metadata are
+// serialized as the "code" and "meta" extension members and the error ID as the instance.
+// Enabling problem details also sets ErrorMediaIdentifier to ProblemMediaIdentifier so that the
+// error handler middleware uses the corresponding content type, disabling them restores the
+// previous value. UseProblemDetails must be called before the service starts handling requests.
+func UseProblemDetails(enable bool) {
+	if enable == problemDetails {
+		return
+	}
+	problemDetails = enable
+	if enable {
+		errorMediaIdentifier = ErrorMediaIdentifier
+		ErrorMediaIdentifier = ProblemMediaIdentifier
+	} else {
+		ErrorMediaIdentifier = errorMediaIdentifier
+	}
+}
+
+// errorMetaMap is true if the error metadata is serialized as a JSON object.
+var errorMetaMap bool
+
+// UseErrorMetaMap controls whether the metadata of ErrorResponse is serialized as a single JSON
+// object mapping the keys to the values instead of a list of objects containing one key/value
+// pair each. With the object representation keys that appear more than once keep the last value
+// and MergeErrors overrides the values of the existing keys instead of appending duplicates.
+// ErrorResponse decodes both representations regardless of this setting so that clients can be
+// upgraded before the services they call. UseErrorMetaMap must be called before the service
+// starts handling requests.
+func UseErrorMetaMap(enable bool) {
+	errorMetaMap = enable
+}
+
 // NewErrorClass creates a new error class.
-// It is the responsibility of the client to guarantee uniqueness of code.
+// The class is recorded in ErrorClasses unless a class with the same code already is, use
+// ErrorClasses.MustRegister instead to guarantee uniqueness of code.
 func NewErrorClass(code string, status int) ErrorClass {
+	class := newErrorClass(code, status)
+	ErrorClasses.record(code, status, class)
+	return class
+}
+
+// newErrorClass creates a new error class without recording it.
+func newErrorClass(code string, status int) ErrorClass {
 	return func(message interface{}, keyvals ...interface{}) error {
 		var msg string
 		switch actual := message.(type) {
@@ -148,33 +289,36 @@ func MissingPayloadError() error {
 // defined in the design.
 func InvalidParamTypeError(name string, val interface{}, expected string) error {
 	msg := fmt.Sprintf("invalid value %#v for parameter %#v, must be a %s", val, name, expected)
-	return ErrInvalidRequest(msg, "param", name, "value", val, "expected", expected)
+	err := ErrInvalidRequest(msg, "param", name, "value", val, "expected", expected)
+	return withFieldError(err, name, "invalid_type", val)
 }
 
 // MissingParamError is the error produced for requests that are missing path or querystring
 // parameters.
 func MissingParamError(name string) error {
 	msg := fmt.Sprintf("missing required parameter %#v", name)
-	return ErrInvalidRequest(msg, "name", name)
+	return withFieldError(ErrInvalidRequest(msg, "name", name), name, "missing", nil)
 }
 
 // InvalidAttributeTypeError is the error produced when the type of payload field does not match
 // the type defined in the design.
 func InvalidAttributeTypeError(ctx string, val interface{}, expected string) error {
 	msg := fmt.Sprintf("type of %s must be %s but got value %#v", ctx, expected, val)
-	return ErrInvalidRequest(msg, "attribute", ctx, "value", val, "expected", expected)
+	err := ErrInvalidRequest(msg, "attribute", ctx, "value", val, "expected", expected)
+	return withFieldError(err, ctx, "invalid_type", val)
 }
 
 // MissingAttributeError is the error produced when a request payload is missing a required field.
 func MissingAttributeError(ctx, name string) error {
 	msg := fmt.Sprintf("attribute %#v of %s is missing and required", name, ctx)
-	return ErrInvalidRequest(msg, "attribute", name, "parent", ctx)
+	err := ErrInvalidRequest(msg, "attribute", name, "parent", ctx)
+	return withFieldError(err, ctx+"."+name, "missing", nil)
 }
 
 // MissingHeaderError is the error produced when a request is missing a required header.
 func MissingHeaderError(name string) error {
 	msg := fmt.Sprintf("missing required HTTP header %#v", name)
-	return ErrInvalidRequest(msg, "name", name)
+	return withFieldError(ErrInvalidRequest(msg, "name", name), name, "missing", nil)
 }
 
 // InvalidEnumValueError is the error produced when the value of a parameter or payload field does
@@ -185,32 +329,37 @@ func InvalidEnumValueError(ctx string, val interface{}, allowed []interface{}) e
 		elems[i] = fmt.Sprintf("%#v", a)
 	}
 	msg := fmt.Sprintf("value of %s must be one of %s but got value %#v", ctx, strings.Join(elems, ", "), val)
-	return ErrInvalidRequest(msg, "attribute", ctx, "value", val, "expected", strings.Join(elems, ", "))
+	err := ErrInvalidRequest(msg, "attribute", ctx, "value", val, "expected", strings.Join(elems, ", "))
+	return withFieldError(err, ctx, "invalid_enum_value", val)
 }
 
 // InvalidFormatError is the error produced when the value of a parameter or payload field does not
 // match the format validation defined in the design.
 func InvalidFormatError(ctx, target string, format Format, formatError error) error {
 	msg := fmt.Sprintf("%s must be formatted as a %s but got value %#v, %s", ctx, format, target, formatError.Error())
-	return ErrInvalidRequest(msg, "attribute", ctx, "value", target, "expected", format, "error", formatError.Error())
+	err := ErrInvalidRequest(msg, "attribute", ctx, "value", target, "expected", format, "error", formatError.Error())
+	return withFieldError(err, ctx, "invalid_format", target)
 }
 
 // InvalidPatternError is the error produced when the value of a parameter or payload field does
 // not match the pattern validation defined in the design.
 func InvalidPatternError(ctx, target string, pattern string) error {
 	msg := fmt.Sprintf("%s must match the regexp %#v but got value %#v", ctx, pattern, target)
-	return ErrInvalidRequest(msg, "attribute", ctx, "value", target, "regexp", pattern)
+	err := ErrInvalidRequest(msg, "attribute", ctx, "value", target, "regexp", pattern)
+	return withFieldError(err, ctx, "invalid_pattern", target)
 }
 
 // InvalidRangeError is the error produced when the value of a parameter or payload field does
-// not match the range validation defined in the design.
-func InvalidRangeError(ctx string, target interface{}, value int, min bool) error {
+// not match the range validation defined in the design. value is the minimum or maximum bound, an
+// integer (including int64 and uint64 values) or a float.
+func InvalidRangeError(ctx string, target interface{}, value interface{}, min bool) error {
 	comp := "greater or equal"
 	if !min {
 		comp = "lesser or equal"
 	}
-	msg := fmt.Sprintf("%s must be %s than %d but got value %#v", ctx, comp, value, target)
-	return ErrInvalidRequest(msg, "attribute", ctx, "value", target, "comp", comp, "expected", value)
+	msg := fmt.Sprintf("%s must be %s than %v but got value %#v", ctx, comp, value, target)
+	err := ErrInvalidRequest(msg, "attribute", ctx, "value", target, "comp", comp, "expected", value)
+	return withFieldError(err, ctx, "invalid_range", target)
 }
 
 // InvalidLengthError is the error produced when the value of a parameter or payload field does
@@ -221,7 +370,32 @@ func InvalidLengthError(ctx string, target interface{}, ln, value int, min bool)
 		comp = "lesser or equal"
 	}
 	msg := fmt.Sprintf("length of %s must be %s than %d but got value %#v (len=%d)", ctx, comp, value, target, ln)
-	return ErrInvalidRequest(msg, "attribute", ctx, "value", target, "len", ln, "comp", comp, "expected", value)
+	err := ErrInvalidRequest(msg, "attribute", ctx, "value", target, "len", ln, "comp", comp, "expected", value)
+	return withFieldError(err, ctx, "invalid_length", target)
+}
+
+// InvalidNormalizationError is the error produced when the value of a parameter or payload field
+// is not in the Unicode normalization form defined in the design.
+func InvalidNormalizationError(ctx string, target interface{}, form string) error {
+	msg := fmt.Sprintf("%s must be in Unicode normalization form %s but got value %#v", ctx, form, target)
+	err := ErrInvalidRequest(msg, "attribute", ctx, "value", target, "expected", form)
+	return withFieldError(err, ctx, "invalid_normalization", target)
+}
+
+// InvalidCharacterCountError is the error produced when the number of characters of the value of
+// a parameter or payload field exceeds the maximum defined in the design. unit is "runes" or
+// "graphemes" and indicates how the characters are counted.
+func InvalidCharacterCountError(ctx string, target interface{}, unit string, count, value int) error {
+	msg := fmt.Sprintf("number of %s of %s must be lesser or equal than %d but got value %#v (count=%d)", unit, ctx, value, target, count)
+	err := ErrInvalidRequest(msg, "attribute", ctx, "value", target, "unit", unit, "count", count, "comp", "lesser or equal", "expected", value)
+	return withFieldError(err, ctx, "invalid_"+strings.TrimSuffix(unit, "s")+"_count", target)
+}
+
+// withFieldError records the validation error of the given field in err.
+func withFieldError(err error, field, code string, val interface{}) error {
+	e := err.(*ErrorResponse)
+	e.Errors = []*FieldError{{Field: field, Code: code, Message: e.Detail, Value: val}}
+	return e
 }
 
 // NoAuthMiddleware is the error produced when goa is unable to lookup a auth middleware for a
@@ -231,6 +405,20 @@ func NoAuthMiddleware(schemeName string) error {
 	return ErrNoAuthMiddleware(msg, "scheme", schemeName)
 }
 
+// MissingScopesError is the error produced when the credentials of a request do not grant the
+// given required scopes. The scopes are listed in the "missing_scopes" metadata.
+func MissingScopesError(missing []string) error {
+	msg := fmt.Sprintf("missing required scopes %s", strings.Join(missing, ", "))
+	return ErrMissingScopes(msg, "missing_scopes", missing)
+}
+
+// MissingAuthMiddleware is the error produced when verifying that auth middlewares are mounted
+// for all the security schemes defined in the design and some are not.
+func MissingAuthMiddleware(schemeNames ...string) error {
+	msg := fmt.Sprintf("Auth middleware for security schemes %s are not mounted", strings.Join(schemeNames, ", "))
+	return ErrNoAuthMiddleware(msg, "schemes", schemeNames)
+}
+
 // Error returns the error occurrence details.
 func (e *ErrorResponse) Error() string {
 	msg := fmt.Sprintf("[%s] %d %s: %s", e.ID, e.Status, e.Code, e.Detail)
@@ -242,12 +430,205 @@ func (e *ErrorResponse) Error() string {
 	return msg
 }
 
+// MetaMap returns the error metadata as a single map. The value of a key that appears more than
+// once in Meta is the value of the last occurrence. MetaMap returns nil if there is no metadata.
+func (e *ErrorResponse) MetaMap() map[string]interface{} {
+	if len(e.Meta) == 0 {
+		return nil
+	}
+	m := make(map[string]interface{}, len(e.Meta))
+	for _, val := range e.Meta {
+		for k, v := range val {
+			m[k] = v
+		}
+	}
+	return m
+}
+
 // ResponseStatus is the status used to build responses.
 func (e *ErrorResponse) ResponseStatus() int { return e.Status }
 
 // Token is the unique error occurrence identifier.
 func (e *ErrorResponse) Token() string { return e.ID }
 
+// MarshalJSON encodes the error using the field names defined by ErrorResponseFields or as a
+// problem details object if enabled with UseProblemDetails.
+func (e *ErrorResponse) MarshalJSON() ([]byte, error) {
+	if problemDetails {
+		p, err := e.problem()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(p)
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(name string, val interface{}) error {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+		return nil
+	}
+	names := ErrorResponseFields
+	if err := write(names.ID, e.ID); err != nil {
+		return nil, err
+	}
+	if err := write(names.Code, e.Code); err != nil {
+		return nil, err
+	}
+	if err := write(names.Status, e.Status); err != nil {
+		return nil, err
+	}
+	if err := write(names.Detail, e.Detail); err != nil {
+		return nil, err
+	}
+	if len(e.Meta) > 0 {
+		if err := write(names.Meta, e.metaValue()); err != nil {
+			return nil, err
+		}
+	}
+	if len(e.Errors) > 0 && names.Errors != "" {
+		if err := write(names.Errors, e.Errors); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes an error encoded using the field names defined by ErrorResponseFields or
+// as a problem details object if enabled with UseProblemDetails.
+func (e *ErrorResponse) UnmarshalJSON(b []byte) error {
+	if problemDetails {
+		var p problem
+		if err := json.Unmarshal(b, &p); err != nil {
+			return err
+		}
+		meta, err := decodeErrorMeta(p.Meta)
+		if err != nil {
+			return fmt.Errorf("invalid error response field \"meta\": %s", err)
+		}
+		*e = ErrorResponse{ID: p.Instance, Code: p.Code, Status: p.Status, Detail: p.Detail, Meta: meta, Errors: p.Errors}
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	names := ErrorResponseFields
+	targets := map[string]interface{}{
+		names.ID:     &e.ID,
+		names.Code:   &e.Code,
+		names.Status: &e.Status,
+		names.Detail: &e.Detail,
+	}
+	if names.Errors != "" {
+		targets[names.Errors] = &e.Errors
+	}
+	for name, target := range targets {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			return fmt.Errorf("invalid error response field %#v: %s", name, err)
+		}
+	}
+	meta, err := decodeErrorMeta(fields[names.Meta])
+	if err != nil {
+		return fmt.Errorf("invalid error response field %#v: %s", names.Meta, err)
+	}
+	e.Meta = meta
+	return nil
+}
+
+// metaValue returns the value used to serialize the error metadata.
+func (e *ErrorResponse) metaValue() interface{} {
+	if errorMetaMap {
+		return e.MetaMap()
+	}
+	return e.Meta
+}
+
+// decodeErrorMeta decodes error metadata serialized either as a list of objects or as a single
+// object. The pairs of a single object are sorted by key.
+func decodeErrorMeta(raw json.RawMessage) ([]map[string]interface{}, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	if raw[0] != '{' {
+		var meta []map[string]interface{}
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, err
+		}
+		return meta, nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	meta := make([]map[string]interface{}, len(keys))
+	for i, k := range keys {
+		meta[i] = map[string]interface{}{k: m[k]}
+	}
+	return meta, nil
+}
+
+// problem is the RFC 7807 representation of ErrorResponse.
+type problem struct {
+	Type     string          `json:"type"`
+	Title    string          `json:"title"`
+	Status   int             `json:"status"`
+	Detail   string          `json:"detail"`
+	Instance string          `json:"instance"`
+	Code     string          `json:"code"`
+	Meta     json.RawMessage `json:"meta,omitempty"`
+	Errors   []*FieldError   `json:"errors,omitempty"`
+}
+
+// problem builds the problem details representation of the error.
+func (e *ErrorResponse) problem() (*problem, error) {
+	typ := "about:blank"
+	if ProblemTypeBase != "" {
+		typ = ProblemTypeBase + e.Code
+	}
+	var meta json.RawMessage
+	if len(e.Meta) > 0 {
+		b, err := json.Marshal(e.metaValue())
+		if err != nil {
+			return nil, err
+		}
+		meta = b
+	}
+	return &problem{
+		Type:     typ,
+		Title:    http.StatusText(e.Status),
+		Status:   e.Status,
+		Detail:   e.Detail,
+		Instance: e.ID,
+		Code:     e.Code,
+		Meta:     meta,
+		Errors:   e.Errors,
+	}, nil
+}
+
 // MergeErrors updates an error by merging another into it. It first converts other into a
 // ServiceError if not already one - producing an internal error in that case. The merge algorithm
 // is:
@@ -257,8 +638,10 @@ func (e *ErrorResponse) Token() string { return e.ID }
 // * If the status or code of e and other don't match then the result is a 400 "bad_request"
 //
 // The Detail field is updated by concatenating the Detail fields of e and other separated
-// by a semi-colon. The MetaValues field of is updated by merging the map of other MetaValues
-// into e's where values in e with identical keys to values in other get overwritten.
+// by a semi-colon. The Meta field is updated by appending the key/value pairs of other sorted by
+// key. If UseErrorMetaMap is enabled the values of the keys that e already defines get overwritten
+// with the values of other instead. The Errors field is updated by appending the validation
+// errors of other so that the merged error lists each failing field individually.
 //
 // Merge returns the updated error. This is useful in case the error was initially nil in
 // which case other is returned.
@@ -287,13 +670,41 @@ func MergeErrors(err, other error) error {
 	e.Detail = e.Detail + "; " + o.Detail
 
 	for _, val := range o.Meta {
-		for k, v := range val {
-			e.Meta = append(e.Meta, map[string]interface{}{k: v})
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if errorMetaMap && e.setMeta(k, val[k]) {
+				continue
+			}
+			e.Meta = append(e.Meta, map[string]interface{}{k: val[k]})
 		}
 	}
+	e.Errors = append(e.Errors, o.Errors...)
 	return e
 }
 
+// setMeta sets the value of all the occurrences of key k in the error metadata to v. It returns
+// false if the metadata does not contain k.
+func (e *ErrorResponse) setMeta(k string, v interface{}) bool {
+	found := false
+	for i, val := range e.Meta {
+		if _, ok := val[k]; !ok {
+			continue
+		}
+		m := make(map[string]interface{}, len(val))
+		for mk, mv := range val {
+			m[mk] = mv
+		}
+		m[k] = v
+		e.Meta[i] = m
+		found = true
+	}
+	return found
+}
+
 func asErrorResponse(err error) *ErrorResponse {
 	e, ok := err.(*ErrorResponse)
 	if !ok {
@@ -306,7 +717,11 @@ func asErrorResponse(err error) *ErrorResponse {
 // values is n^2 / 2^49. For n = 1 million this gives around 1 chance in 500. 6 bytes seems to be a
 // good trade-off between probability of clashes and length of ID (6 * 4/3 = 8 chars) since clashes
 // are not catastrophic.
+// Error IDs are sequential when SequentialIDs is in effect.
 func newErrorID() string {
+	if id, ok := NextSequentialID(); ok {
+		return id
+	}
 	b := make([]byte, 6)
 	io.ReadFull(rand.Reader, b)
 	return base64.StdEncoding.EncodeToString(b)
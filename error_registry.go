@@ -0,0 +1,113 @@
+package goa
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+type (
+	// ErrorClassRegistry tracks the error classes of a service indexed by code so that two
+	// classes cannot share the same code and the error codes can be documented.
+	ErrorClassRegistry struct {
+		mu      sync.RWMutex
+		classes map[string]*ErrorClassInfo
+	}
+
+	// ErrorClassInfo describes a registered error class.
+	ErrorClassInfo struct {
+		// Code is the code of the errors created by the class.
+		Code string `json:"code"`
+		// Status is the HTTP status of the responses that carry the errors.
+		Status int `json:"status"`
+		// Description describes the errors if any.
+		Description string `json:"description,omitempty"`
+		// Class is the error class.
+		Class ErrorClass `json:"-"`
+	}
+)
+
+// ErrorClasses is the registry of the error classes created with NewErrorClass, including the
+// goa error classes. Use ErrorClasses.MustRegister to create error classes whose code must not
+// collide with an existing class.
+var ErrorClasses = NewErrorClassRegistry()
+
+// NewErrorClassRegistry returns an empty error class registry.
+func NewErrorClassRegistry() *ErrorClassRegistry {
+	return &ErrorClassRegistry{classes: make(map[string]*ErrorClassInfo)}
+}
+
+// Register creates an error class with the given code, HTTP status and description. It returns an
+// error if a class with the same code is already registered.
+func (r *ErrorClassRegistry) Register(code string, status int, description string) (ErrorClass, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.classes[code]; ok {
+		return nil, fmt.Errorf("error code %#v is already registered with status %d", code, existing.Status)
+	}
+	class := newErrorClass(code, status)
+	r.classes[code] = &ErrorClassInfo{Code: code, Status: status, Description: description, Class: class}
+	return class, nil
+}
+
+// MustRegister is like Register but panics if a class with the same code is already registered.
+// It is intended for initializing package level error class variables:
+//
+//	var ErrOutOfStock = goa.ErrorClasses.MustRegister("out_of_stock", 409, "The item is out of stock")
+func (r *ErrorClassRegistry) MustRegister(code string, status int, description string) ErrorClass {
+	class, err := r.Register(code, status, description)
+	if err != nil {
+		panic(err)
+	}
+	return class
+}
+
+// Lookup returns the class registered with the given code if any.
+func (r *ErrorClassRegistry) Lookup(code string) (*ErrorClassInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.classes[code]
+	return info, ok
+}
+
+// Catalog returns the registered error classes sorted by code.
+func (r *ErrorClassRegistry) Catalog() []*ErrorClassInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	infos := make([]*ErrorClassInfo, 0, len(r.classes))
+	for _, info := range r.classes {
+		infos = append(infos, info)
+	}
+	sort.Sort(byCode(infos))
+	return infos
+}
+
+// WriteCatalog writes the JSON representation of the catalog to w: a list of objects with the
+// "code", "status" and optional "description" fields.
+func (r *ErrorClassRegistry) WriteCatalog(w io.Writer) error {
+	b, err := json.MarshalIndent(r.Catalog(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// record adds the class created by NewErrorClass to the registry unless a class with the same code
+// is already registered.
+func (r *ErrorClassRegistry) record(code string, status int, class ErrorClass) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.classes[code]; !ok {
+		r.classes[code] = &ErrorClassInfo{Code: code, Status: status, Class: class}
+	}
+}
+
+// byCode sorts error class infos by code.
+type byCode []*ErrorClassInfo
+
+func (b byCode) Len() int           { return len(b) }
+func (b byCode) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byCode) Less(i, j int) bool { return b[i].Code < b[j].Code }
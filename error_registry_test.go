@@ -0,0 +1,72 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ErrorClassRegistry", func() {
+	var registry *ErrorClassRegistry
+
+	BeforeEach(func() {
+		registry = NewErrorClassRegistry()
+	})
+
+	Describe("Register", func() {
+		It("creates error classes", func() {
+			class, err := registry.Register("out_of_stock", 409, "The item is out of stock")
+			Ω(err).ShouldNot(HaveOccurred())
+			e := class("no more bottles").(*ErrorResponse)
+			Ω(e.Code).Should(Equal("out_of_stock"))
+			Ω(e.Status).Should(Equal(409))
+		})
+
+		It("rejects duplicate codes", func() {
+			_, err := registry.Register("out_of_stock", 409, "")
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = registry.Register("out_of_stock", 410, "")
+			Ω(err).Should(HaveOccurred())
+			Ω(func() { registry.MustRegister("out_of_stock", 409, "") }).Should(Panic())
+		})
+	})
+
+	Describe("Lookup", func() {
+		It("returns the registered classes", func() {
+			registry.MustRegister("out_of_stock", 409, "The item is out of stock")
+			info, ok := registry.Lookup("out_of_stock")
+			Ω(ok).Should(BeTrue())
+			Ω(info.Status).Should(Equal(409))
+			Ω(info.Class).ShouldNot(BeNil())
+			_, ok = registry.Lookup("unknown")
+			Ω(ok).Should(BeFalse())
+		})
+	})
+
+	Describe("WriteCatalog", func() {
+		It("writes the classes sorted by code", func() {
+			registry.MustRegister("out_of_stock", 409, "The item is out of stock")
+			registry.MustRegister("expired", 410, "")
+			var buf bytes.Buffer
+			Ω(registry.WriteCatalog(&buf)).Should(Succeed())
+			var catalog []map[string]interface{}
+			Ω(json.Unmarshal(buf.Bytes(), &catalog)).Should(Succeed())
+			Ω(catalog).Should(Equal([]map[string]interface{}{
+				{"code": "expired", "status": 410.0},
+				{"code": "out_of_stock", "status": 409.0, "description": "The item is out of stock"},
+			}))
+		})
+	})
+
+	Describe("ErrorClasses", func() {
+		It("records the classes created with NewErrorClass", func() {
+			info, ok := ErrorClasses.Lookup("not_found")
+			Ω(ok).Should(BeTrue())
+			Ω(info.Status).Should(Equal(404))
+			_, err := ErrorClasses.Register("not_found", 404, "")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
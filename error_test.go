@@ -29,6 +29,124 @@ var _ = Describe("Error", func() {
 		Ω(err).ShouldNot(HaveOccurred())
 		Ω(string(b)).Should(Equal(`{"id":"foo","code":"invalid","status":400,"detail":"error","meta":[{"what":42}]}`))
 	})
+
+	It("deserializes from JSON", func() {
+		var e ErrorResponse
+		err := json.Unmarshal([]byte(`{"id":"foo","code":"invalid","status":400,"detail":"error"}`), &e)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(e).Should(Equal(ErrorResponse{ID: id, Code: code, Status: status, Detail: detail}))
+	})
+
+	Context("with custom field names", func() {
+		var fields ErrorFieldNames
+
+		BeforeEach(func() {
+			fields = ErrorResponseFields
+			ErrorResponseFields.Code = "error_code"
+			ErrorResponseFields.Detail = "message"
+		})
+
+		AfterEach(func() {
+			ErrorResponseFields = fields
+		})
+
+		It("serializes to JSON using the custom names", func() {
+			b, err := json.Marshal(gerr)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(Equal(`{"id":"foo","error_code":"invalid","status":400,"message":"error","meta":[{"what":42}]}`))
+		})
+
+		It("deserializes from JSON using the custom names", func() {
+			var e ErrorResponse
+			err := json.Unmarshal([]byte(`{"id":"foo","error_code":"invalid","status":400,"message":"error"}`), &e)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(e).Should(Equal(ErrorResponse{ID: id, Code: code, Status: status, Detail: detail}))
+		})
+	})
+
+	Context("with problem details", func() {
+		BeforeEach(func() {
+			UseProblemDetails(true)
+			ProblemTypeBase = "https://example.com/errors/"
+		})
+
+		AfterEach(func() {
+			UseProblemDetails(false)
+			ProblemTypeBase = ""
+		})
+
+		It("uses the problem details media type", func() {
+			Ω(ErrorMediaIdentifier).Should(Equal(ProblemMediaIdentifier))
+		})
+
+		It("restores the previous media type when disabled", func() {
+			UseProblemDetails(false)
+			ErrorMediaIdentifier = "application/vnd.custom.error"
+			defer func() { ErrorMediaIdentifier = "application/vnd.goa.error" }()
+			UseProblemDetails(true)
+			UseProblemDetails(true)
+			Ω(ErrorMediaIdentifier).Should(Equal(ProblemMediaIdentifier))
+			UseProblemDetails(false)
+			Ω(ErrorMediaIdentifier).Should(Equal("application/vnd.custom.error"))
+		})
+
+		It("serializes to RFC 7807 JSON", func() {
+			b, err := json.Marshal(gerr)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(Equal(`{"type":"https://example.com/errors/invalid","title":"Bad Request","status":400,"detail":"error","instance":"foo","code":"invalid","meta":[{"what":42}]}`))
+		})
+
+		It("deserializes from RFC 7807 JSON", func() {
+			var e ErrorResponse
+			err := json.Unmarshal([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"error","instance":"foo","code":"invalid"}`), &e)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(e).Should(Equal(ErrorResponse{ID: id, Code: code, Status: status, Detail: detail}))
+		})
+	})
+
+	Context("with error metadata maps", func() {
+		BeforeEach(func() {
+			UseErrorMetaMap(true)
+			gerr.Meta = []map[string]interface{}{{"what": 42}, {"where": "here"}, {"what": 43}}
+		})
+
+		AfterEach(func() {
+			UseErrorMetaMap(false)
+		})
+
+		It("serializes the metadata to a JSON object", func() {
+			b, err := json.Marshal(gerr)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(Equal(`{"id":"foo","code":"invalid","status":400,"detail":"error","meta":{"what":43,"where":"here"}}`))
+		})
+
+		It("serializes the problem details metadata to a JSON object", func() {
+			UseProblemDetails(true)
+			defer UseProblemDetails(false)
+			b, err := json.Marshal(gerr)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(ContainSubstring(`"meta":{"what":43,"where":"here"}`))
+		})
+	})
+
+	It("returns the metadata as a map", func() {
+		gerr.Meta = []map[string]interface{}{{"what": 42}, {"where": "here"}, {"what": 43}}
+		Ω(gerr.MetaMap()).Should(Equal(map[string]interface{}{"what": 43, "where": "here"}))
+	})
+
+	It("deserializes metadata encoded as a JSON object", func() {
+		var e ErrorResponse
+		err := json.Unmarshal([]byte(`{"id":"foo","code":"invalid","status":400,"detail":"error","meta":{"where":"here","what":42}}`), &e)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(e.Meta).Should(Equal([]map[string]interface{}{{"what": 42.0}, {"where": "here"}}))
+	})
+
+	It("deserializes metadata encoded as a JSON array", func() {
+		var e ErrorResponse
+		err := json.Unmarshal([]byte(`{"id":"foo","code":"invalid","status":400,"detail":"error","meta":[{"what":42}]}`), &e)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(e.Meta).Should(Equal([]map[string]interface{}{{"what": 42.0}}))
+	})
 })
 
 var _ = Describe("InvalidParamTypeError", func() {
@@ -105,6 +223,23 @@ var _ = Describe("MissingAttributeError", func() {
 	})
 })
 
+var _ = Describe("MissingAuthMiddleware", func() {
+	var valErr error
+	schemes := []string{"basic", "jwt"}
+
+	JustBeforeEach(func() {
+		valErr = MissingAuthMiddleware(schemes...)
+	})
+
+	It("creates a http error listing the schemes", func() {
+		Ω(valErr).ShouldNot(BeNil())
+		Ω(valErr).Should(BeAssignableToTypeOf(&ErrorResponse{}))
+		err := valErr.(*ErrorResponse)
+		Ω(err.Status).Should(Equal(500))
+		Ω(err.Detail).Should(ContainSubstring("basic, jwt"))
+	})
+})
+
 var _ = Describe("MissingHeaderError", func() {
 	var valErr error
 	name := "param"
@@ -203,6 +338,13 @@ var _ = Describe("InvalidRangeError", func() {
 		Ω(err.Detail).Should(ContainSubstring(fmt.Sprintf("%#v", value)))
 		Ω(err.Detail).Should(ContainSubstring(target))
 	})
+
+	It("formats uint64 and float bounds exactly", func() {
+		err := InvalidRangeError(ctx, target, uint64(18446744073709551615), false).(*ErrorResponse)
+		Ω(err.Detail).Should(ContainSubstring("lesser or equal than 18446744073709551615"))
+		err = InvalidRangeError(ctx, target, 0.25, true).(*ErrorResponse)
+		Ω(err.Detail).Should(ContainSubstring("greater or equal than 0.25"))
+	})
 })
 
 var _ = Describe("InvalidLengthError", func() {
@@ -254,6 +396,30 @@ var _ = Describe("InvalidLengthError", func() {
 	})
 })
 
+var _ = Describe("InvalidNormalizationError", func() {
+	It("creates a http error", func() {
+		valErr := InvalidNormalizationError("ctx", "cafe\u0301", "NFC")
+		Ω(valErr).Should(BeAssignableToTypeOf(&ErrorResponse{}))
+		err := valErr.(*ErrorResponse)
+		Ω(err.Detail).Should(ContainSubstring("ctx"))
+		Ω(err.Detail).Should(ContainSubstring("NFC"))
+		Ω(err.Errors).Should(HaveLen(1))
+		Ω(err.Errors[0].Code).Should(Equal("invalid_normalization"))
+	})
+})
+
+var _ = Describe("InvalidCharacterCountError", func() {
+	It("creates a http error", func() {
+		valErr := InvalidCharacterCountError("ctx", "target", "graphemes", 6, 4)
+		Ω(valErr).Should(BeAssignableToTypeOf(&ErrorResponse{}))
+		err := valErr.(*ErrorResponse)
+		Ω(err.Detail).Should(ContainSubstring("number of graphemes of ctx"))
+		Ω(err.Detail).Should(ContainSubstring("count=6"))
+		Ω(err.Errors).Should(HaveLen(1))
+		Ω(err.Errors[0].Code).Should(Equal("invalid_grapheme_count"))
+	})
+})
+
 var _ = Describe("Merge", func() {
 	var err, err2 error
 	var mErr *ErrorResponse
@@ -408,7 +574,50 @@ var _ = Describe("Merge", func() {
 				})
 			})
 
+			Context("with error metadata maps", func() {
+				BeforeEach(func() {
+					UseErrorMetaMap(true)
+					err.(*ErrorResponse).Meta = []map[string]interface{}{{"foo": 1}, {"baz": 3}}
+					mErr2.Meta = []map[string]interface{}{{"foo": 2, "bar": 2}}
+				})
+
+				AfterEach(func() {
+					UseErrorMetaMap(false)
+				})
+
+				It("overrides the values of existing keys", func() {
+					Ω(mErr.Meta).Should(Equal([]map[string]interface{}{{"foo": 2}, {"baz": 3}, {"bar": 2}}))
+					Ω(mErr.MetaMap()).Should(Equal(map[string]interface{}{"foo": 2, "bar": 2, "baz": 3}))
+				})
+			})
+
 		})
 	})
 
 })
+
+var _ = Describe("FieldError", func() {
+	var err error
+
+	BeforeEach(func() {
+		err = MergeErrors(MissingAttributeError("payload", "name"), InvalidFormatError("payload.email", "foo", FormatEmail, errors.New("invalid")))
+	})
+
+	It("lists each failing field", func() {
+		e := err.(*ErrorResponse)
+		Ω(e.Errors).Should(HaveLen(2))
+		Ω(e.Errors[0].Field).Should(Equal("payload.name"))
+		Ω(e.Errors[0].Code).Should(Equal("missing"))
+		Ω(e.Errors[1].Field).Should(Equal("payload.email"))
+		Ω(e.Errors[1].Code).Should(Equal("invalid_format"))
+		Ω(e.Errors[1].Value).Should(Equal("foo"))
+	})
+
+	It("serializes the field errors to JSON", func() {
+		b, jerr := json.Marshal(err)
+		Ω(jerr).ShouldNot(HaveOccurred())
+		var e ErrorResponse
+		Ω(json.Unmarshal(b, &e)).ShouldNot(HaveOccurred())
+		Ω(e.Errors).Should(Equal(err.(*ErrorResponse).Errors))
+	})
+})
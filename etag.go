@@ -0,0 +1,179 @@
+package goa
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ComputeETag computes a strong entity tag from the given values. The values are typically the
+// media type attributes listed with the ETag DSL, nil pointers are treated as empty values and
+// non-nil pointers as the values they point to so that the result does not depend on memory
+// addresses.
+func ComputeETag(values ...interface{}) string {
+	h := sha1.New()
+	for _, v := range values {
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				break
+			}
+			rv = rv.Elem()
+		}
+		if rv.Kind() == reflect.Ptr || !rv.IsValid() {
+			h.Write([]byte{0})
+			continue
+		}
+		switch val := rv.Interface().(type) {
+		case time.Time:
+			fmt.Fprint(h, val.UTC().Format(time.RFC3339Nano))
+		default:
+			fmt.Fprintf(h, "%v", val)
+		}
+		h.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// ComputeWeakETag computes a weak entity tag from the given values, see ComputeETag. Weak entity
+// tags indicate that two representations with the same tag are semantically equivalent but not
+// necessarily byte for byte identical.
+func ComputeWeakETag(values ...interface{}) string {
+	return "W/" + ComputeETag(values...)
+}
+
+// ETagMatches returns true if the value of an If-None-Match request header matches etag using
+// the weak comparison function defined in RFC 7232 section 2.3.2.
+func ETagMatches(header, etag string) bool {
+	if header == "" || etag == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, t := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(t), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckETag sets the ETag header of the response stored in ctx to etag and returns true if the
+// request is a GET or HEAD request whose If-None-Match header matches etag, in which case the
+// caller should respond with status code 304 Not Modified instead of sending the entity. It
+// returns false if etag is empty.
+func CheckETag(ctx context.Context, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	return CheckNotModified(ctx, etag, time.Time{})
+}
+
+// CheckNotModified sets the ETag and Last-Modified headers of the response stored in ctx to etag
+// and lastModified and returns true if the request is a GET or HEAD request whose conditional
+// headers indicate that the client representation is up-to-date, in which case the caller should
+// respond with status code 304 Not Modified instead of sending the entity. The If-Modified-Since
+// header is only evaluated if the request has no If-None-Match header as specified by RFC 7232
+// section 6. Empty entity tags and zero times are ignored.
+func CheckNotModified(ctx context.Context, etag string, lastModified time.Time) bool {
+	if resp := ContextResponse(ctx); resp != nil {
+		if etag != "" {
+			resp.Header().Set("ETag", etag)
+		}
+		if !lastModified.IsZero() {
+			resp.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+		}
+	}
+	req := ContextRequest(ctx)
+	if req == nil || (req.Method != "GET" && req.Method != "HEAD") {
+		return false
+	}
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		return ETagMatches(inm, etag)
+	}
+	if ims := req.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// CheckPreconditions evaluates the If-Match and If-Unmodified-Since headers of the request stored
+// in ctx against etag and lastModified, the entity tag and last modification time of the current
+// state of the resource. It returns an error created with ErrPreconditionFailed if the
+// preconditions are not met, mutating actions should then return the error without applying any
+// change. If-Match uses the strong comparison function so that weak entity tags never match and
+// the If-Unmodified-Since header is only evaluated if the request has no If-Match header as
+// specified by RFC 7232 section 6. Pass an empty etag and a zero time if the resource does not
+// exist.
+func CheckPreconditions(ctx context.Context, etag string, lastModified time.Time) error {
+	req := ContextRequest(ctx)
+	if req == nil {
+		return nil
+	}
+	if im := req.Header.Get("If-Match"); im != "" {
+		if !strongETagMatches(im, etag) {
+			return ErrPreconditionFailed("If-Match precondition failed", "etag", etag)
+		}
+		return nil
+	}
+	if ius := req.Header.Get("If-Unmodified-Since"); ius != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ius)
+		if err == nil && lastModified.Truncate(time.Second).After(t) {
+			return ErrPreconditionFailed("If-Unmodified-Since precondition failed",
+				"last_modified", lastModified.UTC().Format(http.TimeFormat))
+		}
+	}
+	return nil
+}
+
+// strongETagMatches returns true if the value of an If-Match request header matches etag using
+// the strong comparison function defined in RFC 7232 section 2.3.2.
+func strongETagMatches(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	for _, t := range strings.Split(header, ",") {
+		if strings.TrimSpace(t) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddSurrogateKeys adds the given keys to the Surrogate-Key header of h. The header holds a space
+// separated list of keys, keys already present are not duplicated. The response methods generated
+// for responses that define surrogate keys call AddSurrogateKeys so that handlers may add keys
+// specific to the response instance beforehand.
+func AddSurrogateKeys(h http.Header, keys ...string) {
+	existing := strings.Fields(h.Get("Surrogate-Key"))
+	for _, k := range keys {
+		found := false
+		for _, e := range existing {
+			if e == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing = append(existing, k)
+		}
+	}
+	if len(existing) > 0 {
+		h.Set("Surrogate-Key", strings.Join(existing, " "))
+	}
+}
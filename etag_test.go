@@ -0,0 +1,225 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ComputeETag", func() {
+	It("depends on the values only", func() {
+		id := 1
+		t := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+		etag := goa.ComputeETag(&id, t)
+		Ω(etag).Should(HavePrefix(`"`))
+		Ω(etag).Should(HaveSuffix(`"`))
+		other := 1
+		Ω(goa.ComputeETag(&other, t.In(time.FixedZone("X", 3600)))).Should(Equal(etag))
+		Ω(goa.ComputeETag(2, t)).ShouldNot(Equal(etag))
+	})
+
+	It("treats nil pointers as empty values", func() {
+		var s *string
+		Ω(goa.ComputeETag(s)).Should(Equal(goa.ComputeETag("")))
+	})
+
+	It("computes weak entity tags", func() {
+		Ω(goa.ComputeWeakETag(1)).Should(Equal("W/" + goa.ComputeETag(1)))
+	})
+})
+
+var _ = Describe("ETagMatches", func() {
+	It("uses weak comparison", func() {
+		Ω(goa.ETagMatches(`"a", W/"b"`, `"b"`)).Should(BeTrue())
+		Ω(goa.ETagMatches(`"a"`, `W/"a"`)).Should(BeTrue())
+		Ω(goa.ETagMatches("*", `"a"`)).Should(BeTrue())
+		Ω(goa.ETagMatches(`"a"`, `"b"`)).Should(BeFalse())
+		Ω(goa.ETagMatches("", `"b"`)).Should(BeFalse())
+	})
+})
+
+var _ = Describe("CheckETag", func() {
+	var method, ifNoneMatch string
+	var rw *httptest.ResponseRecorder
+	var matched bool
+
+	BeforeEach(func() {
+		method = "GET"
+		ifNoneMatch = `"a"`
+	})
+
+	JustBeforeEach(func() {
+		req, err := http.NewRequest(method, "/", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rw = httptest.NewRecorder()
+		ctx := goa.NewContext(context.Background(), rw, req, nil)
+		matched = goa.CheckETag(ctx, `"a"`)
+	})
+
+	It("sets the ETag header and matches", func() {
+		Ω(rw.Header().Get("ETag")).Should(Equal(`"a"`))
+		Ω(matched).Should(BeTrue())
+	})
+
+	Context("with a different entity tag", func() {
+		BeforeEach(func() {
+			ifNoneMatch = `"b"`
+		})
+
+		It("does not match", func() {
+			Ω(matched).Should(BeFalse())
+		})
+	})
+
+	Context("with a PUT request", func() {
+		BeforeEach(func() {
+			method = "PUT"
+		})
+
+		It("does not match", func() {
+			Ω(matched).Should(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("CheckNotModified", func() {
+	var lastModified = time.Date(2016, 1, 1, 10, 0, 0, 500, time.UTC)
+	var ifModifiedSince string
+	var rw *httptest.ResponseRecorder
+	var notModified bool
+
+	BeforeEach(func() {
+		ifModifiedSince = lastModified.Format(http.TimeFormat)
+	})
+
+	JustBeforeEach(func() {
+		req, err := http.NewRequest("GET", "/", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("If-Modified-Since", ifModifiedSince)
+		rw = httptest.NewRecorder()
+		ctx := goa.NewContext(context.Background(), rw, req, nil)
+		notModified = goa.CheckNotModified(ctx, "", lastModified)
+	})
+
+	It("sets the Last-Modified header and compares with second precision", func() {
+		Ω(rw.Header().Get("Last-Modified")).Should(Equal("Fri, 01 Jan 2016 10:00:00 GMT"))
+		Ω(rw.Header().Get("ETag")).Should(BeEmpty())
+		Ω(notModified).Should(BeTrue())
+	})
+
+	Context("with an older If-Modified-Since header", func() {
+		BeforeEach(func() {
+			ifModifiedSince = lastModified.Add(-time.Minute).Format(http.TimeFormat)
+		})
+
+		It("reports the entity as modified", func() {
+			Ω(notModified).Should(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("CheckPreconditions", func() {
+	var lastModified = time.Date(2016, 1, 1, 10, 0, 0, 0, time.UTC)
+	var etag string
+	var headers map[string]string
+	var err error
+
+	BeforeEach(func() {
+		etag = `"a"`
+		headers = nil
+	})
+
+	JustBeforeEach(func() {
+		req, e := http.NewRequest("PUT", "/", nil)
+		Ω(e).ShouldNot(HaveOccurred())
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		ctx := goa.NewContext(context.Background(), httptest.NewRecorder(), req, nil)
+		err = goa.CheckPreconditions(ctx, etag, lastModified)
+	})
+
+	It("succeeds without conditional headers", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Context("with a matching If-Match header", func() {
+		BeforeEach(func() {
+			headers = map[string]string{"If-Match": `"b", "a"`}
+		})
+
+		It("succeeds", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("with a different If-Match header", func() {
+		BeforeEach(func() {
+			headers = map[string]string{"If-Match": `"b"`}
+		})
+
+		It("fails with status code 412", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(412))
+		})
+	})
+
+	Context("with a weak entity tag", func() {
+		BeforeEach(func() {
+			etag = `W/"a"`
+			headers = map[string]string{"If-Match": `W/"a"`}
+		})
+
+		It("fails", func() {
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a wildcard If-Match header and no current entity", func() {
+		BeforeEach(func() {
+			etag = ""
+			headers = map[string]string{"If-Match": "*"}
+		})
+
+		It("fails", func() {
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an older If-Unmodified-Since header", func() {
+		BeforeEach(func() {
+			headers = map[string]string{"If-Unmodified-Since": lastModified.Add(-time.Hour).Format(http.TimeFormat)}
+		})
+
+		It("fails with status code 412", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(412))
+		})
+	})
+
+	Context("with a current If-Unmodified-Since header", func() {
+		BeforeEach(func() {
+			headers = map[string]string{"If-Unmodified-Since": lastModified.Format(http.TimeFormat)}
+		})
+
+		It("succeeds", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("AddSurrogateKeys", func() {
+	It("appends the keys that are not already present", func() {
+		h := make(http.Header)
+		h.Set("Surrogate-Key", "bottle-1")
+		goa.AddSurrogateKeys(h, "bottles", "bottle-1")
+		Ω(h.Get("Surrogate-Key")).Should(Equal("bottle-1 bottles"))
+	})
+})
@@ -3,6 +3,9 @@ package codegen
 import (
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -18,7 +21,11 @@ var (
 	patternValT  *template.Template
 	minMaxValT   *template.Template
 	lengthValT   *template.Template
+	fileSizeValT *template.Template
+	normValT     *template.Template
+	countValT    *template.Template
 	requiredValT *template.Template
+	knownEnumT   *template.Template
 )
 
 //  init instantiates the templates.
@@ -31,7 +38,7 @@ func init() {
 		"constant":         constant,
 		"goifyAtt":         GoifyAtt,
 		"add":              Add,
-		"recursiveChecker": RecursiveChecker,
+		"recursiveChecker": recursiveChecker,
 	}
 	if arrayValT, err = template.New("array").Funcs(fm).Parse(arrayValTmpl); err != nil {
 		panic(err)
@@ -54,63 +61,58 @@ func init() {
 	if lengthValT, err = template.New("length").Funcs(fm).Parse(lengthValTmpl); err != nil {
 		panic(err)
 	}
+	if fileSizeValT, err = template.New("fileSize").Funcs(fm).Parse(fileSizeValTmpl); err != nil {
+		panic(err)
+	}
+	if normValT, err = template.New("norm").Funcs(fm).Parse(normValTmpl); err != nil {
+		panic(err)
+	}
+	if countValT, err = template.New("count").Funcs(fm).Parse(countValTmpl); err != nil {
+		panic(err)
+	}
 	if requiredValT, err = template.New("required").Funcs(fm).Parse(requiredValTmpl); err != nil {
 		panic(err)
 	}
+	if knownEnumT, err = template.New("knownEnum").Funcs(fm).Parse(knownEnumTmpl); err != nil {
+		panic(err)
+	}
 }
 
 // RecursiveChecker produces Go code that runs the validation checks recursively over the given
-// attribute.
+// attribute. The validations of attributes that produce warnings instead of errors (see the
+// "validation:warn" metadata) are skipped, use RecursiveWarningChecker to produce the code for
+// these.
 func RecursiveChecker(att *design.AttributeDefinition, nonzero, required, hasDefault bool, target, context string, depth int, private bool) string {
+	return recursiveChecker(false, att, nonzero, required, hasDefault, target, context, depth, private)
+}
+
+// RecursiveWarningChecker is similar to RecursiveChecker but only produces the code for the
+// validations of the attributes that produce warnings instead of errors. Nested user types are
+// checked by calling their ValidateWarnings method.
+func RecursiveWarningChecker(att *design.AttributeDefinition, nonzero, required, hasDefault bool, target, context string, depth int, private bool) string {
+	return recursiveChecker(true, att, nonzero, required, hasDefault, target, context, depth, private)
+}
+
+func recursiveChecker(warn bool, att *design.AttributeDefinition, nonzero, required, hasDefault bool, target, context string, depth int, private bool) string {
 	var checks []string
 	if o := att.Type.ToObject(); o != nil {
 		if ds, ok := att.Type.(design.DataStructure); ok {
 			att = ds.Definition()
 		}
-		validation := ValidationChecker(att, nonzero, required, hasDefault, target, context, depth, private)
+		validation := validationChecker(warn, att, nonzero, required, hasDefault, target, context, depth, private)
 		if validation != "" {
 			checks = append(checks, validation)
 		}
 		o.IterateAttributes(func(n string, catt *design.AttributeDefinition) error {
 			var validation string
 			if ds, ok := catt.Type.(design.DataStructure); ok {
-				// We need to check empirically whether there are validations to be
-				// generated, we can't just generate and check whether something was
-				// generated to avoid infinite recursions.
-				hasValidations := false
-				done := errors.New("done")
-				ds.Walk(func(a *design.AttributeDefinition) error {
-					if a.Validation != nil {
-						if private {
-							hasValidations = true
-							return done
-						}
-						// For public data structures there is a case where
-						// there is validation but no actual validation
-						// code: if the validation is a required validation
-						// that applies to attributes that cannot be nil or
-						// empty string i.e. primitive types other than
-						// string.
-						if !a.Validation.HasRequiredOnly() {
-							hasValidations = true
-							return done
-						}
-						for _, name := range a.Validation.Required {
-							att := a.Type.ToObject()[name]
-							if att != nil && (!att.Type.IsPrimitive() || att.Type.Kind() == design.StringKind) {
-								hasValidations = true
-								return done
-							}
-						}
-					}
-					return nil
-				})
-				if hasValidations {
+				if hasValidations(ds, warn, private) {
 					validation = RunTemplate(
 						userValT,
 						map[string]interface{}{
 							"depth":  depth,
 							"target": fmt.Sprintf("%s.%s", target, GoifyAtt(catt, n, true)),
+							"warn":   warn,
 						},
 					)
 				}
@@ -119,7 +121,8 @@ func RecursiveChecker(att *design.AttributeDefinition, nonzero, required, hasDef
 				if catt.Type.IsObject() {
 					dp++
 				}
-				validation = RecursiveChecker(
+				validation = recursiveChecker(
+					warn,
 					catt,
 					att.IsNonZero(n),
 					att.IsRequired(n),
@@ -141,7 +144,7 @@ func RecursiveChecker(att *design.AttributeDefinition, nonzero, required, hasDef
 		})
 	} else if a := att.Type.ToArray(); a != nil {
 		// Perform any validation on the array type such as MinLength, MaxLength, etc.
-		validation := ValidationChecker(att, nonzero, required, hasDefault, target, context, depth, private)
+		validation := validationChecker(warn, att, nonzero, required, hasDefault, target, context, depth, private)
 		if validation != "" {
 			checks = append(checks, validation)
 		}
@@ -151,13 +154,14 @@ func RecursiveChecker(att *design.AttributeDefinition, nonzero, required, hasDef
 			"target":   target,
 			"depth":    1,
 			"private":  private,
+			"warn":     warn,
 		}
 		validation = RunTemplate(arrayValT, data)
 		if validation != "" {
 			checks = append(checks, validation)
 		}
 	} else {
-		validation := ValidationChecker(att, nonzero, required, hasDefault, target, context, depth, private)
+		validation := validationChecker(warn, att, nonzero, required, hasDefault, target, context, depth, private)
 		if validation != "" {
 			checks = append(checks, validation)
 		}
@@ -165,6 +169,42 @@ func RecursiveChecker(att *design.AttributeDefinition, nonzero, required, hasDef
 	return strings.Join(checks, "\n")
 }
 
+// hasValidations returns true if validation code must be generated for the data structure ds.
+func hasValidations(ds design.DataStructure, warn, private bool) bool {
+	// We need to check empirically whether there are validations to be
+	// generated, we can't just generate and check whether something was
+	// generated to avoid infinite recursions.
+	found := false
+	done := errors.New("done")
+	ds.Walk(func(a *design.AttributeDefinition) error {
+		if a.Validation != nil && a.ValidationWarns() == warn {
+			if private && (len(a.Validation.Required) > 0 || !a.Validation.HasRequiredOnly()) {
+				found = true
+				return done
+			}
+			// For public data structures there is a case where
+			// there is validation but no actual validation
+			// code: if the validation is a required validation
+			// that applies to attributes that cannot be nil or
+			// empty string i.e. primitive types other than
+			// string.
+			if !a.Validation.HasRequiredOnly() {
+				found = true
+				return done
+			}
+			for _, name := range a.Validation.Required {
+				att := a.Type.ToObject()[name]
+				if att != nil && (!att.Type.IsPrimitive() || att.Type.Kind() == design.StringKind || att.Type.Kind() == design.FileKind) {
+					found = true
+					return done
+				}
+			}
+		}
+		return nil
+	})
+	return found
+}
+
 // ValidationChecker produces Go code that runs the validation defined in the given attribute
 // definition against the content of the variable named target recursively.
 // context is used to keep track of recursion to produce helpful error messages in case of type
@@ -172,7 +212,24 @@ func RecursiveChecker(att *design.AttributeDefinition, nonzero, required, hasDef
 // The generated code assumes that there is a pre-existing "err" variable of type
 // error. It initializes that variable in case a validation fails.
 // Note: we do not want to recurse here, recursion is done by the marshaler/unmarshaler code.
+// ValidationChecker produces no code for attributes whose validations produce warnings instead of
+// errors, see WarningChecker.
 func ValidationChecker(att *design.AttributeDefinition, nonzero, required, hasDefault bool, target, context string, depth int, private bool) string {
+	return validationChecker(false, att, nonzero, required, hasDefault, target, context, depth, private)
+}
+
+// WarningChecker is similar to ValidationChecker but only produces code for attributes whose
+// validations produce warnings instead of errors (see the "validation:warn" metadata). The
+// generated code also initializes the "err" variable, it is up to the caller to report it as a
+// warning.
+func WarningChecker(att *design.AttributeDefinition, nonzero, required, hasDefault bool, target, context string, depth int, private bool) string {
+	return validationChecker(true, att, nonzero, required, hasDefault, target, context, depth, private)
+}
+
+func validationChecker(warn bool, att *design.AttributeDefinition, nonzero, required, hasDefault bool, target, context string, depth int, private bool) string {
+	if att.ValidationWarns() != warn {
+		return ""
+	}
 	t := target
 	isPointer := private || (!required && !hasDefault && !nonzero)
 	if isPointer && att.Type.IsPrimitive() {
@@ -189,16 +246,56 @@ func ValidationChecker(att *design.AttributeDefinition, nonzero, required, hasDe
 		"hash":      att.Type.IsHash(),
 		"depth":     depth,
 		"private":   private,
+		"redacted":  "",
+	}
+	if att.Classification != "" {
+		// Do not leak the values of classified attributes in the error messages
+		data["redacted"] = fmt.Sprintf("goa.Redacted(%q)", att.Classification)
 	}
 	res := validationsCode(att.Validation, data)
 	return strings.Join(res, "\n")
 }
 
+// KnownEnumMethods produces the "IsKnown" methods of the public Go type typeRef for the primitive
+// attributes of the given object that define an open enum (see the OpenEnum DSL). The methods
+// return true if the attribute value is one of the enum values listed in the design. Unknown values
+// are accepted and preserved by the generated code so that the enum values may evolve.
+func KnownEnumMethods(att *design.AttributeDefinition, typeRef, receiver string) string {
+	o := att.Type.ToObject()
+	if o == nil {
+		return ""
+	}
+	var methods []string
+	o.IterateAttributes(func(n string, catt *design.AttributeDefinition) error {
+		if !catt.Type.IsPrimitive() || catt.Validation == nil || !catt.Validation.OpenEnum || len(catt.Validation.Values) == 0 {
+			return nil
+		}
+		isPointer := !att.IsRequired(n) && !att.HasDefaultValue(n) && !att.IsNonZero(n)
+		field := fmt.Sprintf("%s.%s", receiver, GoifyAtt(catt, n, true))
+		val := field
+		if isPointer {
+			val = "*" + field
+		}
+		data := map[string]interface{}{
+			"name":      Goify(n, true),
+			"typeRef":   typeRef,
+			"receiver":  receiver,
+			"isPointer": isPointer,
+			"field":     field,
+			"val":       val,
+			"values":    catt.Validation.Values,
+		}
+		methods = append(methods, RunTemplate(knownEnumT, data))
+		return nil
+	})
+	return strings.Join(methods, "\n\n")
+}
+
 func validationsCode(validation *dslengine.ValidationDefinition, data map[string]interface{}) (res []string) {
 	if validation == nil {
 		return nil
 	}
-	if values := validation.Values; values != nil {
+	if values := validation.Values; values != nil && !validation.OpenEnum {
 		data["values"] = values
 		if val := RunTemplate(enumValT, data); val != "" {
 			res = append(res, val)
@@ -216,22 +313,65 @@ func validationsCode(validation *dslengine.ValidationDefinition, data map[string
 			res = append(res, val)
 		}
 	}
-	if min := validation.Minimum; min != nil {
-		data["min"] = *min
-		data["isMin"] = true
-		delete(data, "max")
-		if val := RunTemplate(minMaxValT, data); val != "" {
+	res = append(res, rangeValidationsCode(validation, data)...)
+	res = append(res, lengthValidationsCode(validation, data)...)
+	if form := validation.Normalization; form != "" {
+		data["form"] = form
+		if val := RunTemplate(normValT, data); val != "" {
 			res = append(res, val)
 		}
 	}
-	if max := validation.Maximum; max != nil {
-		data["max"] = *max
-		data["isMin"] = false
-		delete(data, "min")
-		if val := RunTemplate(minMaxValT, data); val != "" {
+	if maxRunes := validation.MaxRunes; maxRunes != nil {
+		data["maxCount"] = *maxRunes
+		data["unit"] = "runes"
+		if val := RunTemplate(countValT, data); val != "" {
+			res = append(res, val)
+		}
+	}
+	if maxGraphemes := validation.MaxGraphemes; maxGraphemes != nil {
+		data["maxCount"] = *maxGraphemes
+		data["unit"] = "graphemes"
+		if val := RunTemplate(countValT, data); val != "" {
+			res = append(res, val)
+		}
+	}
+	if required := validation.Required; len(required) > 0 {
+		data["required"] = required
+		if val := RunTemplate(requiredValT, data); val != "" {
 			res = append(res, val)
 		}
 	}
+	return
+}
+
+// rangeValidationsCode produces the code validating the minimum and maximum values.
+func rangeValidationsCode(validation *dslengine.ValidationDefinition, data map[string]interface{}) (res []string) {
+	att, _ := data["attribute"].(*design.AttributeDefinition)
+	if min := validation.Minimum; min != nil {
+		if bound, value, ok := rangeBound(att, validation.MinimumLiteral, *min, true); ok {
+			data["bound"] = bound
+			data["boundValue"] = value
+			data["isMin"] = true
+			if val := RunTemplate(minMaxValT, data); val != "" {
+				res = append(res, val)
+			}
+		}
+	}
+	if max := validation.Maximum; max != nil {
+		if bound, value, ok := rangeBound(att, validation.MaximumLiteral, *max, false); ok {
+			data["bound"] = bound
+			data["boundValue"] = value
+			data["isMin"] = false
+			if val := RunTemplate(minMaxValT, data); val != "" {
+				res = append(res, val)
+			}
+		}
+	}
+	return
+}
+
+// lengthValidationsCode produces the code validating the minimum and maximum lengths.
+func lengthValidationsCode(validation *dslengine.ValidationDefinition, data map[string]interface{}) (res []string) {
 	if minLength := validation.MinLength; minLength != nil {
 		data["minLength"] = minLength
 		data["isMinLength"] = true
@@ -244,19 +384,60 @@ func validationsCode(validation *dslengine.ValidationDefinition, data map[string
 		data["maxLength"] = maxLength
 		data["isMinLength"] = false
 		delete(data, "minLength")
-		if val := RunTemplate(lengthValT, data); val != "" {
-			res = append(res, val)
+		tmpl := lengthValT
+		if att, ok := data["attribute"].(*design.AttributeDefinition); ok && att.Type.Kind() == design.FileKind {
+			// The maximum length of a file is its maximum size in bytes
+			tmpl = fileSizeValT
 		}
-	}
-	if required := validation.Required; len(required) > 0 {
-		data["required"] = required
-		if val := RunTemplate(requiredValT, data); val != "" {
+		if val := RunTemplate(tmpl, data); val != "" {
 			res = append(res, val)
 		}
 	}
 	return
 }
 
+// rangeBound returns the Go constant compared with the values of att to check the given minimum
+// or maximum bound and the Go expression of the bound given to goa.InvalidRangeError. The bound is
+// computed from its exact decimal literal if any and from f otherwise. The bounds of Integer
+// attributes are rounded towards the allowed range. rangeBound returns false if no value of the
+// attribute Go type can be out of bound in which case no check is needed.
+func rangeBound(att *design.AttributeDefinition, literal string, f float64, isMin bool) (string, string, bool) {
+	r, ok := new(big.Rat).SetString(literal)
+	if literal == "" || !ok {
+		if r = new(big.Rat).SetFloat64(f); r == nil {
+			return "", "", false // infinite bound
+		}
+	}
+	if att != nil && att.Type.Kind() == design.IntegerKind {
+		q, m := new(big.Int).DivMod(r.Num(), r.Denom(), new(big.Int))
+		if isMin && m.Sign() != 0 {
+			q.Add(q, big.NewInt(1))
+		}
+		if (isMin && q.Cmp(big.NewInt(math.MinInt64)) <= 0) || (!isMin && q.Cmp(big.NewInt(math.MaxInt64)) >= 0) {
+			return "", "", false
+		}
+		return q.String(), intConstant(q, f), true
+	}
+	if r.IsInt() {
+		return r.Num().String(), intConstant(r.Num(), f), true
+	}
+	lit := strconv.FormatFloat(f, 'g', -1, 64)
+	return lit, lit, true
+}
+
+// intConstant returns a Go expression of the integer i whose default type can hold i exactly: an
+// untyped constant if i fits in an int64, a uint64 conversion if it fits in an uint64 and the
+// float literal of f otherwise.
+func intConstant(i *big.Int, f float64) string {
+	switch {
+	case i.IsInt64():
+		return i.String()
+	case i.IsUint64():
+		return fmt.Sprintf("uint64(%s)", i)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
 // oneof produces code that compares target with each element of vals and ORs
 // the result, e.g. "target == 1 || target == 2".
 func oneof(target string, vals []interface{}) string {
@@ -295,40 +476,40 @@ func constant(formatName string) string {
 }
 
 const (
-	arrayValTmpl = `{{$validation := recursiveChecker .elemType false false false "e" (printf "%s[*]" .context) (add .depth 1) .private}}{{/*
+	arrayValTmpl = `{{$validation := recursiveChecker .warn .elemType false false false "e" (printf "%s[*]" .context) (add .depth 1) .private}}{{/*
 */}}{{if $validation}}{{tabs .depth}}for _, e := range {{.target}} {
 {{$validation}}
 {{tabs .depth}}}{{end}}`
 
-	userValTmpl = `{{tabs .depth}}if err2 := {{.target}}.Validate(); err2 != nil {
+	userValTmpl = `{{tabs .depth}}if err2 := {{.target}}.{{if .warn}}ValidateWarnings{{else}}Validate{{end}}(); err2 != nil {
 {{tabs .depth}}	err = goa.MergeErrors(err, err2)
 {{tabs .depth}}}`
 
 	enumValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs $depth}}if !({{oneof .targetVal .values}}) {
-{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidEnumValueError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, {{slice .values}}))
+{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidEnumValueError(` + "`" + `{{.context}}` + "`" + `, {{or .redacted .targetVal}}, {{slice .values}}))
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
 	patternValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs $depth}}if ok := goa.ValidatePattern(` + "`{{.pattern}}`" + `, {{.targetVal}}); !ok {
-{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidPatternError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, ` + "`{{.pattern}}`" + `))
+{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidPatternError(` + "`" + `{{.context}}` + "`" + `, {{or .redacted .targetVal}}, ` + "`{{.pattern}}`" + `))
 {{tabs $depth}}}{{if .isPointer}}
 {{tabs .depth}}}{{end}}`
 
 	formatValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs $depth}}if err2 := goa.ValidateFormat({{constant .format}}, {{.targetVal}}); err2 != nil {
-{{tabs $depth}}		err = goa.MergeErrors(err, goa.InvalidFormatError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, {{constant .format}}, err2))
+{{tabs $depth}}		err = goa.MergeErrors(err, goa.InvalidFormatError(` + "`" + `{{.context}}` + "`" + `, {{or .redacted .targetVal}}, {{constant .format}}, err2))
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
 	minMaxValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
-{{end}}{{tabs .depth}}	if {{.targetVal}} {{if .isMin}}<{{else}}>{{end}} {{if .isMin}}{{.min}}{{else}}{{.max}}{{end}} {
-{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidRangeError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, {{if .isMin}}{{.min}}, true{{else}}{{.max}}, false{{end}}))
+{{end}}{{tabs .depth}}	if {{.targetVal}} {{if .isMin}}<{{else}}>{{end}} {{.bound}} {
+{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidRangeError(` + "`" + `{{.context}}` + "`" + `, {{or .redacted .targetVal}}, {{.boundValue}}, {{.isMin}}))
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
@@ -336,16 +517,43 @@ const (
 */}}{{$target := or (and (or (or .array .hash) .nonzero) .target) .targetVal}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs .depth}}	if len({{$target}}) {{if .isMinLength}}<{{else}}>{{end}} {{if .isMinLength}}{{.minLength}}{{else}}{{.maxLength}}{{end}} {
-{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidLengthError(` + "`" + `{{.context}}` + "`" + `, {{$target}}, len({{$target}}), {{if .isMinLength}}{{.minLength}}, true{{else}}{{.maxLength}}, false{{end}}))
+{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidLengthError(` + "`" + `{{.context}}` + "`" + `, {{or .redacted $target}}, len({{$target}}), {{if .isMinLength}}{{.minLength}}, true{{else}}{{.maxLength}}, false{{end}}))
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
+	fileSizeValTmpl = `{{tabs .depth}}	if {{.target}} != nil && {{.target}}.Size > {{.maxLength}} {
+{{tabs .depth}}		err = goa.MergeErrors(err, goa.InvalidLengthError(` + "`" + `{{.context}}` + "`" + `, {{or .redacted (printf "%s.Filename" .target)}}, int({{.target}}.Size), {{.maxLength}}, false))
+{{tabs .depth}}	}`
+
+	normValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
+*/}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
+{{end}}{{tabs $depth}}if ok := goa.ValidateNormalization("{{.form}}", {{.targetVal}}); !ok {
+{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidNormalizationError(` + "`" + `{{.context}}` + "`" + `, {{or .redacted .targetVal}}, "{{.form}}"))
+{{tabs $depth}}}{{if .isPointer}}
+{{tabs .depth}}}{{end}}`
+
+	countValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
+*/}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
+{{end}}{{tabs $depth}}if n := {{if eq .unit "runes"}}len([]rune({{.targetVal}})){{else}}goa.GraphemeCount({{.targetVal}}){{end}}; n > {{.maxCount}} {
+{{tabs $depth}}	err = goa.MergeErrors(err, goa.InvalidCharacterCountError(` + "`" + `{{.context}}` + "`" + `, {{or .redacted .targetVal}}, "{{.unit}}", n, {{.maxCount}}))
+{{tabs $depth}}}{{if .isPointer}}
+{{tabs .depth}}}{{end}}`
+
 	requiredValTmpl = `{{range $r := .required}}{{$catt := index $.attribute.Type.ToObject $r}}{{/*
 */}}{{if and (not $.private) (eq $catt.Type.Kind 4)}}{{tabs $.depth}}if {{$.target}}.{{goifyAtt $catt $r true}} == "" {
 {{tabs $.depth}}	err = goa.MergeErrors(err, goa.MissingAttributeError(` + "`" + `{{$.context}}` + "`" + `, "{{$r}}"))
 {{tabs $.depth}}}
-{{else if or $.private (not $catt.Type.IsPrimitive)}}{{tabs $.depth}}if {{$.target}}.{{goifyAtt $catt $r true}} == nil {
+{{else if or $.private (not $catt.Type.IsPrimitive) (eq $catt.Type.Kind 13)}}{{tabs $.depth}}if {{$.target}}.{{goifyAtt $catt $r true}} == nil {
 {{tabs $.depth}}	err = goa.MergeErrors(err, goa.MissingAttributeError(` + "`" + `{{$.context}}` + "`" + `, "{{$r}}"))
 {{tabs $.depth}}}
 {{end}}{{end}}`
+
+	knownEnumTmpl = `// IsKnown{{.name}} returns true if the value of {{.name}} is {{if .isPointer}}nil or {{end}}one of the enum values
+// defined in the design. The enum is open so that unknown values are accepted and preserved.
+func ({{.receiver}} {{.typeRef}}) IsKnown{{.name}}() bool {
+{{if .isPointer}}	if {{.field}} == nil {
+		return true
+	}
+{{end}}	return {{oneof .val .values}}
+}`
 )
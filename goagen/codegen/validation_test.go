@@ -57,6 +57,52 @@ var _ = Describe("validation code generation", func() {
 				})
 			})
 
+			Context("of normalization", func() {
+				BeforeEach(func() {
+					attType = design.String
+					validation = &dslengine.ValidationDefinition{
+						Normalization: "NFC",
+					}
+				})
+
+				It("produces the validation go code", func() {
+					Ω(code).Should(Equal(normValCode))
+				})
+			})
+
+			Context("of max runes and graphemes", func() {
+				BeforeEach(func() {
+					runes, graphemes := 64, 32
+					attType = design.String
+					validation = &dslengine.ValidationDefinition{
+						MaxRunes:     &runes,
+						MaxGraphemes: &graphemes,
+					}
+				})
+
+				It("produces the validation go code", func() {
+					Ω(code).Should(Equal(countValCode))
+				})
+			})
+
+			Context("of pattern on a classified attribute", func() {
+				BeforeEach(func() {
+					attType = design.String
+					validation = &dslengine.ValidationDefinition{
+						Pattern: ".*",
+					}
+					att.Classification = "pii:email"
+				})
+
+				AfterEach(func() {
+					att.Classification = ""
+				})
+
+				It("does not leak the value in the error", func() {
+					Ω(code).Should(Equal(classifiedPatternValCode))
+				})
+			})
+
 			Context("of min value 0", func() {
 				BeforeEach(func() {
 					attType = design.Integer
@@ -71,6 +117,83 @@ var _ = Describe("validation code generation", func() {
 				})
 			})
 
+			Context("of int64 max value", func() {
+				BeforeEach(func() {
+					attType = design.Integer
+					max := float64(9007199254740993)
+					validation = &dslengine.ValidationDefinition{
+						Maximum:        &max,
+						MaximumLiteral: "9007199254740993",
+					}
+				})
+
+				It("uses the exact bound", func() {
+					Ω(code).Should(ContainSubstring("if *val > 9007199254740993 {"))
+					Ω(code).Should(ContainSubstring("*val, 9007199254740993, false))"))
+				})
+			})
+
+			Context("of fractional min value on an integer", func() {
+				BeforeEach(func() {
+					attType = design.Integer
+					min := 1.5
+					validation = &dslengine.ValidationDefinition{
+						Minimum:        &min,
+						MinimumLiteral: "1.5",
+					}
+				})
+
+				It("rounds the bound up", func() {
+					Ω(code).Should(ContainSubstring("if *val < 2 {"))
+				})
+			})
+
+			Context("of uint64 max value on an integer", func() {
+				BeforeEach(func() {
+					attType = design.Integer
+					max := float64(18446744073709551615)
+					validation = &dslengine.ValidationDefinition{
+						Maximum:        &max,
+						MaximumLiteral: "18446744073709551615",
+					}
+				})
+
+				It("does not produce a check", func() {
+					Ω(code).Should(BeEmpty())
+				})
+			})
+
+			Context("of uint64 min value on a number", func() {
+				BeforeEach(func() {
+					attType = design.Number
+					min := float64(18446744073709551615)
+					validation = &dslengine.ValidationDefinition{
+						Minimum:        &min,
+						MinimumLiteral: "18446744073709551615",
+					}
+				})
+
+				It("passes the exact bound to the error", func() {
+					Ω(code).Should(ContainSubstring("if *val < 18446744073709551615 {"))
+					Ω(code).Should(ContainSubstring("*val, uint64(18446744073709551615), true))"))
+				})
+			})
+
+			Context("of float max value on a number", func() {
+				BeforeEach(func() {
+					attType = design.Number
+					max := 0.25
+					validation = &dslengine.ValidationDefinition{
+						Maximum: &max,
+					}
+				})
+
+				It("uses a float bound", func() {
+					Ω(code).Should(ContainSubstring("if *val > 0.25 {"))
+					Ω(code).Should(ContainSubstring("*val, 0.25, false))"))
+				})
+			})
+
 			Context("of min length 1", func() {
 				BeforeEach(func() {
 					attType = &design.Array{
@@ -155,6 +278,67 @@ var _ = Describe("validation code generation", func() {
 
 		})
 	})
+
+	Describe("open enums", func() {
+		var att *design.AttributeDefinition
+
+		BeforeEach(func() {
+			att = &design.AttributeDefinition{
+				Type: design.Object{
+					"status": &design.AttributeDefinition{
+						Type: design.String,
+						Validation: &dslengine.ValidationDefinition{
+							Values:   []interface{}{"a", "b"},
+							OpenEnum: true,
+						},
+					},
+				},
+			}
+		})
+
+		It("does not validate the enum values", func() {
+			code := codegen.RecursiveChecker(att, false, false, false, "ut", "context", 1, false)
+			Ω(code).Should(BeEmpty())
+		})
+
+		It("produces the IsKnown methods", func() {
+			code := codegen.KnownEnumMethods(att, "*Bottle", "ut")
+			Ω(code).Should(Equal(knownEnumCode))
+		})
+	})
+
+	Describe("WarningChecker", func() {
+		var att *design.AttributeDefinition
+		var validationCode, warningCode string
+
+		BeforeEach(func() {
+			att = &design.AttributeDefinition{
+				Type:       design.Integer,
+				Validation: &dslengine.ValidationDefinition{Values: []interface{}{1, 2, 3}},
+			}
+		})
+
+		JustBeforeEach(func() {
+			validationCode = codegen.RecursiveChecker(att, false, false, false, "val", "context", 1, false)
+			warningCode = codegen.RecursiveWarningChecker(att, false, false, false, "val", "context", 1, false)
+		})
+
+		It("does not produce warning code for attributes validated normally", func() {
+			Ω(validationCode).Should(Equal(enumValCode))
+			Ω(warningCode).Should(BeEmpty())
+		})
+
+		Context("with the validation:warn metadata", func() {
+			BeforeEach(func() {
+				att.Metadata = dslengine.MetadataDefinition{"validation:warn": nil}
+			})
+
+			It("moves the validation code to the warning code", func() {
+				Ω(validationCode).Should(BeEmpty())
+				Ω(warningCode).Should(Equal(enumValCode))
+			})
+		})
+	})
 })
 
 const (
@@ -170,6 +354,29 @@ const (
 		}
 	}`
 
+	normValCode = `	if val != nil {
+		if ok := goa.ValidateNormalization("NFC", *val); !ok {
+			err = goa.MergeErrors(err, goa.InvalidNormalizationError(` + "`context`" + `, *val, "NFC"))
+		}
+	}`
+
+	countValCode = `	if val != nil {
+		if n := len([]rune(*val)); n > 64 {
+			err = goa.MergeErrors(err, goa.InvalidCharacterCountError(` + "`context`" + `, *val, "runes", n, 64))
+		}
+	}
+	if val != nil {
+		if n := goa.GraphemeCount(*val); n > 32 {
+			err = goa.MergeErrors(err, goa.InvalidCharacterCountError(` + "`context`" + `, *val, "graphemes", n, 32))
+		}
+	}`
+
+	classifiedPatternValCode = `	if val != nil {
+		if ok := goa.ValidatePattern(` + "`.*`" + `, *val); !ok {
+			err = goa.MergeErrors(err, goa.InvalidPatternError(` + "`context`" + `, goa.Redacted("pii:email"), ` + "`.*`" + `))
+		}
+	}`
+
 	minValCode = `	if val != nil {
 		if *val < 0 {
 			err = goa.MergeErrors(err, goa.InvalidRangeError(` + "`" + `context` + "`" + `, *val, 0, true))
@@ -217,4 +424,13 @@ const (
 			}
 		}
 	}`
+
+	knownEnumCode = `// IsKnownStatus returns true if the value of Status is nil or one of the enum values
+// defined in the design. The enum is open so that unknown values are accepted and preserved.
+func (ut *Bottle) IsKnownStatus() bool {
+	if ut.Status == nil {
+		return true
+	}
+	return *ut.Status == "a" || *ut.Status == "b"
+}`
 )
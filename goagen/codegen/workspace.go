@@ -65,14 +65,17 @@ var (
 		"gotypedesc":          GoTypeDesc,
 		"gotyperef":           GoTypeRef,
 		"join":                strings.Join,
+		"knownEnumMethods":    KnownEnumMethods,
 		"recursiveFinalizer":  RecursiveFinalizer,
 		"recursiveValidate":   RecursiveChecker,
+		"recursiveWarnings":   RecursiveWarningChecker,
 		"recursivePublicizer": RecursivePublicizer,
 		"tabs":                Tabs,
 		"tempvar":             Tempvar,
 		"title":               strings.Title,
 		"toLower":             strings.ToLower,
 		"validationChecker":   ValidationChecker,
+		"warningChecker":      WarningChecker,
 	}
 )
 
@@ -84,7 +84,7 @@ func normalizeEncodingDefinitions(defs []*design.EncodingDefinition) []*design.E
 	// Next make sure all definitions have a package path
 	for _, enc := range encs {
 		if enc.PackagePath == "" {
-			mt := enc.MIMETypes[0]
+			mt := design.KnownEncoderMIMEType(enc.MIMETypes[0])
 			enc.PackagePath = design.KnownEncoders[mt]
 			idx := 0
 			if !enc.Encoder {
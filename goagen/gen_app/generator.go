@@ -1,11 +1,14 @@
 package genapp
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"unicode"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/goagen/codegen"
@@ -78,6 +81,12 @@ func (g *Generator) Generate() (_ []string, err error) {
 	if err := g.generateSecurity(); err != nil {
 		return nil, err
 	}
+	if err := g.generateDependencies(); err != nil {
+		return nil, err
+	}
+	if err := g.generateWebhooks(); err != nil {
+		return nil, err
+	}
 	if err := g.generateHrefs(); err != nil {
 		return nil, err
 	}
@@ -117,6 +126,8 @@ func (g *Generator) generateContexts() error {
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.SimpleImport("io"),
+		codegen.SimpleImport("mime/multipart"),
 		codegen.SimpleImport("strconv"),
 		codegen.SimpleImport("strings"),
 		codegen.SimpleImport("time"),
@@ -155,6 +166,14 @@ func (g *Generator) generateContexts() error {
 				API:          g.API,
 				DefaultPkg:   g.Target,
 				Security:     a.Security,
+				FailFast:     a.FailFast(),
+				Pagination:   a.Pagination,
+
+				Preconditions: preconditionsMediaType(a),
+
+				Streams:            a.Streams(),
+				StreamWriteTimeout: a.StreamWriteTimeout(),
+				StreamBuffer:       a.StreamBuffer(),
 			}
 			return ctxWr.Execute(&ctxData)
 		})
@@ -175,12 +194,17 @@ func (g *Generator) generateControllers() error {
 	}
 	title := fmt.Sprintf("%s: Application Controllers", g.API.Context())
 	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("bytes"),
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("golang.org/x/net/context"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("github.com/goadesign/goa/cors"),
+		codegen.SimpleImport("github.com/goadesign/goa/middleware"),
 		codegen.SimpleImport("regexp"),
+		codegen.SimpleImport("strconv"),
+		codegen.SimpleImport("time"),
+		codegen.NewImport("uuid", "github.com/satori/go.uuid"),
 	}
 	encoders, err := BuildEncoders(g.API.Produces, true)
 	if err != nil {
@@ -190,62 +214,25 @@ func (g *Generator) generateControllers() error {
 	if err != nil {
 		return err
 	}
-	encoderImports := make(map[string]bool)
-	for _, data := range encoders {
-		encoderImports[data.PackagePath] = true
-	}
-	for _, data := range decoders {
-		encoderImports[data.PackagePath] = true
-	}
-	var packagePaths []string
-	for packagePath := range encoderImports {
-		if packagePath != "github.com/goadesign/goa" {
-			packagePaths = append(packagePaths, packagePath)
-		}
-	}
-	sort.Strings(packagePaths)
-	for _, packagePath := range packagePaths {
-		imports = append(imports, codegen.SimpleImport(packagePath))
-	}
+	imports = append(imports, encoderImports(encoders, decoders)...)
 	ctlWr.WriteHeader(title, g.Target, imports)
 	ctlWr.WriteInitService(encoders, decoders)
 
 	var controllersData []*ControllerTemplateData
 	err = g.API.IterateResources(func(r *design.ResourceDefinition) error {
-		// Create file servers for all directory file servers that serve index.html.
-		fileServers := r.FileServers
-		for _, fs := range r.FileServers {
-			if fs.IsDir() {
-				rpath := design.WildcardRegex.ReplaceAllLiteralString(fs.RequestPath, "")
-				rpath += "/"
-				fileServers = append(fileServers, &design.FileServerDefinition{
-					Parent:      fs.Parent,
-					Description: fs.Description,
-					Docs:        fs.Docs,
-					FilePath:    filepath.Join(fs.FilePath, "index.html"),
-					RequestPath: rpath,
-					Metadata:    fs.Metadata,
-					Security:    fs.Security,
-				})
-			}
-		}
 		data := &ControllerTemplateData{
 			API:            g.API,
 			Resource:       codegen.Goify(r.Name, true),
 			PreflightPaths: r.PreflightPaths(),
-			FileServers:    fileServers,
+			FileServers:    indexFileServers(r),
+			Uploads:        r.Uploads,
+			Proxy:          r.Proxy,
 		}
+		preflights := make(map[string]*PreflightData)
 		ierr := r.IterateActions(func(a *design.ActionDefinition) error {
-			context := fmt.Sprintf("%s%sContext", codegen.Goify(a.Name, true), codegen.Goify(r.Name, true))
-			unmarshal := fmt.Sprintf("unmarshal%s%sPayload", codegen.Goify(a.Name, true), codegen.Goify(r.Name, true))
-			action := map[string]interface{}{
-				"Name":            codegen.Goify(a.Name, true),
-				"Routes":          a.Routes,
-				"Context":         context,
-				"Unmarshal":       unmarshal,
-				"Payload":         a.Payload,
-				"PayloadOptional": a.PayloadOptional,
-				"Security":        a.Security,
+			action, err := g.actionData(r, a, data, preflights)
+			if err != nil {
+				return err
 			}
 			data.Actions = append(data.Actions, action)
 			return nil
@@ -253,7 +240,18 @@ func (g *Generator) generateControllers() error {
 		if ierr != nil {
 			return ierr
 		}
-		if len(data.Actions) > 0 || len(data.FileServers) > 0 {
+		// Paths shared by actions that override the resource CORS policies and actions
+		// that do not are handled by the action preflight handlers.
+		var paths []string
+		for _, p := range data.PreflightPaths {
+			if pf, ok := preflights[p]; ok {
+				pf.Default = true
+				continue
+			}
+			paths = append(paths, p)
+		}
+		data.PreflightPaths = paths
+		if len(data.Actions) > 0 || len(data.FileServers) > 0 || len(data.Uploads) > 0 {
 			data.Encoders = encoders
 			data.Decoders = decoders
 			data.Origins = r.AllOrigins()
@@ -271,6 +269,171 @@ func (g *Generator) generateControllers() error {
 	return ctlWr.FormatCode()
 }
 
+// encoderImports returns the imports of the packages implementing the given encoders and
+// decoders.
+func encoderImports(encoders, decoders []*EncoderTemplateData) []*codegen.ImportSpec {
+	encoderImports := make(map[string]bool)
+	for _, data := range encoders {
+		encoderImports[data.PackagePath] = true
+	}
+	for _, data := range decoders {
+		encoderImports[data.PackagePath] = true
+	}
+	var packagePaths []string
+	for packagePath := range encoderImports {
+		if packagePath != "github.com/goadesign/goa" {
+			packagePaths = append(packagePaths, packagePath)
+		}
+	}
+	sort.Strings(packagePaths)
+	imports := make([]*codegen.ImportSpec, len(packagePaths))
+	for i, packagePath := range packagePaths {
+		imports[i] = codegen.SimpleImport(packagePath)
+	}
+	return imports
+}
+
+// indexFileServers returns the resource file servers together with file servers that serve
+// index.html for all the directory file servers.
+func indexFileServers(r *design.ResourceDefinition) []*design.FileServerDefinition {
+	fileServers := r.FileServers
+	for _, fs := range r.FileServers {
+		if fs.IsDir() {
+			rpath := design.WildcardRegex.ReplaceAllLiteralString(fs.RequestPath, "")
+			rpath += "/"
+			fileServers = append(fileServers, &design.FileServerDefinition{
+				Parent:      fs.Parent,
+				Description: fs.Description,
+				Docs:        fs.Docs,
+				FilePath:    filepath.Join(fs.FilePath, "index.html"),
+				RequestPath: rpath,
+				Metadata:    fs.Metadata,
+				Security:    fs.Security,
+			})
+		}
+	}
+	return fileServers
+}
+
+// actionData builds the data used by the controllers template to render the handler of the
+// given action. It records the action CORS preflight handlers in preflights.
+func (g *Generator) actionData(r *design.ResourceDefinition, a *design.ActionDefinition, data *ControllerTemplateData, preflights map[string]*PreflightData) (map[string]interface{}, error) {
+	context := fmt.Sprintf("%s%sContext", codegen.Goify(a.Name, true), codegen.Goify(r.Name, true))
+	unmarshal := fmt.Sprintf("unmarshal%s%sPayload", codegen.Goify(a.Name, true), codegen.Goify(r.Name, true))
+	action := map[string]interface{}{
+		"Name":             codegen.Goify(a.Name, true),
+		"Routes":           a.Routes,
+		"Context":          context,
+		"Unmarshal":        unmarshal,
+		"Payload":          a.Payload,
+		"PayloadOptional":  a.PayloadOptional,
+		"PayloadMultipart": a.PayloadMultipart,
+		"Security":         a.Security,
+		"FailFast":         a.FailFast(),
+		"Localizable":      a.Localizable(),
+		"Middleware":       a.EffectiveMiddleware(),
+		"SecurityHeaders":  a.EffectiveSecurityHeaders(),
+		"ScrubHeaders":     a.ScrubsHeaders(),
+		"AllowedHeaders":   a.AllowedResponseHeaders(),
+	}
+	if a.StrictContentType() {
+		var mimeTypes []string
+		for _, c := range g.API.Consumes {
+			mimeTypes = append(mimeTypes, c.MIMETypes...)
+		}
+		action["StrictContentType"] = mimeTypes
+	}
+	actionPolicies(a, action, data)
+	if len(a.Origins) > 0 {
+		action["Origins"] = a.AllOrigins()
+		actionPreflights(a, data, preflights)
+	}
+	if len(a.Fallbacks) > 0 {
+		action["Fallbacks"] = actionFallbacks(a)
+	}
+	if g.API.Sandbox && r.Proxy == nil {
+		responses, err := sandboxResponses(a)
+		if err != nil {
+			return nil, err
+		}
+		action["Sandbox"] = responses
+	}
+	if r.Proxy != nil && r.Proxy.ValidateResponse {
+		pmt, err := proxyResponse(a)
+		if err != nil {
+			return nil, err
+		}
+		action["ProxyResponse"] = pmt
+	}
+	return action, nil
+}
+
+// actionPolicies adds the data used to render the action request policies (rate limiting,
+// affinity, client certificates, challenges, data classification, body limit and compression).
+func actionPolicies(a *design.ActionDefinition, action map[string]interface{}, data *ControllerTemplateData) {
+	if rl := a.EffectiveRateLimit(); rl != nil {
+		action["RateLimit"] = map[string]interface{}{
+			"Requests": rl.Requests,
+			"Interval": rl.Interval,
+			"Burst":    rl.Burst,
+			"Key":      rateLimitKey(rl),
+		}
+	}
+	if af := a.EffectiveAffinity(); af != nil {
+		action["Affinity"] = affinityKey(af)
+	}
+	if cc := a.EffectiveClientCert(); cc != nil {
+		action["ClientCert"] = cc
+	}
+	if a.Challenge != nil {
+		action["Challenge"] = a.Challenge
+	}
+	if cl := a.Classifications(); len(cl) > 0 {
+		action["Classifications"] = cl
+		data.Classified = true
+	}
+	if limit := a.EffectiveBodyLimit(); limit > 0 {
+		action["BodyLimit"] = limit
+		data.BodyLimited = true
+	}
+	if mts := a.IncompressibleMediaTypes(); len(mts) > 0 {
+		action["Incompressible"] = mts
+	}
+}
+
+// actionPreflights records the CORS preflight handlers of the routes of an action that
+// overrides the resource CORS policies.
+func actionPreflights(a *design.ActionDefinition, data *ControllerTemplateData, preflights map[string]*PreflightData) {
+	for _, route := range a.Routes {
+		if route.Verb == "OPTIONS" {
+			continue
+		}
+		fp := route.FullPath()
+		p, ok := preflights[fp]
+		if !ok {
+			p = &PreflightData{Path: fp, Handlers: make(map[string]string)}
+			preflights[fp] = p
+			data.ActionPreflights = append(data.ActionPreflights, p)
+		}
+		p.Handlers[route.Verb] = codegen.Goify(a.Name, true)
+	}
+}
+
+// actionFallbacks returns the data used to render the action downstream fallback responses.
+func actionFallbacks(a *design.ActionDefinition) []map[string]interface{} {
+	fallbacks := make([]map[string]interface{}, len(a.Fallbacks))
+	for i, f := range a.Fallbacks {
+		resp := a.Responses[f.Response]
+		fallbacks[i] = map[string]interface{}{
+			"Downstream": f.Downstream,
+			"Status":     resp.Status,
+			"HasBody":    resp.Example != nil,
+			"Body":       resp.Example,
+		}
+	}
+	return fallbacks
+}
+
 // generateControllers iterates through the API resources and generates the low level
 // controllers.
 func (g *Generator) generateSecurity() error {
@@ -290,6 +453,7 @@ func (g *Generator) generateSecurity() error {
 		codegen.SimpleImport("errors"),
 		codegen.SimpleImport("golang.org/x/net/context"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("github.com/goadesign/goa/middleware/security/apikey"),
 	}
 	secWr.WriteHeader(title, g.Target, imports)
 
@@ -302,6 +466,97 @@ func (g *Generator) generateSecurity() error {
 	return secWr.FormatCode()
 }
 
+// generateDependencies generates the typed accessors for the request-scoped dependencies.
+func (g *Generator) generateDependencies() error {
+	if len(g.API.Dependencies) == 0 {
+		return nil
+	}
+
+	depFile := filepath.Join(g.OutDir, "dependencies.go")
+	depWr, err := NewDependenciesWriter(depFile)
+	if err != nil {
+		panic(err) // bug
+	}
+
+	title := fmt.Sprintf("%s: Application Dependencies", g.API.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	seen := make(map[string]bool)
+	for _, d := range g.API.Dependencies {
+		if d.PackagePath != "" && !seen[d.PackagePath] {
+			imports = append(imports, codegen.SimpleImport(d.PackagePath))
+			seen[d.PackagePath] = true
+		}
+	}
+	depWr.WriteHeader(title, g.Target, imports)
+
+	g.genfiles = append(g.genfiles, depFile)
+
+	if err = depWr.Execute(g.API.Dependencies); err != nil {
+		return err
+	}
+
+	return depWr.FormatCode()
+}
+
+// generateWebhooks iterates through the API actions and generates the functions that dispatch
+// the events of their webhooks.
+func (g *Generator) generateWebhooks() error {
+	var webhooks []*WebhookData
+	err := g.API.IterateResources(func(r *design.ResourceDefinition) error {
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			for _, w := range a.Webhooks {
+				mt := g.API.MediaTypeWithIdentifier(w.MediaType)
+				if mt == nil {
+					continue
+				}
+				projected, _, err := mt.Project(design.DefaultView)
+				if err != nil {
+					return err
+				}
+				words := strings.FieldsFunc(w.Event, func(r rune) bool {
+					return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+				})
+				webhooks = append(webhooks, &WebhookData{
+					Name:         codegen.Goify(strings.Join(words, "_"), true),
+					Event:        w.Event,
+					Description:  w.Description,
+					ResourceName: r.Name,
+					ActionName:   a.Name,
+					TypeRef:      codegen.GoTypeRef(projected, projected.AllRequired(), 0, false),
+				})
+			}
+			return nil
+		})
+	})
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	whFile := filepath.Join(g.OutDir, "webhooks.go")
+	whWr, err := NewWebhooksWriter(whFile)
+	if err != nil {
+		panic(err) // bug
+	}
+
+	title := fmt.Sprintf("%s: Application Webhooks", g.API.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	whWr.WriteHeader(title, g.Target, imports)
+
+	g.genfiles = append(g.genfiles, whFile)
+
+	if err = whWr.Execute(webhooks); err != nil {
+		return err
+	}
+
+	return whWr.FormatCode()
+}
+
 // generateHrefs iterates through the API resources and generates the href factory methods.
 func (g *Generator) generateHrefs() error {
 	hrefFile := filepath.Join(g.OutDir, "hrefs.go")
@@ -330,6 +585,21 @@ func (g *Generator) generateHrefs() error {
 			CanonicalTemplate: codegen.CanonicalTemplate(r),
 			CanonicalParams:   codegen.CanonicalParams(r),
 		}
+		for _, m := range r.Mounts {
+			ro := m.CanonicalRoute()
+			if ro == nil {
+				continue
+			}
+			params := ro.Params()
+			for i, p := range params {
+				params[i] = codegen.Goify(p, false)
+			}
+			data.Mounts = append(data.Mounts, &MountData{
+				Name:              codegen.Goify(m.Name, true),
+				CanonicalTemplate: design.WildcardRegex.ReplaceAllLiteralString(ro.FullPath(), "/%v"),
+				CanonicalParams:   params,
+			})
+		}
 		return resWr.Execute(&data)
 	})
 	g.genfiles = append(g.genfiles, hrefFile)
@@ -382,6 +652,7 @@ func (g *Generator) generateUserTypes() error {
 	title := fmt.Sprintf("%s: Application User Types", g.API.Context())
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("mime/multipart"),
 		codegen.SimpleImport("time"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.NewImport("uuid", "github.com/satori/go.uuid"),
@@ -396,3 +667,171 @@ func (g *Generator) generateUserTypes() error {
 	}
 	return utWr.FormatCode()
 }
+
+// rateLimitKey returns the code of the function that identifies the clients of the rate limiter.
+func rateLimitKey(r *design.RateLimitDefinition) string {
+	switch r.KeyBy {
+	case "user":
+		return `middleware.RateLimitKeyHeader("Authorization")`
+	case "apikey":
+		return fmt.Sprintf("middleware.RateLimitKeyHeader(%q)", r.KeyHeader)
+	default:
+		return "middleware.RateLimitKeyIP"
+	}
+}
+
+// affinityKey returns the code that creates the key function of the Affinity middleware.
+func affinityKey(a *design.AffinityDefinition) string {
+	if a.Source == "param" {
+		return fmt.Sprintf("middleware.AffinityFromParam(%q)", a.Name)
+	}
+	return fmt.Sprintf("middleware.AffinityFromHeader(%q)", a.Name)
+}
+
+// proxyResponse returns the media type of the action OK response projected onto the response
+// view, nil if the action has no OK response with a media type.
+func proxyResponse(a *design.ActionDefinition) (*design.MediaTypeDefinition, error) {
+	ok, found := a.Responses["OK"]
+	if !found {
+		return nil, nil
+	}
+	mt := design.Design.MediaTypeWithIdentifier(ok.MediaType)
+	if mt == nil {
+		return nil, nil
+	}
+	view := ok.ViewName
+	if view == "" {
+		view = design.DefaultView
+	}
+	pmt, _, err := mt.Project(view)
+	return pmt, err
+}
+
+// sandboxResponses returns the data used to render the sandbox responses of the action sorted by
+// status code. The example bodies are JSON encoded and indexed by view name, they are taken from
+// the response Example DSL if any or generated from each view of the response media type.
+func sandboxResponses(a *design.ActionDefinition) ([]map[string]interface{}, error) {
+	resps := make([]*design.ResponseDefinition, 0, len(a.Responses))
+	for _, r := range a.Responses {
+		resps = append(resps, r)
+	}
+	sort.Sort(byStatus(resps))
+	res := make([]map[string]interface{}, len(resps))
+	for i, r := range resps {
+		contentType := r.MediaType
+		views := make(map[string]string)
+		mt := design.Design.MediaTypeWithIdentifier(r.MediaType)
+		if mt != nil {
+			contentType = mt.ContentType
+		}
+		switch {
+		case r.Example != nil:
+			view := r.ViewName
+			if view == "" {
+				view = design.DefaultView
+			}
+			b, err := json.Marshal(toStringMap(r.Example))
+			if err != nil {
+				return nil, fmt.Errorf("invalid example of response %s of action %s: %s", r.Name, a.Name, err)
+			}
+			views[view] = string(b)
+		case mt != nil:
+			names := []string{r.ViewName}
+			if r.ViewName == "" {
+				names = names[:0]
+				for n := range mt.ComputeViews() {
+					names = append(names, n)
+				}
+			}
+			for _, n := range names {
+				pmt, _, err := mt.Project(n)
+				if err != nil {
+					return nil, err
+				}
+				ex := pmt.GenerateExample(design.NewRandomGenerator(mt.Identifier+"; view="+n), nil)
+				if ex == nil {
+					continue
+				}
+				b, err := json.Marshal(toStringMap(ex))
+				if err != nil {
+					return nil, err
+				}
+				views[n] = string(b)
+			}
+		}
+		res[i] = map[string]interface{}{
+			"Name":        r.Name,
+			"Status":      r.Status,
+			"ContentType": contentType,
+			"Views":       views,
+		}
+	}
+	return res, nil
+}
+
+// byStatus makes it possible to sort responses by status code.
+type byStatus []*design.ResponseDefinition
+
+func (b byStatus) Len() int      { return len(b) }
+func (b byStatus) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byStatus) Less(i, j int) bool {
+	if b[i].Status == b[j].Status {
+		return b[i].Name < b[j].Name
+	}
+	return b[i].Status < b[j].Status
+}
+
+// toStringMap converts map[interface{}]interface{} to a map[string]interface{} when possible.
+func toStringMap(val interface{}) interface{} {
+	switch actual := val.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for k, v := range actual {
+			m[fmt.Sprintf("%v", k)] = toStringMap(v)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for k, v := range actual {
+			m[k] = toStringMap(v)
+		}
+		return m
+	case []interface{}:
+		mapSlice := make([]interface{}, len(actual))
+		for i, e := range actual {
+			mapSlice[i] = toStringMap(e)
+		}
+		return mapSlice
+	default:
+		return actual
+	}
+}
+
+// preconditionsMediaType returns the default media type of the action resource projected onto
+// its default view if the action has a PUT, PATCH or DELETE route and the projection defines an
+// entity tag or a last modification time, nil otherwise.
+func preconditionsMediaType(a *design.ActionDefinition) *design.MediaTypeDefinition {
+	mutating := false
+	for _, r := range a.Routes {
+		if r.Verb == "PUT" || r.Verb == "PATCH" || r.Verb == "DELETE" {
+			mutating = true
+			break
+		}
+	}
+	if !mutating {
+		return nil
+	}
+	mt := design.Design.MediaTypeWithIdentifier(a.Parent.MediaType)
+	if mt == nil || !mt.Type.IsObject() {
+		return nil
+	}
+	view := a.Parent.DefaultViewName
+	if view == "" {
+		view = design.DefaultView
+	}
+	pmt, _, err := mt.Project(view)
+	if err != nil || (len(pmt.ETagAttributes) == 0 && pmt.LastModifiedAttribute == "") {
+		return nil
+	}
+	return pmt
+}
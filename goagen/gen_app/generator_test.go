@@ -244,6 +244,31 @@ var _ = Describe("Generate", func() {
 			})
 		})
 
+		Context("with sandbox mode", func() {
+			BeforeEach(func() {
+				design.Design.Sandbox = true
+				design.Design.Resources["Widget"].Actions["get"].Responses["notFound"] = &design.ResponseDefinition{
+					Name:   "notFound",
+					Status: 404,
+				}
+			})
+
+			It("generates the sandbox responses", func() {
+				Ω(genErr).Should(BeNil())
+
+				controllersContent, err := ioutil.ReadFile(filepath.Join(outDir, "app", "controllers.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				controllers := string(controllersContent)
+				Ω(controllers).Should(ContainSubstring(`	sandboxGet := []*goa.SandboxResponse{
+		{Name: "ok", Status: 200, ContentType: "application/vnd.rightscale.codegen.test.widgets", Views: map[string]string{
+			"default": "\"`))
+				Ω(controllers).Should(ContainSubstring(`		{Name: "notFound", Status: 404},
+	}
+`))
+				Ω(controllers).Should(ContainSubstring(`			return service.SendSandboxResponse(ctx, sandboxGet)`))
+			})
+		})
+
 	})
 })
 
@@ -341,14 +366,18 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 			return err
 		}
 		// Build the context
+		endValidate := goa.StartPhase(ctx, goa.PhaseValidate)
 		rctx, err := NewGetWidgetContext(ctx, service)
+		endValidate()
 		if err != nil {
 			return err
 		}
+		defer goa.StartPhase(ctx, goa.PhaseHandler)()
 		return ctrl.Get(rctx)
 	}
 	service.Mux.Handle("GET", "/:id", ctrl.MuxHandler("Get", h, nil))
 	service.LogInfo("mount", "ctrl", "Widget", "action", "Get", "route", "GET /:id")
+	service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: "/:id", Resource: "Widget", Action: "Get"})
 }
 `
 
@@ -396,13 +425,23 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 	var h goa.Handler
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
-		// Check if there was an error loading the request
-		if err := goa.ContextError(ctx); err != nil {
-			return err
+		// Payloads that cannot be loaded at all (e.g. too large or of an unsupported media
+		// type) are reported as is, bad request errors are reported together with the
+		// parameter validation errors
+		loadErr := goa.ContextError(ctx)
+		if serr, ok := loadErr.(goa.ServiceError); ok && serr.ResponseStatus() != 400 {
+			return loadErr
 		}
 		// Build the context
+		endValidate := goa.StartPhase(ctx, goa.PhaseValidate)
 		rctx, err := NewGetWidgetContext(ctx, service)
-		if err != nil {
+		endValidate()
+		if err = goa.MergeErrors(loadErr, err); err != nil {
+			return err
+		}
+		// Load the payload of requests that expect a 100 Continue response now that the
+		// request has been authorized and its headers validated
+		if err := goa.LoadDeferredPayload(ctx); err != nil {
 			return err
 		}
 		// Build the payload
@@ -411,10 +450,12 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 		} else {
 			return goa.MissingPayloadError()
 		}
+		defer goa.StartPhase(ctx, goa.PhaseHandler)()
 		return ctrl.Get(rctx)
 	}
 	service.Mux.Handle("GET", "/:id", ctrl.MuxHandler("Get", h, unmarshalGetWidgetPayload))
 	service.LogInfo("mount", "ctrl", "Widget", "action", "Get", "route", "GET /:id")
+	service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: "/:id", Resource: "Widget", Action: "Get"})
 }
 
 // unmarshalGetWidgetPayload unmarshals the request body into the context request data Payload field.
@@ -435,23 +476,35 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 	var h goa.Handler
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
-		// Check if there was an error loading the request
-		if err := goa.ContextError(ctx); err != nil {
-			return err
+		// Payloads that cannot be loaded at all (e.g. too large or of an unsupported media
+		// type) are reported as is, bad request errors are reported together with the
+		// parameter validation errors
+		loadErr := goa.ContextError(ctx)
+		if serr, ok := loadErr.(goa.ServiceError); ok && serr.ResponseStatus() != 400 {
+			return loadErr
 		}
 		// Build the context
+		endValidate := goa.StartPhase(ctx, goa.PhaseValidate)
 		rctx, err := NewGetWidgetContext(ctx, service)
-		if err != nil {
+		endValidate()
+		if err = goa.MergeErrors(loadErr, err); err != nil {
+			return err
+		}
+		// Load the payload of requests that expect a 100 Continue response now that the
+		// request has been authorized and its headers validated
+		if err := goa.LoadDeferredPayload(ctx); err != nil {
 			return err
 		}
 		// Build the payload
 		if rawPayload := goa.ContextRequest(ctx).Payload; rawPayload != nil {
 			rctx.Payload = rawPayload.(Collection)
 		}
+		defer goa.StartPhase(ctx, goa.PhaseHandler)()
 		return ctrl.Get(rctx)
 	}
 	service.Mux.Handle("GET", "/:id", ctrl.MuxHandler("Get", h, unmarshalGetWidgetPayload))
 	service.LogInfo("mount", "ctrl", "Widget", "action", "Get", "route", "GET /:id")
+	service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: "/:id", Resource: "Widget", Action: "Get"})
 }
 
 // unmarshalGetWidgetPayload unmarshals the request body into the context request data Payload field.
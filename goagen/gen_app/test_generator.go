@@ -102,7 +102,7 @@ func (g *Generator) generateResourceTest() error {
 				}
 				for routeIndex, route := range action.Routes {
 					mediaType := design.Design.MediaTypeWithIdentifier(response.MediaType)
-					if mediaType == nil {
+					if mediaType == nil || response.Stream != "" { // Streamed bodies are not decoded
 						methods = append(methods, g.createTestMethod(res, action, response, route, routeIndex, nil, nil))
 					} else {
 						if err := mediaType.IterateViews(func(view *design.ViewDefinition) error {
@@ -167,7 +167,8 @@ func (g *Generator) createTestMethod(resource *design.ResourceDefinition, action
 		if p.IsObject() && !p.IsError() {
 			returnType.Pointer = "*"
 		}
-		returnType.Validatable = validate != ""
+		// Error media types are decoded into goa.ErrorResponse which has no Validate method.
+		returnType.Validatable = validate != "" && !p.IsError()
 	}
 
 	comment = "runs the method " + actionName + " of the given controller with the given parameters"
@@ -6,9 +6,11 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	"sort"
 
+	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/goagen/codegen"
 )
@@ -42,6 +44,16 @@ type (
 		SecurityTmpl *template.Template
 	}
 
+	// DependenciesWriter generate code for the request-scoped dependency accessors.
+	DependenciesWriter struct {
+		*codegen.SourceFile
+	}
+
+	// WebhooksWriter generate code for the functions that dispatch the webhook events.
+	WebhooksWriter struct {
+		*codegen.SourceFile
+	}
+
 	// ResourcesWriter generate code for a goa application resources.
 	// Resources are data structures initialized by the application handlers and passed to controller
 	// actions.
@@ -78,18 +90,41 @@ type (
 		API          *design.APIDefinition
 		DefaultPkg   string
 		Security     *design.SecurityDefinition
+		FailFast     bool // Stop at first validation error
+		Pagination   *design.PaginationDefinition
+		// Preconditions is the projected media type used to evaluate the request
+		// preconditions of mutating actions if any.
+		Preconditions *design.MediaTypeDefinition
+
+		Streams            bool          // Whether to generate the Stream method
+		StreamWriteTimeout time.Duration // Stream write timeout
+		StreamBuffer       int           // Stream maximum number of buffered bytes
 	}
 
 	// ControllerTemplateData contains the information required to generate an action handler.
 	ControllerTemplateData struct {
 		API            *design.APIDefinition          // API definition
 		Resource       string                         // Lower case plural resource name, e.g. "bottles"
-		Actions        []map[string]interface{}       // Array of actions, each action has keys "Name", "Routes", "Context" and "Unmarshal"
+		Actions        []map[string]interface{}       // Array of actions, each action has keys "Name", "Routes", "Context", "Unmarshal", "FailFast", "Localizable", "Middleware", "SecurityHeaders", "ScrubHeaders", "AllowedHeaders", "StrictContentType", "Classifications", "BodyLimit", "Challenge" and "ProxyResponse"
 		FileServers    []*design.FileServerDefinition // File servers
+		Uploads        []*design.UploadDefinition     // Resumable uploads
 		Encoders       []*EncoderTemplateData         // Encoder data
 		Decoders       []*EncoderTemplateData         // Decoder data
 		Origins        []*design.CORSDefinition       // CORS policies
+		Proxy          *design.ProxyDefinition        // Upstream service the actions are forwarded to if any
+		Classified     bool                           // Whether actions define classified params or payload fields
+		BodyLimited    bool                           // Whether actions define request body length limits
 		PreflightPaths []string
+		// ActionPreflights lists the paths of the actions that override the resource CORS policies
+		ActionPreflights []*PreflightData
+	}
+
+	// PreflightData contains the information required to mount the handler of the preflight
+	// requests made to a path served by actions that override the resource CORS policies.
+	PreflightData struct {
+		Path     string            // Request path
+		Handlers map[string]string // Name of the action indexed by HTTP method
+		Default  bool              // Whether actions that use the resource policies serve the path
 	}
 
 	// ResourceData contains the information required to generate the resource GoGenerator
@@ -100,6 +135,26 @@ type (
 		Type              *design.MediaTypeDefinition // Type of resource media type
 		CanonicalTemplate string                      // CanonicalFormat represents the resource canonical path in the form of a fmt.Sprintf format.
 		CanonicalParams   []string                    // CanonicalParams is the list of parameter names that appear in the resource canonical path in order.
+		Mounts            []*MountData                // Mounts lists the additional resource mounts that have a canonical path.
+	}
+
+	// WebhookData contains the information required to generate the dispatch function of a
+	// webhook.
+	WebhookData struct {
+		Name         string // Name is the Go identifier derived from the event name.
+		Event        string // Event is the name of the event.
+		Description  string // Description of the event.
+		ResourceName string // ResourceName is the name of the resource of the action declaring the webhook.
+		ActionName   string // ActionName is the name of the action declaring the webhook.
+		TypeRef      string // TypeRef is the Go type reference of the event payload.
+	}
+
+	// MountData contains the information required to generate the href function of a resource
+	// mount.
+	MountData struct {
+		Name              string   // Name of mount
+		CanonicalTemplate string   // CanonicalTemplate is the mounted resource canonical path in the form of a fmt.Sprintf format.
+		CanonicalParams   []string // CanonicalParams is the list of parameter names that appear in CanonicalTemplate in order.
 	}
 
 	// EncoderTemplateData contains the data needed to render the registration code for a single
@@ -118,6 +173,15 @@ type (
 	}
 )
 
+// Mux returns the expression that evaluates to the mux the controller is mounted on in the
+// generated code.
+func (d *ControllerTemplateData) Mux() string {
+	if d.API != nil && d.API.VersionNegotiated() {
+		return "serviceMux(service)"
+	}
+	return "service.Mux"
+}
+
 // IsPathParam returns true if the given parameter name corresponds to a path parameter for all
 // the context action routes. Such parameter is required but does not need to be validated as
 // httptreemux takes care of that.
@@ -159,6 +223,72 @@ func (c *ContextTemplateData) HasParamAndHeader(name string) bool {
 	return false
 }
 
+// HeadersName returns the name of the struct that groups the typed values of the action request
+// headers, e.g. "ListBottleHeaders". It returns the empty string if the action does not declare
+// headers or if a context field is already named "Headers".
+func (c *ContextTemplateData) HeadersName() string {
+	if c.Headers == nil || len(c.Headers.Type.ToObject()) == 0 {
+		return ""
+	}
+	for _, att := range []*design.AttributeDefinition{c.Headers, c.Params} {
+		if att == nil {
+			continue
+		}
+		for n, a := range att.Type.ToObject() {
+			if codegen.GoifyAtt(a, n, true) == "Headers" {
+				return ""
+			}
+		}
+	}
+	return strings.TrimSuffix(c.Name, "Context") + "Headers"
+}
+
+// HeaderFields returns the names and Go types of the fields of the struct named after HeadersName
+// sorted by name. The types are the types of the context fields the values are copied from: a
+// header whose field name matches a param field uses the param field type.
+func (c *ContextTemplateData) HeaderFields() []map[string]string {
+	obj := c.Headers.Type.ToObject()
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fields := make([]map[string]string, len(names))
+	for i, n := range names {
+		parent, att := c.Headers, obj[n]
+		field := codegen.GoifyAtt(att, n, true)
+		if c.HasParamAndHeader(n) {
+			for pn, patt := range c.Params.Type.ToObject() {
+				if codegen.GoifyAtt(patt, pn, true) == field {
+					parent, att, n = c.Params, patt, pn
+					break
+				}
+			}
+		}
+		typ := codegen.GoTypeRef(att.Type, nil, 0, false)
+		if att.Type.IsPrimitive() && parent.IsPrimitivePointer(n) {
+			typ = "*" + typ
+		}
+		fields[i] = map[string]string{"Name": field, "Type": typ}
+	}
+	return fields
+}
+
+// PaginationParams returns the names of the pagination parameters defined by the action.
+func (c *ContextTemplateData) PaginationParams() []string {
+	if c.Pagination == nil || c.Params == nil {
+		return nil
+	}
+	obj := c.Params.Type.ToObject()
+	var names []string
+	for _, n := range []string{goa.PageParam, goa.PerPageParam, goa.CursorParam} {
+		if _, ok := obj[n]; ok {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
 // MustValidate returns true if code that checks for the presence of the given param must be
 // generated.
 func (c *ContextTemplateData) MustValidate(name string) bool {
@@ -230,26 +360,20 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 			var ok bool
 			if mt, ok = resp.Type.(*design.MediaTypeDefinition); !ok {
 				respData["Type"] = resp.Type
+				if resp.Stream != "" {
+					return w.writeStreamResponse(resp, nil, respData)
+				}
 				respData["ContentType"] = resp.MediaType
 				return w.ExecuteTemplate("response", ctxTRespT, nil, respData)
 			}
 		} else {
 			mt = design.Design.MediaTypeWithIdentifier(resp.MediaType)
 		}
+		if resp.Stream != "" {
+			return w.writeStreamResponse(resp, mt, respData)
+		}
 		if mt != nil {
-			var views []string
-			if resp.ViewName != "" {
-				views = []string{resp.ViewName}
-			} else {
-				views = make([]string, len(mt.Views))
-				i := 0
-				for name := range mt.Views {
-					views[i] = name
-					i++
-				}
-				sort.Strings(views)
-			}
-			for _, view := range views {
+			for _, view := range responseViews(resp, mt) {
 				projected, _, err := mt.Project(view)
 				if err != nil {
 					return err
@@ -258,12 +382,7 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 				respData["ViewName"] = view
 				respData["MediaType"] = mt
 				respData["ContentType"] = mt.ContentType
-				if view == "default" {
-					respData["RespName"] = codegen.Goify(resp.Name, true)
-				} else {
-					base := fmt.Sprintf("%s%s", resp.Name, strings.Title(view))
-					respData["RespName"] = codegen.Goify(base, true)
-				}
+				respData["RespName"] = responseName(resp, view)
 				if err := w.ExecuteTemplate("response", ctxMTRespT, fn, respData); err != nil {
 					return err
 				}
@@ -274,6 +393,84 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 	})
 }
 
+// writeStreamResponse writes the helpers of a response defined with the Stream DSL. mt is the
+// response media type definition if any. NDJSON streams get one helper per media type view like
+// the other responses.
+func (w *ContextsWriter) writeStreamResponse(resp *design.ResponseDefinition, mt *design.MediaTypeDefinition, respData map[string]interface{}) error {
+	respData["RespName"] = codegen.Goify(resp.Name, true)
+	if resp.Stream != "ndjson" {
+		ct := resp.MediaType
+		if mt != nil && mt.ContentType != "" {
+			ct = mt.ContentType
+		}
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		respData["ContentType"] = ct
+		return w.ExecuteTemplate("response", ctxStreamRespT, nil, respData)
+	}
+	respData["ContentType"] = "application/x-ndjson"
+	if t, ok := respData["Type"]; ok {
+		respData["Item"] = streamItem(t.(design.DataType))
+		return w.writeNDJSONResponse(respData)
+	}
+	for _, view := range responseViews(resp, mt) {
+		projected, _, err := mt.Project(view)
+		if err != nil {
+			return err
+		}
+		respData["Item"] = streamItem(projected)
+		respData["RespName"] = responseName(resp, view)
+		if err := w.writeNDJSONResponse(respData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNDJSONResponse writes the helpers of a NDJSON stream response: one that reads the items
+// from a channel and one that reads them from a goa.ItemIterator.
+func (w *ContextsWriter) writeNDJSONResponse(respData map[string]interface{}) error {
+	if err := w.ExecuteTemplate("response", ctxStreamRespT, nil, respData); err != nil {
+		return err
+	}
+	respData["Iterator"] = true
+	defer delete(respData, "Iterator")
+	return w.ExecuteTemplate("response", ctxStreamRespT, nil, respData)
+}
+
+// responseViews returns the names of the views of mt rendered by resp sorted alphabetically.
+func responseViews(resp *design.ResponseDefinition, mt *design.MediaTypeDefinition) []string {
+	if resp.ViewName != "" {
+		return []string{resp.ViewName}
+	}
+	views := make([]string, len(mt.Views))
+	i := 0
+	for name := range mt.Views {
+		views[i] = name
+		i++
+	}
+	sort.Strings(views)
+	return views
+}
+
+// responseName returns the name of the helper method that sends resp rendered with view.
+func responseName(resp *design.ResponseDefinition, view string) string {
+	if view == "default" {
+		return codegen.Goify(resp.Name, true)
+	}
+	return codegen.Goify(fmt.Sprintf("%s%s", resp.Name, strings.Title(view)), true)
+}
+
+// streamItem returns the type of the items of a NDJSON stream response of type dt: the element
+// type if dt is an array, dt otherwise.
+func streamItem(dt design.DataType) design.DataType {
+	if dt.IsArray() {
+		return dt.ToArray().ElemType.Type
+	}
+	return dt
+}
+
 // NewControllersWriter returns a handlers code writer.
 // Handlers provide the glue between the underlying request data and the user controller.
 func NewControllersWriter(filename string) (*ControllersWriter, error) {
@@ -287,9 +484,15 @@ func NewControllersWriter(filename string) (*ControllersWriter, error) {
 // WriteInitService writes the initService function
 func (w *ControllersWriter) WriteInitService(encoders, decoders []*EncoderTemplateData) error {
 	ctx := map[string]interface{}{
-		"API":      design.Design,
-		"Encoders": encoders,
-		"Decoders": decoders,
+		"API":         design.Design,
+		"Encoders":    encoders,
+		"Decoders":    decoders,
+		"RateLimited": design.Design != nil && design.Design.HasRateLimits(),
+		"Challenged":  design.Design != nil && design.Design.HasChallenges(),
+		"Versioned":   design.Design != nil && design.Design.VersionNegotiated(),
+	}
+	if design.Design != nil {
+		ctx["OriginFuncs"] = design.Design.OriginFuncs()
 	}
 	if err := w.ExecuteTemplate("service", serviceT, nil, ctx); err != nil {
 		return err
@@ -306,7 +509,7 @@ func (w *ControllersWriter) Execute(data []*ControllerTemplateData) error {
 		if err := w.ExecuteTemplate("controller", ctrlT, nil, d); err != nil {
 			return err
 		}
-		if err := w.ExecuteTemplate("mount", mountT, nil, d); err != nil {
+		if err := w.ExecuteTemplate("mount", mountT, template.FuncMap{"sandboxViews": sandboxViews}, d); err != nil {
 			return err
 		}
 		if len(d.Origins) > 0 {
@@ -314,7 +517,22 @@ func (w *ControllersWriter) Execute(data []*ControllerTemplateData) error {
 				return err
 			}
 		}
-		if err := w.ExecuteTemplate("unmarshal", unmarshalT, nil, d); err != nil {
+		for _, a := range d.Actions {
+			if origins, ok := a["Origins"]; ok {
+				data := map[string]interface{}{
+					"Resource": d.Resource + a["Name"].(string),
+					"Origins":  origins,
+				}
+				if err := w.ExecuteTemplate("handleCORS", handleCORST, nil, data); err != nil {
+					return err
+				}
+			}
+		}
+		fn := template.FuncMap{
+			"newCoerceData":  newCoerceData,
+			"arrayAttribute": arrayAttribute,
+		}
+		if err := w.ExecuteTemplate("unmarshal", unmarshalT, fn, d); err != nil {
 			return err
 		}
 	}
@@ -336,6 +554,34 @@ func (w *SecurityWriter) Execute(schemes []*design.SecuritySchemeDefinition) err
 	return w.ExecuteTemplate("security_schemes", securitySchemesT, nil, schemes)
 }
 
+// NewDependenciesWriter returns a dependency accessors code writer.
+func NewDependenciesWriter(filename string) (*DependenciesWriter, error) {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &DependenciesWriter{SourceFile: file}, nil
+}
+
+// Execute writes the typed accessors for the given dependencies.
+func (w *DependenciesWriter) Execute(deps []*design.DependencyDefinition) error {
+	return w.ExecuteTemplate("dependencies", dependenciesT, nil, deps)
+}
+
+// NewWebhooksWriter returns a webhook dispatch functions code writer.
+func NewWebhooksWriter(filename string) (*WebhooksWriter, error) {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhooksWriter{SourceFile: file}, nil
+}
+
+// Execute writes the dispatch functions of the given webhooks.
+func (w *WebhooksWriter) Execute(webhooks []*WebhookData) error {
+	return w.ExecuteTemplate("webhooks", webhooksT, nil, webhooks)
+}
+
 // NewResourcesWriter returns a contexts code writer.
 // Resources provide the glue between the underlying request data and the user controller.
 func NewResourcesWriter(filename string) (*ResourcesWriter, error) {
@@ -417,6 +663,28 @@ func newCoerceData(name string, att *design.AttributeDefinition, pointer bool, p
 	}
 }
 
+// sandboxViews returns the quoted view names and examples of a sandbox response sorted by view
+// name. The keys include the colon and are padded the way gofmt aligns the map entries.
+func sandboxViews(views map[string]string) []map[string]string {
+	names := make([]string, 0, len(views))
+	width := 0
+	for n := range views {
+		names = append(names, n)
+		if l := len(fmt.Sprintf("%q:", n)); l > width {
+			width = l
+		}
+	}
+	sort.Strings(names)
+	res := make([]map[string]string, len(names))
+	for i, n := range names {
+		res[i] = map[string]string{
+			"Key":   fmt.Sprintf("%-*s", width, fmt.Sprintf("%q:", n)),
+			"Value": fmt.Sprintf("%q", views[n]),
+		}
+	}
+	return res
+}
+
 // arrayAttribute returns the array element attribute definition.
 func arrayAttribute(a *design.AttributeDefinition) *design.AttributeDefinition {
 	return a.Type.(*design.Array).ElemType
@@ -435,8 +703,54 @@ type {{ .Name }} struct {
 {{ end }}{{ end }}{{ end }}{{ if .Params }}{{ range $name, $att := .Params.Type.ToObject }}{{/*
 */}}	{{ goifyatt $att $name true }} {{ if and $att.Type.IsPrimitive ($.Params.IsPrimitivePointer $name) }}*{{ end }}{{ gotyperef .Type nil 0 false }}
 {{ end }}{{ end }}{{ if .Payload }}	Payload {{ gotyperef .Payload nil 0 false }}
+{{ end }}{{ if .Pagination }}	pagination *goa.Pagination
 {{ end }}}
-`
+{{ with .HeadersName }}
+// {{ . }} groups the typed values of the request headers of the {{ $.ResourceName }} {{ $.ActionName }} action.
+type {{ . }} struct {
+{{ range $.HeaderFields }}	{{ .Name }} {{ .Type }}
+{{ end }}}
+
+// Headers returns the typed values of the request headers declared in the design, the values are
+// coerced and validated by New{{ $.Name }}.
+func (ctx *{{ $.Name }}) Headers() *{{ . }} {
+	return &{{ . }}{
+{{ range $.HeaderFields }}		{{ .Name }}: ctx.{{ .Name }},
+{{ end }}	}
+}
+{{ end }}{{ if .Pagination }}
+// Pagination returns the pagination parameters of the request. Set the {{ if .Pagination.Cursor }}NextCursor{{ else }}Total{{ end }} field of the
+// returned value before sending the OK response so that it links to the other pages.
+func (ctx *{{ .Name }}) Pagination() *goa.Pagination {
+	if ctx.pagination == nil {
+		ctx.pagination = &goa.Pagination{Total: -1}
+{{ range $name := .PaginationParams }}{{ $att := index $.Params.Type.ToObject $name }}{{ $field := goifyatt $att $name true }}{{/*
+*/}}{{ if $.Params.IsPrimitivePointer $name }}		if ctx.{{ $field }} != nil {
+			ctx.pagination.{{ $field }} = *ctx.{{ $field }}
+		}
+{{ else }}		ctx.pagination.{{ $field }} = ctx.{{ $field }}
+{{ end }}{{ end }}	}
+	return ctx.pagination
+}
+{{ end }}{{ if .Preconditions }}{{ $mt := .Preconditions }}
+// CheckPreconditions evaluates the request If-Match and If-Unmodified-Since headers against current,
+// the current state of the resource. It returns an error with status code 412 that the action
+// should return without applying any change if the preconditions are not met. Pass nil if the
+// resource does not exist.
+func (ctx *{{ .Name }}) CheckPreconditions(current {{ gotyperef $mt $mt.AllRequired 0 false }}) error {
+	return goa.CheckPreconditions(ctx.Context, {{ if $mt.ETagAttributes }}current.ETag(){{ else }}""{{ end }}, {{/*
+*/}}{{ if $mt.LastModifiedAttribute }}current.LastModified(){{ else }}time.Time{}{{ end }})
+}
+{{ end }}{{ if .Streams }}
+// Stream returns a writer that streams the response to the client, the writer applies the write
+// timeout and buffer limits defined in the design. Close must be called on the writer once done.
+func (ctx *{{ .Name }}) Stream() *goa.StreamWriter {
+	return goa.NewStreamWriter(ctx, ctx.ResponseData, goa.StreamOptions{
+		WriteTimeout: {{ printf "%d" .StreamWriteTimeout }}, // {{ .StreamWriteTimeout }}
+		MaxBuffered:  {{ .StreamBuffer }},
+	})
+}
+{{ end }}`
 	// coerceT generates the code that coerces the generic deserialized
 	// data to the actual type.
 	// template input: map[string]interface{} as returned by newCoerceData
@@ -448,7 +762,7 @@ type {{ .Name }} struct {
 {{ if .Pointer }}{{ tabs .Depth }}	{{ $varName }} := &{{ .VarName }}
 {{ end }}{{ tabs .Depth }}	{{ .Pkg }} = {{ $varName }}
 {{ tabs .Depth }}} else {
-{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "boolean"))
+{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", {{ if .Attribute.Classification }}goa.Redacted({{ printf "%q" .Attribute.Classification }}){{ else }}raw{{ goify .Name true }}{{ end }}, "boolean"))
 {{ tabs .Depth }}}
 {{ end }}{{ if eq .Attribute.Type.Kind 2 }}{{/*
 
@@ -460,7 +774,7 @@ type {{ .Name }} struct {
 {{ tabs .Depth }}	{{ .Pkg }} = {{ $tmp }}
 {{ else }}{{ tabs .Depth }}	{{ .Pkg }} = {{ .VarName }}
 {{ end }}{{ tabs .Depth }}} else {
-{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "integer"))
+{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", {{ if .Attribute.Classification }}goa.Redacted({{ printf "%q" .Attribute.Classification }}){{ else }}raw{{ goify .Name true }}{{ end }}, "integer"))
 {{ tabs .Depth }}}
 {{ end }}{{ if eq .Attribute.Type.Kind 3 }}{{/*
 
@@ -470,7 +784,7 @@ type {{ .Name }} struct {
 {{ if .Pointer }}{{ tabs .Depth }}	{{ $varName }} := &{{ .VarName }}
 {{ end }}{{ tabs .Depth }}	{{ .Pkg }} = {{ $varName }}
 {{ tabs .Depth }}} else {
-{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "number"))
+{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", {{ if .Attribute.Classification }}goa.Redacted({{ printf "%q" .Attribute.Classification }}){{ else }}raw{{ goify .Name true }}{{ end }}, "number"))
 {{ tabs .Depth }}}
 {{ end }}{{ if eq .Attribute.Type.Kind 4 }}{{/*
 
@@ -484,7 +798,7 @@ type {{ .Name }} struct {
 {{ if .Pointer }}{{ tabs .Depth }}	{{ $varName }} := &{{ .VarName }}
 {{ end }}{{ tabs .Depth }}	{{ .Pkg }} = {{ $varName }}
 {{ tabs .Depth }}} else {
-{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "datetime"))
+{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", {{ if .Attribute.Classification }}goa.Redacted({{ printf "%q" .Attribute.Classification }}){{ else }}raw{{ goify .Name true }}{{ end }}, "datetime"))
 {{ tabs .Depth }}}
 {{ end }}{{ if eq .Attribute.Type.Kind 6 }}{{/*
 
@@ -494,7 +808,7 @@ type {{ .Name }} struct {
 {{ if .Pointer }}{{ tabs .Depth }}	{{ $varName }} := &{{ .VarName }}
 {{ end }}{{ tabs .Depth }}	{{ .Pkg }} = {{ $varName }}
 {{ tabs .Depth }}} else {
-{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", raw{{ goify .Name true }}, "uuid"))
+{{ tabs .Depth }}	err = goa.MergeErrors(err, goa.InvalidParamTypeError("{{ .Name }}", {{ if .Attribute.Classification }}goa.Redacted({{ printf "%q" .Attribute.Classification }}){{ else }}raw{{ goify .Name true }}{{ end }}, "uuid"))
 {{ tabs .Depth }}}
 {{ end }}{{ if eq .Attribute.Type.Kind 7 }}{{/*
 
@@ -521,7 +835,10 @@ func New{{ .Name }}(ctx context.Context, service *goa.Service) (*{{ .Name }}, er
 		err = goa.MergeErrors(err, goa.MissingHeaderError("{{ $name }}"))
 	} else {
 {{ else }}	if len(header{{ goify $name true }}) > 0 {
-{{ end }}{{/* if $mustValidate */}}{{ if $att.Type.IsArray }}		req.Params["{{ $name }}"] = header{{ goify $name true }}
+{{ end }}{{/* if $mustValidate */}}{{ with $att.Transforms }}		for i, v := range header{{ goify $name true }} {
+			header{{ goify $name true }}[i] = goa.TransformParam(v{{ range . }}, {{ printf "%q" . }}{{ end }})
+		}
+{{ end }}{{ if $att.Type.IsArray }}		req.Params["{{ $name }}"] = header{{ goify $name true }}
 {{ if eq (arrayAttribute $att).Type.Kind 4 }}		headers := header{{ goify $name true }}
 {{ else }}		headers := make({{ gotypedef $att 2 true false }}, len(header{{ goify $name true }}))
 		for i, raw{{ goify $name true}} := range header{{ goify $name true}} {
@@ -533,15 +850,26 @@ func New{{ .Name }}(ctx context.Context, service *goa.Service) (*{{ .Name }}, er
 {{ template "Coerce" (newCoerceData $name $att ($.Headers.IsPrimitivePointer $name) (printf "rctx.%s" (goifyatt $att $name true)) 2) }}{{ end }}{{/*
 */}}{{ $validation := validationChecker $att ($.Headers.IsNonZero $name) ($.Headers.IsRequired $name) ($.Headers.HasDefaultValue $name) (printf "rctx.%s" (goifyatt $att $name true)) $name 2 false }}{{/*
 */}}{{ if $validation }}{{ $validation }}
+{{ end }}{{ $warning := warningChecker $att ($.Headers.IsNonZero $name) ($.Headers.IsRequired $name) ($.Headers.HasDefaultValue $name) (printf "rctx.%s" (goifyatt $att $name true)) $name 3 false }}{{/*
+*/}}{{ if $warning }}		goa.ReportValidationWarning(ctx, func() (err error) {
+{{ $warning }}
+			return
+		}())
 {{ end }}	}
-{{ end }}{{ end }}{{/* if .Headers }}{{/*
+{{ if $.FailFast }}	if err != nil {
+		return &rctx, err
+	}
+{{ end }}{{ end }}{{ end }}{{/* if .Headers }}{{/*
 
 */}}{{ if.Params }}{{ range $name, $att := .Params.Type.ToObject }}	param{{ goify $name true }} := req.Params["{{ $name }}"]
 {{ $mustValidate := $.MustValidate $name }}{{ if $mustValidate }}	if len(param{{ goify $name true }}) == 0 {
 		err = goa.MergeErrors(err, goa.MissingParamError("{{ $name }}"))
 	} else {
 {{ else }}	if len(param{{ goify $name true }}) > 0 {
-{{ end }}{{/* if $mustValidate */}}{{ if $att.Type.IsArray }}{{ if eq (arrayAttribute $att).Type.Kind 4 }}		params := param{{ goify $name true }}
+{{ end }}{{/* if $mustValidate */}}{{ with $att.Transforms }}		for i, v := range param{{ goify $name true }} {
+			param{{ goify $name true }}[i] = goa.TransformParam(v{{ range . }}, {{ printf "%q" . }}{{ end }})
+		}
+{{ end }}{{ if $att.Type.IsArray }}{{ if eq (arrayAttribute $att).Type.Kind 4 }}		params := param{{ goify $name true }}
 {{ else }}		params := make({{ gotypedef $att 2 true false }}, len(param{{ goify $name true }}))
 		for i, raw{{ goify $name true}} := range param{{ goify $name true}} {
 {{ template "Coerce" (newCoerceData $name (arrayAttribute $att) ($.Params.IsPrimitivePointer $name) "params[i]" 3) }}{{/*
@@ -551,8 +879,16 @@ func New{{ .Name }}(ctx context.Context, service *goa.Service) (*{{ .Name }}, er
 {{ template "Coerce" (newCoerceData $name $att ($.Params.IsPrimitivePointer $name) (printf "rctx.%s" (goifyatt $att $name true)) 2) }}{{ end }}{{/*
 */}}{{ $validation := validationChecker $att ($.Params.IsNonZero $name) ($.Params.IsRequired $name) ($.Params.HasDefaultValue $name) (printf "rctx.%s" (goifyatt $att $name true)) $name 2 false }}{{/*
 */}}{{ if $validation }}{{ $validation }}
+{{ end }}{{ $warning := warningChecker $att ($.Params.IsNonZero $name) ($.Params.IsRequired $name) ($.Params.HasDefaultValue $name) (printf "rctx.%s" (goifyatt $att $name true)) $name 3 false }}{{/*
+*/}}{{ if $warning }}		goa.ReportValidationWarning(ctx, func() (err error) {
+{{ $warning }}
+			return
+		}())
 {{ end }}	}
-{{ end }}{{ end }}{{/* if .Params */}}	return &rctx, err
+{{ if $.FailFast }}	if err != nil {
+		return &rctx, err
+	}
+{{ end }}{{ end }}{{ end }}{{/* if .Params */}}	return &rctx, err
 }
 `
 
@@ -560,7 +896,17 @@ func New{{ .Name }}(ctx context.Context, service *goa.Service) (*{{ .Name }}, er
 	// template input: map[string]interface{}
 	ctxMTRespT = `// {{ goify .RespName true }} sends a HTTP response with status code {{ .Response.Status }}.
 func (ctx *{{ .Context.Name }}) {{ goify .RespName true }}(r {{ gotyperef .Projected .Projected.AllRequired 0 false }}) error {
-	ctx.ResponseData.Header().Set("Content-Type", "{{ .ContentType }}")
+{{ if and .Context.Pagination (eq .Response.Status 200) }}	ctx.Pagination().SetHeaders(ctx.ResponseData.Header(), ctx.RequestData.URL)
+{{ end }}{{ with .Response.CacheControl }}	ctx.ResponseData.Header().Set("Cache-Control", {{ printf "%q" .HeaderValue }})
+{{ end }}{{ range .Response.Vary }}	ctx.ResponseData.Header().Add("Vary", {{ printf "%q" . }})
+{{ end }}{{ with .Response.SurrogateKeys }}	goa.AddSurrogateKeys(ctx.ResponseData.Header(){{ range . }}, {{ printf "%q" . }}{{ end }})
+{{ end }}{{ if and (or .Projected.ETagAttributes .Projected.LastModifiedAttribute) (eq .Response.Status 200) }}{{/*
+*/}}	if goa.CheckNotModified(ctx.Context, {{ if .Projected.ETagAttributes }}r.ETag(){{ else }}""{{ end }}, {{/*
+*/}}{{ if .Projected.LastModifiedAttribute }}r.LastModified(){{ else }}time.Time{}{{ end }}) {
+		ctx.ResponseData.WriteHeader(304)
+		return nil
+	}
+{{ end }}	ctx.ResponseData.Header().Set("Content-Type", "{{ .ContentType }}")
 	return ctx.ResponseData.Service.Send(ctx.Context, {{ .Response.Status }}, r)
 }
 `
@@ -569,9 +915,51 @@ func (ctx *{{ .Context.Name }}) {{ goify .RespName true }}(r {{ gotyperef .Proje
 	// template input: map[string]interface{}
 	ctxTRespT = `// {{ goify .Response.Name true }} sends a HTTP response with status code {{ .Response.Status }}.
 func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}(r {{ gotyperef .Type nil 0 false }}) error {
-	ctx.ResponseData.Header().Set("Content-Type", "{{ .ContentType }}")
+{{ if and .Context.Pagination (eq .Response.Status 200) }}	ctx.Pagination().SetHeaders(ctx.ResponseData.Header(), ctx.RequestData.URL)
+{{ end }}{{ with .Response.CacheControl }}	ctx.ResponseData.Header().Set("Cache-Control", {{ printf "%q" .HeaderValue }})
+{{ end }}{{ range .Response.Vary }}	ctx.ResponseData.Header().Add("Vary", {{ printf "%q" . }})
+{{ end }}{{ with .Response.SurrogateKeys }}	goa.AddSurrogateKeys(ctx.ResponseData.Header(){{ range . }}, {{ printf "%q" . }}{{ end }})
+{{ end }}	ctx.ResponseData.Header().Set("Content-Type", "{{ .ContentType }}")
 	return ctx.ResponseData.Service.Send(ctx.Context, {{ .Response.Status }}, r)
 }
+`
+
+	// ctxStreamRespT generates the response helpers for streamed responses.
+	// template input: map[string]interface{}
+	ctxStreamRespT = `
+{{ if .Iterator }}// {{ .RespName }}Iterator streams a HTTP response with status code {{ .Response.Status }}, each item
+// returned by it is written as a line of JSON. The items must be of type {{ gotyperef .Item nil 0 false }}.
+// It returns once it returns io.EOF or the request is canceled.
+func (ctx *{{ .Context.Name }}) {{ .RespName }}Iterator(it goa.ItemIterator) error {
+{{ else if .Item }}// {{ .RespName }} streams a HTTP response with status code {{ .Response.Status }}, each item received on
+// items is written as a line of JSON. It returns once items is closed or the request is canceled.
+func (ctx *{{ .Context.Name }}) {{ .RespName }}(items <-chan {{ gotyperef .Item nil 0 false }}) error {
+{{ else }}// {{ .RespName }} streams a HTTP response with status code {{ .Response.Status }}, the content of r is
+// copied to the client.
+func (ctx *{{ .Context.Name }}) {{ .RespName }}(r io.Reader) error {
+{{ end }}{{ if and .Context.Pagination (eq .Response.Status 200) }}	ctx.Pagination().SetHeaders(ctx.ResponseData.Header(), ctx.RequestData.URL)
+{{ end }}{{ with .Response.CacheControl }}	ctx.ResponseData.Header().Set("Cache-Control", {{ printf "%q" .HeaderValue }})
+{{ end }}{{ range .Response.Vary }}	ctx.ResponseData.Header().Add("Vary", {{ printf "%q" . }})
+{{ end }}{{ with .Response.SurrogateKeys }}	goa.AddSurrogateKeys(ctx.ResponseData.Header(){{ range . }}, {{ printf "%q" . }}{{ end }})
+{{ end }}	ctx.ResponseData.Header().Set("Content-Type", "{{ .ContentType }}")
+	ctx.ResponseData.WriteHeader({{ .Response.Status }})
+{{ if .Iterator }}	return goa.StreamNDJSON(ctx, ctx.ResponseData, it)
+{{ else if .Item }}	enc := goa.NewNDJSONEncoder(ctx.ResponseData)
+	for {
+		select {
+		case item, ok := <-items:
+			if !ok {
+				return nil
+			}
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+{{ else }}	return goa.CopyStream(ctx.ResponseData, r)
+{{ end }}}
 `
 
 	// ctxNoMTRespT generates the response helpers for responses with no known media type.
@@ -579,7 +967,11 @@ func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}(r {{ gotyperef .
 	ctxNoMTRespT = `
 // {{ goify .Response.Name true }} sends a HTTP response with status code {{ .Response.Status }}.
 func (ctx *{{ .Context.Name }}) {{ goify .Response.Name true }}({{ if .Response.MediaType }}resp []byte{{ end }}) error {
-{{ if .Response.MediaType }}	ctx.ResponseData.Header().Set("Content-Type", "{{ .Response.MediaType }}")
+{{ if and .Context.Pagination (eq .Response.Status 200) }}	ctx.Pagination().SetHeaders(ctx.ResponseData.Header(), ctx.RequestData.URL)
+{{ end }}{{ with .Response.CacheControl }}	ctx.ResponseData.Header().Set("Cache-Control", {{ printf "%q" .HeaderValue }})
+{{ end }}{{ range .Response.Vary }}	ctx.ResponseData.Header().Add("Vary", {{ printf "%q" . }})
+{{ end }}{{ with .Response.SurrogateKeys }}	goa.AddSurrogateKeys(ctx.ResponseData.Header(){{ range . }}, {{ printf "%q" . }}{{ end }})
+{{ end }}{{ if .Response.MediaType }}	ctx.ResponseData.Header().Set("Content-Type", "{{ .Response.MediaType }}")
 {{ end }}	ctx.ResponseData.WriteHeader({{ .Response.Status }}){{ if .Response.MediaType }}
 	_, err := ctx.ResponseData.Write(resp)
 	return err{{ else }}
@@ -603,7 +995,14 @@ func (payload {{ gotyperef .Payload .Payload.AllRequired 0 true }}) Validate() (
 {{ $validation }}
 	return
 }{{ end }}
-{{ $typeName := gotypename .Payload .Payload.AllRequired 1 false }}
+{{ $warning := recursiveWarnings .Payload.AttributeDefinition false false false "payload" "raw" 1 true }}{{ if $warning }}
+// ValidateWarnings runs the validation rules defined in the design that produce warnings instead
+// of errors.
+func (payload {{ gotyperef .Payload .Payload.AllRequired 0 true }}) ValidateWarnings() (err error) {
+{{ $warning }}
+	return
+}
+{{ end }}{{ $typeName := gotypename .Payload .Payload.AllRequired 1 false }}
 // Publicize creates {{ $typeName }} from {{ $privateTypeName }}
 func (payload {{ gotyperef .Payload .Payload.AllRequired 0 true }}) Publicize() {{ gotyperef .Payload .Payload.AllRequired 0 false }} {
 	var pub {{ $typeName }}
@@ -619,14 +1018,26 @@ func (payload {{ gotyperef .Payload .Payload.AllRequired 0 false }}) Validate()
 {{ $validation }}
 	return
 }{{ end }}
-`
+{{ $warning := recursiveWarnings .Payload.AttributeDefinition false false false "payload" "raw" 1 false }}{{ if $warning }}
+// ValidateWarnings runs the validation rules defined in the design that produce warnings instead
+// of errors.
+func (payload {{ gotyperef .Payload .Payload.AllRequired 0 false }}) ValidateWarnings() (err error) {
+{{ $warning }}
+	return
+}
+{{ end }}{{ $known := knownEnumMethods .Payload.AttributeDefinition (gotyperef .Payload .Payload.AllRequired 0 false) "payload" }}{{ if $known }}
+{{ $known }}
+{{ end }}`
 	// ctrlT generates the controller interface for a given resource.
 	// template input: *ControllerTemplateData
 	ctrlT = `// {{ .Resource }}Controller is the controller interface for the {{ .Resource }} actions.
 type {{ .Resource }}Controller interface {
 	goa.Muxer
 {{ if .FileServers }}	goa.FileServer
-{{ end }}{{ range .Actions }}	{{ .Name }}(*{{ .Context }}) error
+{{ end }}{{ if .Classified }}	goa.Classifier
+{{ end }}{{ if .BodyLimited }}	goa.BodyLimiter
+{{ end }}{{ if not .Proxy }}{{ range .Actions }}	{{ .Name }}(*{{ .Context }}) error
+{{ end }}{{ end }}{{ range .Uploads }}	{{ goify .Name true }}{{ if .Presigned }}BlobStore() goa.BlobStore{{ else }}UploadStore() goa.UploadStore{{ end }}
 {{ end }}}
 `
 
@@ -648,7 +1059,29 @@ func initService(service *goa.Service) {
 {{ end }}{{ end }}{{ range .Decoders }}{{ if .Default }}{{/*
 */}}	service.Decoder.Register({{ .PackageName }}.{{ .Function }}, "*/*")
 {{ end }}{{ end }}}
-`
+{{ if .RateLimited }}
+// RateLimitStore is the store used by the rate limiting middleware of the actions that define a
+// rate limit in the design. It defaults to an in-memory store, set it before mounting the
+// controllers to share the limits between the service instances, see
+// middleware.NewRedisRateLimitStore.
+var RateLimitStore middleware.RateLimitStore = middleware.NewMemoryRateLimitStore()
+{{ end }}{{ if .Challenged }}
+// ChallengeVerifier verifies the challenge tokens of the requests made to the actions that
+// require one in the design. Set it before mounting the controllers, the requests fail until it
+// is set, see middleware.NewTurnstileVerifier.
+var ChallengeVerifier middleware.ChallengeVerifier
+{{ end }}{{ range .OriginFuncs }}
+// {{ . }} validates the origins of the CORS requests matching the policies that use it in the
+// design. The requests from these origins are rejected until it is set.
+var {{ . }} cors.OriginFunc
+{{ end }}{{ if .Versioned }}
+// serviceMux returns the mux the controllers are mounted on. Requests are dispatched to the
+// controllers of version {{ printf "%q" .API.Version }} of the API when they request it{{ if .API.VersionHeader }} with the
+// {{ .API.VersionHeader }} header{{ end }}{{ if and .API.VersionHeader .API.VersionMediaType }} or{{ end }}{{ if .API.VersionMediaType }} with the Accept header{{ end }}.
+func serviceMux(service *goa.Service) goa.ServeMux {
+	return service.VersionMux({{ printf "%q" .API.Version }}, goa.VersionScheme{ {{- if .API.VersionHeader }}Header: {{ printf "%q" .API.VersionHeader }}{{ end }}{{ if and .API.VersionHeader .API.VersionMediaType }}, {{ end }}{{ if .API.VersionMediaType }}MediaType: {{ printf "%q" .API.VersionMediaType }}{{ end }}})
+}
+{{ end }}`
 
 	// mountT generates the code for a resource "Mount" function.
 	// template input: *ControllerTemplateData
@@ -657,42 +1090,141 @@ func initService(service *goa.Service) {
 func Mount{{ .Resource }}Controller(service *goa.Service, ctrl {{ .Resource }}Controller) {
 	initService(service)
 	var h goa.Handler
-{{ $res := .Resource }}{{ if .Origins }}{{ range .PreflightPaths }}{{/*
-*/}}	service.Mux.Handle("OPTIONS", "{{ . }}", ctrl.MuxHandler("preflight", handle{{ $res }}Origin(cors.HandlePreflight()), nil))
-{{ end }}{{ end }}{{ range .Actions }}{{ $action := . }}
-	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
-		// Check if there was an error loading the request
+{{ with .Proxy }}	proxy, err := goa.NewProxy({{ printf "%q" .URL }}, goa.ProxyOptions{
+{{ if .Timeout }}		Timeout: {{ printf "%d" .Timeout }}, // {{ .Timeout }}
+{{ end }}{{ if .SetHeaders }}		SetHeaders: map[string]string{
+{{ range $k, $v := .SetHeaders }}			{{ printf "%q" $k }}: {{ printf "%q" $v }},
+{{ end }}		},
+{{ end }}{{ if .RemoveHeaders }}		RemoveHeaders: []string{ {{- range $i, $h := .RemoveHeaders }}{{ if $i }}, {{ end }}{{ printf "%q" $h }}{{ end -}} },
+{{ end }}	})
+	if err != nil {
+		panic(err) // bug: the upstream URL is validated by the design
+	}
+{{ end }}{{ $res := .Resource }}{{ $proxy := .Proxy }}{{ if .Origins }}{{ range .PreflightPaths }}{{/*
+*/}}	{{ $.Mux }}.Handle("OPTIONS", "{{ . }}", ctrl.MuxHandler("preflight", handle{{ $res }}Origin(cors.HandlePreflight()), nil))
+{{ end }}{{ end }}{{ range .ActionPreflights }}	{{ $.Mux }}.Handle("OPTIONS", {{ printf "%q" .Path }}, ctrl.MuxHandler("preflight", cors.HandlePreflightByMethod({{ if and .Default $.Origins }}handle{{ $res }}Origin(cors.HandlePreflight()){{ else }}cors.HandlePreflight(){{ end }}, map[string]goa.Handler{
+{{ range $verb, $name := .Handlers }}		{{ printf "%q" $verb }}: handle{{ $res }}{{ $name }}Origin(cors.HandlePreflight()),
+{{ end }}	}), nil))
+{{ end }}{{ range .Actions }}{{ $action := . }}{{ if $proxy }}
+{{ if .ProxyResponse }}	h = proxy.Handler(func(status int, header http.Header, body []byte) error {
+		if status != 200 {
+			return nil
+		}
+		var res {{ gotyperef .ProxyResponse .ProxyResponse.AllRequired 1 false }}
+		if err := service.Decoder.Decode(&res, bytes.NewReader(body), header.Get("Content-Type")); err != nil {
+			return err
+		}
+		if v, ok := interface{}(res).(interface {
+			Validate() error
+		}); ok {
+			return v.Validate()
+		}
+		return nil
+	})
+{{ else }}	h = proxy.Handler(nil)
+{{ end }}{{ else }}
+{{ with .Sandbox }}	sandbox{{ $action.Name }} := []*goa.SandboxResponse{
+{{ range . }}		{Name: {{ printf "%q" .Name }}, Status: {{ .Status }}{{ if .ContentType }}, ContentType: {{ printf "%q" .ContentType }}{{ end }}{{ if .Views }}, Views: map[string]string{
+{{ range sandboxViews .Views }}			{{ .Key }} {{ .Value }},
+{{ end }}		}{{ end }}},
+{{ end }}	}
+{{ end }}	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+{{ if .Localizable }}		// Responses depend on the requested locales
+		rw.Header().Add("Vary", "Accept-Language")
+{{ end }}{{ if or .FailFast (not .Payload) }}		// Check if there was an error loading the request
 		if err := goa.ContextError(ctx); err != nil {
 			return err
 		}
 		// Build the context
+		endValidate := goa.StartPhase(ctx, goa.PhaseValidate)
 		rctx, err := New{{ .Context }}(ctx, service)
+		endValidate()
 		if err != nil {
 			return err
 		}
-{{ if .Payload }}		// Build the payload
+{{ else }}		// Payloads that cannot be loaded at all (e.g. too large or of an unsupported media
+		// type) are reported as is, bad request errors are reported together with the
+		// parameter validation errors
+		loadErr := goa.ContextError(ctx)
+		if serr, ok := loadErr.(goa.ServiceError); ok && serr.ResponseStatus() != 400 {
+			return loadErr
+		}
+		// Build the context
+		endValidate := goa.StartPhase(ctx, goa.PhaseValidate)
+		rctx, err := New{{ .Context }}(ctx, service)
+		endValidate()
+		if err = goa.MergeErrors(loadErr, err); err != nil {
+			return err
+		}
+{{ end }}{{ if .Payload }}		// Load the payload of requests that expect a 100 Continue response now that the
+		// request has been authorized and its headers validated
+		if err := goa.LoadDeferredPayload(ctx); err != nil {
+			return err
+		}
+		// Build the payload
 		if rawPayload := goa.ContextRequest(ctx).Payload; rawPayload != nil {
 			rctx.Payload = rawPayload.({{ gotyperef .Payload nil 1 false }})
 {{ if not .PayloadOptional }}		} else {
 			return goa.MissingPayloadError()
 {{ end }}		}
-{{ end }}		return ctrl.{{ .Name }}(rctx)
+{{ end }}{{ if .Sandbox }}		if service.Sandbox {
+			return service.SendSandboxResponse(ctx, sandbox{{ .Name }})
+		}
+{{ end }}		defer goa.StartPhase(ctx, goa.PhaseHandler)()
+		return ctrl.{{ .Name }}(rctx)
 	}
-{{ if $.Origins }}	h = handle{{ $res }}Origin(h)
+{{ end }}{{ range .Fallbacks }}	h = service.Fallback({{ printf "%q" .Downstream }}, {{ .Status }}, {{ if .HasBody }}{{ printf "%#v" .Body }}{{ else }}nil{{ end }})(h)
+{{ end }}{{ with .Affinity }}	h = middleware.Affinity({{ . }})(h)
+{{ end }}{{ if .Origins }}	h = handle{{ $res }}{{ .Name }}Origin(h)
+{{ else if $.Origins }}	h = handle{{ $res }}Origin(h)
+{{ end }}{{ if .Middleware }}	h = service.NamedMiddleware({{ range $i, $m := .Middleware }}{{ if $i }}, {{ end }}{{ printf "%q" $m }}{{ end }})(h)
+{{ end }}{{ if .ScrubHeaders }}	h = middleware.ScrubHeaders({{ range $i, $n := .AllowedHeaders }}{{ if $i }}, {{ end }}{{ printf "%q" $n }}{{ end }})(h)
 {{ end }}{{ if .Security }}	h = handleSecurity({{ printf "%q" .Security.Scheme.SchemeName }}, h{{ range .Security.Scopes }}, {{ printf "%q" . }}{{ end }})
-{{ end }}{{ range .Routes }}	service.Mux.Handle("{{ .Verb }}", {{ printf "%q" .FullPath }}, ctrl.MuxHandler({{ printf "%q" $action.Name }}, h, {{ if $action.Payload }}{{ $action.Unmarshal }}{{ else }}nil{{ end }}))
+{{ end }}{{ with .ClientCert }}	h = middleware.RequireClientCert({{ range $i, $s := .Subjects }}{{ if $i }}, {{ end }}{{ printf "%q" $s }}{{ end }})(h)
+{{ end }}{{ with .Challenge }}	h = middleware.RequireChallenge(ChallengeVerifier, {{ printf "%q" .Header }})(h)
+{{ end }}{{ with .RateLimit }}	h = middleware.RateLimiter(RateLimitStore, middleware.RateLimit{Requests: {{ .Requests }}, Interval: {{ printf "%d" .Interval }}{{ if .Burst }}, Burst: {{ .Burst }}{{ end }}}, {{ .Key }})(h) // {{ .Requests }} requests per {{ .Interval }}
+{{ end }}{{ with .SecurityHeaders }}	h = middleware.SecurityHeaders({{ printf "%q" .Profile }}, {{ if .Headers }}map[string]string{
+{{ range $n, $v := .Headers }}		{{ printf "%q" $n }}: {{ printf "%q" $v }},
+{{ end }}	}{{ else }}nil{{ end }})(h)
+{{ end }}{{ with .Classifications }}	ctrl.Classify({{ printf "%q" $action.Name }}, map[string]string{
+{{ range $n, $c := . }}		{{ printf "%q" $n }}: {{ printf "%q" $c }},
+{{ end }}	})
+{{ end }}{{ with .BodyLimit }}	ctrl.SetBodyLimit({{ printf "%q" $action.Name }}, {{ . }})
+{{ end }}{{ with .Incompressible }}	service.SetIncompressible({{ range $i, $m := . }}{{ if $i }}, {{ end }}{{ printf "%q" $m }}{{ end }})
+{{ end }}{{ range .Routes }}	{{ $.Mux }}.Handle("{{ .Verb }}", {{ printf "%q" .FullPath }}, ctrl.MuxHandler({{ printf "%q" $action.Name }}, h, {{ if and $action.Payload (not $proxy) }}{{ $action.Unmarshal }}{{ else }}nil{{ end }}))
 	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "action", {{ printf "%q" $action.Name }}, "route", {{ printf "%q" (printf "%s %s" .Verb .FullPath) }}{{ with $action.Security }}, "security", {{ printf "%q" .Scheme.SchemeName }}{{ end }})
+	service.AddRoute(goa.RouteInfo{Method: "{{ .Verb }}", Pattern: {{ printf "%q" .FullPath }}, Resource: {{ printf "%q" $res }}, Action: {{ printf "%q" $action.Name }}{{ with $action.Security }}, Security: {{ printf "%q" .Scheme.SchemeName }}{{ end }}})
 {{ end }}{{ end }}{{ range .FileServers }}
 	h = ctrl.FileHandler({{ printf "%q" .RequestPath }}, {{ printf "%q" .FilePath }})
 {{ if $.Origins }}	h = handle{{ $res }}Origin(h)
 {{ end }}{{ if .Security }}	h = handleSecurity({{ printf "%q" .Security.Scheme.SchemeName }}, h{{ range .Security.Scopes }}, {{ printf "%q" . }}{{ end }})
-{{ end }}	service.Mux.Handle("GET", "{{ .RequestPath }}", ctrl.MuxHandler("serve", h, nil))
+{{ end }}	{{ $.Mux }}.Handle("GET", "{{ .RequestPath }}", ctrl.MuxHandler("serve", h, nil))
 	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "files", {{ printf "%q" .FilePath }}, "route", {{ printf "%q" (printf "GET %s" .RequestPath) }}{{ with .Security }}, "security", {{ printf "%q" .Scheme.SchemeName }}{{ end }})
-{{ end }}}
+	service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: {{ printf "%q" .RequestPath }}, Resource: {{ printf "%q" $res }}, Action: "serve"{{ with .Security }}, Security: {{ printf "%q" .Scheme.SchemeName }}{{ end }}})
+{{ end }}{{ range .Uploads }}
+{{ if .Presigned }}	h = goa.NewDirectUploader(service, ctrl.{{ goify .Name true }}BlobStore(), goa.DirectUploadOptions{MaxSize: {{ .MaxSize }}{{ if .Expiry }}, Expiry: {{ printf "%d" .Expiry }}{{ end }}}).Handler(){{ if .Expiry }} // URL expiry: {{ .Expiry }}{{ end }}
+{{ else }}	h = goa.NewUploader(ctrl.{{ goify .Name true }}UploadStore(), goa.UploadOptions{MaxSize: {{ .MaxSize }}}).Handler()
+{{ end }}{{ if $.Origins }}	h = handle{{ $res }}Origin(h)
+{{ end }}{{ if .Security }}	h = handleSecurity({{ printf "%q" .Security.Scheme.SchemeName }}, h{{ range .Security.Scopes }}, {{ printf "%q" . }}{{ end }})
+{{ end }}	{{ $.Mux }}.Handle("POST", {{ printf "%q" .RequestPath }}, ctrl.MuxHandler("upload", h, nil))
+{{ if .Presigned }}	{{ $.Mux }}.Handle("POST", {{ printf "%q" (printf "%s/:uploadID/url" .RequestPath) }}, ctrl.MuxHandler("upload", h, nil))
+	{{ $.Mux }}.Handle("POST", {{ printf "%q" (printf "%s/:uploadID/confirm" .RequestPath) }}, ctrl.MuxHandler("upload", h, nil))
+{{ else }}	{{ $.Mux }}.Handle("HEAD", {{ printf "%q" (printf "%s/:uploadID" .RequestPath) }}, ctrl.MuxHandler("upload", h, nil))
+	{{ $.Mux }}.Handle("PATCH", {{ printf "%q" (printf "%s/:uploadID" .RequestPath) }}, ctrl.MuxHandler("upload", h, nil))
+{{ if not $.Origins }}	{{ $.Mux }}.Handle("OPTIONS", {{ printf "%q" .RequestPath }}, ctrl.MuxHandler("upload", h, nil))
+{{ end }}{{ end }}	service.LogInfo("mount", "ctrl", {{ printf "%q" $res }}, "upload", {{ printf "%q" .Name }}, "route", {{ printf "%q" .RequestPath }}{{ with .Security }}, "security", {{ printf "%q" .Scheme.SchemeName }}{{ end }})
+	service.AddRoute(goa.RouteInfo{Method: "POST", Pattern: {{ printf "%q" .RequestPath }}, Resource: {{ printf "%q" $res }}, Action: "upload"{{ with .Security }}, Security: {{ printf "%q" .Scheme.SchemeName }}{{ end }}})
+{{ if .Presigned }}	service.AddRoute(goa.RouteInfo{Method: "POST", Pattern: {{ printf "%q" (printf "%s/:uploadID/url" .RequestPath) }}, Resource: {{ printf "%q" $res }}, Action: "upload"{{ with .Security }}, Security: {{ printf "%q" .Scheme.SchemeName }}{{ end }}})
+	service.AddRoute(goa.RouteInfo{Method: "POST", Pattern: {{ printf "%q" (printf "%s/:uploadID/confirm" .RequestPath) }}, Resource: {{ printf "%q" $res }}, Action: "upload"{{ with .Security }}, Security: {{ printf "%q" .Scheme.SchemeName }}{{ end }}})
+{{ else }}	service.AddRoute(goa.RouteInfo{Method: "HEAD", Pattern: {{ printf "%q" (printf "%s/:uploadID" .RequestPath) }}, Resource: {{ printf "%q" $res }}, Action: "upload"{{ with .Security }}, Security: {{ printf "%q" .Scheme.SchemeName }}{{ end }}})
+	service.AddRoute(goa.RouteInfo{Method: "PATCH", Pattern: {{ printf "%q" (printf "%s/:uploadID" .RequestPath) }}, Resource: {{ printf "%q" $res }}, Action: "upload"{{ with .Security }}, Security: {{ printf "%q" .Scheme.SchemeName }}{{ end }}})
+{{ if not $.Origins }}	service.AddRoute(goa.RouteInfo{Method: "OPTIONS", Pattern: {{ printf "%q" .RequestPath }}, Resource: {{ printf "%q" $res }}, Action: "upload"{{ with .Security }}, Security: {{ printf "%q" .Scheme.SchemeName }}{{ end }}})
+{{ end }}{{ end }}{{ end }}}
 `
 
 	// handleCORST generates the code that checks whether a CORS request is authorized
-	// template input: *ControllerTemplateData
+	// template input: *ControllerTemplateData or map with keys "Resource" and "Origins" for the
+	// actions that override the resource policies
 	handleCORST = `// handle{{ .Resource }}Origin applies the CORS response headers corresponding to the origin.
 func handle{{ .Resource }}Origin(h goa.Handler) goa.Handler {
 {{ range $i, $policy := .Origins }}{{ if $policy.Regexp }}	spec{{$i}} := regexp.MustCompile({{ printf "%q" $policy.Origin }})
@@ -703,17 +1235,17 @@ func handle{{ .Resource }}Origin(h goa.Handler) goa.Handler {
 			// Not a CORS request
 			return h(ctx, rw, req)
 		}
-{{ range $i, $policy := .Origins }}		{{ if $policy.Regexp }}if cors.MatchOriginRegexp(origin, spec{{$i}}){{else}}if cors.MatchOrigin(origin, {{ printf "%q" $policy.Origin }}){{end}} {
+{{ range $i, $policy := .Origins }}		{{ if $policy.Regexp }}if cors.MatchOriginRegexp(origin, spec{{$i}}){{else}}if cors.MatchOrigin(origin, {{ printf "%q" $policy.Origin }}){{end}}{{ with $policy.Func }} && cors.CheckOrigin({{ . }}, origin, req){{ end }} {
 			ctx = goa.WithLogContext(ctx, "origin", origin)
 			rw.Header().Set("Access-Control-Allow-Origin", origin)
-{{ if not (eq $policy.Origin "*") }}			rw.Header().Set("Vary", "Origin")
+{{ if or (not (eq $policy.Origin "*")) $policy.Func }}			rw.Header().Set("Vary", "Origin")
 {{ end }}{{ if $policy.Exposed }}			rw.Header().Set("Access-Control-Expose-Headers", "{{ join $policy.Exposed ", " }}")
-{{ end }}{{ if gt $policy.MaxAge 0 }}			rw.Header().Set("Access-Control-Max-Age", "{{ $policy.MaxAge }}")
 {{ end }}			rw.Header().Set("Access-Control-Allow-Credentials", "{{ $policy.Credentials }}")
 			if acrm := req.Header.Get("Access-Control-Request-Method"); acrm != "" {
 				// We are handling a preflight request
 {{ if $policy.Methods }}				rw.Header().Set("Access-Control-Allow-Methods", "{{ join $policy.Methods ", " }}")
 {{ end }}{{ if $policy.Headers }}				rw.Header().Set("Access-Control-Allow-Headers", "{{ join $policy.Headers ", " }}")
+{{ end }}{{ if gt $policy.MaxAge 0 }}				rw.Header().Set("Access-Control-Max-Age", "{{ $policy.MaxAge }}")
 {{ end }}			}
 			return h(ctx, rw, req)
 		}
@@ -725,22 +1257,56 @@ func handle{{ .Resource }}Origin(h goa.Handler) goa.Handler {
 
 	// unmarshalT generates the code for an action payload unmarshal function.
 	// template input: *ControllerTemplateData
-	unmarshalT = `{{ range .Actions }}{{ if .Payload }}
+	unmarshalT = `{{ define "Coerce" }}` + coerceT + `{{ end }}` + `{{ range .Actions }}{{ if .Payload }}
 // {{ .Unmarshal }} unmarshals the request body into the context request data Payload field.
 func {{ .Unmarshal }}(ctx context.Context, service *goa.Service, req *http.Request) error {
-	{{ if .Payload.IsObject }}payload := &{{ gotypename .Payload nil 1 true }}{}
+{{ with .StrictContentType }}	if err := goa.RequireContentType(req{{ range . }}, {{ printf "%q" . }}{{ end }}); err != nil {
+		return err
+	}
+{{ end }}{{ if .PayloadMultipart }}	// Keep up to 32MB of the form in memory, larger file parts are stored in temporary files
+	if err := req.ParseMultipartForm(32 << 20); err != nil {
+		return err
+	}
+	var err error
+	payload := &{{ gotypename .Payload nil 1 true }}{}
+{{ range $name, $att := .Payload.ToObject }}{{ if eq $att.Type.Kind 13 }}{{/*
+
+*/}}	if files := req.MultipartForm.File["{{ $name }}"]; len(files) > 0 {
+		payload.{{ goifyatt $att $name true }} = files[0]
+	}
+{{ else if $att.Type.IsArray }}{{ if eq (arrayAttribute $att).Type.Kind 13 }}{{/*
+
+*/}}	if files := req.MultipartForm.File["{{ $name }}"]; len(files) > 0 {
+		payload.{{ goifyatt $att $name true }} = files
+	}
+{{ else }}	if field{{ goify $name true }} := req.MultipartForm.Value["{{ $name }}"]; len(field{{ goify $name true }}) > 0 {
+{{ if eq (arrayAttribute $att).Type.Kind 4 }}		payload.{{ goifyatt $att $name true }} = field{{ goify $name true }}
+{{ else }}		values := make({{ gotypedef $att 2 true false }}, len(field{{ goify $name true }}))
+		for i, raw{{ goify $name true }} := range field{{ goify $name true }} {
+{{ template "Coerce" (newCoerceData $name (arrayAttribute $att) false "values[i]" 3) }}{{/*
+*/}}		}
+		payload.{{ goifyatt $att $name true }} = values
+{{ end }}	}
+{{ end }}{{ else }}	if field{{ goify $name true }} := req.MultipartForm.Value["{{ $name }}"]; len(field{{ goify $name true }}) > 0 {
+		raw{{ goify $name true }} := field{{ goify $name true }}[0]
+{{ template "Coerce" (newCoerceData $name $att true (printf "payload.%s" (goifyatt $att $name true)) 2) }}{{/*
+*/}}	}
+{{ end }}{{ end }}	if err != nil {
+		return err
+	}{{ else }}	{{ if .Payload.IsObject }}payload := &{{ gotypename .Payload nil 1 true }}{}
 	if err := service.DecodeRequest(req, payload); err != nil {
 		return err
 	}{{ $assignment := recursiveFinalizer .Payload.AttributeDefinition "payload" 1 }}{{ if $assignment }}
 	payload.Finalize(){{ end }}{{ else }}var payload {{ gotypename .Payload nil 1 false }}
 	if err := service.DecodeRequest(req, &payload); err != nil {
 		return err
-	}{{ end }}{{ $validation := recursiveValidate .Payload.AttributeDefinition false false false "payload" "raw" 1 false }}{{ if $validation }}
+	}{{ end }}{{ end }}{{ $validation := recursiveValidate .Payload.AttributeDefinition false false false "payload" "raw" 1 false }}{{ if $validation }}
 	if err := payload.Validate(); err != nil {
 		// Initialize payload with private data structure so it can be logged
 		goa.ContextRequest(ctx).Payload = payload
 		return err
-	}{{ end }}
+	}{{ end }}{{ $warning := recursiveWarnings .Payload.AttributeDefinition false false false "payload" "raw" 1 false }}{{ if $warning }}
+	goa.ReportValidationWarning(ctx, payload.ValidateWarnings()){{ end }}
 	goa.ContextRequest(ctx).Payload = payload{{ if .Payload.IsObject }}.Publicize(){{ end }}
 	return nil
 }
@@ -753,6 +1319,11 @@ func {{ .Unmarshal }}(ctx context.Context, service *goa.Service, req *http.Reque
 func {{ .Name }}Href({{ if .CanonicalParams }}{{ join .CanonicalParams ", " }} interface{}{{ end }}) string {
 	return fmt.Sprintf("{{ .CanonicalTemplate }}", {{ join .CanonicalParams ", " }})
 }
+{{ end }}{{ $res := .Name }}{{ range .Mounts }}
+// {{ $res }}{{ .Name }}Href returns the resource href for the {{ .Name }} mount.
+func {{ $res }}{{ .Name }}Href({{ if .CanonicalParams }}{{ join .CanonicalParams ", " }} interface{}{{ end }}) string {
+	return fmt.Sprintf("{{ .CanonicalTemplate }}", {{ join .CanonicalParams ", " }})
+}
 {{ end }}`
 
 	// mediaTypeT generates the code for a media type.
@@ -767,6 +1338,31 @@ func (mt {{ gotyperef . .AllRequired 0 false }}) Validate() (err error) {
 {{ $validation }}
 	return
 }
+{{ end }}{{ $warning := recursiveWarnings .AttributeDefinition false false false "mt" "response" 1 false }}{{ if $warning }}// ValidateWarnings runs the validations of the {{$typeName}} media type instance that produce
+// warnings instead of errors.
+func (mt {{ gotyperef . .AllRequired 0 false }}) ValidateWarnings() (err error) {
+{{ $warning }}
+	return
+}
+{{ end }}{{ if .ETagAttributes }}{{ $obj := .Type.ToObject }}// ETag computes the entity tag of the {{$typeName}} media type instance from the values of its
+// {{ join .ETagAttributes ", " }} attributes. It returns an empty string if mt is nil.
+func (mt {{ gotyperef . .AllRequired 0 false }}) ETag() string {
+	if mt == nil {
+		return ""
+	}
+	return goa.Compute{{ if .WeakETag }}Weak{{ end }}ETag({{ range $i, $n := .ETagAttributes }}{{ if $i }}, {{ end }}mt.{{ goifyatt (index $obj $n) $n true }}{{ end }})
+}
+{{ end }}{{ if .LastModifiedAttribute }}{{ $name := .LastModifiedAttribute }}{{ $field := goifyatt (index .Type.ToObject $name) $name true }}{{/*
+*/}}{{ $ptr := .IsPrimitivePointer $name }}// LastModified returns the last modification time of the {{$typeName}} media type instance, the
+// value of its {{ $name }} attribute. It returns the zero time if mt is nil{{ if $ptr }} or the attribute is not set{{ end }}.
+func (mt {{ gotyperef . .AllRequired 0 false }}) LastModified() time.Time {
+	if mt == nil{{ if $ptr }} || mt.{{ $field }} == nil{{ end }} {
+		return time.Time{}
+	}
+	return {{ if $ptr }}*{{ end }}mt.{{ $field }}
+}
+{{ end }}{{ $known := knownEnumMethods .AttributeDefinition (gotyperef . .AllRequired 0 false) "mt" }}{{ if $known }}
+{{ $known }}
 {{ end }}
 `
 
@@ -778,6 +1374,12 @@ type {{ $typeName }} {{ gotypedef . 0 true false }}
 func (ut {{ gotyperef . .AllRequired 0 false }}) Validate() (err error) {
 {{ $validation }}
 	return
+}{{ end }}{{ $warning := recursiveWarnings .AttributeDefinition false false false "ut" "response" 1 false }}{{ if $warning }}
+// ValidateWarnings runs the validations of the {{$typeName}} type instance that produce warnings
+// instead of errors.
+func (ut {{ gotyperef . .AllRequired 0 false }}) ValidateWarnings() (err error) {
+{{ $warning }}
+	return
 }{{ end }}
 `
 
@@ -793,6 +1395,12 @@ func (ut {{ gotyperef . .AllRequired 0 true }}) Finalize() {
 func (ut {{ gotyperef . .AllRequired 0 true }}) Validate() (err error) {
 {{ $validation }}
 	return
+}{{ end }}{{ $warning := recursiveWarnings .AttributeDefinition false false false "ut" "response" 1 true }}{{ if $warning }}
+// ValidateWarnings runs the validations of the {{$privateTypeName}} type instance that produce warnings
+// instead of errors.
+func (ut {{ gotyperef . .AllRequired 0 true }}) ValidateWarnings() (err error) {
+{{ $warning }}
+	return
 }{{ end }}
 {{ $typeName := gotypename . .AllRequired 0 false }}
 // Publicize creates {{ $typeName }} from {{ $privateTypeName }}
@@ -808,9 +1416,60 @@ type {{ $typeName }} {{ gotypedef . 0 true false }}
 func (ut {{ gotyperef . .AllRequired 0 false }}) Validate() (err error) {
 {{ $validation }}
 	return
-}{{ end }}
+}{{ end }}{{ $warning := recursiveWarnings .AttributeDefinition false false false "ut" "response" 1 false }}{{ if $warning }}
+// ValidateWarnings runs the validations of the {{$typeName}} type instance that produce warnings
+// instead of errors.
+func (ut {{ gotyperef . .AllRequired 0 false }}) ValidateWarnings() (err error) {
+{{ $warning }}
+	return
+}{{ end }}{{ $known := knownEnumMethods .AttributeDefinition (gotyperef . .AllRequired 0 false) "ut" }}{{ if $known }}
+
+{{ $known }}{{ end }}
 `
 
+	// dependenciesT generates the typed accessors of the request-scoped dependencies.
+	// template input: []*design.DependencyDefinition
+	dependenciesT = `{{ range . }}{{ $name := goify .Name true }}
+// Set{{ $name }} stores the {{ .Name }} dependency in the request context container. It panics if
+// the context does not contain a container, see the goa middleware.Container middleware.
+func Set{{ $name }}(ctx context.Context, v {{ .GoType }}) {
+	c := goa.ContextContainer(ctx)
+	if c == nil {
+		panic("no dependency container in context, use the middleware.Container middleware")
+	}
+	c.Set({{ printf "%q" .Name }}, v)
+}
+
+// Get{{ $name }} retrieves the {{ .Name }} dependency from the request context container. ok is
+// false if the dependency was not set.
+func Get{{ $name }}(ctx context.Context) (v {{ .GoType }}, ok bool) {
+	c := goa.ContextContainer(ctx)
+	if c == nil {
+		return
+	}
+	var raw interface{}
+	if raw, ok = c.Get({{ printf "%q" .Name }}); ok {
+		v, ok = raw.({{ .GoType }})
+	}
+	return
+}
+{{ end }}`
+
+	// webhooksT generates the dispatch functions of the webhooks.
+	// template input: []*WebhookData
+	webhooksT = `{{ range . }}
+// {{ .Name }}Event is the name of the event sent by the {{ .ResourceName }} {{ .ActionName }} webhook.
+const {{ .Name }}Event = {{ printf "%q" .Event }}
+
+// Dispatch{{ .Name }} delivers the {{ .Event }} event declared by the {{ .ResourceName }} {{ .ActionName }}
+// action to url using d.{{ if .Description }}
+//
+{{ comment .Description }}{{ end }}
+func Dispatch{{ .Name }}(ctx context.Context, d *goa.WebhookDispatcher, url string, payload {{ .TypeRef }}) error {
+	return d.Deliver(ctx, url, {{ .Name }}Event, payload)
+}
+{{ end }}`
+
 	// securitySchemesT generates the code for the security module.
 	// template input: []*design.SecuritySchemeDefinition
 	securitySchemesT = `
@@ -843,7 +1502,8 @@ func {{ $funcName }}() *goa.{{ .Context }} {
 */}}{{ else if eq .Context "JWTSecurity" }}{{/*
 */}}		In:   {{ if eq .In "header" }}goa.LocHeader{{ else }}goa.LocQuery{{ end }},
 		Name:             {{ printf "%q" .Name }},
-		TokenURL:         {{ printf "%q" .TokenURL }},{{ with .Scopes }}
+		TokenURL:         {{ printf "%q" .TokenURL }},{{ with .ScopesClaim }}
+		ScopesClaim:      {{ printf "%q" . }},{{ end }}{{ with .Scopes }}
 		Scopes: map[string]string{
 {{ range $k, $v := . }}			{{ printf "%q" $k }}: {{ printf "%q" $v }},
 {{ end }}{{/*
@@ -853,7 +1513,13 @@ func {{ $funcName }}() *goa.{{ .Context }} {
 {{ if .Description }} def.Description = {{ printf "%q" .Description }}
 {{ end }}	return &def
 }
-
+{{ if eq .Context "APIKeySecurity" }}
+{{ $schemeName := goify .SchemeName true }}// New{{ $schemeName }}Middleware creates a {{ .SchemeName }} auth middleware that accepts the keys
+// returned by resolver. Resolve a primary and a secondary key to rotate keys without downtime.
+func New{{ $schemeName }}Middleware(resolver apikey.KeyResolver) goa.Middleware {
+	return apikey.NewWithResolver(resolver, New{{ $schemeName }}Security())
+}
+{{ end }}
 {{ end }}// handleSecurity creates a handler that runs the auth middleware for the security scheme.
 func handleSecurity(schemeName string, h goa.Handler, scopes ...string) goa.Handler {
 	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
@@ -866,5 +1532,29 @@ func handleSecurity(schemeName string, h goa.Handler, scopes ...string) goa.Hand
 		return am(h)(ctx, rw, req)
 	}
 }
+
+// VerifySecurity checks that an auth middleware is mounted for each security scheme defined in
+// the design. Call it once all the middlewares are mounted to detect missing ones at startup
+// rather than on the first request. unmounted lists the names of the schemes that are
+// intentionally left without middleware.
+func VerifySecurity(service *goa.Service, unmounted ...string) error {
+	skip := make(map[string]bool, len(unmounted))
+	for _, name := range unmounted {
+		skip[name] = true
+	}
+	var missing []string
+	for _, name := range []string{ {{- range $i, $s := . }}{{ if $i }}, {{ end }}{{ printf "%q" $s.SchemeName }}{{ end -}} } {
+		if skip[name] {
+			continue
+		}
+		if _, ok := service.Context.Value(authMiddlewareKey(name)).(goa.Middleware); !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return goa.MissingAuthMiddleware(missing...)
+	}
+	return nil
+}
 `
 )
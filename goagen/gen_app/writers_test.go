@@ -3,6 +3,7 @@ package genapp_test
 import (
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
@@ -50,6 +51,7 @@ var _ = Describe("ContextsWriter", func() {
 			var params, headers *design.AttributeDefinition
 			var payload *design.UserTypeDefinition
 			var responses map[string]*design.ResponseDefinition
+			var failFast bool
 
 			var data *genapp.ContextTemplateData
 
@@ -58,6 +60,7 @@ var _ = Describe("ContextsWriter", func() {
 				headers = nil
 				payload = nil
 				responses = nil
+				failFast = false
 				data = nil
 			})
 
@@ -72,6 +75,7 @@ var _ = Describe("ContextsWriter", func() {
 					Responses:    responses,
 					API:          design.Design,
 					DefaultPkg:   "",
+					FailFast:     failFast,
 				}
 			})
 
@@ -88,6 +92,48 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with streaming enabled", func() {
+				It("writes the Stream method", func() {
+					data.Streams = true
+					data.StreamWriteTimeout = 10 * time.Second
+					data.StreamBuffer = 1024
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(streamContext))
+				})
+			})
+
+			Context("with pagination", func() {
+				BeforeEach(func() {
+					design.Design = new(design.APIDefinition)
+					params = &design.AttributeDefinition{
+						Type: design.Object{
+							"page":     {Type: design.Integer, DefaultValue: 1},
+							"per_page": {Type: design.Integer, DefaultValue: 20},
+						},
+					}
+					responses = map[string]*design.ResponseDefinition{"OK": {
+						Name:   "OK",
+						Status: 200,
+					}}
+				})
+
+				It("writes the Pagination method and the Link headers", func() {
+					data.Pagination = &design.PaginationDefinition{DefaultPerPage: 20}
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("	pagination *goa.Pagination\n"))
+					Ω(written).Should(ContainSubstring(paginationContext))
+					Ω(written).Should(ContainSubstring("	ctx.Pagination().SetHeaders(ctx.ResponseData.Header(), ctx.RequestData.URL)\n"))
+				})
+			})
+
 			Context("with a media type setting a ContentType", func() {
 				var contentType = "application/json"
 
@@ -130,6 +176,180 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with a streamed response", func() {
+				BeforeEach(func() {
+					mediaType := &design.MediaTypeDefinition{
+						UserTypeDefinition: &design.UserTypeDefinition{
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{"foo": {Type: design.String}},
+							},
+							TypeName: "Bottle",
+						},
+						Identifier: "application/vnd.goa.test",
+					}
+					defView := &design.ViewDefinition{
+						AttributeDefinition: mediaType.AttributeDefinition,
+						Name:                "default",
+						Parent:              mediaType,
+					}
+					mediaType.Views = map[string]*design.ViewDefinition{"default": defView}
+					design.Design = new(design.APIDefinition)
+					design.Design.MediaTypes = map[string]*design.MediaTypeDefinition{
+						design.CanonicalIdentifier(mediaType.Identifier): mediaType,
+					}
+					design.ProjectedMediaTypes = make(map[string]*design.MediaTypeDefinition)
+					responses = map[string]*design.ResponseDefinition{"OK": {
+						Name:      "OK",
+						Status:    200,
+						MediaType: mediaType.Identifier,
+						Stream:    "bytes",
+					}}
+				})
+
+				It("writes a response method that copies a reader", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(streamBytesResponse))
+				})
+
+				Context("as NDJSON", func() {
+					BeforeEach(func() {
+						responses["OK"].Stream = "ndjson"
+					})
+
+					It("writes a response method that encodes the items of a channel", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(ContainSubstring(streamNDJSONResponse))
+					})
+
+					It("writes a response method that encodes the items of an iterator", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(ContainSubstring(streamNDJSONIteratorResponse))
+					})
+				})
+
+				Context("with caching headers", func() {
+					BeforeEach(func() {
+						maxAge := uint(60)
+						responses["OK"].CacheControl = &design.CacheControlDefinition{
+							Public:               true,
+							MaxAge:               &maxAge,
+							StaleWhileRevalidate: 30,
+						}
+						responses["OK"].Vary = []string{"Accept-Language"}
+						responses["OK"].SurrogateKeys = []string{"bottles", "cellar"}
+					})
+
+					It("sets the caching headers", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(ContainSubstring(cachingHeaders))
+					})
+				})
+			})
+
+			Context("with a media type with an entity tag", func() {
+				var mediaType *design.MediaTypeDefinition
+
+				BeforeEach(func() {
+					mediaType = &design.MediaTypeDefinition{
+						UserTypeDefinition: &design.UserTypeDefinition{
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{
+									"id":         {Type: design.Integer},
+									"updated_at": {Type: design.DateTime},
+								},
+							},
+							TypeName: "Bottle",
+						},
+						Identifier:     "application/vnd.goa.test",
+						ETagAttributes: []string{"id", "updated_at"},
+					}
+					defView := &design.ViewDefinition{
+						AttributeDefinition: mediaType.AttributeDefinition,
+						Name:                "default",
+						Parent:              mediaType,
+					}
+					mediaType.Views = map[string]*design.ViewDefinition{"default": defView}
+					design.Design = new(design.APIDefinition)
+					design.Design.MediaTypes = map[string]*design.MediaTypeDefinition{
+						design.CanonicalIdentifier(mediaType.Identifier): mediaType,
+					}
+					design.ProjectedMediaTypes = make(map[string]*design.MediaTypeDefinition)
+					responses = map[string]*design.ResponseDefinition{"OK": {
+						Name:      "OK",
+						Status:    200,
+						MediaType: mediaType.Identifier,
+					}}
+				})
+
+				It("the generated code checks the entity tag", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(etagResponse))
+				})
+
+				It("the generated media type computes the entity tag", func() {
+					mtWriter, err := genapp.NewMediaTypesWriter(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(mtWriter.Execute(mediaType)).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(string(b)).Should(ContainSubstring("return goa.ComputeETag(mt.ID, mt.UpdatedAt)"))
+				})
+
+				Context("and a last modification time", func() {
+					BeforeEach(func() {
+						mediaType.WeakETag = true
+						mediaType.LastModifiedAttribute = "updated_at"
+					})
+
+					It("the generated code checks the entity tag and the last modification time", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(string(b)).Should(ContainSubstring("	if goa.CheckNotModified(ctx.Context, r.ETag(), r.LastModified()) {\n"))
+					})
+
+					It("the generated code checks the preconditions of mutating actions", func() {
+						data.Preconditions = mediaType
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(string(b)).Should(ContainSubstring(preconditionsContext))
+					})
+
+					It("the generated media type computes the entity tag and last modification time", func() {
+						mtWriter, err := genapp.NewMediaTypesWriter(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(mtWriter.Execute(mediaType)).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(string(b)).Should(ContainSubstring("return goa.ComputeWeakETag(mt.ID, mt.UpdatedAt)"))
+						Ω(string(b)).Should(ContainSubstring(lastModifiedMethod))
+					})
+				})
+			})
+
 			Context("with an integer param", func() {
 				BeforeEach(func() {
 					intParam := &design.AttributeDefinition{Type: design.Integer}
@@ -176,6 +396,28 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with a transformed string param", func() {
+				BeforeEach(func() {
+					strParam := &design.AttributeDefinition{Type: design.String, Transforms: []string{"trim", "lowercase"}}
+					dataType := design.Object{
+						"param": strParam,
+					}
+					params = &design.AttributeDefinition{
+						Type: dataType,
+					}
+				})
+
+				It("writes the contexts code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(transformedStrContextFactory))
+				})
+			})
+
 			Context("with a number param", func() {
 				BeforeEach(func() {
 					numParam := &design.AttributeDefinition{Type: design.Number}
@@ -324,6 +566,33 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with a required param and fail-fast validation", func() {
+				BeforeEach(func() {
+					intParam := &design.AttributeDefinition{Type: design.Integer}
+					dataType := design.Object{
+						"int": intParam,
+					}
+					required := &dslengine.ValidationDefinition{
+						Required: []string{"int"},
+					}
+					params = &design.AttributeDefinition{
+						Type:       dataType,
+						Validation: required,
+					}
+					failFast = true
+				})
+
+				It("writes the contexts code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(failFastContextFactory))
+				})
+			})
+
 			Context("with a custom name param", func() {
 				BeforeEach(func() {
 					intParam := &design.AttributeDefinition{
@@ -372,6 +641,7 @@ var _ = Describe("ContextsWriter", func() {
 					Ω(written).ShouldNot(BeEmpty())
 					Ω(written).Should(ContainSubstring(strHeaderContext))
 					Ω(written).Should(ContainSubstring(strHeaderContextFactory))
+					Ω(written).Should(ContainSubstring(strHeaderGroup))
 				})
 			})
 
@@ -514,7 +784,24 @@ var _ = Describe("ControllersWriter", func() {
 			var actions, verbs, paths, contexts, unmarshals []string
 			var payloads []*design.UserTypeDefinition
 			var encoders, decoders []*genapp.EncoderTemplateData
-			var origins []*design.CORSDefinition
+			var origins, actionOrigins []*design.CORSDefinition
+			var actionPreflights []*genapp.PreflightData
+			var proxy *design.ProxyDefinition
+			var securityHeaders *design.SecurityHeadersDefinition
+			var allowedHeaders []string
+			var strictContentType []string
+			var multipart bool
+			var fallbacks []map[string]interface{}
+			var rateLimit map[string]interface{}
+			var affinity string
+			var classifications map[string]string
+			var bodyLimit int64
+			var incompressible []string
+			var clientCert *design.ClientCertDefinition
+			var challenge *design.ChallengeDefinition
+			var sandbox []map[string]interface{}
+			var uploads []*design.UploadDefinition
+			var versionHeader string
 
 			var data []*genapp.ControllerTemplateData
 
@@ -528,14 +815,35 @@ var _ = Describe("ControllersWriter", func() {
 				encoders = nil
 				decoders = nil
 				origins = nil
+				actionOrigins = nil
+				actionPreflights = nil
+				proxy = nil
+				securityHeaders = nil
+				allowedHeaders = nil
+				strictContentType = nil
+				multipart = false
+				fallbacks = nil
+				rateLimit = nil
+				affinity = ""
+				classifications = nil
+				bodyLimit = 0
+				incompressible = nil
+				clientCert = nil
+				challenge = nil
+				sandbox = nil
+				uploads = nil
+				versionHeader = ""
 			})
 
 			JustBeforeEach(func() {
 				codegen.TempCount = 0
-				api := &design.APIDefinition{}
+				api := &design.APIDefinition{Version: "2", VersionHeader: versionHeader}
 				d := &genapp.ControllerTemplateData{
-					Resource: "Bottles",
-					Origins:  origins,
+					Resource:         "Bottles",
+					Origins:          origins,
+					Proxy:            proxy,
+					Uploads:          uploads,
+					ActionPreflights: actionPreflights,
 				}
 				as := make([]map[string]interface{}, len(actions))
 				for i, a := range actions {
@@ -554,9 +862,48 @@ var _ = Describe("ControllersWriter", func() {
 								Verb: verbs[i],
 								Path: paths[i],
 							}},
-						"Context":   contexts[i],
-						"Unmarshal": unmarshal,
-						"Payload":   payload,
+						"Context":          contexts[i],
+						"Unmarshal":        unmarshal,
+						"Payload":          payload,
+						"PayloadMultipart": multipart,
+						"SecurityHeaders":  securityHeaders,
+						"ScrubHeaders":     allowedHeaders != nil,
+						"AllowedHeaders":   allowedHeaders,
+					}
+					if strictContentType != nil {
+						as[i]["StrictContentType"] = strictContentType
+					}
+					if fallbacks != nil {
+						as[i]["Fallbacks"] = fallbacks
+					}
+					if rateLimit != nil {
+						as[i]["RateLimit"] = rateLimit
+					}
+					if affinity != "" {
+						as[i]["Affinity"] = affinity
+					}
+					if classifications != nil {
+						as[i]["Classifications"] = classifications
+						d.Classified = true
+					}
+					if bodyLimit > 0 {
+						as[i]["BodyLimit"] = bodyLimit
+						d.BodyLimited = true
+					}
+					if incompressible != nil {
+						as[i]["Incompressible"] = incompressible
+					}
+					if clientCert != nil {
+						as[i]["ClientCert"] = clientCert
+					}
+					if challenge != nil {
+						as[i]["Challenge"] = challenge
+					}
+					if sandbox != nil {
+						as[i]["Sandbox"] = sandbox
+					}
+					if actionOrigins != nil && i == 0 {
+						as[i]["Origins"] = actionOrigins
 					}
 				}
 				if len(as) > 0 {
@@ -601,100 +948,479 @@ var _ = Describe("ControllersWriter", func() {
 				})
 			})
 
-			Context("with actions that take a payload", func() {
+			Context("with fallbacks", func() {
 				BeforeEach(func() {
 					actions = []string{"List"}
 					verbs = []string{"GET"}
 					paths = []string{"/accounts/:accountID/bottles"}
 					contexts = []string{"ListBottleContext"}
-					unmarshals = []string{"unmarshalListBottlePayload"}
-					payloads = []*design.UserTypeDefinition{
-						{
-							TypeName: "ListBottlePayload",
-							AttributeDefinition: &design.AttributeDefinition{
-								Type: design.Object{
-									"id": &design.AttributeDefinition{
-										Type: design.String,
-									},
-								},
-							},
-						},
+					fallbacks = []map[string]interface{}{
+						{"Downstream": "ratings", "Status": 203, "HasBody": true, "Body": map[string]interface{}{"name": "Unrated"}},
+						{"Downstream": "stock", "Status": 503, "HasBody": false, "Body": nil},
 					}
 				})
 
-				It("writes the payload unmarshal function", func() {
+				It("mounts the fallback middleware", func() {
 					err := writer.Execute(data)
 					Ω(err).ShouldNot(HaveOccurred())
 					b, err := ioutil.ReadFile(filename)
 					Ω(err).ShouldNot(HaveOccurred())
 					written := string(b)
-					Ω(written).Should(ContainSubstring(payloadNoValidationsObjUnmarshal))
+					Ω(written).Should(ContainSubstring(fallbackMount))
 				})
 			})
-			Context("with actions that take a payload with a required validation", func() {
+
+			Context("with a rate limit", func() {
 				BeforeEach(func() {
 					actions = []string{"List"}
-					required := &dslengine.ValidationDefinition{
-						Required: []string{"id"},
-					}
 					verbs = []string{"GET"}
 					paths = []string{"/accounts/:accountID/bottles"}
 					contexts = []string{"ListBottleContext"}
-					unmarshals = []string{"unmarshalListBottlePayload"}
-					payloads = []*design.UserTypeDefinition{
-						{
-							TypeName: "ListBottlePayload",
-							AttributeDefinition: &design.AttributeDefinition{
-								Type: design.Object{
-									"id": &design.AttributeDefinition{
-										Type: design.String,
-									},
-								},
-								Validation: required,
-							},
-						},
+					rateLimit = map[string]interface{}{
+						"Requests": 10,
+						"Interval": time.Second,
+						"Burst":    20,
+						"Key":      `middleware.RateLimitKeyHeader("X-Api-Key")`,
 					}
 				})
 
-				It("writes the payload unmarshal function", func() {
+				It("mounts the rate limiting middleware", func() {
 					err := writer.Execute(data)
 					Ω(err).ShouldNot(HaveOccurred())
 					b, err := ioutil.ReadFile(filename)
 					Ω(err).ShouldNot(HaveOccurred())
 					written := string(b)
-					Ω(written).Should(ContainSubstring(payloadObjUnmarshal))
+					Ω(written).Should(ContainSubstring(rateLimitMount))
 				})
 			})
 
-			Context("with multiple controllers", func() {
+			Context("with an affinity", func() {
 				BeforeEach(func() {
-					actions = []string{"List", "Show"}
-					verbs = []string{"GET", "GET"}
-					paths = []string{"/accounts/:accountID/bottles", "/accounts/:accountID/bottles/:id"}
-					contexts = []string{"ListBottleContext", "ShowBottleContext"}
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					affinity = `middleware.AffinityFromParam("accountID")`
 				})
 
-				It("writes the controllers code", func() {
+				It("mounts the affinity middleware", func() {
 					err := writer.Execute(data)
 					Ω(err).ShouldNot(HaveOccurred())
 					b, err := ioutil.ReadFile(filename)
 					Ω(err).ShouldNot(HaveOccurred())
 					written := string(b)
-					Ω(written).ShouldNot(BeEmpty())
-					Ω(written).Should(ContainSubstring(multiController))
-					Ω(written).Should(ContainSubstring(multiMount))
+					Ω(written).Should(ContainSubstring(affinityMount))
 				})
 			})
 
-			Context("with encoder and decoder maps", func() {
+			Context("with a proxied resource", func() {
 				BeforeEach(func() {
 					actions = []string{"List"}
 					verbs = []string{"GET"}
 					paths = []string{"/accounts/:accountID/bottles"}
 					contexts = []string{"ListBottleContext"}
-					encoders = []*genapp.EncoderTemplateData{
-						{
-							PackageName: "goa",
+					proxy = &design.ProxyDefinition{
+						URL:           "http://legacy.internal",
+						Timeout:       5 * time.Second,
+						RemoveHeaders: []string{"Cookie"},
+					}
+				})
+
+				It("forwards the requests upstream", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(proxyMount))
+					Ω(written).ShouldNot(ContainSubstring("List(*ListBottleContext) error"))
+					Ω(written).ShouldNot(ContainSubstring("ctrl.List(rctx)"))
+				})
+			})
+
+			Context("with security headers", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					securityHeaders = &design.SecurityHeadersDefinition{
+						Profile: "strict",
+						Headers: map[string]string{"X-Frame-Options": ""},
+					}
+				})
+
+				It("applies the security headers middleware", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(securityHeadersMount))
+				})
+			})
+
+			Context("with scrubbed headers", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					allowedHeaders = []string{"X-Rate-Limit", "X-Request-Id"}
+				})
+
+				It("applies the scrubbing middleware", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`	h = middleware.ScrubHeaders("X-Rate-Limit", "X-Request-Id")(h)
+`))
+				})
+			})
+
+			Context("with classified fields", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					classifications = map[string]string{"owner.email": "pii:email"}
+				})
+
+				It("records the classifications", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("\tgoa.Classifier\n"))
+					Ω(written).Should(ContainSubstring(`	ctrl.Classify("List", map[string]string{
+		"owner.email": "pii:email",
+	})
+`))
+				})
+			})
+
+			Context("with a body limit", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					bodyLimit = 1048576
+				})
+
+				It("sets the body limit", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("\tgoa.BodyLimiter\n"))
+					Ω(written).Should(ContainSubstring(`	ctrl.SetBodyLimit("List", 1048576)
+`))
+				})
+			})
+
+			Context("with a client certificate requirement", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					clientCert = &design.ClientCertDefinition{Subjects: []string{"ops", "ops.example.com"}}
+				})
+
+				It("requires client certificates", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`	h = middleware.RequireClientCert("ops", "ops.example.com")(h)
+`))
+				})
+			})
+
+			Context("with a challenge", func() {
+				BeforeEach(func() {
+					actions = []string{"Signup"}
+					verbs = []string{"POST"}
+					paths = []string{"/accounts"}
+					contexts = []string{"SignupAccountContext"}
+					challenge = &design.ChallengeDefinition{Header: "X-Captcha"}
+				})
+
+				It("verifies the challenge token", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`	h = middleware.RequireChallenge(ChallengeVerifier, "X-Captcha")(h)
+`))
+				})
+			})
+
+			Context("with sandbox responses", func() {
+				BeforeEach(func() {
+					actions = []string{"Show"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles/:id"}
+					contexts = []string{"ShowBottleContext"}
+					sandbox = []map[string]interface{}{
+						{
+							"Name":        "OK",
+							"Status":      200,
+							"ContentType": "application/vnd.bottle",
+							"Views": map[string]string{
+								"default": `{"id":1,"name":"Number 8"}`,
+								"tiny":    `{"id":1}`,
+							},
+						},
+						{
+							"Name":   "NotFound",
+							"Status": 404,
+							"Views":  map[string]string{},
+						},
+					}
+				})
+
+				It("sends the example responses in sandbox mode", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(sandboxResponses))
+					Ω(written).Should(ContainSubstring(sandboxHandler))
+				})
+			})
+
+			Context("with incompressible responses", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					incompressible = []string{"application/zip", "image/png"}
+				})
+
+				It("registers the incompressible media types", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`	service.SetIncompressible("application/zip", "image/png")
+`))
+				})
+			})
+
+			Context("with a resumable upload", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					uploads = []*design.UploadDefinition{{
+						Name:        "label",
+						RequestPath: "/bottles/labels",
+						MaxSize:     1024,
+					}}
+				})
+
+				It("mounts the upload endpoints", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("	LabelUploadStore() goa.UploadStore\n"))
+					Ω(written).Should(ContainSubstring(uploadMount))
+				})
+
+				Context("that is presigned", func() {
+					BeforeEach(func() {
+						uploads[0].Presigned = true
+						uploads[0].Expiry = 5 * time.Minute
+					})
+
+					It("mounts the presigned upload endpoints", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(ContainSubstring("	LabelBlobStore() goa.BlobStore\n"))
+						Ω(written).Should(ContainSubstring(presignedUploadMount))
+					})
+				})
+			})
+
+			Context("with actions that take a payload", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					unmarshals = []string{"unmarshalListBottlePayload"}
+					payloads = []*design.UserTypeDefinition{
+						{
+							TypeName: "ListBottlePayload",
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{
+									"id": &design.AttributeDefinition{
+										Type: design.String,
+									},
+								},
+							},
+						},
+					}
+				})
+
+				It("writes the payload unmarshal function", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(payloadNoValidationsObjUnmarshal))
+				})
+
+				Context("with a strict content type", func() {
+					BeforeEach(func() {
+						strictContentType = []string{"application/json", "application/vnd.goa+json"}
+					})
+
+					It("checks the request content type before decoding", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(ContainSubstring(strictContentTypeUnmarshal))
+					})
+				})
+			})
+
+			Context("with actions that take a multipart payload", func() {
+				BeforeEach(func() {
+					actions = []string{"Create"}
+					verbs = []string{"POST"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"CreateBottleContext"}
+					unmarshals = []string{"unmarshalCreateBottlePayload"}
+					payloads = []*design.UserTypeDefinition{
+						{
+							TypeName: "CreateBottlePayload",
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{
+									"vintage": &design.AttributeDefinition{Type: design.Integer},
+									"label":   &design.AttributeDefinition{Type: design.File},
+									"photos":  &design.AttributeDefinition{Type: &design.Array{ElemType: &design.AttributeDefinition{Type: design.File}}},
+								},
+							},
+						},
+					}
+					multipart = true
+				})
+
+				It("loads the payload from the multipart form", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(multipartUnmarshal))
+				})
+			})
+			Context("with actions that take a payload with a required validation", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					required := &dslengine.ValidationDefinition{
+						Required: []string{"id"},
+					}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					unmarshals = []string{"unmarshalListBottlePayload"}
+					payloads = []*design.UserTypeDefinition{
+						{
+							TypeName: "ListBottlePayload",
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{
+									"id": &design.AttributeDefinition{
+										Type: design.String,
+									},
+								},
+								Validation: required,
+							},
+						},
+					}
+				})
+
+				It("writes the payload unmarshal function", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(payloadObjUnmarshal))
+				})
+			})
+
+			Context("with multiple controllers", func() {
+				BeforeEach(func() {
+					actions = []string{"List", "Show"}
+					verbs = []string{"GET", "GET"}
+					paths = []string{"/accounts/:accountID/bottles", "/accounts/:accountID/bottles/:id"}
+					contexts = []string{"ListBottleContext", "ShowBottleContext"}
+				})
+
+				It("writes the controllers code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(multiController))
+					Ω(written).Should(ContainSubstring(multiMount))
+				})
+			})
+
+			Context("with a version negotiated API", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					versionHeader = "X-API-Version"
+				})
+
+				It("mounts the controller on the version mux", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`	serviceMux(service).Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`))
+					Ω(written).ShouldNot(ContainSubstring("service.Mux.Handle"))
+				})
+			})
+
+			Context("with encoder and decoder maps", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					encoders = []*genapp.EncoderTemplateData{
+						{
+							PackageName: "goa",
 							Function:    "NewEncoder",
 							MIMETypes:   []string{"application/json"},
 						},
@@ -719,77 +1445,285 @@ var _ = Describe("ControllersWriter", func() {
 				})
 			})
 
-			Context("with multiple origins", func() {
-				BeforeEach(func() {
-					actions = []string{"List"}
-					verbs = []string{"GET"}
-					paths = []string{"/accounts"}
-					contexts = []string{"ListBottleContext"}
-					origins = []*design.CORSDefinition{
-						{
-							Origin:      "here.example.com",
-							Headers:     []string{"X-One", "X-Two"},
-							Methods:     []string{"GET", "POST"},
-							Exposed:     []string{"X-Three"},
-							Credentials: true,
-						},
-						{
-							Origin:  "there.example.com",
-							Headers: []string{"*"},
-							Methods: []string{"*"},
-						},
-					}
+			Context("with multiple origins", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts"}
+					contexts = []string{"ListBottleContext"}
+					origins = []*design.CORSDefinition{
+						{
+							Origin:      "here.example.com",
+							Headers:     []string{"X-One", "X-Two"},
+							Methods:     []string{"GET", "POST"},
+							Exposed:     []string{"X-Three"},
+							Credentials: true,
+						},
+						{
+							Origin:  "there.example.com",
+							Headers: []string{"*"},
+							Methods: []string{"*"},
+						},
+					}
+
+				})
+
+				It("writes the controller code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(originsIntegration))
+					Ω(written).Should(ContainSubstring(originsHandler))
+				})
+			})
+
+			Context("with regexp origins", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts"}
+					contexts = []string{"ListBottleContext"}
+					origins = []*design.CORSDefinition{
+						{
+							Origin:      "[here|there].example.com",
+							Headers:     []string{"X-One", "X-Two"},
+							Methods:     []string{"GET", "POST"},
+							Exposed:     []string{"X-Three"},
+							Credentials: true,
+							Regexp:      true,
+						},
+						{
+							Origin:  "there.example.com",
+							Headers: []string{"*"},
+							Methods: []string{"*"},
+						},
+					}
+
+				})
+
+				It("writes the controller code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(originsIntegration))
+					Ω(written).Should(ContainSubstring(regexpOriginsHandler))
+				})
+			})
+
+			Context("with action origins", func() {
+				BeforeEach(func() {
+					actions = []string{"Delete", "Show"}
+					verbs = []string{"DELETE", "GET"}
+					paths = []string{"/accounts/:id", "/accounts/:id"}
+					contexts = []string{"DeleteBottleContext", "ShowBottleContext"}
+					origins = []*design.CORSDefinition{{Origin: "here.example.com"}}
+					actionOrigins = []*design.CORSDefinition{
+						{
+							Origin:      "*.example.com",
+							Methods:     []string{"DELETE"},
+							MaxAge:      60,
+							Credentials: true,
+							Func:        "TenantOrigin",
+						},
+					}
+					actionPreflights = []*genapp.PreflightData{
+						{Path: "/accounts/:id", Handlers: map[string]string{"DELETE": "Delete"}, Default: true},
+					}
+				})
+
+				It("writes the action CORS handler", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(actionOriginsIntegration))
+					Ω(written).Should(ContainSubstring("h = handleBottlesDeleteOrigin(h)"))
+					Ω(written).Should(ContainSubstring("h = handleBottlesOrigin(h)"))
+					Ω(written).Should(ContainSubstring(actionOriginsHandler))
+				})
+			})
+
+		})
+	})
+})
+
+var _ = Describe("DependenciesWriter", func() {
+	var writer *genapp.DependenciesWriter
+	var workspace *codegen.Workspace
+	var filename string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("controllers")
+		Ω(err).ShouldNot(HaveOccurred())
+		src := pkg.CreateSourceFile("test.go")
+		filename = src.Abs()
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		writer, err = genapp.NewDependenciesWriter(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	Context("with dependencies", func() {
+		var deps []*design.DependencyDefinition
+
+		BeforeEach(func() {
+			deps = []*design.DependencyDefinition{
+				{Name: "tx", GoType: "*sql.Tx", PackagePath: "database/sql"},
+			}
+		})
+
+		It("writes the typed accessors", func() {
+			err := writer.Execute(deps)
+			Ω(err).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadFile(filename)
+			Ω(err).ShouldNot(HaveOccurred())
+			written := string(b)
+			Ω(written).ShouldNot(BeEmpty())
+			Ω(written).Should(Equal(dependenciesCode))
+		})
+	})
+})
+
+var _ = Describe("WebhooksWriter", func() {
+	var writer *genapp.WebhooksWriter
+	var workspace *codegen.Workspace
+	var filename string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("app")
+		Ω(err).ShouldNot(HaveOccurred())
+		src := pkg.CreateSourceFile("test.go")
+		filename = src.Abs()
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		writer, err = genapp.NewWebhooksWriter(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	Context("with webhooks", func() {
+		var webhooks []*genapp.WebhookData
+
+		BeforeEach(func() {
+			webhooks = []*genapp.WebhookData{{
+				Name:         "BottleCreated",
+				Event:        "bottle.created",
+				Description:  "Sent when a bottle is added",
+				ResourceName: "bottles",
+				ActionName:   "subscribe",
+				TypeRef:      "*Bottle",
+			}}
+		})
+
+		It("writes the dispatch functions", func() {
+			err := writer.Execute(webhooks)
+			Ω(err).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadFile(filename)
+			Ω(err).ShouldNot(HaveOccurred())
+			written := string(b)
+			Ω(written).ShouldNot(BeEmpty())
+			Ω(written).Should(Equal(webhooksCode))
+		})
+	})
+})
+
+var _ = Describe("SecurityWriter", func() {
+	var writer *genapp.SecurityWriter
+	var workspace *codegen.Workspace
+	var filename string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("app")
+		Ω(err).ShouldNot(HaveOccurred())
+		src := pkg.CreateSourceFile("test.go")
+		filename = src.Abs()
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		writer, err = genapp.NewSecurityWriter(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
 
-				})
+	AfterEach(func() {
+		workspace.Delete()
+	})
 
-				It("writes the controller code", func() {
-					err := writer.Execute(data)
-					Ω(err).ShouldNot(HaveOccurred())
-					b, err := ioutil.ReadFile(filename)
-					Ω(err).ShouldNot(HaveOccurred())
-					written := string(b)
-					Ω(written).ShouldNot(BeEmpty())
-					Ω(written).Should(ContainSubstring(originsIntegration))
-					Ω(written).Should(ContainSubstring(originsHandler))
-				})
-			})
+	Context("with security schemes", func() {
+		var schemes []*design.SecuritySchemeDefinition
 
-			Context("with regexp origins", func() {
-				BeforeEach(func() {
-					actions = []string{"List"}
-					verbs = []string{"GET"}
-					paths = []string{"/accounts"}
-					contexts = []string{"ListBottleContext"}
-					origins = []*design.CORSDefinition{
-						{
-							Origin:      "[here|there].example.com",
-							Headers:     []string{"X-One", "X-Two"},
-							Methods:     []string{"GET", "POST"},
-							Exposed:     []string{"X-Three"},
-							Credentials: true,
-							Regexp:      true,
-						},
-						{
-							Origin:  "there.example.com",
-							Headers: []string{"*"},
-							Methods: []string{"*"},
-						},
-					}
+		BeforeEach(func() {
+			schemes = []*design.SecuritySchemeDefinition{
+				{SchemeName: "basic", Kind: design.BasicAuthSecurityKind},
+				{SchemeName: "key", Kind: design.APIKeySecurityKind, In: "header", Name: "X-Key"},
+			}
+		})
 
-				})
+		It("writes the verification function", func() {
+			err := writer.Execute(schemes)
+			Ω(err).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadFile(filename)
+			Ω(err).ShouldNot(HaveOccurred())
+			written := string(b)
+			Ω(written).Should(ContainSubstring(verifySecurityCode))
+		})
 
-				It("writes the controller code", func() {
-					err := writer.Execute(data)
-					Ω(err).ShouldNot(HaveOccurred())
-					b, err := ioutil.ReadFile(filename)
-					Ω(err).ShouldNot(HaveOccurred())
-					written := string(b)
-					Ω(written).ShouldNot(BeEmpty())
-					Ω(written).Should(ContainSubstring(originsIntegration))
-					Ω(written).Should(ContainSubstring(regexpOriginsHandler))
+		It("writes the API key middleware constructor", func() {
+			err := writer.Execute(schemes)
+			Ω(err).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadFile(filename)
+			Ω(err).ShouldNot(HaveOccurred())
+			written := string(b)
+			Ω(written).Should(ContainSubstring(apiKeyMiddlewareCode))
+			Ω(written).ShouldNot(ContainSubstring("func NewBasicMiddleware"))
+		})
+
+		Context("with a JWT scheme reading the scopes from a custom claim", func() {
+			BeforeEach(func() {
+				schemes = append(schemes, &design.SecuritySchemeDefinition{
+					SchemeName:  "jwt",
+					Kind:        design.JWTSecurityKind,
+					In:          "header",
+					Name:        "Authorization",
+					ScopesClaim: "scp",
 				})
 			})
 
+			It("sets the claim name in the security definition", func() {
+				err := writer.Execute(schemes)
+				Ω(err).ShouldNot(HaveOccurred())
+				b, err := ioutil.ReadFile(filename)
+				Ω(err).ShouldNot(HaveOccurred())
+				written := string(b)
+				Ω(written).Should(ContainSubstring("\t\tScopesClaim:      \"scp\",\n"))
+			})
 		})
 	})
 })
@@ -904,6 +1838,26 @@ var _ = Describe("HrefWriter", func() {
 						Ω(written).ShouldNot(BeEmpty())
 						Ω(written).Should(ContainSubstring(simpleResourceHref))
 					})
+
+					Context("and a mount", func() {
+						JustBeforeEach(func() {
+							data.Mounts = []*genapp.MountData{{
+								Name:              "Cellar",
+								CanonicalTemplate: "/cellars/%v/bottles/%v",
+								CanonicalParams:   []string{"cellarID", "id"},
+							}}
+						})
+
+						It("writes the mount href method", func() {
+							err := writer.Execute(data)
+							Ω(err).ShouldNot(HaveOccurred())
+							b, err := ioutil.ReadFile(filename)
+							Ω(err).ShouldNot(HaveOccurred())
+							written := string(b)
+							Ω(written).Should(ContainSubstring(simpleResourceHref))
+							Ω(written).Should(ContainSubstring(mountResourceHref))
+						})
+					})
 				})
 			})
 		})
@@ -919,6 +1873,13 @@ type ListBottleContext struct {
 }
 `
 
+	cachingHeaders = `
+	ctx.ResponseData.Header().Set("Cache-Control", "public, max-age=60, stale-while-revalidate=30")
+	ctx.ResponseData.Header().Add("Vary", "Accept-Language")
+	goa.AddSurrogateKeys(ctx.ResponseData.Header(), "bottles", "cellar")
+	ctx.ResponseData.Header().Set("Content-Type", "application/vnd.goa.test")
+`
+
 	emptyContextFactory = `
 func NewListBottleContext(ctx context.Context, service *goa.Service) (*ListBottleContext, error) {
 	var err error
@@ -986,6 +1947,17 @@ func NewListBottleContext(ctx context.Context, service *goa.Service) (*ListBottl
 }
 `
 
+	transformedStrContextFactory = `
+	paramParam := req.Params["param"]
+	if len(paramParam) > 0 {
+		for i, v := range paramParam {
+			paramParam[i] = goa.TransformParam(v, "trim", "lowercase")
+		}
+		rawParam := paramParam[0]
+		rctx.Param = &rawParam
+	}
+`
+
 	strHeaderContext = `
 type ListBottleContext struct {
 	context.Context
@@ -993,6 +1965,21 @@ type ListBottleContext struct {
 	*goa.RequestData
 	Header *string
 }
+`
+
+	strHeaderGroup = `
+// ListBottleHeaders groups the typed values of the request headers of the bottles list action.
+type ListBottleHeaders struct {
+	Header *string
+}
+
+// Headers returns the typed values of the request headers declared in the design, the values are
+// coerced and validated by NewListBottleContext.
+func (ctx *ListBottleContext) Headers() *ListBottleHeaders {
+	return &ListBottleHeaders{
+		Header: ctx.Header,
+	}
+}
 `
 
 	strHeaderContextFactory = `
@@ -1210,6 +2197,115 @@ func NewListBottleContext(ctx context.Context, service *goa.Service) (*ListBottl
 	}
 	return &rctx, err
 }
+`
+
+	streamContext = `
+// Stream returns a writer that streams the response to the client, the writer applies the write
+// timeout and buffer limits defined in the design. Close must be called on the writer once done.
+func (ctx *ListBottleContext) Stream() *goa.StreamWriter {
+	return goa.NewStreamWriter(ctx, ctx.ResponseData, goa.StreamOptions{
+		WriteTimeout: 10000000000, // 10s
+		MaxBuffered:  1024,
+	})
+}
+`
+
+	etagResponse = `func (ctx *ListBottleContext) OK(r *Bottle) error {
+	if goa.CheckNotModified(ctx.Context, r.ETag(), time.Time{}) {
+		ctx.ResponseData.WriteHeader(304)
+		return nil
+	}
+`
+
+	preconditionsContext = `
+// CheckPreconditions evaluates the request If-Match and If-Unmodified-Since headers against current,
+// the current state of the resource. It returns an error with status code 412 that the action
+// should return without applying any change if the preconditions are not met. Pass nil if the
+// resource does not exist.
+func (ctx *ListBottleContext) CheckPreconditions(current *Bottle) error {
+	return goa.CheckPreconditions(ctx.Context, current.ETag(), current.LastModified())
+}
+`
+
+	lastModifiedMethod = `func (mt *Bottle) LastModified() time.Time {
+	if mt == nil || mt.UpdatedAt == nil {
+		return time.Time{}
+	}
+	return *mt.UpdatedAt
+}
+`
+
+	streamBytesResponse = `// OK streams a HTTP response with status code 200, the content of r is
+// copied to the client.
+func (ctx *ListBottleContext) OK(r io.Reader) error {
+	ctx.ResponseData.Header().Set("Content-Type", "application/vnd.goa.test")
+	ctx.ResponseData.WriteHeader(200)
+	return goa.CopyStream(ctx.ResponseData, r)
+}
+`
+
+	streamNDJSONResponse = `// OK streams a HTTP response with status code 200, each item received on
+// items is written as a line of JSON. It returns once items is closed or the request is canceled.
+func (ctx *ListBottleContext) OK(items <-chan *Bottle) error {
+	ctx.ResponseData.Header().Set("Content-Type", "application/x-ndjson")
+	ctx.ResponseData.WriteHeader(200)
+	enc := goa.NewNDJSONEncoder(ctx.ResponseData)
+	for {
+		select {
+		case item, ok := <-items:
+			if !ok {
+				return nil
+			}
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+`
+
+	streamNDJSONIteratorResponse = `// OKIterator streams a HTTP response with status code 200, each item
+// returned by it is written as a line of JSON. The items must be of type *Bottle.
+// It returns once it returns io.EOF or the request is canceled.
+func (ctx *ListBottleContext) OKIterator(it goa.ItemIterator) error {
+	ctx.ResponseData.Header().Set("Content-Type", "application/x-ndjson")
+	ctx.ResponseData.WriteHeader(200)
+	return goa.StreamNDJSON(ctx, ctx.ResponseData, it)
+}
+`
+
+	paginationContext = `
+// Pagination returns the pagination parameters of the request. Set the Total field of the
+// returned value before sending the OK response so that it links to the other pages.
+func (ctx *ListBottleContext) Pagination() *goa.Pagination {
+	if ctx.pagination == nil {
+		ctx.pagination = &goa.Pagination{Total: -1}
+		ctx.pagination.Page = ctx.Page
+		ctx.pagination.PerPage = ctx.PerPage
+	}
+	return ctx.pagination
+}
+`
+
+	failFastContextFactory = `
+	paramInt := req.Params["int"]
+	if len(paramInt) == 0 {
+		err = goa.MergeErrors(err, goa.MissingParamError("int"))
+	} else {
+		rawInt := paramInt[0]
+		if int_, err2 := strconv.Atoi(rawInt); err2 == nil {
+			rctx.Int = int_
+		} else {
+			err = goa.MergeErrors(err, goa.InvalidParamTypeError("int", rawInt, "integer"))
+		}
+	}
+	if err != nil {
+		return &rctx, err
+	}
+	return &rctx, err
+}
 `
 
 	customContext = `
@@ -1285,6 +2381,98 @@ func unmarshalListBottlePayload(ctx context.Context, service *goa.Service, req *
 	goa.ContextRequest(ctx).Payload = payload.Publicize()
 	return nil
 }
+`
+	uploadMount = `
+	h = goa.NewUploader(ctrl.LabelUploadStore(), goa.UploadOptions{MaxSize: 1024}).Handler()
+	service.Mux.Handle("POST", "/bottles/labels", ctrl.MuxHandler("upload", h, nil))
+	service.Mux.Handle("HEAD", "/bottles/labels/:uploadID", ctrl.MuxHandler("upload", h, nil))
+	service.Mux.Handle("PATCH", "/bottles/labels/:uploadID", ctrl.MuxHandler("upload", h, nil))
+	service.Mux.Handle("OPTIONS", "/bottles/labels", ctrl.MuxHandler("upload", h, nil))
+	service.LogInfo("mount", "ctrl", "Bottles", "upload", "label", "route", "/bottles/labels")
+	service.AddRoute(goa.RouteInfo{Method: "POST", Pattern: "/bottles/labels", Resource: "Bottles", Action: "upload"})
+	service.AddRoute(goa.RouteInfo{Method: "HEAD", Pattern: "/bottles/labels/:uploadID", Resource: "Bottles", Action: "upload"})
+	service.AddRoute(goa.RouteInfo{Method: "PATCH", Pattern: "/bottles/labels/:uploadID", Resource: "Bottles", Action: "upload"})
+	service.AddRoute(goa.RouteInfo{Method: "OPTIONS", Pattern: "/bottles/labels", Resource: "Bottles", Action: "upload"})
+}
+`
+
+	presignedUploadMount = `
+	h = goa.NewDirectUploader(service, ctrl.LabelBlobStore(), goa.DirectUploadOptions{MaxSize: 1024, Expiry: 300000000000}).Handler() // URL expiry: 5m0s
+	service.Mux.Handle("POST", "/bottles/labels", ctrl.MuxHandler("upload", h, nil))
+	service.Mux.Handle("POST", "/bottles/labels/:uploadID/url", ctrl.MuxHandler("upload", h, nil))
+	service.Mux.Handle("POST", "/bottles/labels/:uploadID/confirm", ctrl.MuxHandler("upload", h, nil))
+	service.LogInfo("mount", "ctrl", "Bottles", "upload", "label", "route", "/bottles/labels")
+	service.AddRoute(goa.RouteInfo{Method: "POST", Pattern: "/bottles/labels", Resource: "Bottles", Action: "upload"})
+	service.AddRoute(goa.RouteInfo{Method: "POST", Pattern: "/bottles/labels/:uploadID/url", Resource: "Bottles", Action: "upload"})
+	service.AddRoute(goa.RouteInfo{Method: "POST", Pattern: "/bottles/labels/:uploadID/confirm", Resource: "Bottles", Action: "upload"})
+}
+`
+
+	strictContentTypeUnmarshal = `
+func unmarshalListBottlePayload(ctx context.Context, service *goa.Service, req *http.Request) error {
+	if err := goa.RequireContentType(req, "application/json", "application/vnd.goa+json"); err != nil {
+		return err
+	}
+	payload := &listBottlePayload{}
+`
+	apiKeyMiddlewareCode = `// NewKeyMiddleware creates a key auth middleware that accepts the keys
+// returned by resolver. Resolve a primary and a secondary key to rotate keys without downtime.
+func NewKeyMiddleware(resolver apikey.KeyResolver) goa.Middleware {
+	return apikey.NewWithResolver(resolver, NewKeySecurity())
+}
+`
+
+	verifySecurityCode = `func VerifySecurity(service *goa.Service, unmounted ...string) error {
+	skip := make(map[string]bool, len(unmounted))
+	for _, name := range unmounted {
+		skip[name] = true
+	}
+	var missing []string
+	for _, name := range []string{"basic", "key"} {
+		if skip[name] {
+			continue
+		}
+		if _, ok := service.Context.Value(authMiddlewareKey(name)).(goa.Middleware); !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return goa.MissingAuthMiddleware(missing...)
+	}
+	return nil
+}
+`
+
+	multipartUnmarshal = `
+func unmarshalCreateBottlePayload(ctx context.Context, service *goa.Service, req *http.Request) error {
+	// Keep up to 32MB of the form in memory, larger file parts are stored in temporary files
+	if err := req.ParseMultipartForm(32 << 20); err != nil {
+		return err
+	}
+	var err error
+	payload := &createBottlePayload{}
+	if files := req.MultipartForm.File["label"]; len(files) > 0 {
+		payload.Label = files[0]
+	}
+	if files := req.MultipartForm.File["photos"]; len(files) > 0 {
+		payload.Photos = files
+	}
+	if fieldVintage := req.MultipartForm.Value["vintage"]; len(fieldVintage) > 0 {
+		rawVintage := fieldVintage[0]
+		if vintage, err2 := strconv.Atoi(rawVintage); err2 == nil {
+			tmp2 := vintage
+			tmp1 := &tmp2
+			payload.Vintage = tmp1
+		} else {
+			err = goa.MergeErrors(err, goa.InvalidParamTypeError("vintage", rawVintage, "integer"))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	goa.ContextRequest(ctx).Payload = payload.Publicize()
+	return nil
+}
 `
 	payloadNoValidationsObjUnmarshal = `
 func unmarshalListBottlePayload(ctx context.Context, service *goa.Service, req *http.Request) error {
@@ -1389,6 +2577,59 @@ func handleBottlesOrigin(h goa.Handler) goa.Handler {
 }
 `
 
+	actionOriginsIntegration = `	service.Mux.Handle("OPTIONS", "/accounts/:id", ctrl.MuxHandler("preflight", cors.HandlePreflightByMethod(handleBottlesOrigin(cors.HandlePreflight()), map[string]goa.Handler{
+		"DELETE": handleBottlesDeleteOrigin(cors.HandlePreflight()),
+	}), nil))
+`
+
+	actionOriginsHandler = `// handleBottlesDeleteOrigin applies the CORS response headers corresponding to the origin.
+func handleBottlesDeleteOrigin(h goa.Handler) goa.Handler {
+
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		origin := req.Header.Get("Origin")
+		if origin == "" {
+			// Not a CORS request
+			return h(ctx, rw, req)
+		}
+		if cors.MatchOrigin(origin, "*.example.com") && cors.CheckOrigin(TenantOrigin, origin, req) {
+			ctx = goa.WithLogContext(ctx, "origin", origin)
+			rw.Header().Set("Access-Control-Allow-Origin", origin)
+			rw.Header().Set("Vary", "Origin")
+			rw.Header().Set("Access-Control-Allow-Credentials", "true")
+			if acrm := req.Header.Get("Access-Control-Request-Method"); acrm != "" {
+				// We are handling a preflight request
+				rw.Header().Set("Access-Control-Allow-Methods", "DELETE")
+				rw.Header().Set("Access-Control-Max-Age", "60")
+			}
+			return h(ctx, rw, req)
+		}
+
+		return h(ctx, rw, req)
+	}
+}
+`
+
+	sandboxResponses = `
+	sandboxShow := []*goa.SandboxResponse{
+		{Name: "OK", Status: 200, ContentType: "application/vnd.bottle", Views: map[string]string{
+			"default": "{\"id\":1,\"name\":\"Number 8\"}",
+			"tiny":    "{\"id\":1}",
+		}},
+		{Name: "NotFound", Status: 404},
+	}
+	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+`
+
+	sandboxHandler = `		if err != nil {
+			return err
+		}
+		if service.Sandbox {
+			return service.SendSandboxResponse(ctx, sandboxShow)
+		}
+		defer goa.StartPhase(ctx, goa.PhaseHandler)()
+		return ctrl.Show(rctx)
+`
+
 	encoderController = `
 // MountBottlesController "mounts" a Bottles resource controller on the given service.
 func MountBottlesController(service *goa.Service, ctrl BottlesController) {
@@ -1401,14 +2642,18 @@ func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 			return err
 		}
 		// Build the context
+		endValidate := goa.StartPhase(ctx, goa.PhaseValidate)
 		rctx, err := NewListBottleContext(ctx, service)
+		endValidate()
 		if err != nil {
 			return err
 		}
+		defer goa.StartPhase(ctx, goa.PhaseHandler)()
 		return ctrl.List(rctx)
 	}
 	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
+	service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: "/accounts/:accountID/bottles", Resource: "Bottles", Action: "List"})
 }
 `
 
@@ -1422,17 +2667,40 @@ func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 			return err
 		}
 		// Build the context
+		endValidate := goa.StartPhase(ctx, goa.PhaseValidate)
 		rctx, err := NewListBottleContext(ctx, service)
+		endValidate()
 		if err != nil {
 			return err
 		}
+		defer goa.StartPhase(ctx, goa.PhaseHandler)()
 		return ctrl.List(rctx)
 	}
 	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
+	service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: "/accounts/:accountID/bottles", Resource: "Bottles", Action: "List"})
 }
 `
 
+	fallbackMount = `		return ctrl.List(rctx)
+	}
+	h = service.Fallback("ratings", 203, map[string]interface {}{"name":"Unrated"})(h)
+	h = service.Fallback("stock", 503, nil)(h)
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+`
+
+	rateLimitMount = `		return ctrl.List(rctx)
+	}
+	h = middleware.RateLimiter(RateLimitStore, middleware.RateLimit{Requests: 10, Interval: 1000000000, Burst: 20}, middleware.RateLimitKeyHeader("X-Api-Key"))(h) // 10 requests per 1s
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+`
+
+	affinityMount = `		return ctrl.List(rctx)
+	}
+	h = middleware.Affinity(middleware.AffinityFromParam("accountID"))(h)
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+`
+
 	multiController = `// BottlesController is the controller interface for the Bottles actions.
 type BottlesController interface {
 	goa.Muxer
@@ -1451,14 +2719,18 @@ type BottlesController interface {
 			return err
 		}
 		// Build the context
+		endValidate := goa.StartPhase(ctx, goa.PhaseValidate)
 		rctx, err := NewListBottleContext(ctx, service)
+		endValidate()
 		if err != nil {
 			return err
 		}
+		defer goa.StartPhase(ctx, goa.PhaseHandler)()
 		return ctrl.List(rctx)
 	}
 	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "List", "route", "GET /accounts/:accountID/bottles")
+	service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: "/accounts/:accountID/bottles", Resource: "Bottles", Action: "List"})
 
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 		// Check if there was an error loading the request
@@ -1466,19 +2738,86 @@ type BottlesController interface {
 			return err
 		}
 		// Build the context
+		endValidate := goa.StartPhase(ctx, goa.PhaseValidate)
 		rctx, err := NewShowBottleContext(ctx, service)
+		endValidate()
 		if err != nil {
 			return err
 		}
+		defer goa.StartPhase(ctx, goa.PhaseHandler)()
 		return ctrl.Show(rctx)
 	}
 	service.Mux.Handle("GET", "/accounts/:accountID/bottles/:id", ctrl.MuxHandler("Show", h, nil))
 	service.LogInfo("mount", "ctrl", "Bottles", "action", "Show", "route", "GET /accounts/:accountID/bottles/:id")
+	service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: "/accounts/:accountID/bottles/:id", Resource: "Bottles", Action: "Show"})
 }
 `
 
+	proxyMount = `	proxy, err := goa.NewProxy("http://legacy.internal", goa.ProxyOptions{
+		Timeout: 5000000000, // 5s
+		RemoveHeaders: []string{"Cookie"},
+	})
+	if err != nil {
+		panic(err) // bug: the upstream URL is validated by the design
+	}
+
+	h = proxy.Handler(nil)
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+`
+
 	simpleResourceHref = `func BottleHref(id interface{}) string {
 	return fmt.Sprintf("/bottles/%v", id)
 }
+`
+
+	mountResourceHref = `// BottleCellarHref returns the resource href for the Cellar mount.
+func BottleCellarHref(cellarID, id interface{}) string {
+	return fmt.Sprintf("/cellars/%v/bottles/%v", cellarID, id)
+}
+`
+
+	securityHeadersMount = `	h = middleware.SecurityHeaders("strict", map[string]string{
+		"X-Frame-Options": "",
+	})(h)
+	service.Mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+`
+
+	webhooksCode = `
+// BottleCreatedEvent is the name of the event sent by the bottles subscribe webhook.
+const BottleCreatedEvent = "bottle.created"
+
+// DispatchBottleCreated delivers the bottle.created event declared by the bottles subscribe
+// action to url using d.
+//
+// Sent when a bottle is added
+func DispatchBottleCreated(ctx context.Context, d *goa.WebhookDispatcher, url string, payload *Bottle) error {
+	return d.Deliver(ctx, url, BottleCreatedEvent, payload)
+}
+`
+
+	dependenciesCode = `
+// SetTx stores the tx dependency in the request context container. It panics if
+// the context does not contain a container, see the goa middleware.Container middleware.
+func SetTx(ctx context.Context, v *sql.Tx) {
+	c := goa.ContextContainer(ctx)
+	if c == nil {
+		panic("no dependency container in context, use the middleware.Container middleware")
+	}
+	c.Set("tx", v)
+}
+
+// GetTx retrieves the tx dependency from the request context container. ok is
+// false if the dependency was not set.
+func GetTx(ctx context.Context) (v *sql.Tx, ok bool) {
+	c := goa.ContextContainer(ctx)
+	if c == nil {
+		return
+	}
+	var raw interface{}
+	if raw, ok = c.Get("tx"); ok {
+		v, ok = raw.(*sql.Tx)
+	}
+	return
+}
 `
 )
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/gen_app"
@@ -135,6 +136,7 @@ func (g *Generator) Generate() (_ []string, err error) {
 			"pathParamNames":     pathParamNames,
 			"pathParams":         pathParams,
 			"pathTemplate":       pathTemplate,
+			"recursiveValidate":  codegen.RecursiveChecker,
 			"signerType":         signerType,
 			"tempvar":            codegen.Tempvar,
 			"title":              strings.Title,
@@ -294,6 +296,7 @@ func (g *Generator) generateResourceClient(pkgDir string, res *design.ResourceDe
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("io"),
 		codegen.SimpleImport("io/ioutil"),
+		codegen.SimpleImport("mime/multipart"),
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("net/url"),
 		codegen.SimpleImport("os"),
@@ -303,7 +306,9 @@ func (g *Generator) generateResourceClient(pkgDir string, res *design.ResourceDe
 		codegen.SimpleImport("time"),
 		codegen.SimpleImport("golang.org/x/net/context"),
 		codegen.SimpleImport("golang.org/x/net/websocket"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.NewImport("uuid", "github.com/goadesign/goa/uuid"),
+		codegen.NewImport("goaclient", "github.com/goadesign/goa/client"),
 	}
 	if err := file.WriteHeader("", g.Target, imports); err != nil {
 		return err
@@ -402,7 +407,11 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		signer        string
 		clientsTmpl   = template.Must(template.New("clients").Funcs(funcs).Parse(clientsTmpl))
 		requestsTmpl  = template.Must(template.New("requests").Funcs(funcs).Parse(requestsTmpl))
+		builderTmpl   = template.Must(template.New("builder").Funcs(funcs).Parse(builderTmpl))
 		clientsWSTmpl = template.Must(template.New("clientsws").Funcs(funcs).Parse(clientsWSTmpl))
+		eventsTmpl    = template.Must(template.New("events").Funcs(funcs).Parse(eventsTmpl))
+		wsStreamTmpl  = template.Must(template.New("wsstream").Funcs(funcs).Parse(wsStreamTmpl))
+		pagesTmpl     = template.Must(template.New("pages").Funcs(funcs).Parse(pagesTmpl))
 	)
 	if action.Payload != nil {
 		params = append(params, "payload "+codegen.GoTypeRef(action.Payload, action.Payload.AllRequired(), 1, false))
@@ -465,6 +474,13 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 	}
 	queryParams = initParams(action.QueryParams)
 	headers = initParams(action.Headers)
+	var validations []string
+	for _, p := range append(queryParams, headers...) {
+		// Only required primitive parameters are passed by value and are not checked for nil.
+		if v := codegen.ValidationChecker(p.Attribute, false, !p.CheckNil, false, p.VarName, p.Name, 2, false); v != "" {
+			validations = append(validations, v)
+		}
+	}
 	if action.Security != nil {
 		signer = codegen.Goify(action.Security.Scheme.SchemeName, true)
 	}
@@ -480,6 +496,11 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		Signer          string
 		QueryParams     []*paramData
 		Headers         []*paramData
+		Validations     string
+		EventType       string
+		EventTypeName   string
+		EventPointer    bool
+		Pages           *pagesData
 	}{
 		Name:            action.Name,
 		ResourceName:    action.Parent.Name,
@@ -492,14 +513,99 @@ func (g *Generator) generateActionClient(action *design.ActionDefinition, file *
 		Signer:          signer,
 		QueryParams:     queryParams,
 		Headers:         headers,
+		Validations:     strings.Join(validations, "\n"),
+		Pages:           newPagesData(action, params, names),
 	}
 	if action.WebSocket() {
-		return clientsWSTmpl.Execute(file, data)
+		if err := clientsWSTmpl.Execute(file, data); err != nil {
+			return err
+		}
+		return wsStreamTmpl.Execute(file, data)
 	}
-	if err := clientsTmpl.Execute(file, data); err != nil {
-		return err
+	tmpls := []*template.Template{clientsTmpl, requestsTmpl, builderTmpl}
+	if data.Pages != nil {
+		tmpls = append(tmpls, pagesTmpl)
+	}
+	for _, tmpl := range tmpls {
+		if err := tmpl.Execute(file, data); err != nil {
+			return err
+		}
+	}
+	if !action.ServerSentEvents() {
+		return nil
+	}
+	data.EventType = "*goaclient.Event"
+	if p := okMediaType(action); p != nil {
+		data.EventType = decodeGoTypeRef(p, p.AllRequired(), 0, false)
+		data.EventTypeName = decodeGoTypeName(p, p.AllRequired(), 0, false)
+		data.EventPointer = p.IsObject()
 	}
-	return requestsTmpl.Execute(file, data)
+	return eventsTmpl.Execute(file, data)
+}
+
+// okMediaType returns the projection of the OK response media type of the given action used to
+// decode the server sent events it streams or the pages it lists, nil if the OK response has no
+// media type.
+func okMediaType(action *design.ActionDefinition) *design.MediaTypeDefinition {
+	resp, ok := action.Responses["OK"]
+	if !ok || resp.MediaType == "" {
+		return nil
+	}
+	mt := design.Design.MediaTypeWithIdentifier(resp.MediaType)
+	if mt == nil {
+		return nil
+	}
+	view := resp.ViewName
+	if view == "" {
+		view = design.DefaultView
+	}
+	p, _, err := mt.Project(view)
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+// newPagesData returns the data used to generate the page iterator of paginated actions whose OK
+// response is a collection, nil for other actions. params and names are the parameters of the
+// action client method and their names, the page iterators set the page or cursor parameter
+// themselves.
+func newPagesData(action *design.ActionDefinition, params, names []string) *pagesData {
+	if action.Pagination == nil {
+		return nil
+	}
+	p := okMediaType(action)
+	if p == nil || !p.IsArray() {
+		return nil
+	}
+	elem := p.Type.ToArray().ElemType
+	mt, ok := elem.Type.(*design.MediaTypeDefinition)
+	if !ok {
+		return nil
+	}
+	cursor := codegen.Goify(goa.PageParam, false)
+	if action.Pagination.Cursor {
+		cursor = codegen.Goify(goa.CursorParam, false)
+	}
+	data := &pagesData{
+		CollectionType: decodeGoTypeRef(p, p.AllRequired(), 0, false),
+		DecodeFunc:     "Decode" + typeName(p),
+		ItemType:       decodeGoTypeRef(mt, mt.AllRequired(), 0, false),
+	}
+	var iterParams, iterNames, callNames []string
+	for i, n := range names {
+		if n == cursor {
+			callNames = append(callNames, "nil")
+			continue
+		}
+		iterParams = append(iterParams, params[i])
+		iterNames = append(iterNames, n)
+		callNames = append(callNames, n)
+	}
+	data.Params = strings.Join(iterParams, ", ")
+	data.ParamNames = strings.Join(callNames, ", ")
+	data.IteratorArgs = strings.Join(iterNames, ", ")
+	return data
 }
 
 // fileServerMethod returns the name of the client method for downloading assets served by the given
@@ -589,6 +695,7 @@ func (g *Generator) generateUserTypes(pkgDir string) error {
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("mime/multipart"),
 		codegen.SimpleImport("time"),
 	}
 	utWr.WriteHeader(title, g.Target, imports)
@@ -824,6 +931,24 @@ type paramData struct {
 	CheckNil      bool
 }
 
+// pagesData is the data structure holding the information needed to generate the page
+// iterators of paginated actions.
+type pagesData struct {
+	// Params lists the parameters of the iterator methods: the parameters of the action
+	// client method but the page or cursor parameter.
+	Params string
+	// ParamNames lists the arguments given to the request method by the iterators.
+	ParamNames string
+	// IteratorArgs lists the names of the iterator method parameters.
+	IteratorArgs string
+	// CollectionType is the Go type of the decoded pages.
+	CollectionType string
+	// DecodeFunc is the name of the client method that decodes the pages.
+	DecodeFunc string
+	// ItemType is the Go type of the items.
+	ItemType string
+}
+
 type byParamName []*paramData
 
 func (b byParamName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
@@ -840,7 +965,13 @@ const (
 
 	payloadTmpl = `// {{ gotypename .Payload nil 0 false }} is the {{ .Parent.Name }} {{ .Name }} action payload.
 type {{ gotypename .Payload nil 1 false }} {{ gotypedef .Payload 0 true false }}
-`
+{{ $validation := recursiveValidate .Payload.AttributeDefinition false false false "payload" "raw" 1 false }}{{ if $validation }}
+// Validate runs the validation rules defined in the design.
+func (payload {{ gotyperef .Payload .Payload.AllRequired 0 false }}) Validate() (err error) {
+{{ $validation }}
+	return
+}
+{{ end }}`
 
 	typeDecodeTmpl = `{{ $typeName := typeName . }}{{ $funcName := printf "Decode%s" $typeName }}// {{ $funcName }} decodes the {{ $typeName }} instance encoded in resp body.
 func (c *Client) {{ $funcName }}(resp *http.Response) ({{ decodegotyperef . .AllRequired 0 false }}, error) {
@@ -865,7 +996,7 @@ func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params}}
 	if err != nil {
 		return nil, err
 	}
-	return c.Client.Do(ctx, req)
+	return c.Client.Do(goaclient.WithOperation(ctx, {{ printf "%q" .ResourceName }}, {{ printf "%q" .Name }}), req)
 }
 `
 
@@ -939,7 +1070,21 @@ func (c * Client) {{ .Name }}(ctx context.Context, {{ if .DirName }}filename, {{
 	requestsTmpl = `{{ $funcName := goify (printf "New%s%sRequest" (title .Name) (title .ResourceName)) true }}{{/*
 */}}// {{ $funcName }} create the request corresponding to the {{ .Name }} action endpoint of the {{ .ResourceName }} resource.
 func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}{{ if .HasPayload }}, contentType string{{ end }}) (*http.Request, error) {
-{{ if .HasPayload }}	var body bytes.Buffer
+{{ if or .HasPayload .Validations }}	if c.ValidateRequests {
+{{ if .HasPayload }}		if v, ok := interface{}(payload).(interface {
+			Validate() error
+		}); ok {
+			if err := v.Validate(); err != nil {
+				return nil, err
+			}
+		}
+{{ end }}{{ if .Validations }}		var err error
+{{ .Validations }}
+		if err != nil {
+			return nil, err
+		}
+{{ end }}	}
+{{ end }}{{ if .HasPayload }}	var body bytes.Buffer
 	if contentType == "" {
 		contentType = "*/*" // Use default encoder
 	}
@@ -993,6 +1138,169 @@ func (c *Client) {{ $funcName }}(ctx context.Context, path string{{ if .Params }
 	}
 {{ end }}	return req, nil
 }
+`
+
+	builderTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{ $builder := printf "%sRequestBuilder" $funcName }}{{/*
+*/}}// {{ $builder }} builds and sends requests to the {{ .Name }} action endpoint of the {{ .ResourceName }}
+// resource with per-call customizations.
+type {{ $builder }} struct {
+	client  *Client
+	options goaclient.RequestOptions
+	build   func(context.Context) (*http.Request, error)
+}
+
+// {{ $funcName }}Builder returns a builder for requests to the {{ .Name }} action endpoint of the
+// {{ .ResourceName }} resource, call Do to send the request.
+func (c *Client) {{ $funcName }}Builder(path string{{ if .Params }}, {{ .Params }}{{ end }}{{ if .HasPayload }}, contentType string{{ end }}) *{{ $builder }} {
+	return &{{ $builder }}{
+		client: c,
+		build: func(ctx context.Context) (*http.Request, error) {
+			return c.New{{ $funcName }}Request(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }}{{ if .HasPayload }}, contentType{{ end }})
+		},
+	}
+}
+
+// WithContext sets the context used to build and send the request.
+func (b *{{ $builder }}) WithContext(ctx context.Context) *{{ $builder }} {
+	b.options.Context = ctx
+	return b
+}
+
+// WithHeader sets the given request header.
+func (b *{{ $builder }}) WithHeader(name, value string) *{{ $builder }} {
+	b.options.SetHeader(name, value)
+	return b
+}
+
+// WithQuery adds the given query string value to the request.
+func (b *{{ $builder }}) WithQuery(name, value string) *{{ $builder }} {
+	b.options.AddQuery(name, value)
+	return b
+}
+
+// WithIdempotencyKey sets the key the service uses to detect retries of the request.
+func (b *{{ $builder }}) WithIdempotencyKey(key string) *{{ $builder }} {
+	b.options.SetHeader(goaclient.IdempotencyKeyHeader, key)
+	return b
+}
+
+// WithAffinityKey sets the key load balancers and the service use to route the request.
+func (b *{{ $builder }}) WithAffinityKey(key string) *{{ $builder }} {
+	b.options.SetHeader(goaclient.AffinityKeyHeader, key)
+	return b
+}
+
+// WithRetryPolicy sets the policy used to retry the request, it overrides the client policy.
+func (b *{{ $builder }}) WithRetryPolicy(policy goaclient.RetryPolicy) *{{ $builder }} {
+	b.options.RetryPolicy = policy
+	return b
+}
+
+// Do builds the request, applies the customizations and sends it.
+func (b *{{ $builder }}) Do() (*http.Response, error) {
+	ctx := b.options.Ctx()
+	req, err := b.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.options.Apply(req)
+	return b.client.Client.Do(goaclient.WithOperation(ctx, {{ printf "%q" .ResourceName }}, {{ printf "%q" .Name }}), req)
+}
+`
+
+	eventsTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{/*
+*/}}// {{ $funcName }}Events streams the server sent events sent by the {{ .Name }} action endpoint of the
+// {{ .ResourceName }} resource. The connection is re-established with backoff when it is lost and
+// the stream resumes after the last event received. The events channel is closed when ctx is
+// canceled or the stream stops, the error that stopped it if any is then sent to the error channel.
+func (c *Client) {{ $funcName }}Events(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}{{ if .HasPayload }}, contentType string{{ end }}, options goaclient.StreamOptions) (<-chan {{ .EventType }}, <-chan error) {
+{{ if .EventTypeName }}	ctx, cancel := context.WithCancel(ctx)
+{{ end }}	events, errc := c.Client.StreamEvents(ctx, func(ctx context.Context) (*http.Request, error) {
+		return c.New{{ $funcName }}Request(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }}{{ if .HasPayload }}, contentType{{ end }})
+	}, options)
+{{ if .EventTypeName }}	decoded := make(chan {{ .EventType }})
+	errs := make(chan error, 1)
+	go func() {
+		defer cancel()
+		defer close(errs)
+		defer close(decoded)
+		for ev := range events {
+			var e {{ .EventTypeName }}
+			if err := json.Unmarshal(ev.Data, &e); err != nil {
+				errs <- fmt.Errorf("failed to decode event %#v: %s", ev.ID, err)
+				return
+			}
+			select {
+			case decoded <- {{ if .EventPointer }}&{{ end }}e:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := <-errc; err != nil {
+			errs <- err
+		}
+	}()
+	return decoded, errs
+{{ else }}	return events, errc
+{{ end }}}
+`
+
+	pagesTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{/*
+*/}}// {{ $funcName }}Iterator returns an iterator over the items listed by the {{ .Name }} action
+// endpoint of the {{ .ResourceName }} resource. The iterator requests the pages as needed by following
+// the "next" links of the responses, the items are of type {{ .Pages.ItemType }}.
+func (c *Client) {{ $funcName }}Iterator(ctx context.Context, path string{{ if .Pages.Params }}, {{ .Pages.Params }}{{ end }}) *goaclient.PageIterator {
+	return goaclient.NewPageIterator(ctx, func(ctx context.Context, cursor string) ([]interface{}, string, error) {
+		req, err := c.New{{ $funcName }}Request(ctx, path{{ if .Pages.ParamNames }}, {{ .Pages.ParamNames }}{{ end }})
+		if err != nil {
+			return nil, "", err
+		}
+		if err := goaclient.FollowPageLink(req, cursor); err != nil {
+			return nil, "", err
+		}
+		resp, err := c.Client.Do(goaclient.WithOperation(ctx, {{ printf "%q" .ResourceName }}, {{ printf "%q" .Name }}), req)
+		if err != nil {
+			return nil, "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("failed to list page: %s", resp.Status)
+		}
+		page, err := c.{{ .Pages.DecodeFunc }}(resp)
+		if err != nil {
+			return nil, "", err
+		}
+		items := make([]interface{}, len(page))
+		for i, item := range page {
+			items[i] = item
+		}
+		return items, goaclient.NextPageLink(resp), nil
+	})
+}
+
+{{ $allName := goify (printf "%sAll%s" .Name (title .ResourceName)) true }}{{/*
+*/}}// {{ $allName }} returns the items listed by the {{ .Name }} action endpoint of the
+// {{ .ResourceName }} resource by following the pages, see {{ $funcName }}Iterator. It stops after
+// max items if max is positive.
+func (c *Client) {{ $allName }}(ctx context.Context, path string{{ if .Pages.Params }}, {{ .Pages.Params }}{{ end }}, max int) ({{ .Pages.CollectionType }}, error) {
+	var all {{ .Pages.CollectionType }}
+	it := c.{{ $funcName }}Iterator(ctx, path{{ if .Pages.IteratorArgs }}, {{ .Pages.IteratorArgs }}{{ end }})
+	for (max <= 0 || len(all) < max) && it.Next() {
+		all = append(all, it.Item().({{ .Pages.ItemType }}))
+	}
+	return all, it.Err()
+}
+`
+
+	wsStreamTmpl = `{{ $funcName := goify (printf "%s%s" .Name (title .ResourceName)) true }}{{/*
+*/}}// {{ $funcName }}Stream establishes a websocket connection to the {{ .Name }} action endpoint of the
+// {{ .ResourceName }} resource that is re-established with backoff when it is lost. Pings are sent
+// at the interval given in options to keep the connection alive.
+func (c *Client) {{ $funcName }}Stream(ctx context.Context, path string{{ if .Params }}, {{ .Params }}{{ end }}, options goaclient.StreamOptions) *goaclient.WSStream {
+	return goaclient.NewWSStream(ctx, func(ctx context.Context) (*websocket.Conn, error) {
+		return c.{{ $funcName }}(ctx, path{{ if .ParamNames }}, {{ .ParamNames }}{{ end }})
+	}, options)
+}
 `
 
 	clientTmpl = `// Client is the {{ .API.Name }} service client.
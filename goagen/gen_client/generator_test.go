@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
 	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/gen_client"
 	"github.com/goadesign/goa/version"
@@ -135,6 +137,25 @@ var _ = Describe("Generate", func() {
 			Ω(strings.Count(string(content), "func ShowFooPath2(")).Should(Equal(1))
 		})
 
+		It("generates a request builder", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("type ShowFooRequestBuilder struct {"))
+			Ω(content).Should(ContainSubstring("func (c *Client) ShowFooBuilder(path string) *ShowFooRequestBuilder {"))
+			Ω(content).Should(ContainSubstring("func (b *ShowFooRequestBuilder) WithIdempotencyKey(key string) *ShowFooRequestBuilder {"))
+			Ω(content).Should(ContainSubstring("func (b *ShowFooRequestBuilder) WithRetryPolicy(policy goaclient.RetryPolicy) *ShowFooRequestBuilder {"))
+			Ω(content).Should(ContainSubstring("func (b *ShowFooRequestBuilder) WithAffinityKey(key string) *ShowFooRequestBuilder {"))
+			Ω(content).Should(ContainSubstring(`return b.client.Client.Do(goaclient.WithOperation(ctx, "foo", "show"), req)`))
+		})
+
+		It("records the operation of the requests", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring(`return c.Client.Do(goaclient.WithOperation(ctx, "foo", "show"), req)`))
+		})
+
 		Context("with a file server", func() {
 			BeforeEach(func() {
 				res := design.Design.Resources["foo"]
@@ -158,6 +179,173 @@ var _ = Describe("Generate", func() {
 		})
 	})
 
+	Context("with an action with validations", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			min := 1.0
+			minLength := 2
+			design.Design = &design.APIDefinition{
+				Name: "testapi",
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"update": {
+								Name: "update",
+								QueryParams: &design.AttributeDefinition{
+									Type: design.Object{
+										"limit": &design.AttributeDefinition{Type: design.Integer, Validation: &dslengine.ValidationDefinition{Minimum: &min}},
+										"name":  &design.AttributeDefinition{Type: design.String, Validation: &dslengine.ValidationDefinition{MinLength: &minLength}},
+									},
+									Validation: &dslengine.ValidationDefinition{Required: []string{"name"}},
+								},
+								Payload: &design.UserTypeDefinition{
+									TypeName: "UpdateFooPayload",
+									AttributeDefinition: &design.AttributeDefinition{
+										Type: design.Object{
+											"title": &design.AttributeDefinition{Type: design.String},
+										},
+										Validation: &dslengine.ValidationDefinition{Required: []string{"title"}},
+									},
+								},
+								Routes: []*design.RouteDefinition{
+									{
+										Verb: "PUT",
+										Path: "",
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			updateAct := fooRes.Actions["update"]
+			updateAct.Parent = fooRes
+			updateAct.Routes[0].Parent = updateAct
+		})
+
+		It("generates the client side validation code", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func (payload *UpdateFooPayload) Validate() (err error) {"))
+			Ω(content).Should(ContainSubstring("	if c.ValidateRequests {"))
+			Ω(content).Should(ContainSubstring(`goa.InvalidRangeError(` + "`limit`" + `, *limit, 1, true)`))
+			Ω(content).Should(ContainSubstring(`goa.InvalidLengthError(` + "`name`" + `, name, len(name), 2, true)`))
+		})
+	})
+
+	Context("with streaming actions", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			design.ProjectedMediaTypes = make(design.MediaTypeRoot)
+			tickAttr := &design.AttributeDefinition{
+				Type: design.Object{"value": &design.AttributeDefinition{Type: design.Integer}},
+			}
+			tick := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					AttributeDefinition: tickAttr,
+					TypeName:            "Tick",
+				},
+				Identifier: "application/vnd.tick",
+			}
+			tick.Views = map[string]*design.ViewDefinition{
+				"default": {Name: "default", AttributeDefinition: tickAttr, Parent: tick},
+			}
+			design.Design = &design.APIDefinition{
+				Name:       "testapi",
+				MediaTypes: map[string]*design.MediaTypeDefinition{"application/vnd.tick": tick},
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"ticks": {
+								Name:     "ticks",
+								Metadata: dslengine.MetadataDefinition{"stream:sse": nil},
+								Responses: map[string]*design.ResponseDefinition{
+									"OK": {Name: "OK", Status: 200, MediaType: "application/vnd.tick"},
+								},
+								Routes: []*design.RouteDefinition{{Verb: "GET", Path: "/ticks"}},
+							},
+							"chat": {
+								Name:    "chat",
+								Schemes: []string{"ws"},
+								Routes:  []*design.RouteDefinition{{Verb: "GET", Path: "/chat"}},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			for _, a := range fooRes.Actions {
+				a.Parent = fooRes
+				a.Routes[0].Parent = a
+			}
+		})
+
+		It("generates typed event and websocket streams", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func (c *Client) TicksFooEvents(ctx context.Context, path string, options goaclient.StreamOptions) (<-chan *Tick, <-chan error) {"))
+			Ω(content).Should(ContainSubstring("c.Client.StreamEvents(ctx, func(ctx context.Context) (*http.Request, error) {"))
+			Ω(content).Should(ContainSubstring("case decoded <- &e:"))
+			Ω(content).Should(ContainSubstring("func (c *Client) ChatFooStream(ctx context.Context, path string, options goaclient.StreamOptions) *goaclient.WSStream {"))
+			Ω(content).Should(ContainSubstring("return c.ChatFoo(ctx, path)"))
+		})
+	})
+
+	Context("with a paginated action", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			// Other tests replace design.Design, restore the registered root.
+			roots, err := dslengine.SortRoots()
+			Ω(err).ShouldNot(HaveOccurred())
+			for _, r := range roots {
+				if api, ok := r.(*design.APIDefinition); ok {
+					design.Design = api
+				}
+			}
+			dslengine.Reset()
+			API("test api", func() {})
+			bottle := MediaType("application/vnd.bottle", func() {
+				Attributes(func() {
+					Attribute("id", design.Integer)
+				})
+				View("default", func() {
+					Attribute("id")
+				})
+			})
+			Resource("bottle", func() {
+				BasePath("/bottles")
+				Action("list", func() {
+					Routing(GET(""))
+					Params(func() {
+						Param("color", design.String)
+					})
+					Paginate("page")
+					Response(design.OK, CollectionOf(bottle))
+				})
+			})
+			Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+		})
+
+		It("generates the page iterators", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "bottle.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(content).Should(ContainSubstring("func (c *Client) ListBottleIterator(ctx context.Context, path string, color *string, perPage *int) *goaclient.PageIterator {"))
+			Ω(content).Should(ContainSubstring("req, err := c.NewListBottleRequest(ctx, path, color, nil, perPage)"))
+			Ω(content).Should(ContainSubstring("page, err := c.DecodeBottleCollection(resp)"))
+			Ω(content).Should(ContainSubstring("return items, goaclient.NextPageLink(resp), nil"))
+			Ω(content).Should(ContainSubstring("func (c *Client) ListAllBottle(ctx context.Context, path string, color *string, perPage *int, max int) (BottleCollection, error) {"))
+			Ω(content).Should(ContainSubstring("it := c.ListBottleIterator(ctx, path, color, perPage)"))
+			Ω(content).Should(ContainSubstring("for (max <= 0 || len(all) < max) && it.Next() {"))
+			Ω(content).Should(ContainSubstring("all = append(all, it.Item().(*Bottle))"))
+		})
+	})
+
 	Context("with an action with security configured", func() {
 		BeforeEach(func() {
 			codegen.TempCount = 0
@@ -0,0 +1,479 @@
+package gencompat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// The names of the checks run against the recorded exchanges.
+const (
+	RouteCheck    = "route"
+	ParamsCheck   = "params"
+	HeadersCheck  = "headers"
+	PayloadCheck  = "payload"
+	StatusCheck   = "status"
+	ResponseCheck = "response"
+)
+
+type (
+	// Report is the result of checking recorded traffic against an API design.
+	Report struct {
+		// API is the API name.
+		API string `json:"api"`
+		// Compatible is true if none of the exchanges has issues.
+		Compatible bool `json:"compatible"`
+		// Consumers contains the reports of the consumers sorted by name.
+		Consumers []*ConsumerReport `json:"consumers"`
+	}
+
+	// ConsumerReport is the result of checking the exchanges of a consumer.
+	ConsumerReport struct {
+		// Name identifies the consumer.
+		Name string `json:"name"`
+		// Requests is the number of recorded exchanges.
+		Requests int `json:"requests"`
+		// Failures is the number of exchanges with issues.
+		Failures int `json:"failures"`
+		// Issues lists the distinct issues found in the exchanges.
+		Issues []*Issue `json:"issues,omitempty"`
+	}
+
+	// Issue describes an incompatibility between a recorded exchange and the design.
+	Issue struct {
+		// Check is the name of the check that found the issue, e.g. "params".
+		Check string `json:"check"`
+		// Request is the method and path of the action route matching the exchange, e.g.
+		// "GET /bottles/:id", or the method and path of the request if no route matches.
+		Request string `json:"request"`
+		// Action is the resource and action names, empty if no route matches.
+		Action string `json:"action,omitempty"`
+		// Message describes the issue.
+		Message string `json:"message"`
+		// Count is the number of exchanges with the issue.
+		Count int `json:"count"`
+	}
+
+	// route is an action route together with the regular expression matching its paths.
+	route struct {
+		action   *design.ActionDefinition
+		verb     string
+		path     string
+		regex    *regexp.Regexp
+		params   []string
+		literals int
+	}
+
+	// checker checks the exchanges of a consumer.
+	checker struct {
+		api    *design.APIDefinition
+		report *ConsumerReport
+		issues map[string]*Issue
+		failed bool
+	}
+
+	// byPriority sorts routes so that routes with more literal segments match first.
+	byPriority []*route
+)
+
+// Check checks the recorded exchanges against api and returns the issues found for each
+// consumer.
+func Check(api *design.APIDefinition, exchanges []*Exchange) *Report {
+	routes := apiRoutes(api)
+	checkers := make(map[string]*checker)
+	for _, ex := range exchanges {
+		if ex.Method == "OPTIONS" {
+			continue
+		}
+		c, ok := checkers[ex.Consumer]
+		if !ok {
+			c = &checker{
+				api:    api,
+				report: &ConsumerReport{Name: ex.Consumer},
+				issues: make(map[string]*Issue),
+			}
+			checkers[ex.Consumer] = c
+		}
+		c.check(ex, routes)
+	}
+	names := make([]string, 0, len(checkers))
+	for n := range checkers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	report := &Report{API: api.Name, Compatible: true}
+	for _, n := range names {
+		cr := checkers[n].report
+		if cr.Failures > 0 {
+			report.Compatible = false
+		}
+		report.Consumers = append(report.Consumers, cr)
+	}
+	return report
+}
+
+// apiRoutes returns the routes of the API actions sorted by priority.
+func apiRoutes(api *design.APIDefinition) []*route {
+	var routes []*route
+	api.IterateResources(func(r *design.ResourceDefinition) error {
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			for _, rt := range a.Routes {
+				routes = append(routes, newRoute(a, rt))
+			}
+			return nil
+		})
+	})
+	sort.Stable(byPriority(routes))
+	return routes
+}
+
+// newRoute computes the regular expression matching the paths of the action route rt.
+func newRoute(a *design.ActionDefinition, rt *design.RouteDefinition) *route {
+	path := rt.FullPath()
+	r := &route{action: a, verb: rt.Verb, path: path}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		switch {
+		case strings.HasPrefix(s, ":"):
+			segments[i] = "([^/]+)"
+			r.params = append(r.params, s[1:])
+		case strings.HasPrefix(s, "*"):
+			segments[i] = "(.*)"
+			r.params = append(r.params, s[1:])
+		default:
+			segments[i] = regexp.QuoteMeta(s)
+			if s != "" {
+				r.literals++
+			}
+		}
+	}
+	r.regex = regexp.MustCompile("^" + strings.Join(segments, "/") + "$")
+	return r
+}
+
+// check runs the checks against ex and records the issues.
+func (c *checker) check(ex *Exchange, routes []*route) {
+	c.report.Requests++
+	c.failed = false
+	defer func() {
+		if c.failed {
+			c.report.Failures++
+		}
+	}()
+
+	var (
+		match, head       *route
+		captures, headCap []string
+		verbs             []string
+	)
+	for _, r := range routes {
+		m := r.regex.FindStringSubmatch(ex.URL.Path)
+		if m == nil {
+			continue
+		}
+		if r.verb == ex.Method {
+			match, captures = r, m[1:]
+			break
+		}
+		if ex.Method == "HEAD" && r.verb == "GET" && head == nil {
+			head, headCap = r, m[1:]
+		}
+		verbs = append(verbs, r.verb)
+	}
+	if match == nil {
+		match, captures = head, headCap
+	}
+	if match == nil {
+		req := ex.Method + " " + ex.URL.Path
+		if len(verbs) == 0 {
+			c.issue(RouteCheck, req, nil, "no action route matches the request path")
+		} else {
+			c.issue(RouteCheck, req, nil, "method %s is not allowed, use %s", ex.Method, strings.Join(verbs, ", "))
+		}
+		return
+	}
+
+	a := match.action
+	req := match.verb + " " + match.path
+	c.params(req, a, match, captures, ex)
+	if ex.Detailed {
+		c.headers(req, a, ex)
+		c.payload(req, a, ex)
+	}
+	c.response(req, a, ex)
+}
+
+// params checks the path and query string parameters of ex.
+func (c *checker) params(req string, a *design.ActionDefinition, r *route, captures []string, ex *Exchange) {
+	values := ex.URL.Query()
+	for i, p := range r.params {
+		values[p] = []string{captures[i]}
+	}
+	c.values(ParamsCheck, req, a, "parameter", a.AllParams(), values)
+}
+
+// headers checks the request headers of ex.
+func (c *checker) headers(req string, a *design.ActionDefinition, ex *Exchange) {
+	headers := a.Headers
+	if a.Parent.Headers != nil {
+		headers = design.DupAtt(a.Parent.Headers).Merge(a.Headers)
+	}
+	if headers == nil {
+		return
+	}
+	values := make(map[string][]string)
+	for n := range headers.Type.ToObject() {
+		if v, ok := ex.Header[http.CanonicalHeaderKey(n)]; ok {
+			values[n] = v
+		}
+	}
+	c.values(HeadersCheck, req, a, "header", headers, values)
+}
+
+// values checks the given parameter or header values against the object attribute att.
+func (c *checker) values(check, req string, a *design.ActionDefinition, kind string, att *design.AttributeDefinition, values map[string][]string) {
+	if att == nil {
+		return
+	}
+	obj := att.Type.ToObject()
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		raw, ok := values[n]
+		if !ok || len(raw) == 0 {
+			if att.IsRequired(n) {
+				c.issue(check, req, a, "missing required %s %#v", kind, n)
+			}
+			continue
+		}
+		val, err := convert(obj[n].Type, raw)
+		if err != nil {
+			c.issue(check, req, a, "%s %#v: %s", kind, n, err)
+			continue
+		}
+		c.validate(check, req, a, obj[n].ValidateValue(n, val, a))
+	}
+}
+
+// payload checks the request body of ex.
+func (c *checker) payload(req string, a *design.ActionDefinition, ex *Exchange) {
+	if a.Payload == nil {
+		return
+	}
+	if len(bytes.TrimSpace(ex.Body)) == 0 {
+		if !a.PayloadOptional {
+			c.issue(PayloadCheck, req, a, "missing request payload")
+		}
+		return
+	}
+	if ct := ex.Header.Get("Content-Type"); ct != "" && !isJSON(ct) {
+		return
+	}
+	val, err := decodeJSON(ex.Body)
+	if err != nil {
+		c.issue(PayloadCheck, req, a, "request payload is not valid JSON: %s", err)
+		return
+	}
+	c.validate(PayloadCheck, req, a, a.Payload.AttributeDefinition.ValidateValue("payload", val, a))
+}
+
+// response checks the status and the body of the response recorded in ex. Error responses are
+// not checked.
+func (c *checker) response(req string, a *design.ActionDefinition, ex *Exchange) {
+	if ex.Status == 0 || ex.Status >= 400 {
+		return
+	}
+	var resp *design.ResponseDefinition
+	for _, r := range a.Responses {
+		if r.Status == ex.Status {
+			resp = r
+			break
+		}
+	}
+	if resp == nil {
+		c.issue(StatusCheck, req, a, "response with status %d is no longer declared", ex.Status)
+		return
+	}
+	if !ex.Detailed || len(bytes.TrimSpace(ex.ResponseBody)) == 0 || !isJSON(ex.ResponseContentType) {
+		return
+	}
+	mt := c.api.MediaTypeWithIdentifier(resp.MediaType)
+	if mt == nil {
+		return
+	}
+	val, err := decodeJSON(ex.ResponseBody)
+	if err != nil {
+		return
+	}
+	c.rendered(req, a, mt, "", val)
+}
+
+// rendered checks that the attributes of the recorded response body val are still rendered by
+// one of the views of mt with a compatible type. The elements of collections are checked against
+// the element media type and share the same issues.
+func (c *checker) rendered(req string, a *design.ActionDefinition, mt *design.MediaTypeDefinition, ctx string, val interface{}) {
+	if mt.IsArray() {
+		elem, ok := mt.ToArray().ElemType.Type.(*design.MediaTypeDefinition)
+		vals, isSlice := val.([]interface{})
+		if !ok || !isSlice {
+			return
+		}
+		for _, v := range vals {
+			c.rendered(req, a, elem, ctx, v)
+		}
+		return
+	}
+	obj, ok := val.(map[string]interface{})
+	if !ok || !mt.IsObject() {
+		return
+	}
+	rendered := make(map[string]bool)
+	for _, v := range mt.Views {
+		for n := range v.Type.ToObject() {
+			rendered[n] = true
+		}
+	}
+	if len(mt.Links) > 0 {
+		rendered["links"] = true
+	}
+	atts := mt.Type.ToObject()
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := k
+		if ctx != "" {
+			path = ctx + "." + k
+		}
+		if !rendered[k] {
+			c.issue(ResponseCheck, req, a, "response attribute %#v is no longer rendered", path)
+			continue
+		}
+		att, v := atts[k], obj[k]
+		if att == nil || v == nil {
+			continue
+		}
+		if !att.Type.IsCompatible(v) {
+			c.issue(ResponseCheck, req, a, "response attribute %#v: value %#v is incompatible with type %s", path, v, att.Type.Name())
+			continue
+		}
+		if child, ok := att.Type.(*design.MediaTypeDefinition); ok {
+			c.rendered(req, a, child, path, v)
+		}
+	}
+}
+
+// validate records the validation errors as issues.
+func (c *checker) validate(check, req string, a *design.ActionDefinition, verr *dslengine.ValidationErrors) {
+	if verr == nil {
+		return
+	}
+	for _, err := range verr.Errors {
+		c.issue(check, req, a, "%s", err)
+	}
+}
+
+// issue records an issue, issues with the same check, request and message are only recorded once.
+func (c *checker) issue(check, req string, a *design.ActionDefinition, format string, vals ...interface{}) {
+	c.failed = true
+	msg := fmt.Sprintf(format, vals...)
+	key := check + " " + req + " " + msg
+	if i, ok := c.issues[key]; ok {
+		i.Count++
+		return
+	}
+	i := &Issue{Check: check, Request: req, Message: msg, Count: 1}
+	if a != nil {
+		i.Action = a.Parent.Name + "#" + a.Name
+	}
+	c.issues[key] = i
+	c.report.Issues = append(c.report.Issues, i)
+}
+
+// convert converts the raw parameter or header values into a value of type t.
+func convert(t design.DataType, raw []string) (interface{}, error) {
+	if t.IsArray() {
+		elem := t.ToArray().ElemType.Type
+		vals := make([]interface{}, len(raw))
+		for i, r := range raw {
+			v, err := convert(elem, []string{r})
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = v
+		}
+		return vals, nil
+	}
+	r := raw[0]
+	switch t.Kind() {
+	case design.IntegerKind:
+		v, err := strconv.Atoi(r)
+		if err != nil {
+			return nil, fmt.Errorf("value %#v is not an integer", r)
+		}
+		return v, nil
+	case design.NumberKind:
+		v, err := strconv.ParseFloat(r, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value %#v is not a number", r)
+		}
+		return v, nil
+	case design.BooleanKind:
+		v, err := strconv.ParseBool(r)
+		if err != nil {
+			return nil, fmt.Errorf("value %#v is not a boolean", r)
+		}
+		return v, nil
+	}
+	return r, nil
+}
+
+// decodeJSON decodes the given JSON document, integers are decoded into int values and other
+// numbers into float64 values as expected by AttributeDefinition.ValidateValue.
+func decodeJSON(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var val interface{}
+	if err := dec.Decode(&val); err != nil {
+		return nil, err
+	}
+	return normalize(val), nil
+}
+
+// normalize replaces the json.Number values contained in val.
+func normalize(val interface{}) interface{} {
+	switch actual := val.(type) {
+	case json.Number:
+		if i, err := strconv.Atoi(string(actual)); err == nil {
+			return i
+		}
+		f, _ := actual.Float64()
+		return f
+	case map[string]interface{}:
+		for k, v := range actual {
+			actual[k] = normalize(v)
+		}
+	case []interface{}:
+		for i, v := range actual {
+			actual[i] = normalize(v)
+		}
+	}
+	return val
+}
+
+func (b byPriority) Len() int           { return len(b) }
+func (b byPriority) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byPriority) Less(i, j int) bool { return b[i].literals > b[j].literals }
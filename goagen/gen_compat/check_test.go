@@ -0,0 +1,176 @@
+package gencompat_test
+
+import (
+	"net/http"
+	"net/url"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_compat"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// newExchange creates a detailed exchange with JSON bodies.
+func newExchange(consumer, method, rawurl, body string, status int, respBody string) *gencompat.Exchange {
+	u, err := url.Parse(rawurl)
+	Ω(err).ShouldNot(HaveOccurred())
+	ex := &gencompat.Exchange{
+		Consumer:            consumer,
+		Method:              method,
+		URL:                 u,
+		Header:              http.Header{"Content-Type": {"application/json"}},
+		Body:                []byte(body),
+		Status:              status,
+		ResponseBody:        []byte(respBody),
+		ResponseContentType: "application/json",
+		Detailed:            true,
+	}
+	return ex
+}
+
+// designBottles runs the DSL of the design the exchanges are checked against.
+func designBottles() {
+	dslengine.Reset()
+	API("cellar", func() {})
+	BottleMedia := MediaType("application/vnd.bottle+json", func() {
+		Attributes(func() {
+			Attribute("id", Integer)
+			Attribute("name", String)
+			Attribute("vintage", Integer)
+		})
+		View("default", func() {
+			Attribute("id")
+			Attribute("name")
+		})
+		View("tiny", func() {
+			Attribute("id")
+		})
+	})
+	Resource("bottle", func() {
+		BasePath("/bottles")
+		Action("show", func() {
+			Routing(GET("/:id"))
+			Params(func() {
+				Param("id", Integer, func() {
+					Minimum(1)
+				})
+			})
+			Response(OK, BottleMedia)
+		})
+		Action("create", func() {
+			Routing(POST(""))
+			Headers(func() {
+				Header("X-Account", String)
+				Required("X-Account")
+			})
+			Payload(func() {
+				Attribute("name", String)
+				Required("name")
+			})
+			Response(Created)
+		})
+		Action("list", func() {
+			Routing(GET(""))
+			Params(func() {
+				Param("page", Integer)
+			})
+			Response(OK, CollectionOf(BottleMedia))
+		})
+	})
+	dslengine.Run()
+	Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+}
+
+var _ = Describe("Check", func() {
+	var exchanges []*gencompat.Exchange
+	var report *gencompat.Report
+
+	BeforeEach(func() {
+		designBottles()
+		exchanges = nil
+	})
+
+	JustBeforeEach(func() {
+		report = gencompat.Check(Design, exchanges)
+	})
+
+	Context("with compatible traffic", func() {
+		BeforeEach(func() {
+			create := newExchange("ui", "POST", "/bottles", `{"name": "Chateau"}`, 201, "")
+			create.Header.Set("X-Account", "acme")
+			exchanges = []*gencompat.Exchange{
+				newExchange("ui", "GET", "/bottles/1", "", 200, `{"id": 1, "name": "Chateau"}`),
+				newExchange("ui", "HEAD", "/bottles/1", "", 200, ""),
+				newExchange("cli", "GET", "/bottles?page=2", "", 200, `[{"id": 1}, {"id": 2}]`),
+				newExchange("cli", "GET", "/bottles/3", "", 404, ""),
+				newExchange("cli", "OPTIONS", "/bottles", "", 200, ""),
+				create,
+			}
+		})
+
+		It("reports no issue", func() {
+			Ω(report.API).Should(Equal("cellar"))
+			Ω(report.Compatible).Should(BeTrue())
+			Ω(report.Consumers).Should(HaveLen(2))
+			Ω(report.Consumers[0].Name).Should(Equal("cli"))
+			Ω(report.Consumers[0].Requests).Should(Equal(2))
+			Ω(report.Consumers[0].Issues).Should(BeEmpty())
+			Ω(report.Consumers[1].Name).Should(Equal("ui"))
+			Ω(report.Consumers[1].Requests).Should(Equal(3))
+			Ω(report.Consumers[1].Issues).Should(BeEmpty())
+		})
+	})
+
+	Context("with breaking traffic", func() {
+		BeforeEach(func() {
+			exchanges = []*gencompat.Exchange{
+				newExchange("ui", "GET", "/cellars/1", "", 200, ""),
+				newExchange("ui", "PUT", "/bottles/1", "", 200, ""),
+				newExchange("ui", "GET", "/bottles/0", "", 200, ""),
+				newExchange("ui", "GET", "/bottles?page=next", "", 200, ""),
+				newExchange("ui", "POST", "/bottles", `{}`, 201, ""),
+				newExchange("ui", "GET", "/bottles/1", "", 206, ""),
+				newExchange("ui", "GET", "/bottles/1", "", 200, `{"id": 1, "color": "red"}`),
+				newExchange("ui", "GET", "/bottles/2", "", 200, `{"id": 2, "color": "red"}`),
+				newExchange("ui", "GET", "/bottles", "", 200, `[{"id": "one"}]`),
+			}
+		})
+
+		It("reports the issues", func() {
+			Ω(report.Compatible).Should(BeFalse())
+			Ω(report.Consumers).Should(HaveLen(1))
+			cr := report.Consumers[0]
+			Ω(cr.Requests).Should(Equal(9))
+			Ω(cr.Failures).Should(Equal(9))
+			issues := make([]gencompat.Issue, len(cr.Issues))
+			for i, is := range cr.Issues {
+				issues[i] = *is
+			}
+			Ω(issues).Should(Equal([]gencompat.Issue{
+				{Check: "route", Request: "GET /cellars/1", Message: "no action route matches the request path", Count: 1},
+				{Check: "route", Request: "PUT /bottles/1", Message: "method PUT is not allowed, use GET", Count: 1},
+				{Check: "params", Request: "GET /bottles/:id", Action: "bottle#show", Message: `value of "id": value 0 is lower than the minimum 1`, Count: 1},
+				{Check: "params", Request: "GET /bottles", Action: "bottle#list", Message: `parameter "page": value "next" is not an integer`, Count: 1},
+				{Check: "headers", Request: "POST /bottles", Action: "bottle#create", Message: `missing required header "X-Account"`, Count: 1},
+				{Check: "payload", Request: "POST /bottles", Action: "bottle#create", Message: `value of "payload": missing required attribute "name"`, Count: 1},
+				{Check: "status", Request: "GET /bottles/:id", Action: "bottle#show", Message: "response with status 206 is no longer declared", Count: 1},
+				{Check: "response", Request: "GET /bottles/:id", Action: "bottle#show", Message: `response attribute "color" is no longer rendered`, Count: 2},
+				{Check: "response", Request: "GET /bottles", Action: "bottle#list", Message: `response attribute "id": value "one" is incompatible with type integer`, Count: 1},
+			}))
+		})
+	})
+
+	Context("with access log traffic", func() {
+		BeforeEach(func() {
+			ex := newExchange("cli", "POST", "/bottles", "", 201, "")
+			ex.Detailed = false
+			exchanges = []*gencompat.Exchange{ex}
+		})
+
+		It("does not check the headers and payload", func() {
+			Ω(report.Compatible).Should(BeTrue())
+		})
+	})
+})
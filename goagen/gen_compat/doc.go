@@ -0,0 +1,25 @@
+/*
+Package gencompat provides a generator that checks recorded consumer traffic against the design.
+Running it with the design of a proposed change reports which consumers of the API would break if
+the change shipped. It complements reviewing the design diff: the checks are driven by what the
+consumers actually send and read rather than by what the design allows. The checks are:
+
+	route:    the request method and path match an action route
+	params:   the path and query string parameters satisfy the action parameter types and
+	          validations and all the required parameters are present
+	headers:  the request headers satisfy the action header types and validations
+	payload:  the request body satisfies the action payload type and validations
+	status:   the status code of successful responses is still declared by the action
+	response: the response body attributes read by the consumers are still rendered by one of the
+	          views of the response media type with a compatible type
+
+The traffic is read from HAR files (as exported by browsers and most HTTP proxies) or from access
+logs in the Common or Combined Log Format. Access logs do not record the headers or the bodies so
+only the route, params and status checks run against them. The consumers are identified by the
+value of a request header in HAR files, User-Agent by default, and by the authenticated user or
+the user agent in access logs.
+
+The generator writes a Markdown report listing the issues found for each consumer and the same
+information as JSON so that it can be used to fail a CI pipeline.
+*/
+package gencompat
@@ -0,0 +1,156 @@
+package gencompat
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/goadesign/goa/version"
+)
+
+// Generator is the consumer compatibility report generator.
+type Generator struct {
+	API            *design.APIDefinition // The API definition
+	OutDir         string                // Path to output directory
+	Traffic        []string              // Paths to the HAR files and access logs
+	ConsumerHeader string                // Request header identifying the consumers in HAR files
+	genfiles       []string              // Generated files
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, traffic, consumerHeader, ver string
+
+	set := flag.NewFlagSet("compat", flag.PanicOnError)
+	set.String("design", "", "")
+	set.StringVar(&outDir, "out", "", "")
+	set.StringVar(&traffic, "traffic", "", "")
+	set.StringVar(&consumerHeader, "consumer-header", "", "")
+	set.StringVar(&ver, "version", "", "")
+	set.Parse(os.Args[1:])
+
+	if err := codegen.CheckVersion(ver); err != nil {
+		return nil, err
+	}
+
+	g := &Generator{OutDir: outDir, API: design.Design, ConsumerHeader: consumerHeader}
+	for _, t := range strings.Split(traffic, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			g.Traffic = append(g.Traffic, t)
+		}
+	}
+
+	return g.Generate()
+}
+
+// Generate checks the recorded traffic and produces the compatibility report.
+func (g *Generator) Generate() (_ []string, err error) {
+	if g.API == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design is properly initialized")
+	}
+	if len(g.Traffic) == 0 {
+		return nil, fmt.Errorf("missing traffic, use --traffic to specify the HAR files or access logs to check")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	var exchanges []*Exchange
+	for _, t := range g.Traffic {
+		ex, err := LoadTraffic(t, g.ConsumerHeader)
+		if err != nil {
+			return nil, err
+		}
+		exchanges = append(exchanges, ex...)
+	}
+	report := Check(g.API, exchanges)
+
+	outDir := filepath.Join(g.OutDir, "compat")
+	if err = os.RemoveAll(outDir); err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, outDir)
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	jsonFile := filepath.Join(outDir, "compat.json")
+	g.genfiles = append(g.genfiles, jsonFile)
+	if err = ioutil.WriteFile(jsonFile, b, 0644); err != nil {
+		return nil, err
+	}
+
+	reportFile := filepath.Join(outDir, "report.md")
+	file, err := os.Create(reportFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	g.genfiles = append(g.genfiles, reportFile)
+	data := map[string]interface{}{
+		"Report":      report,
+		"Traffic":     g.Traffic,
+		"Requests":    len(exchanges),
+		"ToolVersion": version.String(),
+	}
+	if err = reportTmpl.Execute(file, data); err != nil {
+		return nil, err
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.RemoveAll(f)
+	}
+	g.genfiles = nil
+}
+
+// status returns the text displayed in the report for a compatible or breaking consumer.
+func status(cr *ConsumerReport) string {
+	if cr.Failures == 0 {
+		return "compatible"
+	}
+	return "**breaks**"
+}
+
+var reportTmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"status": status,
+	"join":   strings.Join,
+}).Parse(reportT))
+
+const reportT = `# {{ .Report.API }} consumer compatibility report
+
+Generated by goagen {{ .ToolVersion }} from {{ .Requests }} recorded requests ({{ join .Traffic ", " }}).
+
+**{{ if .Report.Compatible }}No consumer breaks{{ else }}Some consumers break{{ end }}**
+
+| Consumer | Requests | Failures | Status |
+|----------|----------|----------|--------|
+{{ range .Report.Consumers }}| {{ .Name }} | {{ .Requests }} | {{ .Failures }} | {{ status . }} |
+{{ end }}{{ range .Report.Consumers }}{{ if .Issues }}
+## {{ .Name }}
+
+| Check | Request | Issue | Count |
+|-------|---------|-------|-------|
+{{ range .Issues }}| {{ .Check }} | {{ .Request }} | {{ .Message }} | {{ .Count }} |
+{{ end }}{{ end }}{{ end }}`
@@ -0,0 +1,72 @@
+package gencompat_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/gen_compat"
+	"github.com/goadesign/goa/version"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var outDir string
+	var traffic string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		var err error
+		outDir, err = ioutil.TempDir("", "compat")
+		Ω(err).ShouldNot(HaveOccurred())
+		traffic = filepath.Join(outDir, "access.log")
+		Ω(ioutil.WriteFile(traffic, []byte(accessLog), 0644)).Should(Succeed())
+		designBottles()
+	})
+
+	JustBeforeEach(func() {
+		os.Args = []string{"goagen", "--out=" + outDir, "--design=foo", "--version=" + version.String()}
+		if traffic != "" {
+			os.Args = append(os.Args, "--traffic="+traffic)
+		}
+		files, genErr = gencompat.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	It("generates the report", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(3))
+
+		content, err := ioutil.ReadFile(filepath.Join(outDir, "compat", "report.md"))
+		Ω(err).ShouldNot(HaveOccurred())
+		report := string(content)
+		Ω(report).Should(ContainSubstring("# cellar consumer compatibility report"))
+		Ω(report).Should(ContainSubstring("from 3 recorded requests"))
+		Ω(report).Should(ContainSubstring("| alice | 1 | 0 | compatible |"))
+		Ω(report).Should(ContainSubstring("| 10.0.0.3 | 1 | 1 | **breaks** |"))
+		Ω(report).Should(ContainSubstring("| route | DELETE /bottles/2 | method DELETE is not allowed, use GET | 1 |"))
+
+		content, err = ioutil.ReadFile(filepath.Join(outDir, "compat", "compat.json"))
+		Ω(err).ShouldNot(HaveOccurred())
+		var r gencompat.Report
+		Ω(json.Unmarshal(content, &r)).Should(Succeed())
+		Ω(r.Compatible).Should(BeFalse())
+		Ω(r.Consumers).Should(HaveLen(3))
+	})
+
+	Context("with no traffic", func() {
+		BeforeEach(func() {
+			traffic = ""
+		})
+
+		It("fails", func() {
+			Ω(genErr).Should(HaveOccurred())
+		})
+	})
+})
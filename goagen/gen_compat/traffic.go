@@ -0,0 +1,190 @@
+package gencompat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Exchange is a recorded request together with the response sent by the API.
+type Exchange struct {
+	// Consumer identifies the API consumer that made the request.
+	Consumer string
+	// Method is the request method.
+	Method string
+	// URL is the request URL.
+	URL *url.URL
+	// Header contains the request headers.
+	Header http.Header
+	// Body is the request body.
+	Body []byte
+	// Status is the response status code.
+	Status int
+	// ResponseBody is the response body.
+	ResponseBody []byte
+	// ResponseContentType is the value of the response Content-Type header.
+	ResponseContentType string
+	// Detailed is true if the recording includes the headers and the bodies, false for access
+	// log entries.
+	Detailed bool
+}
+
+// har describes the subset of the HAR 1.2 format read by ParseHAR.
+type har struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method  string      `json:"method"`
+				URL     string      `json:"url"`
+				Headers []harHeader `json:"headers"`
+				Body    *struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+			Response struct {
+				Status  int `json:"status"`
+				Content struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+					Encoding string `json:"encoding"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// harHeader is a HAR request header.
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// accessLogRegex matches Common and Combined Log Format lines. The request line is matched
+// leniently so that lines recording malformed requests can be skipped.
+var accessLogRegex = regexp.MustCompile(`^(\S+) \S+ (\S+) \[[^\]]*\] "([^"]*)" (\d{3}) \S+(?: "[^"]*" "([^"]*)")?`)
+
+// ParseHAR reads the exchanges recorded in a HAR file. The consumers are identified by the value
+// of the given request header, "User-Agent" if empty.
+func ParseHAR(r io.Reader, consumerHeader string) ([]*Exchange, error) {
+	if consumerHeader == "" {
+		consumerHeader = "User-Agent"
+	}
+	var h har
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return nil, fmt.Errorf("invalid HAR: %s", err)
+	}
+	exchanges := make([]*Exchange, 0, len(h.Log.Entries))
+	for i, e := range h.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HAR: entry %d: %s", i, err)
+		}
+		header := make(http.Header)
+		for _, hd := range e.Request.Headers {
+			header.Add(hd.Name, hd.Value)
+		}
+		ex := &Exchange{
+			Consumer:            header.Get(consumerHeader),
+			Method:              strings.ToUpper(e.Request.Method),
+			URL:                 u,
+			Header:              header,
+			Status:              e.Response.Status,
+			ResponseContentType: e.Response.Content.MimeType,
+			Detailed:            true,
+		}
+		if ex.Consumer == "" {
+			ex.Consumer = "unknown"
+		}
+		if b := e.Request.Body; b != nil {
+			ex.Body = []byte(b.Text)
+			if header.Get("Content-Type") == "" && b.MimeType != "" {
+				header.Set("Content-Type", b.MimeType)
+			}
+		}
+		ex.ResponseBody = []byte(e.Response.Content.Text)
+		if e.Response.Content.Encoding == "base64" {
+			if ex.ResponseBody, err = base64.StdEncoding.DecodeString(e.Response.Content.Text); err != nil {
+				return nil, fmt.Errorf("invalid HAR: entry %d: %s", i, err)
+			}
+		}
+		exchanges = append(exchanges, ex)
+	}
+	return exchanges, nil
+}
+
+// ParseAccessLog reads the exchanges recorded in an access log using the Common or Combined Log
+// Format. The consumers are identified by the authenticated user if any, the user agent
+// otherwise. Lines that do not record a request are skipped.
+func ParseAccessLog(r io.Reader) ([]*Exchange, error) {
+	var exchanges []*Exchange
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := accessLogRegex.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		req := strings.Fields(m[3])
+		if len(req) < 2 {
+			continue
+		}
+		u, err := url.ParseRequestURI(req[1])
+		if err != nil {
+			continue
+		}
+		status, _ := strconv.Atoi(m[4])
+		consumer := m[2]
+		if consumer == "-" {
+			consumer = m[5]
+		}
+		if consumer == "" || consumer == "-" {
+			consumer = m[1]
+		}
+		exchanges = append(exchanges, &Exchange{
+			Consumer: consumer,
+			Method:   strings.ToUpper(req[0]),
+			URL:      u,
+			Header:   make(http.Header),
+			Status:   status,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return exchanges, nil
+}
+
+// LoadTraffic reads the exchanges recorded in the file at the given path. Files with the ".har"
+// extension or whose content is a JSON object are read as HAR files, other files as access logs.
+func LoadTraffic(path, consumerHeader string) ([]*Exchange, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var exchanges []*Exchange
+	if strings.EqualFold(filepath.Ext(path), ".har") || bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		exchanges, err = ParseHAR(bytes.NewReader(b), consumerHeader)
+	} else {
+		exchanges, err = ParseAccessLog(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return exchanges, nil
+}
+
+// isJSON returns true if the given content type denotes JSON content.
+func isJSON(contentType string) bool {
+	ct := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	return ct == "application/json" || strings.HasSuffix(ct, "+json")
+}
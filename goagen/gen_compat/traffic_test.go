@@ -0,0 +1,105 @@
+package gencompat_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goadesign/goa/goagen/gen_compat"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const har = `{"log": {"entries": [{
+	"request": {
+		"method": "post",
+		"url": "http://localhost/bottles?vintage=2012",
+		"headers": [{"name": "User-Agent", "value": "cellar-ui"}, {"name": "X-Client", "value": "web"}],
+		"postData": {"mimeType": "application/json", "text": "{\"name\": \"Chateau\"}"}
+	},
+	"response": {
+		"status": 201,
+		"content": {"mimeType": "application/json", "text": "eyJpZCI6IDF9", "encoding": "base64"}
+	}
+}]}}`
+
+const accessLog = `10.0.0.1 - alice [10/Oct/2026:13:55:36 -0700] "GET /bottles/1 HTTP/1.1" 200 2326
+10.0.0.2 - - [10/Oct/2026:13:55:37 -0700] "GET /bottles?page=2 HTTP/1.1" 404 12 "-" "cellar-cli/1.2"
+10.0.0.3 - - [10/Oct/2026:13:55:38 -0700] "-" 400 0
+10.0.0.3 - - [10/Oct/2026:13:55:39 -0700] "DELETE /bottles/2 HTTP/1.1" 204 0`
+
+var _ = Describe("ParseHAR", func() {
+	It("reads the requests and responses", func() {
+		exchanges, err := gencompat.ParseHAR(strings.NewReader(har), "")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(exchanges).Should(HaveLen(1))
+		ex := exchanges[0]
+		Ω(ex.Consumer).Should(Equal("cellar-ui"))
+		Ω(ex.Method).Should(Equal("POST"))
+		Ω(ex.URL.Path).Should(Equal("/bottles"))
+		Ω(ex.URL.Query().Get("vintage")).Should(Equal("2012"))
+		Ω(ex.Header.Get("Content-Type")).Should(Equal("application/json"))
+		Ω(string(ex.Body)).Should(Equal(`{"name": "Chateau"}`))
+		Ω(ex.Status).Should(Equal(201))
+		Ω(string(ex.ResponseBody)).Should(Equal(`{"id": 1}`))
+		Ω(ex.Detailed).Should(BeTrue())
+	})
+
+	It("identifies the consumers with the given header", func() {
+		exchanges, err := gencompat.ParseHAR(strings.NewReader(har), "X-Client")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(exchanges[0].Consumer).Should(Equal("web"))
+	})
+
+	It("fails on invalid content", func() {
+		_, err := gencompat.ParseHAR(strings.NewReader("{"), "")
+		Ω(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("ParseAccessLog", func() {
+	It("reads the requests", func() {
+		exchanges, err := gencompat.ParseAccessLog(strings.NewReader(accessLog))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(exchanges).Should(HaveLen(3))
+		Ω(exchanges[0].Consumer).Should(Equal("alice"))
+		Ω(exchanges[0].Method).Should(Equal("GET"))
+		Ω(exchanges[0].URL.Path).Should(Equal("/bottles/1"))
+		Ω(exchanges[0].Status).Should(Equal(200))
+		Ω(exchanges[0].Detailed).Should(BeFalse())
+		Ω(exchanges[1].Consumer).Should(Equal("cellar-cli/1.2"))
+		Ω(exchanges[1].URL.Query().Get("page")).Should(Equal("2"))
+		Ω(exchanges[2].Consumer).Should(Equal("10.0.0.3"))
+	})
+})
+
+var _ = Describe("LoadTraffic", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "compat")
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("detects the file format", func() {
+		harFile := filepath.Join(dir, "traffic.json")
+		logFile := filepath.Join(dir, "access.log")
+		Ω(ioutil.WriteFile(harFile, []byte(har), 0644)).Should(Succeed())
+		Ω(ioutil.WriteFile(logFile, []byte(accessLog), 0644)).Should(Succeed())
+
+		exchanges, err := gencompat.LoadTraffic(harFile, "")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(exchanges).Should(HaveLen(1))
+		Ω(exchanges[0].Detailed).Should(BeTrue())
+
+		exchanges, err = gencompat.LoadTraffic(logFile, "")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(exchanges).Should(HaveLen(3))
+	})
+})
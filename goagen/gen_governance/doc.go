@@ -0,0 +1,28 @@
+/*
+Package gengovernance provides a generator for API governance reports. The report scores each
+resource of the API against a set of checks meant to help platform teams review the designs of
+many services consistently:
+
+	naming:       resource, action, parameter and attribute names follow the naming convention
+	descriptions: resources, actions, parameters and payload attributes are described
+	validations:  string and numeric parameters and payload attributes are validated
+	deprecation:  deprecated actions and attributes explain what replaces them and have a sunset
+	              date that has not passed yet
+	errors:       actions document their error responses
+
+The score of a check is the percentage of the items it inspects that pass, the score of a resource
+is the weighted average of the check scores. The weights, the naming convention and the other
+parameters of the checks are read from an optional YAML rules file, see Rules. The generator
+writes a Markdown report listing the findings and a JSON scorecard that can be aggregated across
+services.
+
+Actions and attributes are marked as deprecated with the "deprecated" metadata whose value explains
+why, the sunset date is set with the "deprecated:sunset" metadata using the YYYY-MM-DD format.
+The sunset dates are checked against the current date unless the --date flag is set, use it to
+produce reproducible reports.
+
+The generator also writes a data inventory listing the attributes classified with the
+Classification DSL (e.g. "pii:email") together with the types, media types or actions that define
+them. The inventory is meant to support privacy compliance reviews.
+*/
+package gengovernance
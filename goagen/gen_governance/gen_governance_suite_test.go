@@ -0,0 +1,13 @@
+package gengovernance_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenGovernance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenGovernance Suite")
+}
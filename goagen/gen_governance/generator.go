@@ -0,0 +1,195 @@
+package gengovernance
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/goadesign/goa/version"
+)
+
+// Generator is the governance report generator.
+type Generator struct {
+	API      *design.APIDefinition // The API definition
+	OutDir   string                // Path to output directory
+	Rules    *Rules                // Scoring rules, DefaultRules() if nil
+	Date     time.Time             // Date the sunset dates are checked against, today if zero
+	genfiles []string              // Generated files
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, rulesFile, date, ver string
+
+	set := flag.NewFlagSet("governance", flag.PanicOnError)
+	set.String("design", "", "")
+	set.StringVar(&outDir, "out", "", "")
+	set.StringVar(&rulesFile, "rules", "", "")
+	set.StringVar(&date, "date", "", "")
+	set.StringVar(&ver, "version", "", "")
+	set.Parse(os.Args[1:])
+
+	if err := codegen.CheckVersion(ver); err != nil {
+		return nil, err
+	}
+
+	g := &Generator{OutDir: outDir, API: design.Design}
+	if rulesFile != "" {
+		if g.Rules, err = LoadRules(rulesFile); err != nil {
+			return nil, err
+		}
+	}
+	if date != "" {
+		if g.Date, err = time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("invalid date %#v, use the YYYY-MM-DD format", date)
+		}
+	}
+
+	return g.Generate()
+}
+
+// Generate produces the governance report, scorecard and data inventory.
+func (g *Generator) Generate() (_ []string, err error) {
+	if g.API == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	rules := g.Rules
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	if err = rules.Validate(); err != nil {
+		return nil, err
+	}
+	date := g.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	sc := Evaluate(g.API, rules, date)
+	inventory := Inventory(g.API)
+
+	outDir := filepath.Join(g.OutDir, "governance")
+	if err = os.RemoveAll(outDir); err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, outDir)
+
+	b, err := json.MarshalIndent(sc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	scorecard := filepath.Join(outDir, "scorecard.json")
+	g.genfiles = append(g.genfiles, scorecard)
+	if err = ioutil.WriteFile(scorecard, b, 0644); err != nil {
+		return nil, err
+	}
+
+	if b, err = json.MarshalIndent(inventory, "", "  "); err != nil {
+		return nil, err
+	}
+	inventoryFile := filepath.Join(outDir, "inventory.json")
+	g.genfiles = append(g.genfiles, inventoryFile)
+	if err = ioutil.WriteFile(inventoryFile, b, 0644); err != nil {
+		return nil, err
+	}
+
+	report := filepath.Join(outDir, "report.md")
+	file, err := os.Create(report)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	g.genfiles = append(g.genfiles, report)
+	data := map[string]interface{}{
+		"Scorecard":   sc,
+		"Inventory":   inventory,
+		"Rules":       rules,
+		"ToolVersion": version.String(),
+	}
+	if err = reportTmpl.Execute(file, data); err != nil {
+		return nil, err
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.RemoveAll(f)
+	}
+	g.genfiles = nil
+}
+
+// status returns the text displayed in the report for a passing or failing resource.
+func status(passing bool) string {
+	if passing {
+		return "pass"
+	}
+	return "**fail**"
+}
+
+// hasFindings returns true if any check of the resource has findings.
+func hasFindings(rs *ResourceScorecard) bool {
+	for _, c := range rs.Checks {
+		if len(c.Findings) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+var reportTmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"status":      status,
+	"hasFindings": hasFindings,
+	"title":       strings.Title,
+}).Parse(reportT))
+
+const reportT = `# {{ .Scorecard.API }} governance report
+
+Generated by goagen {{ .ToolVersion }}. Resources pass with a score of at least {{ printf "%.0f" .Rules.PassingScore }}.
+
+**Score: {{ printf "%.1f" .Scorecard.Score }}** ({{ status .Scorecard.Passing }})
+
+| Check | Weight | Passed | Score |
+|-------|--------|--------|-------|
+{{ range .Scorecard.Checks }}| {{ .Name }} | {{ .Weight }} | {{ .Passed }}/{{ .Total }} | {{ printf "%.1f" .Score }} |
+{{ end }}
+## Resources
+
+| Resource | Score | Status |
+|----------|-------|--------|
+{{ range .Scorecard.Resources }}| {{ .Name }} | {{ printf "%.1f" .Score }} | {{ status .Passing }} |
+{{ end }}{{ range .Scorecard.Resources }}{{ if hasFindings . }}
+### {{ .Name }}
+{{ range .Checks }}{{ if .Findings }}
+{{ title .Name }}:
+{{ range .Findings }}
+* {{ . }}{{ end }}
+{{ end }}{{ end }}{{ end }}{{ end }}{{ if .Inventory }}
+## Data inventory
+
+| Classification | Owner | Attribute | Description |
+|----------------|-------|-----------|-------------|
+{{ range .Inventory }}| {{ .Classification }} | {{ .Owner }} | {{ .Attribute }} | {{ .Description }} |
+{{ end }}{{ end }}`
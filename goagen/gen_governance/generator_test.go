@@ -0,0 +1,212 @@
+package gengovernance_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_governance"
+	"github.com/goadesign/goa/version"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var outDir string
+	var rules string
+	var date string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		var err error
+		outDir, err = ioutil.TempDir("", "governance")
+		Ω(err).ShouldNot(HaveOccurred())
+		rules = ""
+		date = ""
+		dslengine.Reset()
+		API("test api", func() {})
+		Resource("bottle", func() {
+			Description("A bottle of wine")
+			Action("show", func() {
+				Description("Show a bottle")
+				Routing(GET("/:id"))
+				Params(func() {
+					Param("id", Integer, "Bottle ID", func() {
+						Minimum(1)
+					})
+				})
+				Response(OK)
+				Response(NotFound, func() {
+					Description("The bottle does not exist")
+				})
+			})
+			Action("listAll", func() {
+				Routing(GET(""))
+				Response(OK)
+			})
+		})
+		dslengine.Run()
+	})
+
+	JustBeforeEach(func() {
+		os.Args = []string{"goagen", "--out=" + outDir, "--design=foo", "--version=" + version.String()}
+		if rules != "" {
+			os.Args = append(os.Args, "--rules="+rules)
+		}
+		if date != "" {
+			os.Args = append(os.Args, "--date="+date)
+		}
+		files, genErr = gengovernance.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	It("generates the report and the scorecard", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(4))
+
+		content, err := ioutil.ReadFile(filepath.Join(outDir, "governance", "report.md"))
+		Ω(err).ShouldNot(HaveOccurred())
+		report := string(content)
+		Ω(report).Should(ContainSubstring("# test api governance report"))
+		Ω(report).Should(ContainSubstring("| bottle | 83.3 | pass |"))
+		Ω(report).Should(ContainSubstring(`* action "listAll" of resource "bottle" does not follow the snake naming convention`))
+		Ω(report).Should(ContainSubstring(`* action "listAll" of resource "bottle" does not define error responses`))
+
+		content, err = ioutil.ReadFile(filepath.Join(outDir, "governance", "scorecard.json"))
+		Ω(err).ShouldNot(HaveOccurred())
+		var sc gengovernance.Scorecard
+		Ω(json.Unmarshal(content, &sc)).ShouldNot(HaveOccurred())
+		Ω(sc.API).Should(Equal("test api"))
+		Ω(sc.Passing).Should(BeTrue())
+		Ω(sc.Resources).Should(HaveLen(1))
+
+		content, err = ioutil.ReadFile(filepath.Join(outDir, "governance", "inventory.json"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(content)).Should(Equal("[]"))
+	})
+
+	Context("with classified attributes", func() {
+		BeforeEach(func() {
+			dslengine.Reset()
+			API("test api", func() {})
+			Resource("user", func() {
+				Action("create", func() {
+					Routing(POST(""))
+					Payload(func() {
+						Attribute("email", String, "User email", func() {
+							Classification("pii:email")
+						})
+					})
+					Response(NoContent)
+				})
+			})
+			dslengine.Run()
+		})
+
+		It("writes the data inventory", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "governance", "inventory.json"))
+			Ω(err).ShouldNot(HaveOccurred())
+			var inventory []*gengovernance.InventoryEntry
+			Ω(json.Unmarshal(content, &inventory)).ShouldNot(HaveOccurred())
+			Ω(inventory).Should(HaveLen(1))
+			Ω(inventory[0].Classification).Should(Equal("pii:email"))
+
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "governance", "report.md"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`| pii:email | payload of action "create" of resource "user" | email | User email |`))
+		})
+	})
+
+	Context("with a deprecated action", func() {
+		BeforeEach(func() {
+			dslengine.Reset()
+			API("test api", func() {})
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+					Metadata("deprecated", "use get instead")
+					Metadata("deprecated:sunset", "2017-06-30")
+					Response(OK)
+				})
+			})
+			dslengine.Run()
+		})
+
+		Context("checked before the sunset date", func() {
+			BeforeEach(func() {
+				date = "2017-06-01"
+			})
+
+			It("does not report the sunset", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "governance", "report.md"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).ShouldNot(ContainSubstring("past its sunset date"))
+			})
+		})
+
+		Context("checked after the sunset date", func() {
+			BeforeEach(func() {
+				date = "2017-07-01"
+			})
+
+			It("reports the sunset", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "governance", "report.md"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring("past its sunset date 2017-06-30"))
+			})
+		})
+
+		Context("with an invalid date", func() {
+			BeforeEach(func() {
+				date = "06/01/2017"
+			})
+
+			It("returns an error", func() {
+				Ω(genErr).Should(HaveOccurred())
+				Ω(files).Should(BeEmpty())
+			})
+		})
+	})
+
+	Context("with a rules file", func() {
+		BeforeEach(func() {
+			rules = filepath.Join(outDir, "rules.yaml")
+			content := "weights:\n  errors: 0\nnaming: camel\npassing_score: 90\n"
+			Ω(ioutil.WriteFile(rules, []byte(content), 0644)).ShouldNot(HaveOccurred())
+		})
+
+		It("applies the rules", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "governance", "scorecard.json"))
+			Ω(err).ShouldNot(HaveOccurred())
+			var sc gengovernance.Scorecard
+			Ω(json.Unmarshal(content, &sc)).ShouldNot(HaveOccurred())
+			Ω(sc.Resources[0].Score).Should(Equal(93.75))
+			Ω(sc.Passing).Should(BeTrue())
+			Ω(sc.Checks).Should(HaveLen(4))
+		})
+	})
+
+	Context("with an invalid rules file", func() {
+		BeforeEach(func() {
+			rules = filepath.Join(outDir, "rules.yaml")
+			Ω(ioutil.WriteFile(rules, []byte("naming: pascal\n"), 0644)).ShouldNot(HaveOccurred())
+		})
+
+		It("returns an error", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(files).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,92 @@
+package gengovernance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+)
+
+// InventoryEntry describes an attribute holding classified data, see the Classification DSL.
+type InventoryEntry struct {
+	// Classification is the attribute data classification, e.g. "pii:email".
+	Classification string `json:"classification"`
+	// Owner describes the type, media type or action params that define the attribute.
+	Owner string `json:"owner"`
+	// Attribute is the path to the attribute from its owner, e.g. "address.phone".
+	Attribute string `json:"attribute"`
+	// Description is the attribute description.
+	Description string `json:"description,omitempty"`
+}
+
+// Inventory returns the data inventory of api: the list of the classified attributes of its user
+// types, media types, action params and inline payloads sorted by classification, owner and
+// attribute.
+func Inventory(api *design.APIDefinition) []*InventoryEntry {
+	res := []*InventoryEntry{}
+	add := func(owner string, att *design.AttributeDefinition) {
+		for path, class := range att.Classifications() {
+			entry := &InventoryEntry{Classification: class, Owner: owner, Attribute: path}
+			if catt := childAttribute(att, path); catt != nil {
+				entry.Description = catt.Description
+			}
+			res = append(res, entry)
+		}
+	}
+	api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		add(fmt.Sprintf("type %s", ut.TypeName), ut.AttributeDefinition)
+		return nil
+	})
+	api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		add(fmt.Sprintf("media type %s", mt.Identifier), mt.AttributeDefinition)
+		return nil
+	})
+	api.IterateResources(func(r *design.ResourceDefinition) error {
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			ctx := fmt.Sprintf("action %#v of resource %#v", a.Name, r.Name)
+			if a.Params != nil {
+				add("params of "+ctx, a.Params)
+			}
+			if a.Payload != nil && api.Types[a.Payload.TypeName] == nil {
+				add("payload of "+ctx, a.Payload.AttributeDefinition)
+			}
+			return nil
+		})
+	})
+	sort.Sort(byClassification(res))
+	return res
+}
+
+// childAttribute returns the child attribute of att with the given path, nil if there is none.
+func childAttribute(att *design.AttributeDefinition, path string) *design.AttributeDefinition {
+	for _, n := range strings.Split(path, ".") {
+		for att.Type.IsArray() {
+			att = att.Type.ToArray().ElemType
+		}
+		o := att.Type.ToObject()
+		if o == nil {
+			return nil
+		}
+		if att = o[n]; att == nil {
+			return nil
+		}
+	}
+	return att
+}
+
+// byClassification makes it possible to sort inventory entries by classification, owner and
+// attribute.
+type byClassification []*InventoryEntry
+
+func (b byClassification) Len() int      { return len(b) }
+func (b byClassification) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byClassification) Less(i, j int) bool {
+	if b[i].Classification != b[j].Classification {
+		return b[i].Classification < b[j].Classification
+	}
+	if b[i].Owner != b[j].Owner {
+		return b[i].Owner < b[j].Owner
+	}
+	return b[i].Attribute < b[j].Attribute
+}
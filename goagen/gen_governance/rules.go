@@ -0,0 +1,117 @@
+package gengovernance
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Names of the governance checks.
+const (
+	NamingCheck       = "naming"
+	DescriptionsCheck = "descriptions"
+	ValidationsCheck  = "validations"
+	DeprecationCheck  = "deprecation"
+	ErrorsCheck       = "errors"
+)
+
+// Checks lists the names of the governance checks in the order they appear in the reports.
+var Checks = []string{NamingCheck, DescriptionsCheck, ValidationsCheck, DeprecationCheck, ErrorsCheck}
+
+// namingConventions contains the regular expressions that names must match indexed by naming
+// convention.
+var namingConventions = map[string]*regexp.Regexp{
+	"snake": regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`),
+	"camel": regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`),
+	"kebab": regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`),
+}
+
+// Rules configures the governance checks. A rules file looks like:
+//
+//	weights:
+//	  naming: 1
+//	  descriptions: 2
+//	  validations: 1
+//	  deprecation: 1
+//	  errors: 2
+//	naming: snake
+//	min_description: 10
+//	passing_score: 80
+//	require_sunset: true
+//
+type Rules struct {
+	// Weights is the weight of each check in the resource scores indexed by check name.
+	// Checks with a weight of 0 are not run, checks missing from the map have a weight of 1.
+	Weights map[string]float64 `yaml:"weights"`
+	// Naming is the naming convention of the resource, action, parameter and attribute
+	// names: "snake" (default), "camel" or "kebab".
+	Naming string `yaml:"naming"`
+	// MinDescription is the minimum length of descriptions, defaults to 1.
+	MinDescription int `yaml:"min_description"`
+	// PassingScore is the minimum score of passing resources, defaults to 80.
+	PassingScore float64 `yaml:"passing_score"`
+	// RequireSunset makes the deprecation check require a sunset date on deprecated actions
+	// and attributes.
+	RequireSunset bool `yaml:"require_sunset"`
+}
+
+// DefaultRules returns the rules used when no rules file is given.
+func DefaultRules() *Rules {
+	return &Rules{Naming: "snake", MinDescription: 1, PassingScore: 80}
+}
+
+// LoadRules reads the rules from the YAML file with the given name. The fields missing from the
+// file have their default values.
+func LoadRules(filename string) (*Rules, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	rules := DefaultRules()
+	if err := yaml.Unmarshal(b, rules); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %s", filename, err)
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %s", filename, err)
+	}
+	return rules, nil
+}
+
+// Validate checks that the rules are consistent.
+func (r *Rules) Validate() error {
+	if _, ok := namingConventions[r.Naming]; !ok {
+		return fmt.Errorf(`invalid naming convention %#v, must be one of "snake", "camel" or "kebab"`, r.Naming)
+	}
+	for name, w := range r.Weights {
+		if !isCheck(name) {
+			return fmt.Errorf("unknown check %#v", name)
+		}
+		if w < 0 {
+			return fmt.Errorf("weight of check %#v cannot be negative", name)
+		}
+	}
+	if r.PassingScore < 0 || r.PassingScore > 100 {
+		return fmt.Errorf("passing score must be between 0 and 100")
+	}
+	return nil
+}
+
+// Weight returns the weight of the check with the given name.
+func (r *Rules) Weight(check string) float64 {
+	if w, ok := r.Weights[check]; ok {
+		return w
+	}
+	return 1
+}
+
+// isCheck returns true if name is the name of a governance check.
+func isCheck(name string) bool {
+	for _, c := range Checks {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,282 @@
+package gengovernance
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/goadesign/goa/design"
+)
+
+type (
+	// Scorecard is the result of the governance checks run against an API.
+	Scorecard struct {
+		// API is the API name.
+		API string `json:"api"`
+		// Score is the weighted average of the API check scores.
+		Score float64 `json:"score"`
+		// Passing is true if the score of all the resources is at least the passing score.
+		Passing bool `json:"passing"`
+		// Checks contains the results of the checks aggregated over all the resources.
+		Checks []*CheckResult `json:"checks"`
+		// Resources contains the scorecards of the API resources sorted by name.
+		Resources []*ResourceScorecard `json:"resources"`
+	}
+
+	// ResourceScorecard is the result of the governance checks run against a resource.
+	ResourceScorecard struct {
+		// Name is the resource name.
+		Name string `json:"name"`
+		// Score is the weighted average of the resource check scores.
+		Score float64 `json:"score"`
+		// Passing is true if the score is at least the passing score.
+		Passing bool `json:"passing"`
+		// Checks contains the results of the checks.
+		Checks []*CheckResult `json:"checks"`
+	}
+
+	// CheckResult is the result of a governance check.
+	CheckResult struct {
+		// Name is the check name.
+		Name string `json:"name"`
+		// Weight is the check weight.
+		Weight float64 `json:"weight"`
+		// Passed is the number of items that pass the check.
+		Passed int `json:"passed"`
+		// Total is the number of items inspected by the check.
+		Total int `json:"total"`
+		// Score is the percentage of items that pass the check, 100 if there is none.
+		Score float64 `json:"score"`
+		// Findings describes the items that do not pass the check.
+		Findings []string `json:"findings,omitempty"`
+	}
+
+	// evaluator runs the checks against a resource.
+	evaluator struct {
+		api     *design.APIDefinition
+		rules   *Rules
+		now     time.Time
+		results map[string]*CheckResult
+	}
+)
+
+// Evaluate runs the governance checks against api using rules and returns the resulting
+// scorecard. now is used to detect deprecated actions and attributes past their sunset date.
+func Evaluate(api *design.APIDefinition, rules *Rules, now time.Time) *Scorecard {
+	sc := &Scorecard{API: api.Name, Passing: true}
+	totals := newEvaluator(api, rules, now)
+	api.IterateResources(func(r *design.ResourceDefinition) error {
+		e := newEvaluator(api, rules, now)
+		e.resource(r)
+		rs := &ResourceScorecard{Name: r.Name, Checks: e.checks()}
+		rs.Score = score(rs.Checks)
+		rs.Passing = rs.Score >= rules.PassingScore
+		if !rs.Passing {
+			sc.Passing = false
+		}
+		sc.Resources = append(sc.Resources, rs)
+		for _, c := range rs.Checks {
+			t := totals.results[c.Name]
+			t.Passed += c.Passed
+			t.Total += c.Total
+			t.Findings = append(t.Findings, c.Findings...)
+		}
+		return nil
+	})
+	sort.Sort(byName(sc.Resources))
+	sc.Checks = totals.checks()
+	sc.Score = score(sc.Checks)
+	return sc
+}
+
+// newEvaluator creates an evaluator for the checks enabled by rules.
+func newEvaluator(api *design.APIDefinition, rules *Rules, now time.Time) *evaluator {
+	results := make(map[string]*CheckResult)
+	for _, c := range Checks {
+		if w := rules.Weight(c); w > 0 {
+			results[c] = &CheckResult{Name: c, Weight: w}
+		}
+	}
+	return &evaluator{api: api, rules: rules, now: now, results: results}
+}
+
+// resource runs the checks against r and its actions.
+func (e *evaluator) resource(r *design.ResourceDefinition) {
+	e.name(r.Name, "resource %#v", r.Name)
+	e.description(r.Description, "resource %#v", r.Name)
+	if mt := e.api.MediaTypeWithIdentifier(r.MediaType); mt != nil && mt.Type.IsObject() {
+		for _, n := range sortedKeys(mt.Type.ToObject()) {
+			e.name(n, "attribute %#v of media type %s", n, mt.Identifier)
+		}
+	}
+	r.IterateActions(func(a *design.ActionDefinition) error {
+		e.action(a)
+		return nil
+	})
+}
+
+// action runs the checks against a.
+func (e *evaluator) action(a *design.ActionDefinition) {
+	ctx := fmt.Sprintf("action %#v of resource %#v", a.Name, a.Parent.Name)
+	e.name(a.Name, "%s", ctx)
+	e.description(a.Description, "%s", ctx)
+	e.deprecation(a.Metadata, "%s", ctx)
+	if a.Params != nil {
+		params := a.Params.Type.ToObject()
+		for _, n := range sortedKeys(params) {
+			e.attribute(n, params[n], "param %#v of %s", n, ctx)
+		}
+	}
+	if a.Payload != nil && a.Payload.IsObject() {
+		atts := a.Payload.ToObject()
+		for _, n := range sortedKeys(atts) {
+			e.attribute(n, atts[n], "payload attribute %#v of %s", n, ctx)
+		}
+	}
+	var errs []*design.ResponseDefinition
+	a.IterateResponses(func(r *design.ResponseDefinition) error {
+		if r.Status >= 400 {
+			errs = append(errs, r)
+		}
+		return nil
+	})
+	e.record(ErrorsCheck, len(errs) > 0, "%s does not define error responses", ctx)
+	for _, r := range errs {
+		documented := r.Description != "" && r.Description != http.StatusText(r.Status)
+		e.record(ErrorsCheck, documented, "response %#v of %s does not describe when it occurs", r.Name, ctx)
+	}
+}
+
+// attribute runs the checks against the parameter or payload attribute att.
+func (e *evaluator) attribute(name string, att *design.AttributeDefinition, format string, args ...interface{}) {
+	e.name(name, format, args...)
+	e.description(att.Description, format, args...)
+	e.deprecation(att.Metadata, format, args...)
+	if !needsValidation(att) {
+		return
+	}
+	e.record(ValidationsCheck, isValidated(att), format+" is not validated", args...)
+}
+
+// name checks that name follows the naming convention.
+func (e *evaluator) name(name string, format string, args ...interface{}) {
+	ok := namingConventions[e.rules.Naming].MatchString(name)
+	e.record(NamingCheck, ok, format+" does not follow the %s naming convention", append(args, e.rules.Naming)...)
+}
+
+// description checks that desc is long enough.
+func (e *evaluator) description(desc string, format string, args ...interface{}) {
+	min := e.rules.MinDescription
+	if min < 1 {
+		min = 1
+	}
+	if desc == "" {
+		e.record(DescriptionsCheck, false, format+" has no description", args...)
+		return
+	}
+	e.record(DescriptionsCheck, len(desc) >= min, format+" description is shorter than %d characters", append(args, min)...)
+}
+
+// deprecation checks that the deprecated element with the given metadata explains why and has a
+// sunset date that has not passed.
+func (e *evaluator) deprecation(md map[string][]string, format string, args ...interface{}) {
+	reason, ok := md["deprecated"]
+	if !ok {
+		return
+	}
+	if len(reason) == 0 || reason[0] == "" {
+		e.record(DeprecationCheck, false, format+" is deprecated without explanation", args...)
+		return
+	}
+	sunset, ok := md["deprecated:sunset"]
+	if !ok || len(sunset) == 0 {
+		e.record(DeprecationCheck, !e.rules.RequireSunset, format+" is deprecated without sunset date", args...)
+		return
+	}
+	t, err := time.Parse("2006-01-02", sunset[0])
+	if err != nil {
+		e.record(DeprecationCheck, false, format+" has an invalid sunset date %#v", append(args, sunset[0])...)
+		return
+	}
+	e.record(DeprecationCheck, e.now.Before(t), format+" is past its sunset date %s", append(args, sunset[0])...)
+}
+
+// record records the outcome of a check for an item, it does nothing if the check is disabled.
+func (e *evaluator) record(check string, ok bool, format string, args ...interface{}) {
+	res, enabled := e.results[check]
+	if !enabled {
+		return
+	}
+	res.Total++
+	if ok {
+		res.Passed++
+		return
+	}
+	res.Findings = append(res.Findings, fmt.Sprintf(format, args...))
+}
+
+// checks returns the results of the enabled checks with their score computed.
+func (e *evaluator) checks() []*CheckResult {
+	var res []*CheckResult
+	for _, c := range Checks {
+		if r, ok := e.results[c]; ok {
+			r.Score = 100
+			if r.Total > 0 {
+				r.Score = 100 * float64(r.Passed) / float64(r.Total)
+			}
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// score returns the weighted average of the scores of checks.
+func score(checks []*CheckResult) float64 {
+	var sum, weights float64
+	for _, c := range checks {
+		sum += c.Weight * c.Score
+		weights += c.Weight
+	}
+	if weights == 0 {
+		return 100
+	}
+	return sum / weights
+}
+
+// needsValidation returns true if att is a string, integer or number or an array.
+func needsValidation(att *design.AttributeDefinition) bool {
+	switch att.Type.Kind() {
+	case design.StringKind, design.IntegerKind, design.NumberKind, design.ArrayKind:
+		return true
+	}
+	return false
+}
+
+// isValidated returns true if att has validations that restrict its values.
+func isValidated(att *design.AttributeDefinition) bool {
+	v := att.Validation
+	if v == nil {
+		return false
+	}
+	return len(v.Values) > 0 || v.Format != "" || v.Pattern != "" || v.Minimum != nil ||
+		v.Maximum != nil || v.MinLength != nil || v.MaxLength != nil || v.MaxRunes != nil ||
+		v.MaxGraphemes != nil
+}
+
+// sortedKeys returns the names of the attributes of o sorted alphabetically.
+func sortedKeys(o design.Object) []string {
+	keys := make([]string, 0, len(o))
+	for n := range o {
+		keys = append(keys, n)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// byName makes it possible to sort resource scorecards by name.
+type byName []*ResourceScorecard
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
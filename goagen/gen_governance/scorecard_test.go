@@ -0,0 +1,160 @@
+package gengovernance_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_governance"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Evaluate", func() {
+	var rules *gengovernance.Rules
+	var sc *gengovernance.Scorecard
+
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		rules = gengovernance.DefaultRules()
+		dslengine.Reset()
+		API("test api", func() {})
+		Resource("bottle", func() {
+			Description("A bottle of wine")
+			Action("create", func() {
+				Description("Create a bottle")
+				Routing(POST(""))
+				Payload(func() {
+					Member("name", String, "Bottle name", func() {
+						MinLength(1)
+					})
+					Member("vintage", Integer, "Bottle vintage")
+					Member("color", String, "Wine color", func() {
+						Metadata("deprecated", "use the variety attribute instead")
+						Metadata("deprecated:sunset", "2016-12-31")
+						Enum("red", "white")
+					})
+				})
+				Response(Created)
+				Response(BadRequest)
+			})
+			Action("rate", func() {
+				Description("Rate a bottle")
+				Routing(PUT("/:id/rating"))
+				Metadata("deprecated", "use the review action instead")
+				Params(func() {
+					Param("id", Integer, "Bottle ID", func() {
+						Minimum(1)
+					})
+				})
+				Response(NoContent)
+				Response(NotFound, func() {
+					Description("The bottle does not exist")
+				})
+			})
+		})
+		Resource("account", func() {
+			Description("An account")
+			Action("show", func() {
+				Description("Show an account")
+				Routing(GET("/accounts"))
+				Response(OK)
+				Response(NotFound, func() {
+					Description("The account does not exist")
+				})
+			})
+		})
+		dslengine.Run()
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		sc = gengovernance.Evaluate(Design, rules, now)
+	})
+
+	It("scores each resource", func() {
+		Ω(sc.Resources).Should(HaveLen(2))
+		Ω(sc.Resources[0].Name).Should(Equal("account"))
+		Ω(sc.Resources[0].Score).Should(Equal(100.0))
+		Ω(sc.Resources[1].Name).Should(Equal("bottle"))
+		Ω(sc.Resources[1].Passing).Should(BeTrue())
+	})
+
+	It("reports the attributes that are not validated", func() {
+		check := sc.Resources[1].Checks[2]
+		Ω(check.Name).Should(Equal(gengovernance.ValidationsCheck))
+		Ω(check.Passed).Should(Equal(3))
+		Ω(check.Total).Should(Equal(4))
+		Ω(check.Findings).Should(ConsistOf(`payload attribute "vintage" of action "create" of resource "bottle" is not validated`))
+	})
+
+	It("reports the deprecated attributes past their sunset date", func() {
+		check := sc.Resources[1].Checks[3]
+		Ω(check.Name).Should(Equal(gengovernance.DeprecationCheck))
+		Ω(check.Passed).Should(Equal(1))
+		Ω(check.Total).Should(Equal(2))
+		Ω(check.Findings).Should(ConsistOf(`payload attribute "color" of action "create" of resource "bottle" is past its sunset date 2016-12-31`))
+	})
+
+	It("reports the error responses that are not described", func() {
+		check := sc.Resources[1].Checks[4]
+		Ω(check.Name).Should(Equal(gengovernance.ErrorsCheck))
+		Ω(check.Findings).Should(ConsistOf(`response "BadRequest" of action "create" of resource "bottle" does not describe when it occurs`))
+	})
+
+	It("aggregates the checks over the resources", func() {
+		Ω(sc.Checks).Should(HaveLen(5))
+		Ω(sc.Checks[4].Passed).Should(Equal(5))
+		Ω(sc.Checks[4].Total).Should(Equal(6))
+	})
+
+	Context("with a sunset date required", func() {
+		BeforeEach(func() {
+			rules.RequireSunset = true
+		})
+
+		It("reports the deprecated actions with no sunset date", func() {
+			Ω(sc.Resources[1].Checks[3].Passed).Should(Equal(0))
+			Ω(sc.Resources[1].Checks[3].Findings).Should(ContainElement(`action "rate" of resource "bottle" is deprecated without sunset date`))
+		})
+	})
+
+	Context("with a minimum description length", func() {
+		BeforeEach(func() {
+			rules.MinDescription = 12
+		})
+
+		It("reports the short descriptions", func() {
+			Ω(sc.Resources[0].Checks[1].Findings).Should(ConsistOf(`resource "account" description is shorter than 12 characters`))
+		})
+	})
+
+	Context("with a disabled check", func() {
+		BeforeEach(func() {
+			rules.Weights = map[string]float64{gengovernance.ErrorsCheck: 0}
+		})
+
+		It("does not run it", func() {
+			Ω(sc.Checks).Should(HaveLen(4))
+			for _, c := range sc.Checks {
+				Ω(c.Name).ShouldNot(Equal(gengovernance.ErrorsCheck))
+			}
+		})
+	})
+})
+
+var _ = Describe("Rules", func() {
+	It("rejects unknown checks", func() {
+		rules := gengovernance.DefaultRules()
+		rules.Weights = map[string]float64{"style": 1}
+		Ω(rules.Validate()).Should(HaveOccurred())
+	})
+
+	It("rejects unknown naming conventions", func() {
+		rules := gengovernance.DefaultRules()
+		rules.Naming = "pascal"
+		Ω(rules.Validate()).Should(HaveOccurred())
+	})
+})
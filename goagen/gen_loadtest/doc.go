@@ -0,0 +1,11 @@
+/*
+Package genloadtest provides a generator for load test scenarios. The generator produces either a
+k6 (https://k6.io) script or a vegeta (https://github.com/tsenart/vegeta) targets file together with
+a script that runs the attack. There is one scenario per action, the request paths, query strings
+and bodies are built from the examples generated for the action parameters and payload.
+
+The relative frequency of each scenario is set with the "loadtest:weight" action metadata. The
+credentials required by the action security schemes are read from environment variables whose
+names are derived from the scheme names, the generated files list the variables that must be set.
+*/
+package genloadtest
@@ -0,0 +1,13 @@
+package genloadtest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenLoadtest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenLoadtest Suite")
+}
@@ -0,0 +1,566 @@
+package genloadtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/goadesign/goa/version"
+)
+
+// Generator is the load test scenarios generator.
+type Generator struct {
+	API      *design.APIDefinition // The API definition
+	OutDir   string                // Path to output directory
+	Tool     string                // Load testing tool, one of "k6" or "vegeta"
+	Scheme   string                // Default scheme used to build the API base URL
+	Host     string                // Default host used to build the API base URL
+	genfiles []string              // Generated files
+}
+
+type (
+	// scenario describes the request made to exercise a single action.
+	scenario struct {
+		// Name identifies the scenario, it is of the form "resource#action".
+		Name string
+		// Action is the action exercised by the scenario.
+		Action *design.ActionDefinition
+		// Method is the request HTTP method.
+		Method string
+		// Path is the request path including the query string.
+		Path string
+		// Body is the JSON encoded request body if any.
+		Body string
+		// Weight is the relative frequency of the scenario.
+		Weight int
+		// Auth is the scheme used to authenticate the request if any.
+		Auth *scheme
+	}
+
+	// scheme describes how to set the credentials of a security scheme on requests.
+	scheme struct {
+		*design.SecuritySchemeDefinition
+		// Env is the prefix of the environment variables holding the credentials.
+		Env string
+	}
+)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, tool, scheme, host, ver string
+
+	set := flag.NewFlagSet("loadtest", flag.PanicOnError)
+	set.String("design", "", "")
+	set.StringVar(&outDir, "out", "", "")
+	set.StringVar(&tool, "tool", "k6", "")
+	set.StringVar(&scheme, "scheme", "", "")
+	set.StringVar(&host, "host", "", "")
+	set.StringVar(&ver, "version", "", "")
+	set.Parse(os.Args[1:])
+
+	if err := codegen.CheckVersion(ver); err != nil {
+		return nil, err
+	}
+
+	g := &Generator{OutDir: outDir, Tool: tool, Scheme: scheme, Host: host, API: design.Design}
+
+	return g.Generate()
+}
+
+// Generate produces the load test scenarios.
+func (g *Generator) Generate() (_ []string, err error) {
+	if g.API == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	if err = g.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	scenarios, schemes := g.scenarios()
+	if len(scenarios) == 0 {
+		return nil, nil
+	}
+
+	outDir := filepath.Join(g.OutDir, "loadtest")
+	if err = os.RemoveAll(outDir); err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, outDir)
+
+	data := map[string]interface{}{
+		"API":         g.API,
+		"BaseURL":     g.Scheme + "://" + g.Host,
+		"Scenarios":   scenarios,
+		"Schemes":     schemes,
+		"ToolVersion": version.String(),
+	}
+	funcs := template.FuncMap{
+		"js":         jsString,
+		"k6Auth":     k6Auth,
+		"envVars":    envVars,
+		"vegetaURL":  vegetaURL,
+		"vegetaAuth": vegetaAuth,
+		"bodyFile":   bodyFile,
+		"repeat":     repeat,
+		"isBasic":    func(s *scheme) bool { return s.Kind == design.BasicAuthSecurityKind },
+	}
+
+	if g.Tool == "k6" {
+		err = g.generate(filepath.Join(outDir, "script.js"), k6T, funcs, data)
+		return g.genfiles, err
+	}
+
+	bodiesDir := filepath.Join(outDir, "bodies")
+	if err = os.MkdirAll(bodiesDir, 0755); err != nil {
+		return nil, err
+	}
+	for _, s := range scenarios {
+		if s.Body == "" {
+			continue
+		}
+		body := filepath.Join(outDir, bodyFile(s))
+		g.genfiles = append(g.genfiles, body)
+		var buf bytes.Buffer
+		if err = json.Indent(&buf, []byte(s.Body), "", "  "); err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(body, buf.Bytes(), 0644); err != nil {
+			return nil, err
+		}
+	}
+	if err = g.generate(filepath.Join(outDir, "targets.txt"), vegetaTargetsT, funcs, data); err != nil {
+		return
+	}
+	attack := filepath.Join(outDir, "attack.sh")
+	if err = g.generate(attack, vegetaAttackT, funcs, data); err != nil {
+		return
+	}
+	if err = os.Chmod(attack, 0755); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// setDefaults validates the load testing tool and sets the default values of the generator
+// fields.
+func (g *Generator) setDefaults() error {
+	if g.Tool == "" {
+		g.Tool = "k6"
+	}
+	if g.Tool != "k6" && g.Tool != "vegeta" {
+		return fmt.Errorf(`invalid load testing tool %#v, must be one of "k6" or "vegeta"`, g.Tool)
+	}
+	if g.Scheme == "" && len(g.API.Schemes) > 0 {
+		g.Scheme = g.API.Schemes[0]
+	}
+	if g.Scheme == "" {
+		g.Scheme = "http"
+	}
+	if g.Host == "" {
+		g.Host = g.API.Host
+	}
+	if g.Host == "" {
+		g.Host = "localhost:8080"
+	}
+	return nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.RemoveAll(f)
+	}
+	g.genfiles = nil
+}
+
+// generate renders the given template into the file with the given name.
+func (g *Generator) generate(filename, tmpl string, funcs template.FuncMap, data interface{}) error {
+	t, err := template.New(filepath.Base(filename)).Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		panic(err) // bug
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	g.genfiles = append(g.genfiles, filename)
+	return t.Execute(file, data)
+}
+
+// scenarios builds the scenarios for all the API actions sorted by name together with the
+// security schemes they use.
+func (g *Generator) scenarios() ([]*scenario, []*scheme) {
+	var scenarios []*scenario
+	schemes := make(map[string]*scheme)
+	rand := g.API.RandomGenerator()
+	g.API.IterateResources(func(r *design.ResourceDefinition) error {
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			weight := 1
+			if w, ok := a.Metadata["loadtest:weight"]; ok && len(w) > 0 {
+				if n, err := strconv.Atoi(w[0]); err == nil && n >= 0 {
+					weight = n
+				}
+			}
+			if weight == 0 || len(a.Routes) == 0 {
+				return nil
+			}
+			route := a.Routes[0]
+			s := &scenario{
+				Name:   r.Name + "#" + a.Name,
+				Action: a,
+				Method: route.Verb,
+				Path:   examplePath(a, route, rand),
+				Weight: weight,
+			}
+			if a.Payload != nil {
+				if ex := a.Payload.GenerateExample(rand, nil); ex != nil {
+					if b, err := json.Marshal(toStringMap(ex)); err == nil {
+						s.Body = string(b)
+					}
+				}
+			}
+			if a.Security != nil && a.Security.Scheme != nil {
+				name := a.Security.Scheme.SchemeName
+				sch, ok := schemes[name]
+				if !ok {
+					sch = &scheme{
+						SecuritySchemeDefinition: a.Security.Scheme,
+						Env:                      strings.ToUpper(codegen.SnakeCase(codegen.Goify(name, true))),
+					}
+					schemes[name] = sch
+				}
+				s.Auth = sch
+			}
+			scenarios = append(scenarios, s)
+			return nil
+		})
+	})
+	sort.Sort(byName(scenarios))
+
+	names := make([]string, 0, len(schemes))
+	for n := range schemes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	sorted := make([]*scheme, len(names))
+	for i, n := range names {
+		sorted[i] = schemes[n]
+	}
+	return scenarios, sorted
+}
+
+// examplePath returns the path of the given action route where the path parameters are replaced
+// with example values and the required query string parameters are set.
+func examplePath(a *design.ActionDefinition, route *design.RouteDefinition, rand *design.RandomGenerator) string {
+	params := a.AllParams().Type.ToObject()
+	path := route.FullPath()
+	pathParams := route.Params()
+	if len(pathParams) > 0 {
+		values := make([]interface{}, len(pathParams))
+		for i, n := range pathParams {
+			var ex interface{}
+			if att, ok := params[n]; ok {
+				ex = att.GenerateExample(rand, nil)
+			}
+			values[i] = (&url.URL{Path: fmt.Sprintf("%v", ex)}).String()
+		}
+		format := design.WildcardRegex.ReplaceAllLiteralString(path, "/%v")
+		path = fmt.Sprintf(format, values...)
+	}
+	if a.QueryParams == nil {
+		return path
+	}
+	query := url.Values{}
+	for n, att := range a.QueryParams.Type.ToObject() {
+		if !a.QueryParams.IsRequired(n) {
+			continue
+		}
+		ex := att.GenerateExample(rand, nil)
+		if ex == nil {
+			continue
+		}
+		if att.Type.IsArray() {
+			for _, e := range toSlice(ex) {
+				query.Add(n, fmt.Sprintf("%v", e))
+			}
+			continue
+		}
+		query.Set(n, fmt.Sprintf("%v", ex))
+	}
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+// k6Auth returns the JavaScript code that sets the credentials of the given scheme on the k6
+// request object "req".
+func k6Auth(s *scheme) string {
+	if param, env, ok := queryAuth(s); ok {
+		return fmt.Sprintf(`req.url += (req.url.indexOf("?") < 0 ? "?" : "&") + %s + "=" + encodeURIComponent(__ENV.%s);`, jsString(param), env)
+	}
+	switch s.Kind {
+	case design.BasicAuthSecurityKind:
+		return fmt.Sprintf(`req.headers["Authorization"] = "Basic " + encoding.b64encode(__ENV.%s_USERNAME + ":" + __ENV.%s_PASSWORD);`, s.Env, s.Env)
+	case design.APIKeySecurityKind:
+		return fmt.Sprintf(`req.headers[%s] = __ENV.%s_KEY;`, jsString(s.Name), s.Env)
+	default:
+		return fmt.Sprintf(`req.headers[%s] = "Bearer " + __ENV.%s_TOKEN;`, jsString(tokenHeader(s)), s.Env)
+	}
+}
+
+// jsString returns the JavaScript string literal for s. Go quoted strings are not valid JavaScript
+// when s contains non-printable characters (e.g. "\a" or "\U0001f600") so s is JSON encoded instead.
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// vegetaURL returns the URL of the vegeta target for the given scenario.
+func vegetaURL(s *scenario) string {
+	u := "${BASE_URL}" + s.Path
+	if s.Auth == nil {
+		return u
+	}
+	if param, env, ok := queryAuth(s.Auth); ok {
+		sep := "?"
+		if strings.Contains(u, "?") {
+			sep = "&"
+		}
+		u += fmt.Sprintf("%s%s=${%s}", sep, url.QueryEscape(param), env)
+	}
+	return u
+}
+
+// vegetaAuth returns the vegeta target header that sets the credentials of the given scheme, it
+// returns the empty string if the credentials are set in the query string.
+func vegetaAuth(s *scheme) string {
+	if _, _, ok := queryAuth(s); ok {
+		return ""
+	}
+	switch s.Kind {
+	case design.BasicAuthSecurityKind:
+		return fmt.Sprintf("Authorization: Basic ${%s_CREDENTIALS}", s.Env)
+	case design.APIKeySecurityKind:
+		return fmt.Sprintf("%s: ${%s_KEY}", s.Name, s.Env)
+	default:
+		return fmt.Sprintf("%s: Bearer ${%s_TOKEN}", tokenHeader(s), s.Env)
+	}
+}
+
+// queryAuth returns the name of the query string parameter and of the environment variable
+// holding the credentials for API key and JWT schemes that read the credentials from the query
+// string. ok is false for all other schemes.
+func queryAuth(s *scheme) (param, env string, ok bool) {
+	if s.In != "query" || s.Name == "" {
+		return "", "", false
+	}
+	switch s.Kind {
+	case design.APIKeySecurityKind:
+		return s.Name, s.Env + "_KEY", true
+	case design.JWTSecurityKind:
+		return s.Name, s.Env + "_TOKEN", true
+	}
+	return "", "", false
+}
+
+// envVars returns the names of the environment variables that hold the credentials of the given
+// scheme.
+func envVars(s *scheme) []string {
+	switch s.Kind {
+	case design.BasicAuthSecurityKind:
+		return []string{s.Env + "_USERNAME", s.Env + "_PASSWORD"}
+	case design.APIKeySecurityKind:
+		return []string{s.Env + "_KEY"}
+	default:
+		return []string{s.Env + "_TOKEN"}
+	}
+}
+
+// tokenHeader returns the name of the header that carries the bearer token of JWT and OAuth2
+// schemes.
+func tokenHeader(s *scheme) string {
+	if s.Kind == design.JWTSecurityKind && s.In == "header" && s.Name != "" {
+		return s.Name
+	}
+	return "Authorization"
+}
+
+// bodyFile returns the path to the file containing the request body of the given scenario
+// relative to the output directory.
+func bodyFile(s *scenario) string {
+	return fmt.Sprintf("bodies/%s_%s.json", codegen.SnakeCase(s.Action.Parent.Name), codegen.SnakeCase(s.Action.Name))
+}
+
+// repeat returns a slice of n elements so that templates can range over it.
+func repeat(n int) []struct{} {
+	return make([]struct{}, n)
+}
+
+// toSlice returns the elements of the given array example.
+func toSlice(val interface{}) []interface{} {
+	if s, ok := val.([]interface{}); ok {
+		return s
+	}
+	var res []interface{}
+	b, err := json.Marshal(val)
+	if err == nil {
+		json.Unmarshal(b, &res)
+	}
+	return res
+}
+
+// toStringMap converts map[interface{}]interface{} to a map[string]interface{} when possible.
+func toStringMap(val interface{}) interface{} {
+	switch actual := val.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for k, v := range actual {
+			m[fmt.Sprintf("%v", k)] = toStringMap(v)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for k, v := range actual {
+			m[k] = toStringMap(v)
+		}
+		return m
+	case []interface{}:
+		mapSlice := make([]interface{}, len(actual))
+		for i, e := range actual {
+			mapSlice[i] = toStringMap(e)
+		}
+		return mapSlice
+	default:
+		return actual
+	}
+}
+
+// byName makes it possible to sort scenarios by name.
+type byName []*scenario
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+
+const k6T = `// {{ .API.Name }} load test scenarios generated by goagen {{ .ToolVersion }}.
+//
+// Run with:
+//
+//    k6 run -e BASE_URL={{ .BaseURL }} script.js
+//
+// The number of virtual users and the test duration can be set with the VUS and DURATION
+// environment variables.{{ if .Schemes }} The credentials are read from the following environment
+// variables:
+//{{ range .Schemes }}{{ $scheme := . }}{{ range envVars . }}
+//    {{ . }}: {{ $scheme.SchemeName }} credentials{{ end }}{{ end }}{{ end }}
+import http from "k6/http";
+import encoding from "k6/encoding";
+import { check } from "k6";
+
+const baseURL = __ENV.BASE_URL || {{ js .BaseURL }};
+
+export let options = {
+  vus: Number(__ENV.VUS || 10),
+  duration: __ENV.DURATION || "30s",
+};
+
+const scenarios = [
+{{ range .Scenarios }}  {
+    name: {{ js .Name }},
+    weight: {{ .Weight }},
+    method: {{ js .Method }},
+    path: {{ js .Path }},
+    body: {{ if .Body }}{{ .Body }}{{ else }}null{{ end }},
+    auth: {{ if .Auth }}{{ js .Auth.SchemeName }}{{ else }}null{{ end }},
+  },
+{{ end }}];
+
+const totalWeight = scenarios.reduce(function (sum, s) { return sum + s.weight; }, 0);
+
+// pick returns a random scenario taking into account the scenario weights.
+function pick() {
+  let n = Math.random() * totalWeight;
+  for (let i = 0; i < scenarios.length; i++) {
+    n -= scenarios[i].weight;
+    if (n < 0) {
+      return scenarios[i];
+    }
+  }
+  return scenarios[scenarios.length - 1];
+}
+
+// authenticate sets the credentials required by the given security scheme on the request.
+function authenticate(scheme, req) {
+  switch (scheme) {
+{{ range .Schemes }}  case {{ js .SchemeName }}:
+    {{ k6Auth . }}
+    break;
+{{ end }}  }
+}
+
+export default function () {
+  const s = pick();
+  const req = { url: baseURL + s.path, headers: { "Content-Type": "application/json" } };
+  if (s.auth) {
+    authenticate(s.auth, req);
+  }
+  const body = s.body === null ? null : JSON.stringify(s.body);
+  const res = http.request(s.method, req.url, body, { headers: req.headers, tags: { name: s.name } });
+  check(res, { "no server error": function (r) { return r.status < 500; } });
+}
+`
+
+const vegetaTargetsT = `{{ range .Scenarios }}{{ $s := . }}{{ range repeat .Weight }}{{ $s.Method }} {{ vegetaURL $s }}
+{{ if $s.Body }}Content-Type: application/json
+{{ end }}{{ if $s.Auth }}{{ with vegetaAuth $s.Auth }}{{ . }}
+{{ end }}{{ end }}{{ if $s.Body }}@{{ bodyFile $s }}
+{{ end }}
+{{ end }}{{ end }}`
+
+const vegetaAttackT = `#!/bin/sh
+#
+# {{ .API.Name }} load test generated by goagen {{ .ToolVersion }}.
+# Requires vegeta (https://github.com/tsenart/vegeta) and envsubst.
+#
+# The following environment variables are used:
+#
+#    BASE_URL: URL of the API, defaults to {{ .BaseURL }}
+#    RATE: number of requests per second, defaults to 50
+#    DURATION: duration of the test, defaults to 30s
+{{ range .Schemes }}{{ $scheme := . }}{{ range envVars . }}#    {{ . }}: {{ $scheme.SchemeName }} credentials
+{{ end }}{{ end }}
+set -e
+cd "$(dirname "$0")"
+
+export BASE_URL="${BASE_URL:-{{ .BaseURL }}}"
+{{ range .Schemes }}{{ if isBasic . }}export {{ .Env }}_CREDENTIALS="$(printf '%s:%s' "${{ .Env }}_USERNAME" "${{ .Env }}_PASSWORD" | base64)"
+{{ end }}{{ end }}
+envsubst < targets.txt | vegeta attack -rate="${RATE:-50}" -duration="${DURATION:-30s}" | vegeta report
+`
@@ -0,0 +1,175 @@
+package genloadtest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_loadtest"
+	"github.com/goadesign/goa/version"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var outDir string
+	var tool string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		var err error
+		outDir, err = ioutil.TempDir("", "loadtest")
+		Ω(err).ShouldNot(HaveOccurred())
+		tool = "k6"
+		dslengine.Reset()
+		API("test api", func() {
+			Host("example.com")
+			Scheme("https")
+			JWTSecurity("jwt", func() {
+				Header("Authorization")
+			})
+			BasicAuthSecurity("basic")
+			APIKeySecurity("key", func() {
+				Query("api_key")
+			})
+		})
+		Resource("bottle", func() {
+			BasePath("/bottles")
+			Security("jwt")
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Params(func() {
+					Param("id", Integer, func() {
+						Example(42)
+					})
+				})
+				Response(OK)
+			})
+			Action("list", func() {
+				Routing(GET(""))
+				Security("key")
+				Params(func() {
+					Param("limit", Integer, func() {
+						Example(10)
+					})
+					Required("limit")
+				})
+				Response(OK)
+			})
+			Action("create", func() {
+				Routing(POST(""))
+				Security("basic")
+				Metadata("loadtest:weight", "3")
+				Payload(func() {
+					Member("name", String, func() {
+						Example("Sweet Wine")
+					})
+					Required("name")
+				})
+				Response(Created)
+			})
+			Action("delete", func() {
+				Routing(DELETE("/:id"))
+				Metadata("loadtest:weight", "0")
+				Response(NoContent)
+			})
+		})
+		dslengine.Run()
+	})
+
+	JustBeforeEach(func() {
+		os.Args = []string{"goagen", "--out=" + outDir, "--design=foo", "--tool=" + tool, "--version=" + version.String()}
+		files, genErr = genloadtest.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	It("generates a k6 script", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(2))
+
+		content, err := ioutil.ReadFile(filepath.Join(outDir, "loadtest", "script.js"))
+		Ω(err).ShouldNot(HaveOccurred())
+		script := string(content)
+		Ω(script).Should(ContainSubstring(`const baseURL = __ENV.BASE_URL || "https://example.com";`))
+		Ω(script).Should(ContainSubstring(`name: "bottle#show",`))
+		Ω(script).Should(ContainSubstring(`path: "/bottles/42",`))
+		Ω(script).Should(ContainSubstring(`path: "/bottles?limit=10",`))
+		Ω(script).Should(ContainSubstring(`weight: 3,`))
+		Ω(script).Should(ContainSubstring(`body: {"name":"Sweet Wine"},`))
+		Ω(script).Should(ContainSubstring(`req.headers["Authorization"] = "Bearer " + __ENV.JWT_TOKEN;`))
+		Ω(script).Should(ContainSubstring(`encoding.b64encode(__ENV.BASIC_USERNAME + ":" + __ENV.BASIC_PASSWORD)`))
+		Ω(script).Should(ContainSubstring(`"api_key" + "=" + encodeURIComponent(__ENV.KEY_KEY)`))
+		Ω(script).ShouldNot(ContainSubstring("bottle#delete"))
+	})
+
+	Context("with names containing control characters", func() {
+		BeforeEach(func() {
+			dslengine.Reset()
+			API("test api", func() {
+				Host("example.com")
+				Scheme("https")
+			})
+			Resource("bottle", func() {
+				Action("show\a", func() {
+					Routing(GET("/:id"))
+					Response(OK)
+				})
+			})
+			dslengine.Run()
+		})
+
+		It("generates valid JavaScript string literals", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "loadtest", "script.js"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`name: "bottle#show\u0007",`))
+		})
+	})
+
+	Context("with the vegeta tool", func() {
+		BeforeEach(func() {
+			tool = "vegeta"
+		})
+
+		It("generates the targets, bodies and attack script", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(HaveLen(4))
+
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "loadtest", "targets.txt"))
+			Ω(err).ShouldNot(HaveOccurred())
+			targets := string(content)
+			Ω(strings.Count(targets, "POST ${BASE_URL}/bottles\n")).Should(Equal(3))
+			Ω(targets).Should(ContainSubstring("GET ${BASE_URL}/bottles/42\nAuthorization: Bearer ${JWT_TOKEN}\n"))
+			Ω(targets).Should(ContainSubstring("GET ${BASE_URL}/bottles?limit=10&api_key=${KEY_KEY}\n"))
+			Ω(targets).Should(ContainSubstring("Authorization: Basic ${BASIC_CREDENTIALS}\n@bodies/bottle_create.json\n"))
+
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "loadtest", "bodies", "bottle_create.json"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`"name": "Sweet Wine"`))
+
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "loadtest", "attack.sh"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`export BASIC_CREDENTIALS="$(printf '%s:%s' "$BASIC_USERNAME" "$BASIC_PASSWORD" | base64)"`))
+			Ω(string(content)).Should(ContainSubstring("vegeta attack"))
+		})
+	})
+
+	Context("with an unknown tool", func() {
+		BeforeEach(func() {
+			tool = "ab"
+		})
+
+		It("returns an error", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(files).Should(BeEmpty())
+		})
+	})
+})
@@ -5,5 +5,8 @@ bootstrap new applications.
 The generator creates a main.go file and one file per resource listed in the API metadata.
 If a file already exists it skips its creation unless the flag --force is provided on the command
 line in which case it overrides the content of existing files.
+The profiling endpoints, the Swagger specification file server and the echo controllers are
+mounted by the generated debug.go file which is only compiled when the binary is built with the
+"debug" build tag (configurable with --debug-tag) so that production binaries exclude them.
 */
 package genmain
@@ -22,14 +22,15 @@ type Generator struct {
 	DesignPkg string                // Path to design package, only used to mark generated files.
 	Target    string                // Name of generated "app" package
 	Force     bool                  // Whether to override existing files
+	DebugTag  string                // Build tag enabling the optional debug endpoints
 	genfiles  []string              // Generated files
 }
 
 // Generate is the generator entry point called by the meta generator.
 func Generate() (files []string, err error) {
 	var (
-		outDir, designPkg, target, ver string
-		force                          bool
+		outDir, designPkg, target, ver, debugTag string
+		force                                    bool
 	)
 
 	set := flag.NewFlagSet("main", flag.PanicOnError)
@@ -38,6 +39,7 @@ func Generate() (files []string, err error) {
 	set.StringVar(&target, "pkg", "app", "")
 	set.StringVar(&ver, "version", "", "")
 	set.BoolVar(&force, "force", false, "")
+	set.StringVar(&debugTag, "debug-tag", "debug", "")
 	set.Parse(os.Args[1:])
 
 	if err := codegen.CheckVersion(ver); err != nil {
@@ -45,7 +47,7 @@ func Generate() (files []string, err error) {
 	}
 
 	target = codegen.Goify(target, false)
-	g := &Generator{OutDir: outDir, DesignPkg: designPkg, Target: target, Force: force, API: design.Design}
+	g := &Generator{OutDir: outDir, DesignPkg: designPkg, Target: target, Force: force, DebugTag: debugTag, API: design.Design}
 
 	return g.Generate()
 }
@@ -63,6 +65,9 @@ func (g *Generator) Generate() (_ []string, err error) {
 	if g.Target == "" {
 		g.Target = "app"
 	}
+	if g.DebugTag == "" {
+		g.DebugTag = "debug"
+	}
 
 	codegen.Reserved[g.Target] = true
 
@@ -74,6 +79,8 @@ func (g *Generator) Generate() (_ []string, err error) {
 		"tempvar":   tempvar,
 		"okResp":    g.okResp,
 		"targetPkg": func() string { return g.Target },
+		"debugTag":  func() string { return g.DebugTag },
+		"optional":  isOptional,
 	}
 	imp, err := codegen.PackagePath(g.OutDir)
 	if err != nil {
@@ -86,11 +93,19 @@ func (g *Generator) Generate() (_ []string, err error) {
 			return nil, err
 		}
 	}
+	if err = g.createDebugFiles(funcs); err != nil {
+		return nil, err
+	}
 	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("encoding/json"),
 		codegen.SimpleImport("io"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport(imp),
 		codegen.SimpleImport("golang.org/x/net/websocket"),
+		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.SimpleImport("github.com/goadesign/goa/healthcheck"),
+		codegen.SimpleImport("github.com/goadesign/goa/middleware/security/apikey"),
+		codegen.SimpleImport("time"),
 	}
 	err = g.API.IterateResources(func(r *design.ResourceDefinition) error {
 		filename := filepath.Join(g.OutDir, codegen.SnakeCase(r.Name)+".go")
@@ -105,19 +120,26 @@ func (g *Generator) Generate() (_ []string, err error) {
 			if err2 != nil {
 				return err
 			}
+			if isOptional(r) {
+				file.Write([]byte("// +build " + g.DebugTag + "\n\n"))
+			}
 			file.WriteHeader("", "main", imports)
-			if err2 = file.ExecuteTemplate("controller", ctrlT, funcs, r); err2 != nil {
+			name, tmpl := controllerTemplate(r)
+			if err2 = file.ExecuteTemplate(name, tmpl, funcs, r); err2 != nil {
 				return err
 			}
 			err2 = r.IterateActions(func(a *design.ActionDefinition) error {
-				if a.WebSocket() {
-					return file.ExecuteTemplate("actionWS", actionWST, funcs, a)
-				}
-				return file.ExecuteTemplate("action", actionT, funcs, a)
+				name, tmpl := g.actionTemplate(r, a)
+				return file.ExecuteTemplate(name, tmpl, funcs, a)
 			})
 			if err2 != nil {
 				return err
 			}
+			for _, u := range r.Uploads {
+				if err2 = file.ExecuteTemplate("upload", uploadT, funcs, u); err2 != nil {
+					return err
+				}
+			}
 			if err2 = file.FormatCode(); err2 != nil {
 				return err2
 			}
@@ -131,6 +153,45 @@ func (g *Generator) Generate() (_ []string, err error) {
 	return g.genfiles, nil
 }
 
+// controllerTemplate returns the name and content of the template used to generate the
+// controller of the given resource.
+func controllerTemplate(r *design.ResourceDefinition) (string, string) {
+	if _, ok := r.Metadata["goa:data_subject"]; ok {
+		return "controllerDataSubject", ctrlDataSubjectT
+	}
+	if _, ok := r.Metadata["goa:api_keys"]; ok {
+		return "controllerAPIKeys", ctrlAPIKeysT
+	}
+	if _, ok := r.Metadata["goa:batch"]; ok {
+		return "controllerBatch", ctrlBatchT
+	}
+	return "controller", ctrlT
+}
+
+// actionTemplate returns the name and content of the template used to generate the
+// implementation of the given action.
+func (g *Generator) actionTemplate(r *design.ResourceDefinition, a *design.ActionDefinition) (string, string) {
+	if a.WebSocket() {
+		return "actionWS", actionWST
+	}
+	if _, ok := r.Metadata["goa:data_subject"]; ok {
+		return "actionDataSubject", actionDataSubjectT
+	}
+	if _, ok := r.Metadata["goa:api_keys"]; ok {
+		return "actionAPIKeys", actionAPIKeysT
+	}
+	if _, ok := r.Metadata["goa:batch"]; ok {
+		return "actionBatch", actionBatchT
+	}
+	if _, ok := r.Metadata["goa:healthcheck"]; ok {
+		return "actionHealthCheck", actionHealthCheckT
+	}
+	if _, ok := r.Metadata["goa:echo"]; ok && g.okResp(a) != nil {
+		return "actionEcho", actionEchoT
+	}
+	return "action", actionT
+}
+
 // Cleanup removes all the files generated by this generator during the last invokation of Generate.
 func (g *Generator) Cleanup() {
 	for _, f := range g.genfiles {
@@ -170,6 +231,7 @@ func (g *Generator) createMainFile(mainFile string, funcs template.FuncMap) erro
 	}
 	appPkg := path.Join(outPkg, "app")
 	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("os"),
 		codegen.SimpleImport("time"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("github.com/goadesign/goa/middleware"),
@@ -187,6 +249,72 @@ func (g *Generator) createMainFile(mainFile string, funcs template.FuncMap) erro
 	return file.FormatCode()
 }
 
+// createDebugFiles generates the files defining the mountDebug function called by main. The
+// function mounts the profiling endpoints, the Swagger specification file server and the optional
+// controllers when the binary is built with the debug tag and does nothing otherwise, this keeps
+// these endpoints out of production binaries.
+func (g *Generator) createDebugFiles(funcs template.FuncMap) error {
+	outPkg, err := codegen.PackagePath(g.OutDir)
+	if err != nil {
+		return err
+	}
+	appPkg := path.Join(outPkg, "app")
+	files := []struct {
+		Name, Constraint, Tmpl string
+		Imports                []*codegen.ImportSpec
+	}{
+		{
+			Name:       "debug.go",
+			Constraint: g.DebugTag,
+			Tmpl:       debugT,
+			Imports: []*codegen.ImportSpec{
+				codegen.SimpleImport("net/http"),
+				codegen.SimpleImport("net/http/pprof"),
+				codegen.SimpleImport("net/url"),
+				codegen.SimpleImport("github.com/goadesign/goa"),
+				codegen.SimpleImport(appPkg),
+			},
+		},
+		{
+			Name:       "nodebug.go",
+			Constraint: "!" + g.DebugTag,
+			Tmpl:       nodebugT,
+			Imports:    []*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa")},
+		},
+	}
+	for _, f := range files {
+		filename := filepath.Join(g.OutDir, f.Name)
+		if g.Force {
+			os.Remove(filename)
+		}
+		if _, err := os.Stat(filename); err == nil {
+			continue
+		}
+		g.genfiles = append(g.genfiles, filename)
+		file, err := codegen.SourceFileFor(filename)
+		if err != nil {
+			return err
+		}
+		file.Write([]byte("// +build " + f.Constraint + "\n\n"))
+		file.WriteHeader("", "main", f.Imports)
+		data := map[string]interface{}{"API": g.API}
+		if err = file.ExecuteTemplate("debug", f.Tmpl, funcs, data); err != nil {
+			return err
+		}
+		if err = file.FormatCode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isOptional returns true if the controller of the given resource should only be mounted in debug
+// builds. This is the case of the echo resources which are only meant for development.
+func isOptional(r *design.ResourceDefinition) bool {
+	_, ok := r.Metadata["goa:echo"]
+	return ok
+}
+
 func (g *Generator) okResp(a *design.ActionDefinition) map[string]interface{} {
 	var ok *design.ResponseDefinition
 	for _, resp := range a.Responses {
@@ -243,18 +371,63 @@ func main() {
 	service.Use(middleware.ErrorHandler(service, true))
 	service.Use(middleware.Recover())
 {{ $api := .API }}
-{{ range $name, $res := $api.Resources }}{{ $name := goify $res.Name true }} // Mount "{{$res.Name}}" controller
+{{ range $name, $res := $api.Resources }}{{ if not (optional $res) }}{{ $name := goify $res.Name true }} // Mount "{{$res.Name}}" controller
 	{{ $tmp := tempvar }}{{ $tmp }} := New{{ $name }}Controller(service)
 	{{ targetPkg }}.Mount{{ $name }}Controller(service, {{ $tmp }})
-{{ end }}
+{{ end }}{{ end }}
+	// Mount debug endpoints, only built with the "{{ debugTag }}" build tag
+	mountDebug(service)
 
-	// Start service
-	if err := service.ListenAndServe(":{{ getPort .API.Host }}"); err != nil {
-		service.LogError("startup", "err", err)
+	// Print the mounted routes and exit when run with the "routes" subcommand
+	if len(os.Args) > 1 && os.Args[1] == "routes" {
+		service.PrintRoutes(os.Stdout)
+		return
+	}
+
+	// Start service, drain the in-flight requests for up to 30 seconds on SIGINT or SIGTERM
+	if err := service.ListenAndServeGraceful(":{{ getPort .API.Host }}", 30*time.Second); err != nil {
+		service.LogError("serve", "err", err)
 	}
 }
 `
 
+const debugT = `
+// mountDebug mounts the endpoints that should not be exposed by production binaries: the
+// profiling endpoints, the Swagger specification and the echo controllers. This file is only
+// compiled when the "{{ debugTag }}" build tag is set, e.g.:
+//
+//	go build -tags {{ debugTag }}
+func mountDebug(service *goa.Service) {
+{{ range $name, $res := .API.Resources }}{{ if optional $res }}{{ $name := goify $res.Name true }}	// Mount "{{$res.Name}}" controller
+	{{ $tmp := tempvar }}{{ $tmp }} := New{{ $name }}Controller(service)
+	{{ targetPkg }}.Mount{{ $name }}Controller(service, {{ $tmp }})
+
+{{ end }}{{ end }}	// Mount profiling endpoints
+	service.Mux.Handle("GET", "/debug/pprof/*name", func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		switch params.Get("name") {
+		case "cmdline":
+			pprof.Cmdline(rw, req)
+		case "profile":
+			pprof.Profile(rw, req)
+		case "symbol":
+			pprof.Symbol(rw, req)
+		case "trace":
+			pprof.Trace(rw, req)
+		default:
+			pprof.Index(rw, req)
+		}
+	})
+
+	// Serve Swagger specification generated by "goagen swagger"
+	service.ServeFiles("/swagger.json", "swagger/swagger.json")
+}
+`
+
+const nodebugT = `
+// mountDebug does nothing in builds that do not set the "{{ debugTag }}" build tag, see debug.go.
+func mountDebug(service *goa.Service) {}
+`
+
 const ctrlT = `// {{ $ctrlName := printf "%s%s" (goify .Name true) "Controller" }}{{ $ctrlName }} implements the {{ .Name }} resource.
 type {{ $ctrlName }} struct {
 	*goa.Controller
@@ -278,6 +451,226 @@ func (c *{{ $ctrlName }}) {{ goify .Name true }}(ctx *{{ targetPkg }}.{{ goify .
 }
 `
 
+const uploadT = `{{ $ctrlName := printf "%s%s" (goify .Parent.Name true) "Controller" }}{{ $store := printf "%s%s" (goify .Name true) (or (and .Presigned "BlobStore") "UploadStore") }}// {{ $store }} returns the store backing the {{ .Name }} {{ if .Presigned }}presigned{{ else }}resumable{{ end }} upload.
+func (c *{{ $ctrlName }}) {{ $store }}() goa.{{ if .Presigned }}BlobStore{{ else }}UploadStore{{ end }} {
+	// {{ $ctrlName }}_{{ $store }}: start_implement
+
+	// Put your logic here
+
+	// {{ $ctrlName }}_{{ $store }}: end_implement
+	return nil
+}
+`
+
+const actionEchoT = `{{ $ctrlName := printf "%s%s" (goify .Parent.Name true) "Controller" }}// {{ goify .Name true }} responds with the request as seen by goa.
+func (c *{{ $ctrlName }}) {{ goify .Name true }}(ctx *{{ targetPkg }}.{{ goify .Name true }}{{ goify .Parent.Name true }}Context) error {
+{{ $ok := okResp . }}	res := {{ $ok.TypeRef }}{
+		Method:  ctx.Request.Method,
+		Path:    ctx.Request.URL.Path,
+		Params:  ctx.Params,
+		Headers: ctx.Request.Header,
+		Payload: ctx.Payload,
+	}
+	return ctx.{{ $ok.Name }}(res)
+}
+`
+
+const ctrlDataSubjectT = `// {{ $ctrlName := printf "%s%s" (goify .Name true) "Controller" }}{{ $ctrlName }} implements the {{ .Name }} resource.
+type {{ $ctrlName }} struct {
+	*goa.Controller
+	requests *goa.DataSubjectService
+}
+
+// New{{ $ctrlName }} creates a {{ .Name }} controller.
+func New{{ $ctrlName }}(service *goa.Service) *{{ $ctrlName }} {
+	return &{{ $ctrlName }}{
+		Controller: service.NewController("{{ $ctrlName }}"),
+		requests:   goa.NewDataSubjectService(goa.NewMemoryDataRequestStore(), &{{ goify .Name false }}Handler{}),
+	}
+}
+
+// {{ goify .Name false }}Handler exports and deletes the data of the subjects of the {{ .Name }}
+// requests. The methods run in the background once the requests have been accepted.
+type {{ goify .Name false }}Handler struct{}
+
+// Export exports the data of the given subject and returns the URL it can be downloaded from.
+func (h *{{ goify .Name false }}Handler) Export(ctx context.Context, subject string) (string, error) {
+	// {{ goify .Name false }}Handler_Export: start_implement
+
+	// Put your logic here
+
+	// {{ goify .Name false }}Handler_Export: end_implement
+	return "", nil
+}
+
+// Delete deletes the data of the given subject.
+func (h *{{ goify .Name false }}Handler) Delete(ctx context.Context, subject string) error {
+	// {{ goify .Name false }}Handler_Delete: start_implement
+
+	// Put your logic here
+
+	// {{ goify .Name false }}Handler_Delete: end_implement
+	return nil
+}
+
+// {{ goify .Name false }}Media builds the response media type of the {{ .Name }} actions.
+func {{ goify .Name false }}Media(req *goa.DataRequest) *{{ targetPkg }}.GoaDataRequest {
+	res := &{{ targetPkg }}.GoaDataRequest{
+		ID:          req.ID,
+		Kind:        req.Kind,
+		Status:      req.Status,
+		CreatedAt:   req.CreatedAt,
+		CompletedAt: req.CompletedAt,
+	}
+	if req.DownloadURL != "" {
+		res.DownloadURL = &req.DownloadURL
+	}
+	if req.Error != "" {
+		res.Error = &req.Error
+	}
+	return res
+}
+`
+
+const actionDataSubjectT = `{{ $ctrlName := printf "%s%s" (goify .Parent.Name true) "Controller" }}// {{ goify .Name true }} runs the {{ .Name }} action.
+func (c *{{ $ctrlName }}) {{ goify .Name true }}(ctx *{{ targetPkg }}.{{ goify .Name true }}{{ goify .Parent.Name true }}Context) error {
+{{ if eq .Name "show" }}	req, err := c.requests.Request(ctx, ctx.RequestID)
+	if err != nil {
+		return err
+	}
+	return ctx.OK({{ goify .Parent.Name false }}Media(req))
+{{ else }}	req, err := c.requests.Submit(ctx, goa.Data{{ goify .Name true }})
+	if err != nil {
+		return err
+	}
+	return ctx.Accepted({{ goify .Parent.Name false }}Media(req))
+{{ end }}}
+`
+
+const ctrlAPIKeysT = `// {{ $ctrlName := printf "%s%s" (goify .Name true) "Controller" }}{{ $ctrlName }} implements the {{ .Name }} resource.
+type {{ $ctrlName }} struct {
+	*goa.Controller
+	keys *apikey.Manager
+}
+
+// {{ goify .Name false }}Store stores the API keys. Mount the API key middleware with the same store
+// so that the keys authenticate the requests, e.g.:
+//
+//	{{ targetPkg }}.UseAPIKeyMiddleware(service, apikey.New({{ goify .Name false }}Store, {{ targetPkg }}.NewAPIKeySecurity()))
+var {{ goify .Name false }}Store apikey.KeyStore = apikey.NewMemoryKeyStore()
+
+// New{{ $ctrlName }} creates a {{ .Name }} controller.
+func New{{ $ctrlName }}(service *goa.Service) *{{ $ctrlName }} {
+	return &{{ $ctrlName }}{
+		Controller: service.NewController("{{ $ctrlName }}"),
+		keys:       apikey.NewManager({{ goify .Name false }}Store),
+	}
+}
+
+// {{ goify .Name false }}Media builds the response media type of the {{ .Name }} actions.
+func {{ goify .Name false }}Media(key *apikey.Key) *{{ targetPkg }}.GoaAPIKey {
+	return &{{ targetPkg }}.GoaAPIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+		ExpiresAt: key.ExpiresAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+`
+const actionAPIKeysT = `{{ $ctrlName := printf "%s%s" (goify .Parent.Name true) "Controller" }}// {{ goify .Name true }} runs the {{ .Name }} action.
+func (c *{{ $ctrlName }}) {{ goify .Name true }}(ctx *{{ targetPkg }}.{{ goify .Name true }}{{ goify .Parent.Name true }}Context) error {
+{{ if eq .Name "create" }}	var ttl time.Duration
+	if ctx.Payload.ExpiresIn != nil {
+		ttl = time.Duration(*ctx.Payload.ExpiresIn) * time.Second
+	}
+	key, value, err := c.keys.Create(ctx, ctx.Payload.Name, ctx.Payload.Scopes, ttl)
+	if err != nil {
+		return err
+	}
+	return ctx.Created(&{{ targetPkg }}.GoaCreatedAPIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+		ExpiresAt: key.ExpiresAt,
+		Key:       value,
+	})
+{{ else if eq .Name "list" }}	keys, err := c.keys.List(ctx)
+	if err != nil {
+		return err
+	}
+	res := make({{ targetPkg }}.GoaAPIKeyCollection, len(keys))
+	for i, key := range keys {
+		res[i] = {{ goify .Parent.Name false }}Media(key)
+	}
+	return ctx.OK(res)
+{{ else }}	if err := c.keys.Revoke(ctx, ctx.KeyID); err != nil {
+		return err
+	}
+	return ctx.NoContent()
+{{ end }}}
+`
+const actionHealthCheckT = `{{ $ctrlName := printf "%s%s" (goify .Parent.Name true) "Controller" }}// {{ goify .Name true }} runs the health checks registered with the healthcheck package.
+func (c *{{ $ctrlName }}) {{ goify .Name true }}(ctx *{{ targetPkg }}.{{ goify .Name true }}{{ goify .Parent.Name true }}Context) error {
+	return healthcheck.Handler()(ctx, ctx.ResponseData, ctx.Request)
+}
+`
+
+const ctrlBatchT = `// {{ $ctrlName := printf "%s%s" (goify .Name true) "Controller" }}{{ $ctrlName }} implements the {{ .Name }} resource.
+type {{ $ctrlName }} struct {
+	*goa.Controller
+	dispatcher *goa.BatchDispatcher
+}
+
+// New{{ $ctrlName }} creates a {{ .Name }} controller.
+func New{{ $ctrlName }}(service *goa.Service) *{{ $ctrlName }} {
+	return &{{ $ctrlName }}{
+		Controller: service.NewController("{{ $ctrlName }}"),
+		dispatcher: goa.NewBatchDispatcher(service{{ range (index .Metadata "goa:batch") }}, {{ printf "%q" . }}{{ end }}),
+	}
+}
+`
+
+const actionBatchT = `{{ $ctrlName := printf "%s%s" (goify .Parent.Name true) "Controller" }}// {{ goify .Name true }} dispatches the sub-requests to the controllers mounted on the service.
+func (c *{{ $ctrlName }}) {{ goify .Name true }}(ctx *{{ targetPkg }}.{{ goify .Name true }}{{ goify .Parent.Name true }}Context) error {
+	reqs := make([]*goa.BatchRequest, len(ctx.Payload))
+	for i, p := range ctx.Payload {
+		req := &goa.BatchRequest{Method: p.Method, Path: p.Path, Headers: p.Headers}
+		if p.ID != nil {
+			req.ID = *p.ID
+		}
+		if p.Body != nil {
+			body, err := json.Marshal(*p.Body)
+			if err != nil {
+				return goa.ErrBadRequest(err)
+			}
+			req.Body = body
+		}
+		reqs[i] = req
+	}
+	resps := c.dispatcher.Dispatch(ctx, reqs)
+	res := make({{ targetPkg }}.GoaBatchResponseCollection, len(resps))
+	for i, r := range resps {
+		resp := &{{ targetPkg }}.GoaBatchResponse{Status: r.Status, Headers: r.Headers}
+		if r.ID != "" {
+			resp.ID = &r.ID
+		}
+		if len(r.Body) > 0 {
+			body := interface{}(r.Body)
+			resp.Body = &body
+		}
+		if r.Error != nil {
+			e := interface{}(r.Error)
+			resp.Error = &e
+		}
+		res[i] = resp
+	}
+	return ctx.OK(res)
+}
+`
+
 const actionWST = `{{ $ctrlName := printf "%s%s" (goify .Parent.Name true) "Controller" }}// {{ goify .Name true }} runs the {{ .Name }} action.
 func (c *{{ $ctrlName }}) {{ goify .Name true }}(ctx *{{ targetPkg }}.{{ goify .Name true }}{{ goify .Parent.Name true }}Context) error {
 	c.{{ goify .Name true }}WSHandler(ctx).ServeHTTP(ctx.ResponseWriter, ctx.Request)
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
 	"github.com/goadesign/goa/goagen/gen_main"
 	"github.com/goadesign/goa/version"
 	. "github.com/onsi/ginkgo"
@@ -48,12 +50,87 @@ var _ = Describe("Generate", func() {
 
 		It("generates a dummy app", func() {
 			Ω(genErr).Should(BeNil())
-			Ω(files).Should(HaveLen(1))
+			Ω(files).Should(HaveLen(3))
 			content, err := ioutil.ReadFile(filepath.Join(outDir, "main.go"))
 			Ω(err).ShouldNot(HaveOccurred())
 			Ω(len(strings.Split(string(content), "\n"))).Should(BeNumerically(">=", 16))
+			Ω(string(content)).Should(ContainSubstring(`service.PrintRoutes(os.Stdout)`))
 			_, err = gexec.Build(testgenPackagePath)
 			Ω(err).ShouldNot(HaveOccurred())
 		})
 	})
+
+	Context("with an echo resource", func() {
+		BeforeEach(func() {
+			// Other tests replace design.Design, restore the registered root.
+			roots, err := dslengine.SortRoots()
+			Ω(err).ShouldNot(HaveOccurred())
+			for _, r := range roots {
+				if api, ok := r.(*design.APIDefinition); ok {
+					design.Design = api
+				}
+			}
+			dslengine.Reset()
+			apidsl.API("test api", func() {})
+			apidsl.Echo("/debug/echo")
+			dslengine.Run()
+		})
+
+		It("generates the echo controller implementation", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "echo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(MatchRegexp(`res := &app_*\.GoaEcho{`))
+			Ω(string(content)).Should(ContainSubstring("Headers: ctx.Request.Header,"))
+			Ω(string(content)).Should(ContainSubstring("return ctx.OK(res)"))
+		})
+
+		It("only mounts the echo controller in debug builds", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "echo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("// +build debug\n"))
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).ShouldNot(ContainSubstring("NewEchoController"))
+			Ω(string(content)).Should(ContainSubstring("mountDebug(service)"))
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "debug.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("// +build debug\n"))
+			Ω(string(content)).Should(ContainSubstring("NewEchoController(service)"))
+			Ω(string(content)).Should(ContainSubstring(`"/debug/pprof/*name"`))
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "nodebug.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("// +build !debug\n"))
+			Ω(string(content)).Should(ContainSubstring("func mountDebug(service *goa.Service) {}"))
+		})
+	})
+
+	Context("with an API key management resource", func() {
+		BeforeEach(func() {
+			// Other tests replace design.Design, restore the registered root.
+			roots, err := dslengine.SortRoots()
+			Ω(err).ShouldNot(HaveOccurred())
+			for _, r := range roots {
+				if api, ok := r.(*design.APIDefinition); ok {
+					design.Design = api
+				}
+			}
+			dslengine.Reset()
+			apidsl.API("test api", func() {})
+			apidsl.APIKeyManagement("/me/keys")
+			dslengine.Run()
+		})
+
+		It("generates the controller implementation on top of the key store", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "api_keys.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("var apiKeysStore apikey.KeyStore = apikey.NewMemoryKeyStore()"))
+			Ω(string(content)).Should(ContainSubstring("keys:       apikey.NewManager(apiKeysStore),"))
+			Ω(string(content)).Should(ContainSubstring("c.keys.Create(ctx, ctx.Payload.Name, ctx.Payload.Scopes, ttl)"))
+			Ω(string(content)).Should(ContainSubstring("c.keys.Revoke(ctx, ctx.KeyID)"))
+			Ω(string(content)).Should(ContainSubstring("return ctx.NoContent()"))
+		})
+	})
 })
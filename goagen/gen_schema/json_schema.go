@@ -48,6 +48,12 @@ type (
 
 		// Union
 		AnyOf []*JSONSchema `json:"anyOf,omitempty"`
+		OneOf []*JSONSchema `json:"oneOf,omitempty"`
+
+		// Extensions
+		Classification string `json:"x-classification,omitempty"`
+		// ExtensibleEnum lists the known values of an open enum, see the OpenEnum DSL.
+		ExtensibleEnum []interface{} `json:"x-extensible-enum,omitempty"`
 	}
 
 	// JSONType is the JSON type enum.
@@ -333,6 +339,7 @@ func (s *JSONSchema) createMergeItems(other *JSONSchema) mergeItems {
 		{&s.ReadOnly, other.ReadOnly, s.ReadOnly == false},
 		{&s.PathStart, other.PathStart, s.PathStart == ""},
 		{&s.Enum, other.Enum, s.Enum == nil},
+		{&s.ExtensibleEnum, other.ExtensibleEnum, s.ExtensibleEnum == nil},
 		{&s.Format, other.Format, s.Format == ""},
 		{&s.Pattern, other.Pattern, s.Pattern == ""},
 		{&s.AdditionalProperties, other.AdditionalProperties, s.AdditionalProperties == false},
@@ -407,6 +414,7 @@ func (s *JSONSchema) Dup() *JSONSchema {
 		Links:                s.Links,
 		Ref:                  s.Ref,
 		Enum:                 s.Enum,
+		ExtensibleEnum:       s.ExtensibleEnum,
 		Format:               s.Format,
 		Pattern:              s.Pattern,
 		Minimum:              s.Minimum,
@@ -443,12 +451,17 @@ func buildAttributeSchema(api *design.APIDefinition, s *JSONSchema, at *design.A
 	}
 	s.DefaultValue = toStringMap(at.DefaultValue)
 	s.Description = at.Description
+	s.Classification = at.Classification
 	s.Example = at.GenerateExample(api.RandomGenerator(), nil)
 	val := at.Validation
 	if val == nil {
 		return s
 	}
-	s.Enum = val.Values
+	if val.OpenEnum {
+		s.ExtensibleEnum = val.Values
+	} else {
+		s.Enum = val.Values
+	}
 	s.Format = val.Format
 	s.Pattern = val.Pattern
 	if val.Minimum != nil {
@@ -46,6 +46,46 @@ var _ = Describe("TypeSchema", func() {
 		})
 	})
 
+	Context("with a classified attribute", func() {
+		BeforeEach(func() {
+			Type("user", func() {
+				Attribute("email", func() {
+					Classification("pii:email")
+				})
+			})
+
+			Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+			typ = design.Design.Types["user"].Type
+		})
+
+		It("sets the x-classification extension", func() {
+			Ω(s).ShouldNot(BeNil())
+			Ω(s.Properties).Should(HaveKey("email"))
+			Ω(s.Properties["email"].Classification).Should(Equal("pii:email"))
+		})
+	})
+
+	Context("with an open enum", func() {
+		BeforeEach(func() {
+			Type("bottle", func() {
+				Attribute("color", design.String, func() {
+					Enum("red", "green")
+					OpenEnum()
+				})
+			})
+
+			Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+			typ = design.Design.Types["bottle"].Type
+		})
+
+		It("sets the x-extensible-enum extension instead of enum", func() {
+			Ω(s).ShouldNot(BeNil())
+			Ω(s.Properties).Should(HaveKey("color"))
+			Ω(s.Properties["color"].Enum).Should(BeNil())
+			Ω(s.Properties["color"].ExtensibleEnum).Should(Equal([]interface{}{"red", "green"}))
+		})
+	})
+
 	Context("with a media type with self-referencing attributes", func() {
 		BeforeEach(func() {
 			MediaType("application/vnd.menu+json", func() {
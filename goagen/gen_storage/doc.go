@@ -0,0 +1,8 @@
+/*
+Package genstorage provides a generator for database integration scaffolding. The generator
+produces model structs and repository interfaces for the media types that define a Storage in the
+design together with functions that convert the models to and from the corresponding media types
+and payloads. The generated structs use "db" and "gorm" struct tags so that they can be used with
+both sqlc and GORM.
+*/
+package genstorage
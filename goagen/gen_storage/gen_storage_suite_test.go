@@ -0,0 +1,13 @@
+package genstorage_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenStorage(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenStorage Suite")
+}
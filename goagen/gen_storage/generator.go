@@ -0,0 +1,425 @@
+package genstorage
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+)
+
+// Generator is the storage scaffolding code generator.
+type Generator struct {
+	API      *design.APIDefinition // The API definition
+	OutDir   string                // Path to output directory
+	Target   string                // Name of generated package
+	AppPkg   string                // Name of generated "app" package
+	genfiles []string              // Generated files
+}
+
+type (
+	// model describes a database model generated from a media type.
+	model struct {
+		// Name is the name of the model Go struct.
+		Name string
+		// Table is the name of the database table.
+		Table string
+		// MediaType is the media type the model is generated from.
+		MediaType *design.MediaTypeDefinition
+		// Fields lists the model fields sorted by attribute name.
+		Fields []*field
+		// PrimaryKeys lists the fields that make up the primary key.
+		PrimaryKeys []*field
+		// Payloads lists the payloads of the actions of the resources that use the media type.
+		Payloads []*payload
+	}
+
+	// field describes a single model field.
+	field struct {
+		// Attribute is the name of the media type attribute.
+		Attribute string
+		// Name is the name of the Go struct field.
+		Name string
+		// Column is the name of the database column.
+		Column string
+		// Type is the Go type of the field.
+		Type string
+		// Pointer is true if the model field is a pointer.
+		Pointer bool
+		// InMedia is true if the attribute is rendered by the media type default view.
+		InMedia bool
+		// MediaPointer is true if the media type struct field is a pointer.
+		MediaPointer bool
+		// PK is true if the field is part of the primary key.
+		PK bool
+	}
+
+	// payload describes an action payload that can be converted to and from a model.
+	payload struct {
+		// Name is the name of the payload Go type.
+		Name string
+		// Action is the action using the payload.
+		Action *design.ActionDefinition
+		// Fields lists the payload fields matching a model field.
+		Fields []*payloadField
+	}
+
+	// payloadField associates a payload field with a model field.
+	payloadField struct {
+		// Field is the model field.
+		Field *field
+		// Pointer is true if the payload struct field is a pointer.
+		Pointer bool
+	}
+)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, target, appPkg, ver string
+
+	set := flag.NewFlagSet("storage", flag.PanicOnError)
+	set.String("design", "", "")
+	set.StringVar(&outDir, "out", "", "")
+	set.StringVar(&target, "pkg", "storage", "")
+	set.StringVar(&appPkg, "app-pkg", "app", "")
+	set.StringVar(&ver, "version", "", "")
+	set.Parse(os.Args[1:])
+
+	if err := codegen.CheckVersion(ver); err != nil {
+		return nil, err
+	}
+
+	target = codegen.Goify(target, false)
+	g := &Generator{OutDir: outDir, Target: target, AppPkg: appPkg, API: design.Design}
+
+	return g.Generate()
+}
+
+// Generate produces the storage models and repository interfaces.
+func (g *Generator) Generate() (_ []string, err error) {
+	if g.API == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	if g.Target == "" {
+		g.Target = "storage"
+	}
+	if g.AppPkg == "" {
+		g.AppPkg = "app"
+	}
+
+	models := g.models()
+	if len(models) == 0 {
+		return nil, nil
+	}
+
+	outPkg, err := codegen.PackagePath(g.OutDir)
+	if err != nil {
+		return nil, err
+	}
+	appPkg := path.Join(filepath.ToSlash(outPkg), g.AppPkg)
+
+	pkgDir := filepath.Join(g.OutDir, g.Target)
+	os.RemoveAll(pkgDir)
+	if err = os.MkdirAll(pkgDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, pkgDir)
+
+	funcs := template.FuncMap{
+		"appPkg": func() string { return path.Base(g.AppPkg) },
+		"assign": assign,
+	}
+
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("time"),
+		codegen.NewImport("uuid", "github.com/satori/go.uuid"),
+		codegen.SimpleImport(appPkg),
+	}
+	modelsFile := filepath.Join(pkgDir, "models.go")
+	if err = g.generate(modelsFile, "Storage Models", imports, "", modelsT, funcs, models); err != nil {
+		return
+	}
+
+	imports = []*codegen.ImportSpec{
+		codegen.SimpleImport("errors"),
+		codegen.SimpleImport("time"),
+		codegen.NewImport("uuid", "github.com/satori/go.uuid"),
+		codegen.SimpleImport("golang.org/x/net/context"),
+	}
+	reposFile := filepath.Join(pkgDir, "repositories.go")
+	if err = g.generate(reposFile, "Storage Repositories", imports, errorsT, repositoriesT, funcs, models); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.RemoveAll(f)
+	}
+	g.genfiles = nil
+}
+
+// generate renders the given template for each model into the file with the given name. The
+// preamble, if not empty, is written once before the models.
+func (g *Generator) generate(filename, title string, imports []*codegen.ImportSpec, preamble, tmpl string, funcs template.FuncMap, models []*model) error {
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	title = fmt.Sprintf("%s: %s", g.API.Context(), title)
+	if err = file.WriteHeader(title, g.Target, imports); err != nil {
+		return err
+	}
+	if preamble != "" {
+		if _, err = file.Write([]byte(preamble)); err != nil {
+			return err
+		}
+	}
+	for _, m := range models {
+		if err = file.ExecuteTemplate("model", tmpl, funcs, m); err != nil {
+			return err
+		}
+	}
+	return file.FormatCode()
+}
+
+// models builds the description of the models generated for the media types that define a
+// storage.
+func (g *Generator) models() []*model {
+	var models []*model
+	g.API.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		if mt.IsError() || !mt.Type.IsObject() {
+			return nil
+		}
+		table, ok := mt.Metadata["storage:table"]
+		if !ok || len(table) == 0 {
+			return nil
+		}
+		pks, ok := mt.Metadata["storage:pk"]
+		if !ok {
+			pks = []string{"id"}
+		}
+		m := &model{
+			Name:      codegen.Goify(mt.TypeName, true),
+			Table:     table[0],
+			MediaType: mt,
+		}
+		// The generated media type struct only has the fields rendered by the default view.
+		var view design.Object
+		pmt, _, err := mt.Project(design.DefaultView)
+		if err == nil {
+			view = pmt.Type.ToObject()
+		}
+		obj := mt.Type.ToObject()
+		names := make([]string, 0, len(obj))
+		for n := range obj {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			att := obj[n]
+			if !storable(att.Type) {
+				continue
+			}
+			f := &field{
+				Attribute: n,
+				Name:      codegen.GoifyAtt(att, n, true),
+				Column:    column(att, n),
+				Type:      codegen.GoTypeRef(att.Type, nil, 0, false),
+			}
+			for _, pk := range pks {
+				if pk == n {
+					f.PK = true
+					m.PrimaryKeys = append(m.PrimaryKeys, f)
+					break
+				}
+			}
+			if _, ok := view[n]; ok {
+				f.InMedia = true
+				f.MediaPointer = att.Type.IsPrimitive() && pmt.IsPrimitivePointer(n)
+			}
+			f.Pointer = att.Type.IsPrimitive() && mt.IsPrimitivePointer(n) && !f.PK
+			m.Fields = append(m.Fields, f)
+		}
+		m.Payloads = g.payloads(mt, m.Fields)
+		models = append(models, m)
+		return nil
+	})
+	return models
+}
+
+// payloads returns the payloads of the actions of the resources whose media type is mt that
+// share at least one field with the model.
+func (g *Generator) payloads(mt *design.MediaTypeDefinition, fields []*field) []*payload {
+	var payloads []*payload
+	id := design.CanonicalIdentifier(mt.Identifier)
+	g.API.IterateResources(func(r *design.ResourceDefinition) error {
+		if design.CanonicalIdentifier(r.MediaType) != id {
+			return nil
+		}
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			if a.Payload == nil || !a.Payload.IsObject() {
+				return nil
+			}
+			obj := a.Payload.ToObject()
+			p := &payload{
+				Name:   codegen.GoTypeName(a.Payload, nil, 0, false),
+				Action: a,
+			}
+			for _, f := range fields {
+				att, ok := obj[f.Attribute]
+				if !ok || !storable(att.Type) {
+					continue
+				}
+				if codegen.GoTypeRef(att.Type, nil, 0, false) != f.Type {
+					continue
+				}
+				ptr := att.Type.IsPrimitive() && a.Payload.IsPrimitivePointer(f.Attribute)
+				p.Fields = append(p.Fields, &payloadField{Field: f, Pointer: ptr})
+			}
+			if len(p.Fields) > 0 {
+				payloads = append(payloads, p)
+			}
+			return nil
+		})
+	})
+	return payloads
+}
+
+// storable returns true if values of the given type can be stored in a database column: the type
+// must be a primitive other than Any or an array or hash of such primitives.
+func storable(t design.DataType) bool {
+	isPrimitive := func(t design.DataType) bool {
+		p, ok := t.(design.Primitive)
+		return ok && p.Kind() != design.AnyKind
+	}
+	switch actual := t.(type) {
+	case design.Primitive:
+		return isPrimitive(actual)
+	case *design.Array:
+		return isPrimitive(actual.ElemType.Type)
+	case *design.Hash:
+		return isPrimitive(actual.KeyType.Type) && isPrimitive(actual.ElemType.Type)
+	}
+	return false
+}
+
+// column returns the name of the database column for the given attribute.
+func column(att *design.AttributeDefinition, name string) string {
+	if col, ok := att.Metadata["storage:column"]; ok && len(col) > 0 {
+		return col[0]
+	}
+	return codegen.SnakeCase(name)
+}
+
+// assign produces the Go code that assigns the field of the source struct to the field of the
+// target struct taking care of dereferencing or taking the address of the value as needed.
+func assign(target string, targetPtr bool, source string, sourcePtr bool) string {
+	switch {
+	case targetPtr == sourcePtr:
+		return fmt.Sprintf("%s = %s", target, source)
+	case sourcePtr:
+		return fmt.Sprintf("if %s != nil {\n%s = *%s\n}", source, target, source)
+	default:
+		tmp := codegen.Tempvar()
+		return fmt.Sprintf("%s := %s\n%s = &%s", tmp, source, target, tmp)
+	}
+}
+
+// Tag returns the struct field tags of the given model field.
+func (f *field) Tag() string {
+	gorm := "column:" + f.Column
+	if f.PK {
+		gorm += ";primary_key"
+	}
+	return fmt.Sprintf("`db:%q gorm:%q`", f.Column, gorm)
+}
+
+// KeyParams returns the Go code declaring the primary key function parameters.
+func (m *model) KeyParams() string {
+	params := make([]string, len(m.PrimaryKeys))
+	for i, pk := range m.PrimaryKeys {
+		params[i] = fmt.Sprintf("%s %s", codegen.Goify(pk.Attribute, false), pk.Type)
+	}
+	return strings.Join(params, ", ")
+}
+
+const modelsT = `{{ $model := . }}// {{ .Name }} is the database model for the "{{ .Table }}" table.
+type {{ .Name }} struct {
+{{ range .Fields }}	{{ .Name }} {{ if .Pointer }}*{{ end }}{{ .Type }} {{ .Tag }}
+{{ end }}}
+
+// TableName returns the name of the database table, it implements the GORM Tabler interface.
+func (m {{ .Name }}) TableName() string {
+	return {{ printf "%q" .Table }}
+}
+
+// {{ .Name }}FromMedia creates a {{ .Name }} model from an instance of the media type.
+func {{ .Name }}FromMedia(mt *{{ appPkg }}.{{ .Name }}) *{{ .Name }} {
+	m := &{{ .Name }}{}
+{{ range .Fields }}{{ if .InMedia }}	{{ assign (printf "m.%s" .Name) .Pointer (printf "mt.%s" .Name) .MediaPointer }}
+{{ end }}{{ end }}	return m
+}
+
+// ToMedia creates an instance of the media type from the model.
+func (m *{{ .Name }}) ToMedia() *{{ appPkg }}.{{ .Name }} {
+	mt := &{{ appPkg }}.{{ .Name }}{}
+{{ range .Fields }}{{ if .InMedia }}	{{ assign (printf "mt.%s" .Name) .MediaPointer (printf "m.%s" .Name) .Pointer }}
+{{ end }}{{ end }}	return mt
+}
+{{ range .Payloads }}
+// {{ $model.Name }}From{{ .Name }} creates a {{ $model.Name }} model from the {{ .Action.Parent.Name }} {{ .Action.Name }} action payload.
+func {{ $model.Name }}From{{ .Name }}(p *{{ appPkg }}.{{ .Name }}) *{{ $model.Name }} {
+	m := &{{ $model.Name }}{}
+{{ range .Fields }}	{{ assign (printf "m.%s" .Field.Name) .Field.Pointer (printf "p.%s" .Field.Name) .Pointer }}
+{{ end }}	return m
+}
+
+// To{{ .Name }} creates a {{ .Action.Parent.Name }} {{ .Action.Name }} action payload from the model.
+func (m *{{ $model.Name }}) To{{ .Name }}() *{{ appPkg }}.{{ .Name }} {
+	p := &{{ appPkg }}.{{ .Name }}{}
+{{ range .Fields }}	{{ assign (printf "p.%s" .Field.Name) .Pointer (printf "m.%s" .Field.Name) .Field.Pointer }}
+{{ end }}	return p
+}
+{{ end }}`
+
+const errorsT = `// ErrNotFound is the error returned by the repositories when no record matches the given
+// primary key.
+var ErrNotFound = errors.New("record not found")
+`
+
+const repositoriesT = `
+// {{ .Name }}Repository is the interface implemented by the "{{ .Table }}" table data access layer.
+type {{ .Name }}Repository interface {
+	// Get returns the {{ .Name }} record with the given primary key or ErrNotFound.
+	Get(ctx context.Context, {{ .KeyParams }}) (*{{ .Name }}, error)
+	// List returns all the {{ .Name }} records.
+	List(ctx context.Context) ([]*{{ .Name }}, error)
+	// Create inserts a new {{ .Name }} record.
+	Create(ctx context.Context, m *{{ .Name }}) error
+	// Update updates an existing {{ .Name }} record or returns ErrNotFound.
+	Update(ctx context.Context, m *{{ .Name }}) error
+	// Delete deletes the {{ .Name }} record with the given primary key or returns ErrNotFound.
+	Delete(ctx context.Context, {{ .KeyParams }}) error
+}
+`
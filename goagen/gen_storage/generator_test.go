@@ -0,0 +1,124 @@
+package genstorage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_storage"
+	"github.com/goadesign/goa/version"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("storagetest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"goagen", "--out=" + testPkg.Abs(), "--design=foo", "--version=" + version.String()}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = genstorage.Generate()
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	Context("with a media type without storage", func() {
+		BeforeEach(func() {
+			dslengine.Reset()
+			API("test api", func() {})
+			MediaType("application/vnd.bottle", func() {
+				Attributes(func() {
+					Attribute("id", Integer)
+				})
+				View("default", func() {
+					Attribute("id")
+				})
+			})
+			dslengine.Run()
+		})
+
+		It("does not generate anything", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(BeEmpty())
+		})
+	})
+
+	Context("with a media type with storage", func() {
+		BeforeEach(func() {
+			dslengine.Reset()
+			API("test api", func() {})
+			bottle := MediaType("application/vnd.bottle", func() {
+				Storage("bottles")
+				Attributes(func() {
+					Attribute("id", Integer)
+					Attribute("name", String, func() {
+						Metadata("storage:column", "bottle_name")
+					})
+					Attribute("tags", ArrayOf(String))
+					Attribute("internal", String)
+					Required("name")
+				})
+				View("default", func() {
+					Attribute("id")
+					Attribute("name")
+					Attribute("tags")
+				})
+			})
+			Resource("bottle", func() {
+				DefaultMedia(bottle)
+				Action("create", func() {
+					Routing(POST(""))
+					Payload(func() {
+						Member("name")
+						Member("tags")
+						Required("name")
+					})
+					Response(NoContent)
+				})
+			})
+			dslengine.Run()
+		})
+
+		It("generates the models and repositories", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(HaveLen(3))
+
+			content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "storage", "models.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			models := string(content)
+			Ω(models).Should(ContainSubstring("type Bottle struct {"))
+			Ω(models).Should(MatchRegexp("ID\\s+int\\s+`db:\"id\" gorm:\"column:id;primary_key\"`"))
+			Ω(models).Should(MatchRegexp("Name\\s+string\\s+`db:\"bottle_name\" gorm:\"column:bottle_name\"`"))
+			Ω(models).Should(MatchRegexp("Internal\\s+\\*string"))
+			Ω(models).Should(ContainSubstring("func (m Bottle) TableName() string {\n\treturn \"bottles\""))
+			Ω(models).Should(ContainSubstring("func BottleFromMedia(mt *app.Bottle) *Bottle {"))
+			Ω(models).Should(ContainSubstring("func (m *Bottle) ToMedia() *app.Bottle {"))
+			Ω(models).Should(ContainSubstring("func BottleFromCreateBottlePayload(p *app.CreateBottlePayload) *Bottle {"))
+			Ω(models).Should(ContainSubstring("func (m *Bottle) ToCreateBottlePayload() *app.CreateBottlePayload {"))
+			Ω(models).ShouldNot(ContainSubstring("mt.Internal"))
+
+			content, err = ioutil.ReadFile(filepath.Join(testPkg.Abs(), "storage", "repositories.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			repos := string(content)
+			Ω(repos).Should(ContainSubstring("type BottleRepository interface {"))
+			Ω(repos).Should(ContainSubstring("Get(ctx context.Context, id int) (*Bottle, error)"))
+			Ω(repos).Should(ContainSubstring("Delete(ctx context.Context, id int) error"))
+		})
+	})
+})
@@ -16,17 +16,19 @@ import (
 
 // Generator is the swagger code generator.
 type Generator struct {
-	API      *design.APIDefinition // The API definition
-	OutDir   string                // Path to output directory
-	genfiles []string              // Generated files
+	API         *design.APIDefinition // The API definition
+	OutDir      string                // Path to output directory
+	SpecVersion string                // Version of the generated specification: "2.0", "3.0" or "3.1"
+	genfiles    []string              // Generated files
 }
 
 // Generate is the generator entry point called by the meta generator.
 func Generate() (files []string, err error) {
-	var outDir, ver string
+	var outDir, ver, specVersion string
 	set := flag.NewFlagSet("swagger", flag.PanicOnError)
 	set.StringVar(&outDir, "out", "", "")
 	set.StringVar(&ver, "version", "", "")
+	set.StringVar(&specVersion, "spec-version", "2.0", "")
 	set.String("design", "", "")
 	set.Parse(os.Args[1:])
 
@@ -34,7 +36,7 @@ func Generate() (files []string, err error) {
 		return nil, err
 	}
 
-	g := &Generator{OutDir: outDir, API: design.Design}
+	g := &Generator{OutDir: outDir, API: design.Design, SpecVersion: specVersion}
 
 	return g.Generate()
 }
@@ -49,7 +51,16 @@ func (g *Generator) Generate() (_ []string, err error) {
 		}
 	}()
 
-	s, err := New(g.API)
+	var (
+		s    interface{}
+		name = "swagger"
+	)
+	if g.SpecVersion == "" || g.SpecVersion == "2.0" {
+		s, err = New(g.API)
+	} else {
+		s, err = NewOpenAPI(g.API, g.SpecVersion)
+		name = "openapi"
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +77,7 @@ func (g *Generator) Generate() (_ []string, err error) {
 	if err != nil {
 		return nil, err
 	}
-	swaggerFile := filepath.Join(swaggerDir, "swagger.json")
+	swaggerFile := filepath.Join(swaggerDir, name+".json")
 	if err := ioutil.WriteFile(swaggerFile, rawJSON, 0644); err != nil {
 		return nil, err
 	}
@@ -82,7 +93,7 @@ func (g *Generator) Generate() (_ []string, err error) {
 	if err != nil {
 		return nil, err
 	}
-	swaggerFile = filepath.Join(swaggerDir, "swagger.yaml")
+	swaggerFile = filepath.Join(swaggerDir, name+".yaml")
 	if err := ioutil.WriteFile(swaggerFile, rawYAML, 0644); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,655 @@
+package genswagger
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/gen_schema"
+)
+
+type (
+	// OpenAPI represents an OpenAPI 3 document.
+	// See https://spec.openapis.org/oas/v3.0.3
+	OpenAPI struct {
+		OpenAPI      string                  `json:"openapi"`
+		Info         *Info                   `json:"info,omitempty"`
+		Servers      []*Server               `json:"servers,omitempty"`
+		Paths        map[string]*OpenAPIPath `json:"paths"`
+		Components   *Components             `json:"components,omitempty"`
+		Tags         []*Tag                  `json:"tags,omitempty"`
+		ExternalDocs *ExternalDocs           `json:"externalDocs,omitempty"`
+	}
+
+	// Server describes a server hosting the API.
+	Server struct {
+		// URL of the server, relative URLs are resolved against the document location.
+		URL string `json:"url"`
+		// Description of the server.
+		Description string `json:"description,omitempty"`
+	}
+
+	// Components holds the reusable objects of the document.
+	Components struct {
+		// Schemas contains the schemas of the API types, one per media type view.
+		Schemas map[string]*genschema.JSONSchema `json:"schemas,omitempty"`
+		// Responses contains the standard responses.
+		Responses map[string]*OpenAPIResponse `json:"responses,omitempty"`
+		// Parameters contains the API level parameters.
+		Parameters map[string]*OpenAPIParameter `json:"parameters,omitempty"`
+		// SecuritySchemes contains the security schemes used by the operations.
+		SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+
+	// OpenAPIPath holds the operations available on a single path.
+	OpenAPIPath struct {
+		// Get defines a GET operation on this path.
+		Get *OpenAPIOperation `json:"get,omitempty"`
+		// Put defines a PUT operation on this path.
+		Put *OpenAPIOperation `json:"put,omitempty"`
+		// Post defines a POST operation on this path.
+		Post *OpenAPIOperation `json:"post,omitempty"`
+		// Delete defines a DELETE operation on this path.
+		Delete *OpenAPIOperation `json:"delete,omitempty"`
+		// Options defines a OPTIONS operation on this path.
+		Options *OpenAPIOperation `json:"options,omitempty"`
+		// Head defines a HEAD operation on this path.
+		Head *OpenAPIOperation `json:"head,omitempty"`
+		// Patch defines a PATCH operation on this path.
+		Patch *OpenAPIOperation `json:"patch,omitempty"`
+	}
+
+	// OpenAPIOperation describes a single API operation on a path.
+	OpenAPIOperation struct {
+		// Tags is a list of tags for API documentation control.
+		Tags []string `json:"tags,omitempty"`
+		// Summary is a short summary of what the operation does.
+		Summary string `json:"summary,omitempty"`
+		// Description is a verbose explanation of the operation behavior.
+		Description string `json:"description,omitempty"`
+		// ExternalDocs points to additional external documentation for this operation.
+		ExternalDocs *ExternalDocs `json:"externalDocs,omitempty"`
+		// OperationID is a unique string used to identify the operation.
+		OperationID string `json:"operationId,omitempty"`
+		// Parameters is the list of path, query and header parameters of the operation.
+		Parameters []*OpenAPIParameter `json:"parameters,omitempty"`
+		// RequestBody describes the operation payload if any.
+		RequestBody *RequestBody `json:"requestBody,omitempty"`
+		// Responses lists the possible responses indexed by status code.
+		Responses map[string]*OpenAPIResponse `json:"responses"`
+		// Deprecated declares this operation to be deprecated.
+		Deprecated bool `json:"deprecated,omitempty"`
+		// Security is a declaration of which security schemes are applied for this operation.
+		Security []map[string][]string `json:"security,omitempty"`
+		// Callbacks describes the webhooks of the operation indexed by event name, see
+		// Callback.
+		Callbacks map[string]Callback `json:"callbacks,omitempty"`
+		// Middleware lists the names of the middleware applied to the operation handler.
+		Middleware []string `json:"x-middleware,omitempty"`
+	}
+
+	// Callback maps runtime expressions evaluated against the operation request, e.g.
+	// "{$request.body#/callback_url}", to the requests made by the API to the resulting URL.
+	Callback map[string]*OpenAPIPath
+
+	// OpenAPIParameter describes a single operation parameter.
+	OpenAPIParameter struct {
+		// Name of the parameter. Parameter names are case sensitive.
+		Name string `json:"name"`
+		// In is the location of the parameter, one of "query", "header" or "path".
+		In string `json:"in"`
+		// Description is a brief description of the parameter.
+		Description string `json:"description,omitempty"`
+		// Required determines whether this parameter is mandatory.
+		Required bool `json:"required"`
+		// Schema defines the type of the parameter.
+		Schema *genschema.JSONSchema `json:"schema,omitempty"`
+	}
+
+	// RequestBody describes a request payload.
+	RequestBody struct {
+		// Description is a brief description of the payload.
+		Description string `json:"description,omitempty"`
+		// Content maps the supported MIME types to the payload schema.
+		Content map[string]*MediaTypeObject `json:"content"`
+		// Required determines whether the payload is mandatory.
+		Required bool `json:"required,omitempty"`
+	}
+
+	// MediaTypeObject provides the schema of a request or response body for a given MIME
+	// type.
+	MediaTypeObject struct {
+		// Schema defines the type of the body.
+		Schema *genschema.JSONSchema `json:"schema,omitempty"`
+		// Example is an example of the body.
+		Example interface{} `json:"example,omitempty"`
+	}
+
+	// OpenAPIResponse describes an operation response.
+	OpenAPIResponse struct {
+		// Description of the response.
+		Description string `json:"description"`
+		// Headers is a list of headers that are sent with the response.
+		Headers map[string]*OpenAPIHeader `json:"headers,omitempty"`
+		// Content maps the MIME types to the response body schema, nil if the response has
+		// no body.
+		Content map[string]*MediaTypeObject `json:"content,omitempty"`
+	}
+
+	// OpenAPIHeader describes a response header.
+	OpenAPIHeader struct {
+		// Description is a brief description of the header.
+		Description string `json:"description,omitempty"`
+		// Schema defines the type of the header.
+		Schema *genschema.JSONSchema `json:"schema,omitempty"`
+	}
+
+	// SecurityScheme defines a security scheme that can be used by the operations.
+	SecurityScheme struct {
+		// Type of the security scheme, one of "apiKey", "http" or "oauth2".
+		Type string `json:"type"`
+		// Description for security scheme.
+		Description string `json:"description,omitempty"`
+		// Name of the header or query parameter to be used when type is "apiKey".
+		Name string `json:"name,omitempty"`
+		// In is the location of the API key when type is "apiKey".
+		In string `json:"in,omitempty"`
+		// Scheme is the HTTP authorization scheme when type is "http".
+		Scheme string `json:"scheme,omitempty"`
+		// BearerFormat describes the format of bearer tokens.
+		BearerFormat string `json:"bearerFormat,omitempty"`
+		// Flows describes the OAuth2 flows when type is "oauth2".
+		Flows *OAuthFlows `json:"flows,omitempty"`
+	}
+
+	// OAuthFlows lists the supported OAuth2 flows.
+	OAuthFlows struct {
+		Implicit          *OAuthFlow `json:"implicit,omitempty"`
+		Password          *OAuthFlow `json:"password,omitempty"`
+		ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty"`
+		AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty"`
+	}
+
+	// OAuthFlow describes an OAuth2 flow.
+	OAuthFlow struct {
+		// AuthorizationURL is the authorization URL used by the flow.
+		AuthorizationURL string `json:"authorizationUrl,omitempty"`
+		// TokenURL is the token URL used by the flow.
+		TokenURL string `json:"tokenUrl,omitempty"`
+		// Scopes lists the available scopes.
+		Scopes map[string]string `json:"scopes"`
+	}
+)
+
+// OpenAPIVersions maps the supported values of the --spec-version flag to the version written in
+// the generated OpenAPI 3 documents.
+var OpenAPIVersions = map[string]string{
+	"3.0": "3.0.3",
+	"3.1": "3.1.0",
+}
+
+// NewOpenAPI creates an OpenAPI 3 document from an API definition. version is one of the keys of
+// OpenAPIVersions.
+func NewOpenAPI(api *design.APIDefinition, version string) (*OpenAPI, error) {
+	oas, ok := OpenAPIVersions[version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported OpenAPI version %#v", version)
+	}
+	s, err := New(api)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	actions := make(map[string]*design.ActionDefinition)
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			actions[fmt.Sprintf("%s#%s", res.Name, a.Name)] = a
+			return nil
+		})
+	})
+
+	o := &OpenAPI{
+		OpenAPI:      oas,
+		Info:         s.Info,
+		Servers:      serversFromSwagger(s),
+		Paths:        make(map[string]*OpenAPIPath, len(s.Paths)),
+		Tags:         s.Tags,
+		ExternalDocs: s.ExternalDocs,
+	}
+	for key, p := range s.Paths {
+		path := &OpenAPIPath{}
+		ops := []struct {
+			op  *Operation
+			dst **OpenAPIOperation
+		}{
+			{p.Get, &path.Get}, {p.Put, &path.Put}, {p.Post, &path.Post}, {p.Delete, &path.Delete},
+			{p.Options, &path.Options}, {p.Head, &path.Head}, {p.Patch, &path.Patch},
+		}
+		for _, e := range ops {
+			if e.op == nil {
+				continue
+			}
+			var action *design.ActionDefinition
+			if chunks := strings.SplitN(e.op.OperationID, "#", 3); len(chunks) > 1 {
+				action = actions[chunks[0]+"#"+chunks[1]]
+			}
+			if *e.dst, err = openAPIOperation(s, api, e.op, action); err != nil {
+				return nil, err
+			}
+		}
+		o.Paths[key] = path
+	}
+
+	// Build the components last as converting the operations may produce new definitions.
+	components := &Components{SecuritySchemes: securitySchemesFromDefinition(api.SecuritySchemes)}
+	if len(genschema.Definitions) > 0 {
+		components.Schemas = make(map[string]*genschema.JSONSchema, len(genschema.Definitions))
+		for n, d := range genschema.Definitions {
+			components.Schemas[n] = openAPISchema(d)
+		}
+	}
+	if len(s.Responses) > 0 {
+		components.Responses = make(map[string]*OpenAPIResponse, len(s.Responses))
+		for n, r := range s.Responses {
+			components.Responses[n] = openAPIResponse(r.Description, r.Headers, s.Produces, r.Schema, r.Examples)
+		}
+	}
+	if len(s.Parameters) > 0 {
+		components.Parameters = make(map[string]*OpenAPIParameter, len(s.Parameters))
+		for n, p := range s.Parameters {
+			components.Parameters[n] = openAPIParameter(p)
+		}
+	}
+	if components.Schemas != nil || components.Responses != nil || components.Parameters != nil || components.SecuritySchemes != nil {
+		o.Components = components
+	}
+	return o, nil
+}
+
+// serversFromSwagger computes the servers from the Swagger host, base path and schemes.
+func serversFromSwagger(s *Swagger) []*Server {
+	if s.Host == "" {
+		if s.BasePath == "" {
+			return nil
+		}
+		return []*Server{{URL: s.BasePath}}
+	}
+	schemes := s.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http"}
+	}
+	servers := make([]*Server, len(schemes))
+	for i, scheme := range schemes {
+		servers[i] = &Server{URL: fmt.Sprintf("%s://%s%s", scheme, s.Host, s.BasePath)}
+	}
+	return servers
+}
+
+// openAPIOperation converts a Swagger operation. The responses are built from the action
+// definition when given so that responses that may render any view of their media type are
+// described using oneOf.
+func openAPIOperation(s *Swagger, api *design.APIDefinition, op *Operation, action *design.ActionDefinition) (*OpenAPIOperation, error) {
+	consumes := op.Consumes
+	if len(consumes) == 0 {
+		consumes = s.Consumes
+	}
+	produces := op.Produces
+	if len(produces) == 0 {
+		produces = s.Produces
+	}
+	o := &OpenAPIOperation{
+		Tags:         op.Tags,
+		Summary:      op.Summary,
+		Description:  op.Description,
+		ExternalDocs: op.ExternalDocs,
+		OperationID:  op.OperationID,
+		Deprecated:   op.Deprecated,
+		Security:     op.Security,
+		Middleware:   op.Middleware,
+		Responses:    make(map[string]*OpenAPIResponse),
+	}
+	var form *genschema.JSONSchema
+	for _, p := range op.Parameters {
+		if p.In == "body" {
+			o.RequestBody = &RequestBody{
+				Description: p.Description,
+				Content:     contentFor(consumes, openAPISchema(p.Schema)),
+				Required:    p.Required,
+			}
+			continue
+		}
+		if p.In == "formData" {
+			// OpenAPI 3 describes form parameters as the properties of the request body
+			if form == nil {
+				form = genschema.NewJSONSchema()
+				form.Type = genschema.JSONObject
+			}
+			prop := openAPISchema(openAPIParameter(p).Schema)
+			prop.Description = p.Description
+			form.Properties[p.Name] = prop
+			if p.Required {
+				form.Required = append(form.Required, p.Name)
+			}
+			continue
+		}
+		o.Parameters = append(o.Parameters, openAPIParameter(p))
+	}
+	if form != nil {
+		o.RequestBody = &RequestBody{
+			Content:  contentFor(consumes, form),
+			Required: true,
+		}
+	}
+	if action == nil {
+		for code, r := range op.Responses {
+			o.Responses[code] = openAPIResponse(r.Description, r.Headers, produces, r.Schema, r.Examples)
+		}
+		return o, nil
+	}
+	for _, r := range action.Responses {
+		resp, err := responseSpecFromDefinition(s, api, r)
+		if err != nil {
+			return nil, err
+		}
+		schema := resp.Schema
+		views, err := viewSchemas(api, r)
+		if err != nil {
+			return nil, err
+		}
+		if len(views) > 1 {
+			schema = &genschema.JSONSchema{OneOf: views}
+		}
+		o.Responses[strconv.Itoa(r.Status)] = openAPIResponse(resp.Description, resp.Headers, produces, schema, resp.Examples)
+	}
+	callbacks, err := webhookCallbacks(api, action)
+	if err != nil {
+		return nil, err
+	}
+	o.Callbacks = callbacks
+	return o, nil
+}
+
+// webhookCallbacks describes the webhooks of the action as callbacks. The callback requests are
+// POST requests whose body is the default view of the webhook media type, signed as described in
+// goa.WebhookSignature.
+func webhookCallbacks(api *design.APIDefinition, action *design.ActionDefinition) (map[string]Callback, error) {
+	if len(action.Webhooks) == 0 {
+		return nil, nil
+	}
+	callbacks := make(map[string]Callback, len(action.Webhooks))
+	for _, w := range action.Webhooks {
+		mt := api.MediaTypeWithIdentifier(w.MediaType)
+		if mt == nil {
+			continue
+		}
+		projected, _, err := mt.Project(design.DefaultView)
+		if err != nil {
+			return nil, err
+		}
+		var expr string
+		switch {
+		case action.PathParams().Type.ToObject()[w.URLParam] != nil:
+			expr = "{$request.path." + w.URLParam + "}"
+		case action.AllParams().Type.ToObject()[w.URLParam] != nil:
+			expr = "{$request.query." + w.URLParam + "}"
+		default:
+			expr = "{$request.body#/" + w.URLParam + "}"
+		}
+		op := &OpenAPIOperation{
+			Summary:     w.Event,
+			Description: w.Description,
+			Parameters: []*OpenAPIParameter{
+				webhookHeader("X-Webhook-Event", "Name of the event", true),
+				webhookHeader("X-Webhook-Delivery", "Identifier of the delivery, identical for all the attempts", true),
+				webhookHeader("X-Webhook-Timestamp", "Unix time at which the delivery was signed", false),
+				webhookHeader("X-Webhook-Signature", "sha256= followed by the hex encoded HMAC-SHA256 of the timestamp, a dot and the body", false),
+			},
+			RequestBody: &RequestBody{
+				Content:  contentFor([]string{"application/json"}, openAPISchema(genschema.TypeSchema(api, projected))),
+				Required: true,
+			},
+			Responses: map[string]*OpenAPIResponse{
+				"2XX": {Description: "The event was received"},
+			},
+		}
+		callbacks[w.Event] = Callback{expr: &OpenAPIPath{Post: op}}
+	}
+	return callbacks, nil
+}
+
+// webhookHeader describes a header of the webhook delivery requests.
+func webhookHeader(name, desc string, required bool) *OpenAPIParameter {
+	schema := genschema.NewJSONSchema()
+	schema.Type = genschema.JSONString
+	return &OpenAPIParameter{Name: name, In: "header", Description: desc, Required: required, Schema: schema}
+}
+
+// viewSchemas returns the schemas of the views of the response media type if the response does
+// not specify a view, the default view schema comes first.
+func viewSchemas(api *design.APIDefinition, r *design.ResponseDefinition) ([]*genschema.JSONSchema, error) {
+	if r.MediaType == "" || r.ViewName != "" {
+		return nil, nil
+	}
+	mt, ok := api.MediaTypes[design.CanonicalIdentifier(r.MediaType)]
+	if !ok {
+		return nil, nil
+	}
+	var names []string
+	for n := range mt.Views {
+		if n != design.DefaultView {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := mt.Views[design.DefaultView]; ok {
+		names = append([]string{design.DefaultView}, names...)
+	}
+	schemas := make([]*genschema.JSONSchema, len(names))
+	for i, n := range names {
+		projected, _, err := mt.Project(n)
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = genschema.TypeSchema(api, projected)
+	}
+	return schemas, nil
+}
+
+// openAPIResponse builds a response from its Swagger representation. The example listed in
+// examples if any is set on all the response content MIME types.
+func openAPIResponse(desc string, headers map[string]*Header, produces []string, schema *genschema.JSONSchema, examples map[string]interface{}) *OpenAPIResponse {
+	r := &OpenAPIResponse{Description: desc}
+	if schema != nil {
+		if schema.Type == genschema.JSONFile {
+			produces = []string{"application/octet-stream"}
+		}
+		r.Content = contentFor(produces, openAPISchema(schema))
+		for _, ex := range examples {
+			for _, c := range r.Content {
+				c.Example = ex
+			}
+		}
+	}
+	if len(headers) > 0 {
+		r.Headers = make(map[string]*OpenAPIHeader, len(headers))
+		for n, h := range headers {
+			r.Headers[n] = &OpenAPIHeader{
+				Description: h.Description,
+				Schema: &genschema.JSONSchema{
+					Type:           genschema.JSONType(h.Type),
+					Format:         h.Format,
+					DefaultValue:   h.Default,
+					Enum:           h.Enum,
+					ExtensibleEnum: h.ExtensibleEnum,
+					Pattern:        h.Pattern,
+					Minimum:        h.Minimum,
+					Maximum:        h.Maximum,
+					MinLength:      h.MinLength,
+					MaxLength:      h.MaxLength,
+					Items:          itemsSchema(h.Items),
+				},
+			}
+		}
+	}
+	if r.Description == "" {
+		// The description is required by the OpenAPI specification.
+		r.Description = "Response"
+	}
+	return r
+}
+
+// openAPIParameter converts a non body Swagger parameter.
+func openAPIParameter(p *Parameter) *OpenAPIParameter {
+	return &OpenAPIParameter{
+		Name:        p.Name,
+		In:          p.In,
+		Description: p.Description,
+		Required:    p.Required,
+		Schema: &genschema.JSONSchema{
+			Type:           genschema.JSONType(p.Type),
+			Format:         p.Format,
+			DefaultValue:   p.Default,
+			Enum:           p.Enum,
+			ExtensibleEnum: p.ExtensibleEnum,
+			Pattern:        p.Pattern,
+			Minimum:        p.Minimum,
+			Maximum:        p.Maximum,
+			MinLength:      p.MinLength,
+			MaxLength:      p.MaxLength,
+			Items:          itemsSchema(p.Items),
+		},
+	}
+}
+
+// itemsSchema converts Swagger items to a JSON schema.
+func itemsSchema(items *Items) *genschema.JSONSchema {
+	if items == nil {
+		return nil
+	}
+	return &genschema.JSONSchema{
+		Type:           genschema.JSONType(items.Type),
+		Format:         items.Format,
+		DefaultValue:   items.Default,
+		Enum:           items.Enum,
+		ExtensibleEnum: items.ExtensibleEnum,
+		Pattern:        items.Pattern,
+		Minimum:        items.Minimum,
+		Maximum:        items.Maximum,
+		MinLength:      items.MinLength,
+		MaxLength:      items.MaxLength,
+		Items:          itemsSchema(items.Items),
+	}
+}
+
+// contentFor returns the content describing a body with the given schema for each MIME type.
+func contentFor(mimeTypes []string, schema *genschema.JSONSchema) map[string]*MediaTypeObject {
+	if len(mimeTypes) == 0 {
+		mimeTypes = []string{"application/json"}
+	}
+	content := make(map[string]*MediaTypeObject, len(mimeTypes))
+	for _, mt := range mimeTypes {
+		content[mt] = &MediaTypeObject{Schema: schema}
+	}
+	return content
+}
+
+// openAPISchema returns a copy of the given schema where the references to definitions are
+// replaced with references to components and the Swagger "file" type is replaced with a binary
+// string.
+func openAPISchema(s *genschema.JSONSchema) *genschema.JSONSchema {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Media = nil
+	c.Links = nil
+	c.Ref = strings.Replace(c.Ref, "#/definitions/", "#/components/schemas/", 1)
+	if c.Type == genschema.JSONFile {
+		c.Type = genschema.JSONString
+		c.Format = "binary"
+	}
+	c.Items = openAPISchema(s.Items)
+	if s.Properties != nil {
+		c.Properties = make(map[string]*genschema.JSONSchema, len(s.Properties))
+		for n, p := range s.Properties {
+			c.Properties[n] = openAPISchema(p)
+		}
+	}
+	if s.Definitions != nil {
+		c.Definitions = make(map[string]*genschema.JSONSchema, len(s.Definitions))
+		for n, d := range s.Definitions {
+			c.Definitions[n] = openAPISchema(d)
+		}
+	}
+	c.AnyOf = openAPISchemas(s.AnyOf)
+	c.OneOf = openAPISchemas(s.OneOf)
+	return &c
+}
+
+// openAPISchemas converts a list of schemas using openAPISchema.
+func openAPISchemas(schemas []*genschema.JSONSchema) []*genschema.JSONSchema {
+	if schemas == nil {
+		return nil
+	}
+	res := make([]*genschema.JSONSchema, len(schemas))
+	for i, s := range schemas {
+		res[i] = openAPISchema(s)
+	}
+	return res
+}
+
+// securitySchemesFromDefinition maps the design security schemes to OpenAPI 3 security schemes.
+func securitySchemesFromDefinition(schemes []*design.SecuritySchemeDefinition) map[string]*SecurityScheme {
+	if len(schemes) == 0 {
+		return nil
+	}
+	defs := make(map[string]*SecurityScheme, len(schemes))
+	for _, scheme := range schemes {
+		def := &SecurityScheme{Description: scheme.Description}
+		switch scheme.Kind {
+		case design.BasicAuthSecurityKind:
+			def.Type = "http"
+			def.Scheme = "basic"
+		case design.APIKeySecurityKind:
+			def.Type = "apiKey"
+			def.Name = scheme.Name
+			def.In = scheme.In
+		case design.JWTSecurityKind:
+			def.Type = "http"
+			def.Scheme = "bearer"
+			def.BearerFormat = "JWT"
+			if scheme.TokenURL != "" {
+				def.Description += fmt.Sprintf("\n\n**Token URL**: %s", scheme.TokenURL)
+			}
+			if len(scheme.Scopes) != 0 {
+				def.Description += fmt.Sprintf("\n\n**Security Scopes**:\n%s", scopesMapList(scheme.Scopes))
+			}
+		case design.OAuth2SecurityKind:
+			def.Type = "oauth2"
+			scopes := scheme.Scopes
+			if scopes == nil {
+				scopes = make(map[string]string)
+			}
+			flow := &OAuthFlow{AuthorizationURL: scheme.AuthorizationURL, TokenURL: scheme.TokenURL, Scopes: scopes}
+			def.Flows = &OAuthFlows{}
+			switch scheme.Flow {
+			case "implicit":
+				flow.TokenURL = ""
+				def.Flows.Implicit = flow
+			case "password":
+				flow.AuthorizationURL = ""
+				def.Flows.Password = flow
+			case "application":
+				flow.AuthorizationURL = ""
+				def.Flows.ClientCredentials = flow
+			default:
+				def.Flows.AuthorizationCode = flow
+			}
+		default:
+			continue
+		}
+		defs[scheme.SchemeName] = def
+	}
+	return defs
+}
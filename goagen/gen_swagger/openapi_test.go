@@ -0,0 +1,145 @@
+package genswagger_test
+
+import (
+	"encoding/json"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_schema"
+	"github.com/goadesign/goa/goagen/gen_swagger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewOpenAPI", func() {
+	var version string
+	var oas *genswagger.OpenAPI
+	var newErr error
+
+	BeforeEach(func() {
+		version = "3.0"
+		dslengine.Reset()
+		genschema.Definitions = make(map[string]*genschema.JSONSchema)
+		API("test", func() {
+			Host("goa.design")
+			Scheme("https")
+			BasePath("/api")
+			JWTSecurity("jwt", func() {
+				Header("Authorization")
+			})
+		})
+		foo := MediaType("application/vnd.foo", func() {
+			Attributes(func() {
+				Attribute("name", String)
+				Attribute("count", Integer)
+			})
+			View("default", func() {
+				Attribute("name")
+				Attribute("count")
+			})
+			View("tiny", func() {
+				Attribute("name")
+			})
+		})
+		Resource("res", func() {
+			Security("jwt")
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Params(func() {
+					Param("id", Integer)
+				})
+				Response(OK, foo)
+			})
+			Action("create", func() {
+				Routing(POST(""))
+				Payload(func() {
+					Attribute("name", String)
+					Attribute("callback_url", String)
+				})
+				Webhook("foo.created", foo, "callback_url", func() {
+					Description("Sent when a foo is created")
+				})
+				Response(Created)
+			})
+		})
+	})
+
+	JustBeforeEach(func() {
+		err := dslengine.Run()
+		Ω(err).ShouldNot(HaveOccurred())
+		oas, newErr = genswagger.NewOpenAPI(Design, version)
+	})
+
+	It("produces an OpenAPI 3.0 document", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		Ω(oas.OpenAPI).Should(Equal("3.0.3"))
+		Ω(oas.Servers).Should(HaveLen(1))
+		Ω(oas.Servers[0].URL).Should(Equal("https://goa.design/api"))
+		Ω(oas.Components.Schemas).Should(HaveKey("Foo"))
+		Ω(oas.Components.Schemas).Should(HaveKey("FooTiny"))
+		Ω(oas.Components.SecuritySchemes).Should(HaveKey("jwt"))
+		Ω(oas.Components.SecuritySchemes["jwt"].Scheme).Should(Equal("bearer"))
+	})
+
+	It("uses oneOf for responses that may render any view", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		show := oas.Paths["/{id}"].Get
+		Ω(show).ShouldNot(BeNil())
+		Ω(show.Parameters).Should(HaveLen(1))
+		Ω(show.Parameters[0].Schema.Type).Should(BeEquivalentTo("integer"))
+		content := show.Responses["200"].Content
+		Ω(content).ShouldNot(BeEmpty())
+		for _, mt := range content {
+			Ω(mt.Schema.OneOf).Should(HaveLen(2))
+			Ω(mt.Schema.OneOf[0].Ref).Should(Equal("#/components/schemas/Foo"))
+			Ω(mt.Schema.OneOf[1].Ref).Should(Equal("#/components/schemas/FooTiny"))
+		}
+	})
+
+	It("describes payloads with request bodies", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		create := oas.Paths[""].Post
+		Ω(create).ShouldNot(BeNil())
+		Ω(create.RequestBody).ShouldNot(BeNil())
+		Ω(create.RequestBody.Content).Should(HaveKey("application/json"))
+		b, err := json.Marshal(oas)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(b)).ShouldNot(ContainSubstring("#/definitions/"))
+	})
+
+	It("describes webhooks with callbacks", func() {
+		Ω(newErr).ShouldNot(HaveOccurred())
+		create := oas.Paths[""].Post
+		Ω(create.Callbacks).Should(HaveKey("foo.created"))
+		cb := create.Callbacks["foo.created"]
+		Ω(cb).Should(HaveKey("{$request.body#/callback_url}"))
+		post := cb["{$request.body#/callback_url}"].Post
+		Ω(post).ShouldNot(BeNil())
+		Ω(post.Description).Should(Equal("Sent when a foo is created"))
+		Ω(post.RequestBody.Content).Should(HaveKey("application/json"))
+		Ω(post.RequestBody.Content["application/json"].Schema.Ref).Should(Equal("#/components/schemas/Foo"))
+		Ω(post.Responses).Should(HaveKey("2XX"))
+	})
+
+	Context("with version 3.1", func() {
+		BeforeEach(func() {
+			version = "3.1"
+		})
+
+		It("sets the document version", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(oas.OpenAPI).Should(Equal("3.1.0"))
+		})
+	})
+
+	Context("with an unsupported version", func() {
+		BeforeEach(func() {
+			version = "4.0"
+		})
+
+		It("returns an error", func() {
+			Ω(newErr).Should(HaveOccurred())
+		})
+	})
+})
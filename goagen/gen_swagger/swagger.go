@@ -95,6 +95,8 @@ type (
 		Deprecated bool `json:"deprecated,omitempty"`
 		// Secury is a declaration of which security schemes are applied for this operation.
 		Security []map[string][]string `json:"security,omitempty"`
+		// Middleware lists the names of the middleware applied to the operation handler.
+		Middleware []string `json:"x-middleware,omitempty"`
 	}
 
 	// Parameter describes a single operation parameter.
@@ -144,6 +146,11 @@ type (
 		UniqueItems      bool          `json:"uniqueItems,omitempty"`
 		Enum             []interface{} `json:"enum,omitempty"`
 		MultipleOf       float64       `json:"multipleOf,omitempty"`
+		// ExtensibleEnum lists the known values of an open enum, see the OpenEnum DSL.
+		ExtensibleEnum []interface{} `json:"x-extensible-enum,omitempty"`
+		// Classification is the data classification of the parameter, see the
+		// Classification DSL.
+		Classification string `json:"x-classification,omitempty"`
 	}
 
 	// Response describes an operation response.
@@ -157,6 +164,8 @@ type (
 		Schema *genschema.JSONSchema `json:"schema,omitempty"`
 		// Headers is a list of headers that are sent with the response.
 		Headers map[string]*Header `json:"headers,omitempty"`
+		// Examples maps MIME types to examples of the response body.
+		Examples map[string]interface{} `json:"examples,omitempty"`
 		// Ref references a global API response.
 		// This field is exclusive with the other fields of Response.
 		Ref string `json:"$ref,omitempty"`
@@ -192,6 +201,8 @@ type (
 		UniqueItems      bool          `json:"uniqueItems,omitempty"`
 		Enum             []interface{} `json:"enum,omitempty"`
 		MultipleOf       float64       `json:"multipleOf,omitempty"`
+		// ExtensibleEnum lists the known values of an open enum, see the OpenEnum DSL.
+		ExtensibleEnum []interface{} `json:"x-extensible-enum,omitempty"`
 	}
 
 	// SecurityDefinition allows the definition of a security scheme that can be used by the
@@ -262,6 +273,8 @@ type (
 		UniqueItems      bool          `json:"uniqueItems,omitempty"`
 		Enum             []interface{} `json:"enum,omitempty"`
 		MultipleOf       float64       `json:"multipleOf,omitempty"`
+		// ExtensibleEnum lists the known values of an open enum, see the OpenEnum DSL.
+		ExtensibleEnum []interface{} `json:"x-extensible-enum,omitempty"`
 	}
 
 	// Tag allows adding meta data to a single tag that is used by the Operation Object. It is
@@ -508,6 +521,12 @@ func summaryFromDefinition(name string, metadata dslengine.MetadataDefinition) s
 	return name
 }
 
+// deprecated returns true if the "deprecated" metadata is set.
+func deprecated(metadata dslengine.MetadataDefinition) bool {
+	_, ok := metadata["deprecated"]
+	return ok
+}
+
 func paramsFromDefinition(params *design.AttributeDefinition, path string) ([]*Parameter, error) {
 	if params == nil {
 		return nil, nil
@@ -549,12 +568,13 @@ func paramsFromHeaders(action *design.ActionDefinition) []*Parameter {
 
 func paramFor(at *design.AttributeDefinition, name, in string, required bool) *Parameter {
 	p := &Parameter{
-		In:          in,
-		Name:        name,
-		Default:     toStringMap(at.DefaultValue),
-		Description: at.Description,
-		Required:    required,
-		Type:        at.Type.Name(),
+		In:             in,
+		Name:           name,
+		Default:        toStringMap(at.DefaultValue),
+		Description:    at.Description,
+		Required:       required,
+		Type:           at.Type.Name(),
+		Classification: at.Classification,
 	}
 	if at.Type.IsArray() {
 		p.Items = itemsFromDefinition(at.Type.ToArray().ElemType)
@@ -619,10 +639,20 @@ func responseSpecFromDefinition(s *Swagger, api *design.APIDefinition, r *design
 	if err != nil {
 		return nil, err
 	}
+	headers = addCachingHeaders(headers, r)
+	var examples map[string]interface{}
+	if r.Example != nil {
+		mimeType := "application/json"
+		if len(s.Produces) > 0 {
+			mimeType = s.Produces[0]
+		}
+		examples = map[string]interface{}{mimeType: r.Example}
+	}
 	return &Response{
 		Description: r.Description,
 		Schema:      schema,
 		Headers:     headers,
+		Examples:    examples,
 	}, nil
 }
 
@@ -672,6 +702,31 @@ func headersFromDefinition(headers *design.AttributeDefinition) (map[string]*Hea
 	return res, nil
 }
 
+// addCachingHeaders documents the Cache-Control, Vary and Surrogate-Key headers set by the
+// response if any, see the CacheControl, Vary and SurrogateKey DSL.
+func addCachingHeaders(headers map[string]*Header, r *design.ResponseDefinition) map[string]*Header {
+	add := func(name, desc, value string) {
+		if headers == nil {
+			headers = make(map[string]*Header)
+		}
+		if _, ok := headers[name]; !ok {
+			headers[name] = &Header{Description: desc, Type: "string", Default: value}
+		}
+	}
+	if r.CacheControl != nil {
+		if v := r.CacheControl.HeaderValue(); v != "" {
+			add("Cache-Control", "Caching directives", v)
+		}
+	}
+	if len(r.Vary) > 0 {
+		add("Vary", "Request headers the response depends on", strings.Join(r.Vary, ", "))
+	}
+	if len(r.SurrogateKeys) > 0 {
+		add("Surrogate-Key", "Keys used to purge the response from CDN caches", strings.Join(r.SurrogateKeys, " "))
+	}
+	return headers
+}
+
 func buildPathFromFileServer(s *Swagger, api *design.APIDefinition, fs *design.FileServerDefinition) error {
 	wcs := design.ExtractWildcards(fs.RequestPath)
 	var param []*Parameter
@@ -739,7 +794,7 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 		// By default tag with resource name
 		tagNames = []string{route.Parent.Parent.Name}
 	}
-	params, err := paramsFromDefinition(action.AllParams(), route.FullPath())
+	params, err := paramsFromDefinition(action.RouteParams(route), route.FullPath())
 	if err != nil {
 		return err
 	}
@@ -755,7 +810,15 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 		responses[strconv.Itoa(r.Status)] = resp
 	}
 
-	if action.Payload != nil {
+	var consumes []string
+	if action.Payload != nil && action.PayloadMultipart {
+		// Multipart payloads are described with one form parameter per payload attribute
+		action.Payload.ToObject().IterateAttributes(func(n string, at *design.AttributeDefinition) error {
+			params = append(params, paramFor(at, n, "formData", action.Payload.IsRequired(n)))
+			return nil
+		})
+		consumes = []string{"multipart/form-data"}
+	} else if action.Payload != nil {
 		payloadSchema := genschema.TypeSchema(api, action.Payload)
 		pp := &Parameter{
 			Name:        "payload",
@@ -767,18 +830,6 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 		params = append(params, pp)
 	}
 
-	operationID := fmt.Sprintf("%s#%s", action.Parent.Name, action.Name)
-	index := 0
-	for i, rt := range action.Routes {
-		if rt == route {
-			index = i
-			break
-		}
-	}
-	if index > 0 {
-		operationID = fmt.Sprintf("%s#%d", operationID, index)
-	}
-
 	schemes := action.Schemes
 	if len(schemes) == 0 {
 		schemes = api.Schemes
@@ -789,11 +840,13 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 		Description:  action.Description,
 		Summary:      summaryFromDefinition(action.Name+" "+action.Parent.Name, action.Metadata),
 		ExternalDocs: docsFromDefinition(action.Docs),
-		OperationID:  operationID,
+		OperationID:  actionOperationID(route),
+		Consumes:     consumes,
 		Parameters:   params,
 		Responses:    responses,
 		Schemes:      schemes,
-		Deprecated:   false,
+		Deprecated:   deprecated(action.Metadata),
+		Middleware:   action.EffectiveMiddleware(),
 	}
 
 	applySecurity(operation, action.Security)
@@ -839,6 +892,20 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 	return nil
 }
 
+// actionOperationID returns the ID of the operation corresponding to the given route. The IDs of the
+// operations of the routes other than the first route of an action are suffixed with the route
+// index.
+func actionOperationID(route *design.RouteDefinition) string {
+	action := route.Parent
+	id := fmt.Sprintf("%s#%s", action.Parent.Name, action.Name)
+	for i, rt := range action.Routes {
+		if rt == route && i > 0 {
+			return fmt.Sprintf("%s#%d", id, i)
+		}
+	}
+	return id
+}
+
 func applySecurity(operation *Operation, security *design.SecurityDefinition) {
 	if security != nil && security.Scheme.Kind != design.NoSecurityKind {
 		if security.Scheme.Kind == design.JWTSecurityKind {
@@ -876,7 +943,11 @@ func docsFromDefinition(docs *design.DocsDefinition) *ExternalDocs {
 	}
 }
 
-func initEnumValidation(def interface{}, values []interface{}) {
+func initEnumValidation(def interface{}, values []interface{}, open bool) {
+	if open {
+		initExtensibleEnumValidation(def, values)
+		return
+	}
 	switch actual := def.(type) {
 	case *Parameter:
 		actual.Enum = values
@@ -887,6 +958,17 @@ func initEnumValidation(def interface{}, values []interface{}) {
 	}
 }
 
+func initExtensibleEnumValidation(def interface{}, values []interface{}) {
+	switch actual := def.(type) {
+	case *Parameter:
+		actual.ExtensibleEnum = values
+	case *Header:
+		actual.ExtensibleEnum = values
+	case *Items:
+		actual.ExtensibleEnum = values
+	}
+}
+
 func initFormatValidation(def interface{}, format string) {
 	switch actual := def.(type) {
 	case *Parameter:
@@ -972,7 +1054,7 @@ func initValidations(attr *design.AttributeDefinition, def interface{}) {
 	if val == nil {
 		return
 	}
-	initEnumValidation(def, val.Values)
+	initEnumValidation(def, val.Values, val.OpenEnum)
 	initFormatValidation(def, val.Format)
 	initPatternValidation(def, val.Pattern)
 	if val.Minimum != nil {
@@ -341,6 +341,137 @@ var _ = Describe("New", func() {
 			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
 		})
 
+		Context("with a response example", func() {
+			var example = map[string]interface{}{"code": "not_found", "status": 404}
+
+			BeforeEach(func() {
+				Resource("res", func() {
+					Action("show", func() {
+						Routing(GET("/:id"))
+						Response(NoContent)
+						Response(NotFound, ErrorMedia, func() {
+							Example(example)
+						})
+					})
+				})
+			})
+
+			It("sets the response examples", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				Ω(swagger.Paths["/{id}"]).ShouldNot(BeNil())
+				get := swagger.Paths["/{id}"].Get
+				Ω(get).ShouldNot(BeNil())
+				Ω(get.Responses["404"]).ShouldNot(BeNil())
+				Ω(get.Responses["404"].Examples).Should(Equal(map[string]interface{}{"application/json": example}))
+				Ω(get.Responses["204"].Examples).Should(BeNil())
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
+		Context("with caching headers", func() {
+			BeforeEach(func() {
+				Resource("res", func() {
+					Action("show", func() {
+						Routing(GET("/:id"))
+						Response(OK, "text/plain", func() {
+							CacheControl(func() {
+								Public()
+								MaxAge(60)
+								SharedMaxAge(300)
+							})
+							Vary("Accept-Language")
+							SurrogateKey("res")
+						})
+					})
+				})
+			})
+
+			It("documents the caching headers", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				Ω(swagger.Paths["/{id}"]).ShouldNot(BeNil())
+				get := swagger.Paths["/{id}"].Get
+				Ω(get).ShouldNot(BeNil())
+				headers := get.Responses["200"].Headers
+				Ω(headers).Should(HaveKey("Cache-Control"))
+				Ω(headers["Cache-Control"].Default).Should(Equal("public, max-age=60, s-maxage=300"))
+				Ω(headers["Vary"].Default).Should(Equal("Accept-Language"))
+				Ω(headers["Surrogate-Key"].Default).Should(Equal("res"))
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
+		Context("with a child resource action with relative and absolute routes", func() {
+			BeforeEach(func() {
+				Resource("parent", func() {
+					BasePath("/parents")
+					Action("show", func() {
+						Routing(GET("/:parentID"))
+						Response(OK, "text/plain")
+					})
+				})
+				Resource("child", func() {
+					Parent("parent")
+					BasePath("/children")
+					Action("list", func() {
+						Routing(GET(""), GET("//children"))
+						Response(OK, "text/plain")
+					})
+				})
+			})
+
+			It("only documents the wildcards of each route", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				relative := swagger.Paths["/base/parents/{parentID}/children"]
+				Ω(relative).ShouldNot(BeNil())
+				Ω(relative.Get.Parameters).Should(HaveLen(1))
+				Ω(relative.Get.Parameters[0].Name).Should(Equal("parentID"))
+				Ω(relative.Get.Parameters[0].In).Should(Equal("path"))
+				absolute := swagger.Paths["/children"]
+				Ω(absolute).ShouldNot(BeNil())
+				Ω(absolute.Get.Parameters).Should(BeEmpty())
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
+		Context("with a multipart payload", func() {
+			BeforeEach(func() {
+				Resource("res", func() {
+					Action("upload", func() {
+						Routing(POST("/upload"))
+						MultipartPayload(func() {
+							Attribute("name", String)
+							Attribute("label", File)
+							Required("label")
+						})
+						Response(NoContent)
+					})
+				})
+			})
+
+			It("describes the payload with form parameters", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				Ω(swagger.Paths["/upload"]).ShouldNot(BeNil())
+				post := swagger.Paths["/upload"].Post
+				Ω(post).ShouldNot(BeNil())
+				Ω(post.Consumes).Should(Equal([]string{"multipart/form-data"}))
+				Ω(post.Parameters).Should(HaveLen(2))
+				params := make(map[string]*genswagger.Parameter)
+				for _, p := range post.Parameters {
+					Ω(p.In).Should(Equal("formData"))
+					params[p.Name] = p
+				}
+				Ω(params["label"].Type).Should(Equal("file"))
+				Ω(params["label"].Required).Should(BeTrue())
+				Ω(params["name"].Type).Should(Equal("string"))
+				Ω(params["name"].Required).Should(BeFalse())
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
 		Context("with resources", func() {
 			var (
 				minLength1  = 1
@@ -406,6 +537,7 @@ var _ = Describe("New", func() {
 					Action("Update", func() {
 						Metadata("swagger:tag:Update")
 						Metadata("swagger:summary", "a summary")
+						Metadata("deprecated", "use the replace action instead")
 						Description("Update account")
 						Docs(func() {
 							Description("docs")
@@ -492,7 +624,8 @@ var _ = Describe("New", func() {
 				Ω(ps[12]).Should(Equal(&genswagger.Parameter{In: "header", Name: "header", Type: "string", Required: true}))
 				Ω(swagger.Paths["/base/bottles/{id}"]).ShouldNot(BeNil())
 				Ω(swagger.Paths["/base/bottles/{id}"].Put).ShouldNot(BeNil())
-				Ω(swagger.Paths["/base/bottles/{id}"].Put.Parameters).Should(HaveLen(14))
+				// The "org" wildcard of the other route is not a parameter of this route.
+				Ω(swagger.Paths["/base/bottles/{id}"].Put.Parameters).Should(HaveLen(13))
 			})
 
 			It("should set the inherited tag and the action tag", func() {
@@ -505,6 +638,10 @@ var _ = Describe("New", func() {
 				Ω(swagger.Paths["/orgs/{org}/accounts/{id}"].Put.Summary).Should(Equal("a summary"))
 			})
 
+			It("flags deprecated actions", func() {
+				Ω(swagger.Paths["/orgs/{org}/accounts/{id}"].Put.Deprecated).Should(BeTrue())
+			})
+
 			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
 		})
 	})
@@ -0,0 +1,11 @@
+/*
+Package gents provides a generator for a TypeScript client module. The module exports the
+interfaces of the API media types (one per view), user types and request payloads as well as a
+Client class with one method per action. The client uses the fetch API to make the requests and
+rejects the promises returned by the action methods with a ResponseError whose error field matches
+the goa ErrorResponse when the response status is not 2xx.
+
+The generated module has no dependency, it works in browsers and in Node.js versions that provide
+fetch (or with a fetch implementation given to the client constructor).
+*/
+package gents
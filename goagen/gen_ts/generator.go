@@ -0,0 +1,658 @@
+package gents
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/goadesign/goa/version"
+)
+
+// Generator is the TypeScript client generator.
+type Generator struct {
+	API      *design.APIDefinition // The API definition
+	OutDir   string                // Path to output directory
+	Scheme   string                // Scheme used to build the default API base URL
+	Host     string                // Host used to build the default API base URL
+	genfiles []string              // Generated files
+}
+
+type (
+	// tsType is a TypeScript interface or type alias.
+	tsType struct {
+		// Name is the type name.
+		Name string
+		// Description is the type description if any.
+		Description string
+		// Alias is the aliased type for type aliases, empty for interfaces.
+		Alias string
+		// Fields lists the interface fields sorted by name.
+		Fields []*tsField
+	}
+
+	// tsField is a field of a TypeScript interface.
+	tsField struct {
+		// Name is the field name, quoted if it is not a valid identifier.
+		Name string
+		// Type is the field type.
+		Type string
+		// Optional is true if the field may be omitted.
+		Optional bool
+		// Description is the field description if any.
+		Description string
+	}
+
+	// method is a client method that calls an action.
+	method struct {
+		// Name is the method name, e.g. "showBottle".
+		Name string
+		// Description is the method description.
+		Description string
+		// Verb is the HTTP method of the request.
+		Verb string
+		// Path is the content of the template literal that builds the request path.
+		Path string
+		// Args lists the method arguments including their types.
+		Args []string
+		// Payload is true if the action accepts a payload.
+		Payload bool
+		// Query is true if the action accepts query string parameters.
+		Query bool
+		// Result is the type of the value the method promise resolves to.
+		Result string
+		// Stream is true if the method resolves to the raw fetch response.
+		Stream bool
+	}
+
+	// types collects the TypeScript types referenced by the client.
+	types struct {
+		byName map[string]*tsType
+	}
+)
+
+// identifierRegex matches valid TypeScript identifiers.
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate() (files []string, err error) {
+	var outDir, scheme, host, ver string
+
+	set := flag.NewFlagSet("ts", flag.PanicOnError)
+	set.String("design", "", "")
+	set.StringVar(&outDir, "out", "", "")
+	set.StringVar(&scheme, "scheme", "", "")
+	set.StringVar(&host, "host", "", "")
+	set.StringVar(&ver, "version", "", "")
+	set.Parse(os.Args[1:])
+
+	if err := codegen.CheckVersion(ver); err != nil {
+		return nil, err
+	}
+
+	g := &Generator{OutDir: outDir, Scheme: scheme, Host: host, API: design.Design}
+
+	return g.Generate()
+}
+
+// Generate produces the TypeScript client module.
+func (g *Generator) Generate() (_ []string, err error) {
+	if g.API == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	if g.Scheme == "" && len(g.API.Schemes) > 0 {
+		g.Scheme = g.API.Schemes[0]
+	}
+	if g.Scheme == "" {
+		g.Scheme = "http"
+	}
+	if g.Host == "" {
+		g.Host = g.API.Host
+	}
+	// Clients served by the API itself make requests relative to the page origin.
+	var baseURL string
+	if g.Host != "" {
+		baseURL = g.Scheme + "://" + g.Host
+	}
+
+	ts := &types{byName: make(map[string]*tsType)}
+	err = g.API.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		if mt.IsError() {
+			return nil
+		}
+		views := mt.ComputeViews()
+		names := make([]string, 0, len(views))
+		for n := range views {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			p, _, err := mt.Project(n)
+			if err != nil {
+				return err
+			}
+			ts.ref(&design.AttributeDefinition{Type: p})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	g.API.IterateUserTypes(func(u *design.UserTypeDefinition) error {
+		ts.ref(&design.AttributeDefinition{Type: u})
+		return nil
+	})
+	methods, err := g.methods(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	outDir := filepath.Join(g.OutDir, "ts")
+	if err = os.RemoveAll(outDir); err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, outDir)
+
+	data := map[string]interface{}{
+		"API":         g.API,
+		"BaseURL":     baseURL,
+		"Types":       ts.sorted(),
+		"Methods":     methods,
+		"ToolVersion": version.String(),
+	}
+	funcs := template.FuncMap{
+		"doc":   doc,
+		"join":  strings.Join,
+		"quote": strconv.Quote,
+	}
+	if err = g.generate(filepath.Join(outDir, "client.ts"), clientT, funcs, data); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.RemoveAll(f)
+	}
+	g.genfiles = nil
+}
+
+// generate renders the given template into the file with the given name.
+func (g *Generator) generate(filename, tmpl string, funcs template.FuncMap, data interface{}) error {
+	t, err := template.New(filepath.Base(filename)).Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		panic(err) // bug
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	g.genfiles = append(g.genfiles, filename)
+	return t.Execute(file, data)
+}
+
+// methods builds the client methods of all the API actions, the types of the payloads and
+// responses are added to ts.
+func (g *Generator) methods(ts *types) ([]*method, error) {
+	var methods []*method
+	err := g.API.IterateResources(func(r *design.ResourceDefinition) error {
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			if len(a.Routes) == 0 {
+				return nil
+			}
+			m, err := g.method(a, ts)
+			if err != nil {
+				return err
+			}
+			methods = append(methods, m)
+			return nil
+		})
+	})
+	return methods, err
+}
+
+// method builds the client method that calls the given action.
+func (g *Generator) method(a *design.ActionDefinition, ts *types) (*method, error) {
+	route := a.Routes[0]
+	m := &method{
+		Name: codegen.Goify(a.Name, false) + codegen.Goify(a.Parent.Name, true),
+		Verb: route.Verb,
+	}
+	desc := a.Description
+	if desc == "" {
+		desc = fmt.Sprintf("%s calls the %s action of the %s resource.", m.Name, a.Name, a.Parent.Name)
+	}
+	m.Description = fmt.Sprintf("%s\n\n%s %s", desc, route.Verb, route.FullPath())
+
+	params := a.AllParams().Type.ToObject()
+	path := strings.NewReplacer("`", "\\`", "${", "\\${").Replace(route.FullPath())
+	m.Path = design.WildcardRegex.ReplaceAllStringFunc(path, func(w string) string {
+		n := design.WildcardRegex.FindStringSubmatch(w)[1]
+		return "/${encodeURIComponent(String(" + codegen.Goify(n, false) + "))}"
+	})
+	for _, n := range route.Params() {
+		typ := "string"
+		if att, ok := params[n]; ok {
+			typ = ts.ref(att)
+		}
+		m.Args = append(m.Args, codegen.Goify(n, false)+": "+typ)
+	}
+
+	query := ""
+	required := false
+	if a.QueryParams != nil && len(a.QueryParams.Type.ToObject()) > 0 {
+		query = ts.ref(a.QueryParams)
+		for n := range a.QueryParams.Type.ToObject() {
+			if a.QueryParams.IsRequired(n) {
+				required = true
+				break
+			}
+		}
+	}
+	if a.Payload != nil {
+		m.Payload = true
+		payload := ts.ref(&design.AttributeDefinition{Type: a.Payload})
+		switch {
+		case !a.PayloadOptional:
+			m.Args = append(m.Args, "payload: "+payload)
+		case required:
+			// Optional arguments cannot precede the required query string parameters.
+			m.Args = append(m.Args, "payload: "+payload+" | undefined")
+		default:
+			m.Args = append(m.Args, "payload?: "+payload)
+		}
+	}
+	if query != "" {
+		m.Query = true
+		if required {
+			m.Args = append(m.Args, "params: "+query)
+		} else {
+			m.Args = append(m.Args, "params?: "+query)
+		}
+	}
+	m.Args = append(m.Args, "init?: RequestInit")
+
+	result, stream, err := g.result(a, ts)
+	if err != nil {
+		return nil, err
+	}
+	m.Result = result
+	m.Stream = stream
+	return m, nil
+}
+
+// result returns the type of the body of the action 2xx responses. stream is true if a 2xx
+// response is streamed in which case the type is the fetch Response.
+func (g *Generator) result(a *design.ActionDefinition, ts *types) (result string, stream bool, err error) {
+	var statuses []int
+	byStatus := make(map[int]*design.ResponseDefinition)
+	for _, r := range a.Responses {
+		if r.Status < 200 || r.Status >= 300 {
+			continue
+		}
+		statuses = append(statuses, r.Status)
+		byStatus[r.Status] = r
+	}
+	sort.Ints(statuses)
+	var results []string
+	seen := make(map[string]bool)
+	for _, s := range statuses {
+		r := byStatus[s]
+		if r.Stream != "" {
+			return "Response", true, nil
+		}
+		t := "void"
+		switch {
+		case r.Type != nil:
+			t = ts.ref(&design.AttributeDefinition{Type: r.Type})
+		case r.MediaType != "":
+			t = "any"
+			if mt := g.API.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+				view := r.ViewName
+				if view == "" {
+					view = design.DefaultView
+				}
+				p, _, err := mt.Project(view)
+				if err != nil {
+					return "", false, err
+				}
+				t = ts.ref(&design.AttributeDefinition{Type: p})
+			}
+		}
+		if !seen[t] {
+			seen[t] = true
+			results = append(results, t)
+		}
+	}
+	if len(results) == 0 {
+		return "void", false, nil
+	}
+	return strings.Join(results, " | "), false, nil
+}
+
+// ref returns the TypeScript type of the given attribute, the user types and media types it
+// references are added to ts.
+func (ts *types) ref(att *design.AttributeDefinition) string {
+	switch t := att.Type.(type) {
+	case *design.MediaTypeDefinition:
+		if t.Identifier != "" && t.IsError() {
+			return "ErrorResponse"
+		}
+		return ts.define(t.UserTypeDefinition)
+	case *design.UserTypeDefinition:
+		return ts.define(t)
+	case design.Object:
+		fields := ts.fields(att)
+		if len(fields) == 0 {
+			return "{}"
+		}
+		defs := make([]string, len(fields))
+		for i, f := range fields {
+			opt := ""
+			if f.Optional {
+				opt = "?"
+			}
+			defs[i] = f.Name + opt + ": " + f.Type
+		}
+		return "{ " + strings.Join(defs, "; ") + " }"
+	case *design.Array:
+		elem := ts.ref(t.ElemType)
+		if strings.Contains(elem, "|") && !strings.HasPrefix(elem, "{") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case *design.Hash:
+		return "{ [key: string]: " + ts.ref(t.ElemType) + " }"
+	case design.Primitive:
+		return primitiveRef(att, t)
+	}
+	return "any"
+}
+
+// primitiveRef returns the TypeScript type of the given primitive attribute, enums are rendered
+// as unions of literal types.
+func primitiveRef(att *design.AttributeDefinition, t design.Primitive) string {
+	if att.Validation != nil && len(att.Validation.Values) > 0 {
+		values := make([]string, len(att.Validation.Values))
+		for i, v := range att.Validation.Values {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "any"
+			}
+			values[i] = string(b)
+		}
+		return strings.Join(values, " | ")
+	}
+	switch t.Kind() {
+	case design.BooleanKind:
+		return "boolean"
+	case design.IntegerKind, design.NumberKind:
+		return "number"
+	case design.StringKind, design.DateTimeKind, design.UUIDKind:
+		return "string"
+	case design.FileKind:
+		return "Blob"
+	}
+	return "any"
+}
+
+// define adds the interface or type alias of the given user type to ts if not already defined and
+// returns its name.
+func (ts *types) define(u *design.UserTypeDefinition) string {
+	name := codegen.Goify(u.TypeName, true)
+	if _, ok := ts.byName[name]; ok {
+		return name
+	}
+	def := &tsType{Name: name, Description: u.Description}
+	ts.byName[name] = def // define before recursing to support recursive types
+	if u.Type.IsObject() {
+		def.Fields = ts.fields(u.AttributeDefinition)
+	} else {
+		def.Alias = ts.ref(u.AttributeDefinition)
+	}
+	return name
+}
+
+// fields returns the fields of the given object attribute sorted by name.
+func (ts *types) fields(att *design.AttributeDefinition) []*tsField {
+	o := att.Type.ToObject()
+	names := make([]string, 0, len(o))
+	for n := range o {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fields := make([]*tsField, len(names))
+	for i, n := range names {
+		name := n
+		if !identifierRegex.MatchString(n) {
+			name = strconv.Quote(n)
+		}
+		fields[i] = &tsField{
+			Name:        name,
+			Type:        ts.ref(o[n]),
+			Optional:    !att.IsRequired(n),
+			Description: o[n].Description,
+		}
+	}
+	return fields
+}
+
+// sorted returns the collected types sorted by name.
+func (ts *types) sorted() []*tsType {
+	names := make([]string, 0, len(ts.byName))
+	for n := range ts.byName {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	sorted := make([]*tsType, len(names))
+	for i, n := range names {
+		sorted[i] = ts.byName[n]
+	}
+	return sorted
+}
+
+// doc returns the JSDoc comment containing text indented with indent, the empty string if text
+// is empty.
+func doc(indent, text string) string {
+	text = strings.TrimSpace(strings.Replace(text, "*/", "*\\/", -1))
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return indent + "/** " + text + " */\n"
+	}
+	var b bytes.Buffer
+	b.WriteString(indent + "/**\n")
+	for _, l := range lines {
+		b.WriteString(strings.TrimRight(indent+" * "+l, " ") + "\n")
+	}
+	b.WriteString(indent + " */\n")
+	return b.String()
+}
+
+const clientT = `// {{ .API.Name }} TypeScript client generated by goagen {{ .ToolVersion }}, DO NOT EDIT.
+//
+// Usage:
+//
+//    const client = new Client({ headers: { Authorization: "Bearer " + token } });
+//
+// The client has one method per API action, the methods reject with a ResponseError when the API
+// responds with a non 2xx status.
+{{ range .Types }}
+{{ doc "" .Description }}{{ if .Alias }}export type {{ .Name }} = {{ .Alias }};
+{{ else }}export interface {{ .Name }} {
+{{ range .Fields }}{{ doc "  " .Description }}  {{ .Name }}{{ if .Optional }}?{{ end }}: {{ .Type }};
+{{ end }}}
+{{ end }}{{ end }}
+/** ErrorResponse is the body of the error responses written by goa. */
+export interface ErrorResponse {
+  /** Unique error instance identifier */
+  id: string;
+  /** Class of errors, e.g. "invalid_value" */
+  code: string;
+  /** HTTP status code of the response */
+  status: number;
+  /** Description of the specific error occurrence */
+  detail: string;
+  /** Additional key/value pairs */
+  meta?: { [key: string]: any }[] | { [key: string]: any };
+  /** Validation errors, one per failing parameter, header or payload attribute */
+  errors?: FieldError[];
+}
+
+/** FieldError describes the validation error of a single parameter, header or payload attribute. */
+export interface FieldError {
+  /** Name of the parameter or header or path to the payload attribute */
+  field: string;
+  /** Validation that failed, e.g. "missing" or "invalid_format" */
+  code: string;
+  /** Error description */
+  message: string;
+  /** Invalid value if any */
+  value?: any;
+}
+
+/** ResponseError is the error raised when the API responds with a non 2xx status. */
+export class ResponseError extends Error {
+  /** HTTP status code of the response */
+  readonly status: number;
+  /** Decoded response body */
+  readonly body: any;
+  /** Error response if the body is a goa error */
+  readonly error?: ErrorResponse;
+
+  constructor(status: number, statusText: string, body: any) {
+    super(status + " " + statusText);
+    Object.setPrototypeOf(this, ResponseError.prototype);
+    this.name = "ResponseError";
+    this.status = status;
+    this.body = body;
+    if (body && typeof body === "object" && "code" in body) {
+      this.error = body as ErrorResponse;
+      if (this.error.detail) {
+        this.message = this.error.detail;
+      }
+    }
+  }
+}
+
+/** ClientOptions configures a Client. */
+export interface ClientOptions {
+  /** API base URL, defaults to {{ if .BaseURL }}{{ quote .BaseURL }}{{ else }}the origin of the page{{ end }} */
+  baseURL?: string;
+  /** Headers set on all requests, e.g. Authorization */
+  headers?: HeadersInit;
+  /** fetch implementation, defaults to the global fetch */
+  fetch?: typeof fetch;
+}
+
+/** Query contains the query string parameters of a request. */
+type Query = { [name: string]: any };
+
+/** Client makes requests to the {{ .API.Name }} API. */
+export class Client {
+  private readonly baseURL: string;
+  private readonly headers?: HeadersInit;
+  private readonly fetchFn: typeof fetch;
+
+  constructor(options: ClientOptions = {}) {
+    this.baseURL = (options.baseURL !== undefined ? options.baseURL : {{ quote .BaseURL }}).replace(/\/+$/, "");
+    this.headers = options.headers;
+    this.fetchFn = options.fetch || fetch;
+  }
+{{ range .Methods }}
+{{ doc "  " .Description }}  {{ .Name }}({{ join .Args ", " }}): Promise<{{ .Result }}> {
+    return this.{{ if .Stream }}send{{ else }}request<{{ .Result }}>{{ end }}({{ quote .Verb }}, ` + "`{{ .Path }}`" + `, {{ if .Query }}params{{ else }}undefined{{ end }}, {{ if .Payload }}payload{{ else }}undefined{{ end }}, init);
+  }
+{{ end }}
+  /** request makes a request and decodes the response body. */
+  private async request<T>(method: string, path: string, query?: Query, body?: any, init?: RequestInit): Promise<T> {
+    const resp = await this.send(method, path, query, body, init);
+    return (await decode(resp)) as T;
+  }
+
+  /** send makes a request and rejects with a ResponseError if the response status is not 2xx. */
+  private async send(method: string, path: string, query?: Query, body?: any, init?: RequestInit): Promise<Response> {
+    const headers = new Headers(this.headers);
+    new Headers(init && init.headers).forEach((value, name) => headers.set(name, value));
+    if (!headers.has("Accept")) {
+      headers.set("Accept", "application/json");
+    }
+    if (body !== undefined && !headers.has("Content-Type")) {
+      headers.set("Content-Type", "application/json");
+    }
+    const f = this.fetchFn;
+    const resp = await f(this.baseURL + path + encodeQuery(query), {
+      ...init,
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (!resp.ok) {
+      throw new ResponseError(resp.status, resp.statusText, await decode(resp));
+    }
+    return resp;
+  }
+}
+
+/** decode reads the response body, bodies that are not text are decoded as JSON when possible. */
+async function decode(resp: Response): Promise<any> {
+  const text = await resp.text();
+  if (text === "") {
+    return undefined;
+  }
+  if (!/^text\//.test(resp.headers.get("Content-Type") || "")) {
+    try {
+      return JSON.parse(text);
+    } catch (e) {
+      // not JSON
+    }
+  }
+  return text;
+}
+
+/** encodeQuery returns the query string for the given parameters, arrays are encoded as repeated keys. */
+function encodeQuery(query?: Query): string {
+  if (!query) {
+    return "";
+  }
+  const parts: string[] = [];
+  Object.keys(query).sort().forEach((name) => {
+    const value = query[name];
+    if (value === undefined || value === null) {
+      return;
+    }
+    (Array.isArray(value) ? value : [value]).forEach((v) => {
+      parts.push(encodeURIComponent(name) + "=" + encodeURIComponent(String(v)));
+    });
+  });
+  return parts.length > 0 ? "?" + parts.join("&") : "";
+}
+`
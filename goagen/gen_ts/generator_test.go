@@ -0,0 +1,114 @@
+package gents_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_ts"
+	"github.com/goadesign/goa/version"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var outDir string
+	var files []string
+	var genErr error
+
+	BeforeEach(func() {
+		var err error
+		outDir, err = ioutil.TempDir("", "ts")
+		Ω(err).ShouldNot(HaveOccurred())
+		dslengine.Reset()
+		API("test api", func() {
+			Host("example.com")
+			Scheme("https")
+		})
+		bottle := MediaType("application/vnd.bottle", func() {
+			Description("A bottle of wine")
+			Attributes(func() {
+				Attribute("id", Integer, "Unique bottle ID")
+				Attribute("name", String)
+				Attribute("color", String, func() {
+					Enum("red", "white")
+				})
+				Attribute("tags", ArrayOf(String))
+				Required("id", "name")
+			})
+			View("default", func() {
+				Attribute("id")
+				Attribute("name")
+				Attribute("color")
+				Attribute("tags")
+			})
+			View("tiny", func() {
+				Attribute("id")
+			})
+		})
+		Resource("bottle", func() {
+			BasePath("/bottles")
+			Action("show", func() {
+				Description("Retrieve a bottle")
+				Routing(GET("/:id"))
+				Params(func() {
+					Param("id", Integer)
+				})
+				Response(OK, bottle)
+				Response(NotFound, ErrorMedia)
+			})
+			Action("list", func() {
+				Routing(GET(""))
+				Params(func() {
+					Param("limit", Integer)
+				})
+				Response(OK, CollectionOf(bottle))
+			})
+			Action("create", func() {
+				Routing(POST(""))
+				Payload(func() {
+					Member("name", String)
+					Required("name")
+				})
+				Response(Created)
+			})
+		})
+		dslengine.Run()
+	})
+
+	JustBeforeEach(func() {
+		os.Args = []string{"goagen", "--out=" + outDir, "--design=foo", "--version=" + version.String()}
+		files, genErr = gents.Generate()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(outDir)
+	})
+
+	It("generates the TypeScript client", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(2))
+
+		content, err := ioutil.ReadFile(filepath.Join(outDir, "ts", "client.ts"))
+		Ω(err).ShouldNot(HaveOccurred())
+		client := string(content)
+		Ω(client).Should(ContainSubstring("export interface Bottle {\n"))
+		Ω(client).Should(ContainSubstring("  /** Unique bottle ID */\n  id: number;\n"))
+		Ω(client).Should(ContainSubstring(`  color?: "red" | "white";`))
+		Ω(client).Should(ContainSubstring("  tags?: string[];"))
+		Ω(client).Should(ContainSubstring("export interface BottleTiny {\n"))
+		Ω(client).Should(ContainSubstring("export type BottleCollection = Bottle[];"))
+		Ω(client).Should(ContainSubstring("export interface CreateBottlePayload {\n  name: string;\n}"))
+		Ω(client).Should(ContainSubstring("export interface ErrorResponse {"))
+		Ω(client).Should(ContainSubstring(`this.baseURL = (options.baseURL !== undefined ? options.baseURL : "https://example.com")`))
+		Ω(client).Should(ContainSubstring("  showBottle(id: number, init?: RequestInit): Promise<Bottle> {\n" +
+			"    return this.request<Bottle>(\"GET\", `/bottles/${encodeURIComponent(String(id))}`, undefined, undefined, init);"))
+		Ω(client).Should(ContainSubstring("  listBottle(params?: { limit?: number }, init?: RequestInit): Promise<BottleCollection> {"))
+		Ω(client).Should(ContainSubstring("  createBottle(payload: CreateBottlePayload, init?: RequestInit): Promise<void> {\n" +
+			"    return this.request<void>(\"POST\", `/bottles`, undefined, payload, init);"))
+	})
+})
@@ -75,7 +75,8 @@ package and tool and the Swagger specification for the API.
 
 	// mainCmd implements the "main" command.
 	var (
-		force bool
+		force    bool
+		debugTag string
 	)
 	mainCmd := &cobra.Command{
 		Use:   "main",
@@ -83,6 +84,7 @@ package and tool and the Swagger specification for the API.
 		Run:   func(c *cobra.Command, _ []string) { files, err = run("genmain", c) },
 	}
 	mainCmd.Flags().BoolVar(&force, "force", false, "overwrite existing files")
+	mainCmd.Flags().StringVar(&debugTag, "debug-tag", "debug", "build tag enabling the profiling, Swagger and echo endpoints in the generated main")
 	rootCmd.AddCommand(mainCmd)
 
 	// clientCmd implements the "client" command.
@@ -102,11 +104,13 @@ package and tool and the Swagger specification for the API.
 	rootCmd.AddCommand(clientCmd)
 
 	// swaggerCmd implements the "swagger" command.
+	var specVersion string
 	swaggerCmd := &cobra.Command{
 		Use:   "swagger",
 		Short: "Generate Swagger",
 		Run:   func(c *cobra.Command, _ []string) { files, err = run("genswagger", c) },
 	}
+	swaggerCmd.Flags().StringVar(&specVersion, "spec-version", "2.0", `Version of the generated specification: "2.0" (Swagger), "3.0" or "3.1" (OpenAPI 3)`)
 	rootCmd.AddCommand(swaggerCmd)
 
 	// jsCmd implements the "js" command.
@@ -126,6 +130,16 @@ package and tool and the Swagger specification for the API.
 	jsCmd.Flags().BoolVar(&noexample, "noexample", false, `Skip generation of example HTML and controller`)
 	rootCmd.AddCommand(jsCmd)
 
+	// tsCmd implements the "ts" command.
+	tsCmd := &cobra.Command{
+		Use:   "ts",
+		Short: "Generate TypeScript client",
+		Run:   func(c *cobra.Command, _ []string) { files, err = run("gents", c) },
+	}
+	tsCmd.Flags().StringVar(&scheme, "scheme", "", `the URL scheme used to build the default API base URL, defaults to the scheme defined in the API design if any.`)
+	tsCmd.Flags().StringVar(&host, "host", "", `the API hostname used to build the default API base URL, defaults to the hostname defined in the API design if any`)
+	rootCmd.AddCommand(tsCmd)
+
 	// schemaCmd implements the "schema" command.
 	schemaCmd := &cobra.Command{
 		Use:   "schema",
@@ -134,6 +148,52 @@ package and tool and the Swagger specification for the API.
 	}
 	rootCmd.AddCommand(schemaCmd)
 
+	// storageCmd implements the "storage" command.
+	var (
+		appPkg string
+	)
+	storageCmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Generate database models and repositories",
+		Run:   func(c *cobra.Command, _ []string) { files, err = run("genstorage", c) },
+	}
+	storageCmd.Flags().StringVar(&pkg, "pkg", "storage", "Name of generated Go package containing the models and repository interfaces")
+	storageCmd.Flags().StringVar(&appPkg, "app-pkg", "app", "Name of the generated application package containing the media types and payloads")
+	rootCmd.AddCommand(storageCmd)
+
+	// loadtestCmd implements the "loadtest" command.
+	loadtestCmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Generate load test scenarios",
+		Run:   func(c *cobra.Command, _ []string) { files, err = run("genloadtest", c) },
+	}
+	loadtestCmd.Flags().StringVar(&tool, "tool", "k6", `Load testing tool, one of "k6" or "vegeta"`)
+	loadtestCmd.Flags().StringVar(&scheme, "scheme", "", `the URL scheme used to make requests to the API, defaults to the scheme defined in the API design if any.`)
+	loadtestCmd.Flags().StringVar(&host, "host", "", `the API hostname, defaults to the hostname defined in the API design if any`)
+	rootCmd.AddCommand(loadtestCmd)
+
+	// governanceCmd implements the "governance" command.
+	var rules, date string
+	governanceCmd := &cobra.Command{
+		Use:   "governance",
+		Short: "Generate API governance report",
+		Run:   func(c *cobra.Command, _ []string) { files, err = run("gengovernance", c) },
+	}
+	governanceCmd.Flags().StringVar(&rules, "rules", "", "path to the YAML file defining the scoring rules")
+	governanceCmd.Flags().StringVar(&date, "date", "", "date the deprecation sunset dates are checked against (YYYY-MM-DD), defaults to today")
+	rootCmd.AddCommand(governanceCmd)
+
+	// compatCmd implements the "compat" command.
+	var traffic, consumerHeader string
+	compatCmd := &cobra.Command{
+		Use:   "compat",
+		Short: "Check recorded consumer traffic against the design",
+		Run:   func(c *cobra.Command, _ []string) { files, err = run("gencompat", c) },
+	}
+	compatCmd.Flags().StringVar(&traffic, "traffic", "", "comma separated paths to the HAR files or access logs recording the consumer traffic")
+	compatCmd.Flags().StringVar(&consumerHeader, "consumer-header", "User-Agent", "name of the request header identifying the consumers in HAR files")
+	rootCmd.AddCommand(compatCmd)
+
 	// genCmd implements the "gen" command.
 	var (
 		pkgPath string
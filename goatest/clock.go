@@ -0,0 +1,106 @@
+package goatest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+)
+
+type (
+	// Clock is a goa.Clock whose time only changes when Set or Advance is called. It makes it
+	// possible to test code that depends on time deterministically:
+	//
+	//	clock := goatest.NewClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+	//	service.WithClock(clock)
+	//	...
+	//	clock.Advance(time.Minute) // Fires the timers due within the next minute
+	Clock struct {
+		mu     sync.Mutex
+		now    time.Time
+		timers []*clockTimer
+	}
+
+	// clockTimer is a timer scheduled with Clock.AfterFunc.
+	clockTimer struct {
+		clock *Clock
+		at    time.Time
+		f     func()
+	}
+)
+
+// NewClock returns a clock set to the given time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the clock current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc schedules f to be called once the clock has advanced by d.
+func (c *Clock) AfterFunc(d time.Duration, f func()) goa.Timer {
+	c.mu.Lock()
+	t := &clockTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+	if d <= 0 {
+		c.Advance(0)
+	}
+	return t
+}
+
+// Advance moves the clock forward by d and synchronously calls the functions of the timers that
+// expire, in order.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	now := c.now.Add(d)
+	c.mu.Unlock()
+	c.Set(now)
+}
+
+// Set sets the clock time and synchronously calls the functions of the timers that expire, in
+// order.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	c.now = now
+	var due, pending []*clockTimer
+	for _, t := range c.timers {
+		if t.at.After(now) {
+			pending = append(pending, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	c.timers = pending
+	c.mu.Unlock()
+	sort.Stable(byExpiration(due))
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// Stop prevents the timer from firing.
+func (t *clockTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, tt := range c.timers {
+		if tt == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// byExpiration implements sort.Interface sorting timers by expiration time.
+type byExpiration []*clockTimer
+
+func (b byExpiration) Len() int           { return len(b) }
+func (b byExpiration) Less(i, j int) bool { return b[i].at.Before(b[j].at) }
+func (b byExpiration) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
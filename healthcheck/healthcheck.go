@@ -0,0 +1,245 @@
+/*
+Package healthcheck implements the health check endpoint of goa services. Services register named
+checks (e.g. a database ping or a call to a downstream API) with a Registry whose handler runs them
+concurrently, each with a timeout, and responds with the health of the service using the format
+described in https://tools.ietf.org/html/draft-inadarei-api-health-check.
+
+The code generated by goagen for the HealthCheck DSL serves the default registry so that services
+only need to register their checks:
+
+	healthcheck.Register("db", func(ctx context.Context) error {
+		return db.Ping()
+	})
+*/
+package healthcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+)
+
+// ContentType is the content type of health check responses.
+const ContentType = "application/health+json"
+
+// DefaultTimeout is the default maximum duration of a check.
+const DefaultTimeout = 5 * time.Second
+
+// Health check statuses.
+const (
+	// StatusPass indicates that the service or component is healthy.
+	StatusPass = "pass"
+	// StatusWarn indicates that the service is healthy but a non critical component failed.
+	StatusWarn = "warn"
+	// StatusFail indicates that the service or component is unhealthy.
+	StatusFail = "fail"
+)
+
+type (
+	// Check is a health check, it returns an error if the component it checks is unhealthy.
+	// Checks must return once ctx is done.
+	Check func(ctx context.Context) error
+
+	// Registry holds the checks that make up the health of a service. Registry is safe for
+	// concurrent use.
+	Registry struct {
+		// ServiceID is the unique identifier of the service reported in responses if not
+		// empty.
+		ServiceID string
+		// Version is the version of the service reported in responses if not empty.
+		Version string
+		// Timeout is the maximum duration of each check, checks that take longer fail.
+		// Defaults to DefaultTimeout.
+		Timeout time.Duration
+
+		mu     sync.Mutex
+		checks map[string]*check
+	}
+
+	// Health is the health of a service as described by the health check response format.
+	Health struct {
+		// Status is StatusPass, StatusWarn or StatusFail.
+		Status string `json:"status"`
+		// Version is the version of the service.
+		Version string `json:"version,omitempty"`
+		// ServiceID is the unique identifier of the service.
+		ServiceID string `json:"serviceId,omitempty"`
+		// Checks lists the results of the checks indexed by check name.
+		Checks map[string][]*Result `json:"checks,omitempty"`
+	}
+
+	// Result is the result of a check.
+	Result struct {
+		// Status is StatusPass, StatusWarn or StatusFail.
+		Status string `json:"status"`
+		// Time is the time the check ran in RFC 3339 format.
+		Time string `json:"time"`
+		// Output is the error message of failed checks.
+		Output string `json:"output,omitempty"`
+	}
+
+	// check is a registered check.
+	check struct {
+		run      Check
+		critical bool
+	}
+)
+
+// Default is the registry served by the code generated for the HealthCheck DSL.
+var Default = New()
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{Timeout: DefaultTimeout, checks: make(map[string]*check)}
+}
+
+// Register adds a critical check to the default registry, see Registry.Register.
+func Register(name string, c Check) {
+	Default.Register(name, c)
+}
+
+// RegisterNonCritical adds a non critical check to the default registry, see
+// Registry.RegisterNonCritical.
+func RegisterNonCritical(name string, c Check) {
+	Default.RegisterNonCritical(name, c)
+}
+
+// Handler returns the handler that serves the default registry, see Registry.Handler.
+func Handler() goa.Handler {
+	return Default.Handler()
+}
+
+// Downstream returns a check that fails while the downstream with the given name is marked down in
+// the given registry, see goa.DownstreamRegistry.
+func Downstream(downstreams *goa.DownstreamRegistry, name string) Check {
+	downstreams.Register(name)
+	return func(ctx context.Context) error {
+		return downstreams.Err(name)
+	}
+}
+
+// Register adds a critical check with the given name, the service is unhealthy when the check
+// fails. Register replaces any check previously registered with the same name.
+func (r *Registry) Register(name string, c Check) {
+	r.add(name, c, true)
+}
+
+// RegisterNonCritical adds a non critical check with the given name, the service health status is
+// "warn" when the check fails. RegisterNonCritical replaces any check previously registered with
+// the same name.
+func (r *Registry) RegisterNonCritical(name string, c Check) {
+	r.add(name, c, false)
+}
+
+// Run runs all the registered checks concurrently and returns the resulting health.
+func (r *Registry) Run(ctx context.Context) *Health {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.checks))
+	checks := make([]*check, 0, len(r.checks))
+	for n, c := range r.checks {
+		names = append(names, n)
+		checks = append(checks, c)
+	}
+	timeout := r.Timeout
+	r.mu.Unlock()
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	results := make([]*Result, len(checks))
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for i, c := range checks {
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.result(ctx, timeout)
+		}(i, c)
+	}
+	wg.Wait()
+
+	health := &Health{
+		Status:    StatusPass,
+		Version:   r.Version,
+		ServiceID: r.ServiceID,
+		Checks:    make(map[string][]*Result, len(checks)),
+	}
+	for i, res := range results {
+		health.Checks[names[i]] = []*Result{res}
+		switch {
+		case res.Status == StatusFail:
+			health.Status = StatusFail
+		case res.Status == StatusWarn && health.Status == StatusPass:
+			health.Status = StatusWarn
+		}
+	}
+	return health
+}
+
+// Handler returns the handler that runs the registered checks and writes the resulting health.
+// The response status code is 200 if the service is healthy and 503 otherwise.
+func (r *Registry) Handler() goa.Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		health := r.Run(ctx)
+		if health.Status == StatusFail {
+			var failed []string
+			for n, res := range health.Checks {
+				if res[0].Status == StatusFail {
+					failed = append(failed, n)
+				}
+			}
+			sort.Strings(failed)
+			goa.LogError(ctx, "health check failed", "checks", failed)
+		}
+		body, err := json.Marshal(health)
+		if err != nil {
+			return err
+		}
+		rw.Header().Set("Content-Type", ContentType)
+		rw.Header().Set("Cache-Control", "no-cache")
+		status := http.StatusOK
+		if health.Status == StatusFail {
+			status = http.StatusServiceUnavailable
+		}
+		rw.WriteHeader(status)
+		_, err = rw.Write(body)
+		return err
+	}
+}
+
+// add registers a check.
+func (r *Registry) add(name string, c Check, critical bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = &check{run: c, critical: critical}
+}
+
+// result runs the check and returns its result, the check fails if it does not return within
+// timeout.
+func (c *check) result(ctx context.Context, timeout time.Duration) *Result {
+	res := &Result{Status: StatusPass, Time: goa.ContextClock(ctx).Now().UTC().Format(time.RFC3339)}
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- c.run(cctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-cctx.Done():
+		err = fmt.Errorf("check did not complete within %s", timeout)
+	}
+	if err != nil {
+		res.Status = StatusFail
+		if !c.critical {
+			res.Status = StatusWarn
+		}
+		res.Output = err.Error()
+	}
+	return res
+}
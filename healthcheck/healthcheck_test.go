@@ -0,0 +1,93 @@
+package healthcheck_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/healthcheck"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Registry", func() {
+	var registry *healthcheck.Registry
+	var rw *httptest.ResponseRecorder
+	var health *healthcheck.Health
+
+	pass := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	BeforeEach(func() {
+		registry = healthcheck.New()
+		registry.Version = "1"
+		registry.Register("db", pass)
+	})
+
+	JustBeforeEach(func() {
+		req, _ := http.NewRequest("GET", "/healthz", nil)
+		rw = httptest.NewRecorder()
+		err := registry.Handler()(context.Background(), rw, req)
+		Ω(err).ShouldNot(HaveOccurred())
+		health = nil
+		Ω(json.Unmarshal(rw.Body.Bytes(), &health)).ShouldNot(HaveOccurred())
+	})
+
+	It("reports a healthy service", func() {
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("Content-Type")).Should(Equal(healthcheck.ContentType))
+		Ω(health.Status).Should(Equal(healthcheck.StatusPass))
+		Ω(health.Version).Should(Equal("1"))
+		Ω(health.Checks).Should(HaveKey("db"))
+		Ω(health.Checks["db"][0].Status).Should(Equal(healthcheck.StatusPass))
+		Ω(health.Checks["db"][0].Time).ShouldNot(BeEmpty())
+	})
+
+	Context("with a failing non critical check", func() {
+		BeforeEach(func() {
+			registry.RegisterNonCritical("cache", fail)
+		})
+
+		It("warns", func() {
+			Ω(rw.Code).Should(Equal(200))
+			Ω(health.Status).Should(Equal(healthcheck.StatusWarn))
+			Ω(health.Checks["cache"][0].Status).Should(Equal(healthcheck.StatusWarn))
+			Ω(health.Checks["cache"][0].Output).Should(Equal("connection refused"))
+		})
+	})
+
+	Context("with a failing critical check", func() {
+		BeforeEach(func() {
+			downstreams := goa.NewDownstreamRegistry()
+			registry.Register("ratings", healthcheck.Downstream(downstreams, "ratings"))
+			downstreams.MarkDown(context.Background(), "ratings", errors.New("ratings is down"))
+		})
+
+		It("fails", func() {
+			Ω(rw.Code).Should(Equal(503))
+			Ω(health.Status).Should(Equal(healthcheck.StatusFail))
+			Ω(health.Checks["ratings"][0].Output).Should(Equal("ratings is down"))
+			Ω(health.Checks["db"][0].Status).Should(Equal(healthcheck.StatusPass))
+		})
+	})
+
+	Context("with a check that times out", func() {
+		BeforeEach(func() {
+			registry.Timeout = 10 * time.Millisecond
+			registry.Register("slow", func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			})
+		})
+
+		It("fails the check", func() {
+			Ω(rw.Code).Should(Equal(503))
+			Ω(health.Checks["slow"][0].Status).Should(Equal(healthcheck.StatusFail))
+			Ω(health.Checks["slow"][0].Output).Should(ContainSubstring("did not complete"))
+		})
+	})
+})
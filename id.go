@@ -0,0 +1,46 @@
+package goa
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	// idMu protects idPrefix and idSeq.
+	idMu sync.Mutex
+	// idPrefix is the prefix of sequential IDs, nil if IDs are random.
+	idPrefix *string
+	// idSeq is the last sequence number used to produce a sequential ID.
+	idSeq int
+)
+
+// SequentialIDs makes the identifiers generated by goa and its middlewares deterministic: error IDs
+// and request IDs consist of the given prefix followed by a sequence number starting at 1, e.g.
+// "test-1", "test-2" etc. This is intended for tests that compare responses with golden files and
+// should not be used in production. Call RandomIDs to restore random identifiers.
+func SequentialIDs(prefix string) {
+	idMu.Lock()
+	defer idMu.Unlock()
+	idPrefix = &prefix
+	idSeq = 0
+}
+
+// RandomIDs restores the default behavior of generating random error IDs and request IDs.
+func RandomIDs() {
+	idMu.Lock()
+	defer idMu.Unlock()
+	idPrefix = nil
+	idSeq = 0
+}
+
+// NextSequentialID returns the next sequential ID and true if SequentialIDs is in effect, it
+// returns false otherwise. This is intended for middlewares that generate identifiers.
+func NextSequentialID() (string, bool) {
+	idMu.Lock()
+	defer idMu.Unlock()
+	if idPrefix == nil {
+		return "", false
+	}
+	idSeq++
+	return fmt.Sprintf("%s-%d", *idPrefix, idSeq), true
+}
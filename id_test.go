@@ -0,0 +1,43 @@
+package goa_test
+
+import (
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SequentialIDs", func() {
+	BeforeEach(func() {
+		goa.SequentialIDs("test")
+	})
+
+	AfterEach(func() {
+		goa.RandomIDs()
+	})
+
+	It("makes the error IDs deterministic", func() {
+		err1 := goa.ErrBadRequest("foo").(*goa.ErrorResponse)
+		err2 := goa.ErrBadRequest("bar").(*goa.ErrorResponse)
+		Ω(err1.ID).Should(Equal("test-1"))
+		Ω(err2.ID).Should(Equal("test-2"))
+	})
+
+	It("restarts the sequence", func() {
+		id, ok := goa.NextSequentialID()
+		Ω(ok).Should(BeTrue())
+		Ω(id).Should(Equal("test-1"))
+	})
+
+	Context("after RandomIDs is called", func() {
+		BeforeEach(func() {
+			goa.RandomIDs()
+		})
+
+		It("produces random IDs", func() {
+			_, ok := goa.NextSequentialID()
+			Ω(ok).Should(BeFalse())
+			err := goa.ErrBadRequest("foo").(*goa.ErrorResponse)
+			Ω(err.ID).ShouldNot(HavePrefix("test-"))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package goa
+
+import (
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// LifecycleHook is a function run when the service starts or shuts down, see OnStartup and
+// OnShutdown.
+type LifecycleHook func(ctx context.Context) error
+
+// DefaultShutdownSignals lists the signals that cause ListenAndServeGraceful to shut down the
+// service unless the service ShutdownSignals field is set.
+var DefaultShutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// OnStartup registers a hook run by ListenAndServeGraceful before the server starts, e.g. to open
+// a database connection pool. The hooks run in the order they were registered with the service
+// root context, the service does not start if a hook returns an error.
+func (service *Service) OnStartup(hook LifecycleHook) {
+	service.startupHooks = append(service.startupHooks, hook)
+}
+
+// OnShutdown registers a hook run by Shutdown once the in-flight requests completed or the drain
+// timed out, e.g. to close a database connection pool. The hooks run in the reverse order of
+// registration with the context given to Shutdown which may be done if the drain timed out. The
+// hooks run once even if Shutdown is called multiple times.
+func (service *Service) OnShutdown(hook LifecycleHook) {
+	service.shutdownHooks = append(service.shutdownHooks, hook)
+}
+
+// ListenAndServeGraceful runs the startup hooks, starts a HTTP server listening on the given
+// host/port and shuts the service down gracefully when the process receives one of the
+// ShutdownSignals (SIGINT or SIGTERM by default): the server stops accepting connections, the
+// in-flight requests are given up to timeout to complete (no limit if timeout is 0), the requests
+// still in flight after that are canceled and the shutdown hooks run. It returns nil once the
+// service is shut down unless a startup hook fails, the server fails to start or the drain times
+// out. The graceful shutdown relies on http.Server.Shutdown and thus requires Go 1.8 or later.
+func (service *Service) ListenAndServeGraceful(addr string, timeout time.Duration) error {
+	signals := service.ShutdownSignals
+	if len(signals) == 0 {
+		signals = DefaultShutdownSignals
+	}
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, signals...)
+	defer signal.Stop(sigc)
+
+	for _, hook := range service.startupHooks {
+		if err := hook(service.Context); err != nil {
+			service.LogError("startup hook failed", "err", err)
+			return err
+		}
+	}
+
+	service.LogInfo("listen", "transport", "http", "addr", addr)
+	srv := &http.Server{Addr: addr, Handler: service.Mux}
+	service.drain.addServer(srv)
+	errc := make(chan error, 1)
+	go func() { errc <- service.serve(srv, srv.ListenAndServe) }()
+
+	select {
+	case err := <-errc:
+		service.runShutdownHooks(context.Background())
+		return err
+	case sig := <-sigc:
+		service.LogInfo("shutdown", "signal", sig.String(), "timeout", timeout.String())
+	}
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := service.Shutdown(ctx)
+	if err != nil {
+		// Cancel the requests still in flight.
+		service.CancelAll()
+	}
+	if serr := <-errc; err == nil {
+		err = serr
+	}
+	return err
+}
+
+// runShutdownHooks runs the shutdown hooks in the reverse order of registration unless they
+// already ran and returns the first error.
+func (service *Service) runShutdownHooks(ctx context.Context) error {
+	if d := service.drain; d != nil {
+		d.mu.Lock()
+		ran := d.hooksRan
+		d.hooksRan = true
+		d.mu.Unlock()
+		if ran {
+			return nil
+		}
+	}
+	var err error
+	for i := len(service.shutdownHooks) - 1; i >= 0; i-- {
+		if herr := service.shutdownHooks[i](ctx); herr != nil {
+			service.LogError("shutdown hook failed", "err", herr)
+			if err == nil {
+				err = herr
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package goa_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Lifecycle hooks", func() {
+	var service *goa.Service
+	var calls []string
+
+	hook := func(name string, err error) goa.LifecycleHook {
+		return func(ctx context.Context) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.WithLogger(goa.NewLogger(log.New(ioutil.Discard, "", 0)))
+		calls = nil
+	})
+
+	It("runs the shutdown hooks once in reverse order", func() {
+		service.OnShutdown(hook("db", nil))
+		service.OnShutdown(hook("cache", nil))
+		Ω(service.Shutdown(context.Background())).Should(Succeed())
+		Ω(service.Shutdown(context.Background())).Should(Succeed())
+		Ω(calls).Should(Equal([]string{"cache", "db"}))
+	})
+
+	It("returns the shutdown hook errors", func() {
+		boom := errors.New("boom")
+		service.OnShutdown(hook("db", boom))
+		service.OnShutdown(hook("cache", nil))
+		Ω(service.Shutdown(context.Background())).Should(Equal(boom))
+		Ω(calls).Should(Equal([]string{"cache", "db"}))
+	})
+
+	Describe("ListenAndServeGraceful", func() {
+		It("does not start if a startup hook fails", func() {
+			boom := errors.New("boom")
+			service.OnStartup(hook("db", boom))
+			service.OnStartup(hook("cache", nil))
+			Ω(service.ListenAndServeGraceful("127.0.0.1:0", time.Second)).Should(Equal(boom))
+			Ω(calls).Should(Equal([]string{"db"}))
+		})
+
+		It("shuts down on signal", func() {
+			started := make(chan struct{})
+			service.OnStartup(func(ctx context.Context) error {
+				close(started)
+				return nil
+			})
+			service.OnShutdown(hook("db", nil))
+			service.ShutdownSignals = []os.Signal{syscall.SIGHUP}
+			errc := make(chan error, 1)
+			go func() { errc <- service.ListenAndServeGraceful("127.0.0.1:0", time.Second) }()
+			<-started
+			p, err := os.FindProcess(os.Getpid())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(p.Signal(syscall.SIGHUP)).Should(Succeed())
+			Eventually(errc, 5*time.Second).Should(Receive(BeNil()))
+			Ω(calls).Should(Equal([]string{"db"}))
+			Ω(service.DrainStatus().Draining).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+// ErrOverloaded is the class of errors returned when a request is rejected because the number of
+// requests being handled reached the adaptive concurrency limit.
+var ErrOverloaded = goa.NewErrorClass("overloaded", 503)
+
+type (
+	// AdaptiveLimit configures adaptive concurrency limiters. The zero value uses the defaults.
+	AdaptiveLimit struct {
+		// Initial is the initial concurrency limit, defaults to 20.
+		Initial int
+		// Min is the minimum concurrency limit, defaults to 1.
+		Min int
+		// Max is the maximum concurrency limit, defaults to 1000.
+		Max int
+		// Tolerance is the ratio of the smoothed request latency to the no-load latency
+		// above which the service is considered overloaded, defaults to 2.
+		Tolerance float64
+		// Backoff is the factor applied to the limit when the service is overloaded,
+		// defaults to 0.9.
+		Backoff float64
+		// Window is the duration of the windows over which the no-load latency is measured
+		// as the minimum latency, defaults to 30 seconds.
+		Window time.Duration
+	}
+
+	// AdaptiveLimiter limits the number of requests handled concurrently using an AIMD
+	// (additive increase, multiplicative decrease) algorithm: the limit increases by one
+	// when a request completes without sign of overload while the limit is being used and
+	// decreases by the Backoff factor when the latency grows past Tolerance times the no-load
+	// latency or a request fails with a server error. AdaptiveLimiter is safe for concurrent
+	// use.
+	AdaptiveLimiter struct {
+		conf        AdaptiveLimit
+		mu          sync.Mutex
+		limit       float64
+		inFlight    int
+		latency     float64 // exponentially weighted moving average of the latency
+		minRTT      time.Duration
+		prevMinRTT  time.Duration
+		windowStart time.Time
+	}
+)
+
+// latencySmoothing is the weight of the last sample in the moving average of the latency.
+const latencySmoothing = 0.1
+
+// AdaptiveConcurrency is a middleware that protects the service from overload by limiting the
+// number of requests handled concurrently by each action. The limits are adjusted automatically
+// based on the observed latency (see AdaptiveLimiter) rather than configured by hand. Requests
+// that exceed the limit fail with ErrOverloaded.
+func AdaptiveConcurrency(conf AdaptiveLimit) goa.Middleware {
+	var mu sync.Mutex
+	limiters := make(map[string]*AdaptiveLimiter)
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			key := goa.ContextController(ctx) + "#" + goa.ContextAction(ctx)
+			mu.Lock()
+			l, ok := limiters[key]
+			if !ok {
+				l = NewAdaptiveLimiter(conf)
+				limiters[key] = l
+			}
+			mu.Unlock()
+			if !l.Acquire() {
+				return ErrOverloaded("too many concurrent requests", "limit", l.Limit())
+			}
+			clock := goa.ContextClock(ctx)
+			start := clock.Now()
+			failed := true // if h panics
+			defer func() { l.Release(start, clock.Now(), failed) }()
+			err := h(ctx, rw, req)
+			failed = serverError(ctx, err)
+			return err
+		}
+	}
+}
+
+// NewAdaptiveLimiter creates a limiter using the given configuration.
+func NewAdaptiveLimiter(conf AdaptiveLimit) *AdaptiveLimiter {
+	if conf.Min <= 0 {
+		conf.Min = 1
+	}
+	if conf.Max <= 0 {
+		conf.Max = 1000
+	}
+	if conf.Max < conf.Min {
+		conf.Max = conf.Min
+	}
+	if conf.Initial <= 0 {
+		conf.Initial = 20
+	}
+	if conf.Initial < conf.Min {
+		conf.Initial = conf.Min
+	}
+	if conf.Initial > conf.Max {
+		conf.Initial = conf.Max
+	}
+	if conf.Tolerance <= 1 {
+		conf.Tolerance = 2
+	}
+	if conf.Backoff <= 0 || conf.Backoff >= 1 {
+		conf.Backoff = 0.9
+	}
+	if conf.Window <= 0 {
+		conf.Window = 30 * time.Second
+	}
+	return &AdaptiveLimiter{conf: conf, limit: float64(conf.Initial)}
+}
+
+// Acquire reserves a slot for a request, it returns false if the limit is reached. Release must
+// be called once the request completes if Acquire returns true.
+func (l *AdaptiveLimiter) Acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight >= int(l.limit) {
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+// Release frees the slot of a request that started at start and completed at end and adjusts the
+// limit. failed indicates whether the request failed because of the service, e.g. with a server
+// error or a timeout.
+func (l *AdaptiveLimiter) Release(start, end time.Time, failed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	inFlight := l.inFlight
+	l.inFlight--
+
+	if end.Sub(l.windowStart) >= l.conf.Window {
+		l.prevMinRTT = l.minRTT
+		l.minRTT = 0
+		l.windowStart = end
+	}
+	rtt := end.Sub(start)
+	if !failed {
+		if l.minRTT == 0 || rtt < l.minRTT {
+			l.minRTT = rtt
+		}
+		if l.latency == 0 {
+			l.latency = float64(rtt)
+		} else {
+			l.latency += latencySmoothing * (float64(rtt) - l.latency)
+		}
+	}
+	noLoad := l.minRTT
+	if l.prevMinRTT > 0 && (noLoad == 0 || l.prevMinRTT < noLoad) {
+		noLoad = l.prevMinRTT
+	}
+
+	switch {
+	case failed || (noLoad > 0 && l.latency > l.conf.Tolerance*float64(noLoad)):
+		l.limit = math.Max(float64(l.conf.Min), math.Floor(l.limit*l.conf.Backoff))
+	case 2*inFlight >= int(l.limit):
+		l.limit = math.Min(float64(l.conf.Max), l.limit+1)
+	}
+}
+
+// Limit returns the current concurrency limit.
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of requests being handled.
+func (l *AdaptiveLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// serverError returns true if the request failed because of the service: the handler returned an
+// error that is not a client error or the response status is 5xx.
+func serverError(ctx context.Context, err error) bool {
+	if err != nil {
+		serr, ok := err.(goa.ServiceError)
+		return !ok || serr.ResponseStatus() >= 500
+	}
+	resp := goa.ContextResponse(ctx)
+	return resp != nil && resp.Status >= 500
+}
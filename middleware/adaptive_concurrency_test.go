@@ -0,0 +1,79 @@
+package middleware_test
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AdaptiveLimiter", func() {
+	var limiter *middleware.AdaptiveLimiter
+	var now time.Time
+
+	BeforeEach(func() {
+		limiter = middleware.NewAdaptiveLimiter(middleware.AdaptiveLimit{Initial: 2, Max: 3})
+		now = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	complete := func(latency time.Duration, failed bool) {
+		Ω(limiter.Acquire()).Should(BeTrue())
+		limiter.Release(now, now.Add(latency), failed)
+		now = now.Add(time.Second)
+	}
+
+	It("limits the number of requests in flight", func() {
+		Ω(limiter.Acquire()).Should(BeTrue())
+		Ω(limiter.Acquire()).Should(BeTrue())
+		Ω(limiter.Acquire()).Should(BeFalse())
+		Ω(limiter.InFlight()).Should(Equal(2))
+	})
+
+	It("increases the limit up to the maximum while the limit is used", func() {
+		Ω(limiter.Acquire()).Should(BeTrue())
+		complete(10*time.Millisecond, false)
+		complete(10*time.Millisecond, false)
+		Ω(limiter.Limit()).Should(Equal(3))
+	})
+
+	It("decreases the limit when the latency grows", func() {
+		complete(10*time.Millisecond, false)
+		for i := 0; i < 20; i++ {
+			complete(100*time.Millisecond, false)
+		}
+		Ω(limiter.Limit()).Should(Equal(1))
+	})
+
+	It("decreases the limit when requests fail", func() {
+		complete(10*time.Millisecond, true)
+		Ω(limiter.Limit()).Should(Equal(1))
+	})
+})
+
+var _ = Describe("AdaptiveConcurrency", func() {
+	It("rejects the requests that exceed the limit", func() {
+		service := newService(nil)
+		req, err := http.NewRequest("GET", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		var nestedErr error
+		var h goa.Handler
+		h = middleware.AdaptiveConcurrency(middleware.AdaptiveLimit{Initial: 1, Max: 1})(
+			func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				if nestedErr == nil {
+					nestedErr = h(ctx, rw, req)
+				}
+				return service.Send(ctx, 200, "ok")
+			})
+		rw := newTestResponseWriter()
+		err = h(newContext(service, rw, req, nil), rw, req)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Status).Should(Equal(200))
+		Ω(nestedErr).Should(HaveOccurred())
+		Ω(nestedErr.(goa.ServiceError).ResponseStatus()).Should(Equal(503))
+	})
+})
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"hash/crc32"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+// AffinityKeyHeader is the name of the response header set by the Affinity middleware to the
+// affinity key of the request.
+const AffinityKeyHeader = "X-Affinity-Key"
+
+// DefaultHashRingReplicas is the default number of points each node has on a HashRing.
+const DefaultHashRingReplicas = 100
+
+type (
+	// AffinityKeyFunc computes the affinity key of a request, requests with an empty key have
+	// no affinity.
+	AffinityKeyFunc func(ctx context.Context, req *http.Request) string
+
+	// HashRing maps keys to nodes using consistent hashing: adding or removing a node only
+	// remaps the keys of that node. Use it with the affinity key of the requests to pick the
+	// cache shard or backend that handles them. HashRing is safe for concurrent use.
+	HashRing struct {
+		replicas int
+		mu       sync.RWMutex
+		points   []uint32
+		nodes    map[uint32]string
+	}
+)
+
+// Affinity is a middleware that computes the affinity key of the requests using key. The key is
+// stored in the request context, see ContextAffinityKey, and set in the X-Affinity-Key response
+// header so that load balancers may route the subsequent requests of the client to the same
+// instance. Use the Affinity DSL to have the generated code mount the middleware.
+func Affinity(key AffinityKeyFunc) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if k := key(ctx, req); k != "" {
+				ctx = context.WithValue(ctx, affinityKey, k)
+				rw.Header().Set(AffinityKeyHeader, k)
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// AffinityFromHeader returns a key function that reads the affinity key from the request header
+// with the given name.
+func AffinityFromHeader(name string) AffinityKeyFunc {
+	return func(_ context.Context, req *http.Request) string {
+		return req.Header.Get(name)
+	}
+}
+
+// AffinityFromParam returns a key function that reads the affinity key from the path or query
+// string parameter with the given name.
+func AffinityFromParam(name string) AffinityKeyFunc {
+	return func(ctx context.Context, req *http.Request) string {
+		if r := goa.ContextRequest(ctx); r != nil {
+			return r.Params.Get(name)
+		}
+		return req.URL.Query().Get(name)
+	}
+}
+
+// ContextAffinityKey extracts the affinity key of the request from the context, it returns the
+// empty string if the request has no affinity.
+func ContextAffinityKey(ctx context.Context) string {
+	if k, ok := ctx.Value(affinityKey).(string); ok {
+		return k
+	}
+	return ""
+}
+
+// NewHashRing creates a hash ring with the given nodes. Each node is placed replicas times on the
+// ring, DefaultHashRingReplicas times if replicas is 0 or less.
+func NewHashRing(replicas int, nodes ...string) *HashRing {
+	if replicas <= 0 {
+		replicas = DefaultHashRingReplicas
+	}
+	r := &HashRing{replicas: replicas, nodes: make(map[uint32]string)}
+	r.Add(nodes...)
+	return r
+}
+
+// Add adds nodes to the ring.
+func (r *HashRing) Add(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range nodes {
+		for i := 0; i < r.replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + n))
+			if _, ok := r.nodes[h]; !ok {
+				r.points = append(r.points, h)
+			}
+			r.nodes[h] = n
+		}
+	}
+	sort.Sort(uint32s(r.points))
+}
+
+// Remove removes a node from the ring.
+func (r *HashRing) Remove(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	points := r.points[:0]
+	for _, p := range r.points {
+		if r.nodes[p] == node {
+			delete(r.nodes, p)
+			continue
+		}
+		points = append(points, p)
+	}
+	r.points = points
+}
+
+// Node returns the node that owns key, the empty string if the ring is empty.
+func (r *HashRing) Node(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.nodes[r.points[i]]
+}
+
+// uint32s sorts hash ring points.
+type uint32s []uint32
+
+func (u uint32s) Len() int           { return len(u) }
+func (u uint32s) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+func (u uint32s) Less(i, j int) bool { return u[i] < u[j] }
@@ -0,0 +1,99 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Affinity", func() {
+	var key middleware.AffinityKeyFunc
+	var rw *testResponseWriter
+	var affinity string
+
+	BeforeEach(func() {
+		affinity = ""
+	})
+
+	JustBeforeEach(func() {
+		service := newService(nil)
+		req, err := http.NewRequest("GET", "/accounts/42?region=eu", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("X-Tenant-Id", "acme")
+		rw = newTestResponseWriter()
+		ctx := newContext(service, rw, req, url.Values{"accountID": {"42"}})
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			affinity = middleware.ContextAffinityKey(ctx)
+			return nil
+		}
+		Ω(middleware.Affinity(key)(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+	})
+
+	Context("with a header key", func() {
+		BeforeEach(func() {
+			key = middleware.AffinityFromHeader("X-Tenant-Id")
+		})
+
+		It("exposes the key", func() {
+			Ω(affinity).Should(Equal("acme"))
+			Ω(rw.Header().Get(middleware.AffinityKeyHeader)).Should(Equal("acme"))
+		})
+	})
+
+	Context("with a param key", func() {
+		BeforeEach(func() {
+			key = middleware.AffinityFromParam("accountID")
+		})
+
+		It("exposes the key", func() {
+			Ω(affinity).Should(Equal("42"))
+		})
+	})
+
+	Context("with a missing key", func() {
+		BeforeEach(func() {
+			key = middleware.AffinityFromHeader("X-Missing")
+		})
+
+		It("does not set the key", func() {
+			Ω(affinity).Should(BeEmpty())
+			Ω(rw.Header()).ShouldNot(HaveKey(middleware.AffinityKeyHeader))
+		})
+	})
+})
+
+var _ = Describe("HashRing", func() {
+	var ring *middleware.HashRing
+
+	BeforeEach(func() {
+		ring = middleware.NewHashRing(0, "a", "b", "c")
+	})
+
+	It("maps keys to nodes consistently", func() {
+		owners := make(map[string]string)
+		for i := 0; i < 1000; i++ {
+			k := fmt.Sprintf("key%d", i)
+			owners[k] = ring.Node(k)
+			Ω([]string{"a", "b", "c"}).Should(ContainElement(owners[k]))
+			Ω(ring.Node(k)).Should(Equal(owners[k]))
+		}
+		ring.Remove("b")
+		for k, owner := range owners {
+			if owner != "b" {
+				Ω(ring.Node(k)).Should(Equal(owner))
+			} else {
+				Ω([]string{"a", "c"}).Should(ContainElement(ring.Node(k)))
+			}
+		}
+	})
+
+	It("returns the empty string when empty", func() {
+		Ω(middleware.NewHashRing(10).Node("key")).Should(BeEmpty())
+	})
+})
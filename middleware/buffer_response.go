@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// SpillBuffer is a buffer that holds up to Threshold bytes in memory and moves its content to
+	// a temporary file once it grows past Threshold. Close must be called to remove the
+	// temporary file.
+	SpillBuffer struct {
+		// Threshold is the maximum number of bytes held in memory.
+		Threshold int64
+		// Dir is the directory of the temporary file, defaults to os.TempDir().
+		Dir string
+
+		mem  bytes.Buffer
+		file *os.File
+		size int64
+	}
+
+	// bufferWriter buffers the response status and body.
+	bufferWriter struct {
+		http.ResponseWriter
+		status int
+		buf    *SpillBuffer
+	}
+)
+
+// BufferResponse returns a middleware that buffers the responses so that their length is known
+// before they are sent: the Content-Length header is set from the buffered body. Response bodies
+// larger than threshold bytes are buffered in a temporary file created in dir (os.TempDir() if
+// empty) rather than in memory, the file is removed once the response is sent or if the handler
+// fails or panics. This makes it possible to compute the Content-Length of large exports without
+// holding them in memory.
+//
+// The buffered response is discarded if the handler returns an error so that the error handler
+// may write the error response instead of a truncated body.
+func BufferResponse(threshold int64, dir string) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resp := goa.ContextResponse(ctx)
+			if resp == nil {
+				return h(ctx, rw, req)
+			}
+			bw := &bufferWriter{
+				ResponseWriter: resp.SwitchWriter(nil),
+				buf:            &SpillBuffer{Threshold: threshold, Dir: dir},
+			}
+			defer bw.buf.Close()
+			resp.SwitchWriter(bw)
+			err := h(ctx, rw, req)
+			resp.SwitchWriter(bw.ResponseWriter)
+			if err != nil {
+				// Discard the response so that the error handler may write the error.
+				resp.Status = 0
+				resp.Length = 0
+				resp.Header().Del("Content-Length")
+				return err
+			}
+			if bw.status == 0 {
+				return nil
+			}
+			if bw.status != http.StatusNoContent && bw.status != http.StatusNotModified {
+				bw.Header().Set("Content-Length", strconv.FormatInt(bw.buf.Len(), 10))
+			}
+			bw.ResponseWriter.WriteHeader(bw.status)
+			_, err = bw.buf.WriteTo(bw.ResponseWriter)
+			return err
+		}
+	}
+}
+
+// WriteHeader records the response status.
+func (w *bufferWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers the response body.
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.buf.Write(b)
+}
+
+// Write appends b to the buffer, moving the content to a temporary file if the buffer grows past
+// its threshold.
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && int64(b.mem.Len()+len(p)) > b.Threshold {
+		f, err := ioutil.TempFile(b.Dir, "goa-buffer-")
+		if err != nil {
+			return 0, err
+		}
+		b.file = f
+		if _, err := b.mem.WriteTo(f); err != nil {
+			return 0, err
+		}
+	}
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+// Len returns the number of bytes written to the buffer.
+func (b *SpillBuffer) Len() int64 {
+	return b.size
+}
+
+// Spilled returns true if the content of the buffer was moved to a temporary file.
+func (b *SpillBuffer) Spilled() bool {
+	return b.file != nil
+}
+
+// WriteTo writes the content of the buffer to w.
+func (b *SpillBuffer) WriteTo(w io.Writer) (int64, error) {
+	if b.file == nil {
+		return b.mem.WriteTo(w)
+	}
+	if _, err := b.file.Seek(0, 0); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, b.file)
+}
+
+// Close releases the buffer resources, it removes the temporary file if any. Close may be called
+// multiple times.
+func (b *SpillBuffer) Close() error {
+	b.mem.Reset()
+	if b.file == nil {
+		return nil
+	}
+	name := b.file.Name()
+	err := b.file.Close()
+	if rerr := os.Remove(name); err == nil {
+		err = rerr
+	}
+	b.file = nil
+	return err
+}
@@ -0,0 +1,101 @@
+package middleware_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BufferResponse", func() {
+	var dir string
+	var ctx context.Context
+	var rw *testResponseWriter
+	var req *http.Request
+	var body string
+	var handlerErr error
+	var spilled []string
+	var err error
+
+	BeforeEach(func() {
+		var derr error
+		dir, derr = ioutil.TempDir("", "buffer-response-test")
+		Ω(derr).ShouldNot(HaveOccurred())
+		service := newService(nil)
+		req, _ = http.NewRequest("GET", "/export", nil)
+		rw = newTestResponseWriter()
+		ctx = newContext(service, rw, req, nil)
+		body = "small"
+		handlerErr = nil
+		spilled = nil
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	JustBeforeEach(func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.WriteHeader(200)
+			for i := 0; i < len(body); i += 4 {
+				end := i + 4
+				if end > len(body) {
+					end = len(body)
+				}
+				rw.Write([]byte(body[i:end]))
+			}
+			files, _ := ioutil.ReadDir(dir)
+			for _, f := range files {
+				spilled = append(spilled, f.Name())
+			}
+			return handlerErr
+		}
+		err = middleware.BufferResponse(16, dir)(h)(ctx, goa.ContextResponse(ctx), req)
+	})
+
+	It("sets the Content-Length header", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Status).Should(Equal(200))
+		Ω(rw.ParentHeader.Get("Content-Length")).Should(Equal("5"))
+		Ω(string(rw.Body)).Should(Equal(body))
+		Ω(spilled).Should(BeEmpty())
+	})
+
+	Context("with a body larger than the threshold", func() {
+		BeforeEach(func() {
+			body = strings.Repeat("0123456789", 10)
+		})
+
+		It("spills the body to a temporary file and removes it", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(spilled).Should(HaveLen(1))
+			Ω(rw.ParentHeader.Get("Content-Length")).Should(Equal("100"))
+			Ω(string(rw.Body)).Should(Equal(body))
+			files, _ := ioutil.ReadDir(dir)
+			Ω(files).Should(BeEmpty())
+		})
+	})
+
+	Context("with a handler failing", func() {
+		BeforeEach(func() {
+			body = strings.Repeat("0123456789", 10)
+			handlerErr = goa.ErrInternal("boom")
+		})
+
+		It("discards the response", func() {
+			Ω(err).Should(Equal(handlerErr))
+			Ω(rw.Status).Should(Equal(0))
+			Ω(rw.Body).Should(BeEmpty())
+			Ω(goa.ContextResponse(ctx).Written()).Should(BeFalse())
+			files, _ := ioutil.ReadDir(dir)
+			Ω(files).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// ChallengeHeader is the name of the request header holding the challenge token by default.
+const ChallengeHeader = "X-Challenge-Token"
+
+// ErrChallengeFailed is the error returned by the RequireChallenge middleware when the request
+// challenge token is missing or invalid.
+var ErrChallengeFailed = goa.NewErrorClass("challenge_failed", 403)
+
+type (
+	// ChallengeVerifier is the interface implemented by the anti-automation challenge
+	// providers used by the RequireChallenge middleware.
+	ChallengeVerifier interface {
+		// VerifyChallenge returns true if token is a valid response to the challenge
+		// presented to the client making the request. It returns an error if the token
+		// could not be verified.
+		VerifyChallenge(ctx context.Context, token string, req *http.Request) (bool, error)
+	}
+
+	// ChallengeVerifierFunc is a function that implements ChallengeVerifier.
+	ChallengeVerifierFunc func(ctx context.Context, token string, req *http.Request) (bool, error)
+
+	// SiteVerifier verifies challenge tokens with a "siteverify" endpoint as implemented by
+	// Cloudflare Turnstile, Google reCAPTCHA and hCaptcha: the token is posted together with the
+	// secret key and the client IP address and the endpoint responds with a JSON object whose
+	// "success" field indicates whether the token is valid.
+	SiteVerifier struct {
+		// URL is the URL of the siteverify endpoint.
+		URL string
+		// Secret is the secret key of the site.
+		Secret string
+		// Hostname is the expected hostname of the site the challenge was solved on, it is
+		// not checked if empty.
+		Hostname string
+		// Client is the HTTP client used to make the requests, defaults to
+		// http.DefaultClient.
+		Client *http.Client
+	}
+)
+
+// VerifyChallenge calls f.
+func (f ChallengeVerifierFunc) VerifyChallenge(ctx context.Context, token string, req *http.Request) (bool, error) {
+	return f(ctx, token, req)
+}
+
+// RequireChallenge is a middleware that verifies the challenge token held by the given request
+// header with verifier before running the handler. Requests without a token or with an invalid
+// token fail with ErrChallengeFailed. The generated code applies it to the actions that use the
+// Challenge DSL with the verifier set in the app package ChallengeVerifier variable.
+func RequireChallenge(verifier ChallengeVerifier, header string) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if verifier == nil {
+				return goa.ErrInternal("no challenge verifier")
+			}
+			token := req.Header.Get(header)
+			if token == "" {
+				return ErrChallengeFailed(fmt.Sprintf("missing challenge token %q", header))
+			}
+			ok, err := verifier.VerifyChallenge(ctx, token, req)
+			if err != nil {
+				if _, isServiceErr := err.(goa.ServiceError); isServiceErr {
+					return err
+				}
+				return goa.ErrInternal(err)
+			}
+			if !ok {
+				return ErrChallengeFailed("invalid challenge token")
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// NewTurnstileVerifier returns a verifier of Cloudflare Turnstile tokens.
+func NewTurnstileVerifier(secret string) *SiteVerifier {
+	return &SiteVerifier{URL: "https://challenges.cloudflare.com/turnstile/v0/siteverify", Secret: secret}
+}
+
+// NewReCAPTCHAVerifier returns a verifier of Google reCAPTCHA tokens.
+func NewReCAPTCHAVerifier(secret string) *SiteVerifier {
+	return &SiteVerifier{URL: "https://www.google.com/recaptcha/api/siteverify", Secret: secret}
+}
+
+// NewHCaptchaVerifier returns a verifier of hCaptcha tokens.
+func NewHCaptchaVerifier(secret string) *SiteVerifier {
+	return &SiteVerifier{URL: "https://api.hcaptcha.com/siteverify", Secret: secret}
+}
+
+// VerifyChallenge posts the token to the siteverify endpoint.
+func (v *SiteVerifier) VerifyChallenge(ctx context.Context, token string, req *http.Request) (bool, error) {
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	form := url.Values{"secret": {v.Secret}, "response": {token}}
+	if ip := RateLimitKeyIP(req); ip != "" {
+		form.Set("remoteip", ip)
+	}
+	resp, err := ctxhttp.PostForm(ctx, client, v.URL, form)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("challenge verification failed: %s", resp.Status)
+	}
+	var body struct {
+		Success  bool   `json:"success"`
+		Hostname string `json:"hostname"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("challenge verification failed: %s", err)
+	}
+	if v.Hostname != "" && body.Hostname != v.Hostname {
+		return false, nil
+	}
+	return body.Success, nil
+}
@@ -0,0 +1,125 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequireChallenge", func() {
+	var verifier middleware.ChallengeVerifier
+	var req *http.Request
+	var called bool
+	var err error
+
+	BeforeEach(func() {
+		verifier = middleware.ChallengeVerifierFunc(func(ctx context.Context, token string, req *http.Request) (bool, error) {
+			return token == "valid", nil
+		})
+		called = false
+		req, _ = http.NewRequest("POST", "/signup", nil)
+	})
+
+	JustBeforeEach(func() {
+		service := newService(nil)
+		rw := httptest.NewRecorder()
+		ctx := newContext(service, rw, req, nil)
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called = true
+			return nil
+		}
+		err = middleware.RequireChallenge(verifier, middleware.ChallengeHeader)(h)(ctx, goa.ContextResponse(ctx), req)
+	})
+
+	It("rejects requests without a token", func() {
+		Ω(called).Should(BeFalse())
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(*goa.ErrorResponse).Status).Should(Equal(403))
+	})
+
+	Context("with a valid token", func() {
+		BeforeEach(func() {
+			req.Header.Set(middleware.ChallengeHeader, "valid")
+		})
+
+		It("runs the handler", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(called).Should(BeTrue())
+		})
+	})
+
+	Context("with an invalid token", func() {
+		BeforeEach(func() {
+			req.Header.Set(middleware.ChallengeHeader, "invalid")
+		})
+
+		It("rejects the request", func() {
+			Ω(called).Should(BeFalse())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(403))
+		})
+	})
+
+	Context("with a verifier failing to verify the token", func() {
+		BeforeEach(func() {
+			verifier = middleware.ChallengeVerifierFunc(func(ctx context.Context, token string, req *http.Request) (bool, error) {
+				return false, fmt.Errorf("unavailable")
+			})
+			req.Header.Set(middleware.ChallengeHeader, "valid")
+		})
+
+		It("fails with an internal error", func() {
+			Ω(called).Should(BeFalse())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(500))
+		})
+	})
+})
+
+var _ = Describe("SiteVerifier", func() {
+	var server *httptest.Server
+	var form map[string]string
+
+	BeforeEach(func() {
+		form = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			form = map[string]string{
+				"secret":   r.PostForm.Get("secret"),
+				"response": r.PostForm.Get("response"),
+				"remoteip": r.PostForm.Get("remoteip"),
+			}
+			fmt.Fprintf(w, `{"success": %t, "hostname": "example.com"}`, r.PostForm.Get("response") == "valid")
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("verifies the token with the siteverify endpoint", func() {
+		v := &middleware.SiteVerifier{URL: server.URL, Secret: "shh", Hostname: "example.com"}
+		req, _ := http.NewRequest("POST", "/signup", nil)
+		req.RemoteAddr = "10.0.0.1:4242"
+		ok, err := v.VerifyChallenge(context.Background(), "valid", req)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeTrue())
+		Ω(form).Should(Equal(map[string]string{"secret": "shh", "response": "valid", "remoteip": "10.0.0.1"}))
+		ok, err = v.VerifyChallenge(context.Background(), "invalid", req)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeFalse())
+	})
+
+	It("rejects tokens solved on another site", func() {
+		v := &middleware.SiteVerifier{URL: server.URL, Secret: "shh", Hostname: "other.com"}
+		req, _ := http.NewRequest("POST", "/signup", nil)
+		ok, err := v.VerifyChallenge(context.Background(), "valid", req)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeFalse())
+	})
+})
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+// ErrClientCertRequired is the error returned by the RequireClientCert middleware when the
+// request is not authenticated with an acceptable client certificate.
+var ErrClientCertRequired = goa.NewErrorClass("client_cert_required", 403)
+
+// RequireClientCert is a middleware that rejects the requests that were not made over a TLS
+// connection authenticated with a verified client certificate. If subjects is not empty the
+// certificate common name or one of its DNS names must also be listed in subjects.
+//
+// The middleware checks the certificate once the TLS handshake is done so that the service may
+// require client certificates for specific actions only: the server must verify the certificates
+// presented by clients without requiring them, see goa.Service.ListenAndServeMutualTLS. Services
+// that expose the protected actions on a dedicated listener that requires client certificates
+// may also use the middleware as a safeguard. The generated code applies it to the actions that
+// use the RequireClientCert DSL.
+func RequireClientCert(subjects ...string) goa.Middleware {
+	allowed := make(map[string]bool, len(subjects))
+	for _, s := range subjects {
+		allowed[s] = true
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+				return ErrClientCertRequired("a verified client certificate is required")
+			}
+			cert := req.TLS.VerifiedChains[0][0]
+			if len(allowed) > 0 && !certMatches(cert, allowed) {
+				return ErrClientCertRequired("client certificate subject is not allowed", "subject", cert.Subject.CommonName)
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// certMatches returns true if the common name or one of the DNS names of cert is allowed.
+func certMatches(cert *x509.Certificate, allowed map[string]bool) bool {
+	if allowed[cert.Subject.CommonName] {
+		return true
+	}
+	for _, n := range cert.DNSNames {
+		if allowed[n] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package middleware_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequireClientCert", func() {
+	var subjects []string
+	var req *http.Request
+	var called bool
+	var err error
+
+	BeforeEach(func() {
+		subjects = nil
+		called = false
+		req, _ = http.NewRequest("GET", "/admin", nil)
+	})
+
+	JustBeforeEach(func() {
+		service := newService(nil)
+		rw := httptest.NewRecorder()
+		ctx := newContext(service, rw, req, nil)
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called = true
+			return nil
+		}
+		err = middleware.RequireClientCert(subjects...)(h)(ctx, goa.ContextResponse(ctx), req)
+	})
+
+	It("rejects requests without a client certificate", func() {
+		Ω(called).Should(BeFalse())
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(*goa.ErrorResponse).Status).Should(Equal(403))
+	})
+
+	Context("with a verified client certificate", func() {
+		BeforeEach(func() {
+			cert := &x509.Certificate{
+				Subject:  pkix.Name{CommonName: "ops"},
+				DNSNames: []string{"ops.example.com"},
+			}
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		})
+
+		It("accepts the request", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(called).Should(BeTrue())
+		})
+
+		Context("whose DNS name is allowed", func() {
+			BeforeEach(func() {
+				subjects = []string{"ops.example.com"}
+			})
+
+			It("accepts the request", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(called).Should(BeTrue())
+			})
+		})
+
+		Context("whose subject is not allowed", func() {
+			BeforeEach(func() {
+				subjects = []string{"admin"}
+			})
+
+			It("rejects the request", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(called).Should(BeFalse())
+			})
+		})
+	})
+})
@@ -0,0 +1,362 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+type (
+	// Compressor returns a writer that compresses the data written to w. Closing the writer
+	// must flush the compressed data but not close w.
+	Compressor func(w io.Writer) (io.WriteCloser, error)
+
+	// Compression configures the Compress middleware.
+	Compression struct {
+		// Encodings lists the content codings the middleware may use by order of
+		// preference, defaults to "br", "gzip" and "deflate". Codings without a registered
+		// compressor are ignored, see RegisterCompressor.
+		Encodings []string
+		// MinSize is the minimum length in bytes of the compressed responses whose media
+		// type does not match any rule. Smaller responses are written as is.
+		MinSize int
+		// Rules configures the compression of specific media types. The first rule whose
+		// media type matches the response Content-Type applies.
+		Rules []CompressionRule
+	}
+
+	// CompressionRule configures the compression of the responses with a given media type.
+	CompressionRule struct {
+		// MediaType is the media type the rule applies to, e.g. "application/json". It may
+		// use a wildcard subtype, e.g. "text/*". Media type parameters are ignored.
+		MediaType string
+		// MinSize is the minimum length in bytes of the compressed responses, smaller
+		// responses are written as is.
+		MinSize int
+		// Disable prevents the compression of the responses.
+		Disable bool
+	}
+
+	// compressWriter compresses the response body as it is written. Writes are buffered until
+	// the body reaches the minimum size or the handler returns, whichever comes first, the
+	// decision to compress is made then.
+	compressWriter struct {
+		http.ResponseWriter
+		conf       *Compression
+		encoding   string
+		compressor Compressor
+		resp       *goa.ResponseData
+		head       bool
+		status     int
+		buf        []byte
+		decided    bool
+		cw         io.WriteCloser
+	}
+)
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[string]Compressor{
+		"gzip":    gzipCompressor,
+		"deflate": deflateCompressor,
+	}
+)
+
+// defaultEncodings lists the content codings used when Compression.Encodings is empty.
+var defaultEncodings = []string{"br", "gzip", "deflate"}
+
+// RegisterCompressor registers the compressor used by Compress for the given content coding. gzip
+// and deflate are supported out of the box, register a compressor to support other codings such
+// as br:
+//
+//	middleware.RegisterCompressor("br", func(w io.Writer) (io.WriteCloser, error) {
+//		return brotli.NewWriterLevel(w, 5), nil
+//	})
+func RegisterCompressor(encoding string, c Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[strings.ToLower(encoding)] = c
+}
+
+// Compress returns a middleware that compresses the response bodies using the preferred content
+// coding accepted by the client. The bodies are compressed as they are written by the generated
+// encoders so that the responses are not buffered beyond the configured minimum size.
+//
+// Responses are written as is when their Content-Encoding header is already set, when their media
+// type is disabled by a rule or registered as incompressible with the service (see the
+// Incompressible DSL and goa.Service.SetIncompressible) or when they are smaller than the minimum
+// size. The size of the responses is known upfront when the handler sets the Content-Length
+// header.
+//
+//	service.Use(middleware.Compress(middleware.Compression{
+//		MinSize: 1024,
+//		Rules: []middleware.CompressionRule{
+//			{MediaType: "text/*", MinSize: 256},
+//			{MediaType: "application/zip", Disable: true},
+//		},
+//	}))
+func Compress(conf Compression) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if req.Header.Get("Sec-WebSocket-Key") != "" {
+				return h(ctx, rw, req)
+			}
+			resp := goa.ContextResponse(ctx)
+			// The response varies with the request Accept-Encoding header whether it ends
+			// up compressed or not.
+			resp.Header().Add("Vary", "Accept-Encoding")
+			encoding, compressor := conf.negotiate(req.Header.Get("Accept-Encoding"))
+			if compressor == nil {
+				return h(ctx, rw, req)
+			}
+			w := resp.SwitchWriter(nil)
+			cw := &compressWriter{
+				ResponseWriter: w,
+				conf:           &conf,
+				encoding:       encoding,
+				compressor:     compressor,
+				resp:           resp,
+				head:           req.Method == "HEAD",
+			}
+			resp.SwitchWriter(cw)
+			err := h(ctx, rw, req)
+			if err != nil && cw.status == 0 && len(cw.buf) == 0 {
+				// Let the error handler write the error response directly.
+				resp.SwitchWriter(w)
+				return err
+			}
+			ferr := cw.finish()
+			resp.SwitchWriter(w)
+			if err == nil {
+				err = ferr
+			}
+			return err
+		}
+	}
+}
+
+// negotiate returns the preferred content coding accepted by the client and its compressor, nil
+// if the client does not accept any of the configured codings.
+func (conf *Compression) negotiate(acceptEncoding string) (string, Compressor) {
+	if acceptEncoding == "" {
+		return "", nil
+	}
+	accepted := make(map[string]float64)
+	for _, e := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(e, ";")
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		q := 1.0
+		for _, p := range parts[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		accepted[name] = q
+	}
+	encodings := conf.Encodings
+	if len(encodings) == 0 {
+		encodings = defaultEncodings
+	}
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	var (
+		best  string
+		bestQ float64
+	)
+	for _, e := range encodings {
+		e = strings.ToLower(e)
+		if _, ok := compressors[e]; !ok {
+			continue
+		}
+		q, ok := accepted[e]
+		if !ok {
+			q = accepted["*"]
+		}
+		if q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	if best == "" {
+		return "", nil
+	}
+	return best, compressors[best]
+}
+
+// minSize returns the minimum size of the compressed responses with the given Content-Type, false
+// if the responses must not be compressed.
+func (conf *Compression) minSize(contentType string) (int, bool) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	for _, r := range conf.Rules {
+		pattern := strings.ToLower(r.MediaType)
+		if pattern == mt || pattern == "*/*" ||
+			strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mt, pattern[:len(pattern)-1]) {
+			return r.MinSize, !r.Disable
+		}
+	}
+	return conf.MinSize, true
+}
+
+// WriteHeader records the status code, it is written once the decision to compress is made.
+func (c *compressWriter) WriteHeader(status int) {
+	if c.status != 0 {
+		return
+	}
+	c.status = status
+	c.decide(0, false)
+}
+
+// Write compresses b or buffers it until the decision to compress is made. It sets the
+// Content-Type header using the net/http content type detection if it is not set yet.
+func (c *compressWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.decided {
+		if c.cw != nil {
+			return c.cw.Write(b)
+		}
+		return c.ResponseWriter.Write(b)
+	}
+	if len(c.buf) == 0 && c.Header().Get("Content-Type") == "" {
+		c.Header().Set("Content-Type", http.DetectContentType(b))
+	}
+	c.buf = append(c.buf, b...)
+	if err := c.decide(len(c.buf), false); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Flush compresses the response if allowed and flushes the data written so far to the client.
+func (c *compressWriter) Flush() {
+	if c.status == 0 {
+		c.WriteHeader(http.StatusOK)
+	}
+	c.decide(math.MaxInt32, false)
+	if f, ok := c.cw.(interface {
+		Flush() error
+	}); ok {
+		f.Flush()
+	}
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes the buffered data and the end of the compressed data.
+func (c *compressWriter) finish() error {
+	if c.status == 0 {
+		return nil
+	}
+	if err := c.decide(len(c.buf), true); err != nil {
+		return err
+	}
+	if c.cw != nil {
+		return c.cw.Close()
+	}
+	return nil
+}
+
+// decide decides whether to compress the response once enough is known about it: size is the
+// length of the body written so far and final is true once the handler returned.
+func (c *compressWriter) decide(size int, final bool) error {
+	if c.decided {
+		return nil
+	}
+	h := c.Header()
+	ct := h.Get("Content-Type")
+	min, ok := c.conf.minSize(ct)
+	switch {
+	case !ok, h.Get("Content-Encoding") != "", c.head, !bodyAllowed(c.status),
+		c.status == http.StatusPartialContent,
+		c.resp.Service != nil && !c.resp.Service.Compressible(ct):
+		return c.start(false)
+	case h.Get("Content-Length") != "":
+		if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil {
+			return c.start(n > 0 && n >= min)
+		}
+	}
+	switch {
+	case final:
+		return c.start(size > 0 && size >= min)
+	case size >= min:
+		return c.start(true)
+	}
+	return nil
+}
+
+// start writes the header and the buffered data, compressing them if compress is true.
+func (c *compressWriter) start(compress bool) error {
+	c.decided = true
+	if compress {
+		cw, err := c.compressor(c.ResponseWriter)
+		if err == nil {
+			c.cw = cw
+			c.Header().Set("Content-Encoding", c.encoding)
+			c.Header().Del("Content-Length")
+		}
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+	if len(c.buf) == 0 {
+		return nil
+	}
+	buf := c.buf
+	c.buf = nil
+	var err error
+	if c.cw != nil {
+		_, err = c.cw.Write(buf)
+	} else {
+		_, err = c.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// bodyAllowed returns true if responses with the given status may have a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// gzipWriters pools the gzip writers used by gzipCompressor.
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(ioutil.Discard) },
+}
+
+// pooledGzipWriter returns its gzip writer to the pool once closed.
+type pooledGzipWriter struct {
+	*gzip.Writer
+}
+
+// Close flushes the compressed data and returns the writer to the pool.
+func (w pooledGzipWriter) Close() error {
+	err := w.Writer.Close()
+	gzipWriters.Put(w.Writer)
+	return err
+}
+
+// gzipCompressor compresses data using the gzip coding.
+func gzipCompressor(w io.Writer) (io.WriteCloser, error) {
+	gz := gzipWriters.Get().(*gzip.Writer)
+	gz.Reset(w)
+	return pooledGzipWriter{gz}, nil
+}
+
+// deflateCompressor compresses data using the deflate coding which is the zlib format.
+func deflateCompressor(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriter(w), nil
+}
@@ -0,0 +1,158 @@
+package middleware_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compress", func() {
+	var (
+		service     *goa.Service
+		ctx         context.Context
+		rw          *testResponseWriter
+		req         *http.Request
+		conf        middleware.Compression
+		contentType string
+		encoding    string
+		body        string
+		err         error
+	)
+
+	BeforeEach(func() {
+		service = newService(nil)
+		req, _ = http.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate;q=0.5")
+		rw = newTestResponseWriter()
+		conf = middleware.Compression{MinSize: 100}
+		contentType = "application/json"
+		encoding = ""
+		body = strings.Repeat(`{"name":"goa"}`, 20)
+	})
+
+	JustBeforeEach(func() {
+		ctx = newContext(service, rw, req, nil)
+		goa.ContextResponse(ctx).Service = service
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.Header().Set("Content-Type", contentType)
+			if encoding != "" {
+				rw.Header().Set("Content-Encoding", encoding)
+			}
+			rw.WriteHeader(200)
+			for i := 0; i < len(body); i += 30 {
+				end := i + 30
+				if end > len(body) {
+					end = len(body)
+				}
+				rw.Write([]byte(body[i:end]))
+			}
+			return nil
+		}
+		err = middleware.Compress(conf)(h)(ctx, goa.ContextResponse(ctx), req)
+	})
+
+	It("compresses the response with the preferred encoding", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Status).Should(Equal(200))
+		Ω(rw.ParentHeader.Get("Content-Encoding")).Should(Equal("gzip"))
+		Ω(rw.ParentHeader.Get("Vary")).Should(Equal("Accept-Encoding"))
+		gz, gerr := gzip.NewReader(bytes.NewReader(rw.Body))
+		Ω(gerr).ShouldNot(HaveOccurred())
+		decoded, _ := ioutil.ReadAll(gz)
+		Ω(string(decoded)).Should(Equal(body))
+	})
+
+	Context("with a client preferring deflate", func() {
+		BeforeEach(func() {
+			req.Header.Set("Accept-Encoding", "gzip;q=0.2, deflate")
+		})
+
+		It("uses deflate", func() {
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(Equal("deflate"))
+			zr, zerr := zlib.NewReader(bytes.NewReader(rw.Body))
+			Ω(zerr).ShouldNot(HaveOccurred())
+			decoded, _ := ioutil.ReadAll(zr)
+			Ω(string(decoded)).Should(Equal(body))
+		})
+	})
+
+	Context("with a response smaller than the minimum size", func() {
+		BeforeEach(func() {
+			body = `{"name":"goa"}`
+		})
+
+		It("writes the response as is", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Status).Should(Equal(200))
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(BeEmpty())
+			Ω(string(rw.Body)).Should(Equal(body))
+		})
+	})
+
+	Context("with a rule for the media type", func() {
+		BeforeEach(func() {
+			conf.Rules = []middleware.CompressionRule{{MediaType: "application/*", MinSize: 1000}}
+		})
+
+		It("applies the rule minimum size", func() {
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(BeEmpty())
+			Ω(string(rw.Body)).Should(Equal(body))
+		})
+	})
+
+	Context("with a rule disabling the media type", func() {
+		BeforeEach(func() {
+			contentType = "image/png"
+			conf.Rules = []middleware.CompressionRule{{MediaType: "image/png", Disable: true}}
+		})
+
+		It("writes the response as is", func() {
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(BeEmpty())
+			Ω(string(rw.Body)).Should(Equal(body))
+		})
+	})
+
+	Context("with an incompressible media type", func() {
+		BeforeEach(func() {
+			service.SetIncompressible("application/json")
+		})
+
+		It("writes the response as is", func() {
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(BeEmpty())
+			Ω(string(rw.Body)).Should(Equal(body))
+		})
+	})
+
+	Context("with a response already encoded", func() {
+		BeforeEach(func() {
+			encoding = "br"
+		})
+
+		It("writes the response as is", func() {
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(Equal("br"))
+			Ω(string(rw.Body)).Should(Equal(body))
+		})
+	})
+
+	Context("with a client that does not accept compressed responses", func() {
+		BeforeEach(func() {
+			req.Header.Set("Accept-Encoding", "identity")
+		})
+
+		It("writes the response as is", func() {
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(BeEmpty())
+			Ω(rw.ParentHeader.Get("Vary")).Should(Equal("Accept-Encoding"))
+			Ω(string(rw.Body)).Should(Equal(body))
+		})
+	})
+})
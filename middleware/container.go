@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+// Container creates a dependency container for each request and stores it in the request
+// context. Middleware and controllers mounted after it may then share request-scoped resources
+// such as database transactions using the accessors generated for the dependencies declared in
+// the design with the Dependency DSL.
+func Container() goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return h(goa.WithContainer(ctx, goa.NewContainer()), rw, req)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package middleware_test
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Container", func() {
+	It("creates a container for each request", func() {
+		service := newService(nil)
+		req, err := http.NewRequest("GET", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw := new(testResponseWriter)
+		ctx := newContext(service, rw, req, nil)
+		var containers []*goa.Container
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			containers = append(containers, goa.ContextContainer(ctx))
+			return nil
+		}
+		c := middleware.Container()(h)
+		Ω(c(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(c(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(containers).Should(HaveLen(2))
+		Ω(containers[0]).ShouldNot(BeNil())
+		Ω(containers[0]).ShouldNot(BeIdenticalTo(containers[1]))
+	})
+})
@@ -6,3 +6,12 @@ type middlewareKey int
 
 // ReqIDKey is the context key used by the RequestID middleware to store the request ID value.
 const reqIDKey middlewareKey = 1
+
+// localesKey is the context key used by the Locale middleware to store the request locales.
+const localesKey middlewareKey = 2
+
+// txKey is the context key used by the Transaction middleware to store the request transaction.
+const txKey middlewareKey = 3
+
+// affinityKey is the context key used by the Affinity middleware to store the request affinity key.
+const affinityKey middlewareKey = 4
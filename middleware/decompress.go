@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/goadesign/goa"
+)
+
+// Decompressor returns a reader that decompresses the data read from r.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   = map[string]Decompressor{
+		"gzip":    gzipDecompressor,
+		"x-gzip":  gzipDecompressor,
+		"deflate": deflateDecompressor,
+	}
+)
+
+// RegisterDecompressor registers the decompressor used by Decompress for request bodies encoded
+// with the given content coding. gzip and deflate are supported out of the box, register a
+// decompressor to support other codings such as br:
+//
+//	middleware.RegisterDecompressor("br", func(r io.Reader) (io.ReadCloser, error) {
+//		return ioutil.NopCloser(brotli.NewReader(r)), nil
+//	})
+func RegisterDecompressor(encoding string, d Decompressor) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	decompressors[strings.ToLower(encoding)] = d
+}
+
+// Decompress returns a HTTP middleware that transparently decompresses the request bodies encoded
+// according to their Content-Encoding header. The decompressed bodies are limited to maxSize
+// bytes, zero means no limit, which protects the service against decompression bombs.
+//
+// Request bodies are decoded before the goa middleware run so Decompress must wrap the service
+// mux, for example:
+//
+//	srv := &http.Server{Addr: ":8080", Handler: middleware.Decompress(10 << 20)(service.Mux)}
+//
+// Decompression happens as the body is read: the generated code reports decompression failures
+// like any other error that occurs while loading the request payload. Bodies that fail to
+// decompress produce errors of class goa.ErrInvalidEncoding, bodies that exceed maxSize errors of
+// class goa.ErrRequestBodyTooLarge and bodies encoded with an unknown coding errors of class
+// goa.ErrUnsupportedMediaType.
+func Decompress(maxSize int64) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			var encodings []string
+			for _, e := range strings.Split(req.Header.Get("Content-Encoding"), ",") {
+				if e = strings.ToLower(strings.TrimSpace(e)); e != "" && e != "identity" {
+					encodings = append(encodings, e)
+				}
+			}
+			if len(encodings) == 0 || req.Body == nil {
+				h.ServeHTTP(rw, req)
+				return
+			}
+			req.Body = &decompressingReader{src: req.Body, encodings: encodings, max: maxSize}
+			// ContentLength keeps the length of the compressed body, goa only loads the
+			// payload of requests with a positive length.
+			req.Header.Del("Content-Encoding")
+			req.Header.Del("Content-Length")
+			h.ServeHTTP(rw, req)
+		})
+	}
+}
+
+// decompressingReader decompresses the request body on the fly.
+type decompressingReader struct {
+	src       io.ReadCloser
+	encodings []string
+	max       int64
+	r         io.Reader
+	closers   []io.Closer
+	read      int64
+	err       error
+}
+
+// Read reads decompressed data, the decompressors are created on the first call.
+func (d *decompressingReader) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if d.r == nil {
+		if d.err = d.init(); d.err != nil {
+			return 0, d.err
+		}
+	}
+	if d.max > 0 && int64(len(p)) > d.max-d.read+1 {
+		// Read one more byte than allowed to detect bodies that are too large.
+		p = p[:d.max-d.read+1]
+	}
+	n, err := d.r.Read(p)
+	d.read += int64(n)
+	if d.max > 0 && d.read > d.max {
+		d.err = goa.ErrRequestBodyTooLarge(fmt.Sprintf("decompressed request body length exceeds %d bytes", d.max), "limit", d.max)
+		return 0, d.err
+	}
+	if err != nil && err != io.EOF {
+		d.err = goa.ErrInvalidEncoding(err, "encoding", strings.Join(d.encodings, ", "))
+		return n, d.err
+	}
+	return n, err
+}
+
+// Close closes the decompressors and the original body.
+func (d *decompressingReader) Close() error {
+	for _, c := range d.closers {
+		c.Close()
+	}
+	return d.src.Close()
+}
+
+// init creates the decompressors, the codings are undone in the reverse order of their
+// application.
+func (d *decompressingReader) init() error {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+	var r io.Reader = d.src
+	for i := len(d.encodings) - 1; i >= 0; i-- {
+		e := d.encodings[i]
+		dec, ok := decompressors[e]
+		if !ok {
+			supported := make([]string, 0, len(decompressors))
+			for n := range decompressors {
+				supported = append(supported, n)
+			}
+			sort.Strings(supported)
+			return goa.ErrUnsupportedMediaType(fmt.Sprintf("unsupported content encoding %#v", e),
+				"encoding", e, "supported", strings.Join(supported, ", "))
+		}
+		rc, err := dec(r)
+		if err != nil {
+			return goa.ErrInvalidEncoding(err, "encoding", e)
+		}
+		d.closers = append(d.closers, rc)
+		r = rc
+	}
+	d.r = r
+	return nil
+}
+
+// gzipDecompressor decompresses gzip encoded data.
+func gzipDecompressor(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// deflateDecompressor decompresses deflate encoded data. The HTTP deflate coding is the zlib
+// format but some clients send raw deflate data so both are accepted.
+func deflateDecompressor(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	if h, err := br.Peek(2); err == nil && h[0]&0x0f == 8 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
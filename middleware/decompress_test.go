@@ -0,0 +1,132 @@
+package middleware_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Decompress", func() {
+	var (
+		body     []byte
+		encoding string
+		maxSize  int64
+		payload  interface{}
+		loadErr  error
+	)
+
+	BeforeEach(func() {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte(`{"name":"goa"}`))
+		gz.Close()
+		body = buf.Bytes()
+		encoding = "gzip"
+		maxSize = 0
+		payload = nil
+		loadErr = nil
+	})
+
+	JustBeforeEach(func() {
+		service := newService(nil)
+		ctrl := service.NewController("test")
+		unmarshal := func(ctx context.Context, service *goa.Service, req *http.Request) error {
+			var p interface{}
+			if err := service.DecodeRequest(req, &p); err != nil {
+				return err
+			}
+			goa.ContextRequest(ctx).Payload = p
+			return nil
+		}
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			payload = goa.ContextRequest(ctx).Payload
+			loadErr = goa.ContextError(ctx)
+			return nil
+		}
+		service.Mux.Handle("POST", "/", ctrl.MuxHandler("create", handler, unmarshal))
+		req, _ := http.NewRequest("POST", "/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", encoding)
+		middleware.Decompress(maxSize)(service.Mux).ServeHTTP(httptest.NewRecorder(), req)
+	})
+
+	It("decompresses gzip request bodies", func() {
+		Ω(loadErr).ShouldNot(HaveOccurred())
+		Ω(payload).Should(Equal(map[string]interface{}{"name": "goa"}))
+	})
+
+	Context("with a deflate encoded body", func() {
+		BeforeEach(func() {
+			var buf bytes.Buffer
+			zw := zlib.NewWriter(&buf)
+			zw.Write([]byte(`{"name":"goa"}`))
+			zw.Close()
+			body = buf.Bytes()
+			encoding = "deflate"
+		})
+
+		It("decompresses the body", func() {
+			Ω(loadErr).ShouldNot(HaveOccurred())
+			Ω(payload).Should(Equal(map[string]interface{}{"name": "goa"}))
+		})
+	})
+
+	Context("with a body that exceeds the maximum size once decompressed", func() {
+		BeforeEach(func() {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write([]byte(`"` + strings.Repeat("a", 10000) + `"`))
+			gz.Close()
+			body = buf.Bytes()
+			maxSize = 1000
+		})
+
+		It("fails with a request too large error", func() {
+			Ω(loadErr).Should(HaveOccurred())
+			Ω(loadErr.(*goa.ErrorResponse).Status).Should(Equal(413))
+		})
+	})
+
+	Context("with a corrupted body", func() {
+		BeforeEach(func() {
+			body = []byte("not gzip")
+		})
+
+		It("fails with an invalid encoding error", func() {
+			Ω(loadErr).Should(HaveOccurred())
+			Ω(loadErr.(*goa.ErrorResponse).Code).Should(Equal("invalid_encoding"))
+		})
+	})
+
+	Context("with an unknown encoding", func() {
+		BeforeEach(func() {
+			encoding = "compress"
+		})
+
+		It("fails with an unsupported media type error", func() {
+			Ω(loadErr).Should(HaveOccurred())
+			Ω(loadErr.(*goa.ErrorResponse).Status).Should(Equal(415))
+		})
+	})
+
+	It("leaves requests without encoding alone", func() {
+		req, _ := http.NewRequest("POST", "/", strings.NewReader("raw"))
+		var read []byte
+		h := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			read, _ = ioutil.ReadAll(req.Body)
+		})
+		middleware.Decompress(10)(h).ServeHTTP(httptest.NewRecorder(), req)
+		Ω(string(read)).Should(Equal("raw"))
+	})
+})
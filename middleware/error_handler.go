@@ -13,6 +13,8 @@ import (
 // understands instances of goa.ServiceError and returns the status and response body embodied in
 // them, it turns other Go error types into a 500 internal error response.
 // If verbose is false the details of internal errors is not included in HTTP responses.
+// The response status may be remapped using the service RemapErrorClass and RemapErrorStatus
+// methods and the error responses localized using the service TranslateErrors method.
 func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
@@ -20,12 +22,25 @@ func ErrorHandler(service *goa.Service, verbose bool) goa.Middleware {
 			if e == nil {
 				return nil
 			}
+			if goa.ContextClientDisconnected(ctx) {
+				// Not an internal error, let the service log and count the disconnect
+				return e
+			}
 
 			status := http.StatusInternalServerError
 			var respBody interface{}
 			if err, ok := e.(goa.ServiceError); ok {
-				status = err.ResponseStatus()
+				status = service.ErrorStatus(err)
 				respBody = err
+				if gerr, ok := err.(*goa.ErrorResponse); ok && status != gerr.Status {
+					// Error class status was remapped, make sure the body is consistent
+					remapped := *gerr
+					remapped.Status = status
+					respBody = &remapped
+				}
+				if gerr, ok := respBody.(*goa.ErrorResponse); ok {
+					respBody = service.TranslateError(ctx, gerr)
+				}
 				goa.ContextResponse(ctx).ErrorCode = err.Token()
 				rw.Header().Set("Content-Type", goa.ErrorMediaIdentifier)
 			} else {
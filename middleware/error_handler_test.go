@@ -143,5 +143,54 @@ var _ = Describe("ErrorHandler", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 			Ω(decoded.Error()).Should(Equal(gerr.Error()))
 		})
+
+		Context("with a remapped error class", func() {
+			BeforeEach(func() {
+				service.RemapErrorStatus(418, 500)
+				service.RemapErrorClass(goa.NewErrorClass("code", 418), 400)
+			})
+
+			It("uses the remapped status", func() {
+				var decoded errorResponse
+				Ω(rw.Status).Should(Equal(400))
+				err := service.Decoder.Decode(&decoded, bytes.NewBuffer(rw.Body), "application/json")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(decoded.Status).Should(Equal(400))
+				Ω(decoded.Code).Should(Equal("code"))
+			})
+		})
+
+		Context("with a remapped status", func() {
+			BeforeEach(func() {
+				service.RemapErrorStatus(418, 404)
+			})
+
+			It("uses the remapped status", func() {
+				var decoded errorResponse
+				Ω(rw.Status).Should(Equal(404))
+				err := service.Decoder.Decode(&decoded, bytes.NewBuffer(rw.Body), "application/json")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(decoded.Status).Should(Equal(404))
+				Ω(gerr.(goa.ServiceError).ResponseStatus()).Should(Equal(418))
+			})
+		})
+
+		Context("with an error translator", func() {
+			BeforeEach(func() {
+				service.TranslateErrors(func(ctx context.Context, err *goa.ErrorResponse) *goa.ErrorResponse {
+					translated := *err
+					translated.Detail = "translated " + err.Detail
+					return &translated
+				})
+			})
+
+			It("sends the translated error", func() {
+				var decoded errorResponse
+				err := service.Decoder.Decode(&decoded, bytes.NewBuffer(rw.Body), "application/json")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(decoded.Detail).Should(Equal("translated " + gerr.(*goa.ErrorResponse).Detail))
+				Ω(decoded.ID).Should(Equal(gerr.(*goa.ErrorResponse).ID))
+			})
+		})
 	})
 })
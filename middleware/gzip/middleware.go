@@ -25,22 +25,58 @@ const (
 )
 
 // gzipResponseWriter wraps the http.ResponseWriter to provide gzip
-// capabilities.
+// capabilities. The decision to compress the response is made when the
+// response status or the first bytes of the body are written so that it may
+// take the response Content-Type and Content-Encoding headers into account.
 type gzipResponseWriter struct {
 	http.ResponseWriter
-	gzw *gzip.Writer
+	gzw      *gzip.Writer
+	resp     *goa.ResponseData
+	decided  bool
+	compress bool
+}
+
+// WriteHeader decides whether to compress the response and writes the status
+// code.
+func (grw *gzipResponseWriter) WriteHeader(status int) {
+	grw.decide()
+	grw.ResponseWriter.WriteHeader(status)
 }
 
 // Write writes bytes to the gzip.Writer. It will also set the Content-Type
 // header using the net/http library content type detection if the Content-Type
 // header was not set yet.
-func (grw gzipResponseWriter) Write(b []byte) (int, error) {
+func (grw *gzipResponseWriter) Write(b []byte) (int, error) {
 	if len(grw.Header().Get(headerContentType)) == 0 {
 		grw.Header().Set(headerContentType, http.DetectContentType(b))
 	}
+	grw.decide()
+	if !grw.compress {
+		return grw.ResponseWriter.Write(b)
+	}
 	return grw.gzw.Write(b)
 }
 
+// decide compresses the response unless it is already encoded or its media
+// type was registered as incompressible with the service, see
+// goa.Service.SetIncompressible. It sets the gzip headers accordingly.
+func (grw *gzipResponseWriter) decide() {
+	if grw.decided {
+		return
+	}
+	grw.decided = true
+	h := grw.Header()
+	if h.Get(headerContentEncoding) != "" {
+		return
+	}
+	if s := grw.resp.Service; s != nil && !s.Compressible(h.Get(headerContentType)) {
+		return
+	}
+	grw.compress = true
+	h.Set(headerContentEncoding, encodingGzip)
+	h.Del(headerContentLength)
+}
+
 // handler struct contains the ServeHTTP method
 type handler struct {
 	pool sync.Pool
@@ -48,7 +84,9 @@ type handler struct {
 
 // Middleware encodes the response using Gzip encoding and sets all the appropriate
 // headers. If the Content-Type is not set, it will be set by calling
-// http.DetectContentType on the data being written.
+// http.DetectContentType on the data being written. Responses whose
+// Content-Encoding header is already set by the handler or whose media type
+// is registered as incompressible with the service are written as is.
 func Middleware(level int) goa.Middleware {
 	gzipPool := sync.Pool{
 		New: func() interface{} {
@@ -69,10 +107,10 @@ func Middleware(level int) goa.Middleware {
 				return h(ctx, rw, req)
 			}
 
-			// Set the appropriate gzip headers.
+			// The response varies with the request Accept-Encoding header
+			// whether it ends up compressed or not.
 			resp := goa.ContextResponse(ctx)
-			resp.Header().Set(headerContentEncoding, encodingGzip)
-			resp.Header().Set(headerVary, headerAcceptEncoding)
+			resp.Header().Add(headerVary, headerAcceptEncoding)
 
 			// Retrieve gzip writer from the pool. Reset it to use the ResponseWriter.
 			// This allows us to re-use an already allocated buffer rather than
@@ -85,9 +123,10 @@ func Middleware(level int) goa.Middleware {
 			gz.Reset(w)
 
 			// Wrap the original http.ResponseWriter with our gzipResponseWriter
-			grw := gzipResponseWriter{
+			grw := &gzipResponseWriter{
 				ResponseWriter: w,
 				gzw:            gz,
+				resp:           resp,
 			}
 
 			// Set the new http.ResponseWriter
@@ -100,9 +139,10 @@ func Middleware(level int) goa.Middleware {
 				return
 			}
 
-			// Delete the content length after we know we have been written to.
-			grw.Header().Del(headerContentLength)
-			gz.Close()
+			// Flush the compressed data if the response was compressed.
+			if grw.compress {
+				gz.Close()
+			}
 			gzipPool.Put(gz)
 			return
 		}
@@ -70,6 +70,45 @@ var _ = Describe("Gzip", func() {
 		io.Copy(buf, gzr)
 		Ω(err).ShouldNot(HaveOccurred())
 		Ω(buf.String()).Should(Equal("gzip me!"))
+		Ω(rw.ParentHeader.Get("Content-Encoding")).Should(Equal("gzip"))
+	})
+
+	Context("with a media type registered as incompressible", func() {
+		BeforeEach(func() {
+			service := goa.New("test")
+			service.SetIncompressible("image/png")
+			goa.ContextResponse(ctx).Service = service
+		})
+
+		It("does not encode the response", func() {
+			h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				resp := goa.ContextResponse(ctx)
+				resp.Header().Set("Content-Type", "image/png")
+				resp.WriteHeader(http.StatusOK)
+				resp.Write([]byte("not worth it"))
+				return nil
+			}
+			t := gzm.Middleware(gzip.BestCompression)(h)
+			err := t(ctx, rw, req)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.ParentHeader.Get("Content-Encoding")).Should(BeEmpty())
+			Ω(rw.ParentHeader.Get("Vary")).Should(Equal("Accept-Encoding"))
+			Ω(string(rw.Body)).Should(Equal("not worth it"))
+		})
+	})
+
+	It("does not encode responses already encoded by the handler", func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resp := goa.ContextResponse(ctx)
+			resp.Header().Set("Content-Encoding", "br")
+			resp.Write([]byte("already encoded"))
+			return nil
+		}
+		t := gzm.Middleware(gzip.BestCompression)(h)
+		err := t(ctx, rw, req)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.ParentHeader.Get("Content-Encoding")).Should(Equal("br"))
+		Ω(string(rw.Body)).Should(Equal("already encoded"))
 	})
 
 })
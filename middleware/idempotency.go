@@ -0,0 +1,298 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// IdempotencyKeyHeader is the name of the request header that holds the key clients use to
+	// identify retries of the same request.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is the name of the response header set to "true" when the
+	// response is a replay of the response stored for the request idempotency key.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultIdempotencyTTL is the default duration during which responses are stored.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	// maxIdempotencyKeyLength is the maximum length of idempotency keys.
+	maxIdempotencyKeyLength = 255
+)
+
+var (
+	// ErrIdempotencyConflict is the class of errors returned when a request uses the
+	// idempotency key of a request that is still being handled.
+	ErrIdempotencyConflict = goa.NewErrorClass("idempotency_conflict", 409)
+
+	// ErrIdempotencyKeyReused is the class of errors returned when a request uses the
+	// idempotency key of a previous request with a different payload.
+	ErrIdempotencyKeyReused = goa.NewErrorClass("idempotency_key_reused", 422)
+)
+
+type (
+	// IdempotentResponse is the snapshot of a response stored by the Idempotency middleware.
+	IdempotentResponse struct {
+		// Fingerprint identifies the request payload and query string.
+		Fingerprint string
+		// Status is the response status code.
+		Status int
+		// Header contains the response headers.
+		Header http.Header
+		// Body is the response body.
+		Body []byte
+	}
+
+	// IdempotencyStore is the interface implemented by the stores used by the Idempotency
+	// middleware to persist response snapshots. Implementations must be safe for concurrent
+	// use.
+	IdempotencyStore interface {
+		// Load returns the response stored under key, nil if there is none or if the
+		// key is only reserved.
+		Load(ctx context.Context, key string) (*IdempotentResponse, error)
+		// Reserve marks key as being handled until Save or Release is called or ttl
+		// elapses. It returns false if key is already reserved or has a stored response.
+		Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+		// Save stores the response under key for ttl, replacing the reservation.
+		Save(ctx context.Context, key string, resp *IdempotentResponse, ttl time.Duration) error
+		// Release removes the reservation of key so that the request may be retried.
+		Release(ctx context.Context, key string) error
+	}
+
+	// MemoryIdempotencyStore is an IdempotencyStore that keeps the responses in memory. It is
+	// only suitable for services that run a single instance.
+	MemoryIdempotencyStore struct {
+		mu      sync.Mutex
+		entries map[string]*memoryIdempotencyEntry
+	}
+
+	// memoryIdempotencyEntry is a reservation or a stored response.
+	memoryIdempotencyEntry struct {
+		resp    *IdempotentResponse
+		expires time.Time
+	}
+
+	// idempotencyRecorder records the response body written by the handler.
+	idempotencyRecorder struct {
+		http.ResponseWriter
+		body bytes.Buffer
+	}
+)
+
+// Idempotency is a middleware that makes POST and PATCH requests carrying an Idempotency-Key
+// header safe to retry. The first request with a given key is handled normally and its response
+// is stored in store for ttl (DefaultIdempotencyTTL if zero). Subsequent requests with the same
+// key, method and path get the stored response replayed with the Idempotent-Replayed header set
+// instead of running the handler again. Requests made while the first request is still being
+// handled fail with ErrIdempotencyConflict and requests that reuse a key with a different
+// payload fail with ErrIdempotencyKeyReused. Responses with a 5xx status code and handler errors
+// are not stored so that the request may be retried. Use the Idempotent DSL to document the
+// header in the API design.
+//
+// The middleware runs before the action security handlers so the stored responses are scoped to
+// the credentials of the request: the values of the given credential headers (Authorization and
+// Cookie if none is given) and the TLS client certificate. A request that reuses a key with
+// different credentials is handled as a new request and thus goes through authentication. List
+// the headers used by the API key security schemes of the API in credentialHeaders.
+//
+// The middleware loads the payload of requests whose loading was deferred (see
+// goa.LoadDeferredPayload) to compare it with the payload of the stored response.
+func Idempotency(store IdempotencyStore, ttl time.Duration, credentialHeaders ...string) goa.Middleware {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	if len(credentialHeaders) == 0 {
+		credentialHeaders = []string{"Authorization", "Cookie"}
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			key := req.Header.Get(IdempotencyKeyHeader)
+			if key == "" || (req.Method != "POST" && req.Method != "PATCH") {
+				return h(ctx, rw, req)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return goa.ErrBadRequest("%s header is too long, maximum length is %d", IdempotencyKeyHeader, maxIdempotencyKeyLength)
+			}
+			if err := goa.LoadDeferredPayload(ctx); err != nil {
+				// Let the handler report the invalid payload.
+				return h(ctx, rw, req)
+			}
+			credentials := requestCredentials(req, credentialHeaders)
+			key = req.Method + " " + req.URL.Path + " " + credentials + " " + key
+			fingerprint := requestFingerprint(ctx, req, credentials)
+
+			resp := goa.ContextResponse(ctx)
+			stored, err := store.Load(ctx, key)
+			if err != nil {
+				return goa.ErrInternal(err)
+			}
+			if stored != nil {
+				if stored.Fingerprint != fingerprint {
+					return ErrIdempotencyKeyReused("%s header value was already used with a different request", IdempotencyKeyHeader)
+				}
+				return replay(resp, stored)
+			}
+			ok, err := store.Reserve(ctx, key, ttl)
+			if err != nil {
+				return goa.ErrInternal(err)
+			}
+			if !ok {
+				return ErrIdempotencyConflict("a request with the same %s header value is in progress", IdempotencyKeyHeader)
+			}
+
+			saved := false
+			defer func() {
+				// Release the key if the handler failed or panicked.
+				if saved {
+					return
+				}
+				if rerr := store.Release(ctx, key); rerr != nil {
+					goa.LogError(ctx, "idempotency key release failed", "err", rerr)
+				}
+			}()
+			rec := &idempotencyRecorder{ResponseWriter: resp.SwitchWriter(nil)}
+			resp.SwitchWriter(rec)
+			err = h(ctx, rw, req)
+			resp.SwitchWriter(rec.ResponseWriter)
+			if err != nil || resp.Status == 0 || resp.Status >= 500 {
+				return err
+			}
+			snapshot := &IdempotentResponse{
+				Fingerprint: fingerprint,
+				Status:      resp.Status,
+				Header:      cloneHeader(resp.Header()),
+				Body:        rec.body.Bytes(),
+			}
+			if serr := store.Save(ctx, key, snapshot, ttl); serr != nil {
+				goa.LogError(ctx, "idempotent response save failed", "err", serr)
+				return nil
+			}
+			saved = true
+			return nil
+		}
+	}
+}
+
+// replay writes the stored response.
+func replay(resp *goa.ResponseData, stored *IdempotentResponse) error {
+	for k, v := range stored.Header {
+		resp.Header()[k] = v
+	}
+	resp.Header().Set(IdempotentReplayedHeader, "true")
+	resp.WriteHeader(stored.Status)
+	_, err := resp.Write(stored.Body)
+	return err
+}
+
+// cloneHeader returns a deep copy of h.
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+// NewMemoryIdempotencyStore creates an in-memory idempotency store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]*memoryIdempotencyEntry)}
+}
+
+// Load returns the response stored under key.
+func (s *MemoryIdempotencyStore) Load(ctx context.Context, key string) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.entry(ctx, key); e != nil {
+		return e.resp, nil
+	}
+	return nil, nil
+}
+
+// Reserve marks key as being handled.
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.entry(ctx, key); e != nil {
+		return false, nil
+	}
+	s.entries[key] = &memoryIdempotencyEntry{expires: goa.ContextClock(ctx).Now().Add(ttl)}
+	return true, nil
+}
+
+// Save stores the response under key.
+func (s *MemoryIdempotencyStore) Save(ctx context.Context, key string, resp *IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = &memoryIdempotencyEntry{resp: resp, expires: goa.ContextClock(ctx).Now().Add(ttl)}
+	return nil
+}
+
+// Release removes the reservation of key.
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// entry returns the entry stored under key, nil if there is none or if it has expired in which
+// case it is removed. s.mu must be held.
+func (s *MemoryIdempotencyStore) entry(ctx context.Context, key string) *memoryIdempotencyEntry {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !goa.ContextClock(ctx).Now().Before(e.expires) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+// Write records the body and writes it to the underlying writer.
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// requestCredentials computes a hash of the values of the given request headers and of the TLS
+// client certificate used to scope the stored responses to the request credentials.
+func requestCredentials(req *http.Request, headers []string) string {
+	h := sha256.New()
+	for _, name := range headers {
+		for _, v := range req.Header[http.CanonicalHeaderKey(name)] {
+			h.Write([]byte(name + ":" + v))
+			h.Write([]byte{0})
+		}
+	}
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		h.Write(req.TLS.PeerCertificates[0].Raw)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// requestFingerprint computes a hash of the request credentials, query string and decoded
+// payload used to detect idempotency keys reused with different requests.
+func requestFingerprint(ctx context.Context, req *http.Request, credentials string) string {
+	h := sha256.New()
+	h.Write([]byte(credentials))
+	h.Write([]byte{0})
+	h.Write([]byte(req.URL.RawQuery))
+	h.Write([]byte{0})
+	if r := goa.ContextRequest(ctx); r != nil && r.Payload != nil {
+		if b, err := json.Marshal(r.Payload); err == nil {
+			h.Write(b)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
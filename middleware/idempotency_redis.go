@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// RedisDoer sends commands to a Redis server. The Do method of the redigo connections
+	// (github.com/garyburd/redigo/redis.Conn) implements this interface, use RedisDoFunc to
+	// adapt a connection pool.
+	RedisDoer interface {
+		// Do sends the command with the given arguments and returns the server reply.
+		Do(command string, args ...interface{}) (interface{}, error)
+	}
+
+	// RedisDoFunc is a function that implements RedisDoer, for example:
+	//
+	//	doer := middleware.RedisDoFunc(func(cmd string, args ...interface{}) (interface{}, error) {
+	//		conn := pool.Get()
+	//		defer conn.Close()
+	//		return conn.Do(cmd, args...)
+	//	})
+	RedisDoFunc func(command string, args ...interface{}) (interface{}, error)
+
+	// RedisIdempotencyStore is an IdempotencyStore that keeps the responses in Redis so that
+	// they are shared by all the instances of a service. Reservations are stored as empty
+	// values and responses as JSON documents, both expire after the middleware TTL.
+	RedisIdempotencyStore struct {
+		doer   RedisDoer
+		prefix string
+	}
+)
+
+// NewRedisIdempotencyStore creates an idempotency store that sends commands using doer. prefix
+// is prepended to the keys, doer must be safe for concurrent use.
+func NewRedisIdempotencyStore(doer RedisDoer, prefix string) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{doer: doer, prefix: prefix}
+}
+
+// Do calls f.
+func (f RedisDoFunc) Do(command string, args ...interface{}) (interface{}, error) {
+	return f(command, args...)
+}
+
+// Load returns the response stored under key.
+func (s *RedisIdempotencyStore) Load(ctx context.Context, key string) (*IdempotentResponse, error) {
+	reply, err := s.doer.Do("GET", s.prefix+key)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	var b []byte
+	switch r := reply.(type) {
+	case []byte:
+		b = r
+	case string:
+		b = []byte(r)
+	default:
+		return nil, fmt.Errorf("unexpected redis reply type %T", reply)
+	}
+	if len(b) == 0 {
+		return nil, nil // Reserved
+	}
+	var resp IdempotentResponse
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Reserve marks key as being handled.
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	reply, err := s.doer.Do("SET", s.prefix+key, "", "PX", ttlMillis(ttl), "NX")
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+// Save stores the response under key.
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key string, resp *IdempotentResponse, ttl time.Duration) error {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = s.doer.Do("SET", s.prefix+key, b, "PX", ttlMillis(ttl))
+	return err
+}
+
+// Release removes the reservation of key.
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := s.doer.Do("DEL", s.prefix+key)
+	return err
+}
+
+// ttlMillis returns the number of milliseconds in ttl, at least 1.
+func ttlMillis(ttl time.Duration) int64 {
+	if ms := int64(ttl / time.Millisecond); ms > 0 {
+		return ms
+	}
+	return 1
+}
@@ -0,0 +1,241 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Idempotency", func() {
+	var store middleware.IdempotencyStore
+	var service *goa.Service
+	var handler goa.Handler
+	var calls int
+	var payload interface{}
+	var header http.Header
+
+	BeforeEach(func() {
+		store = middleware.NewMemoryIdempotencyStore()
+		service = newService(nil)
+		calls = 0
+		payload = map[string]string{"name": "foo"}
+		header = make(http.Header)
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			calls++
+			rw.Header().Set("Location", fmt.Sprintf("/bottles/%d", calls))
+			return service.Send(ctx, 201, map[string]int{"id": calls})
+		}
+	})
+
+	run := func(method, key string) (*testResponseWriter, error) {
+		req, err := http.NewRequest(method, "/bottles", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if key != "" {
+			req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		}
+		rw := newTestResponseWriter()
+		ctx := newContext(service, rw, req, nil)
+		goa.ContextRequest(ctx).Payload = payload
+		err = middleware.Idempotency(store, 0, "Authorization", "X-Api-Key")(handler)(ctx, rw, req)
+		return rw, err
+	}
+
+	It("replays the response to retried requests", func() {
+		rw, err := run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Status).Should(Equal(201))
+		first := string(rw.Body)
+
+		rw, err = run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(calls).Should(Equal(1))
+		Ω(rw.Status).Should(Equal(201))
+		Ω(string(rw.Body)).Should(Equal(first))
+		Ω(rw.Header().Get("Location")).Should(Equal("/bottles/1"))
+		Ω(rw.Header().Get(middleware.IdempotentReplayedHeader)).Should(Equal("true"))
+	})
+
+	It("runs the handler for requests with different keys", func() {
+		_, err := run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = run("POST", "def")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(calls).Should(Equal(2))
+	})
+
+	It("ignores requests with no key or other methods", func() {
+		_, err := run("POST", "")
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = run("PUT", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = run("PUT", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(calls).Should(Equal(3))
+	})
+
+	It("rejects keys reused with a different payload", func() {
+		_, err := run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		payload = map[string]string{"name": "bar"}
+		_, err = run("POST", "abc")
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(422))
+		Ω(calls).Should(Equal(1))
+	})
+
+	It("does not replay responses to requests with different credentials", func() {
+		header.Set("Authorization", "Bearer alice")
+		_, err := run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		header.Set("Authorization", "Bearer mallory")
+		rw, err := run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Header().Get(middleware.IdempotentReplayedHeader)).Should(BeEmpty())
+
+		header = make(http.Header)
+		_, err = run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(calls).Should(Equal(3))
+
+		header.Set("X-Api-Key", "secret")
+		_, err = run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(calls).Should(Equal(4))
+
+		header.Set("X-Api-Key", "secret")
+		rw, err = run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(calls).Should(Equal(4))
+		Ω(rw.Header().Get(middleware.IdempotentReplayedHeader)).Should(Equal("true"))
+	})
+
+	It("rejects keys reused with a different deferred payload", func() {
+		service.Use(middleware.ErrorHandler(service, false))
+		service.Use(middleware.Idempotency(store, 0))
+		unmarshal := func(ctx context.Context, service *goa.Service, req *http.Request) error {
+			var p interface{}
+			if err := service.DecodeRequest(req, &p); err != nil {
+				return err
+			}
+			goa.ContextRequest(ctx).Payload = p
+			return nil
+		}
+		h := service.NewController("test").MuxHandler("create", handler, unmarshal)
+		send := func(body string) *testResponseWriter {
+			req, err := http.NewRequest("POST", "/bottles", strings.NewReader(body))
+			Ω(err).ShouldNot(HaveOccurred())
+			req.Header.Set("Expect", "100-continue")
+			req.Header.Set(middleware.IdempotencyKeyHeader, "abc")
+			rw := newTestResponseWriter()
+			h(rw, req, nil)
+			return rw
+		}
+		Ω(send(`{"name":"foo"}`).Status).Should(Equal(201))
+		Ω(send(`{"name":"bar"}`).Status).Should(Equal(422))
+		Ω(calls).Should(Equal(1))
+	})
+
+	It("rejects requests made while the first request is in progress", func() {
+		var inner error
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			calls++
+			if calls == 1 {
+				_, inner = run("POST", "abc")
+			}
+			return service.Send(ctx, 201, "ok")
+		}
+		_, err := run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(inner).Should(HaveOccurred())
+		Ω(inner.(goa.ServiceError).ResponseStatus()).Should(Equal(409))
+	})
+
+	It("does not store failed responses", func() {
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			calls++
+			if calls == 1 {
+				return goa.ErrInternal("boom")
+			}
+			return service.Send(ctx, 201, "ok")
+		}
+		_, err := run("POST", "abc")
+		Ω(err).Should(HaveOccurred())
+		rw, err := run("POST", "abc")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Status).Should(Equal(201))
+		Ω(calls).Should(Equal(2))
+	})
+
+	Context("with a Redis store", func() {
+		var redis *testRedis
+
+		BeforeEach(func() {
+			redis = &testRedis{values: make(map[string]string)}
+			store = middleware.NewRedisIdempotencyStore(redis, "idem:")
+		})
+
+		It("replays the response to retried requests", func() {
+			_, err := run("POST", "abc")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(redis.values).Should(HaveLen(1))
+			rw, err := run("POST", "abc")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(calls).Should(Equal(1))
+			Ω(rw.Status).Should(Equal(201))
+			Ω(rw.Header().Get(middleware.IdempotentReplayedHeader)).Should(Equal("true"))
+		})
+	})
+})
+
+// testRedis implements the subset of the Redis commands used by the idempotency and rate limit
+// stores.
+type testRedis struct {
+	values map[string]string
+	err    error // returned by all commands if not nil
+}
+
+func (r *testRedis) Do(command string, args ...interface{}) (interface{}, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	key := args[0].(string)
+	switch command {
+	case "GET":
+		v, ok := r.values[key]
+		if !ok {
+			return nil, nil
+		}
+		return []byte(v), nil
+	case "SET":
+		if len(args) > 4 && args[4] == "NX" {
+			if _, ok := r.values[key]; ok {
+				return nil, nil
+			}
+		}
+		r.values[key] = fmt.Sprintf("%s", args[1])
+		return "OK", nil
+	case "DEL":
+		delete(r.values, key)
+		return int64(1), nil
+	case "INCR":
+		n, _ := strconv.ParseInt(r.values[key], 10, 64)
+		n++
+		r.values[key] = strconv.FormatInt(n, 10)
+		return n, nil
+	case "PEXPIRE":
+		return int64(1), nil
+	}
+	return nil, fmt.Errorf("unsupported command %s", command)
+}
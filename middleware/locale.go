@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+// Locale is a middleware that parses the request Accept-Language header and stores the resulting
+// list of locales ranked by preference in the context. Retrieve the list using ContextLocales.
+// The given default locales are appended to the list so that handlers may always fall back to
+// them. Locales are lower cased, e.g. "en-us".
+func Locale(defaults ...string) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			locales := ParseAcceptLanguage(req.Header.Get("Accept-Language"))
+			for _, d := range defaults {
+				d = strings.ToLower(d)
+				found := false
+				for _, l := range locales {
+					if l == d {
+						found = true
+						break
+					}
+				}
+				if !found {
+					locales = append(locales, d)
+				}
+			}
+			ctx = context.WithValue(ctx, localesKey, locales)
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// ContextLocales extracts the locales ranked by preference stored in the context by the Locale
+// middleware. It returns nil if the middleware is not mounted.
+func ContextLocales(ctx context.Context) []string {
+	if l := ctx.Value(localesKey); l != nil {
+		return l.([]string)
+	}
+	return nil
+}
+
+// ParseAcceptLanguage parses the value of an Accept-Language header as defined by RFC 7231 section
+// 5.3.5 and returns the locales it contains sorted by decreasing quality. Locales with a quality of
+// 0 and the "*" wildcard are omitted.
+func ParseAcceptLanguage(header string) []string {
+	var rs rankedLocales
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		locale, q := part, 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			locale = strings.TrimSpace(part[:i])
+			for _, param := range strings.Split(part[i+1:], ";") {
+				param = strings.TrimSpace(param)
+				if !strings.HasPrefix(param, "q=") {
+					continue
+				}
+				v, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					v = 0
+				}
+				q = v
+			}
+		}
+		if locale == "" || locale == "*" || q <= 0 {
+			continue
+		}
+		rs = append(rs, rankedLocale{strings.ToLower(locale), q})
+	}
+	sort.Stable(rs)
+	locales := make([]string, len(rs))
+	for i, r := range rs {
+		locales[i] = r.locale
+	}
+	return locales
+}
+
+type (
+	// rankedLocale is a locale and its quality as given in the Accept-Language header.
+	rankedLocale struct {
+		locale string
+		q      float64
+	}
+
+	// rankedLocales implements sort.Interface sorting by decreasing quality.
+	rankedLocales []rankedLocale
+)
+
+func (r rankedLocales) Len() int           { return len(r) }
+func (r rankedLocales) Less(i, j int) bool { return r[i].q > r[j].q }
+func (r rankedLocales) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
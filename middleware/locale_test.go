@@ -0,0 +1,80 @@
+package middleware_test
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locale", func() {
+	var header string
+	var defaults []string
+	var locales []string
+
+	BeforeEach(func() {
+		header = ""
+		defaults = nil
+		locales = nil
+	})
+
+	JustBeforeEach(func() {
+		service := newService(nil)
+		req, err := http.NewRequest("GET", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		if header != "" {
+			req.Header.Set("Accept-Language", header)
+		}
+		rw := new(testResponseWriter)
+		ctx := newContext(service, rw, req, nil)
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			locales = middleware.ContextLocales(ctx)
+			return nil
+		}
+		Ω(middleware.Locale(defaults...)(h)(ctx, rw, req)).ShouldNot(HaveOccurred())
+	})
+
+	It("sets an empty list when there is no header", func() {
+		Ω(locales).ShouldNot(BeNil())
+		Ω(locales).Should(BeEmpty())
+	})
+
+	Context("with an Accept-Language header", func() {
+		BeforeEach(func() {
+			header = "fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5, es;q=0"
+		})
+
+		It("ranks the locales by quality", func() {
+			Ω(locales).Should(Equal([]string{"fr-ch", "fr", "en", "de"}))
+		})
+
+		Context("and default locales", func() {
+			BeforeEach(func() {
+				defaults = []string{"en", "en-US"}
+			})
+
+			It("appends the missing defaults", func() {
+				Ω(locales).Should(Equal([]string{"fr-ch", "fr", "en", "de", "en-us"}))
+			})
+		})
+	})
+})
+
+var _ = Describe("ContextLocales", func() {
+	It("returns nil when the middleware is not mounted", func() {
+		Ω(middleware.ContextLocales(context.Background())).Should(BeNil())
+	})
+})
+
+var _ = Describe("ParseAcceptLanguage", func() {
+	It("keeps the header order for equal qualities", func() {
+		Ω(middleware.ParseAcceptLanguage("da, en-gb;q=0.8, en;q=0.8")).Should(Equal([]string{"da", "en-gb", "en"}))
+	})
+
+	It("ignores invalid qualities", func() {
+		Ω(middleware.ParseAcceptLanguage("da;q=foo, en")).Should(Equal([]string{"en"}))
+	})
+})
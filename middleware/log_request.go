@@ -8,7 +8,6 @@ import (
 	"net"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/goadesign/goa"
 
@@ -18,7 +17,8 @@ import (
 // LogRequest creates a request logger middleware.
 // This middleware is aware of the RequestID middleware and if registered after it leverages the
 // request ID for logging.
-// If verbose is true then the middlware logs the request and response bodies.
+// If verbose is true then the middlware logs the request and response bodies. The values of the
+// params and payload fields classified in the design (see the Classification DSL) are redacted.
 func LogRequest(verbose bool) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
@@ -27,7 +27,8 @@ func LogRequest(verbose bool) goa.Middleware {
 				reqID = shortID()
 			}
 			ctx = goa.WithLogContext(ctx, "req_id", reqID)
-			startedAt := time.Now()
+			clock := goa.ContextClock(ctx)
+			startedAt := clock.Now()
 			r := goa.ContextRequest(ctx)
 			goa.LogInfo(ctx, "started", r.Method, r.URL.String(), "from", from(req),
 				"ctrl", goa.ContextController(ctx), "action", goa.ContextAction(ctx))
@@ -37,7 +38,7 @@ func LogRequest(verbose bool) goa.Middleware {
 					i := 0
 					for k, v := range r.Params {
 						logCtx[i] = k
-						logCtx[i+1] = interface{}(strings.Join(v, ", "))
+						logCtx[i+1] = goa.Redact(ctx, k, strings.Join(v, ", "))
 						i = i + 2
 					}
 					goa.LogInfo(ctx, "params", logCtx...)
@@ -48,13 +49,13 @@ func LogRequest(verbose bool) goa.Middleware {
 						i := 0
 						for k, v := range mp {
 							logCtx[i] = k
-							logCtx[i+1] = interface{}(v)
+							logCtx[i+1] = goa.Redact(ctx, k, v)
 							i = i + 2
 						}
 						goa.LogInfo(ctx, "payload", logCtx...)
 					} else {
 						// Not the most efficient but this is used for debugging
-						js, err := json.Marshal(r.Payload)
+						js, err := json.Marshal(goa.RedactPayload(ctx, r.Payload))
 						if err != nil {
 							js = []byte("<invalid JSON>")
 						}
@@ -66,10 +67,10 @@ func LogRequest(verbose bool) goa.Middleware {
 			resp := goa.ContextResponse(ctx)
 			if code := resp.ErrorCode; code != "" {
 				goa.LogInfo(ctx, "completed", "status", resp.Status, "error", code,
-					"bytes", resp.Length, "time", time.Since(startedAt).String())
+					"bytes", resp.Length, "time", clock.Now().Sub(startedAt).String())
 			} else {
 				goa.LogInfo(ctx, "completed", "status", resp.Status,
-					"bytes", resp.Length, "time", time.Since(startedAt).String())
+					"bytes", resp.Length, "time", clock.Now().Sub(startedAt).String())
 			}
 			return err
 		}
@@ -79,6 +80,9 @@ func LogRequest(verbose bool) goa.Middleware {
 // shortID produces a "unique" 6 bytes long string.
 // Do not use as a reliable way to get unique IDs, instead use for things like logging.
 func shortID() string {
+	if id, ok := goa.NextSequentialID(); ok {
+		return id
+	}
 	b := make([]byte, 6)
 	io.ReadFull(rand.Reader, b)
 	return base64.StdEncoding.EncodeToString(b)
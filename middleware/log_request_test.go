@@ -69,6 +69,18 @@ var _ = Describe("LogRequest", func() {
 		Ω(logger.InfoEntries[3].Data[6]).Should(Equal("time"))
 	})
 
+	It("redacts classified fields", func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return service.Send(ctx, 200, "ok")
+		}
+		ctx = goa.WithClassifications(ctx, map[string]string{"query": "pii:email", "payload": "secret"})
+		lg := middleware.LogRequest(true)(h)
+		Ω(lg(ctx, rw, req)).ShouldNot(HaveOccurred())
+		Ω(logger.InfoEntries).Should(HaveLen(4))
+		Ω(logger.InfoEntries[1].Data[3]).Should(Equal("[redacted pii:email]"))
+		Ω(logger.InfoEntries[2].Data[3]).Should(Equal("[redacted secret]"))
+	})
+
 	It("logs error codes", func() {
 		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 			return goa.MissingParamError("foo")
@@ -88,7 +100,7 @@ var _ = Describe("LogRequest", func() {
 		Ω(logger.InfoEntries[1].Data[4]).Should(Equal("error"))
 		Ω(logger.InfoEntries[1].Data[5]).Should(HaveLen(8)) // Error ID
 		Ω(logger.InfoEntries[1].Data[6]).Should(Equal("bytes"))
-		Ω(logger.InfoEntries[1].Data[7]).Should(Equal(126))
+		Ω(logger.InfoEntries[1].Data[7]).Should(Equal(217))
 		Ω(logger.InfoEntries[1].Data[8]).Should(Equal("time"))
 	})
 })
@@ -0,0 +1,58 @@
+// +build otel
+
+package otel
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa/client"
+)
+
+// doFunc implements client.Doer with a function.
+type doFunc func(context.Context, *http.Request) (*http.Response, error)
+
+// Do calls f.
+func (f doFunc) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return f(ctx, req)
+}
+
+// Client wraps d so that a client span is created for each request it sends and the trace
+// context is injected in the request headers. The spans of the requests sent by the generated
+// clients are named after the resource and action being called (see client.WithOperation), the
+// spans of other requests are named after the HTTP method.
+func Client(d client.Doer, opts ...Option) client.Doer {
+	o := newOptions(opts)
+	tracer := o.provider.Tracer(instrumentationName)
+	return doFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		name := "HTTP " + req.Method
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.String()),
+		}
+		if resource, action := client.ContextOperation(ctx); action != "" {
+			name = resource + "." + action
+			attrs = append(attrs, attribute.String("goa.resource", resource), attribute.String("goa.action", action))
+		}
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		defer span.End()
+		o.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		resp, err := d.Do(ctx, req)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return resp, err
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= 500 {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+		return resp, nil
+	})
+}
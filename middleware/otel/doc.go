@@ -0,0 +1,27 @@
+// +build otel
+
+/*
+Package otel provides OpenTelemetry tracing for goa services and clients.
+
+The server middleware created with New starts a span for each request named after the resource and
+action that handle it, e.g. "Bottle.show". The span continues the trace propagated by the caller in
+the W3C traceparent and tracestate headers and the trace and span IDs are added to the log context
+of the request:
+
+	service.Use(otel.New())
+
+The client instrumentation created with Client starts a span for each request sent by a generated
+client, named after the resource and action being called, and injects the trace context in the
+request headers so that the downstream service continues the trace:
+
+	c := bottles.New(otel.Client(client.HTTPClientDoer(http.DefaultClient)))
+
+Both use the global tracer provider by default, see WithTracerProvider and WithPropagator to
+override the tracer provider and the propagation format.
+
+The OpenTelemetry packages require a much more recent version of Go than the rest of goa so this
+package is only built when the otel build tag is set:
+
+	go build -tags otel
+*/
+package otel
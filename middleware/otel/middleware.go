@@ -0,0 +1,66 @@
+// +build otel
+
+package otel
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+)
+
+// New returns a middleware that traces the requests handled by the service. The span of each
+// request is named after the resource and action that handle it and is a child of the span
+// propagated in the request headers if any. The span records the HTTP method, path and response
+// status and its status is set to error if the handler fails with a 5xx error.
+func New(opts ...Option) goa.Middleware {
+	o := newOptions(opts)
+	tracer := o.provider.Tracer(instrumentationName)
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			ctx = o.propagator.Extract(ctx, propagation.HeaderCarrier(req.Header))
+			resource := strings.TrimSuffix(goa.ContextController(ctx), "Controller")
+			action := goa.ContextAction(ctx)
+			ctx, span := tracer.Start(ctx, resource+"."+action,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("goa.resource", resource),
+					attribute.String("goa.action", action),
+					attribute.String("http.request.method", req.Method),
+					attribute.String("url.path", req.URL.Path),
+				),
+			)
+			defer span.End()
+			if sc := span.SpanContext(); sc.IsValid() {
+				ctx = goa.WithLogContext(ctx, "trace", sc.TraceID().String(), "span", sc.SpanID().String())
+			}
+
+			err := h(ctx, rw, req)
+
+			var status int
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				status = resp.Status
+			}
+			if err != nil {
+				span.RecordError(err)
+				status = http.StatusInternalServerError
+				if serr, ok := err.(goa.ServiceError); ok {
+					status = serr.ResponseStatus()
+				}
+			}
+			if status != 0 {
+				span.SetAttributes(attribute.Int("http.response.status_code", status))
+			}
+			if status >= 500 {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// +build otel
+
+package otel
+
+import (
+	global "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer used to create the spans.
+const instrumentationName = "github.com/goadesign/goa/middleware/otel"
+
+type (
+	// Option configures the server middleware and the client instrumentation.
+	Option func(*options)
+
+	// options holds the tracer provider and propagator used to create and propagate spans.
+	options struct {
+		provider   trace.TracerProvider
+		propagator propagation.TextMapPropagator
+	}
+)
+
+// WithTracerProvider sets the tracer provider used to create the spans, defaults to the global
+// tracer provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *options) {
+		o.provider = provider
+	}
+}
+
+// WithPropagator sets the propagator used to read and write the trace context in the request
+// headers, defaults to the W3C trace context format.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(o *options) {
+		o.propagator = propagator
+	}
+}
+
+// newOptions applies opts to the default options.
+func newOptions(opts []Option) *options {
+	o := &options{propagator: propagation.TraceContext{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.provider == nil {
+		o.provider = global.GetTracerProvider()
+	}
+	return o
+}
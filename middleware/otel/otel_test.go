@@ -0,0 +1,141 @@
+// +build otel
+
+package otel_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/client"
+	"github.com/goadesign/goa/middleware/otel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+type doFunc func(context.Context, *http.Request) (*http.Response, error)
+
+func (f doFunc) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return f(ctx, req)
+}
+
+var _ = Describe("New", func() {
+	var recorder *tracetest.SpanRecorder
+	var provider *sdktrace.TracerProvider
+	var service *goa.Service
+	var req *http.Request
+	var rw *httptest.ResponseRecorder
+	var handlerErr error
+	var handlerCtx context.Context
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		var err error
+		req, err = http.NewRequest("GET", "/bottles/1", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw = httptest.NewRecorder()
+		handlerErr = nil
+	})
+
+	JustBeforeEach(func() {
+		ctrl := service.NewController("BottleController")
+		ctx := goa.NewContext(goa.WithAction(ctrl.Context, "show"), rw, req, nil)
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			handlerCtx = ctx
+			if handlerErr != nil {
+				return handlerErr
+			}
+			return service.Send(ctx, 200, "ok")
+		}
+		otel.New(otel.WithTracerProvider(provider))(h)(ctx, rw, req)
+	})
+
+	It("creates a span named after the resource and action", func() {
+		spans := recorder.Ended()
+		Ω(spans).Should(HaveLen(1))
+		Ω(spans[0].Name()).Should(Equal("Bottle.show"))
+		Ω(spans[0].SpanKind()).Should(Equal(trace.SpanKindServer))
+		Ω(spans[0].Attributes()).Should(ContainElement(attribute.Int("http.response.status_code", 200)))
+		Ω(trace.SpanContextFromContext(handlerCtx)).Should(Equal(spans[0].SpanContext()))
+	})
+
+	Context("with a traceparent header", func() {
+		BeforeEach(func() {
+			req.Header.Set("traceparent", traceparent)
+		})
+
+		It("continues the trace", func() {
+			spans := recorder.Ended()
+			Ω(spans).Should(HaveLen(1))
+			Ω(spans[0].SpanContext().TraceID().String()).Should(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Ω(spans[0].Parent().SpanID().String()).Should(Equal("00f067aa0ba902b7"))
+		})
+	})
+
+	Context("with a handler that fails", func() {
+		BeforeEach(func() {
+			handlerErr = goa.ErrInternal("boom")
+		})
+
+		It("records the error", func() {
+			spans := recorder.Ended()
+			Ω(spans).Should(HaveLen(1))
+			Ω(spans[0].Status().Code).Should(Equal(codes.Error))
+			Ω(spans[0].Events()).Should(HaveLen(1))
+			Ω(spans[0].Attributes()).Should(ContainElement(attribute.Int("http.response.status_code", 500)))
+		})
+	})
+})
+
+var _ = Describe("Client", func() {
+	var recorder *tracetest.SpanRecorder
+	var provider *sdktrace.TracerProvider
+	var sent *http.Request
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		sent = nil
+	})
+
+	send := func(ctx context.Context, status int) {
+		d := doFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			sent = req
+			return &http.Response{StatusCode: status, Status: http.StatusText(status)}, nil
+		})
+		req, err := http.NewRequest("GET", "http://localhost/bottles/1", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = otel.Client(d, otel.WithTracerProvider(provider)).Do(ctx, req)
+		Ω(err).ShouldNot(HaveOccurred())
+	}
+
+	It("injects the trace context in the request", func() {
+		send(client.WithOperation(context.Background(), "bottle", "show"), 200)
+		spans := recorder.Ended()
+		Ω(spans).Should(HaveLen(1))
+		Ω(spans[0].Name()).Should(Equal("bottle.show"))
+		Ω(spans[0].SpanKind()).Should(Equal(trace.SpanKindClient))
+		sc := spans[0].SpanContext()
+		Ω(sent.Header.Get("traceparent")).Should(Equal("00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-01"))
+	})
+
+	It("names the spans of other requests after the method", func() {
+		send(context.Background(), 503)
+		spans := recorder.Ended()
+		Ω(spans).Should(HaveLen(1))
+		Ω(spans[0].Name()).Should(Equal("HTTP GET"))
+		Ω(spans[0].Status().Code).Should(Equal(codes.Error))
+	})
+})
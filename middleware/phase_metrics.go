@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+// PhaseTotal is the key of PhaseThresholds that sets the threshold of the total request duration.
+const PhaseTotal = "total"
+
+// PhaseThresholds maps the names of the request processing phases (goa.PhaseDecode,
+// goa.PhaseValidate, goa.PhaseHandler, goa.PhaseEncode or PhaseTotal) to the duration above which
+// the phase is considered slow.
+type PhaseThresholds map[string]time.Duration
+
+// PhaseMetrics returns a middleware that records the duration of the request processing phases
+// (see goa.Timings) as metric samples named "goa.phase.<controller>.<action>.<phase>" in
+// milliseconds. This makes it possible to tell whether the time is spent in the framework
+// (decoding, validation and encoding) or in the action handler.
+//
+// Requests where a phase takes longer than its threshold in slow are logged with the duration of
+// all the phases, a nil or empty slow disables logging.
+func PhaseMetrics(slow PhaseThresholds) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			timings := goa.ContextTimings(ctx)
+			if timings == nil {
+				return h(ctx, rw, req)
+			}
+			err := h(ctx, rw, req)
+			total := timings.Finish()
+			ctrl := strings.TrimSuffix(goa.ContextController(ctx), "Controller")
+			action := goa.ContextAction(ctx)
+			phases := append(timings.Phases(), goa.Phase{Name: PhaseTotal, Duration: total})
+			var slowPhases []string
+			keyvals := []interface{}{"ctrl", goa.ContextController(ctx), "action", action}
+			for _, p := range phases {
+				goa.AddSample([]string{"goa", "phase", ctrl, action, p.Name}, float32(p.Duration)/float32(time.Millisecond))
+				if th, ok := slow[p.Name]; ok && th > 0 && p.Duration > th {
+					slowPhases = append(slowPhases, p.Name)
+				}
+				keyvals = append(keyvals, p.Name, p.Duration.String())
+			}
+			if len(slowPhases) > 0 {
+				goa.LogInfo(ctx, "slow request", append(keyvals, "slow", strings.Join(slowPhases, ","))...)
+			}
+			return err
+		}
+	}
+}
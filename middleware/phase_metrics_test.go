@@ -0,0 +1,72 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PhaseMetrics", func() {
+	var logger *testLogger
+	var clock *goatest.Clock
+	var delay time.Duration
+
+	BeforeEach(func() {
+		logger = new(testLogger)
+		clock = goatest.NewClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+		delay = time.Millisecond
+	})
+
+	JustBeforeEach(func() {
+		service := newService(logger)
+		service.WithClock(clock)
+		service.Use(middleware.PhaseMetrics(middleware.PhaseThresholds{goa.PhaseHandler: 10 * time.Millisecond}))
+		ctrl := service.NewController("BottleController")
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			defer goa.StartPhase(ctx, goa.PhaseHandler)()
+			clock.Advance(delay)
+			rw.WriteHeader(200)
+			clock.Advance(2 * time.Millisecond)
+			_, err := rw.Write([]byte("ok"))
+			return err
+		}
+		req, err := http.NewRequest("GET", "/bottles/1", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		ctrl.MuxHandler("show", h, nil)(httptest.NewRecorder(), req, nil)
+	})
+
+	It("does not log fast requests", func() {
+		for _, e := range logger.InfoEntries {
+			Ω(e.Msg).ShouldNot(Equal("slow request"))
+		}
+	})
+
+	Context("with a slow handler", func() {
+		BeforeEach(func() {
+			delay = 20 * time.Millisecond
+		})
+
+		It("logs the duration of the phases", func() {
+			var entry *logEntry
+			for i, e := range logger.InfoEntries {
+				if e.Msg == "slow request" {
+					entry = &logger.InfoEntries[i]
+				}
+			}
+			Ω(entry).ShouldNot(BeNil())
+			Ω(entry.Data).Should(Equal([]interface{}{
+				"ctrl", "BottleController", "action", "show",
+				"handler", "20ms", "encode", "2ms", "total", "22ms",
+				"slow", "handler",
+			}))
+		})
+	})
+})
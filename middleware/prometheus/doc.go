@@ -0,0 +1,16 @@
+/*
+Package prometheus provides a middleware that records Prometheus metrics for the requests handled by
+a goa service.
+
+The metrics are labeled with the names of the resource and action that handle the requests, as
+defined in the design, and with the class of the response status (e.g. "2xx") rather than with the
+raw request paths so that the number of time series stays bounded:
+
+	m := prometheus.New("cellar")
+	service.Use(m.Middleware())
+	service.Mux.Handle("GET", "/metrics", prometheus.Handler(m))
+
+Collectors implement prometheus.Collector so they may also be registered with an existing registry
+and exposed with promhttp.
+*/
+package prometheus
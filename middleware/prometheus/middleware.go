@@ -0,0 +1,142 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+)
+
+// Collector records the metrics of the requests handled by a service. It exposes:
+//
+//	<namespace>_requests_total{resource, action, class}            counter
+//	<namespace>_request_duration_seconds{resource, action, class}  histogram
+//	<namespace>_requests_in_flight{resource, action}                gauge
+//	<namespace>_request_phase_duration_seconds{resource, action, phase} histogram
+//
+// The phase histogram records the duration of the request processing phases (decode, validate,
+// handler and encode, see goa.Timings) to tell the time spent in the framework from the time
+// spent in the action handler.
+//
+// Collector implements prometheus.Collector.
+type Collector struct {
+	requests *prom.CounterVec
+	duration *prom.HistogramVec
+	inFlight *prom.GaugeVec
+	phases   *prom.HistogramVec
+}
+
+// New creates a collector whose metric names are prefixed with namespace. The request and phase
+// duration histograms use the given buckets or prometheus.DefBuckets if none is given.
+func New(namespace string, buckets ...float64) *Collector {
+	if len(buckets) == 0 {
+		buckets = prom.DefBuckets
+	}
+	return &Collector{
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Number of requests handled by resource, action and response status class.",
+		}, []string{"resource", "action", "class"}),
+		duration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the requests by resource, action and response status class.",
+			Buckets:   buckets,
+		}, []string{"resource", "action", "class"}),
+		inFlight: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: namespace,
+			Name:      "requests_in_flight",
+			Help:      "Number of requests being handled by resource and action.",
+		}, []string{"resource", "action"}),
+		phases: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_phase_duration_seconds",
+			Help:      "Duration of the request processing phases by resource, action and phase.",
+			Buckets:   buckets,
+		}, []string{"resource", "action", "phase"}),
+	}
+}
+
+// Middleware returns a middleware that records the metrics of the requests. The middleware must
+// be mounted on the service so that the resource and action names are available in the request
+// context. The request durations are measured using the clock stored in the context, see
+// goa.WithClock.
+func (c *Collector) Middleware() goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resource := strings.TrimSuffix(goa.ContextController(ctx), "Controller")
+			action := goa.ContextAction(ctx)
+			inFlight := c.inFlight.WithLabelValues(resource, action)
+			inFlight.Inc()
+			defer inFlight.Dec()
+			clock := goa.ContextClock(ctx)
+			startedAt := clock.Now()
+
+			err := h(ctx, rw, req)
+
+			var status int
+			if resp := goa.ContextResponse(ctx); resp != nil {
+				status = resp.Status
+			}
+			if err != nil {
+				status = http.StatusInternalServerError
+				if serr, ok := err.(goa.ServiceError); ok {
+					status = serr.ResponseStatus()
+				}
+			}
+			class := statusClass(status)
+			c.requests.WithLabelValues(resource, action, class).Inc()
+			c.duration.WithLabelValues(resource, action, class).Observe(clock.Now().Sub(startedAt).Seconds())
+			if timings := goa.ContextTimings(ctx); timings != nil {
+				timings.Finish()
+				for _, p := range timings.Phases() {
+					c.phases.WithLabelValues(resource, action, p.Name).Observe(p.Duration.Seconds())
+				}
+			}
+			return err
+		}
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.inFlight.Describe(ch)
+	c.phases.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.inFlight.Collect(ch)
+	c.phases.Collect(ch)
+}
+
+// Handler returns a handler that serves the metrics of the given collectors in the Prometheus
+// exposition format. The collectors are registered in a dedicated registry, use promhttp to
+// expose the metrics of an existing registry instead.
+func Handler(collectors ...prom.Collector) goa.MuxHandler {
+	reg := prom.NewRegistry()
+	reg.MustRegister(collectors...)
+	h := promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+	return func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+		h.ServeHTTP(rw, req)
+	}
+}
+
+// statusClass returns the class of the given response status, e.g. "2xx".
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
@@ -0,0 +1,71 @@
+package prometheus_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
+	"github.com/goadesign/goa/middleware/prometheus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Collector", func() {
+	var collector *prometheus.Collector
+	var service *goa.Service
+	var clock *goatest.Clock
+
+	BeforeEach(func() {
+		collector = prometheus.New("test")
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		clock = goatest.NewClock(time.Unix(1500000000, 0))
+		service.WithClock(clock)
+	})
+
+	serve := func(path string, err error) {
+		req, e := http.NewRequest("GET", path, nil)
+		Ω(e).ShouldNot(HaveOccurred())
+		rw := httptest.NewRecorder()
+		ctrl := service.NewController("BottleController")
+		ctx := goa.NewContext(goa.WithAction(ctrl.Context, "show"), rw, req, nil)
+		ctx = goa.WithTimings(ctx, goa.NewTimings(goa.ContextClock(ctx)))
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			defer goa.StartPhase(ctx, goa.PhaseHandler)()
+			clock.Advance(250 * time.Millisecond)
+			if err != nil {
+				return err
+			}
+			return service.Send(ctx, 200, "ok")
+		}
+		collector.Middleware()(h)(ctx, rw, req)
+	}
+
+	scrape := func() string {
+		req, err := http.NewRequest("GET", "/metrics", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw := httptest.NewRecorder()
+		prometheus.Handler(collector)(rw, req, nil)
+		Ω(rw.Code).Should(Equal(200))
+		return rw.Body.String()
+	}
+
+	It("labels the metrics with the resource, action and status class", func() {
+		serve("/bottles/1", nil)
+		serve("/bottles/2", nil)
+		serve("/bottles/3", goa.ErrNotFound("no bottle"))
+		metrics := scrape()
+		Ω(metrics).Should(ContainSubstring(`test_requests_total{action="show",class="2xx",resource="Bottle"} 2`))
+		Ω(metrics).Should(ContainSubstring(`test_requests_total{action="show",class="4xx",resource="Bottle"} 1`))
+		Ω(metrics).Should(ContainSubstring(`test_request_duration_seconds_count{action="show",class="2xx",resource="Bottle"} 2`))
+		Ω(metrics).Should(ContainSubstring(`test_request_duration_seconds_sum{action="show",class="2xx",resource="Bottle"} 0.5`))
+		Ω(metrics).Should(ContainSubstring(`test_requests_in_flight{action="show",resource="Bottle"} 0`))
+		Ω(metrics).Should(ContainSubstring(`test_request_phase_duration_seconds_count{action="show",phase="handler",resource="Bottle"} 3`))
+		Ω(metrics).Should(ContainSubstring(`test_request_phase_duration_seconds_sum{action="show",phase="handler",resource="Bottle"} 0.75`))
+		Ω(metrics).ShouldNot(ContainSubstring("/bottles"))
+	})
+})
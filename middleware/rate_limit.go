@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// RateLimitLimitHeader is the name of the response header that holds the number of
+	// requests allowed per interval.
+	RateLimitLimitHeader = "X-RateLimit-Limit"
+
+	// RateLimitRemainingHeader is the name of the response header that holds the number of
+	// requests left before the limit is reached.
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+
+	// RateLimitResetHeader is the name of the response header that holds the number of
+	// seconds until the limit is fully replenished.
+	RateLimitResetHeader = "X-RateLimit-Reset"
+)
+
+// ErrTooManyRequests is the class of errors returned when a request exceeds the rate limit.
+var ErrTooManyRequests = goa.NewErrorClass("too_many_requests", 429)
+
+type (
+	// RateLimit describes the number of requests allowed per interval.
+	RateLimit struct {
+		// Requests is the number of requests allowed per interval.
+		Requests int
+		// Interval is the duration over which Requests are allowed.
+		Interval time.Duration
+		// Burst is the maximum number of requests that may be made at once, defaults to
+		// Requests.
+		Burst int
+	}
+
+	// RateLimitResult is the outcome of taking a request from a rate limit quota.
+	RateLimitResult struct {
+		// Allowed is true if the request is within the limit.
+		Allowed bool
+		// Remaining is the number of requests left before the limit is reached.
+		Remaining int
+		// Reset is the delay until the quota is fully replenished.
+		Reset time.Duration
+		// RetryAfter is the delay until the next request is allowed if the request is
+		// not.
+		RetryAfter time.Duration
+	}
+
+	// RateLimitStore is the interface implemented by the stores used by the RateLimiter
+	// middleware to keep track of the requests made by each client. Implementations must be
+	// safe for concurrent use.
+	RateLimitStore interface {
+		// Take records a request made by the client identified by key.
+		Take(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error)
+	}
+
+	// RateLimitKeyFunc computes the key that identifies the client making a request, requests
+	// with an empty key are not limited.
+	RateLimitKeyFunc func(*http.Request) string
+
+	// MemoryRateLimitStore is a RateLimitStore that implements a token bucket per client in
+	// memory: the bucket holds up to Burst tokens and is refilled at the rate of Requests per
+	// Interval. It is only suitable for services that run a single instance.
+	MemoryRateLimitStore struct {
+		mu        sync.Mutex
+		buckets   map[string]*rateLimitBucket
+		lastSweep time.Time
+	}
+
+	// rateLimitBucket is the token bucket of a client.
+	rateLimitBucket struct {
+		tokens  float64
+		updated time.Time
+		full    time.Time
+	}
+)
+
+// RateLimiter is a middleware that limits the number of requests each client can make. The
+// client is identified by the key computed by key from the request. Responses to allowed requests
+// have the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers set. Requests
+// that exceed the limit fail with ErrTooManyRequests and the response has the Retry-After header
+// set. Use the RateLimit DSL to have the generated code mount the middleware.
+func RateLimiter(store RateLimitStore, limit RateLimit, key RateLimitKeyFunc) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			k := key(req)
+			if k == "" {
+				return h(ctx, rw, req)
+			}
+			res, err := store.Take(ctx, k, limit)
+			if err != nil {
+				return goa.ErrInternal(err)
+			}
+			rw.Header().Set(RateLimitLimitHeader, strconv.Itoa(limit.Requests))
+			rw.Header().Set(RateLimitRemainingHeader, strconv.Itoa(res.Remaining))
+			rw.Header().Set(RateLimitResetHeader, strconv.Itoa(seconds(res.Reset)))
+			if !res.Allowed {
+				rw.Header().Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+				return ErrTooManyRequests("rate limit of %d requests per %s exceeded", limit.Requests, limit.Interval)
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// RateLimitKeyIP identifies clients by their IP address.
+func RateLimitKeyIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// RateLimitKeyHeader returns a key function that identifies clients by the value of the request
+// header with the given name, for example the Authorization header or an API key header.
+func RateLimitKeyHeader(name string) RateLimitKeyFunc {
+	return func(req *http.Request) string {
+		if v := req.Header.Get(name); v != "" {
+			return name + ":" + v
+		}
+		return ""
+	}
+}
+
+// NewMemoryRateLimitStore creates a rate limit store that keeps the token buckets in memory.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*rateLimitBucket)}
+}
+
+// Take takes a token from the bucket of key.
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	now := goa.ContextClock(ctx).Now()
+	capacity := float64(limit.Burst)
+	if capacity <= 0 {
+		capacity = float64(limit.Requests)
+	}
+	rate := float64(limit.Requests) / float64(limit.Interval) // tokens per nanosecond
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastSweep) > limit.Interval {
+		for k, b := range s.buckets {
+			if !now.Before(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &rateLimitBucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.updated))*rate)
+	b.updated = now
+	res := &RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) / rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((capacity - b.tokens) / rate)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// seconds returns the number of seconds in d rounded up.
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
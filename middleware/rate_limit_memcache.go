@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+var (
+	// ErrMemcacheMiss is the error returned by MemcacheClient implementations when a key does
+	// not exist.
+	ErrMemcacheMiss = errors.New("memcache: cache miss")
+
+	// ErrMemcacheNotStored is the error returned by MemcacheClient.Add when a key already
+	// exists.
+	ErrMemcacheNotStored = errors.New("memcache: item not stored")
+)
+
+type (
+	// MemcacheClient sends commands to memcached. The methods map directly to the Increment,
+	// Add and Get methods of the github.com/bradfitz/gomemcache/memcache client once its errors
+	// are translated to ErrMemcacheMiss and ErrMemcacheNotStored.
+	MemcacheClient interface {
+		// Increment atomically increments the value of key by delta and returns the new
+		// value. It returns ErrMemcacheMiss if key does not exist.
+		Increment(key string, delta uint64) (uint64, error)
+		// Add stores value under key with the given expiration in seconds. It returns
+		// ErrMemcacheNotStored if key already exists.
+		Add(key string, value []byte, expiration int32) error
+		// Get returns the value of key. It returns ErrMemcacheMiss if key does not exist.
+		Get(key string) ([]byte, error)
+	}
+
+	// MemcacheRateLimitCounter is a RateLimitCounter that stores the counters in memcached.
+	MemcacheRateLimitCounter struct {
+		client MemcacheClient
+	}
+)
+
+// NewMemcacheRateLimitCounter creates a rate limit counter that sends commands using client,
+// client must be safe for concurrent use.
+func NewMemcacheRateLimitCounter(client MemcacheClient) *MemcacheRateLimitCounter {
+	return &MemcacheRateLimitCounter{client: client}
+}
+
+// Incr increments the counter, creating it with the given TTL if it does not exist. memcached
+// expirations have a resolution of one second so ttl is rounded up.
+func (c *MemcacheRateLimitCounter) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	n, err := c.client.Increment(key, 1)
+	if err == nil {
+		return int64(n), nil
+	}
+	if err != ErrMemcacheMiss {
+		return 0, err
+	}
+	err = c.client.Add(key, []byte("1"), int32(seconds(ttl)))
+	if err == nil {
+		return 1, nil
+	}
+	if err != ErrMemcacheNotStored {
+		return 0, err
+	}
+	// Another instance created the counter concurrently.
+	n, err = c.client.Increment(key, 1)
+	return int64(n), err
+}
+
+// Get reads the counter.
+func (c *MemcacheRateLimitCounter) Get(ctx context.Context, key string) (int64, error) {
+	v, err := c.client.Get(key)
+	if err == ErrMemcacheMiss {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	// memcached may pad incremented values with spaces.
+	return strconv.ParseInt(strings.TrimSpace(string(v)), 10, 64)
+}
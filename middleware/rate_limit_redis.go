@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// RedisRateLimitStore is a RateLimitStore that counts the requests in Redis so that the
+	// limits are shared by all the instances of a service. Requests are counted in fixed windows
+	// of the limit interval, the Burst field of the limit does not apply. See
+	// SlidingWindowRateLimitStore for a smoother limit.
+	RedisRateLimitStore struct {
+		counter *RedisRateLimitCounter
+		prefix  string
+	}
+
+	// RedisRateLimitCounter is a RateLimitCounter that stores the counters in Redis. Each
+	// command sent by the counter involves a single key so that doer may be a Redis Cluster
+	// client.
+	RedisRateLimitCounter struct {
+		doer RedisDoer
+	}
+)
+
+// NewRedisRateLimitStore creates a rate limit store that sends commands using doer. prefix is
+// prepended to the keys, doer must be safe for concurrent use.
+func NewRedisRateLimitStore(doer RedisDoer, prefix string) *RedisRateLimitStore {
+	return &RedisRateLimitStore{counter: NewRedisRateLimitCounter(doer), prefix: prefix}
+}
+
+// Take increments the request count of key for the current window.
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	now := goa.ContextClock(ctx).Now()
+	window := now.UnixNano() / int64(limit.Interval)
+	reset := time.Duration((window+1)*int64(limit.Interval) - now.UnixNano())
+	count, err := s.counter.Incr(ctx, s.prefix+key+":"+strconv.FormatInt(window, 10), reset)
+	if err != nil {
+		return nil, err
+	}
+	res := &RateLimitResult{Reset: reset}
+	if count <= int64(limit.Requests) {
+		res.Allowed = true
+		res.Remaining = limit.Requests - int(count)
+	} else {
+		res.RetryAfter = reset
+	}
+	return res, nil
+}
+
+// NewRedisRateLimitCounter creates a rate limit counter that sends commands using doer, doer must
+// be safe for concurrent use.
+func NewRedisRateLimitCounter(doer RedisDoer) *RedisRateLimitCounter {
+	return &RedisRateLimitCounter{doer: doer}
+}
+
+// Incr increments the counter with INCR and sets its TTL with PEXPIRE when it is created.
+func (c *RedisRateLimitCounter) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	reply, err := c.doer.Do("INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	count, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected reply to INCR: %v", reply)
+	}
+	if count == 1 {
+		if _, err := c.doer.Do("PEXPIRE", key, ttlMillis(ttl)); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// Get reads the counter with GET.
+func (c *RedisRateLimitCounter) Get(ctx context.Context, key string) (int64, error) {
+	reply, err := c.doer.Do("GET", key)
+	if err != nil || reply == nil {
+		return 0, err
+	}
+	var s string
+	switch v := reply.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return 0, fmt.Errorf("unexpected reply to GET: %v", reply)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// RateLimitCounter is the interface implemented by the backends that store the request
+	// counters of SlidingWindowRateLimitStore. Backends shared by the instances of a service
+	// make the limits apply to the service as a whole. Implementations must be safe for
+	// concurrent use.
+	RateLimitCounter interface {
+		// Incr atomically increments the counter stored under key and returns its new
+		// value. The counter is created with the given TTL if it does not exist.
+		Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+		// Get returns the value of the counter stored under key, 0 if it does not exist.
+		Get(ctx context.Context, key string) (int64, error)
+	}
+
+	// SlidingWindowRateLimitStore is a RateLimitStore that counts the requests in a
+	// RateLimitCounter using a sliding window: the number of requests made during the last
+	// interval is estimated from the counts of the current and previous fixed windows weighted
+	// by their overlap with the sliding window. This avoids the bursts allowed at the window
+	// boundaries by fixed windows. The Burst field of the limit does not apply.
+	SlidingWindowRateLimitStore struct {
+		counter RateLimitCounter
+		prefix  string
+	}
+
+	// FailoverRateLimitStore is a RateLimitStore that uses Fallback when Primary fails so that
+	// requests are still limited, per instance, while a distributed store is unavailable.
+	FailoverRateLimitStore struct {
+		// Primary is the store used as long as it does not fail.
+		Primary RateLimitStore
+		// Fallback is the store used when Primary fails.
+		Fallback RateLimitStore
+	}
+)
+
+// NewSlidingWindowRateLimitStore creates a rate limit store that keeps its counters in counter.
+// prefix is prepended to the keys, the client key is enclosed in braces so that the counters of a
+// client share the same Redis Cluster hash slot.
+func NewSlidingWindowRateLimitStore(counter RateLimitCounter, prefix string) *SlidingWindowRateLimitStore {
+	return &SlidingWindowRateLimitStore{counter: counter, prefix: prefix}
+}
+
+// Take counts a request made by the client identified by key.
+func (s *SlidingWindowRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	now := goa.ContextClock(ctx).Now()
+	window := now.UnixNano() / int64(limit.Interval)
+	elapsed := time.Duration(now.UnixNano() - window*int64(limit.Interval))
+	curr, err := s.counter.Incr(ctx, s.key(key, window), 2*limit.Interval)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := s.counter.Get(ctx, s.key(key, window-1))
+	if err != nil {
+		return nil, err
+	}
+	weight := 1 - float64(elapsed)/float64(limit.Interval)
+	count := float64(prev)*weight + float64(curr)
+	res := &RateLimitResult{Reset: limit.Interval - elapsed}
+	if prev > 0 {
+		res.Reset += limit.Interval
+	}
+	requests := float64(limit.Requests)
+	if count <= requests {
+		res.Allowed = true
+		res.Remaining = int(math.Floor(requests - count))
+		return res, nil
+	}
+	if float64(curr) > requests || prev == 0 {
+		// The limit is exceeded until the next window starts at least.
+		res.RetryAfter = limit.Interval - elapsed
+	} else {
+		// Wait until the weight of the previous window lets one more request in.
+		w := (requests - float64(curr)) / float64(prev)
+		res.RetryAfter = time.Duration((1-w)*float64(limit.Interval)) - elapsed
+	}
+	return res, nil
+}
+
+// key computes the key of the counter of the client for the given window.
+func (s *SlidingWindowRateLimitStore) key(key string, window int64) string {
+	return s.prefix + "{" + key + "}:" + strconv.FormatInt(window, 10)
+}
+
+// NewFailoverRateLimitStore creates a rate limit store that uses primary and falls back to an
+// in-memory store when primary fails.
+func NewFailoverRateLimitStore(primary RateLimitStore) *FailoverRateLimitStore {
+	return &FailoverRateLimitStore{Primary: primary, Fallback: NewMemoryRateLimitStore()}
+}
+
+// Take takes the request from Primary or from Fallback if Primary fails.
+func (s *FailoverRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	res, err := s.Primary.Take(ctx, key, limit)
+	if err == nil {
+		return res, nil
+	}
+	goa.LogError(ctx, "rate limit store failed, using fallback", "err", err)
+	return s.Fallback.Take(ctx, key, limit)
+}
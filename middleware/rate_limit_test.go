@@ -0,0 +1,243 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimiter", func() {
+	var store middleware.RateLimitStore
+	var limit middleware.RateLimit
+	var service *goa.Service
+	var clock *goatest.Clock
+	var calls int
+
+	BeforeEach(func() {
+		store = middleware.NewMemoryRateLimitStore()
+		limit = middleware.RateLimit{Requests: 2, Interval: time.Second}
+		service = newService(nil)
+		clock = goatest.NewClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+		service.WithClock(clock)
+		calls = 0
+	})
+
+	run := func(addr string) (*testResponseWriter, error) {
+		req, err := http.NewRequest("GET", "/bottles", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		req.RemoteAddr = addr
+		rw := newTestResponseWriter()
+		ctx := newContext(service, rw, req, nil)
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			calls++
+			return service.Send(ctx, 200, "ok")
+		}
+		err = middleware.RateLimiter(store, limit, middleware.RateLimitKeyIP)(h)(ctx, rw, req)
+		return rw, err
+	}
+
+	It("limits the requests of each client", func() {
+		rw, err := run("10.0.0.1:1234")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Header().Get(middleware.RateLimitLimitHeader)).Should(Equal("2"))
+		Ω(rw.Header().Get(middleware.RateLimitRemainingHeader)).Should(Equal("1"))
+		Ω(rw.Header().Get(middleware.RateLimitResetHeader)).Should(Equal("1"))
+		_, err = run("10.0.0.1:1235")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		rw, err = run("10.0.0.1:1236")
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(429))
+		Ω(rw.Header().Get("Retry-After")).Should(Equal("1"))
+		Ω(rw.Header().Get(middleware.RateLimitRemainingHeader)).Should(Equal("0"))
+		Ω(calls).Should(Equal(2))
+
+		_, err = run("10.0.0.2:1234")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(calls).Should(Equal(3))
+	})
+
+	It("replenishes the quota over time", func() {
+		run("10.0.0.1:1234")
+		run("10.0.0.1:1234")
+		clock.Advance(500 * time.Millisecond)
+		_, err := run("10.0.0.1:1234")
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = run("10.0.0.1:1234")
+		Ω(err).Should(HaveOccurred())
+	})
+
+	Context("with a burst", func() {
+		BeforeEach(func() {
+			limit.Burst = 4
+		})
+
+		It("allows the burst at once", func() {
+			for i := 0; i < 4; i++ {
+				_, err := run("10.0.0.1:1234")
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+			_, err := run("10.0.0.1:1234")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a Redis store", func() {
+		var redis *testRedis
+
+		BeforeEach(func() {
+			redis = &testRedis{values: make(map[string]string)}
+			store = middleware.NewRedisRateLimitStore(redis, "rl:")
+		})
+
+		It("counts the requests in fixed windows", func() {
+			run("10.0.0.1:1234")
+			rw, err := run("10.0.0.1:1234")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Header().Get(middleware.RateLimitRemainingHeader)).Should(Equal("0"))
+			_, err = run("10.0.0.1:1234")
+			Ω(err).Should(HaveOccurred())
+			clock.Advance(time.Second)
+			_, err = run("10.0.0.1:1234")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(redis.values).Should(HaveLen(2))
+		})
+	})
+})
+
+var _ = Describe("SlidingWindowRateLimitStore", func() {
+	var counter middleware.RateLimitCounter
+	var store *middleware.SlidingWindowRateLimitStore
+	var limit middleware.RateLimit
+	var clock *goatest.Clock
+	var ctx context.Context
+
+	take := func() *middleware.RateLimitResult {
+		res, err := store.Take(ctx, "10.0.0.1", limit)
+		Ω(err).ShouldNot(HaveOccurred())
+		return res
+	}
+
+	BeforeEach(func() {
+		limit = middleware.RateLimit{Requests: 4, Interval: time.Minute}
+		clock = goatest.NewClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+		ctx = goa.WithClock(context.Background(), clock)
+	})
+
+	JustBeforeEach(func() {
+		store = middleware.NewSlidingWindowRateLimitStore(counter, "rl:")
+	})
+
+	assertSlidingWindow := func() {
+		It("weights the previous window", func() {
+			for i := 0; i < 4; i++ {
+				Ω(take().Allowed).Should(BeTrue())
+			}
+			res := take()
+			Ω(res.Allowed).Should(BeFalse())
+			Ω(res.RetryAfter).Should(Equal(time.Minute))
+
+			// Half way through the next window the previous window counts for half
+			// of its 5 requests.
+			clock.Advance(90 * time.Second)
+			res = take()
+			Ω(res.Allowed).Should(BeTrue())
+			Ω(res.Remaining).Should(Equal(0))
+			res = take()
+			Ω(res.Allowed).Should(BeFalse())
+			Ω(res.RetryAfter).Should(Equal(6 * time.Second))
+		})
+	}
+
+	Context("with a Redis counter", func() {
+		var redis *testRedis
+
+		BeforeEach(func() {
+			redis = &testRedis{values: make(map[string]string)}
+			counter = middleware.NewRedisRateLimitCounter(redis)
+		})
+
+		assertSlidingWindow()
+
+		It("stores the counters of a client in the same hash slot", func() {
+			take()
+			for k := range redis.values {
+				Ω(k).Should(HavePrefix("rl:{10.0.0.1}:"))
+			}
+		})
+	})
+
+	Context("with a memcached counter", func() {
+		BeforeEach(func() {
+			counter = middleware.NewMemcacheRateLimitCounter(&testMemcache{values: make(map[string]string)})
+		})
+
+		assertSlidingWindow()
+	})
+})
+
+var _ = Describe("FailoverRateLimitStore", func() {
+	It("falls back to the in-memory store when the primary store fails", func() {
+		redis := &testRedis{values: make(map[string]string), err: errors.New("connection refused")}
+		store := middleware.NewFailoverRateLimitStore(middleware.NewRedisRateLimitStore(redis, "rl:"))
+		limit := middleware.RateLimit{Requests: 1, Interval: time.Minute}
+		res, err := store.Take(context.Background(), "10.0.0.1", limit)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(res.Allowed).Should(BeTrue())
+		res, err = store.Take(context.Background(), "10.0.0.1", limit)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(res.Allowed).Should(BeFalse())
+	})
+})
+
+// testMemcache implements an in-memory MemcacheClient.
+type testMemcache struct {
+	values map[string]string
+}
+
+func (m *testMemcache) Increment(key string, delta uint64) (uint64, error) {
+	v, ok := m.values[key]
+	if !ok {
+		return 0, middleware.ErrMemcacheMiss
+	}
+	n, _ := strconv.ParseUint(v, 10, 64)
+	n += delta
+	m.values[key] = strconv.FormatUint(n, 10)
+	return n, nil
+}
+
+func (m *testMemcache) Add(key string, value []byte, expiration int32) error {
+	if _, ok := m.values[key]; ok {
+		return middleware.ErrMemcacheNotStored
+	}
+	m.values[key] = string(value)
+	return nil
+}
+
+func (m *testMemcache) Get(key string) ([]byte, error) {
+	v, ok := m.values[key]
+	if !ok {
+		return nil, middleware.ErrMemcacheMiss
+	}
+	return []byte(v), nil
+}
+
+var _ = Describe("RateLimitKeyHeader", func() {
+	It("identifies clients by header value", func() {
+		req, err := http.NewRequest("GET", "/", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		key := middleware.RateLimitKeyHeader("X-Api-Key")
+		Ω(key(req)).Should(BeEmpty())
+		req.Header.Set("X-Api-Key", "secret")
+		Ω(key(req)).Should(Equal("X-Api-Key:secret"))
+	})
+})
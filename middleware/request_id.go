@@ -54,7 +54,10 @@ func RequestIDWithHeaderAndLengthLimit(requestIDHeader string, lengthLimit int)
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 			id := req.Header.Get(requestIDHeader)
 			if id == "" {
-				id = fmt.Sprintf("%s-%d", reqPrefix, atomic.AddInt64(&reqID, 1))
+				var ok bool
+				if id, ok = goa.NextSequentialID(); !ok {
+					id = fmt.Sprintf("%s-%d", reqPrefix, atomic.AddInt64(&reqID, 1))
+				}
 			} else if lengthLimit >= 0 && len(id) > lengthLimit {
 				id = id[:lengthLimit]
 			}
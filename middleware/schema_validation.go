@@ -0,0 +1,387 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// JSONSchema is the subset of the JSON hyper-schema produced by the goagen "schema" command
+	// used by the ValidateSchema middleware. The generated schema is served by the API under
+	// "/schema.json" and written to "schema/schema.json".
+	JSONSchema struct {
+		Type                 string                 `json:"type,omitempty"`
+		Items                *JSONSchema            `json:"items,omitempty"`
+		Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+		Definitions          map[string]*JSONSchema `json:"definitions,omitempty"`
+		Links                []*JSONLink            `json:"links,omitempty"`
+		Ref                  string                 `json:"$ref,omitempty"`
+		Enum                 []interface{}          `json:"enum,omitempty"`
+		Format               string                 `json:"format,omitempty"`
+		Pattern              string                 `json:"pattern,omitempty"`
+		Minimum              *float64               `json:"minimum,omitempty"`
+		Maximum              *float64               `json:"maximum,omitempty"`
+		MinLength            *int                   `json:"minLength,omitempty"`
+		MaxLength            *int                   `json:"maxLength,omitempty"`
+		Required             []string               `json:"required,omitempty"`
+		AdditionalProperties bool                   `json:"additionalProperties,omitempty"`
+		AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+		OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	}
+
+	// JSONLink is a link of a JSON hyper-schema resource definition. Each link describes the
+	// request payload and response body of an action route.
+	JSONLink struct {
+		Rel          string      `json:"rel,omitempty"`
+		Href         string      `json:"href,omitempty"`
+		Method       string      `json:"method,omitempty"`
+		Schema       *JSONSchema `json:"schema,omitempty"`
+		TargetSchema *JSONSchema `json:"targetSchema,omitempty"`
+	}
+
+	// schemaRoute is a link whose href is split into path segments.
+	schemaRoute struct {
+		link     *JSONLink
+		segments []string
+	}
+
+	// schemaRecorder wraps an http.ResponseWriter and records the JSON response bodies. The
+	// response is only written to the underlying writer by flush when buffering.
+	schemaRecorder struct {
+		http.ResponseWriter
+		buffer bool
+		status int
+		isJSON bool
+		body   bytes.Buffer
+	}
+)
+
+// validatedFormats lists the formats checked by ValidateSchema, the other formats (e.g. "int64")
+// only document the values.
+var validatedFormats = map[string]bool{
+	string(goa.FormatDateTime): true,
+	string(goa.FormatUUID):     true,
+	goa.FormatEmail:            true,
+	goa.FormatHostname:         true,
+	goa.FormatIPv4:             true,
+	goa.FormatIPv6:             true,
+	goa.FormatIP:               true,
+	goa.FormatURI:              true,
+	goa.FormatMAC:              true,
+	goa.FormatCIDR:             true,
+	goa.FormatRegexp:           true,
+}
+
+// LoadSchema reads the JSON hyper-schema generated by goagen from r.
+func LoadSchema(r io.Reader) (*JSONSchema, error) {
+	var s JSONSchema
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %s", err)
+	}
+	return &s, nil
+}
+
+// ValidateSchema creates a middleware that validates the request payloads and the JSON response
+// bodies against the JSON hyper-schema generated from the design, see LoadSchema. The request
+// method and path select the link of the resource definitions that describes the action, requests
+// that do not match any link are not validated. Only the responses with a status code lower than
+// 400 are validated.
+//
+// The violations are always logged. If strict is true the middleware also rejects the requests
+// whose payload does not match the schema with a 400 response and replaces the responses that do
+// not match the schema with a 500 response. Strict mode buffers the response bodies in memory and
+// is meant to catch handler bugs in development and staging environments, the log-only mode can be
+// used in production to detect drifts between the design and the implementation.
+func ValidateSchema(schema *JSONSchema, strict bool) goa.Middleware {
+	var routes []*schemaRoute
+	for _, def := range schema.Definitions {
+		for _, l := range def.Links {
+			if l.Method == "" || l.Href == "" {
+				continue
+			}
+			routes = append(routes, &schemaRoute{link: l, segments: strings.Split(strings.Trim(l.Href, "/"), "/")})
+		}
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			link := matchSchemaLink(routes, req)
+			if link == nil {
+				return h(ctx, rw, req)
+			}
+			if link.Schema != nil {
+				if err := goa.LoadDeferredPayload(ctx); err != nil {
+					// Let the handler report the invalid payload.
+					return h(ctx, rw, req)
+				}
+				if payload := goa.ContextRequest(ctx).Payload; payload != nil {
+					if errs := validateJSON(schema, link.Schema, payload); len(errs) > 0 {
+						goa.LogError(ctx, "schema violation", "in", "request", "errors", strings.Join(errs, "; "))
+						if strict {
+							return goa.ErrBadRequest(fmt.Sprintf("payload does not match the schema: %s", strings.Join(errs, "; ")))
+						}
+					}
+				}
+			}
+			if link.TargetSchema == nil {
+				return h(ctx, rw, req)
+			}
+			resp := goa.ContextResponse(ctx)
+			rec := &schemaRecorder{ResponseWriter: resp.SwitchWriter(nil), buffer: strict}
+			resp.SwitchWriter(rec)
+			err := h(ctx, rw, req)
+			resp.SwitchWriter(rec.ResponseWriter)
+			if err != nil || !rec.isJSON || rec.status >= 400 || rec.body.Len() == 0 {
+				return rec.flush(err)
+			}
+			var body interface{}
+			errs := []string{"response body is not valid JSON"}
+			if json.Unmarshal(rec.body.Bytes(), &body) == nil {
+				errs = validateJSON(schema, link.TargetSchema, body)
+			}
+			if len(errs) == 0 {
+				return rec.flush(nil)
+			}
+			goa.LogError(ctx, "schema violation", "in", "response", "status", rec.status, "errors", strings.Join(errs, "; "))
+			if !strict {
+				return nil
+			}
+			// Discard the response so that the error handler may write the error.
+			resp.Status = 0
+			resp.Length = 0
+			resp.Header().Del("Content-Type")
+			resp.Header().Del("Content-Length")
+			return goa.ErrInternal(fmt.Sprintf("response does not match the schema: %s", strings.Join(errs, "; ")))
+		}
+	}
+}
+
+// WriteHeader records the response status and whether the response body is JSON.
+func (r *schemaRecorder) WriteHeader(status int) {
+	r.status = status
+	if mt, _, err := mime.ParseMediaType(r.Header().Get("Content-Type")); err == nil {
+		r.isJSON = mt == "application/json" || strings.HasSuffix(mt, "+json")
+	}
+	if !r.buffer {
+		r.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write records the JSON response bodies and writes to the underlying writer if not buffering.
+func (r *schemaRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	if r.isJSON || r.buffer {
+		r.body.Write(b)
+	}
+	if r.buffer {
+		return len(b), nil
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// flush writes the buffered response to the underlying writer and returns err.
+func (r *schemaRecorder) flush(err error) error {
+	if !r.buffer || r.status == 0 {
+		return err
+	}
+	r.ResponseWriter.WriteHeader(r.status)
+	if _, werr := r.ResponseWriter.Write(r.body.Bytes()); werr != nil && err == nil {
+		err = werr
+	}
+	return err
+}
+
+// matchSchemaLink returns the link whose method and href match the request, nil if there is
+// none. Links with more literal segments take precedence over links with parameters.
+func matchSchemaLink(routes []*schemaRoute, req *http.Request) *JSONLink {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	var best *JSONLink
+	bestScore := -1
+	for _, r := range routes {
+		if r.link.Method != req.Method || len(r.segments) != len(segments) {
+			continue
+		}
+		score := 0
+		for i, s := range r.segments {
+			if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+				continue
+			}
+			if s != segments[i] {
+				score = -1
+				break
+			}
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = r.link, score
+		}
+	}
+	return best
+}
+
+// validateJSON validates val against s and returns the violations. Values that are not generic
+// JSON values (e.g. the payload structs produced by the generated unmarshalers) are validated
+// using their JSON representation.
+func validateJSON(root, s *JSONSchema, val interface{}) []string {
+	switch val.(type) {
+	case map[string]interface{}, []interface{}, string, float64, bool:
+	default:
+		js, err := json.Marshal(val)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		val = nil
+		if err := json.Unmarshal(js, &val); err != nil {
+			return []string{err.Error()}
+		}
+	}
+	return root.validate(s, val, "$")
+}
+
+// validate validates val against s, root holds the definitions referred to by s.
+func (root *JSONSchema) validate(s *JSONSchema, val interface{}, path string) []string {
+	if s.Ref != "" {
+		ref := root.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+		if ref == nil {
+			return nil
+		}
+		return root.validate(ref, val, path)
+	}
+	if len(s.AnyOf) > 0 || len(s.OneOf) > 0 {
+		for _, alts := range [][]*JSONSchema{s.AnyOf, s.OneOf} {
+			for _, alt := range alts {
+				if len(root.validate(alt, val, path)) == 0 {
+					return nil
+				}
+			}
+		}
+		return []string{fmt.Sprintf("%s does not match any of the allowed schemas", path)}
+	}
+	if val == nil {
+		return nil
+	}
+	if !hasJSONType(s.Type, val) {
+		return []string{fmt.Sprintf("%s must be of type %s", path, s.Type)}
+	}
+	errs := validateEnum(s, val, path)
+	switch actual := val.(type) {
+	case string:
+		errs = append(errs, validateString(s, actual, path)...)
+	case float64:
+		if s.Minimum != nil && actual < *s.Minimum {
+			errs = append(errs, fmt.Sprintf("%s must be greater or equal than %v", path, *s.Minimum))
+		}
+		if s.Maximum != nil && actual > *s.Maximum {
+			errs = append(errs, fmt.Sprintf("%s must be lesser or equal than %v", path, *s.Maximum))
+		}
+	case []interface{}:
+		errs = append(errs, validateLength(s, len(actual), path)...)
+		if s.Items != nil {
+			for i, e := range actual {
+				errs = append(errs, root.validate(s.Items, e, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		errs = append(errs, root.validateObject(s, actual, path)...)
+	}
+	return errs
+}
+
+// validateEnum checks that val is one of the schema enum values if any.
+func validateEnum(s *JSONSchema, val interface{}, path string) []string {
+	if len(s.Enum) == 0 {
+		return nil
+	}
+	for _, e := range s.Enum {
+		if reflect.DeepEqual(e, val) {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("%s must be one of %v", path, s.Enum)}
+}
+
+// validateString checks a string value against the schema format, pattern and length
+// validations.
+func validateString(s *JSONSchema, val string, path string) []string {
+	var errs []string
+	if validatedFormats[s.Format] {
+		if err := goa.ValidateFormat(goa.Format(s.Format), val); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", path, err))
+		}
+	}
+	if s.Pattern != "" && !goa.ValidatePattern(s.Pattern, val) {
+		errs = append(errs, fmt.Sprintf("%s must match the regexp %#v", path, s.Pattern))
+	}
+	return append(errs, validateLength(s, len(val), path)...)
+}
+
+// validateObject checks that the required properties of an object are present and validates
+// its properties in a deterministic order.
+func (root *JSONSchema) validateObject(s *JSONSchema, val map[string]interface{}, path string) []string {
+	var errs []string
+	for _, r := range s.Required {
+		if _, ok := val[r]; !ok {
+			errs = append(errs, fmt.Sprintf("%s.%s is missing and required", path, r))
+		}
+	}
+	names := make([]string, 0, len(s.Properties))
+	for n := range s.Properties {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if v, ok := val[n]; ok {
+			errs = append(errs, root.validate(s.Properties[n], v, path+"."+n)...)
+		}
+	}
+	return errs
+}
+
+// validateLength checks the length of a string or array against the schema length validations.
+func validateLength(s *JSONSchema, ln int, path string) []string {
+	var errs []string
+	if s.MinLength != nil && ln < *s.MinLength {
+		errs = append(errs, fmt.Sprintf("length of %s must be greater or equal than %d", path, *s.MinLength))
+	}
+	if s.MaxLength != nil && ln > *s.MaxLength {
+		errs = append(errs, fmt.Sprintf("length of %s must be lesser or equal than %d", path, *s.MaxLength))
+	}
+	return errs
+}
+
+// hasJSONType returns true if val is of the JSON type t. Unknown types match any value.
+func hasJSONType(t string, val interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := val.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := val.([]interface{})
+		return ok
+	case "string":
+		_, ok := val.(string)
+		return ok
+	case "boolean":
+		_, ok := val.(bool)
+		return ok
+	case "number":
+		_, ok := val.(float64)
+		return ok
+	case "integer":
+		f, ok := val.(float64)
+		return ok && f == float64(int64(f))
+	}
+	return true
+}
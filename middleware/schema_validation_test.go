@@ -0,0 +1,165 @@
+package middleware_test
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const testSchema = `{
+	"definitions": {
+		"Bottle": {
+			"type": "object",
+			"properties": {
+				"id": {"type": "integer"},
+				"name": {"type": "string", "minLength": 2}
+			},
+			"required": ["id", "name"]
+		},
+		"bottle": {
+			"links": [
+				{"method": "GET", "href": "/bottles/{id}", "targetSchema": {"$ref": "#/definitions/Bottle"}},
+				{"method": "POST", "href": "/bottles", "schema": {"type": "object", "properties": {"name": {"type": "string", "minLength": 2}}}}
+			]
+		}
+	}
+}`
+
+var _ = Describe("ValidateSchema", func() {
+	var schema *middleware.JSONSchema
+	var strict bool
+	var method, path string
+	var payload interface{}
+	var body string
+	var logger *testLogger
+	var rw *testResponseWriter
+	var err error
+
+	BeforeEach(func() {
+		var lerr error
+		schema, lerr = middleware.LoadSchema(strings.NewReader(testSchema))
+		Ω(lerr).ShouldNot(HaveOccurred())
+		strict = false
+		method = "GET"
+		path = "/bottles/1"
+		payload = nil
+		body = `{"id":1,"name":"red"}`
+		logger = new(testLogger)
+	})
+
+	JustBeforeEach(func() {
+		service := newService(logger)
+		req, _ := http.NewRequest(method, path, nil)
+		rw = &testResponseWriter{ParentHeader: make(http.Header)}
+		ctx := goa.NewContext(service.Context, rw, req, nil)
+		goa.ContextRequest(ctx).Payload = payload
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resp := goa.ContextResponse(ctx)
+			resp.Header().Set("Content-Type", "application/json")
+			resp.WriteHeader(200)
+			_, err := resp.Write([]byte(body))
+			return err
+		}
+		err = middleware.ValidateSchema(schema, strict)(h)(ctx, goa.ContextResponse(ctx), req)
+	})
+
+	It("writes valid responses", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Status).Should(Equal(200))
+		Ω(string(rw.Body)).Should(Equal(body))
+		Ω(logger.ErrorEntries).Should(BeEmpty())
+	})
+
+	Context("with an invalid response", func() {
+		BeforeEach(func() {
+			body = `{"id":1.5,"name":"r"}`
+		})
+
+		It("logs the violations", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Status).Should(Equal(200))
+			Ω(string(rw.Body)).Should(Equal(body))
+			Ω(logger.ErrorEntries).Should(HaveLen(1))
+			Ω(logger.ErrorEntries[0].Msg).Should(Equal("schema violation"))
+			Ω(logger.ErrorEntries[0].Data).Should(ContainElement("$.id must be of type integer; length of $.name must be greater or equal than 2"))
+		})
+
+		Context("in strict mode", func() {
+			BeforeEach(func() {
+				strict = true
+			})
+
+			It("discards the response", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(*goa.ErrorResponse).Status).Should(Equal(500))
+				Ω(rw.Status).Should(Equal(0))
+				Ω(rw.Body).Should(BeEmpty())
+			})
+		})
+	})
+
+	Context("with an invalid payload in strict mode", func() {
+		BeforeEach(func() {
+			strict = true
+			method = "POST"
+			path = "/bottles"
+			payload = &struct {
+				Name string `json:"name"`
+			}{"r"}
+		})
+
+		It("rejects the request", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(400))
+			Ω(err.Error()).Should(ContainSubstring("length of $.name must be greater or equal than 2"))
+			Ω(rw.Status).Should(Equal(0))
+		})
+	})
+
+	It("validates deferred payloads", func() {
+		service := newService(logger)
+		service.Use(middleware.ErrorHandler(service, false))
+		service.Use(middleware.ValidateSchema(schema, true))
+		unmarshal := func(ctx context.Context, service *goa.Service, req *http.Request) error {
+			var p interface{}
+			if err := service.DecodeRequest(req, &p); err != nil {
+				return err
+			}
+			goa.ContextRequest(ctx).Payload = p
+			return nil
+		}
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return service.Send(ctx, 201, "ok")
+		}
+		h := service.NewController("test").MuxHandler("create", handler, unmarshal)
+		send := func(body string) *testResponseWriter {
+			req, err := http.NewRequest("POST", "/bottles", strings.NewReader(body))
+			Ω(err).ShouldNot(HaveOccurred())
+			req.Header.Set("Expect", "100-continue")
+			rw := newTestResponseWriter()
+			h(rw, req, nil)
+			return rw
+		}
+		Ω(send(`{"name":"red"}`).Status).Should(Equal(201))
+		Ω(send(`{"name":"r"}`).Status).Should(Equal(400))
+	})
+
+	Context("with a request that does not match the schema links", func() {
+		BeforeEach(func() {
+			path = "/wines/1"
+			body = `{"id":"invalid"}`
+		})
+
+		It("does not validate", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(rw.Body)).Should(Equal(body))
+			Ω(logger.ErrorEntries).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultAllowedHeaders lists the response headers that ScrubHeaders never removes. These are the
+// standard headers written by net/http, goa and the middleware provided by this package.
+var DefaultAllowedHeaders = []string{
+	"Access-Control-Allow-Credentials",
+	"Access-Control-Allow-Headers",
+	"Access-Control-Allow-Methods",
+	"Access-Control-Allow-Origin",
+	"Access-Control-Expose-Headers",
+	"Access-Control-Max-Age",
+	"Cache-Control",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Length",
+	"Content-Type",
+	"Date",
+	"Etag",
+	"Last-Modified",
+	"Location",
+	"Retry-After",
+	"Transfer-Encoding",
+	"Vary",
+	"Www-Authenticate",
+}
+
+// scrubbingResponseWriter wraps an http.ResponseWriter and removes the headers that are not
+// allowed prior to writing the response status.
+type scrubbingResponseWriter struct {
+	http.ResponseWriter
+	allowed     map[string]bool
+	wroteHeader bool
+}
+
+// WriteHeader removes the headers that are not allowed and writes the response status.
+func (srw *scrubbingResponseWriter) WriteHeader(status int) {
+	if !srw.wroteHeader {
+		srw.wroteHeader = true
+		for n := range srw.Header() {
+			if !srw.allowed[http.CanonicalHeaderKey(n)] {
+				srw.Header().Del(n)
+			}
+		}
+	}
+	srw.ResponseWriter.WriteHeader(status)
+}
+
+// Write removes the headers that are not allowed if the response status has not been written yet
+// and writes the data.
+func (srw *scrubbingResponseWriter) Write(buf []byte) (int, error) {
+	if !srw.wroteHeader {
+		srw.WriteHeader(http.StatusOK)
+	}
+	return srw.ResponseWriter.Write(buf)
+}
+
+// ScrubHeaders is a middleware that removes the response headers that are neither listed in
+// allowed nor in DefaultAllowedHeaders before the response is written. This prevents internal
+// headers set by handlers or downstream services from leaking to clients. The generated code
+// applies it to the actions that use the ScrubHeaders DSL with the headers declared in the
+// action responses.
+func ScrubHeaders(allowed ...string) goa.Middleware {
+	names := make(map[string]bool, len(allowed)+len(DefaultAllowedHeaders))
+	for _, n := range DefaultAllowedHeaders {
+		names[http.CanonicalHeaderKey(n)] = true
+	}
+	for _, n := range allowed {
+		names[http.CanonicalHeaderKey(n)] = true
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			resp := goa.ContextResponse(ctx)
+			resp.SwitchWriter(
+				&scrubbingResponseWriter{
+					ResponseWriter: resp.SwitchWriter(nil),
+					allowed:        names,
+				})
+			return h(ctx, rw, req)
+		}
+	}
+}
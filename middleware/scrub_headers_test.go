@@ -0,0 +1,37 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ScrubHeaders", func() {
+	It("removes the headers that are not allowed", func() {
+		service := newService(nil)
+		req, err := http.NewRequest("GET", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw := httptest.NewRecorder()
+		ctx := newContext(service, rw, req, nil)
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.Header().Set("X-Backend", "db-42")
+			rw.Header().Set("X-Rate-Limit", "10")
+			rw.Header().Set("Content-Type", "text/plain")
+			rw.WriteHeader(200)
+			_, err := rw.Write([]byte("ok"))
+			return err
+		}
+		err = middleware.ScrubHeaders("x-rate-limit")(h)(ctx, goa.ContextResponse(ctx), req)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header()).ShouldNot(HaveKey("X-Backend"))
+		Ω(rw.Header().Get("X-Rate-Limit")).Should(Equal("10"))
+		Ω(rw.Header().Get("Content-Type")).Should(Equal("text/plain"))
+	})
+})
@@ -0,0 +1,284 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+type (
+	// Key describes an API key. Only the hash of the secret part of the key is stored, the
+	// complete key is only known to its owner.
+	Key struct {
+		// ID is the unique identifier of the key, it is the first part of the key value.
+		ID string
+		// Name is the name given to the key by its owner.
+		Name string
+		// Owner identifies the subject that created the key, requests authenticated with the
+		// key are made on behalf of the owner, see goa.ContextSubject.
+		Owner string
+		// Scopes lists the scopes granted to the requests authenticated with the key.
+		Scopes []string
+		// Hash is the SHA-256 hash of the secret part of the key.
+		Hash []byte
+		// CreatedAt is the time the key was created.
+		CreatedAt time.Time
+		// ExpiresAt is the time the key expires, nil if it never expires.
+		ExpiresAt *time.Time
+		// RevokedAt is the time the key was revoked, nil if it is still active.
+		RevokedAt *time.Time
+	}
+
+	// KeyStore is the interface implemented by the storage backends of API keys. The same store
+	// backs the middleware that authenticates the requests and the Manager used by the code
+	// generated for the APIKeyManagement DSL.
+	KeyStore interface {
+		// SaveKey creates or updates the given key.
+		SaveKey(ctx context.Context, key *Key) error
+		// GetKey returns the key with the given identifier or nil if there is none.
+		GetKey(ctx context.Context, id string) (*Key, error)
+		// ListKeys returns the keys owned by the given subject.
+		ListKeys(ctx context.Context, owner string) ([]*Key, error)
+	}
+
+	// MemoryKeyStore is a KeyStore that keeps the keys in memory. It is meant for development
+	// and tests: the keys are lost when the process exits.
+	MemoryKeyStore struct {
+		sync.Mutex
+		keys map[string]Key
+	}
+
+	// Manager creates, lists and revokes the API keys of the authenticated subject.
+	Manager struct {
+		store KeyStore
+	}
+)
+
+// ErrAPIKeyFailed is the error returned by the middleware when the request API key is missing or
+// invalid.
+var ErrAPIKeyFailed = goa.NewErrorClass("api_key_failed", 401)
+
+// New returns a middleware to be used with the APIKeySecurity DSL definitions of goa. It
+// authenticates the requests with the keys of store and makes the requests on behalf of the key
+// owner (see goa.ContextSubject). The scopes required by the action are checked against the scopes
+// granted to the key. The ID of the key is stored in the request context (see ContextKeyID) and
+// added to the log context under the "api_key_id" key.
+//
+// Mount the middleware with the generated UseXX function where XX is the name of the scheme as
+// defined in the design, e.g.:
+//
+//	app.UseAPIKeyMiddleware(service, apikey.New(store, app.NewAPIKeySecurity()))
+func New(store KeyStore, scheme *goa.APIKeySecurity) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			val := keyValue(req, scheme)
+			if val == "" {
+				return ErrAPIKeyFailed(fmt.Sprintf("missing API key %q", scheme.Name))
+			}
+			key, err := authenticate(ctx, store, val)
+			if err != nil {
+				return err
+			}
+			if err := goa.CheckScopes(ctx, key.Scopes); err != nil {
+				return err
+			}
+			ctx = goa.WithSubject(WithKey(WithKeyID(ctx, key.ID), key), key.Owner)
+			ctx = goa.WithLogContext(ctx, "api_key_id", key.ID)
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+type contextKey int
+
+const (
+	apiKeyKey contextKey = iota + 1
+	apiKeyIDKey
+)
+
+// WithKey creates a child context containing the given API key.
+func WithKey(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, apiKeyKey, key)
+}
+
+// ContextKey retrieves the API key that authenticated the request from a context that went
+// through the middleware.
+func ContextKey(ctx context.Context) *Key {
+	if k, ok := ctx.Value(apiKeyKey).(*Key); ok {
+		return k
+	}
+	return nil
+}
+
+// WithKeyID creates a child context containing the given API key ID.
+func WithKeyID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, apiKeyIDKey, id)
+}
+
+// ContextKeyID retrieves the ID of the API key that authenticated the request from a context that
+// went through the middleware returned by New or NewWithResolver.
+func ContextKeyID(ctx context.Context) string {
+	if id, ok := ctx.Value(apiKeyIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// NewMemoryKeyStore creates an empty in-memory key store.
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{keys: make(map[string]Key)}
+}
+
+// SaveKey creates or updates the given key.
+func (s *MemoryKeyStore) SaveKey(ctx context.Context, key *Key) error {
+	s.Lock()
+	defer s.Unlock()
+	s.keys[key.ID] = *key
+	return nil
+}
+
+// GetKey returns a copy of the key with the given identifier or nil if there is none.
+func (s *MemoryKeyStore) GetKey(ctx context.Context, id string) (*Key, error) {
+	s.Lock()
+	defer s.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+// ListKeys returns copies of the keys owned by the given subject sorted by creation time.
+func (s *MemoryKeyStore) ListKeys(ctx context.Context, owner string) ([]*Key, error) {
+	s.Lock()
+	defer s.Unlock()
+	var keys []*Key
+	for _, k := range s.keys {
+		if k.Owner == owner {
+			key := k
+			keys = append(keys, &key)
+		}
+	}
+	sort.Sort(byCreation(keys))
+	return keys, nil
+}
+
+// NewManager creates a manager that stores the keys in store.
+func NewManager(store KeyStore) *Manager {
+	return &Manager{store: store}
+}
+
+// Create creates a key owned by the subject of the context. It returns the key and its value,
+// the value cannot be retrieved later and must be displayed to the owner once. The key never
+// expires if ttl is zero. Create returns goa.ErrUnauthorized if the context does not define a
+// subject.
+func (m *Manager) Create(ctx context.Context, name string, scopes []string, ttl time.Duration) (*Key, string, error) {
+	subject := goa.ContextSubject(ctx)
+	if subject == "" {
+		return nil, "", goa.ErrUnauthorized("API keys must be created by an authenticated subject")
+	}
+	secret := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return nil, "", err
+	}
+	id := make([]byte, 12)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, "", err
+	}
+	value := base64.RawURLEncoding.EncodeToString(secret)
+	hash := sha256.Sum256([]byte(value))
+	key := &Key{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		Owner:     subject,
+		Scopes:    scopes,
+		Hash:      hash[:],
+		CreatedAt: goa.ContextClock(ctx).Now(),
+	}
+	if ttl > 0 {
+		exp := key.CreatedAt.Add(ttl)
+		key.ExpiresAt = &exp
+	}
+	if err := m.store.SaveKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, key.ID + "." + value, nil
+}
+
+// List returns the keys owned by the subject of the context, including the revoked and expired
+// keys.
+func (m *Manager) List(ctx context.Context) ([]*Key, error) {
+	subject := goa.ContextSubject(ctx)
+	if subject == "" {
+		return nil, goa.ErrUnauthorized("API keys must be listed by an authenticated subject")
+	}
+	return m.store.ListKeys(ctx, subject)
+}
+
+// Revoke revokes the key with the given identifier, the key stops authenticating requests
+// immediately. It returns goa.ErrNotFound if there is no such key or if it is owned by a subject
+// other than the subject of the context. Revoking a revoked key has no effect.
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	subject := goa.ContextSubject(ctx)
+	if subject == "" {
+		return goa.ErrUnauthorized("API keys must be revoked by an authenticated subject")
+	}
+	key, err := m.store.GetKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key == nil || key.Owner != subject {
+		return goa.ErrNotFound(fmt.Sprintf("no API key with id %#v", id))
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	now := goa.ContextClock(ctx).Now()
+	key.RevokedAt = &now
+	return m.store.SaveKey(ctx, key)
+}
+
+// authenticate returns the active key matching the given value.
+func authenticate(ctx context.Context, store KeyStore, val string) (*Key, error) {
+	parts := strings.SplitN(val, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrAPIKeyFailed("invalid API key")
+	}
+	key, err := store.GetKey(ctx, parts[0])
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrAPIKeyFailed("invalid API key")
+	}
+	hash := sha256.Sum256([]byte(parts[1]))
+	if subtle.ConstantTimeCompare(hash[:], key.Hash) != 1 {
+		return nil, ErrAPIKeyFailed("invalid API key")
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyFailed("revoked API key")
+	}
+	if key.ExpiresAt != nil && !goa.ContextClock(ctx).Now().Before(*key.ExpiresAt) {
+		return nil, ErrAPIKeyFailed("expired API key")
+	}
+	return key, nil
+}
+
+// byCreation sorts keys by creation time.
+type byCreation []*Key
+
+func (b byCreation) Len() int           { return len(b) }
+func (b byCreation) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byCreation) Less(i, j int) bool { return b[i].CreatedAt.Before(b[j].CreatedAt) }
@@ -0,0 +1,231 @@
+package apikey_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
+	"github.com/goadesign/goa/middleware/security/apikey"
+	"github.com/goadesign/goa/secrets"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Middleware", func() {
+	var (
+		store   *apikey.MemoryKeyStore
+		manager *apikey.Manager
+		clock   *goatest.Clock
+		ctx     context.Context
+		value   string
+		key     *apikey.Key
+		scheme  *goa.APIKeySecurity
+		request *http.Request
+		subject string
+		fetched *apikey.Key
+		keyID   string
+		err     error
+	)
+
+	BeforeEach(func() {
+		store = apikey.NewMemoryKeyStore()
+		manager = apikey.NewManager(store)
+		clock = goatest.NewClock(time.Unix(1500000000, 0))
+		ctx = goa.WithClock(goa.WithSubject(context.Background(), "user-1"), clock)
+		var cerr error
+		key, value, cerr = manager.Create(ctx, "ci", []string{"api:read"}, time.Hour)
+		Ω(cerr).ShouldNot(HaveOccurred())
+		scheme = &goa.APIKeySecurity{In: goa.LocHeader, Name: "X-API-Key"}
+		request, _ = http.NewRequest("GET", "http://example.com/", nil)
+		request.Header.Set("X-API-Key", value)
+		subject = ""
+		fetched = nil
+	})
+
+	JustBeforeEach(func() {
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			subject = goa.ContextSubject(ctx)
+			fetched = apikey.ContextKey(ctx)
+			keyID = apikey.ContextKeyID(ctx)
+			return nil
+		}
+		reqCtx := goa.WithClock(context.Background(), clock)
+		err = apikey.New(store, scheme)(handler)(reqCtx, httptest.NewRecorder(), request)
+	})
+
+	It("authenticates the request on behalf of the key owner", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(subject).Should(Equal("user-1"))
+		Ω(fetched.ID).Should(Equal(key.ID))
+		Ω(keyID).Should(Equal(key.ID))
+	})
+
+	Context("with the key in the query string", func() {
+		BeforeEach(func() {
+			scheme = &goa.APIKeySecurity{In: goa.LocQuery, Name: "api_key"}
+			request, _ = http.NewRequest("GET", "http://example.com/?api_key="+value, nil)
+		})
+
+		It("authenticates the request", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(subject).Should(Equal("user-1"))
+		})
+	})
+
+	Context("with an invalid key", func() {
+		BeforeEach(func() {
+			request.Header.Set("X-API-Key", key.ID+".invalid")
+		})
+
+		It("fails with a 401 error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(401))
+			Ω(subject).Should(BeEmpty())
+		})
+	})
+
+	Context("with a revoked key", func() {
+		BeforeEach(func() {
+			Ω(manager.Revoke(ctx, key.ID)).Should(Succeed())
+		})
+
+		It("fails with a 401 error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(401))
+		})
+	})
+
+	Context("with an expired key", func() {
+		BeforeEach(func() {
+			clock.Advance(time.Hour)
+		})
+
+		It("fails with a 401 error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(401))
+		})
+	})
+})
+
+var _ = Describe("Manager", func() {
+	var (
+		manager *apikey.Manager
+		ctx     context.Context
+	)
+
+	BeforeEach(func() {
+		manager = apikey.NewManager(apikey.NewMemoryKeyStore())
+		ctx = goa.WithSubject(context.Background(), "user-1")
+	})
+
+	It("lists the keys of the subject without their value", func() {
+		key, value, err := manager.Create(ctx, "ci", nil, 0)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(value).Should(HavePrefix(key.ID + "."))
+		Ω(key.ExpiresAt).Should(BeNil())
+		_, _, err = manager.Create(goa.WithSubject(context.Background(), "user-2"), "other", nil, 0)
+		Ω(err).ShouldNot(HaveOccurred())
+		keys, err := manager.List(ctx)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(keys).Should(HaveLen(1))
+		Ω(keys[0].Name).Should(Equal("ci"))
+		Ω(string(keys[0].Hash)).ShouldNot(ContainSubstring(value))
+	})
+
+	It("does not revoke the keys of other subjects", func() {
+		key, _, err := manager.Create(ctx, "ci", nil, 0)
+		Ω(err).ShouldNot(HaveOccurred())
+		err = manager.Revoke(goa.WithSubject(context.Background(), "user-2"), key.ID)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(*goa.ErrorResponse).Status).Should(Equal(404))
+	})
+
+	It("requires an authenticated subject", func() {
+		_, _, err := manager.Create(context.Background(), "ci", nil, 0)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(*goa.ErrorResponse).Status).Should(Equal(401))
+	})
+})
+
+var _ = Describe("NewWithResolver", func() {
+	var (
+		resolver apikey.KeyResolver
+		scheme   *goa.APIKeySecurity
+		request  *http.Request
+		keyID    string
+		err      error
+	)
+
+	BeforeEach(func() {
+		resolver = apikey.Keys("primary-key", "secondary-key")
+		scheme = &goa.APIKeySecurity{In: goa.LocHeader, Name: "X-API-Key"}
+		request, _ = http.NewRequest("GET", "http://example.com/", nil)
+		keyID = ""
+	})
+
+	JustBeforeEach(func() {
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			keyID = apikey.ContextKeyID(ctx)
+			return nil
+		}
+		err = apikey.NewWithResolver(resolver, scheme)(handler)(context.Background(), httptest.NewRecorder(), request)
+	})
+
+	Context("with the primary key", func() {
+		BeforeEach(func() {
+			request.Header.Set("X-API-Key", "primary-key")
+		})
+
+		It("authenticates the request with the primary key ID", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(keyID).Should(Equal(apikey.KeyID("primary-key")))
+		})
+	})
+
+	Context("with the secondary key", func() {
+		BeforeEach(func() {
+			request.Header.Set("X-API-Key", "secondary-key")
+		})
+
+		It("authenticates the request with the secondary key ID", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(keyID).Should(Equal(apikey.KeyID("secondary-key")))
+		})
+	})
+
+	Context("with a retired key", func() {
+		BeforeEach(func() {
+			request.Header.Set("X-API-Key", "retired-key")
+		})
+
+		It("fails with a 401 error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(401))
+			Ω(keyID).Should(BeEmpty())
+		})
+	})
+
+	Context("with keys resolved from a rotated secret", func() {
+		BeforeEach(func() {
+			value := "first-key"
+			provider := secrets.ProviderFunc(func(ctx context.Context, name string) ([]byte, error) {
+				return []byte(value), nil
+			})
+			secret, serr := secrets.Load(context.Background(), provider, "api-key")
+			Ω(serr).ShouldNot(HaveOccurred())
+			value = "second-key"
+			_, serr = secret.Refresh(context.Background())
+			Ω(serr).ShouldNot(HaveOccurred())
+			resolver = apikey.SecretKeys(secret)
+			request.Header.Set("X-API-Key", "first-key")
+		})
+
+		It("accepts the previous value", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(keyID).Should(Equal(apikey.KeyID("first-key")))
+		})
+	})
+})
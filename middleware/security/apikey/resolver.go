@@ -0,0 +1,136 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/secrets"
+	"golang.org/x/net/context"
+)
+
+type (
+	// ResolvedKey is an API key accepted by the middleware returned by NewWithResolver.
+	ResolvedKey struct {
+		// ID identifies the key in the request context and in the logs, it must not reveal
+		// the key value. See KeyID.
+		ID string
+		// Value is the key value sent by the clients.
+		Value string
+	}
+
+	// KeyResolver is the interface implemented by the sources of the API keys accepted by the
+	// middleware returned by NewWithResolver. Returning several keys makes it possible to
+	// rotate keys without downtime: the new key is added as primary key, the clients are
+	// updated while the previous key remains valid as secondary key, then the previous key is
+	// removed.
+	KeyResolver interface {
+		// ResolveKeys returns the keys that are currently valid.
+		ResolveKeys(ctx context.Context) ([]*ResolvedKey, error)
+	}
+
+	// KeyResolverFunc is a function that implements KeyResolver.
+	KeyResolverFunc func(ctx context.Context) ([]*ResolvedKey, error)
+
+	// staticKeys resolves a fixed set of keys.
+	staticKeys []*ResolvedKey
+
+	// secretKeys resolves the current and previous values of a secret.
+	secretKeys struct {
+		secret *secrets.Secret
+	}
+)
+
+// ResolveKeys calls f.
+func (f KeyResolverFunc) ResolveKeys(ctx context.Context) ([]*ResolvedKey, error) {
+	return f(ctx)
+}
+
+// Keys returns a resolver of the given primary and secondary key values. The key IDs are computed
+// with KeyID so that a key keeps its ID when it goes from primary to secondary.
+func Keys(primary string, secondary ...string) KeyResolver {
+	keys := staticKeys{{ID: KeyID(primary), Value: primary}}
+	for _, s := range secondary {
+		keys = append(keys, &ResolvedKey{ID: KeyID(s), Value: s})
+	}
+	return keys
+}
+
+// SecretKeys returns a resolver of the current and previous values of the given secret: rotating
+// the secret keeps the previous key valid until the next rotation. See the secrets package.
+func SecretKeys(secret *secrets.Secret) KeyResolver {
+	return secretKeys{secret: secret}
+}
+
+// KeyID returns an identifier derived from a key value that can be logged safely: the
+// hexadecimal encoding of the first 6 bytes of the SHA-256 hash of the value.
+func KeyID(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:6])
+}
+
+// NewWithResolver returns a middleware to be used with the APIKeySecurity DSL definitions of goa
+// that authenticates the requests with the keys returned by resolver. The ID of the key that
+// authenticated the request is stored in the request context (see ContextKeyID) and added to the
+// log context under the "api_key_id" key for auditing.
+//
+// The code generated for each APIKeySecurity scheme includes a NewXXMiddleware function that
+// creates the middleware, e.g.:
+//
+//	app.UseAPIKeyMiddleware(service, app.NewAPIKeyMiddleware(apikey.Keys(primary, secondary)))
+func NewWithResolver(resolver KeyResolver, scheme *goa.APIKeySecurity) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			val := keyValue(req, scheme)
+			if val == "" {
+				return ErrAPIKeyFailed(fmt.Sprintf("missing API key %q", scheme.Name))
+			}
+			keys, err := resolver.ResolveKeys(ctx)
+			if err != nil {
+				return goa.ErrInternal(err)
+			}
+			sum := sha256.Sum256([]byte(val))
+			var id string
+			for _, k := range keys {
+				if k.Value == "" {
+					continue
+				}
+				ksum := sha256.Sum256([]byte(k.Value))
+				if subtle.ConstantTimeCompare(sum[:], ksum[:]) == 1 && id == "" {
+					id = k.ID
+				}
+			}
+			if id == "" {
+				return ErrAPIKeyFailed("invalid API key")
+			}
+			ctx = goa.WithLogContext(WithKeyID(ctx, id), "api_key_id", id)
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// ResolveKeys returns the keys.
+func (s staticKeys) ResolveKeys(ctx context.Context) ([]*ResolvedKey, error) {
+	return s, nil
+}
+
+// ResolveKeys returns the current and previous values of the secret.
+func (s secretKeys) ResolveKeys(ctx context.Context) ([]*ResolvedKey, error) {
+	current := string(s.secret.Value())
+	keys := []*ResolvedKey{{ID: KeyID(current), Value: current}}
+	if prev := s.secret.Previous(); len(prev) > 0 {
+		keys = append(keys, &ResolvedKey{ID: KeyID(string(prev)), Value: string(prev)})
+	}
+	return keys, nil
+}
+
+// keyValue returns the API key sent with the request.
+func keyValue(req *http.Request, scheme *goa.APIKeySecurity) string {
+	if scheme.In == goa.LocQuery {
+		return req.URL.Query().Get(scheme.Name)
+	}
+	return req.Header.Get(scheme.Name)
+}
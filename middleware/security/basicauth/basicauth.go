@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware/security/lockout"
 	"golang.org/x/net/context"
 )
 
@@ -29,3 +30,21 @@ func New(username, password string) goa.Middleware {
 	})
 	return middleware
 }
+
+// NewWithLockout creates a static username/password auth middleware protected by the given
+// throttler: usernames and client addresses that fail to authenticate too many times are locked
+// out, see the lockout package.
+//
+// Example:
+//    throttler := lockout.New(lockout.NewMemoryStore(), lockout.Policy{MaxFailures: 5})
+//    app.UseBasicAuth(basicauth.NewWithLockout("admin", "password", throttler))
+func NewWithLockout(username, password string, t *lockout.Throttler) goa.Middleware {
+	return lockout.Protect(t, Username)(New(username, password))
+}
+
+// Username returns the username of the request basic auth credentials, "" if there are none. It
+// identifies the principal of the requests for the lockout package.
+func Username(r *http.Request) string {
+	u, _, _ := r.BasicAuth()
+	return u
+}
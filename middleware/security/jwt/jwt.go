@@ -9,6 +9,7 @@ import (
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/secrets"
 	"golang.org/x/net/context"
 )
 
@@ -20,7 +21,9 @@ import (
 //     1. Validate the "Bearer" token present in the "Authorization" header against the key(s)
 //        given to New
 //     2. If scopes are defined in the design for the action validate them against the "scopes" JWT
-//        claim
+//        claim or the claim named by the ScopesClaim field of the scheme. Requests whose token
+//        does not grant all the required scopes fail with a 403 error of class
+//        goa.ErrMissingScopes that lists the missing scopes in its metadata.
 //
 // The `exp` (expiration) and `nbf` (not before) date checks are validated by the JWT library.
 //
@@ -32,10 +35,12 @@ import (
 //     * a list of []byte
 //     * a single rsa.PublicKey
 //     * a list of rsa.PublicKey
+//     * a *secrets.Secret
 //
 // The type of the keys determine the algorithms that will be used to do the check.  The goal of
 // having lists of keys is to allow for key rotation, still check the previous keys until rotation
-// has been completed.
+// has been completed. A *secrets.Secret holds a HMAC key retrieved from a secret store, tokens are
+// checked against its current value and the value it had before the last rotation.
 //
 // You can define an optional function to do additional validations on the token once the signature
 // and the claims requirements are proven to be valid.  Example:
@@ -55,7 +60,7 @@ import (
 func New(validationKeys interface{}, validationFunc goa.Middleware, scheme *goa.JWTSecurity) goa.Middleware {
 	var algo string
 	var rsaKeys []*rsa.PublicKey
-	var hmacKeys []string
+	var hmacKeys func() []string
 
 	switch keys := validationKeys.(type) {
 	case []*rsa.PublicKey:
@@ -65,13 +70,22 @@ func New(validationKeys interface{}, validationFunc goa.Middleware, scheme *goa.
 		rsaKeys = []*rsa.PublicKey{keys}
 		algo = "RS"
 	case string:
-		hmacKeys = []string{keys}
+		hmacKeys = func() []string { return []string{keys} }
 		algo = "HS"
 	case []string:
-		hmacKeys = keys
+		hmacKeys = func() []string { return keys }
+		algo = "HS"
+	case *secrets.Secret:
+		hmacKeys = func() []string {
+			hkeys := []string{string(keys.Value())}
+			if prev := keys.Previous(); len(prev) > 0 {
+				hkeys = append(hkeys, string(prev))
+			}
+			return hkeys
+		}
 		algo = "HS"
 	default:
-		panic("invalid parameter to `jwt.New()`, only accepts *rsa.publicKey, []*rsa.PublicKey (for RSA-based algorithms) or a signing secret string (for HS algorithms)")
+		panic("invalid parameter to `jwt.New()`, only accepts *rsa.publicKey, []*rsa.PublicKey (for RSA-based algorithms), a signing secret string or a *secrets.Secret (for HS algorithms)")
 	}
 
 	return func(nextHandler goa.Handler) goa.Handler {
@@ -97,7 +111,7 @@ func New(validationKeys interface{}, validationFunc goa.Middleware, scheme *goa.
 			case "RS":
 				token, err = validateRSAKeys(rsaKeys, algo, incomingToken)
 			case "HS":
-				token, err = validateHMACKeys(hmacKeys, algo, incomingToken)
+				token, err = validateHMACKeys(hmacKeys(), algo, incomingToken)
 			default:
 				panic("how did this happen ? unsupported algo in jwt middleware")
 			}
@@ -105,22 +119,25 @@ func New(validationKeys interface{}, validationFunc goa.Middleware, scheme *goa.
 				return ErrJWTError(fmt.Sprintf("JWT validation failed: %s", err))
 			}
 
-			scopesInClaim, scopesInClaimList, err := parseClaimScopes(token)
+			claim := scheme.ScopesClaim
+			if claim == "" {
+				claim = goa.DefaultScopesClaim
+			}
+			scopes, err := parseClaimScopes(token, claim)
 			if err != nil {
 				goa.LogError(ctx, err.Error())
 				return ErrJWTError(err)
 			}
-
-			requiredScopes := goa.ContextRequiredScopes(ctx)
-
-			for _, scope := range requiredScopes {
-				if !scopesInClaim[scope] {
-					msg := "authorization failed: required 'scopes' not present in JWT claim"
-					return ErrJWTError(msg, "required", requiredScopes, "scopes", scopesInClaimList)
-				}
+			if err := goa.CheckScopes(ctx, scopes); err != nil {
+				return err
 			}
 
 			ctx = WithJWT(ctx, token)
+			if claims, ok := token.Claims.(jwt.MapClaims); ok {
+				if sub, ok := claims["sub"].(string); ok && sub != "" {
+					ctx = goa.WithSubject(ctx, sub)
+				}
+			}
 			if validationFunc != nil {
 				nextHandler = validationFunc(nextHandler)
 			}
@@ -129,38 +146,34 @@ func New(validationKeys interface{}, validationFunc goa.Middleware, scheme *goa.
 	}
 }
 
-// parseClaimScopes parses the "scopes" parameter in the Claims. It supports two formats:
+// parseClaimScopes parses the scopes listed in the claim with the given name. It supports two
+// formats:
 //
 // * a list of string
 //
 // * a single string with space-separated scopes (akin to OAuth2's "scope").
-func parseClaimScopes(token *jwt.Token) (map[string]bool, []string, error) {
-	scopesInClaim := make(map[string]bool)
+func parseClaimScopes(token *jwt.Token, claim string) ([]string, error) {
 	var scopesInClaimList []string
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, nil, fmt.Errorf("unsupport claims shape")
+		return nil, fmt.Errorf("unsupport claims shape")
 	}
-	if claims["scopes"] != nil {
-		switch scopes := claims["scopes"].(type) {
+	if claims[claim] != nil {
+		switch scopes := claims[claim].(type) {
 		case string:
-			for _, scope := range strings.Split(scopes, " ") {
-				scopesInClaim[scope] = true
-				scopesInClaimList = append(scopesInClaimList, scope)
-			}
+			scopesInClaimList = strings.Fields(scopes)
 		case []interface{}:
 			for _, scope := range scopes {
 				if val, ok := scope.(string); ok {
-					scopesInClaim[val] = true
 					scopesInClaimList = append(scopesInClaimList, val)
 				}
 			}
 		default:
-			return nil, nil, fmt.Errorf("unsupported 'scopes' format in incoming JWT claim, was type %T", scopes)
+			return nil, fmt.Errorf("unsupported %q format in incoming JWT claim, was type %T", claim, scopes)
 		}
 	}
 	sort.Strings(scopesInClaimList)
-	return scopesInClaim, scopesInClaimList, nil
+	return scopesInClaimList, nil
 }
 
 // ErrJWTError is the error returned by this middleware when any sort of validation or assertion
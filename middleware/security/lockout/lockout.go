@@ -0,0 +1,336 @@
+/*
+Package lockout protects the authentication middlewares against password guessing and credential
+stuffing. It tracks the authentication failures per principal (e.g. username or API key
+identifier) and per client IP address and locks the principal or the address out for a duration
+that doubles with each failure past the allowed number.
+
+	throttler := lockout.New(lockout.NewMemoryStore(), lockout.Policy{MaxFailures: 5})
+	app.UseBasicAuthMiddleware(service, basicauth.NewWithLockout("admin", "password", throttler))
+
+Any authentication middleware can be protected with Protect, see the basicauth package for an
+example.
+*/
+package lockout
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+type (
+	// Policy describes how many authentication failures are allowed and for how long principals
+	// and client addresses are locked out once they exceed them.
+	Policy struct {
+		// MaxFailures is the number of consecutive failures allowed per principal before it
+		// is locked out, defaults to 5.
+		MaxFailures int
+		// IPMaxFailures is the number of failures allowed per client IP address before it is
+		// throttled, defaults to 50. It is higher than MaxFailures as many clients may share
+		// an address.
+		IPMaxFailures int
+		// Lockout is the duration of the first lockout, defaults to one minute. Each failure
+		// past the allowed number doubles the lockout duration.
+		Lockout time.Duration
+		// MaxLockout is the maximum lockout duration, defaults to one hour.
+		MaxLockout time.Duration
+		// Window is the duration the failures are remembered for after the last failure or
+		// lockout, defaults to 15 minutes.
+		Window time.Duration
+	}
+
+	// Failures records the authentication failures of a principal or client address.
+	Failures struct {
+		// Count is the number of failures.
+		Count int
+		// Last is the time of the last failure.
+		Last time.Time
+		// LockedUntil is the time the lockout ends, zero if there is no lockout.
+		LockedUntil time.Time
+		// Expires is the time after which the failures are forgotten, stores may delete
+		// the record then.
+		Expires time.Time
+	}
+
+	// Store is the interface implemented by the stores used by Throttler to keep track of the
+	// authentication failures. Implementations must be safe for concurrent use.
+	Store interface {
+		// Get returns the failures recorded for key, nil if there are none.
+		Get(ctx context.Context, key string) (*Failures, error)
+		// Update atomically replaces the failures recorded for key with the value returned by
+		// f given the current value (nil if there is none). A nil value deletes the record.
+		// Update returns the new value.
+		Update(ctx context.Context, key string, f func(*Failures) *Failures) (*Failures, error)
+	}
+
+	// PrincipalFunc returns the principal a request authenticates, e.g. the username of the
+	// basic auth credentials, or "" if the request does not carry credentials.
+	PrincipalFunc func(*http.Request) string
+
+	// Throttler checks and records the authentication failures of principals and client
+	// addresses.
+	Throttler struct {
+		// Store keeps track of the failures.
+		Store Store
+		// Policy is the lockout policy.
+		Policy Policy
+		// IP returns the address of the client making the request, defaults to the host of
+		// the request RemoteAddr. Override it when the service runs behind a proxy.
+		IP func(*http.Request) string
+	}
+
+	// MemoryStore is a Store that keeps the failures in memory. It is only suitable for
+	// services that run a single instance.
+	MemoryStore struct {
+		mu        sync.Mutex
+		failures  map[string]*Failures
+		lastSweep time.Time
+	}
+)
+
+var (
+	// ErrAccountLocked is the class of errors returned when the principal authenticated by a
+	// request is locked out.
+	ErrAccountLocked = goa.NewErrorClass("account_locked", 423)
+
+	// ErrTooManyFailures is the class of errors returned when the client address made too many
+	// failed authentication attempts.
+	ErrTooManyFailures = goa.NewErrorClass("too_many_auth_failures", 429)
+)
+
+// New creates a throttler that records the failures in store.
+func New(store Store, policy Policy) *Throttler {
+	return &Throttler{Store: store, Policy: policy}
+}
+
+// Protect returns a function that wraps an authentication middleware so that requests made by
+// locked out principals or client addresses are rejected before they are authenticated. A
+// request fails authentication when the middleware returns an error with a 401 status without
+// calling the next handler, the failure is recorded for the principal and the client address. A
+// successful authentication resets the failures of the principal but not those of the address
+// so that clients cannot reset the count with their own credentials.
+func Protect(t *Throttler, principal PrincipalFunc) func(goa.Middleware) goa.Middleware {
+	return func(m goa.Middleware) goa.Middleware {
+		return func(h goa.Handler) goa.Handler {
+			return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				p, ip := principal(req), t.clientIP(req)
+				if err := t.Check(ctx, rw, p, ip); err != nil {
+					return err
+				}
+				authenticated := false
+				next := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					authenticated = true
+					if err := t.Succeed(ctx, p); err != nil {
+						goa.LogError(ctx, "lockout", "err", err)
+					}
+					return h(ctx, rw, req)
+				}
+				err := m(next)(ctx, rw, req)
+				if err != nil && !authenticated && isAuthFailure(err) {
+					if ferr := t.Fail(ctx, p, ip); ferr != nil {
+						goa.LogError(ctx, "lockout", "err", ferr)
+					}
+				}
+				return err
+			}
+		}
+	}
+}
+
+// Check returns ErrAccountLocked if the principal is locked out or ErrTooManyFailures if the
+// client address is and sets the Retry-After response header. An empty principal or address
+// is not checked.
+func (t *Throttler) Check(ctx context.Context, rw http.ResponseWriter, principal, ip string) error {
+	now := goa.ContextClock(ctx).Now()
+	if principal != "" {
+		f, err := t.Store.Get(ctx, principalKey(principal))
+		if err != nil {
+			return goa.ErrInternal(err)
+		}
+		if locked(f, now) {
+			rw.Header().Set("Retry-After", strconv.Itoa(seconds(f.LockedUntil.Sub(now))))
+			return ErrAccountLocked("too many failed authentication attempts, try again later")
+		}
+	}
+	if ip != "" {
+		f, err := t.Store.Get(ctx, ipKey(ip))
+		if err != nil {
+			return goa.ErrInternal(err)
+		}
+		if locked(f, now) {
+			rw.Header().Set("Retry-After", strconv.Itoa(seconds(f.LockedUntil.Sub(now))))
+			return ErrTooManyFailures("too many failed authentication attempts, try again later")
+		}
+	}
+	return nil
+}
+
+// Fail records an authentication failure for the principal and the client address, empty
+// values are ignored.
+func (t *Throttler) Fail(ctx context.Context, principal, ip string) error {
+	now := goa.ContextClock(ctx).Now()
+	policy := t.Policy.withDefaults()
+	if principal != "" {
+		if _, err := t.Store.Update(ctx, principalKey(principal), func(f *Failures) *Failures {
+			return policy.Fail(f, policy.MaxFailures, now)
+		}); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		if _, err := t.Store.Update(ctx, ipKey(ip), func(f *Failures) *Failures {
+			return policy.Fail(f, policy.IPMaxFailures, now)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed resets the failures recorded for the principal.
+func (t *Throttler) Succeed(ctx context.Context, principal string) error {
+	if principal == "" {
+		return nil
+	}
+	_, err := t.Store.Update(ctx, principalKey(principal), func(*Failures) *Failures { return nil })
+	return err
+}
+
+// Fail returns the failures that follow f (nil if there are none) after a new failure at time
+// now given the number of failures allowed before a lockout. Throttler uses it to compute the
+// failures it stores.
+func (p Policy) Fail(f *Failures, max int, now time.Time) *Failures {
+	p = p.withDefaults()
+	next := &Failures{}
+	if f != nil && now.Before(f.Expires) {
+		*next = *f
+	}
+	next.Count++
+	next.Last = now
+	if next.Count >= max {
+		lockout := p.Lockout
+		for i := max; i < next.Count && lockout < p.MaxLockout; i++ {
+			lockout *= 2
+		}
+		if lockout > p.MaxLockout {
+			lockout = p.MaxLockout
+		}
+		next.LockedUntil = now.Add(lockout)
+	}
+	next.Expires = next.Last
+	if next.LockedUntil.After(next.Expires) {
+		next.Expires = next.LockedUntil
+	}
+	next.Expires = next.Expires.Add(p.Window)
+	return next
+}
+
+// withDefaults returns a copy of the policy with the default values set.
+func (p Policy) withDefaults() Policy {
+	if p.MaxFailures <= 0 {
+		p.MaxFailures = 5
+	}
+	if p.IPMaxFailures <= 0 {
+		p.IPMaxFailures = 50
+	}
+	if p.Lockout <= 0 {
+		p.Lockout = time.Minute
+	}
+	if p.MaxLockout <= 0 {
+		p.MaxLockout = time.Hour
+	}
+	if p.Window <= 0 {
+		p.Window = 15 * time.Minute
+	}
+	return p
+}
+
+// NewMemoryStore creates a store that keeps the failures in memory.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{failures: make(map[string]*Failures)}
+}
+
+// Get returns a copy of the failures recorded for key.
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Failures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.failures[key]
+	if !ok {
+		return nil, nil
+	}
+	c := *f
+	return &c, nil
+}
+
+// Update replaces the failures recorded for key with the value returned by f.
+func (s *MemoryStore) Update(ctx context.Context, key string, f func(*Failures) *Failures) (*Failures, error) {
+	now := goa.ContextClock(ctx).Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, v := range s.failures {
+			if !now.Before(v.Expires) {
+				delete(s.failures, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	var current *Failures
+	if v, ok := s.failures[key]; ok {
+		c := *v
+		current = &c
+	}
+	next := f(current)
+	if next == nil {
+		delete(s.failures, key)
+		return nil, nil
+	}
+	c := *next
+	s.failures[key] = &c
+	return next, nil
+}
+
+// clientIP returns the address of the client making the request.
+func (t *Throttler) clientIP(req *http.Request) string {
+	if t.IP != nil {
+		return t.IP(req)
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// locked returns true if f describes an ongoing lockout.
+func locked(f *Failures, now time.Time) bool {
+	return f != nil && now.Before(f.LockedUntil)
+}
+
+// isAuthFailure returns true if err is an authentication failure.
+func isAuthFailure(err error) bool {
+	if s, ok := err.(goa.ServiceError); ok {
+		return s.ResponseStatus() == http.StatusUnauthorized
+	}
+	return false
+}
+
+// principalKey returns the store key of a principal.
+func principalKey(principal string) string {
+	return "principal:" + principal
+}
+
+// ipKey returns the store key of a client address.
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// seconds returns the number of seconds in d rounded up.
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
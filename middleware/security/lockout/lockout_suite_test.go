@@ -0,0 +1,13 @@
+package lockout_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLockout(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lockout Middleware")
+}
@@ -0,0 +1,143 @@
+package lockout_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
+	"github.com/goadesign/goa/middleware/security/basicauth"
+	"github.com/goadesign/goa/middleware/security/lockout"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Protect", func() {
+	var (
+		throttler *lockout.Throttler
+		clock     *goatest.Clock
+		called    bool
+	)
+
+	BeforeEach(func() {
+		throttler = lockout.New(lockout.NewMemoryStore(), lockout.Policy{MaxFailures: 3, IPMaxFailures: 5})
+		clock = goatest.NewClock(time.Unix(1500000000, 0))
+		called = false
+	})
+
+	send := func(username, password, ip string) (*httptest.ResponseRecorder, error) {
+		req, _ := http.NewRequest("GET", "http://example.com/", nil)
+		req.SetBasicAuth(username, password)
+		req.RemoteAddr = ip + ":4242"
+		rw := httptest.NewRecorder()
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called = true
+			return nil
+		}
+		ctx := goa.WithClock(context.Background(), clock)
+		err := basicauth.NewWithLockout("admin", "password", throttler)(handler)(ctx, rw, req)
+		return rw, err
+	}
+
+	It("authenticates valid credentials", func() {
+		_, err := send("admin", "password", "10.0.0.1")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(called).Should(BeTrue())
+	})
+
+	Context("after too many failures for the same username", func() {
+		BeforeEach(func() {
+			for i := 0; i < 3; i++ {
+				_, err := send("admin", "guess", "10.0.0.1")
+				Ω(err.(*goa.ErrorResponse).Status).Should(Equal(401))
+			}
+		})
+
+		It("locks the username out", func() {
+			rw, err := send("admin", "password", "10.0.0.2")
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(423))
+			Ω(rw.Header().Get("Retry-After")).Should(Equal("60"))
+			Ω(called).Should(BeFalse())
+		})
+
+		It("lifts the lockout once it expires", func() {
+			clock.Advance(time.Minute)
+			_, err := send("admin", "password", "10.0.0.2")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(called).Should(BeTrue())
+		})
+
+		It("doubles the lockout with each further failure", func() {
+			clock.Advance(time.Minute)
+			_, err := send("admin", "guess", "10.0.0.2")
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(401))
+			rw, err := send("admin", "password", "10.0.0.2")
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(423))
+			Ω(rw.Header().Get("Retry-After")).Should(Equal("120"))
+		})
+	})
+
+	Context("after a successful authentication", func() {
+		BeforeEach(func() {
+			for i := 0; i < 2; i++ {
+				send("admin", "guess", "10.0.0.1")
+			}
+			_, err := send("admin", "password", "10.0.0.1")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("resets the failures of the username", func() {
+			for i := 0; i < 2; i++ {
+				send("admin", "guess", "10.0.0.1")
+			}
+			_, err := send("admin", "password", "10.0.0.1")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("after too many failures from the same address", func() {
+		BeforeEach(func() {
+			for _, u := range []string{"a", "b", "c", "d", "e"} {
+				send(u, "guess", "10.0.0.1")
+			}
+		})
+
+		It("throttles the address", func() {
+			rw, err := send("admin", "password", "10.0.0.1")
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(429))
+			Ω(rw.Header().Get("Retry-After")).Should(Equal("60"))
+		})
+
+		It("does not throttle other addresses", func() {
+			_, err := send("admin", "password", "10.0.0.2")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Policy", func() {
+	It("forgets the failures after the window", func() {
+		policy := lockout.Policy{Window: time.Minute}
+		now := time.Unix(1500000000, 0)
+		f := policy.Fail(nil, 3, now)
+		f = policy.Fail(f, 3, now.Add(30*time.Second))
+		Ω(f.Count).Should(Equal(2))
+		f = policy.Fail(f, 3, now.Add(2*time.Minute))
+		Ω(f.Count).Should(Equal(1))
+		Ω(f.LockedUntil.IsZero()).Should(BeTrue())
+	})
+
+	It("caps the lockout duration", func() {
+		policy := lockout.Policy{Lockout: time.Minute, MaxLockout: 5 * time.Minute}
+		now := time.Unix(1500000000, 0)
+		var f *lockout.Failures
+		for i := 0; i < 10; i++ {
+			f = policy.Fail(f, 1, now)
+		}
+		Ω(f.LockedUntil).Should(Equal(now.Add(5 * time.Minute)))
+	})
+})
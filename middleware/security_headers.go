@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+// SecurityHeaderProfiles lists the predefined sets of security response headers indexed by
+// profile name. The "basic" profile is suitable for most APIs, the "strict" profile additionally
+// requires HTTPS and prevents any content from being loaded or framed by browsers.
+var SecurityHeaderProfiles = map[string]map[string]string{
+	"basic": {
+		"X-Content-Type-Options": "nosniff",
+		"X-Frame-Options":        "SAMEORIGIN",
+		"Referrer-Policy":        "strict-origin-when-cross-origin",
+	},
+	"strict": {
+		"Strict-Transport-Security": "max-age=63072000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+		"Referrer-Policy":           "no-referrer",
+	},
+}
+
+// SecurityHeaders is a middleware that sets the security headers of the given profile (see
+// SecurityHeaderProfiles) on all responses. overrides may set additional headers or override the
+// values defined by the profile, an empty value prevents the profile header from being set. An
+// empty profile name sets the overrides only. The headers are set prior to calling the handler
+// so that handlers may change them. SecurityHeaders panics if the profile does not exist.
+func SecurityHeaders(profile string, overrides map[string]string) goa.Middleware {
+	headers := make(map[string]string)
+	if profile != "" {
+		p, ok := SecurityHeaderProfiles[profile]
+		if !ok {
+			panic(fmt.Sprintf("unknown security headers profile %#v", profile))
+		}
+		for n, v := range p {
+			headers[n] = v
+		}
+	}
+	for n, v := range overrides {
+		n = http.CanonicalHeaderKey(n)
+		if v == "" {
+			delete(headers, n)
+			continue
+		}
+		headers[n] = v
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			for n, v := range headers {
+				rw.Header().Set(n, v)
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
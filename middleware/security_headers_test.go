@@ -0,0 +1,55 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SecurityHeaders", func() {
+	var profile string
+	var overrides map[string]string
+	var rw *httptest.ResponseRecorder
+
+	BeforeEach(func() {
+		profile = "strict"
+		overrides = nil
+		rw = httptest.NewRecorder()
+	})
+
+	JustBeforeEach(func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.WriteHeader(200)
+			return nil
+		}
+		req, _ := http.NewRequest("GET", "/", nil)
+		err := middleware.SecurityHeaders(profile, overrides)(h)(context.Background(), rw, req)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("sets the profile headers", func() {
+		for n, v := range middleware.SecurityHeaderProfiles["strict"] {
+			Ω(rw.Header().Get(n)).Should(Equal(v))
+		}
+	})
+
+	Context("with overrides", func() {
+		BeforeEach(func() {
+			overrides = map[string]string{
+				"content-security-policy": "default-src 'self'",
+				"X-Frame-Options":         "",
+			}
+		})
+
+		It("overrides and removes the profile headers", func() {
+			Ω(rw.Header().Get("Content-Security-Policy")).Should(Equal("default-src 'self'"))
+			Ω(rw.Header()).ShouldNot(HaveKey("X-Frame-Options"))
+			Ω(rw.Header().Get("X-Content-Type-Options")).Should(Equal("nosniff"))
+		})
+	})
+})
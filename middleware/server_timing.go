@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+// ServerTiming returns a middleware that reports the duration of the request processing phases
+// recorded by goa (see goa.Timings) to clients using the Server-Timing response header, see
+// https://www.w3.org/TR/server-timing. The header is set when the response starts and lists the
+// "decode", "validate" and "handler" phases. The duration of the "encode" phase and the "total"
+// duration are only known once the response body is written so they are sent in a Server-Timing
+// trailer which clients only receive with chunked responses.
+//
+// Server-Timing headers disclose information about the service internals, only mount the
+// middleware when the clients are trusted or on internal services.
+func ServerTiming() goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			timings := goa.ContextTimings(ctx)
+			resp := goa.ContextResponse(ctx)
+			if timings == nil || resp == nil {
+				return h(ctx, rw, req)
+			}
+			tw := &timingWriter{ResponseWriter: resp.SwitchWriter(nil), timings: timings}
+			resp.SwitchWriter(tw)
+			err := h(ctx, rw, req)
+			resp.SwitchWriter(tw.ResponseWriter)
+			if !resp.Written() {
+				// The response is written by an outer middleware, e.g. ErrorHandler.
+				timings.StartResponse()
+				rw.Header().Set("Server-Timing", timings.Header())
+				return err
+			}
+			total := timings.Finish()
+			var trailer []string
+			if d, ok := timings.Duration(goa.PhaseEncode); ok {
+				trailer = append(trailer, goa.ServerTimingMetric(goa.PhaseEncode, d))
+			}
+			trailer = append(trailer, goa.ServerTimingMetric("total", total))
+			rw.Header().Set(http.TrailerPrefix+"Server-Timing", strings.Join(trailer, ", "))
+			return err
+		}
+	}
+}
+
+// timingWriter sets the Server-Timing header when the response starts.
+type timingWriter struct {
+	http.ResponseWriter
+	timings *goa.Timings
+}
+
+// WriteHeader sets the Server-Timing header and writes the response status code.
+func (w *timingWriter) WriteHeader(status int) {
+	w.timings.StartResponse()
+	w.Header().Set("Server-Timing", w.timings.Header())
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,66 @@
+package middleware_test
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ServerTiming", func() {
+	var clock *goatest.Clock
+	var ctx context.Context
+	var rw *testResponseWriter
+	var req *http.Request
+	var handler goa.Handler
+
+	BeforeEach(func() {
+		service := newService(nil)
+		clock = goatest.NewClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+		var err error
+		req, err = http.NewRequest("GET", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw = newTestResponseWriter()
+		ctx = newContext(service, rw, req, nil)
+		ctx = goa.WithTimings(ctx, goa.NewTimings(clock))
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			end := goa.StartPhase(ctx, goa.PhaseHandler)
+			defer end()
+			clock.Advance(2 * time.Millisecond)
+			rw.WriteHeader(200)
+			clock.Advance(time.Millisecond)
+			rw.Write([]byte("ok"))
+			return nil
+		}
+	})
+
+	It("sets the Server-Timing header and trailer", func() {
+		err := middleware.ServerTiming()(handler)(ctx, goa.ContextResponse(ctx), req)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.ParentHeader.Get("Server-Timing")).Should(Equal("handler;dur=2.000"))
+		Ω(rw.ParentHeader.Get(http.TrailerPrefix + "Server-Timing")).Should(Equal("encode;dur=1.000, total;dur=3.000"))
+	})
+
+	Context("with a response written by an outer middleware", func() {
+		BeforeEach(func() {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				defer goa.StartPhase(ctx, goa.PhaseHandler)()
+				clock.Advance(time.Millisecond)
+				return goa.ErrBadRequest("bad")
+			}
+		})
+
+		It("sets the Server-Timing header", func() {
+			err := middleware.ServerTiming()(handler)(ctx, goa.ContextResponse(ctx), req)
+			Ω(err).Should(HaveOccurred())
+			Ω(rw.ParentHeader.Get("Server-Timing")).Should(Equal("handler;dur=1.000"))
+			Ω(rw.ParentHeader.Get(http.TrailerPrefix + "Server-Timing")).Should(BeEmpty())
+		})
+	})
+})
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/goadesign/goa"
@@ -38,12 +39,62 @@ import (
 // 	}
 //
 // Controller actions can check if a timeout is set by calling the context Deadline method.
+// The timeout is measured using the clock stored in the context, see goa.WithClock.
 func Timeout(timeout time.Duration) goa.Middleware {
 	return func(h goa.Handler) goa.Handler {
 		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
-			nctx, cancel := context.WithTimeout(ctx, timeout)
+			nctx, cancel := withClockTimeout(ctx, timeout)
 			defer cancel()
 			return h(nctx, rw, req)
 		}
 	}
 }
+
+// withClockTimeout behaves like context.WithTimeout but uses the context clock to set the
+// deadline and trigger the cancelation.
+func withClockTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	clock := goa.ContextClock(ctx)
+	if clock == goa.SystemClock {
+		return context.WithTimeout(ctx, timeout)
+	}
+	cctx, cancel := context.WithCancel(ctx)
+	dctx := &deadlineContext{Context: cctx, deadline: clock.Now().Add(timeout)}
+	timer := clock.AfterFunc(timeout, func() {
+		dctx.expire()
+		cancel()
+	})
+	return dctx, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+// deadlineContext is a context whose deadline is driven by a goa.Clock.
+type deadlineContext struct {
+	context.Context
+	deadline time.Time
+
+	mu      sync.Mutex
+	expired bool
+}
+
+// Deadline returns the time when the context is canceled.
+func (c *deadlineContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// Err returns context.DeadlineExceeded if the deadline has expired.
+func (c *deadlineContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.expired {
+		return context.DeadlineExceeded
+	}
+	return c.Context.Err()
+}
+
+func (c *deadlineContext) expire() {
+	c.mu.Lock()
+	c.expired = true
+	c.mu.Unlock()
+}
@@ -7,6 +7,8 @@ import (
 
 	"golang.org/x/net/context"
 
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
 	"github.com/goadesign/goa/middleware"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -31,4 +33,29 @@ var _ = Describe("Timeout", func() {
 		_, ok := newCtx.Deadline()
 		Ω(ok).Should(BeTrue())
 	})
+	Context("with a clock in the context", func() {
+		It("uses the clock to trigger the timeout", func() {
+			service := newService(nil)
+			now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock := goatest.NewClock(now)
+			service.WithClock(clock)
+
+			req, err := http.NewRequest("GET", "/goo", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			rw := new(testResponseWriter)
+			ctx := newContext(service, rw, req, nil)
+			h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				deadline, ok := ctx.Deadline()
+				Ω(ok).Should(BeTrue())
+				Ω(deadline).Should(Equal(now.Add(time.Second)))
+				Ω(ctx.Err()).ShouldNot(HaveOccurred())
+				clock.Advance(time.Second)
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			err = middleware.Timeout(time.Second)(h)(ctx, rw, req)
+			Ω(err).Should(Equal(context.DeadlineExceeded))
+			Ω(goa.ContextClock(ctx)).Should(Equal(clock))
+		})
+	})
 })
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/goadesign/goa"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// Tx is the interface implemented by the transactions managed by the Transaction
+	// middleware. *sql.Tx implements this interface.
+	Tx interface {
+		// Commit commits the transaction.
+		Commit() error
+		// Rollback aborts the transaction.
+		Rollback() error
+	}
+
+	// TxStarter begins the transaction used to handle a request.
+	TxStarter func(ctx context.Context) (Tx, error)
+)
+
+// Transaction is a middleware that begins a transaction with start prior to calling the handler
+// and stores it in the context. Retrieve the transaction using ContextTx. The transaction is
+// committed if the handler succeeds and writes a 2xx response. It is rolled back if the handler
+// returns an error, writes any other response or panics, in which case the panic is propagated
+// so that it may be handled by the Recover middleware.
+//
+// Note that the transaction is committed once the handler has written the response so that a
+// commit failure cannot change the response status. The commit error is returned and handled like
+// other handler errors.
+func Transaction(start TxStarter) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			tx, err := start(ctx)
+			if err != nil {
+				return goa.ErrInternal(err)
+			}
+			committed := false
+			defer func() {
+				if committed {
+					return
+				}
+				if rerr := tx.Rollback(); rerr != nil {
+					goa.LogError(ctx, "transaction rollback failed", "err", rerr)
+				}
+			}()
+			if err := h(context.WithValue(ctx, txKey, tx), rw, req); err != nil {
+				return err
+			}
+			if resp := goa.ContextResponse(ctx); resp == nil || resp.Status < 200 || resp.Status > 299 {
+				return nil
+			}
+			committed = true
+			return tx.Commit()
+		}
+	}
+}
+
+// ContextTx extracts the transaction stored in the context by the Transaction middleware. It
+// returns nil if the middleware is not mounted.
+func ContextTx(ctx context.Context) Tx {
+	if tx := ctx.Value(txKey); tx != nil {
+		return tx.(Tx)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transaction", func() {
+	var tx *testTx
+	var handler goa.Handler
+	var service *goa.Service
+	var ctx context.Context
+	var rw http.ResponseWriter
+	var req *http.Request
+	var newCtx context.Context
+
+	BeforeEach(func() {
+		tx = new(testTx)
+		newCtx = nil
+		service = newService(nil)
+		var err error
+		req, err = http.NewRequest("POST", "/goo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw = new(testResponseWriter)
+		ctx = newContext(service, rw, req, nil)
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			newCtx = ctx
+			return service.Send(ctx, 200, "ok")
+		}
+	})
+
+	run := func() error {
+		start := func(context.Context) (middleware.Tx, error) { return tx, nil }
+		return middleware.Transaction(start)(handler)(ctx, rw, req)
+	}
+
+	It("stores the transaction in the context and commits it", func() {
+		Ω(run()).ShouldNot(HaveOccurred())
+		Ω(middleware.ContextTx(newCtx)).Should(BeIdenticalTo(tx))
+		Ω(tx.committed).Should(BeTrue())
+		Ω(tx.rolledBack).Should(BeFalse())
+	})
+
+	It("rolls back when the handler returns an error", func() {
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return goa.ErrBadRequest("invalid")
+		}
+		Ω(run()).Should(HaveOccurred())
+		Ω(tx.committed).Should(BeFalse())
+		Ω(tx.rolledBack).Should(BeTrue())
+	})
+
+	It("rolls back when the handler writes a non 2xx response", func() {
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return service.Send(ctx, 404, "not found")
+		}
+		Ω(run()).ShouldNot(HaveOccurred())
+		Ω(tx.committed).Should(BeFalse())
+		Ω(tx.rolledBack).Should(BeTrue())
+	})
+
+	It("rolls back and propagates panics", func() {
+		handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			panic("boom")
+		}
+		Ω(func() { run() }).Should(Panic())
+		Ω(tx.committed).Should(BeFalse())
+		Ω(tx.rolledBack).Should(BeTrue())
+	})
+
+	It("fails when the transaction cannot be started", func() {
+		start := func(context.Context) (middleware.Tx, error) { return nil, errors.New("no db") }
+		err := middleware.Transaction(start)(handler)(ctx, rw, req)
+		Ω(err).Should(HaveOccurred())
+		Ω(newCtx).Should(BeNil())
+	})
+})
+
+// testTx is a middleware.Tx that records calls to Commit and Rollback.
+type testTx struct {
+	committed, rolledBack bool
+}
+
+func (tx *testTx) Commit() error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *testTx) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
@@ -0,0 +1,99 @@
+package goa
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PageParam is the name of the query string parameter that holds the requested page number
+	// of actions using page based pagination.
+	PageParam = "page"
+	// PerPageParam is the name of the query string parameter that holds the requested number
+	// of items per page of paginated actions.
+	PerPageParam = "per_page"
+	// CursorParam is the name of the query string parameter that holds the cursor of the
+	// requested page of actions using cursor based pagination.
+	CursorParam = "cursor"
+	// TotalCountHeader is the name of the response header that holds the total number of items
+	// listed by paginated actions when known.
+	TotalCountHeader = "X-Total-Count"
+)
+
+// Pagination holds the pagination parameters of a request made to a paginated action as well as
+// the information needed to link to the other pages. Actions set Total (page based pagination) or
+// NextCursor (cursor based pagination) before sending the response so that the generated OK
+// response helper can write the Link (RFC 5988) and X-Total-Count headers.
+type Pagination struct {
+	// Page is the requested page number starting at 1, zero for cursor based pagination.
+	Page int
+	// PerPage is the requested number of items per page.
+	PerPage int
+	// Cursor is the cursor of the requested page, empty for the first page.
+	Cursor string
+	// Total is the total number of items, negative if unknown.
+	Total int
+	// NextCursor is the cursor of the next page, empty if the requested page is the last one.
+	NextCursor string
+}
+
+// Offset returns the index of the first item of the requested page for page based pagination.
+func (p *Pagination) Offset() int {
+	if p.Page < 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.PerPage
+}
+
+// SetHeaders writes the Link header with the "first", "prev", "next" and "last" relations that
+// apply to the requested page and the X-Total-Count header if the total is known. u is the URL of
+// the request, the links keep its query string parameters other than the pagination ones.
+func (p *Pagination) SetHeaders(header http.Header, u *url.URL) {
+	if p.Total >= 0 {
+		header.Set(TotalCountHeader, strconv.Itoa(p.Total))
+	}
+	var links []string
+	link := func(rel string, values map[string]string) {
+		query := u.Query()
+		query.Del(PageParam)
+		query.Del(CursorParam)
+		query.Set(PerPageParam, strconv.Itoa(p.PerPage))
+		for k, v := range values {
+			query.Set(k, v)
+		}
+		l := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, l.String(), rel))
+	}
+	if p.Page == 0 {
+		if p.NextCursor != "" {
+			link("next", map[string]string{CursorParam: p.NextCursor})
+		}
+		if p.Cursor != "" {
+			link("first", nil)
+		}
+	} else {
+		last := 0
+		if p.Total >= 0 && p.PerPage > 0 {
+			last = (p.Total + p.PerPage - 1) / p.PerPage
+			if last == 0 {
+				last = 1
+			}
+		}
+		link("first", map[string]string{PageParam: "1"})
+		if p.Page > 1 {
+			link("prev", map[string]string{PageParam: strconv.Itoa(p.Page - 1)})
+		}
+		if last == 0 || p.Page < last {
+			link("next", map[string]string{PageParam: strconv.Itoa(p.Page + 1)})
+		}
+		if last > 0 {
+			link("last", map[string]string{PageParam: strconv.Itoa(last)})
+		}
+	}
+	if len(links) > 0 {
+		header.Set("Link", strings.Join(links, ", "))
+	}
+}
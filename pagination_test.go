@@ -0,0 +1,74 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pagination", func() {
+	var p *goa.Pagination
+	var header http.Header
+	var u *url.URL
+
+	BeforeEach(func() {
+		header = make(http.Header)
+		var err error
+		u, err = url.Parse("/bottles?page=2&per_page=10&sort=name")
+		Ω(err).ShouldNot(HaveOccurred())
+		p = &goa.Pagination{Page: 2, PerPage: 10, Total: -1}
+	})
+
+	JustBeforeEach(func() {
+		p.SetHeaders(header, u)
+	})
+
+	It("computes the offset", func() {
+		Ω(p.Offset()).Should(Equal(10))
+	})
+
+	It("writes the links without the last page when the total is unknown", func() {
+		Ω(header.Get(goa.TotalCountHeader)).Should(BeEmpty())
+		Ω(header.Get("Link")).Should(Equal(`</bottles?page=1&per_page=10&sort=name>; rel="first", ` +
+			`</bottles?page=1&per_page=10&sort=name>; rel="prev", ` +
+			`</bottles?page=3&per_page=10&sort=name>; rel="next"`))
+	})
+
+	Context("with a known total", func() {
+		BeforeEach(func() {
+			p.Total = 25
+		})
+
+		It("writes the total and the last page link", func() {
+			Ω(header.Get(goa.TotalCountHeader)).Should(Equal("25"))
+			Ω(header.Get("Link")).Should(ContainSubstring(`</bottles?page=3&per_page=10&sort=name>; rel="next"`))
+			Ω(header.Get("Link")).Should(ContainSubstring(`</bottles?page=3&per_page=10&sort=name>; rel="last"`))
+		})
+	})
+
+	Context("on the last page", func() {
+		BeforeEach(func() {
+			p.Page = 3
+			p.Total = 25
+		})
+
+		It("does not write a next link", func() {
+			Ω(header.Get("Link")).ShouldNot(ContainSubstring(`rel="next"`))
+		})
+	})
+
+	Context("with cursor based pagination", func() {
+		BeforeEach(func() {
+			u, _ = url.Parse("/bottles?cursor=abc&per_page=10")
+			p = &goa.Pagination{PerPage: 10, Cursor: "abc", NextCursor: "def", Total: -1}
+		})
+
+		It("writes the next and first links", func() {
+			Ω(header.Get("Link")).Should(Equal(`</bottles?cursor=def&per_page=10>; rel="next", ` +
+				`</bottles?per_page=10>; rel="first"`))
+		})
+	})
+})
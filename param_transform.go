@@ -0,0 +1,72 @@
+package goa
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ParamTransformFunc canonicalizes the value of a request parameter or header, see
+// RegisterParamTransform.
+type ParamTransformFunc func(string) string
+
+var (
+	// paramTransformsMu protects paramTransforms.
+	paramTransformsMu sync.RWMutex
+	// paramTransforms indexes the param transforms by name.
+	paramTransforms = map[string]ParamTransformFunc{
+		"lowercase": strings.ToLower,
+		"uppercase": strings.ToUpper,
+		"trim":      strings.TrimSpace,
+		"nfc":       norm.NFC.String,
+		"ulid":      CanonicalULID,
+	}
+)
+
+// RegisterParamTransform registers a custom transform that may be applied to params and headers
+// with the ParamTransform DSL, the name must also be appended to the design
+// apidsl.SupportedParamTransforms variable. Registering a transform with the name of an existing
+// transform overrides it.
+func RegisterParamTransform(name string, fn ParamTransformFunc) {
+	paramTransformsMu.Lock()
+	defer paramTransformsMu.Unlock()
+	paramTransforms[name] = fn
+}
+
+// TransformParam applies the transforms with the given names to value in order. The generated
+// code calls TransformParam before validating params and headers that use the ParamTransform DSL.
+// TransformParam panics if a transform is not registered.
+func TransformParam(value string, names ...string) string {
+	paramTransformsMu.RLock()
+	defer paramTransformsMu.RUnlock()
+	for _, n := range names {
+		fn, ok := paramTransforms[n]
+		if !ok {
+			panic(fmt.Sprintf("goa: unknown param transform %#v, register it with RegisterParamTransform", n))
+		}
+		value = fn(value)
+	}
+	return value
+}
+
+// CanonicalULID returns the canonical form of the given ULID: hyphens are removed, letters are
+// upper cased and the Crockford base32 aliases "I", "L" and "O" are replaced with the digits they
+// stand for. The result is not validated.
+func CanonicalULID(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-':
+			return -1
+		case 'i', 'I', 'l', 'L':
+			return '1'
+		case 'o', 'O':
+			return '0'
+		}
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}, id)
+}
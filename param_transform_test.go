@@ -0,0 +1,42 @@
+package goa_test
+
+import (
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TransformParam", func() {
+	It("applies the transforms in order", func() {
+		Ω(goa.TransformParam("  My-Slug ", "trim", "lowercase")).Should(Equal("my-slug"))
+		Ω(goa.TransformParam("  My-Slug ", "lowercase")).Should(Equal("  my-slug "))
+	})
+
+	It("normalizes to NFC", func() {
+		Ω(goa.TransformParam("cafe\u0301", "nfc")).Should(Equal("caf\u00e9"))
+	})
+
+	It("canonicalizes ULIDs", func() {
+		Ω(goa.TransformParam("01arz3ndektsv4rrffq69g5fav", "ulid")).Should(Equal("01ARZ3NDEKTSV4RRFFQ69G5FAV"))
+		Ω(goa.CanonicalULID("01ARZ-3NDEK-TSV4R-RFFQ6-9G5FA-V")).Should(Equal("01ARZ3NDEKTSV4RRFFQ69G5FAV"))
+		Ω(goa.CanonicalULID("OlARZ3NDEKTSV4RRFFQ69G5FAI")).Should(Equal("01ARZ3NDEKTSV4RRFFQ69G5FA1"))
+	})
+
+	It("applies custom transforms", func() {
+		goa.RegisterParamTransform("reverse-case", func(s string) string {
+			return strings.Map(func(r rune) rune {
+				if r >= 'a' && r <= 'z' {
+					return r - 'a' + 'A'
+				}
+				return r + 'a' - 'A'
+			}, s)
+		})
+		Ω(goa.TransformParam("aB", "reverse-case")).Should(Equal("Ab"))
+	})
+
+	It("panics on unknown transforms", func() {
+		Ω(func() { goa.TransformParam("foo", "rot13") }).Should(Panic())
+	})
+})
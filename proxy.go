@@ -0,0 +1,177 @@
+package goa
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// ProxyOptions configures the requests made by a Proxy to the upstream service.
+	ProxyOptions struct {
+		// Timeout is the maximum duration of upstream requests including reading the
+		// response body. Zero means no timeout. The timeout is only enforced if Transport
+		// implements CancelRequest like http.Transport does.
+		Timeout time.Duration
+		// SetHeaders lists the headers set on upstream requests, overriding the values sent
+		// by the client if any.
+		SetHeaders map[string]string
+		// RemoveHeaders lists the client headers that are not forwarded upstream.
+		RemoveHeaders []string
+		// Transport is used to make the upstream requests, defaults to
+		// http.DefaultTransport.
+		Transport http.RoundTripper
+	}
+
+	// ProxyValidator validates the response of the upstream service before it is written back
+	// to the client. Responses that fail validation are replaced with an error of class
+	// ErrBadGateway.
+	ProxyValidator func(status int, header http.Header, body []byte) error
+
+	// Proxy forwards requests to an upstream service. It is used by the code generated for
+	// resources that use the Proxy DSL to migrate existing services behind a designed API.
+	Proxy struct {
+		upstream *url.URL
+		options  ProxyOptions
+	}
+)
+
+// ErrBadGateway is the class of errors returned by Proxy handlers when the upstream service
+// cannot be reached or returns an invalid response.
+var ErrBadGateway = NewErrorClass("bad_gateway", 502)
+
+// hopHeaders lists the hop-by-hop headers that proxies must not forward, see
+// https://tools.ietf.org/html/rfc2616#section-13.5.1
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailers",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// NewProxy creates a proxy that forwards requests to the given upstream base URL. The request
+// path is appended to the upstream URL path.
+func NewProxy(upstream string, options ProxyOptions) (*Proxy, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid upstream URL %#v, must be absolute", upstream)
+	}
+	if options.Transport == nil {
+		options.Transport = http.DefaultTransport
+	}
+	return &Proxy{upstream: u, options: options}, nil
+}
+
+// Handler returns a handler that forwards requests to the upstream service and writes back its
+// response. The upstream response body is read in memory and given to validate prior to being
+// written if validate is not nil.
+func (p *Proxy) Handler(validate ProxyValidator) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		out, err := p.upstreamRequest(req)
+		if err != nil {
+			return ErrBadGateway(err)
+		}
+		if p.options.Timeout > 0 {
+			canceler, ok := p.options.Transport.(interface {
+				CancelRequest(*http.Request)
+			})
+			if ok {
+				timer := ContextClock(ctx).AfterFunc(p.options.Timeout, func() {
+					canceler.CancelRequest(out)
+				})
+				defer timer.Stop()
+			}
+		}
+		resp, err := p.options.Transport.RoundTrip(out)
+		if err != nil {
+			return ErrBadGateway(err)
+		}
+		defer resp.Body.Close()
+		var body io.Reader = resp.Body
+		if validate != nil {
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return ErrBadGateway(err)
+			}
+			if err := validate(resp.StatusCode, resp.Header, b); err != nil {
+				LogError(ctx, "invalid upstream response", "upstream", p.upstream.Host, "err", err)
+				return ErrBadGateway(fmt.Sprintf("invalid response from upstream service: %s", err))
+			}
+			body = bytes.NewReader(b)
+		}
+		for k, vs := range resp.Header {
+			for _, v := range vs {
+				rw.Header().Add(k, v)
+			}
+		}
+		removeHopHeaders(rw.Header())
+		rw.WriteHeader(resp.StatusCode)
+		_, err = io.Copy(rw, body)
+		return err
+	}
+}
+
+// upstreamRequest builds the request sent to the upstream service from the client request.
+func (p *Proxy) upstreamRequest(req *http.Request) (*http.Request, error) {
+	u := *p.upstream
+	u.Path = path.Join("/", u.Path, req.URL.Path)
+	if strings.HasSuffix(req.URL.Path, "/") && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	u.RawQuery = req.URL.RawQuery
+	if p.upstream.RawQuery != "" {
+		if u.RawQuery == "" {
+			u.RawQuery = p.upstream.RawQuery
+		} else {
+			u.RawQuery = p.upstream.RawQuery + "&" + u.RawQuery
+		}
+	}
+	out, err := http.NewRequest(req.Method, u.String(), req.Body)
+	if err != nil {
+		return nil, err
+	}
+	out.ContentLength = req.ContentLength
+	for k, vs := range req.Header {
+		out.Header[k] = append([]string(nil), vs...)
+	}
+	removeHopHeaders(out.Header)
+	for _, h := range p.options.RemoveHeaders {
+		out.Header.Del(h)
+	}
+	for h, v := range p.options.SetHeaders {
+		out.Header.Set(h, v)
+	}
+	if ip, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		if prior := out.Header.Get("X-Forwarded-For"); prior != "" {
+			ip = prior + ", " + ip
+		}
+		out.Header.Set("X-Forwarded-For", ip)
+	}
+	if req.Host != "" {
+		out.Header.Set("X-Forwarded-Host", req.Host)
+	}
+	return out, nil
+}
+
+// removeHopHeaders deletes the hop-by-hop headers from h.
+func removeHopHeaders(h http.Header) {
+	for _, hh := range hopHeaders {
+		h.Del(hh)
+	}
+}
@@ -0,0 +1,102 @@
+package goa_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Proxy", func() {
+	var upstream *httptest.Server
+	var received *http.Request
+	var options goa.ProxyOptions
+	var validate goa.ProxyValidator
+
+	var rw *httptest.ResponseRecorder
+	var err error
+
+	BeforeEach(func() {
+		received = nil
+		options = goa.ProxyOptions{}
+		validate = nil
+		upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			w.Header().Set("X-Upstream", "yes")
+			w.WriteHeader(201)
+			w.Write([]byte("created"))
+		}))
+	})
+
+	JustBeforeEach(func() {
+		proxy, perr := goa.NewProxy(upstream.URL+"/v1", options)
+		Ω(perr).ShouldNot(HaveOccurred())
+		req, _ := http.NewRequest("POST", "/bottles?sort=asc", nil)
+		req.Header.Set("Cookie", "secret")
+		req.Header.Set("Connection", "close")
+		req.RemoteAddr = "10.0.0.1:4242"
+		rw = httptest.NewRecorder()
+		err = proxy.Handler(validate)(context.Background(), rw, req)
+	})
+
+	AfterEach(func() {
+		upstream.Close()
+	})
+
+	It("forwards the request upstream", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(received).ShouldNot(BeNil())
+		Ω(received.Method).Should(Equal("POST"))
+		Ω(received.URL.Path).Should(Equal("/v1/bottles"))
+		Ω(received.URL.RawQuery).Should(Equal("sort=asc"))
+		Ω(received.Header.Get("X-Forwarded-For")).Should(Equal("10.0.0.1"))
+	})
+
+	It("writes back the upstream response", func() {
+		Ω(rw.Code).Should(Equal(201))
+		Ω(rw.Header().Get("X-Upstream")).Should(Equal("yes"))
+		Ω(rw.Body.String()).Should(Equal("created"))
+	})
+
+	Context("with header rewriting", func() {
+		BeforeEach(func() {
+			options.SetHeaders = map[string]string{"X-Api-Key": "key"}
+			options.RemoveHeaders = []string{"Cookie"}
+		})
+
+		It("rewrites the upstream request headers", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(received.Header.Get("X-Api-Key")).Should(Equal("key"))
+			Ω(received.Header.Get("Cookie")).Should(BeEmpty())
+		})
+	})
+
+	Context("with a validator that rejects the response", func() {
+		BeforeEach(func() {
+			validate = func(status int, header http.Header, body []byte) error {
+				return errors.New("missing name")
+			}
+		})
+
+		It("returns a bad gateway error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(502))
+			Ω(rw.Body.String()).Should(BeEmpty())
+		})
+	})
+
+	Context("with an unreachable upstream", func() {
+		BeforeEach(func() {
+			upstream.Close()
+		})
+
+		It("returns a bad gateway error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(502))
+		})
+	})
+})
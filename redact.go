@@ -0,0 +1,91 @@
+package goa
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/net/context"
+)
+
+// Redacted returns the placeholder used in place of the value of a field classified with class in
+// errors and logs, see the Classification DSL.
+func Redacted(class string) string {
+	return fmt.Sprintf("[redacted %s]", class)
+}
+
+// WithClassifications sets the classification of the request params and payload fields in the
+// context, see Controller.Classify.
+func WithClassifications(ctx context.Context, fields map[string]string) context.Context {
+	return context.WithValue(ctx, classificationsKey, fields)
+}
+
+// ContextClassification returns the classification of the request param or payload field with the
+// given name. Payload fields nested in objects are named after the dot separated names of the
+// enclosing fields, e.g. "address.phone". It returns an empty string if the field is not
+// classified.
+func ContextClassification(ctx context.Context, field string) string {
+	if c := ctx.Value(classificationsKey); c != nil {
+		return c.(map[string]string)[field]
+	}
+	return ""
+}
+
+// Redact returns the value of the request param or payload field with the given name with the
+// values of the classified fields replaced with placeholders. Objects are redacted recursively.
+func Redact(ctx context.Context, field string, val interface{}) interface{} {
+	c := ctx.Value(classificationsKey)
+	if c == nil {
+		return val
+	}
+	return redact(c.(map[string]string), field, val)
+}
+
+// RedactPayload returns the request payload with the values of the classified fields replaced
+// with placeholders. Payloads that are not generic maps (e.g. the structs produced by the generated
+// unmarshalers) are converted using their JSON representation prior to being redacted. It returns
+// payload unchanged if the action does not define classified fields.
+func RedactPayload(ctx context.Context, payload interface{}) interface{} {
+	c := ctx.Value(classificationsKey)
+	if c == nil || payload == nil {
+		return payload
+	}
+	fields := c.(map[string]string)
+	raw, ok := payload.(map[string]interface{})
+	if !ok {
+		js, err := json.Marshal(payload)
+		if err != nil {
+			return payload
+		}
+		if err := json.Unmarshal(js, &raw); err != nil {
+			// Not an object
+			return payload
+		}
+	}
+	res := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		res[k] = redact(fields, k, v)
+	}
+	return res
+}
+
+// redact implements Redact and RedactPayload.
+func redact(fields map[string]string, field string, val interface{}) interface{} {
+	if class, ok := fields[field]; ok {
+		return Redacted(class)
+	}
+	switch actual := val.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(actual))
+		for k, v := range actual {
+			res[k] = redact(fields, field+"."+k, v)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(actual))
+		for i, v := range actual {
+			res[i] = redact(fields, field, v)
+		}
+		return res
+	}
+	return val
+}
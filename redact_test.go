@@ -0,0 +1,53 @@
+package goa_test
+
+import (
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Redact", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = goa.WithClassifications(context.Background(), map[string]string{
+			"email":         "pii:email",
+			"address.phone": "pii:phone",
+		})
+	})
+
+	It("does not redact without classifications", func() {
+		Ω(goa.Redact(context.Background(), "email", "me@example.com")).Should(Equal("me@example.com"))
+		Ω(goa.ContextClassification(context.Background(), "email")).Should(BeEmpty())
+	})
+
+	It("returns the field classification", func() {
+		Ω(goa.ContextClassification(ctx, "email")).Should(Equal("pii:email"))
+		Ω(goa.ContextClassification(ctx, "name")).Should(BeEmpty())
+	})
+
+	It("redacts classified fields", func() {
+		Ω(goa.Redact(ctx, "email", "me@example.com")).Should(Equal(goa.Redacted("pii:email")))
+		Ω(goa.Redact(ctx, "name", "me")).Should(Equal("me"))
+	})
+
+	It("redacts nested fields", func() {
+		address := map[string]interface{}{"phone": "555-1234", "city": "Paris"}
+		Ω(goa.Redact(ctx, "address", address)).Should(Equal(map[string]interface{}{
+			"phone": "[redacted pii:phone]",
+			"city":  "Paris",
+		}))
+	})
+
+	It("redacts payload structs", func() {
+		payload := &struct {
+			Email string `json:"email"`
+			Name  string `json:"name"`
+		}{"me@example.com", "me"}
+		Ω(goa.RedactPayload(ctx, payload)).Should(Equal(map[string]interface{}{
+			"email": "[redacted pii:email]",
+			"name":  "me",
+		}))
+	})
+})
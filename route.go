@@ -0,0 +1,65 @@
+package goa
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a route mounted on a service: the HTTP method and path pattern and the
+// resource and action that handle the requests. The generated controller mount functions record
+// the routes they mount with Service.AddRoute.
+type RouteInfo struct {
+	// Method is the route HTTP method, e.g. "GET".
+	Method string
+	// Pattern is the route path pattern, e.g. "/bottles/:id".
+	Pattern string
+	// Resource is the name of the resource that handles the requests.
+	Resource string
+	// Action is the name of the action that handles the requests.
+	Action string
+	// Security is the name of the security scheme protecting the route if any.
+	Security string
+}
+
+// AddRoute records a route mounted on the service. The route is only recorded, mounting the
+// handler is done via the service Mux.
+func (service *Service) AddRoute(route RouteInfo) {
+	service.routes = append(service.routes, route)
+}
+
+// Routes returns the routes mounted on the service sorted by path pattern and method.
+func (service *Service) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(service.routes))
+	copy(routes, service.routes)
+	sort.Stable(byPattern(routes))
+	return routes
+}
+
+// PrintRoutes writes the table of the routes mounted on the service to w. The table lists the
+// method, path pattern, resource, action and security scheme of each route.
+func (service *Service) PrintRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tRESOURCE\tACTION\tSECURITY")
+	for _, r := range service.Routes() {
+		security := r.Security
+		if security == "" {
+			security = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Pattern, r.Resource, r.Action, security)
+	}
+	return tw.Flush()
+}
+
+// byPattern sorts routes by path pattern and method.
+type byPattern []RouteInfo
+
+func (b byPattern) Len() int      { return len(b) }
+func (b byPattern) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byPattern) Less(i, j int) bool {
+	if b[i].Pattern == b[j].Pattern {
+		return b[i].Method < b[j].Method
+	}
+	return b[i].Pattern < b[j].Pattern
+}
@@ -0,0 +1,39 @@
+package goa_test
+
+import (
+	"bytes"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Routes", func() {
+	var service *goa.Service
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.AddRoute(goa.RouteInfo{Method: "POST", Pattern: "/bottles", Resource: "bottle", Action: "create", Security: "jwt"})
+		service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: "/bottles/:id", Resource: "bottle", Action: "show"})
+		service.AddRoute(goa.RouteInfo{Method: "GET", Pattern: "/bottles", Resource: "bottle", Action: "list"})
+	})
+
+	It("returns the routes sorted by pattern and method", func() {
+		routes := service.Routes()
+		Ω(routes).Should(HaveLen(3))
+		Ω(routes[0].Action).Should(Equal("list"))
+		Ω(routes[1].Action).Should(Equal("create"))
+		Ω(routes[1].Security).Should(Equal("jwt"))
+		Ω(routes[2].Action).Should(Equal("show"))
+	})
+
+	It("prints the route table", func() {
+		var buf bytes.Buffer
+		Ω(service.PrintRoutes(&buf)).ShouldNot(HaveOccurred())
+		Ω(buf.String()).Should(Equal(`METHOD  PATTERN       RESOURCE  ACTION  SECURITY
+GET     /bottles      bottle    list    -
+POST    /bottles      bottle    create  jwt
+GET     /bottles/:id  bottle    show    -
+`))
+	})
+})
@@ -0,0 +1,124 @@
+package goa
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// SandboxResponseHeader is the name of the request header that selects the response sent
+	// in sandbox mode, either by name (e.g. "NotFound") or by status code (e.g. "404").
+	SandboxResponseHeader = "X-Sandbox-Response"
+
+	// SandboxViewHeader is the name of the request header that selects the view used to
+	// render the response sent in sandbox mode.
+	SandboxViewHeader = "X-Sandbox-View"
+)
+
+// SandboxResponse describes an example response of an action sent in sandbox mode, see
+// Service.SendSandboxResponse. The generated code builds the sandbox responses of each action
+// from the responses and examples defined in the design.
+type SandboxResponse struct {
+	// Name is the name of the response, e.g. "OK".
+	Name string
+	// Status is the response status code.
+	Status int
+	// ContentType is the value of the response Content-Type header if any.
+	ContentType string
+	// Views contains the JSON encoded example bodies indexed by view name, it is empty if
+	// the response has no body.
+	Views map[string]string
+}
+
+// SendSandboxResponse sends one of the given example responses in place of running the action
+// handler. The generated handlers call it when the service Sandbox field is true so that API
+// consumers can integrate against realistic responses before the actions are implemented.
+//
+// The client selects the response with the X-Sandbox-Response header and the view with the
+// X-Sandbox-View header. The response defaults to the success response with the lowest status
+// code and the view to the "default" view. Selecting an unknown response or view results in a
+// bad request error.
+func (service *Service) SendSandboxResponse(ctx context.Context, responses []*SandboxResponse) error {
+	req := ContextRequest(ctx)
+	if req == nil {
+		return fmt.Errorf("no request data in context")
+	}
+	resp, err := selectSandboxResponse(responses, req.Header.Get(SandboxResponseHeader))
+	if err != nil {
+		return err
+	}
+	rw := ContextResponse(ctx)
+	if resp.ContentType != "" {
+		rw.Header().Set("Content-Type", resp.ContentType)
+	}
+	if len(resp.Views) == 0 {
+		rw.WriteHeader(resp.Status)
+		return nil
+	}
+	view := req.Header.Get(SandboxViewHeader)
+	if view == "" {
+		view = sandboxDefaultView(resp.Views)
+	}
+	example, ok := resp.Views[view]
+	if !ok {
+		return ErrBadRequest(fmt.Sprintf("unknown sandbox view %q, must be one of %s", view, strings.Join(sortedKeys(resp.Views), ", ")))
+	}
+	var body interface{}
+	if err := json.Unmarshal([]byte(example), &body); err != nil {
+		return ErrInternal(err)
+	}
+	return service.Send(ctx, resp.Status, body)
+}
+
+// selectSandboxResponse returns the response selected by the value of the X-Sandbox-Response
+// header.
+func selectSandboxResponse(responses []*SandboxResponse, selector string) (*SandboxResponse, error) {
+	if len(responses) == 0 {
+		return nil, ErrInternal("no sandbox response")
+	}
+	if selector == "" {
+		var res *SandboxResponse
+		for _, r := range responses {
+			if r.Status >= 200 && r.Status < 300 && (res == nil || r.Status < res.Status) {
+				res = r
+			}
+		}
+		if res == nil {
+			res = responses[0]
+		}
+		return res, nil
+	}
+	status, _ := strconv.Atoi(selector)
+	names := make([]string, len(responses))
+	for i, r := range responses {
+		if r.Status == status || strings.EqualFold(r.Name, selector) {
+			return r, nil
+		}
+		names[i] = r.Name
+	}
+	return nil, ErrBadRequest(fmt.Sprintf("unknown sandbox response %q, must be one of %s", selector, strings.Join(names, ", ")))
+}
+
+// sandboxDefaultView returns the "default" view if present, the first view in alphabetical order
+// otherwise.
+func sandboxDefaultView(views map[string]string) string {
+	if _, ok := views["default"]; ok {
+		return "default"
+	}
+	return sortedKeys(views)[0]
+}
+
+// sortedKeys returns the keys of m in alphabetical order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
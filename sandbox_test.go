@@ -0,0 +1,104 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SendSandboxResponse", func() {
+	var service *goa.Service
+	var req *http.Request
+	var rw *httptest.ResponseRecorder
+	var err error
+
+	responses := []*goa.SandboxResponse{
+		{Name: "OK", Status: 200, ContentType: "application/vnd.bottle", Views: map[string]string{
+			"default": `{"id":1,"name":"Number 8"}`,
+			"tiny":    `{"id":1}`,
+		}},
+		{Name: "Created", Status: 201},
+		{Name: "NotFound", Status: 404},
+	}
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.Encoder.Register(goa.NewJSONEncoder, "*/*")
+		req, _ = http.NewRequest("GET", "/bottles/1", nil)
+	})
+
+	JustBeforeEach(func() {
+		rw = httptest.NewRecorder()
+		ctx := goa.NewContext(context.Background(), rw, req, nil)
+		err = service.SendSandboxResponse(ctx, responses)
+	})
+
+	It("sends the default view of the first success response", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("Content-Type")).Should(Equal("application/vnd.bottle"))
+		Ω(rw.Body.String()).Should(MatchJSON(`{"id":1,"name":"Number 8"}`))
+	})
+
+	Context("with a selected view", func() {
+		BeforeEach(func() {
+			req.Header.Set(goa.SandboxViewHeader, "tiny")
+		})
+
+		It("sends the view", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Body.String()).Should(MatchJSON(`{"id":1}`))
+		})
+	})
+
+	Context("with a response selected by name", func() {
+		BeforeEach(func() {
+			req.Header.Set(goa.SandboxResponseHeader, "notfound")
+		})
+
+		It("sends the response", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(404))
+			Ω(rw.Body.Len()).Should(Equal(0))
+		})
+	})
+
+	Context("with a response selected by status", func() {
+		BeforeEach(func() {
+			req.Header.Set(goa.SandboxResponseHeader, "201")
+		})
+
+		It("sends the response", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(201))
+		})
+	})
+
+	Context("with an unknown response", func() {
+		BeforeEach(func() {
+			req.Header.Set(goa.SandboxResponseHeader, "Teapot")
+		})
+
+		It("fails with a bad request error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(400))
+			Ω(err.Error()).Should(ContainSubstring("OK, Created, NotFound"))
+		})
+	})
+
+	Context("with an unknown view", func() {
+		BeforeEach(func() {
+			req.Header.Set(goa.SandboxViewHeader, "full")
+		})
+
+		It("fails with a bad request error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Status).Should(Equal(400))
+		})
+	})
+})
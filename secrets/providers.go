@@ -0,0 +1,205 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+type (
+	// VaultProvider retrieves secrets from the key/value version 2 secrets engine of a
+	// HashiCorp Vault server. The name of a secret is the path of the Vault secret optionally
+	// followed by "#" and the name of the field holding the value, e.g. "jwt#key". The field
+	// defaults to "value".
+	VaultProvider struct {
+		// Address is the URL of the Vault server, e.g. "https://vault.example.com:8200".
+		Address string
+		// Token is the Vault token used to authenticate the requests.
+		Token string
+		// Mount is the path where the secrets engine is mounted, defaults to "secret".
+		Mount string
+		// Client is the HTTP client used to make the requests, defaults to
+		// http.DefaultClient.
+		Client *http.Client
+	}
+
+	// AWSSecretsManager is the interface of the AWS Secrets Manager client used by AWS. It is
+	// implemented by wrapping the client of the AWS SDK:
+	//
+	//	type smClient struct{ *secretsmanager.SecretsManager }
+	//
+	//	func (c smClient) GetSecretString(ctx context.Context, id string) (string, error) {
+	//		out, err := c.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{SecretId: &id})
+	//		if err != nil {
+	//			return "", err
+	//		}
+	//		return aws.StringValue(out.SecretString), nil
+	//	}
+	AWSSecretsManager interface {
+		// GetSecretString returns the current string value of the secret with the given
+		// ID. It returns a *NotFoundError if the secret does not exist.
+		GetSecretString(ctx context.Context, id string) (string, error)
+	}
+
+	// chain is a provider that tries a list of providers in order.
+	chain []Provider
+)
+
+// Env returns a provider that reads secrets from environment variables. The name of the variable
+// is the prefix followed by the name of the secret upper cased with the characters other than
+// letters and digits replaced with underscores: the secret "jwt-key" of Env("MYSVC_") is read from
+// MYSVC_JWT_KEY.
+func Env(prefix string) Provider {
+	return ProviderFunc(func(ctx context.Context, name string) ([]byte, error) {
+		v, ok := os.LookupEnv(prefix + envName(name))
+		if !ok {
+			return nil, &NotFoundError{Name: name}
+		}
+		return []byte(v), nil
+	})
+}
+
+// File returns a provider that reads each secret from the file named after the secret in dir, such
+// as the files of a mounted Kubernetes secret. A trailing newline is removed from the file
+// content.
+func File(dir string) Provider {
+	return ProviderFunc(func(ctx context.Context, name string) ([]byte, error) {
+		if name == "" || name != filepath.Base(name) || name == ".." {
+			return nil, fmt.Errorf("invalid secret name %#v", name)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, &NotFoundError{Name: name}
+			}
+			return nil, err
+		}
+		b = []byte(strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r"))
+		return b, nil
+	})
+}
+
+// Vault returns a provider that reads secrets from the key/value secrets engine mounted at
+// "secret" on the given Vault server.
+func Vault(address, token string) *VaultProvider {
+	return &VaultProvider{Address: address, Token: token}
+}
+
+// AWS returns a provider that reads secrets from AWS Secrets Manager. The name of a secret is its
+// ID optionally followed by "#" and a key: the value of secrets stored as JSON objects is the
+// value of the key.
+func AWS(client AWSSecretsManager) Provider {
+	return ProviderFunc(func(ctx context.Context, name string) ([]byte, error) {
+		id, key := splitField(name, "")
+		v, err := client.GetSecretString(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			return []byte(v), nil
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &fields); err != nil {
+			return nil, fmt.Errorf("secret %#v is not a JSON object: %s", id, err)
+		}
+		return fieldValue(fields, name, key)
+	})
+}
+
+// Chain returns a provider that retrieves secrets from the first of the given providers that
+// contains them, for example to let environment variables override the secrets of a store.
+func Chain(providers ...Provider) Provider {
+	return chain(providers)
+}
+
+// Secret retrieves the secret from the first provider that contains it.
+func (c chain) Secret(ctx context.Context, name string) ([]byte, error) {
+	for _, p := range c {
+		v, err := p.Secret(ctx, name)
+		if !IsNotFound(err) {
+			return v, err
+		}
+	}
+	return nil, &NotFoundError{Name: name}
+}
+
+// Secret reads the secret from Vault.
+func (v *VaultProvider) Secret(ctx context.Context, name string) ([]byte, error) {
+	path, field := splitField(name, "value")
+	mount := v.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	u := strings.TrimSuffix(v.Address, "/") + "/v1/" + strings.Trim(mount, "/") + "/data/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := ctxhttp.Do(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &NotFoundError{Name: name}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault: failed to read secret %#v: %s", path, resp.Status)
+	}
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("vault: invalid response for secret %#v: %s", path, err)
+	}
+	return fieldValue(body.Data.Data, name, field)
+}
+
+// envName computes the name of the environment variable holding a secret.
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// splitField splits a secret name of the form "path#field", field defaults to def.
+func splitField(name, def string) (string, string) {
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return name, def
+}
+
+// fieldValue returns the string value of the given field.
+func fieldValue(fields map[string]interface{}, name, field string) ([]byte, error) {
+	v, ok := fields[field]
+	if !ok {
+		return nil, &NotFoundError{Name: name}
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("field %#v of secret %#v is not a string", field, name)
+	}
+	return []byte(s), nil
+}
@@ -0,0 +1,175 @@
+/*
+Package secrets loads secret material such as JWT keys, webhook and signing secrets from a secret
+store instead of static configuration. A Provider retrieves secrets by name from the environment,
+files, Vault or AWS Secrets Manager and a Secret holds the current value of a secret, notifying
+its users when the value is rotated in the store:
+
+	secret, err := secrets.Load(ctx, secrets.Env("MYSVC_"), "jwt-key")
+	stop := secret.Watch(time.Minute, func(err error) { goa.LogError(ctx, "secret refresh", "err", err) })
+	defer stop()
+	app.UseJWT(jwt.New(secret, nil, app.NewJWTSecurity()))
+
+The jwt middleware, the signing package (see signing.NewKeyringFromSecret) and the webhook
+dispatcher (see goa.WebhookDispatcher.SecretFunc) use the rotated values as soon as the Secret
+picks them up.
+*/
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+type (
+	// Provider retrieves secrets from a secret store.
+	Provider interface {
+		// Secret returns the current value of the secret with the given name. It returns
+		// a *NotFoundError if the store does not contain the secret.
+		Secret(ctx context.Context, name string) ([]byte, error)
+	}
+
+	// ProviderFunc is an adapter that makes it possible to use a function as a Provider.
+	ProviderFunc func(ctx context.Context, name string) ([]byte, error)
+
+	// NotFoundError is the error returned by providers when a secret does not exist.
+	NotFoundError struct {
+		// Name is the name of the secret.
+		Name string
+	}
+
+	// RotateFunc is the signature of the callbacks called when the value of a secret changes.
+	RotateFunc func(current, previous []byte)
+
+	// Secret holds the value of a secret retrieved from a provider. The value is refreshed
+	// explicitly with Refresh or periodically with Watch. Secret is safe for concurrent use.
+	Secret struct {
+		// Name is the name of the secret in the provider.
+		Name string
+		// Clock schedules the refreshes made by Watch, defaults to goa.SystemClock.
+		Clock goa.Clock
+
+		provider  Provider
+		mu        sync.RWMutex
+		value     []byte
+		previous  []byte
+		callbacks []RotateFunc
+	}
+)
+
+// Secret calls f(ctx, name).
+func (f ProviderFunc) Secret(ctx context.Context, name string) ([]byte, error) {
+	return f(ctx, name)
+}
+
+// Error returns the error message.
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("secret %#v not found", e.Name)
+}
+
+// IsNotFound returns true if err is a *NotFoundError.
+func IsNotFound(err error) bool {
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
+// Load retrieves the secret with the given name from the provider.
+func Load(ctx context.Context, p Provider, name string) (*Secret, error) {
+	value, err := p.Secret(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		return nil, fmt.Errorf("secret %#v is empty", name)
+	}
+	return &Secret{Name: name, Clock: goa.SystemClock, provider: p, value: value}, nil
+}
+
+// Value returns the current value of the secret. The returned slice must not be modified.
+func (s *Secret) Value() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.value
+}
+
+// Previous returns the value the secret had before the last rotation, nil if the secret was never
+// rotated. Values produced with the previous secret may still be accepted while clients catch up
+// with the rotation. The returned slice must not be modified.
+func (s *Secret) Previous() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.previous
+}
+
+// OnRotate registers a function called with the new and previous values each time the value of
+// the secret changes. The callbacks run in the goroutine that refreshes the secret, in the order
+// they were registered.
+func (s *Secret) OnRotate(f RotateFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callbacks = append(s.callbacks, f)
+}
+
+// Refresh retrieves the value of the secret from the provider and calls the rotation callbacks if
+// it changed. It returns true if the value changed. The current value is kept if the provider
+// fails or returns an empty value.
+func (s *Secret) Refresh(ctx context.Context) (bool, error) {
+	value, err := s.provider.Secret(ctx, s.Name)
+	if err != nil {
+		return false, err
+	}
+	if len(value) == 0 {
+		return false, fmt.Errorf("secret %#v is empty", s.Name)
+	}
+	s.mu.Lock()
+	if bytes.Equal(value, s.value) {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.previous, s.value = s.value, value
+	previous := s.previous
+	callbacks := append([]RotateFunc(nil), s.callbacks...)
+	s.mu.Unlock()
+	for _, f := range callbacks {
+		f(value, previous)
+	}
+	return true, nil
+}
+
+// Watch refreshes the secret every interval until the returned function is called. interval must
+// be positive. Refresh errors are given to onError if not nil.
+func (s *Secret) Watch(interval time.Duration, onError func(error)) (stop func()) {
+	clock := s.Clock
+	if clock == nil {
+		clock = goa.SystemClock
+	}
+	var (
+		mu      sync.Mutex
+		timer   goa.Timer
+		stopped bool
+		tick    func()
+	)
+	tick = func() {
+		if _, err := s.Refresh(context.Background()); err != nil && onError != nil {
+			onError(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if !stopped {
+			timer = clock.AfterFunc(interval, tick)
+		}
+	}
+	mu.Lock()
+	timer = clock.AfterFunc(interval, tick)
+	mu.Unlock()
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		timer.Stop()
+	}
+}
@@ -0,0 +1,211 @@
+package secrets_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goadesign/goa/goatest"
+	"github.com/goadesign/goa/secrets"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+type fakeAWS map[string]string
+
+func (f fakeAWS) GetSecretString(ctx context.Context, id string) (string, error) {
+	v, ok := f[id]
+	if !ok {
+		return "", &secrets.NotFoundError{Name: id}
+	}
+	return v, nil
+}
+
+var _ = Describe("Providers", func() {
+	ctx := context.Background()
+
+	Describe("Env", func() {
+		BeforeEach(func() {
+			os.Setenv("GOATEST_JWT_KEY", "env-secret")
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("GOATEST_JWT_KEY")
+		})
+
+		It("reads the secrets from environment variables", func() {
+			v, err := secrets.Env("GOATEST_").Secret(ctx, "jwt-key")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(v)).Should(Equal("env-secret"))
+			_, err = secrets.Env("GOATEST_").Secret(ctx, "missing")
+			Ω(secrets.IsNotFound(err)).Should(BeTrue())
+		})
+	})
+
+	Describe("File", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "secrets")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ioutil.WriteFile(filepath.Join(dir, "jwt-key"), []byte("file-secret\n"), 0600)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("reads the secrets from files", func() {
+			v, err := secrets.File(dir).Secret(ctx, "jwt-key")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(v)).Should(Equal("file-secret"))
+			_, err = secrets.File(dir).Secret(ctx, "missing")
+			Ω(secrets.IsNotFound(err)).Should(BeTrue())
+			_, err = secrets.File(dir).Secret(ctx, "../jwt-key")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Vault", func() {
+		var server *httptest.Server
+		var token string
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token = r.Header.Get("X-Vault-Token")
+				if r.URL.Path != "/v1/secret/data/jwt" {
+					w.WriteHeader(404)
+					return
+				}
+				w.Write([]byte(`{"data":{"data":{"value":"vault-secret","key":"vault-key"}}}`))
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("reads the secrets from the key/value secrets engine", func() {
+			p := secrets.Vault(server.URL, "root")
+			v, err := p.Secret(ctx, "jwt")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(v)).Should(Equal("vault-secret"))
+			Ω(token).Should(Equal("root"))
+			v, err = p.Secret(ctx, "jwt#key")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(v)).Should(Equal("vault-key"))
+			_, err = p.Secret(ctx, "jwt#missing")
+			Ω(secrets.IsNotFound(err)).Should(BeTrue())
+			_, err = p.Secret(ctx, "missing")
+			Ω(secrets.IsNotFound(err)).Should(BeTrue())
+		})
+	})
+
+	Describe("AWS", func() {
+		It("reads the secrets from Secrets Manager", func() {
+			p := secrets.AWS(fakeAWS{"plain": "aws-secret", "json": `{"key":"aws-key"}`})
+			v, err := p.Secret(ctx, "plain")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(v)).Should(Equal("aws-secret"))
+			v, err = p.Secret(ctx, "json#key")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(v)).Should(Equal("aws-key"))
+			_, err = p.Secret(ctx, "missing")
+			Ω(secrets.IsNotFound(err)).Should(BeTrue())
+		})
+	})
+
+	Describe("Chain", func() {
+		It("returns the secret of the first provider that contains it", func() {
+			p := secrets.Chain(secrets.AWS(fakeAWS{"a": "first"}), secrets.AWS(fakeAWS{"a": "second", "b": "second"}))
+			v, err := p.Secret(ctx, "a")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(v)).Should(Equal("first"))
+			v, err = p.Secret(ctx, "b")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(v)).Should(Equal("second"))
+			_, err = p.Secret(ctx, "c")
+			Ω(secrets.IsNotFound(err)).Should(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("Secret", func() {
+	var (
+		value    string
+		fail     error
+		provider secrets.Provider
+		secret   *secrets.Secret
+		rotated  [][]string
+	)
+
+	BeforeEach(func() {
+		value = "one"
+		fail = nil
+		rotated = nil
+		provider = secrets.ProviderFunc(func(ctx context.Context, name string) ([]byte, error) {
+			if fail != nil {
+				return nil, fail
+			}
+			return []byte(value), nil
+		})
+		var err error
+		secret, err = secrets.Load(context.Background(), provider, "key")
+		Ω(err).ShouldNot(HaveOccurred())
+		secret.OnRotate(func(current, previous []byte) {
+			rotated = append(rotated, []string{string(current), string(previous)})
+		})
+	})
+
+	It("holds the value of the secret", func() {
+		Ω(string(secret.Value())).Should(Equal("one"))
+		Ω(secret.Previous()).Should(BeNil())
+	})
+
+	It("calls the rotation callbacks when the value changes", func() {
+		changed, err := secret.Refresh(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(changed).Should(BeFalse())
+		Ω(rotated).Should(BeEmpty())
+
+		value = "two"
+		changed, err = secret.Refresh(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(changed).Should(BeTrue())
+		Ω(string(secret.Value())).Should(Equal("two"))
+		Ω(string(secret.Previous())).Should(Equal("one"))
+		Ω(rotated).Should(Equal([][]string{{"two", "one"}}))
+	})
+
+	It("keeps the current value when the provider fails", func() {
+		fail = errors.New("unavailable")
+		_, err := secret.Refresh(context.Background())
+		Ω(err).Should(Equal(fail))
+		Ω(string(secret.Value())).Should(Equal("one"))
+	})
+
+	It("refreshes the value periodically", func() {
+		clock := goatest.NewClock(time.Unix(0, 0))
+		secret.Clock = clock
+		var errs []error
+		stop := secret.Watch(time.Minute, func(err error) { errs = append(errs, err) })
+		value = "two"
+		clock.Advance(time.Minute)
+		Ω(string(secret.Value())).Should(Equal("two"))
+		fail = errors.New("unavailable")
+		clock.Advance(time.Minute)
+		Ω(errs).Should(HaveLen(1))
+		stop()
+		fail = nil
+		value = "three"
+		clock.Advance(time.Minute)
+		Ω(string(secret.Value())).Should(Equal("two"))
+		Ω(rotated).Should(HaveLen(1))
+	})
+})
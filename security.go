@@ -12,6 +12,10 @@ const LocHeader Location = "header"
 // LocQuery indicates the secret value should be loaded from the request URL querystring.
 const LocQuery Location = "query"
 
+// DefaultScopesClaim is the name of the JWT claim that lists the scopes granted to the token
+// unless the security scheme specifies otherwise.
+const DefaultScopesClaim = "scopes"
+
 // ContextRequiredScopes extracts the security scopes from the given context.
 // This should be used in auth handlers to validate that the required scopes are present in the
 // JWT or OAuth2 token.
@@ -27,6 +31,27 @@ func WithRequiredScopes(ctx context.Context, scopes []string) context.Context {
 	return context.WithValue(ctx, securityScopesKey, scopes)
 }
 
+// CheckScopes checks that the scopes granted to the request credentials include all the scopes
+// required by the action, see ContextRequiredScopes. It returns an error of class
+// ErrMissingScopes listing the missing scopes in its metadata otherwise. Auth middlewares call
+// CheckScopes once the credentials are validated.
+func CheckScopes(ctx context.Context, granted []string) error {
+	has := make(map[string]bool, len(granted))
+	for _, s := range granted {
+		has[s] = true
+	}
+	var missing []string
+	for _, s := range ContextRequiredScopes(ctx) {
+		if !has[s] {
+			missing = append(missing, s)
+		}
+	}
+	if len(missing) > 0 {
+		return MissingScopesError(missing)
+	}
+	return nil
+}
+
 // OAuth2Security represents the `oauth2` security scheme. It is instantiated by the generated code
 // accordingly to the use of the different `*Security()` DSL functions and `Security()` in the
 // design.
@@ -74,4 +99,7 @@ type JWTSecurity struct {
 	TokenURL string
 	// Scopes defines a list of scopes for the security scheme, along with their description.
 	Scopes map[string]string
+	// ScopesClaim is the name of the JWT claim that lists the scopes granted to the token,
+	// DefaultScopesClaim if empty.
+	ScopesClaim string
 }
@@ -1,8 +1,11 @@
 package goa
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
@@ -10,6 +13,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"golang.org/x/net/context"
 )
@@ -34,9 +38,32 @@ type (
 		Decoder *HTTPDecoder
 		// Response body encoder
 		Encoder *HTTPEncoder
-
-		middleware []Middleware       // Middleware chain
-		cancel     context.CancelFunc // Service context cancel signal trigger
+		// Downstreams tracks the availability of the downstream services the service
+		// depends on.
+		Downstreams *DownstreamRegistry
+		// DrainLogInterval is the interval at which Shutdown logs the draining progress,
+		// defaults to DefaultDrainLogInterval.
+		DrainLogInterval time.Duration
+		// ShutdownSignals lists the signals that cause ListenAndServeGraceful to shut
+		// down the service, defaults to DefaultShutdownSignals.
+		ShutdownSignals []os.Signal
+		// Sandbox causes the generated handlers of the actions designed with the Sandbox DSL
+		// to send example responses instead of calling the controllers, see
+		// SendSandboxResponse.
+		Sandbox bool
+
+		middleware     []Middleware          // Middleware chain
+		named          map[string]Middleware // Middleware registered by name
+		cancel         context.CancelFunc    // Service context cancel signal trigger
+		codeStatuses   map[string]int        // Error response statuses indexed by error code
+		errorStatuses  map[int]int           // Error response statuses indexed by original status
+		translator     ErrorTranslator       // Error response translator if any
+		routes         []RouteInfo           // Routes mounted by the controllers
+		versions       *versionMux           // Dispatcher of versioned routes if any, see VersionMux
+		drain          *drainer              // In-flight requests and servers, see Shutdown
+		startupHooks   []LifecycleHook       // Hooks run by ListenAndServeGraceful, see OnStartup
+		shutdownHooks  []LifecycleHook       // Hooks run by Shutdown, see OnShutdown
+		incompressible map[string]bool       // Media types not worth compressing, see SetIncompressible
 	}
 
 	// Controller defines the common fields and behavior of generated controllers.
@@ -51,7 +78,9 @@ type (
 		// Set to 0 to remove the limit altogether. Defaults to 1GB.
 		MaxRequestBodyLength int64
 
-		middleware []Middleware // Controller specific middleware if any
+		middleware      []Middleware                 // Controller specific middleware if any
+		classifications map[string]map[string]string // Classified fields indexed by action name
+		bodyLimits      map[string]int64             // Request body length limits indexed by action name
 	}
 
 	// FileServer is the interface implemented by controllers that can serve static files.
@@ -60,6 +89,20 @@ type (
 		FileHandler(path, filename string) Handler
 	}
 
+	// Classifier is the interface implemented by controllers that record the classification of
+	// the action params and payload fields, see Controller.Classify.
+	Classifier interface {
+		// Classify records the classification of the fields of the given action.
+		Classify(action string, fields map[string]string)
+	}
+
+	// BodyLimiter is the interface implemented by controllers that limit the length of the
+	// request bodies of specific actions, see Controller.SetBodyLimit.
+	BodyLimiter interface {
+		// SetBodyLimit sets the maximum length of the request bodies of the given action.
+		SetBodyLimit(action string, max int64)
+	}
+
 	// Handler defines the request handler signatures.
 	Handler func(context.Context, http.ResponseWriter, *http.Request) error
 
@@ -68,6 +111,10 @@ type (
 
 	// DecodeFunc is the function that initialize the unmarshaled payload from the request body.
 	DecodeFunc func(context.Context, io.ReadCloser, interface{}) error
+
+	// ErrorTranslator is the function used to localize error responses, see
+	// Service.TranslateErrors.
+	ErrorTranslator func(context.Context, *ErrorResponse) *ErrorResponse
 )
 
 // New instantiates a service with the given name.
@@ -78,13 +125,15 @@ func New(name string) *Service {
 		cctx, cancel = context.WithCancel(ctx)
 		mux          = NewMux()
 		service      = &Service{
-			Name:    name,
-			Context: cctx,
-			Mux:     mux,
-			Decoder: NewHTTPDecoder(),
-			Encoder: NewHTTPEncoder(),
+			Name:        name,
+			Context:     cctx,
+			Mux:         mux,
+			Decoder:     NewHTTPDecoder(),
+			Encoder:     NewHTTPEncoder(),
+			Downstreams: NewDownstreamRegistry(),
 
 			cancel: cancel,
+			drain:  newDrainer(),
 		}
 		notFoundHandler Handler
 	)
@@ -129,6 +178,116 @@ func (service *Service) Use(m Middleware) {
 	service.middleware = append(service.middleware, m)
 }
 
+// RegisterMiddleware registers a middleware under the given name. Named middleware is applied to
+// the resources and actions that list the name in their design Middleware DSL by the generated
+// controller mounting code.
+func (service *Service) RegisterMiddleware(name string, m Middleware) {
+	if service.named == nil {
+		service.named = make(map[string]Middleware)
+	}
+	service.named[name] = m
+}
+
+// NamedMiddleware returns a middleware that applies the middleware registered under the given
+// names in order. The names are resolved on the first request so that middleware may be
+// registered after the controllers are mounted. Requests fail with an internal error if one of the
+// names has not been registered.
+func (service *Service) NamedMiddleware(names ...string) Middleware {
+	return func(h Handler) Handler {
+		var handler Handler
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if handler == nil {
+				chain := make([]Middleware, len(names))
+				for i, name := range names {
+					m, ok := service.named[name]
+					if !ok {
+						return ErrInternal(fmt.Sprintf("no middleware registered with name %q", name))
+					}
+					chain[i] = m
+				}
+				handler = h
+				for i := range chain {
+					handler = chain[len(chain)-i-1](handler)
+				}
+			}
+			return handler(ctx, rw, req)
+		}
+	}
+}
+
+// RemapErrorClass makes the error handler middleware respond to the errors created with the given
+// class using the given HTTP status instead of the class status. The class must have been created
+// with NewErrorClass. RemapErrorClass takes precedence over RemapErrorStatus. Example:
+//
+//	service.RemapErrorClass(goa.ErrUnauthorized, 404)
+//
+// Remappings must be configured before the service starts handling requests.
+func (service *Service) RemapErrorClass(class ErrorClass, status int) {
+	e, ok := class("").(*ErrorResponse)
+	if !ok {
+		return
+	}
+	if service.codeStatuses == nil {
+		service.codeStatuses = make(map[string]int)
+	}
+	service.codeStatuses[e.Code] = status
+}
+
+// RemapErrorStatus makes the error handler middleware respond to all the errors whose status is
+// from using the status to instead. This makes it possible to hide distinctions between statuses
+// at the edge of the service, for example:
+//
+//	service.RemapErrorStatus(403, 401) // Do not tell clients whether they are authenticated
+//	service.RemapErrorStatus(422, 400)
+//
+// Remappings must be configured before the service starts handling requests.
+func (service *Service) RemapErrorStatus(from, to int) {
+	if service.errorStatuses == nil {
+		service.errorStatuses = make(map[int]int)
+	}
+	service.errorStatuses[from] = to
+}
+
+// ErrorStatus returns the HTTP status of the response sent for the given error taking into
+// account the remappings configured with RemapErrorClass and RemapErrorStatus.
+func (service *Service) ErrorStatus(err ServiceError) int {
+	if e, ok := err.(*ErrorResponse); ok {
+		if status, ok := service.codeStatuses[e.Code]; ok {
+			return status
+		}
+	}
+	status := err.ResponseStatus()
+	if remapped, ok := service.errorStatuses[status]; ok {
+		return remapped
+	}
+	return status
+}
+
+// TranslateErrors makes the error handler middleware call the given translator on the error
+// responses prior to sending them. The translator typically localizes the error detail using the
+// locales stored in the context by the Locale middleware. The translator should not modify the
+// given error in place and return a copy instead.
+func (service *Service) TranslateErrors(t ErrorTranslator) {
+	service.translator = t
+}
+
+// TranslateError returns the error response translated using the translator configured with
+// TranslateErrors or err if there is none.
+func (service *Service) TranslateError(ctx context.Context, err *ErrorResponse) *ErrorResponse {
+	if service.translator == nil {
+		return err
+	}
+	if translated := service.translator(ctx, err); translated != nil {
+		return translated
+	}
+	return err
+}
+
+// WithClock sets the clock used by the service and its middlewares, see Clock.
+func (service *Service) WithClock(clock Clock) {
+	service.Context = WithClock(service.Context, clock)
+}
+
 // WithLogger sets the logger used internally by the service and by Log.
 func (service *Service) WithLogger(logger LogAdapter) {
 	service.Context = WithLogger(service.Context, logger)
@@ -144,16 +303,49 @@ func (service *Service) LogError(msg string, keyvals ...interface{}) {
 	LogError(service.Context, msg, keyvals...)
 }
 
-// ListenAndServe starts a HTTP server and sets up a listener on the given host/port.
+// ListenAndServe starts a HTTP server and sets up a listener on the given host/port. It returns
+// nil once Shutdown is called.
 func (service *Service) ListenAndServe(addr string) error {
 	service.LogInfo("listen", "transport", "http", "addr", addr)
-	return http.ListenAndServe(addr, service.Mux)
+	srv := &http.Server{Addr: addr, Handler: service.Mux}
+	service.drain.addServer(srv)
+	return service.serve(srv, srv.ListenAndServe)
 }
 
-// ListenAndServeTLS starts a HTTPS server and sets up a listener on the given host/port.
+// ListenAndServeTLS starts a HTTPS server and sets up a listener on the given host/port. It
+// returns nil once Shutdown is called.
 func (service *Service) ListenAndServeTLS(addr, certFile, keyFile string) error {
 	service.LogInfo("listen", "transport", "https", "addr", addr)
-	return http.ListenAndServeTLS(addr, certFile, keyFile, service.Mux)
+	srv := &http.Server{Addr: addr, Handler: service.Mux}
+	service.drain.addServer(srv)
+	return service.serve(srv, func() error { return srv.ListenAndServeTLS(certFile, keyFile) })
+}
+
+// ListenAndServeMutualTLS is like ListenAndServeTLS but also verifies the certificates presented by
+// clients against the certificate authorities in the PEM encoded clientCAFile. Clients are not
+// required to present a certificate during the TLS handshake so that the actions that do not
+// require one (see the RequireClientCert DSL) remain available to all clients. The generated
+// code applies the middleware.RequireClientCert middleware to the actions that do.
+func (service *Service) ListenAndServeMutualTLS(addr, certFile, keyFile, clientCAFile string) error {
+	pem, err := ioutil.ReadFile(clientCAFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificate found in %s", clientCAFile)
+	}
+	service.LogInfo("listen", "transport", "https", "addr", addr, "client_certs", "verify_if_given")
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: service.Mux,
+		TLSConfig: &tls.Config{
+			ClientAuth: tls.VerifyClientCertIfGiven,
+			ClientCAs:  pool,
+		},
+	}
+	service.drain.addServer(srv)
+	return service.serve(srv, func() error { return srv.ListenAndServeTLS(certFile, keyFile) })
 }
 
 // NewController returns a controller for the given resource. This method is mainly intended for
@@ -185,12 +377,16 @@ func (service *Service) ServeFiles(path, filename string) error {
 }
 
 // DecodeRequest uses the HTTP decoder to unmarshal the request body into the provided value based
-// on the request Content-Type header.
+// on the request Content-Type header. It returns an error of class ErrUnsupportedMediaType if there
+// is no decoder registered for the request Content-Type.
 func (service *Service) DecodeRequest(req *http.Request, v interface{}) error {
 	body, contentType := req.Body, req.Header.Get("Content-Type")
 	defer body.Close()
 
 	if err := service.Decoder.Decode(v, body, contentType); err != nil {
+		if _, ok := err.(ServiceError); ok {
+			return err
+		}
 		return fmt.Errorf("failed to decode request body with content type %#v: %s", contentType, err)
 	}
 
@@ -198,8 +394,12 @@ func (service *Service) DecodeRequest(req *http.Request, v interface{}) error {
 }
 
 // EncodeResponse uses the HTTP encoder to marshal and write the response body based on the request
-// Accept header.
+// Accept header. It returns an error of class ErrClientDisconnected if the client closed the
+// connection.
 func (service *Service) EncodeResponse(ctx context.Context, v interface{}) error {
+	if ContextClientDisconnected(ctx) {
+		return ErrClientDisconnected("client closed the connection")
+	}
 	accept := ContextRequest(ctx).Header.Get("Accept")
 	return service.Encoder.Encode(v, ContextResponse(ctx), accept)
 }
@@ -221,15 +421,94 @@ func (ctrl *Controller) ServeFiles(path, filename string) error {
 	return nil
 }
 
+// LoadDeferredPayload loads the body of a request whose loading was deferred by MuxHandler because
+// the client expects a 100 Continue response. Reading the body causes the HTTP server to send the
+// 100 Continue response so handlers should call LoadDeferredPayload once the request has been
+// authorized and its headers validated. LoadDeferredPayload does nothing if the request payload
+// was not deferred and returns the result of the first load on subsequent calls.
+//
+// The request context Payload field (see ContextRequest) is nil until the deferred payload is
+// loaded, middlewares that inspect the payload must call LoadDeferredPayload first.
+func LoadDeferredPayload(ctx context.Context) error {
+	d, ok := ctx.Value(deferredPayloadKey).(*deferredPayload)
+	if !ok {
+		return nil
+	}
+	if !d.loaded {
+		d.loaded = true
+		d.err = d.load()
+	}
+	return d.err
+}
+
+// deferredPayload holds the function that loads a deferred request payload and its result.
+type deferredPayload struct {
+	load   func() error
+	loaded bool
+	err    error
+}
+
+// loadPayload invokes the unmarshaler and maps the resulting error to the appropriate error class.
+// limit is the maximum length of the request body.
+func (ctrl *Controller) loadPayload(ctx context.Context, req *http.Request, unm Unmarshaler, limit int64) error {
+	err := unm(ctx, ctrl.Service, req)
+	if err == nil {
+		return nil
+	}
+	if err.Error() == "http: request body too large" {
+		return bodyTooLarge(limit)
+	}
+	if _, ok := err.(ServiceError); !ok {
+		// Keep validation errors as is so they can be merged with the parameter
+		// validation errors.
+		return ErrBadRequest(err)
+	}
+	return err
+}
+
+// bodyTooLarge returns the error produced when the length of a request body exceeds limit bytes.
+func bodyTooLarge(limit int64) error {
+	return ErrRequestBodyTooLarge(fmt.Sprintf("request body length exceeds %d bytes", limit), "limit", limit)
+}
+
 // Use adds a middleware to the controller.
 // Service-wide middleware should be added via the Service Use method instead.
 func (ctrl *Controller) Use(m Middleware) {
 	ctrl.middleware = append(ctrl.middleware, m)
 }
 
+// Classify records the classification of the params and payload fields of the action with the
+// given name. fields maps the field names to their classification, the names of the payload
+// fields nested in objects are the dot separated names of the enclosing fields, e.g.
+// "address.phone". The classifications are made available to the request context so that
+// middleware such as LogRequest can redact the values, see ContextClassification. The generated
+// controller mounting code calls Classify for the actions whose design define classified
+// attributes.
+func (ctrl *Controller) Classify(action string, fields map[string]string) {
+	if ctrl.classifications == nil {
+		ctrl.classifications = make(map[string]map[string]string)
+	}
+	ctrl.classifications[action] = fields
+}
+
+// SetBodyLimit sets the maximum length of the request bodies of the action with the given name,
+// overriding MaxRequestBodyLength. A limit of 0 removes the limit altogether. Requests whose
+// body exceeds the limit fail with an error of class ErrRequestBodyTooLarge whose "limit" meta
+// value is the limit. The generated controller mounting code calls SetBodyLimit for the actions
+// whose design define a body limit, see the BodyLimit DSL.
+func (ctrl *Controller) SetBodyLimit(action string, max int64) {
+	if ctrl.bodyLimits == nil {
+		ctrl.bodyLimits = make(map[string]int64)
+	}
+	ctrl.bodyLimits[action] = max
+}
+
 // MuxHandler wraps a request handler into a MuxHandler. The MuxHandler initializes the request
 // context by loading the request state, invokes the handler and in case of error invokes the
 // controller (if there is one) or Service error handler.
+// Loading the body of requests that include the "Expect: 100-continue" header is deferred until
+// the handler calls LoadDeferredPayload so that the client does not get a 100 Continue response
+// and upload the body of a request that the middleware or the header validation rejects.
 // This function is intended for the controller generated code. User code should not need to call
 // it directly.
 func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) MuxHandler {
@@ -253,29 +532,60 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 			}
 		}
 
-		// Build context
-		ctx := NewContext(WithAction(ctrl.Context, name), rw, req, params)
+		// Build context, cancel it if the client disconnects
+		ctx, cancel := withDisconnect(WithAction(ctrl.Context, name), req)
+		defer cancel()
+		ctx = NewContext(ctx, rw, req, params)
+		timings := NewTimings(ContextClock(ctx))
+		ctx = WithTimings(ctx, timings)
+		ContextResponse(ctx).timings = timings
+		ContextResponse(ctx).Service = ctrl.Service
+		if fields, ok := ctrl.classifications[name]; ok {
+			ctx = WithClassifications(ctx, fields)
+		}
+		if ctrl.Service != nil && ctrl.Service.drain != nil {
+			defer ctrl.Service.drain.track(ctrl.Name, name, req, ContextClock(ctx).Now())()
+		}
 
 		// Protect against request bodies with unreasonable length
-		if ctrl.MaxRequestBodyLength > 0 {
-			req.Body = http.MaxBytesReader(rw, req.Body, ctrl.MaxRequestBodyLength)
+		limit := ctrl.MaxRequestBodyLength
+		if l, ok := ctrl.bodyLimits[name]; ok {
+			limit = l
+		}
+		if limit > 0 {
+			req.Body = http.MaxBytesReader(rw, req.Body, limit)
 		}
 
 		// Load body if any
-		if req.ContentLength > 0 && unm != nil {
-			if err := unm(ctx, ctrl.Service, req); err != nil {
-				if err.Error() == "http: request body too large" {
-					msg := fmt.Sprintf("request body length exceeds %d bytes", ctrl.MaxRequestBodyLength)
-					err = ErrRequestBodyTooLarge(msg)
-				} else {
-					err = ErrBadRequest(err)
+		if limit > 0 && req.ContentLength > limit {
+			// No need to read the body to know it is too large
+			ctx = WithError(ctx, bodyTooLarge(limit))
+		} else if req.ContentLength > 0 && unm != nil {
+			if strings.EqualFold(req.Header.Get("Expect"), "100-continue") {
+				ctx = context.WithValue(ctx, deferredPayloadKey, &deferredPayload{
+					load: func() error {
+						defer StartPhase(ctx, PhaseDecode)()
+						return ctrl.loadPayload(ctx, req, unm, limit)
+					},
+				})
+			} else {
+				endDecode := StartPhase(ctx, PhaseDecode)
+				err := ctrl.loadPayload(ctx, req, unm, limit)
+				endDecode()
+				if err != nil {
+					ctx = WithError(ctx, err)
 				}
-				ctx = WithError(ctx, err)
 			}
 		}
 
 		// Invoke handler
-		if err := handler(ctx, ContextResponse(ctx), req); err != nil {
+		err := handler(ctx, ContextResponse(ctx), req)
+		if ContextClientDisconnected(ctx) {
+			// Not an internal error, there is no one to send the response to anyway
+			reportDisconnect(ctx, err)
+			return
+		}
+		if err != nil {
 			LogError(ctx, "uncaught error", "err", err)
 			respBody := fmt.Sprintf("Internal error: %s", err) // Sprintf catches panics
 			ctrl.Service.Send(ctx, 500, respBody)
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"time"
 
 	"golang.org/x/net/context"
 
@@ -115,6 +116,111 @@ var _ = Describe("Service", func() {
 		})
 	})
 
+	Describe("SetBodyLimit", func() {
+		var limit int64
+		var loadErr error
+
+		BeforeEach(func() {
+			limit = 10
+			loadErr = nil
+		})
+
+		JustBeforeEach(func() {
+			body := bytes.NewBuffer([]byte{'"', '2', '3', '4', '"'})
+			req, _ := http.NewRequest("POST", "/foo", body)
+			rw := &TestResponseWriter{ParentHeader: make(http.Header)}
+			ctrl := s.NewController("test")
+			ctrl.MaxRequestBodyLength = 4
+			ctrl.SetBodyLimit("upload", limit)
+			unmarshaler := func(ctx context.Context, service *goa.Service, req *http.Request) error {
+				_, err := ioutil.ReadAll(req.Body)
+				return err
+			}
+			handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				loadErr = goa.ContextError(ctx)
+				return nil
+			}
+			ctrl.MuxHandler("upload", handler, unmarshaler)(rw, req, nil)
+		})
+
+		It("overrides MaxRequestBodyLength", func() {
+			Ω(loadErr).ShouldNot(HaveOccurred())
+		})
+
+		Context("with a body exceeding the limit", func() {
+			BeforeEach(func() {
+				limit = 2
+			})
+
+			It("returns the limit in the error meta", func() {
+				Ω(loadErr).Should(HaveOccurred())
+				serr, ok := loadErr.(*goa.ErrorResponse)
+				Ω(ok).Should(BeTrue())
+				Ω(serr.Status).Should(Equal(413))
+				Ω(serr.MetaMap()).Should(HaveKeyWithValue("limit", int64(2)))
+			})
+		})
+	})
+
+	Describe("SetIncompressible", func() {
+		BeforeEach(func() {
+			s.SetIncompressible("image/png", "Application/Zip")
+		})
+
+		It("makes the media types incompressible", func() {
+			Ω(s.Compressible("image/png")).Should(BeFalse())
+			Ω(s.Compressible("application/zip; name=export.zip")).Should(BeFalse())
+			Ω(s.Compressible("application/json")).Should(BeTrue())
+			Ω(s.Compressible("")).Should(BeTrue())
+		})
+	})
+
+	Describe("NamedMiddleware", func() {
+		var handlerCalled bool
+		var handler goa.Handler
+		var err error
+
+		BeforeEach(func() {
+			handlerCalled = false
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				handlerCalled = true
+				return nil
+			}
+		})
+
+		Context("with registered middleware", func() {
+			var firstCalled, secondCalled bool
+
+			BeforeEach(func() {
+				firstCalled, secondCalled = false, false
+				h := s.NamedMiddleware("first", "second")(handler)
+				// Registering after the middleware is built is supported
+				s.RegisterMiddleware("first", TMiddleware(&firstCalled))
+				s.RegisterMiddleware("second", SecondMiddleware(&firstCalled, &secondCalled))
+				err = h(context.Background(), nil, nil)
+			})
+
+			It("applies the middleware in order", func() {
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(firstCalled).Should(BeTrue())
+				Ω(secondCalled).Should(BeTrue())
+				Ω(handlerCalled).Should(BeTrue())
+			})
+		})
+
+		Context("with unknown middleware", func() {
+			BeforeEach(func() {
+				err = s.NamedMiddleware("unknown")(handler)(context.Background(), nil, nil)
+			})
+
+			It("returns an internal error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.Error()).Should(ContainSubstring(`no middleware registered with name "unknown"`))
+				Ω(handlerCalled).Should(BeFalse())
+			})
+		})
+	})
+
 	Describe("MuxHandler", func() {
 		var handler goa.Handler
 		var unmarshaler goa.Unmarshaler
@@ -209,6 +315,23 @@ var _ = Describe("Service", func() {
 				})
 			})
 
+			Context("with a request that expects a 100 Continue response", func() {
+				BeforeEach(func() {
+					r.Header.Set("Expect", "100-continue")
+					r.Body = ioutil.NopCloser(bytes.NewBuffer([]byte("not json")))
+					r.ContentLength = 8
+				})
+
+				It("defers loading the payload", func() {
+					Ω(rw.(*TestResponseWriter).Status).Should(Equal(respStatus))
+					Ω(goa.ContextRequest(ctx).Payload).Should(BeNil())
+					err := goa.LoadDeferredPayload(ctx)
+					Ω(err).Should(HaveOccurred())
+					Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(400))
+					Ω(goa.LoadDeferredPayload(ctx)).Should(Equal(err))
+				})
+			})
+
 			Context("and middleware", func() {
 				middlewareCalled := false
 
@@ -257,6 +380,49 @@ var _ = Describe("Service", func() {
 				})
 			})
 
+			Context("with a client that disconnects", func() {
+				var encodeErr error
+
+				BeforeEach(func() {
+					reqCtx, disconnect := context.WithCancel(context.Background())
+					r = r.WithContext(reqCtx)
+					handler = func(c context.Context, rw http.ResponseWriter, req *http.Request) error {
+						disconnect()
+						<-c.Done()
+						Ω(goa.ContextClientDisconnected(c)).Should(BeTrue())
+						encodeErr = s.EncodeResponse(c, "response")
+						return encodeErr
+					}
+					s.WithLogger(nil)
+				})
+
+				It("cancels the context and does not write a response", func() {
+					Ω(encodeErr).Should(HaveOccurred())
+					Ω(encodeErr.(*goa.ErrorResponse).Code).Should(Equal("client_disconnected"))
+					Ω(rw.(*TestResponseWriter).Status).Should(Equal(0))
+					Ω(rw.(*TestResponseWriter).Body).Should(BeEmpty())
+				})
+			})
+
+			Context("with a request that times out upstream", func() {
+				var disconnected bool
+
+				BeforeEach(func() {
+					reqCtx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+					r = r.WithContext(reqCtx)
+					handler = func(c context.Context, rw http.ResponseWriter, req *http.Request) error {
+						defer cancel()
+						<-c.Done()
+						disconnected = goa.ContextClientDisconnected(c)
+						return nil
+					}
+				})
+
+				It("cancels the context without reporting a client disconnect", func() {
+					Ω(disconnected).Should(BeFalse())
+				})
+			})
+
 			Context("with different payload types", func() {
 				content := []byte(`{"hello": "world"}`)
 				decodedContent := map[string]interface{}{"hello": "world"}
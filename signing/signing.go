@@ -0,0 +1,387 @@
+/*
+Package signing signs and encrypts values that make a round trip through clients: cookies, OAuth2
+state parameters, signed URLs etc. Values are protected with the keys of a Keyring: the current key
+signs and encrypts new values while the previous keys still verify and decrypt the values they
+produced so that keys may be rotated without invalidating the values held by clients:
+
+	keyring, err := signing.NewKeyring(signing.Key{ID: "2024-01", Secret: secret})
+	token := keyring.Sign([]byte("state"), 10*time.Minute)
+	value, err := keyring.Verify(token)
+
+Signed values are readable by clients, use Encrypt and Decrypt for values that must remain
+confidential. Values may expire, Verify and Decrypt fail once expired.
+*/
+package signing
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/secrets"
+)
+
+// MinSecretLength is the minimum length of the key secrets.
+const MinSecretLength = 32
+
+var (
+	// ErrInvalid is the error returned when a value was not produced by one of the keys of
+	// the keyring or was tampered with.
+	ErrInvalid = errors.New("invalid signed value")
+
+	// ErrExpired is the error returned when a value is valid but expired.
+	ErrExpired = errors.New("signed value expired")
+)
+
+var encoding = base64.RawURLEncoding
+
+type (
+	// Key is a secret used to sign and encrypt values.
+	Key struct {
+		// ID identifies the key in the values it produces, it may not contain dots.
+		ID string
+		// Secret is the key material, at least MinSecretLength random bytes.
+		Secret []byte
+	}
+
+	// Keyring signs and encrypts values with its current key and verifies and decrypts values
+	// with any of its keys. Keyring is safe for concurrent use.
+	Keyring struct {
+		// Clock is used to compute and check the expiry of values, defaults to
+		// goa.SystemClock.
+		Clock goa.Clock
+
+		mu   sync.RWMutex
+		keys []*derivedKey // current key first
+	}
+
+	// derivedKey holds the signing and encryption keys derived from the secret of a Key.
+	derivedKey struct {
+		id   string
+		mac  []byte
+		aead cipher.AEAD
+	}
+)
+
+// NewKeyring returns a keyring whose current key is the first given key. The other keys are only
+// used to verify and decrypt values.
+func NewKeyring(current Key, previous ...Key) (*Keyring, error) {
+	k := &Keyring{Clock: goa.SystemClock}
+	for _, key := range append([]Key{current}, previous...) {
+		d, err := derive(key)
+		if err != nil {
+			return nil, err
+		}
+		if k.find(d.id) != nil {
+			return nil, fmt.Errorf("duplicate key ID %#v", d.id)
+		}
+		k.keys = append(k.keys, d)
+	}
+	return k, nil
+}
+
+// NewKeyringFromSecret returns a keyring whose current key is the value of secret. The keyring
+// rotates its key when the value of the secret changes, it keeps the key of the previous value so
+// that the values it produced remain valid until the next rotation. The key IDs are derived from
+// the secret values. Rotations to values shorter than MinSecretLength are ignored.
+func NewKeyringFromSecret(secret *secrets.Secret) (*Keyring, error) {
+	k, err := NewKeyring(secretKey(secret.Value()))
+	if err != nil {
+		return nil, err
+	}
+	secret.OnRotate(func(current, _ []byte) {
+		if err := k.Rotate(secretKey(current)); err != nil {
+			return
+		}
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		if len(k.keys) > 2 {
+			k.keys = k.keys[:2]
+		}
+	})
+	return k, nil
+}
+
+// Rotate makes key the current key, the previous keys keep verifying and decrypting the values
+// they produced until they are retired.
+func (k *Keyring) Rotate(key Key) error {
+	d, err := derive(key)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.find(d.id) != nil {
+		return fmt.Errorf("duplicate key ID %#v", d.id)
+	}
+	k.keys = append([]*derivedKey{d}, k.keys...)
+	return nil
+}
+
+// Retire removes the key with the given ID, the values it produced become invalid. The current key
+// cannot be retired.
+func (k *Keyring) Retire(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.keys[0].id == id {
+		return fmt.Errorf("cannot retire current key %#v", id)
+	}
+	for i, d := range k.keys {
+		if d.id == id {
+			k.keys = append(k.keys[:i], k.keys[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown key ID %#v", id)
+}
+
+// Sign returns a token containing value and its signature. The value is readable by anyone
+// holding the token. The token never expires if ttl is zero.
+func (k *Keyring) Sign(value []byte, ttl time.Duration) string {
+	return k.sign("", value, ttl)
+}
+
+// Verify checks the signature and expiry of a token produced by Sign and returns its value.
+func (k *Keyring) Verify(token string) ([]byte, error) {
+	return k.verify("", token)
+}
+
+// Encrypt returns a token containing the encrypted value. The token never expires if ttl is zero.
+func (k *Keyring) Encrypt(value []byte, ttl time.Duration) (string, error) {
+	return k.encrypt("", value, ttl)
+}
+
+// Decrypt checks the integrity and expiry of a token produced by Encrypt and returns its value.
+func (k *Keyring) Decrypt(token string) ([]byte, error) {
+	return k.decrypt("", token)
+}
+
+// EncryptCookie replaces the value of the cookie with its encrypted value. The encrypted value is
+// bound to the cookie name so that it cannot be used as the value of another cookie. It expires
+// with the cookie if MaxAge or Expires is set.
+func (k *Keyring) EncryptCookie(c *http.Cookie) error {
+	var ttl time.Duration
+	if c.MaxAge > 0 {
+		ttl = time.Duration(c.MaxAge) * time.Second
+	} else if !c.Expires.IsZero() {
+		if ttl = c.Expires.Sub(k.now()); ttl <= 0 {
+			ttl = time.Nanosecond
+		}
+	}
+	token, err := k.encrypt("cookie:"+c.Name, []byte(c.Value), ttl)
+	if err != nil {
+		return err
+	}
+	c.Value = token
+	return nil
+}
+
+// DecryptCookie returns the value of a cookie encrypted with EncryptCookie.
+func (k *Keyring) DecryptCookie(c *http.Cookie) (string, error) {
+	value, err := k.decrypt("cookie:"+c.Name, c.Value)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// SignURL adds a "signature" query string parameter to u that covers its path and query string.
+// The URL never expires if ttl is zero.
+func (k *Keyring) SignURL(u *url.URL, ttl time.Duration) {
+	q := u.Query()
+	q.Del("signature")
+	u.RawQuery = q.Encode()
+	q.Set("signature", k.sign("url:"+u.EscapedPath()+"?"+u.RawQuery, nil, ttl))
+	u.RawQuery = q.Encode()
+}
+
+// VerifyURL checks the signature of a URL signed with SignURL.
+func (k *Keyring) VerifyURL(u *url.URL) error {
+	q := u.Query()
+	token := q.Get("signature")
+	if token == "" {
+		return ErrInvalid
+	}
+	q.Del("signature")
+	_, err := k.verify("url:"+u.EscapedPath()+"?"+q.Encode(), token)
+	return err
+}
+
+// sign produces "<key ID>.<expiry>.<value>.<signature>" where the signature covers the purpose and
+// the other fields.
+func (k *Keyring) sign(purpose string, value []byte, ttl time.Duration) string {
+	d := k.current()
+	payload := d.id + "." + k.expiry(ttl) + "." + encoding.EncodeToString(value)
+	return payload + "." + encoding.EncodeToString(d.sign(purpose, payload))
+}
+
+// verify checks a token produced by sign with the same purpose and returns its value.
+func (k *Keyring) verify(purpose, token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return nil, ErrInvalid
+	}
+	d := k.key(parts[0])
+	if d == nil {
+		return nil, ErrInvalid
+	}
+	sig, err := encoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if !hmac.Equal(sig, d.sign(purpose, strings.Join(parts[:3], "."))) {
+		return nil, ErrInvalid
+	}
+	if err := k.checkExpiry(parts[1]); err != nil {
+		return nil, err
+	}
+	value, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	return value, nil
+}
+
+// encrypt produces "<key ID>.<expiry>.<nonce and ciphertext>" where the purpose, key ID and
+// expiry are authenticated with the ciphertext.
+func (k *Keyring) encrypt(purpose string, value []byte, ttl time.Duration) (string, error) {
+	d := k.current()
+	header := d.id + "." + k.expiry(ttl)
+	nonce := make([]byte, d.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := d.aead.Seal(nonce, nonce, value, []byte(purpose+"\x00"+header))
+	return header + "." + encoding.EncodeToString(sealed), nil
+}
+
+// decrypt decrypts a token produced by encrypt with the same purpose.
+func (k *Keyring) decrypt(purpose, token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalid
+	}
+	d := k.key(parts[0])
+	if d == nil {
+		return nil, ErrInvalid
+	}
+	sealed, err := encoding.DecodeString(parts[2])
+	if err != nil || len(sealed) < d.aead.NonceSize() {
+		return nil, ErrInvalid
+	}
+	n := d.aead.NonceSize()
+	header := parts[0] + "." + parts[1]
+	value, err := d.aead.Open(nil, sealed[:n], sealed[n:], []byte(purpose+"\x00"+header))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if err := k.checkExpiry(parts[1]); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// expiry returns the encoded expiry of a value produced now, empty if ttl is zero.
+func (k *Keyring) expiry(ttl time.Duration) string {
+	if ttl <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(k.now().Add(ttl).Unix(), 10)
+}
+
+// checkExpiry returns ErrExpired if the encoded expiry is in the past.
+func (k *Keyring) checkExpiry(exp string) error {
+	if exp == "" {
+		return nil
+	}
+	ts, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return ErrInvalid
+	}
+	if k.now().Unix() >= ts {
+		return ErrExpired
+	}
+	return nil
+}
+
+// now returns the current time using the keyring clock.
+func (k *Keyring) now() time.Time {
+	if k.Clock == nil {
+		return time.Now()
+	}
+	return k.Clock.Now()
+}
+
+// current returns the current key.
+func (k *Keyring) current() *derivedKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[0]
+}
+
+// key returns the key with the given ID, nil if there is none.
+func (k *Keyring) key(id string) *derivedKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.find(id)
+}
+
+// find returns the key with the given ID, nil if there is none. The caller must hold the lock.
+func (k *Keyring) find(id string) *derivedKey {
+	for _, d := range k.keys {
+		if d.id == id {
+			return d
+		}
+	}
+	return nil
+}
+
+// secretKey returns the key whose secret is the given value of a secrets.Secret.
+func secretKey(value []byte) Key {
+	sum := sha256.Sum256(value)
+	return Key{ID: hex.EncodeToString(sum[:6]), Secret: value}
+}
+
+// derive computes the signing and encryption keys of key.
+func derive(key Key) (*derivedKey, error) {
+	if key.ID == "" || strings.Contains(key.ID, ".") {
+		return nil, fmt.Errorf("invalid key ID %#v, must be non empty and may not contain dots", key.ID)
+	}
+	if len(key.Secret) < MinSecretLength {
+		return nil, fmt.Errorf("secret of key %#v is too short, must be at least %d bytes", key.ID, MinSecretLength)
+	}
+	block, err := aes.NewCipher(hmacSum(key.Secret, "goa signing encryption key"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &derivedKey{id: key.ID, mac: hmacSum(key.Secret, "goa signing mac key"), aead: aead}, nil
+}
+
+// sign returns the signature of payload for the given purpose.
+func (d *derivedKey) sign(purpose, payload string) []byte {
+	return hmacSum(d.mac, purpose+"\x00"+payload)
+}
+
+// hmacSum returns the HMAC-SHA256 of data using key.
+func hmacSum(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
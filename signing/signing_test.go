@@ -0,0 +1,159 @@
+package signing_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa/goatest"
+	"github.com/goadesign/goa/secrets"
+	"github.com/goadesign/goa/signing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Keyring", func() {
+	var (
+		oldKey  = signing.Key{ID: "old", Secret: bytes.Repeat([]byte{1}, 32)}
+		newKey  = signing.Key{ID: "new", Secret: bytes.Repeat([]byte{2}, 32)}
+		clock   *goatest.Clock
+		keyring *signing.Keyring
+	)
+
+	BeforeEach(func() {
+		var err error
+		keyring, err = signing.NewKeyring(oldKey)
+		Ω(err).ShouldNot(HaveOccurred())
+		clock = goatest.NewClock(time.Unix(1500000000, 0))
+		keyring.Clock = clock
+	})
+
+	It("rejects short secrets", func() {
+		_, err := signing.NewKeyring(signing.Key{ID: "short", Secret: []byte("secret")})
+		Ω(err).Should(HaveOccurred())
+	})
+
+	Describe("Sign", func() {
+		It("produces tokens that verify", func() {
+			token := keyring.Sign([]byte("state"), 0)
+			value, err := keyring.Verify(token)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(value)).Should(Equal("state"))
+		})
+
+		It("detects tampering", func() {
+			token := keyring.Sign([]byte("state"), 0)
+			tampered := strings.Replace(token, base64.RawURLEncoding.EncodeToString([]byte("state")), base64.RawURLEncoding.EncodeToString([]byte("other")), 1)
+			_, err := keyring.Verify(tampered)
+			Ω(err).Should(Equal(signing.ErrInvalid))
+		})
+
+		It("expires tokens", func() {
+			token := keyring.Sign([]byte("state"), time.Minute)
+			_, err := keyring.Verify(token)
+			Ω(err).ShouldNot(HaveOccurred())
+			clock.Advance(time.Minute)
+			_, err = keyring.Verify(token)
+			Ω(err).Should(Equal(signing.ErrExpired))
+		})
+	})
+
+	Describe("Encrypt", func() {
+		It("produces tokens that decrypt", func() {
+			token, err := keyring.Encrypt([]byte("secret"), 0)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(token).ShouldNot(ContainSubstring("secret"))
+			value, err := keyring.Decrypt(token)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(value)).Should(Equal("secret"))
+		})
+
+		It("does not decrypt signed tokens", func() {
+			_, err := keyring.Decrypt(keyring.Sign([]byte("state"), 0))
+			Ω(err).Should(Equal(signing.ErrInvalid))
+		})
+	})
+
+	Describe("Rotate", func() {
+		It("keeps accepting the values produced by the previous keys", func() {
+			token := keyring.Sign([]byte("state"), 0)
+			Ω(keyring.Rotate(newKey)).Should(Succeed())
+			rotated := keyring.Sign([]byte("state"), 0)
+			Ω(rotated).Should(HavePrefix("new."))
+			_, err := keyring.Verify(token)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(keyring.Retire("old")).Should(Succeed())
+			_, err = keyring.Verify(token)
+			Ω(err).Should(Equal(signing.ErrInvalid))
+			_, err = keyring.Verify(rotated)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("does not retire the current key", func() {
+			Ω(keyring.Retire("old")).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("EncryptCookie", func() {
+		It("binds the value to the cookie name", func() {
+			c := &http.Cookie{Name: "session", Value: "42", MaxAge: 60}
+			Ω(keyring.EncryptCookie(c)).Should(Succeed())
+			value, err := keyring.DecryptCookie(c)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(value).Should(Equal("42"))
+			_, err = keyring.DecryptCookie(&http.Cookie{Name: "other", Value: c.Value})
+			Ω(err).Should(Equal(signing.ErrInvalid))
+			clock.Advance(time.Minute)
+			_, err = keyring.DecryptCookie(c)
+			Ω(err).Should(Equal(signing.ErrExpired))
+		})
+	})
+
+	Describe("SignURL", func() {
+		It("signs the path and query string", func() {
+			u, _ := url.Parse("https://example.com/downloads/report.pdf?user=1")
+			keyring.SignURL(u, time.Hour)
+			Ω(u.Query().Get("signature")).ShouldNot(BeEmpty())
+			signed, _ := url.Parse(u.String())
+			Ω(keyring.VerifyURL(signed)).Should(Succeed())
+			q := signed.Query()
+			q.Set("user", "2")
+			signed.RawQuery = q.Encode()
+			Ω(keyring.VerifyURL(signed)).Should(Equal(signing.ErrInvalid))
+		})
+	})
+})
+
+var _ = Describe("NewKeyringFromSecret", func() {
+	It("rotates the key when the secret changes", func() {
+		value := bytes.Repeat([]byte{1}, 32)
+		provider := secrets.ProviderFunc(func(ctx context.Context, name string) ([]byte, error) {
+			return value, nil
+		})
+		secret, err := secrets.Load(context.Background(), provider, "signing")
+		Ω(err).ShouldNot(HaveOccurred())
+		keyring, err := signing.NewKeyringFromSecret(secret)
+		Ω(err).ShouldNot(HaveOccurred())
+		first := keyring.Sign([]byte("state"), 0)
+
+		value = bytes.Repeat([]byte{2}, 32)
+		_, err = secret.Refresh(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+		second := keyring.Sign([]byte("state"), 0)
+		Ω(strings.Split(second, ".")[0]).ShouldNot(Equal(strings.Split(first, ".")[0]))
+		_, err = keyring.Verify(first)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		value = bytes.Repeat([]byte{3}, 32)
+		_, err = secret.Refresh(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = keyring.Verify(second)
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = keyring.Verify(first)
+		Ω(err).Should(Equal(signing.ErrInvalid))
+	})
+})
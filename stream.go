@@ -0,0 +1,306 @@
+package goa
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// StreamOptions configures how a StreamWriter deals with slow clients.
+	StreamOptions struct {
+		// WriteTimeout is the maximum duration a write may block waiting for the client to
+		// catch up. The stream is aborted with an error of class ErrSlowClient when the
+		// timeout elapses. Zero means no timeout.
+		WriteTimeout time.Duration
+		// MaxBuffered is the maximum number of bytes buffered before writes block waiting for
+		// the client to catch up. Zero means DefaultStreamBuffer.
+		MaxBuffered int
+	}
+
+	// StreamWriter writes streamed responses (server sent events, NDJSON etc.) asynchronously
+	// so that a handler producing data is not blocked by each individual write to the client.
+	// The amount of data buffered is bounded: once MaxBuffered bytes are pending Write blocks
+	// until the client catches up (backpressure) or until WriteTimeout elapses in which case
+	// the stream is aborted. This prevents a single slow consumer from pinning server memory.
+	// The data is flushed to the client after each write if the underlying writer implements
+	// http.Flusher.
+	StreamWriter struct {
+		w     io.Writer
+		opts  StreamOptions
+		clock Clock
+
+		mu      sync.Mutex
+		cond    *sync.Cond
+		chunks  [][]byte
+		size    int
+		err     error
+		closed  bool
+		done    chan struct{} // closed when the writer goroutine exits
+		timeout Timer
+	}
+
+	// NDJSONEncoder writes values to a stream as newline delimited JSON, one value per line.
+	// The data is flushed to the client after each value if the underlying writer implements
+	// http.Flusher.
+	NDJSONEncoder struct {
+		w   io.Writer
+		enc *json.Encoder
+	}
+
+	// ItemIterator is the interface implemented by the sources of the items written by
+	// StreamNDJSON, e.g. database cursors. Iterators that implement io.Closer are closed once
+	// the stream ends.
+	ItemIterator interface {
+		// Next returns the next item or io.EOF if there are no more items.
+		Next() (interface{}, error)
+	}
+
+	// ItemIteratorFunc is a function that implements ItemIterator.
+	ItemIteratorFunc func() (interface{}, error)
+)
+
+// DefaultStreamBuffer is the default maximum number of bytes buffered by a StreamWriter.
+const DefaultStreamBuffer = 64 * 1024
+
+// NDJSONContentType is the Content-Type header value of newline delimited JSON streams.
+const NDJSONContentType = "application/x-ndjson"
+
+// ErrSlowClient is the class of errors returned by StreamWriter when a client does not consume
+// the streamed response fast enough.
+var ErrSlowClient = NewErrorClass("slow_client", 503)
+
+// NewStreamWriter creates a stream writer that writes to w. The stream timers use the clock
+// stored in ctx, see WithClock. Close must be called once the handler is done writing.
+func NewStreamWriter(ctx context.Context, w io.Writer, opts StreamOptions) *StreamWriter {
+	if opts.MaxBuffered <= 0 {
+		opts.MaxBuffered = DefaultStreamBuffer
+	}
+	s := &StreamWriter{
+		w:     w,
+		opts:  opts,
+		clock: ContextClock(ctx),
+		done:  make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	go s.run()
+	return s
+}
+
+// Write buffers p and returns once it is queued for writing. Write blocks while the buffer is
+// full. It returns an error if the stream was aborted either because writing to the client
+// failed or because the client was too slow.
+func (s *StreamWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if s.size > 0 && s.size+len(p) > s.opts.MaxBuffered && s.err == nil {
+		if s.opts.WriteTimeout > 0 {
+			timer := s.clock.AfterFunc(s.opts.WriteTimeout, s.expire)
+			defer timer.Stop()
+		}
+		for s.size > 0 && s.size+len(p) > s.opts.MaxBuffered && s.err == nil {
+			s.cond.Wait()
+		}
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	chunk := make([]byte, len(p))
+	copy(chunk, p)
+	s.chunks = append(s.chunks, chunk)
+	s.size += len(p)
+	s.cond.Broadcast()
+	return len(p), nil
+}
+
+// Buffered returns the number of bytes waiting to be written to the client.
+func (s *StreamWriter) Buffered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// Close waits for the buffered data to be written and returns the error that aborted the stream
+// if any. The wait is bounded by WriteTimeout: once it elapses the write blocked on the client is
+// interrupted by closing the connection, see interrupt. Close always returns after the last write
+// to the underlying writer so that the writer may be safely reused or released afterwards.
+func (s *StreamWriter) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	if s.opts.WriteTimeout > 0 && s.size > 0 {
+		s.timeout = s.clock.AfterFunc(s.opts.WriteTimeout, s.expire)
+	}
+	s.cond.Broadcast()
+	s.mu.Unlock()
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timeout != nil {
+		s.timeout.Stop()
+	}
+	return s.err
+}
+
+// run writes the buffered chunks to the underlying writer.
+func (s *StreamWriter) run() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		for len(s.chunks) == 0 && !s.closed && s.err == nil {
+			s.cond.Wait()
+		}
+		if s.err != nil || len(s.chunks) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		chunk := s.chunks[0]
+		s.chunks = s.chunks[1:]
+		s.mu.Unlock()
+
+		_, err := s.w.Write(chunk)
+		if err == nil {
+			flush(s.w)
+		}
+
+		s.mu.Lock()
+		s.size -= len(chunk)
+		if err != nil && s.err == nil {
+			s.err = err
+		}
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}
+}
+
+// expire aborts the stream because the client is too slow.
+func (s *StreamWriter) expire() {
+	s.mu.Lock()
+	aborted := s.err == nil
+	if aborted {
+		s.err = ErrSlowClient(fmt.Sprintf("client did not consume the response within %s", s.opts.WriteTimeout))
+		for _, c := range s.chunks {
+			s.size -= len(c)
+		}
+		s.chunks = nil
+	}
+	s.cond.Broadcast()
+	s.mu.Unlock()
+	if aborted {
+		interrupt(s.w)
+	}
+}
+
+// interrupt unblocks a write to w waiting on a slow client. It sets a write deadline in the
+// past if w supports it (e.g. net.Conn), hijacks and closes the connection if w is a
+// http.Hijacker or closes w if it is an io.Closer. A write blocked on a writer that supports
+// none of these returns once the server write timeout elapses.
+func interrupt(w io.Writer) {
+	if rd, ok := w.(*ResponseData); ok {
+		w = rd.ResponseWriter
+	}
+	if d, ok := w.(interface {
+		SetWriteDeadline(time.Time) error
+	}); ok {
+		d.SetWriteDeadline(time.Unix(1, 0))
+		return
+	}
+	if h, ok := w.(http.Hijacker); ok {
+		if conn, _, err := h.Hijack(); err == nil {
+			conn.Close()
+		}
+		return
+	}
+	if c, ok := w.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// NewNDJSONEncoder creates an encoder that writes newline delimited JSON to w.
+func NewNDJSONEncoder(w io.Writer) *NDJSONEncoder {
+	return &NDJSONEncoder{w: w, enc: json.NewEncoder(w)}
+}
+
+// Encode writes the JSON encoding of v followed by a newline and flushes the stream.
+func (e *NDJSONEncoder) Encode(v interface{}) error {
+	if err := e.enc.Encode(v); err != nil {
+		return err
+	}
+	flush(e.w)
+	return nil
+}
+
+// Next calls f.
+func (f ItemIteratorFunc) Next() (interface{}, error) {
+	return f()
+}
+
+// StreamNDJSON writes the items returned by it to w as newline delimited JSON, one item per line,
+// and flushes w after each item so that clients receive the items as they are produced. It
+// returns nil once it returns io.EOF, the error returned by it otherwise or the context error if
+// ctx is done before the iteration ends.
+func StreamNDJSON(ctx context.Context, w io.Writer, it ItemIterator) error {
+	if c, ok := it.(io.Closer); ok {
+		defer c.Close()
+	}
+	enc := NewNDJSONEncoder(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		item, err := it.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+}
+
+// CopyStream copies r to w flushing w after each chunk so that large response bodies reach the
+// client as they are read rather than being buffered in memory. r is closed once copied if it
+// implements io.Closer.
+func CopyStream(w io.Writer, r io.Reader) error {
+	if c, ok := r.(io.Closer); ok {
+		defer c.Close()
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			flush(w)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// flush flushes w if it implements http.Flusher, w may be the response data of a request.
+func flush(w io.Writer) {
+	if rd, ok := w.(*ResponseData); ok {
+		w = rd.ResponseWriter
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,173 @@
+package goa_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("StreamWriter", func() {
+	var w *blockingWriter
+	var opts goa.StreamOptions
+	var stream *goa.StreamWriter
+
+	BeforeEach(func() {
+		w = &blockingWriter{release: make(chan struct{})}
+		opts = goa.StreamOptions{}
+	})
+
+	JustBeforeEach(func() {
+		stream = goa.NewStreamWriter(context.Background(), w, opts)
+	})
+
+	It("writes the data to the underlying writer", func() {
+		close(w.release)
+		n, err := stream.Write([]byte("foo"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(n).Should(Equal(3))
+		Ω(stream.Close()).ShouldNot(HaveOccurred())
+		Ω(w.String()).Should(Equal("foo"))
+	})
+
+	Context("with a slow client", func() {
+		BeforeEach(func() {
+			opts.MaxBuffered = 4
+			opts.WriteTimeout = 10 * time.Millisecond
+		})
+
+		It("aborts the stream once the write timeout elapses", func() {
+			_, err := stream.Write([]byte("foo"))
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = stream.Write([]byte("bar"))
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(*goa.ErrorResponse).Code).Should(Equal("slow_client"))
+			Ω(stream.Close()).Should(HaveOccurred())
+			Ω(w.String()).Should(BeEmpty())
+		})
+
+		It("waits for the interrupted write to return", func() {
+			_, err := stream.Write([]byte("foo"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(stream.Close()).Should(HaveOccurred())
+			Ω(w.Writes()).Should(Equal(0))
+		})
+	})
+})
+
+var _ = Describe("CopyStream", func() {
+	It("copies the reader to the response and flushes it", func() {
+		rw := httptest.NewRecorder()
+		rd := &goa.ResponseData{ResponseWriter: rw}
+		err := goa.CopyStream(rd, ioutil.NopCloser(strings.NewReader("foo bar")))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Body.String()).Should(Equal("foo bar"))
+		Ω(rw.Flushed).Should(BeTrue())
+	})
+})
+
+var _ = Describe("NDJSONEncoder", func() {
+	It("writes one JSON value per line", func() {
+		rw := httptest.NewRecorder()
+		enc := goa.NewNDJSONEncoder(rw)
+		Ω(enc.Encode(map[string]int{"a": 1})).ShouldNot(HaveOccurred())
+		Ω(enc.Encode(map[string]int{"b": 2})).ShouldNot(HaveOccurred())
+		Ω(rw.Body.String()).Should(Equal("{\"a\":1}\n{\"b\":2}\n"))
+		Ω(rw.Flushed).Should(BeTrue())
+	})
+})
+
+var _ = Describe("StreamNDJSON", func() {
+	var items []interface{}
+	var iter goa.ItemIterator
+
+	BeforeEach(func() {
+		items = []interface{}{map[string]int{"a": 1}, map[string]int{"b": 2}}
+		iter = goa.ItemIteratorFunc(func() (interface{}, error) {
+			if len(items) == 0 {
+				return nil, io.EOF
+			}
+			item := items[0]
+			items = items[1:]
+			return item, nil
+		})
+	})
+
+	It("writes the items one per line", func() {
+		rw := httptest.NewRecorder()
+		Ω(goa.StreamNDJSON(context.Background(), rw, iter)).ShouldNot(HaveOccurred())
+		Ω(rw.Body.String()).Should(Equal("{\"a\":1}\n{\"b\":2}\n"))
+		Ω(rw.Flushed).Should(BeTrue())
+	})
+
+	It("stops once the context is canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		rw := httptest.NewRecorder()
+		Ω(goa.StreamNDJSON(ctx, rw, iter)).Should(Equal(context.Canceled))
+		Ω(rw.Body.Len()).Should(Equal(0))
+	})
+
+	It("returns the iterator errors", func() {
+		iter = goa.ItemIteratorFunc(func() (interface{}, error) {
+			return nil, errors.New("cursor closed")
+		})
+		rw := httptest.NewRecorder()
+		Ω(goa.StreamNDJSON(context.Background(), rw, iter)).Should(MatchError("cursor closed"))
+	})
+})
+
+// blockingWriter is a writer whose writes block until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	closed  bool
+	pending int
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	b.pending++
+	b.mu.Unlock()
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending--
+	if b.closed {
+		return 0, io.ErrClosedPipe
+	}
+	return b.buf.Write(p)
+}
+
+// Close interrupts the pending writes.
+func (b *blockingWriter) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.once.Do(func() { close(b.release) })
+	return nil
+}
+
+// Writes returns the number of writes in progress.
+func (b *blockingWriter) Writes() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pending
+}
+
+func (b *blockingWriter) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,168 @@
+package goa
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// Timings records the duration of the phases of the processing of a request: "decode" for
+	// the decoding of the request payload, "validate" for the validation of the request params
+	// and headers, "handler" for the controller action up to the start of the response and
+	// "encode" for the rest of the processing, typically the encoding of the response body.
+	// The handler phase ends when the response status code is written. MuxHandler initializes
+	// the timings of each request, use ContextTimings to retrieve them. Timings is safe for
+	// concurrent use.
+	Timings struct {
+		mu            sync.Mutex
+		clock         Clock
+		start         time.Time
+		phases        []Phase
+		open          map[string]time.Time
+		responseStart time.Time
+	}
+
+	// Phase is the duration of a request processing phase.
+	Phase struct {
+		// Name is the phase name, e.g. "decode".
+		Name string
+		// Duration is the phase duration.
+		Duration time.Duration
+	}
+)
+
+// Request processing phases recorded by goa.
+const (
+	// PhaseDecode is the name of the request payload decoding phase.
+	PhaseDecode = "decode"
+	// PhaseValidate is the name of the request params and headers validation phase.
+	PhaseValidate = "validate"
+	// PhaseHandler is the name of the controller action phase.
+	PhaseHandler = "handler"
+	// PhaseEncode is the name of the response encoding phase.
+	PhaseEncode = "encode"
+)
+
+// NewTimings creates timings that use the given clock, the request processing starts now.
+func NewTimings(clock Clock) *Timings {
+	return &Timings{clock: clock, start: clock.Now()}
+}
+
+// WithTimings sets the request timings in the context.
+func WithTimings(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, timingsKey, t)
+}
+
+// ContextTimings returns the timings of the request, nil if the context does not contain any.
+func ContextTimings(ctx context.Context) *Timings {
+	if t := ctx.Value(timingsKey); t != nil {
+		return t.(*Timings)
+	}
+	return nil
+}
+
+// StartPhase records the start of the phase with the given name in the request timings and
+// returns the function that records its end. It does nothing if the context does not contain
+// timings. The generated code calls StartPhase to time the validation and handler phases.
+func StartPhase(ctx context.Context, name string) func() {
+	t := ContextTimings(ctx)
+	if t == nil {
+		return func() {}
+	}
+	t.mu.Lock()
+	if t.open == nil {
+		t.open = make(map[string]time.Time)
+	}
+	t.open[name] = t.clock.Now()
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.end(name)
+	}
+}
+
+// StartResponse records that the response started, it ends the handler phase and starts the
+// encode phase. The response data initialized by MuxHandler calls StartResponse when the
+// response status code is written, subsequent calls have no effect.
+func (t *Timings) StartResponse() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.responseStart.IsZero() {
+		return
+	}
+	t.end(PhaseHandler)
+	t.responseStart = t.clock.Now()
+}
+
+// Finish ends the encode phase if the response started and returns the total duration of the
+// request processing.
+func (t *Timings) Finish() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.clock.Now()
+	if !t.responseStart.IsZero() && !t.recorded(PhaseEncode) {
+		t.phases = append(t.phases, Phase{Name: PhaseEncode, Duration: now.Sub(t.responseStart)})
+	}
+	return now.Sub(t.start)
+}
+
+// Phases returns the phases that ended in the order they ended.
+func (t *Timings) Phases() []Phase {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Phase(nil), t.phases...)
+}
+
+// Duration returns the duration of the phase with the given name and true if it ended, false
+// otherwise.
+func (t *Timings) Duration(name string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, p := range t.phases {
+		if p.Name == name {
+			return p.Duration, true
+		}
+	}
+	return 0, false
+}
+
+// Header returns the value of the Server-Timing header listing the phases that ended, see
+// https://www.w3.org/TR/server-timing. Durations are in milliseconds.
+func (t *Timings) Header() string {
+	phases := t.Phases()
+	metrics := make([]string, len(phases))
+	for i, p := range phases {
+		metrics[i] = ServerTimingMetric(p.Name, p.Duration)
+	}
+	return strings.Join(metrics, ", ")
+}
+
+// ServerTimingMetric formats a metric of the Server-Timing header.
+func ServerTimingMetric(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.3f", name, float64(d)/float64(time.Millisecond))
+}
+
+// end records the end of the phase with the given name if it is open, t.mu must be held.
+func (t *Timings) end(name string) {
+	start, ok := t.open[name]
+	if !ok {
+		return
+	}
+	delete(t.open, name)
+	t.phases = append(t.phases, Phase{Name: name, Duration: t.clock.Now().Sub(start)})
+}
+
+// recorded returns true if the phase with the given name ended, t.mu must be held.
+func (t *Timings) recorded(name string) bool {
+	for _, p := range t.phases {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package goa_test
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/goatest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timings", func() {
+	var clock *goatest.Clock
+	var timings *goa.Timings
+	var ctx context.Context
+
+	BeforeEach(func() {
+		clock = goatest.NewClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+		timings = goa.NewTimings(clock)
+		ctx = goa.WithTimings(context.Background(), timings)
+	})
+
+	It("records the phases", func() {
+		end := goa.StartPhase(ctx, goa.PhaseDecode)
+		clock.Advance(time.Millisecond)
+		end()
+		end = goa.StartPhase(ctx, goa.PhaseHandler)
+		clock.Advance(1500 * time.Microsecond)
+		timings.StartResponse()
+		end()
+		clock.Advance(time.Millisecond)
+		Ω(timings.Finish()).Should(Equal(3500 * time.Microsecond))
+		Ω(timings.Phases()).Should(Equal([]goa.Phase{
+			{Name: goa.PhaseDecode, Duration: time.Millisecond},
+			{Name: goa.PhaseHandler, Duration: 1500 * time.Microsecond},
+			{Name: goa.PhaseEncode, Duration: time.Millisecond},
+		}))
+		Ω(timings.Header()).Should(Equal("decode;dur=1.000, handler;dur=1.500, encode;dur=1.000"))
+	})
+
+	It("does nothing when the context does not contain timings", func() {
+		Ω(goa.ContextTimings(context.Background())).Should(BeNil())
+		goa.StartPhase(context.Background(), goa.PhaseDecode)()
+	})
+})
@@ -0,0 +1,214 @@
+package goa
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// UploadInfo describes the state of a resumable upload.
+	UploadInfo struct {
+		// ID is the unique identifier of the upload.
+		ID string
+		// Length is the total size of the upload in bytes.
+		Length int64
+		// Offset is the number of bytes received so far.
+		Offset int64
+		// Metadata contains the decoded values of the Upload-Metadata header sent when the
+		// upload was created.
+		Metadata map[string]string
+	}
+
+	// UploadStore is the interface implemented by the storage backends of resumable uploads.
+	UploadStore interface {
+		// NewUpload creates a new upload with the given length and metadata and returns
+		// its unique identifier.
+		NewUpload(ctx context.Context, length int64, metadata map[string]string) (string, error)
+		// GetUpload returns the upload with the given identifier or nil if there is none.
+		GetUpload(ctx context.Context, id string) (*UploadInfo, error)
+		// WriteChunk appends the content read from src to the upload with the given
+		// identifier starting at offset and returns the number of bytes written.
+		WriteChunk(ctx context.Context, id string, offset int64, src io.Reader) (int64, error)
+	}
+
+	// UploadOptions configures the resumable uploads handled by an Uploader.
+	UploadOptions struct {
+		// MaxSize is the maximum size of an upload in bytes. Zero means no limit.
+		MaxSize int64
+	}
+
+	// Uploader implements the tus resumable upload protocol core and creation extension on top
+	// of an UploadStore, see http://tus.io/protocols/resumable-upload.html. It is used by the
+	// code generated for the ResumableUpload DSL.
+	Uploader struct {
+		store   UploadStore
+		options UploadOptions
+	}
+)
+
+const (
+	// TusVersion is the version of the tus protocol implemented by Uploader.
+	TusVersion = "1.0.0"
+
+	// UploadIDParam is the name of the path parameter that contains the upload identifier.
+	UploadIDParam = "uploadID"
+
+	// uploadContentType is the content type of PATCH requests.
+	uploadContentType = "application/offset+octet-stream"
+)
+
+var (
+	// ErrUploadConflict is the error produced when the Upload-Offset header of a PATCH request
+	// does not match the offset of the upload.
+	ErrUploadConflict = NewErrorClass("upload_conflict", 409)
+
+	// ErrUnsupportedTusVersion is the error produced when the Tus-Resumable header of a request
+	// does not match the protocol version implemented by Uploader.
+	ErrUnsupportedTusVersion = NewErrorClass("unsupported_tus_version", 412)
+)
+
+// NewUploader creates an uploader that stores the uploads in the given store.
+func NewUploader(store UploadStore, options UploadOptions) *Uploader {
+	return &Uploader{store: store, options: options}
+}
+
+// Handler returns the handler that implements the resumable upload protocol. The handler creates
+// uploads on POST requests, returns their offset on HEAD requests and appends to them on PATCH
+// requests. HEAD and PATCH requests must be mounted under a path that defines the UploadIDParam
+// path parameter. OPTIONS requests return the protocol capabilities.
+func (u *Uploader) Handler() Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		rw.Header().Set("Tus-Resumable", TusVersion)
+		if req.Method == "OPTIONS" {
+			return u.capabilities(rw)
+		}
+		if v := req.Header.Get("Tus-Resumable"); v != TusVersion {
+			rw.Header().Set("Tus-Version", TusVersion)
+			return ErrUnsupportedTusVersion(fmt.Sprintf("unsupported Tus-Resumable version %#v", v),
+				"supported", TusVersion)
+		}
+		switch req.Method {
+		case "POST":
+			return u.create(ctx, rw, req)
+		case "HEAD":
+			return u.offset(ctx, rw)
+		case "PATCH":
+			return u.write(ctx, rw, req)
+		}
+		return ErrBadRequest(fmt.Sprintf("unsupported method %s", req.Method))
+	}
+}
+
+// capabilities writes the protocol capabilities.
+func (u *Uploader) capabilities(rw http.ResponseWriter) error {
+	rw.Header().Set("Tus-Version", TusVersion)
+	rw.Header().Set("Tus-Extension", "creation")
+	if u.options.MaxSize > 0 {
+		rw.Header().Set("Tus-Max-Size", strconv.FormatInt(u.options.MaxSize, 10))
+	}
+	rw.WriteHeader(204)
+	return nil
+}
+
+// create creates a new upload and writes its location.
+func (u *Uploader) create(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+	length, err := strconv.ParseInt(req.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return ErrBadRequest("missing or invalid Upload-Length header")
+	}
+	if u.options.MaxSize > 0 && length > u.options.MaxSize {
+		return ErrRequestBodyTooLarge(fmt.Sprintf("upload length exceeds %d bytes", u.options.MaxSize))
+	}
+	metadata, err := parseUploadMetadata(req.Header.Get("Upload-Metadata"))
+	if err != nil {
+		return ErrBadRequest(err)
+	}
+	id, err := u.store.NewUpload(ctx, length, metadata)
+	if err != nil {
+		return err
+	}
+	rw.Header().Set("Location", strings.TrimSuffix(req.URL.Path, "/")+"/"+id)
+	rw.WriteHeader(201)
+	return nil
+}
+
+// offset writes the offset and length of the upload identified by the request path.
+func (u *Uploader) offset(ctx context.Context, rw http.ResponseWriter) error {
+	info, err := u.upload(ctx)
+	if err != nil {
+		return err
+	}
+	rw.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	rw.Header().Set("Upload-Length", strconv.FormatInt(info.Length, 10))
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(200)
+	return nil
+}
+
+// write appends the request body to the upload identified by the request path.
+func (u *Uploader) write(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+	if mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err != nil || mediaType != uploadContentType {
+		return ErrUnsupportedMediaType(fmt.Sprintf("invalid Content-Type %#v", req.Header.Get("Content-Type")),
+			"supported", uploadContentType)
+	}
+	offset, err := strconv.ParseInt(req.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return ErrBadRequest("missing or invalid Upload-Offset header")
+	}
+	info, err := u.upload(ctx)
+	if err != nil {
+		return err
+	}
+	if offset != info.Offset {
+		return ErrUploadConflict(fmt.Sprintf("Upload-Offset %d does not match upload offset %d", offset, info.Offset))
+	}
+	n, err := u.store.WriteChunk(ctx, info.ID, offset, io.LimitReader(req.Body, info.Length-offset))
+	if err != nil {
+		return err
+	}
+	rw.Header().Set("Upload-Offset", strconv.FormatInt(offset+n, 10))
+	rw.WriteHeader(204)
+	return nil
+}
+
+// upload loads the upload identified by the request path.
+func (u *Uploader) upload(ctx context.Context) (*UploadInfo, error) {
+	id := ContextRequest(ctx).Params.Get(UploadIDParam)
+	info, err := u.store.GetUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ErrNotFound(fmt.Sprintf("upload %#v not found", id))
+	}
+	return info, nil
+}
+
+// parseUploadMetadata decodes the value of an Upload-Metadata header which consists of comma
+// separated key value pairs where the key and the base64 encoded value are separated by a space.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		elems := strings.SplitN(pair, " ", 2)
+		var value []byte
+		if len(elems) == 2 {
+			var err error
+			if value, err = base64.StdEncoding.DecodeString(elems[1]); err != nil {
+				return nil, fmt.Errorf("invalid Upload-Metadata value for key %#v", elems[0])
+			}
+		}
+		metadata[elems[0]] = string(value)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,192 @@
+package goa_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+// testUploadStore is an in-memory upload store.
+type testUploadStore struct {
+	uploads map[string]*goa.UploadInfo
+	data    map[string]*bytes.Buffer
+}
+
+func (s *testUploadStore) NewUpload(ctx context.Context, length int64, metadata map[string]string) (string, error) {
+	s.uploads["up1"] = &goa.UploadInfo{ID: "up1", Length: length, Metadata: metadata}
+	s.data["up1"] = new(bytes.Buffer)
+	return "up1", nil
+}
+
+func (s *testUploadStore) GetUpload(ctx context.Context, id string) (*goa.UploadInfo, error) {
+	return s.uploads[id], nil
+}
+
+func (s *testUploadStore) WriteChunk(ctx context.Context, id string, offset int64, src io.Reader) (int64, error) {
+	n, err := io.Copy(s.data[id], src)
+	s.uploads[id].Offset += n
+	return n, err
+}
+
+var _ = Describe("Uploader", func() {
+	var store *testUploadStore
+	var method, path, body string
+	var header http.Header
+	var params url.Values
+
+	var rw *httptest.ResponseRecorder
+	var err error
+
+	BeforeEach(func() {
+		store = &testUploadStore{
+			uploads: map[string]*goa.UploadInfo{"up0": {ID: "up0", Length: 10, Offset: 4}},
+			data:    map[string]*bytes.Buffer{"up0": bytes.NewBufferString("0123")},
+		}
+		body = ""
+		header = http.Header{"Tus-Resumable": {goa.TusVersion}}
+		params = url.Values{}
+	})
+
+	JustBeforeEach(func() {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rw = httptest.NewRecorder()
+		ctx := goa.NewContext(context.Background(), rw, req, params)
+		uploader := goa.NewUploader(store, goa.UploadOptions{MaxSize: 100})
+		err = uploader.Handler()(ctx, rw, req)
+	})
+
+	Context("creating an upload", func() {
+		BeforeEach(func() {
+			method = "POST"
+			path = "/uploads"
+			header.Set("Upload-Length", "42")
+			header.Set("Upload-Metadata", "filename d29ybGQudHh0,private")
+		})
+
+		It("creates the upload and returns its location", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(201))
+			Ω(rw.Header().Get("Location")).Should(Equal("/uploads/up1"))
+			Ω(rw.Header().Get("Tus-Resumable")).Should(Equal(goa.TusVersion))
+			Ω(store.uploads["up1"].Length).Should(Equal(int64(42)))
+			Ω(store.uploads["up1"].Metadata).Should(Equal(map[string]string{"filename": "world.txt", "private": ""}))
+		})
+
+		Context("exceeding the maximum size", func() {
+			BeforeEach(func() {
+				header.Set("Upload-Length", "101")
+			})
+
+			It("returns a request too large error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(413))
+			})
+		})
+
+		Context("with an unsupported protocol version", func() {
+			BeforeEach(func() {
+				header.Set("Tus-Resumable", "0.2.0")
+			})
+
+			It("returns a precondition failed error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(412))
+				Ω(rw.Header().Get("Tus-Version")).Should(Equal(goa.TusVersion))
+			})
+		})
+	})
+
+	Context("retrieving the upload offset", func() {
+		BeforeEach(func() {
+			method = "HEAD"
+			path = "/uploads/up0"
+			params.Set(goa.UploadIDParam, "up0")
+		})
+
+		It("returns the upload offset and length", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get("Upload-Offset")).Should(Equal("4"))
+			Ω(rw.Header().Get("Upload-Length")).Should(Equal("10"))
+			Ω(rw.Header().Get("Cache-Control")).Should(Equal("no-store"))
+		})
+
+		Context("of an unknown upload", func() {
+			BeforeEach(func() {
+				params.Set(goa.UploadIDParam, "unknown")
+			})
+
+			It("returns a not found error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(404))
+			})
+		})
+	})
+
+	Context("appending to an upload", func() {
+		BeforeEach(func() {
+			method = "PATCH"
+			path = "/uploads/up0"
+			params.Set(goa.UploadIDParam, "up0")
+			header.Set("Content-Type", "application/offset+octet-stream")
+			header.Set("Upload-Offset", "4")
+			body = "456789extra"
+		})
+
+		It("writes the chunk up to the upload length", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(204))
+			Ω(rw.Header().Get("Upload-Offset")).Should(Equal("10"))
+			Ω(store.data["up0"].String()).Should(Equal("0123456789"))
+		})
+
+		Context("with a mismatched offset", func() {
+			BeforeEach(func() {
+				header.Set("Upload-Offset", "2")
+			})
+
+			It("returns a conflict error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(409))
+			})
+		})
+
+		Context("with an invalid content type", func() {
+			BeforeEach(func() {
+				header.Set("Content-Type", "application/json")
+			})
+
+			It("returns an unsupported media type error", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(415))
+			})
+		})
+	})
+
+	Context("discovering the capabilities", func() {
+		BeforeEach(func() {
+			method = "OPTIONS"
+			path = "/uploads"
+			header = http.Header{}
+		})
+
+		It("returns the protocol version and extensions", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(204))
+			Ω(rw.Header().Get("Tus-Version")).Should(Equal(goa.TusVersion))
+			Ω(rw.Header().Get("Tus-Extension")).Should(Equal("creation"))
+			Ω(rw.Header().Get("Tus-Max-Size")).Should(Equal("100"))
+		})
+	})
+})
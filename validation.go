@@ -8,8 +8,11 @@ import (
 	"regexp"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/goadesign/goa/uuid"
+	"golang.org/x/net/context"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Format defines a validation format.
@@ -141,3 +144,81 @@ func ValidatePattern(p string, val string) bool {
 	}
 	return r.MatchString(val)
 }
+
+// normalizationForms indexes the Unicode normalization forms by name.
+var normalizationForms = map[string]norm.Form{
+	"NFC":  norm.NFC,
+	"NFD":  norm.NFD,
+	"NFKC": norm.NFKC,
+	"NFKD": norm.NFKD,
+}
+
+// ValidateNormalization returns true if val is in the Unicode normalization form with the given
+// name, one of "NFC", "NFD", "NFKC" or "NFKD". It panics if the form is unknown, the DSL makes
+// sure the generated code only uses known forms.
+func ValidateNormalization(form string, val string) bool {
+	f, ok := normalizationForms[form]
+	if !ok {
+		panic(fmt.Sprintf("goa: unknown Unicode normalization form %#v", form)) // bug
+	}
+	return f.IsNormalString(val)
+}
+
+// GraphemeCount returns the number of user-perceived characters in s. It approximates the
+// extended grapheme clusters of Unicode Standard Annex #29: combining marks, variation
+// selectors, emoji modifiers and characters joined with a zero width joiner are counted with the
+// preceding character, "\r\n" and pairs of regional indicators (flags) count as one character.
+func GraphemeCount(s string) int {
+	count := 0
+	var prev rune
+	joined, flag := false, false
+	for i, r := range s {
+		switch {
+		case i == 0:
+			count++
+		case joined, r == '\n' && prev == '\r':
+		case unicode.Is(unicode.M, r), r == '\u200d', isVariationSelector(r), r >= 0x1F3FB && r <= 0x1F3FF:
+		case isRegionalIndicator(r) && flag:
+			// Second regional indicator of a flag
+			flag = false
+			prev = r
+			continue
+		default:
+			count++
+		}
+		joined = r == '\u200d'
+		flag = isRegionalIndicator(r)
+		prev = r
+	}
+	return count
+}
+
+// isVariationSelector returns true if r is a Unicode variation selector.
+func isVariationSelector(r rune) bool {
+	return (r >= 0xFE00 && r <= 0xFE0F) || (r >= 0xE0100 && r <= 0xE01EF)
+}
+
+// isRegionalIndicator returns true if r is a regional indicator symbol, flags are made of pairs of
+// regional indicators.
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// ReportValidationWarning logs the given validation error and reports it to the client via a
+// "Warning" response header instead of failing the request. It is called by the generated code for
+// the attributes whose validations produce warnings (see the "validation:warn" design metadata).
+// ReportValidationWarning does nothing if err is nil.
+func ReportValidationWarning(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	msg := err.Error()
+	if e, ok := err.(*ErrorResponse); ok {
+		msg = e.Detail
+	}
+	go IncrCounter([]string{"goa", "validation", "warning"}, 1.0)
+	LogInfo(ctx, "validation warning", "warning", msg)
+	if resp := ContextResponse(ctx); resp != nil && resp.ResponseWriter != nil {
+		resp.Header().Add("Warning", fmt.Sprintf("299 - %q", msg))
+	}
+}
@@ -1,9 +1,14 @@
 package goa_test
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
 	"github.com/goadesign/goa"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
 )
 
 var _ = Describe("ValidateFormat", func() {
@@ -343,3 +348,68 @@ var _ = Describe("ValidateFormat", func() {
 
 	})
 })
+
+var _ = Describe("ValidateNormalization", func() {
+	It("validates normalized strings", func() {
+		Ω(goa.ValidateNormalization("NFC", "caf\u00e9")).Should(BeTrue())
+		Ω(goa.ValidateNormalization("NFD", "cafe\u0301")).Should(BeTrue())
+	})
+
+	It("does not validate denormalized strings", func() {
+		Ω(goa.ValidateNormalization("NFC", "cafe\u0301")).Should(BeFalse())
+		Ω(goa.ValidateNormalization("NFKC", "\ufb01")).Should(BeFalse())
+	})
+})
+
+var _ = Describe("GraphemeCount", func() {
+	It("counts user-perceived characters", func() {
+		Ω(goa.GraphemeCount("abc")).Should(Equal(3))
+		Ω(goa.GraphemeCount("e\u0301te\u0301")).Should(Equal(3))
+		Ω(goa.GraphemeCount("\U0001F1EB\U0001F1F7\U0001F1E9\U0001F1EA")).Should(Equal(2))
+		Ω(goa.GraphemeCount("\U0001F44D\U0001F3FD!")).Should(Equal(2))
+		Ω(goa.GraphemeCount("\U0001F468\u200d\U0001F469\u200d\U0001F467")).Should(Equal(1))
+		Ω(goa.GraphemeCount("a\r\nb")).Should(Equal(3))
+	})
+})
+
+var _ = Describe("ReportValidationWarning", func() {
+	var rw *httptest.ResponseRecorder
+	var ctx context.Context
+	var err error
+
+	BeforeEach(func() {
+		req, _ := http.NewRequest("GET", "/", nil)
+		rw = httptest.NewRecorder()
+		ctx = goa.NewContext(context.Background(), rw, req, nil)
+		err = nil
+	})
+
+	JustBeforeEach(func() {
+		goa.ReportValidationWarning(ctx, err)
+	})
+
+	It("does nothing when there is no error", func() {
+		Ω(rw.Header()).ShouldNot(HaveKey("Warning"))
+	})
+
+	Context("with a validation error", func() {
+		BeforeEach(func() {
+			err = goa.InvalidEnumValueError("param", 4, []interface{}{1, 2, 3})
+		})
+
+		It("sets the Warning header", func() {
+			Ω(rw.Header().Get("Warning")).Should(HavePrefix("299 - "))
+			Ω(rw.Header().Get("Warning")).Should(ContainSubstring("param"))
+		})
+	})
+
+	Context("with a plain error", func() {
+		BeforeEach(func() {
+			err = errors.New("boom")
+		})
+
+		It("uses the error message", func() {
+			Ω(rw.Header().Get("Warning")).Should(Equal(`299 - "boom"`))
+		})
+	})
+})
@@ -0,0 +1,213 @@
+package goa
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// VersionScheme describes how clients request a version of the API, see the VersionHeader
+	// and VersionMediaType DSLs.
+	VersionScheme struct {
+		// Header is the name of the request header that contains the requested version, e.g.
+		// "X-API-Version".
+		Header string
+		// MediaType is the vendor media type that precedes the requested version in the
+		// Accept header, e.g. "application/vnd.myapi" for "application/vnd.myapi.v2+json".
+		MediaType string
+	}
+
+	// versionMux dispatches the requests made to the routes mounted by multiple versions of the
+	// API to the handlers of the version they request.
+	versionMux struct {
+		service *Service
+		schemes []VersionScheme
+		routes  map[string]*versionedRoute
+	}
+
+	// versionedRoute lists the handlers of a route indexed by version.
+	versionedRoute struct {
+		handlers map[string]MuxHandler
+		versions []string // versions sorted from most to least recent
+	}
+
+	// versionedMux is the ServeMux used to mount the controllers of a given API version.
+	versionedMux struct {
+		*versionMux
+		version string
+	}
+)
+
+// ErrUnsupportedVersion is the error produced when a request specifies an API version that does
+// not define the route.
+var ErrUnsupportedVersion = NewErrorClass("unsupported_version", 406)
+
+// VersionMux returns the mux used to mount the controllers of the given version of the API. The
+// routes registered on the mux are registered on the service mux with a handler that dispatches
+// the requests to the handlers of the version they request using scheme. Requests that do not
+// specify a version are handled by the most recent version that defines the route. The code
+// generated for designs that use VersionHeader or VersionMediaType mounts the controllers on the
+// mux returned by VersionMux.
+func (service *Service) VersionMux(version string, scheme VersionScheme) ServeMux {
+	if service.versions == nil {
+		service.versions = &versionMux{
+			service: service,
+			routes:  make(map[string]*versionedRoute),
+		}
+	}
+	m := service.versions
+	known := false
+	for _, s := range m.schemes {
+		if s == scheme {
+			known = true
+			break
+		}
+	}
+	if !known {
+		m.schemes = append(m.schemes, scheme)
+	}
+	return &versionedMux{versionMux: m, version: normalizeVersion(version)}
+}
+
+// Handle sets the handler of the mux version for the given verb and path.
+func (m *versionedMux) Handle(method, path string, handle MuxHandler) {
+	key := method + path
+	route, ok := m.routes[key]
+	if !ok {
+		route = &versionedRoute{handlers: make(map[string]MuxHandler)}
+		m.routes[key] = route
+		m.service.Mux.Handle(method, path, m.dispatch(route))
+	}
+	if _, ok := route.handlers[m.version]; !ok {
+		route.versions = append(route.versions, m.version)
+		sort.Sort(byRecency(route.versions))
+	}
+	route.handlers[m.version] = handle
+}
+
+// HandleNotFound sets the not found handler of the service mux.
+func (m *versionedMux) HandleNotFound(handle MuxHandler) {
+	m.service.Mux.HandleNotFound(handle)
+}
+
+// Lookup returns the handler of the mux version for the given method and path.
+func (m *versionedMux) Lookup(method, path string) MuxHandler {
+	if route, ok := m.routes[method+path]; ok {
+		return route.handlers[m.version]
+	}
+	return nil
+}
+
+// ServeHTTP dispatches the request using the service mux.
+func (m *versionedMux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	m.service.Mux.ServeHTTP(rw, req)
+}
+
+// dispatch returns the handler that dispatches the requests made to the given route.
+func (m *versionMux) dispatch(route *versionedRoute) MuxHandler {
+	return func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		accept := false
+		for _, s := range m.schemes {
+			if s.Header != "" {
+				rw.Header().Add("Vary", s.Header)
+			}
+			accept = accept || s.MediaType != ""
+		}
+		if accept {
+			rw.Header().Add("Vary", "Accept")
+		}
+		version := m.requestedVersion(req)
+		if version == "" {
+			version = route.versions[0]
+		}
+		if h, ok := route.handlers[version]; ok {
+			h(rw, req, params)
+			return
+		}
+		// Run the service middleware like for requests that don't match any route.
+		var handler Handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return ErrUnsupportedVersion(fmt.Sprintf("API version %#v does not support %s %s", version, req.Method, req.URL.Path),
+				"supported", route.versions)
+		}
+		chain := m.service.middleware
+		for i := range chain {
+			handler = chain[len(chain)-i-1](handler)
+		}
+		ctx := NewContext(m.service.Context, rw, req, params)
+		err := handler(ctx, ContextResponse(ctx), req)
+		if !ContextResponse(ctx).Written() {
+			m.service.Send(ctx, 406, err)
+		}
+	}
+}
+
+// requestedVersion returns the version requested by req, an empty string if there is none.
+func (m *versionMux) requestedVersion(req *http.Request) string {
+	for _, s := range m.schemes {
+		if s.Header == "" {
+			continue
+		}
+		if v := req.Header.Get(s.Header); v != "" {
+			return normalizeVersion(v)
+		}
+	}
+	for _, s := range m.schemes {
+		if s.MediaType == "" {
+			continue
+		}
+		prefix := s.MediaType + ".v"
+		for _, accept := range req.Header["Accept"] {
+			for _, mr := range strings.Split(accept, ",") {
+				mt := strings.TrimSpace(strings.SplitN(mr, ";", 2)[0])
+				if !strings.HasPrefix(mt, prefix) {
+					continue
+				}
+				v := mt[len(prefix):]
+				if i := strings.IndexByte(v, '+'); i >= 0 {
+					v = v[:i]
+				}
+				if v != "" {
+					return normalizeVersion(v)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// normalizeVersion removes the optional "v" prefix of version so that "v2" and "2" are
+// equivalent.
+func normalizeVersion(version string) string {
+	if strings.HasPrefix(version, "v") || strings.HasPrefix(version, "V") {
+		return version[1:]
+	}
+	return version
+}
+
+// byRecency makes it possible to sort versions from most to least recent. Versions are compared
+// segment by segment, numerically when both segments are numbers.
+type byRecency []string
+
+func (b byRecency) Len() int      { return len(b) }
+func (b byRecency) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byRecency) Less(i, j int) bool {
+	si, sj := strings.Split(b[i], "."), strings.Split(b[j], ".")
+	for k := 0; k < len(si) && k < len(sj); k++ {
+		if si[k] == sj[k] {
+			continue
+		}
+		ni, erri := strconv.Atoi(si[k])
+		nj, errj := strconv.Atoi(sj[k])
+		if erri == nil && errj == nil {
+			return ni > nj
+		}
+		return si[k] > sj[k]
+	}
+	return len(si) > len(sj)
+}
@@ -0,0 +1,80 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VersionMux", func() {
+	var service *goa.Service
+	var header http.Header
+	var path string
+	var rw *httptest.ResponseRecorder
+
+	handler := func(body string) goa.MuxHandler {
+		return func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+			rw.WriteHeader(200)
+			rw.Write([]byte(body))
+		}
+	}
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.WithLogger(nil)
+		scheme := goa.VersionScheme{Header: "X-API-Version", MediaType: "application/vnd.test"}
+		service.VersionMux("1.9", scheme).Handle("GET", "/bottles", handler("v1.9"))
+		service.VersionMux("v1.10", scheme).Handle("GET", "/bottles", handler("v1.10"))
+		service.VersionMux("1.9", scheme).Handle("GET", "/wines", handler("wines"))
+		header = http.Header{}
+		path = "/bottles"
+	})
+
+	JustBeforeEach(func() {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header = header
+		rw = httptest.NewRecorder()
+		service.Mux.ServeHTTP(rw, req)
+	})
+
+	It("dispatches requests without version to the most recent version", func() {
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Body.String()).Should(Equal("v1.10"))
+		Ω(rw.Header()["Vary"]).Should(Equal([]string{"X-API-Version", "Accept"}))
+	})
+
+	Context("with a version header", func() {
+		BeforeEach(func() {
+			header.Set("X-API-Version", "1.9")
+		})
+
+		It("dispatches to the requested version", func() {
+			Ω(rw.Body.String()).Should(Equal("v1.9"))
+		})
+	})
+
+	Context("with a versioned Accept header", func() {
+		BeforeEach(func() {
+			header.Set("Accept", "text/html, application/vnd.test.v1.9+json; q=0.9")
+		})
+
+		It("dispatches to the requested version", func() {
+			Ω(rw.Body.String()).Should(Equal("v1.9"))
+		})
+	})
+
+	Context("with a version that does not define the route", func() {
+		BeforeEach(func() {
+			path = "/wines"
+			header.Set("X-API-Version", "1.10")
+		})
+
+		It("responds with 406", func() {
+			Ω(rw.Code).Should(Equal(406))
+		})
+	})
+})
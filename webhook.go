@@ -0,0 +1,195 @@
+package goa
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Headers set on webhook delivery requests.
+const (
+	// WebhookEventHeader is the name of the header containing the webhook event name.
+	WebhookEventHeader = "X-Webhook-Event"
+	// WebhookDeliveryHeader is the name of the header containing the delivery identifier, it
+	// is the same for all the attempts made to deliver an event so receivers can discard
+	// duplicates.
+	WebhookDeliveryHeader = "X-Webhook-Delivery"
+	// WebhookTimestampHeader is the name of the header containing the time the delivery was
+	// signed as a Unix timestamp in seconds.
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookSignatureHeader is the name of the header containing the signature of the
+	// delivery, see WebhookSignature.
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// ErrWebhookDelivery is the class of errors returned when an event could not be delivered.
+var ErrWebhookDelivery = NewErrorClass("webhook_delivery", 502)
+
+// WebhookDispatcher delivers webhook events by making signed POST requests whose body is the JSON
+// representation of the event payload. Deliveries that fail to get a response or get a 429 or 5xx
+// response are retried with an exponential backoff. The zero value uses http.DefaultClient and
+// sends unsigned requests, WebhookDispatcher is safe for concurrent use. The code generated for
+// the webhooks declared in the design uses a WebhookDispatcher to deliver the events.
+type WebhookDispatcher struct {
+	// Secret is the key used to sign the deliveries, no signature is sent if empty.
+	Secret []byte
+	// SecretFunc returns the key used to sign each delivery, it takes precedence over Secret.
+	// It makes it possible to pick up rotated keys, e.g. with the Value method of a
+	// secrets.Secret.
+	SecretFunc func() []byte
+	// Client is the HTTP client used to make the requests, defaults to http.DefaultClient.
+	Client *http.Client
+	// MaxAttempts is the maximum number of attempts including the first one, defaults to 5.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, it doubles after each attempt. Defaults to
+	// one second.
+	Backoff time.Duration
+	// MaxBackoff is the maximum delay between two attempts, defaults to one minute.
+	MaxBackoff time.Duration
+}
+
+// Deliver sends the event with the given name and payload to url. It returns nil once the
+// receiver acknowledged the delivery with a 2xx response and an error of class
+// ErrWebhookDelivery if all the attempts failed or if the receiver rejected the delivery with a
+// 4xx response other than 429. Deliver returns the context error if ctx is done before the event
+// is delivered. Delays between attempts use the context clock (see ContextClock).
+func (d *WebhookDispatcher) Deliver(ctx context.Context, url, event string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	maxAttempts := d.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	clock := ContextClock(ctx)
+	delivery := newDeliveryID()
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(WebhookEventHeader, event)
+		req.Header.Set(WebhookDeliveryHeader, delivery)
+		secret := d.Secret
+		if d.SecretFunc != nil {
+			secret = d.SecretFunc()
+		}
+		if len(secret) > 0 {
+			ts := clock.Now().Unix()
+			req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(ts, 10))
+			req.Header.Set(WebhookSignatureHeader, WebhookSignature(secret, ts, body))
+		}
+		resp, err := client.Do(req)
+		retry := true
+		if err == nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				return nil
+			}
+			err = fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+			retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		}
+		if !retry || attempt >= maxAttempts {
+			return ErrWebhookDelivery(fmt.Sprintf("failed to deliver %s event: %s", event, err),
+				"event", event, "delivery", delivery, "attempts", attempt)
+		}
+		LogInfo(ctx, "retrying webhook delivery", "event", event, "delivery", delivery, "attempt", attempt, "error", err.Error())
+		if !d.wait(ctx, clock, attempt) {
+			return ctx.Err()
+		}
+	}
+}
+
+// wait waits for the delay that follows the given attempt, it returns false if ctx is done first.
+func (d *WebhookDispatcher) wait(ctx context.Context, clock Clock, attempt int) bool {
+	backoff, maxBackoff := d.Backoff, d.MaxBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = time.Minute
+	}
+	delay := backoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	done := make(chan struct{})
+	t := clock.AfterFunc(delay, func() { close(done) })
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		t.Stop()
+		return false
+	}
+}
+
+// WebhookSignature computes the value of the WebhookSignatureHeader header for the delivery of
+// body signed at the Unix time ts: "sha256=" followed by the hex encoded HMAC-SHA256 of the
+// timestamp, a dot and the body using secret as key. Including the timestamp in the signature
+// lets receivers reject replayed deliveries.
+func WebhookSignature(secret []byte, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks the signature of a webhook delivery received with the given
+// headers and body. It returns an error if the signature is missing or invalid or if the delivery
+// was signed more than tolerance before now, a tolerance of zero disables the timestamp check.
+func VerifyWebhookSignature(secret []byte, header http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	sig := header.Get(WebhookSignatureHeader)
+	if sig == "" || !strings.HasPrefix(sig, "sha256=") {
+		return fmt.Errorf("missing or malformed %s header", WebhookSignatureHeader)
+	}
+	ts, err := strconv.ParseInt(header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or malformed %s header", WebhookTimestampHeader)
+	}
+	if tolerance > 0 {
+		if age := now.Sub(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+			return fmt.Errorf("webhook timestamp is outside of the tolerance")
+		}
+	}
+	expected := WebhookSignature(secret, ts, body)
+	if subtle.ConstantTimeCompare([]byte(sig), []byte(expected)) != 1 {
+		return fmt.Errorf("invalid webhook signature")
+	}
+	return nil
+}
+
+// newDeliveryID generates a webhook delivery identifier, identifiers are sequential when
+// SequentialIDs is in effect.
+func newDeliveryID() string {
+	if id, ok := NextSequentialID(); ok {
+		return id
+	}
+	b := make([]byte, 12)
+	io.ReadFull(rand.Reader, b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,117 @@
+package goa_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("WebhookDispatcher", func() {
+	var secret = []byte("secret")
+	var statuses []int
+	var received []*http.Request
+	var bodies [][]byte
+	var receiver *httptest.Server
+	var dispatcher *goa.WebhookDispatcher
+	var err error
+
+	BeforeEach(func() {
+		statuses = nil
+		received = nil
+		bodies = nil
+		receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			received = append(received, r)
+			bodies = append(bodies, b)
+			status := 204
+			if len(statuses) >= len(received) {
+				status = statuses[len(received)-1]
+			}
+			w.WriteHeader(status)
+		}))
+		dispatcher = &goa.WebhookDispatcher{Secret: secret, MaxAttempts: 3, Backoff: time.Millisecond}
+	})
+
+	AfterEach(func() {
+		receiver.Close()
+	})
+
+	JustBeforeEach(func() {
+		err = dispatcher.Deliver(context.Background(), receiver.URL, "bottle.created", map[string]int{"id": 1})
+	})
+
+	It("delivers signed events", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(received).Should(HaveLen(1))
+		req := received[0]
+		Ω(req.Method).Should(Equal("POST"))
+		Ω(req.Header.Get("Content-Type")).Should(Equal("application/json"))
+		Ω(req.Header.Get(goa.WebhookEventHeader)).Should(Equal("bottle.created"))
+		Ω(req.Header.Get(goa.WebhookDeliveryHeader)).ShouldNot(BeEmpty())
+		Ω(string(bodies[0])).Should(Equal(`{"id":1}`))
+		Ω(goa.VerifyWebhookSignature(secret, req.Header, bodies[0], time.Now(), time.Minute)).Should(Succeed())
+		Ω(goa.VerifyWebhookSignature([]byte("other"), req.Header, bodies[0], time.Now(), 0)).ShouldNot(Succeed())
+		Ω(goa.VerifyWebhookSignature(secret, req.Header, []byte(`{"id":2}`), time.Now(), 0)).ShouldNot(Succeed())
+		Ω(goa.VerifyWebhookSignature(secret, req.Header, bodies[0], time.Now().Add(time.Hour), time.Minute)).ShouldNot(Succeed())
+	})
+
+	Context("with a secret function", func() {
+		var rotated = []byte("rotated")
+
+		BeforeEach(func() {
+			dispatcher.SecretFunc = func() []byte { return rotated }
+		})
+
+		It("signs the deliveries with the returned secret", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(received).Should(HaveLen(1))
+			Ω(goa.VerifyWebhookSignature(rotated, received[0].Header, bodies[0], time.Now(), time.Minute)).Should(Succeed())
+			Ω(goa.VerifyWebhookSignature(secret, received[0].Header, bodies[0], time.Now(), time.Minute)).ShouldNot(Succeed())
+		})
+	})
+
+	Context("with a receiver failing temporarily", func() {
+		BeforeEach(func() {
+			statuses = []int{503, 429}
+		})
+
+		It("retries with the same delivery ID", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(received).Should(HaveLen(3))
+			id := received[0].Header.Get(goa.WebhookDeliveryHeader)
+			Ω(received[1].Header.Get(goa.WebhookDeliveryHeader)).Should(Equal(id))
+			Ω(received[2].Header.Get(goa.WebhookDeliveryHeader)).Should(Equal(id))
+		})
+	})
+
+	Context("with a receiver failing permanently", func() {
+		BeforeEach(func() {
+			statuses = []int{500, 500, 500}
+		})
+
+		It("gives up after MaxAttempts", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(received).Should(HaveLen(3))
+			serr, ok := err.(goa.ServiceError)
+			Ω(ok).Should(BeTrue())
+			Ω(serr.ResponseStatus()).Should(Equal(502))
+		})
+	})
+
+	Context("with a receiver rejecting the delivery", func() {
+		BeforeEach(func() {
+			statuses = []int{410}
+		})
+
+		It("does not retry", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(received).Should(HaveLen(1))
+		})
+	})
+})